@@ -85,7 +85,15 @@ type Action struct {
 // are successfully executed, or none of them are. For that, it's fundamental
 // that all actions are really small and atomic.
 type Pipeline struct {
-	actions []*Action
+	actions  []*Action
+	observer Observer
+}
+
+// Observer is notified about the progress of a pipeline, before and after
+// the forward of each action.
+type Observer interface {
+	ActionStarted(name string, index, total int)
+	ActionFinished(name string, err error)
 }
 
 var (
@@ -113,6 +121,11 @@ func NewPipeline(actions ...*Action) *Pipeline {
 
 }
 
+// SetObserver sets the observer notified about the progress of the pipeline.
+func (p *Pipeline) SetObserver(o Observer) {
+	p.observer = o
+}
+
 // Result returns the result of the last action.
 func (p *Pipeline) Result() Result {
 	action := p.actions[len(p.actions)-1]
@@ -155,6 +168,9 @@ func (p *Pipeline) Execute(params ...interface{}) (err error) {
 	}()
 	for i, a = range p.actions {
 		log.Debugf("[pipeline] running the Forward for the %s action", a.Name)
+		if p.observer != nil {
+			p.observer.ActionStarted(a.Name, i, len(p.actions))
+		}
 		if a.Forward == nil {
 			err = ErrPipelineForwardMissing
 		} else if len(fwCtx.Params) < a.MinParams {
@@ -166,6 +182,9 @@ func (p *Pipeline) Execute(params ...interface{}) (err error) {
 			a.rMutex.Unlock()
 			fwCtx.Previous = r
 		}
+		if p.observer != nil {
+			p.observer.ActionFinished(a.Name, err)
+		}
 		if err != nil {
 			log.Errorf("[pipeline] error running the Forward for the %s action - %s", a.Name, err)
 			if a.OnError != nil {
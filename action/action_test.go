@@ -6,6 +6,7 @@ package action
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"gopkg.in/check.v1"
@@ -198,3 +199,29 @@ func (s *S) TestActionOnError(c *check.C) {
 	c.Assert(err, check.Equals, returnedErr)
 	c.Assert(called, check.Equals, true)
 }
+
+type recordingObserver struct {
+	calls []string
+}
+
+func (o *recordingObserver) ActionStarted(name string, index, total int) {
+	o.calls = append(o.calls, fmt.Sprintf("start %s %d/%d", name, index, total))
+}
+
+func (o *recordingObserver) ActionFinished(name string, err error) {
+	o.calls = append(o.calls, fmt.Sprintf("finish %s %v", name, err))
+}
+
+func (s *S) TestExecuteNotifiesObserver(c *check.C) {
+	var observer recordingObserver
+	pipeline := NewPipeline(&helloAction, &errorAction)
+	pipeline.SetObserver(&observer)
+	err := pipeline.Execute("world")
+	c.Assert(err, check.NotNil)
+	c.Assert(observer.calls, check.DeepEquals, []string{
+		"start hello 0/2",
+		"finish hello <nil>",
+		"start error 1/2",
+		"finish error Failed to execute.",
+	})
+}
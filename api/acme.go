@@ -0,0 +1,160 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+)
+
+// title: list app acme certificates
+// path: /apps/{app}/certificate/acme
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   204: No content
+//   401: Unauthorized
+//   404: App not found
+func listACMECertificates(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	a, err := getAppFromContext(r.URL.Query().Get(":app"), r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppReadCertificate,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	certs, err := app.ListACMECertificates(a.Name)
+	if err != nil {
+		return err
+	}
+	if len(certs) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(certs)
+}
+
+// title: enable app acme certificate
+// path: /apps/{app}/certificate/acme
+// method: POST
+// consume: application/x-www-form-urlencoded
+// responses:
+//   201: Certificate enabled
+//   400: Invalid data
+//   401: Unauthorized
+//   404: App not found
+//   409: Certificate already enabled
+func enableACMECertificate(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	a, err := getAppFromContext(r.URL.Query().Get(":app"), r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdateCertificateSet,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	cname := r.FormValue("cname")
+	if cname == "" {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: "You must provide a cname."}
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(a.Name),
+		Kind:       permission.PermAppUpdateCertificateSet,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	err = app.EnableACMECertificate(&a, cname)
+	if err != nil {
+		if _, ok := err.(*errors.ValidationError); ok || err == app.ErrACMEDisabled {
+			return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+		}
+		if err == app.ErrACMECertificateAlreadyExists {
+			return &errors.HTTP{Code: http.StatusConflict, Message: err.Error()}
+		}
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// title: disable app acme certificate
+// path: /apps/{app}/certificate/acme
+// method: DELETE
+// responses:
+//   200: Certificate disabled
+//   400: Invalid data
+//   401: Unauthorized
+//   404: App or certificate not found
+func disableACMECertificate(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	a, err := getAppFromContext(r.URL.Query().Get(":app"), r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdateCertificateUnset,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	cname := r.FormValue("cname")
+	if cname == "" {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: "You must provide a cname."}
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(a.Name),
+		Kind:       permission.PermAppUpdateCertificateUnset,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	err = app.DisableACMECertificate(a.Name, cname)
+	if err == app.ErrACMECertificateNotFound {
+		return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	return err
+}
+
+// title: acme challenge
+// path: /.well-known/acme-challenge/{token}
+// method: GET
+// produce: text/plain
+// responses:
+//   200: OK
+//   404: Challenge not found
+func acmeChallenge(w http.ResponseWriter, r *http.Request) {
+	keyAuth, err := app.GetACMEChallenge(r.URL.Query().Get(":token"))
+	if err == app.ErrACMEChallengeNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(keyAuth))
+}
@@ -0,0 +1,134 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/event/eventtest"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) createACMEApp(c *check.C) *app.App {
+	config.Set("acme:directory-url", "http://acme.example.com/directory")
+	a := app.App{
+		Name:      "myapp",
+		Platform:  "zend",
+		TeamOwner: s.team.Name,
+		Routers:   []appTypes.AppRouter{{Name: "fake-tls"}},
+		CName:     []string{"myapp.example.com"},
+	}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	return &a
+}
+
+func (s *S) TestEnableACMECertificate(c *check.C) {
+	a := s.createACMEApp(c)
+	defer config.Unset("acme")
+	body := strings.NewReader("cname=myapp.example.com")
+	request, err := http.NewRequest("POST", fmt.Sprintf("/apps/%s/certificate/acme", a.Name), body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusCreated)
+	certs, err := app.ListACMECertificates(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(certs, check.HasLen, 1)
+	c.Assert(certs[0].Status, check.Equals, app.ACMEStatusPending)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.certificate.set",
+		StartCustomData: []map[string]interface{}{
+			{"name": ":app", "value": a.Name},
+			{"name": "cname", "value": "myapp.example.com"},
+		},
+	}, eventtest.HasEvent)
+	request, err = http.NewRequest("POST", fmt.Sprintf("/apps/%s/certificate/acme", a.Name), strings.NewReader("cname=myapp.example.com"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusConflict)
+}
+
+func (s *S) TestEnableACMECertificateInvalidCName(c *check.C) {
+	a := s.createACMEApp(c)
+	defer config.Unset("acme")
+	body := strings.NewReader("cname=other.example.com")
+	request, err := http.NewRequest("POST", fmt.Sprintf("/apps/%s/certificate/acme", a.Name), body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "invalid name\n")
+}
+
+func (s *S) TestListACMECertificates(c *check.C) {
+	a := s.createACMEApp(c)
+	defer config.Unset("acme")
+	err := app.EnableACMECertificate(a, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("GET", fmt.Sprintf("/apps/%s/certificate/acme", a.Name), nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var certs []app.ACMECertificate
+	err = json.Unmarshal(recorder.Body.Bytes(), &certs)
+	c.Assert(err, check.IsNil)
+	c.Assert(certs, check.HasLen, 1)
+	c.Assert(certs[0].CName, check.Equals, "myapp.example.com")
+}
+
+func (s *S) TestDisableACMECertificate(c *check.C) {
+	a := s.createACMEApp(c)
+	defer config.Unset("acme")
+	err := app.EnableACMECertificate(a, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("DELETE", fmt.Sprintf("/apps/%s/certificate/acme?cname=myapp.example.com", a.Name), nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	certs, err := app.ListACMECertificates(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(certs, check.HasLen, 0)
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
+
+func (s *S) TestACMEChallenge(c *check.C) {
+	_, err := s.conn.ACMEChallenges().UpsertId("token1", map[string]string{"keyauth": "token1.thumb"})
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("GET", "/.well-known/acme-challenge/token1", nil)
+	c.Assert(err, check.IsNil)
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Body.String(), check.Equals, "token1.thumb")
+	request, err = http.NewRequest("GET", "/.well-known/acme-challenge/unknown", nil)
+	c.Assert(err, check.IsNil)
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
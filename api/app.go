@@ -895,16 +895,20 @@ func getEnv(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 }
 
 func writeEnvVars(w http.ResponseWriter, a *app.App, variables ...string) error {
+	envs, err := a.DecryptedEnvs()
+	if err != nil {
+		return err
+	}
 	var result []bind.EnvVar
 	w.Header().Set("Content-Type", "application/json")
 	if len(variables) > 0 {
 		for _, variable := range variables {
-			if v, ok := a.Env[variable]; ok {
+			if v, ok := envs[variable]; ok {
 				result = append(result, v)
 			}
 		}
 	} else {
-		for _, v := range a.Envs() {
+		for _, v := range envs {
 			result = append(result, v)
 		}
 	}
@@ -978,6 +982,7 @@ func setEnv(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
 		Envs:          variables,
 		ShouldRestart: !e.NoRestart,
 		Writer:        writer,
+		Owner:         t.GetUserName(),
 	})
 	if v, ok := err.(*errors.ValidationError); ok {
 		return &errors.HTTP{Code: http.StatusBadRequest, Message: v.Message}
@@ -1036,7 +1041,96 @@ func unsetEnv(w http.ResponseWriter, r *http.Request, t auth.Token) (err error)
 		VariableNames: variables,
 		ShouldRestart: !noRestart,
 		Writer:        writer,
+		Owner:         t.GetUserName(),
+	})
+}
+
+// title: list env revisions
+// path: /apps/{app}/env/revisions
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   204: No content
+//   401: Unauthorized
+//   404: App not found
+func listEnvRevisions(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppReadEnv,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	revisions, err := app.ListEnvRevisions(a.Name)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(revisions)
+}
+
+// title: rollback envs
+// path: /apps/{app}/env/rollback
+// method: POST
+// consume: application/x-www-form-urlencoded
+// produce: application/x-json-stream
+// responses:
+//   200: Envs rolled back
+//   400: Invalid data
+//   401: Unauthorized
+//   404: App or revision not found
+func rollbackEnv(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	revision, err := strconv.Atoi(r.FormValue("revision"))
+	if err != nil || revision <= 0 {
+		msg := "You must provide a valid revision"
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: msg}
+	}
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdateEnvRollback,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(appName),
+		Kind:       permission.PermAppUpdateEnvRollback,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	w.Header().Set("Content-Type", "application/x-json-stream")
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	noRestart, _ := strconv.ParseBool(r.FormValue("noRestart"))
+	err = a.RollbackEnvs(app.EnvRollbackArgs{
+		Revision:      revision,
+		ShouldRestart: !noRestart,
+		Writer:        writer,
+		Owner:         t.GetUserName(),
 	})
+	if err == app.ErrEnvRevisionNotFound {
+		return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	return err
 }
 
 // title: set cname
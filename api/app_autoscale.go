@@ -0,0 +1,163 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+)
+
+// title: list app autoscales
+// path: /apps/{app}/autoscale
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   204: No content
+//   401: Unauthorized
+//   404: App not found
+func listAppAutoScales(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppRead,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	autoScales, err := a.AutoScales()
+	if err != nil {
+		return err
+	}
+	if len(autoScales) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(autoScales)
+}
+
+func autoScaleFromForm(r *http.Request) (app.AutoScale, error) {
+	as := app.AutoScale{
+		Process: r.FormValue("process"),
+		Metric:  r.FormValue("metric"),
+	}
+	uintFields := map[string]*uint{"minUnits": &as.MinUnits, "maxUnits": &as.MaxUnits}
+	for name, field := range uintFields {
+		v, err := strconv.ParseUint(r.FormValue(name), 10, 32)
+		if err != nil {
+			return as, &errors.HTTP{Code: http.StatusBadRequest, Message: "invalid value for " + name}
+		}
+		*field = uint(v)
+	}
+	target, err := strconv.ParseFloat(r.FormValue("target"), 64)
+	if err != nil {
+		return as, &errors.HTTP{Code: http.StatusBadRequest, Message: "invalid value for target"}
+	}
+	as.Target = target
+	intFields := map[string]*int{"scaleUpCooldown": &as.ScaleUpCooldown, "scaleDownCooldown": &as.ScaleDownCooldown}
+	for name, field := range intFields {
+		v := r.FormValue(name)
+		if v == "" {
+			continue
+		}
+		*field, err = strconv.Atoi(v)
+		if err != nil {
+			return as, &errors.HTTP{Code: http.StatusBadRequest, Message: "invalid value for " + name}
+		}
+	}
+	return as, nil
+}
+
+// title: set app autoscale
+// path: /apps/{app}/autoscale
+// method: POST
+// consume: application/x-www-form-urlencoded
+// responses:
+//   200: Autoscale set
+//   400: Invalid data
+//   401: Unauthorized
+//   404: App not found
+func setAppAutoScale(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	as, err := autoScaleFromForm(r)
+	if err != nil {
+		return err
+	}
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdateUnitAutoscaleSet,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(appName),
+		Kind:       permission.PermAppUpdateUnitAutoscaleSet,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	err = a.SetAutoScale(as)
+	if _, ok := err.(*errors.ValidationError); ok {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	return err
+}
+
+// title: remove app autoscale
+// path: /apps/{app}/autoscale
+// method: DELETE
+// responses:
+//   200: Autoscale removed
+//   401: Unauthorized
+//   404: App or autoscale not found
+func removeAppAutoScale(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdateUnitAutoscaleRemove,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	process := r.URL.Query().Get("process")
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(appName),
+		Kind:       permission.PermAppUpdateUnitAutoscaleRemove,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.URL.Query()),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	err = a.RemoveAutoScale(process)
+	if err == app.ErrAutoScaleNotFound {
+		return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/event/eventtest"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) newAutoScaleApp(c *check.C) *app.App {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	imgName := "tsuru/app-swift:v1"
+	err = image.AppendAppImageName(a.Name, imgName)
+	c.Assert(err, check.IsNil)
+	err = image.SaveImageCustomData(imgName, map[string]interface{}{
+		"processes": map[string][]string{"web": {"./web"}, "worker": {"./worker"}},
+	})
+	c.Assert(err, check.IsNil)
+	return &a
+}
+
+func (s *S) TestListAppAutoScales(c *check.C) {
+	a := s.newAutoScaleApp(c)
+	err := a.SetAutoScale(app.AutoScale{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: app.AutoScaleMetricCPU, Target: 70})
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("GET", "/apps/swift/autoscale", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var autoScales []app.AutoScale
+	err = json.NewDecoder(recorder.Body).Decode(&autoScales)
+	c.Assert(err, check.IsNil)
+	c.Assert(autoScales, check.DeepEquals, []app.AutoScale{
+		{App: a.Name, Process: "web", MinUnits: 1, MaxUnits: 5, Metric: app.AutoScaleMetricCPU, Target: 70},
+	})
+}
+
+func (s *S) TestListAppAutoScalesEmpty(c *check.C) {
+	s.newAutoScaleApp(c)
+	request, err := http.NewRequest("GET", "/apps/swift/autoscale", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNoContent)
+}
+
+func (s *S) TestSetAppAutoScale(c *check.C) {
+	a := s.newAutoScaleApp(c)
+	body := strings.NewReader("process=web&metric=cpu&minUnits=1&maxUnits=5&target=70&scaleDownCooldown=600")
+	request, err := http.NewRequest("POST", "/apps/swift/autoscale", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	as, err := a.GetAutoScale("web")
+	c.Assert(err, check.IsNil)
+	c.Assert(*as, check.DeepEquals, app.AutoScale{
+		App:               a.Name,
+		Process:           "web",
+		MinUnits:          1,
+		MaxUnits:          5,
+		Metric:            app.AutoScaleMetricCPU,
+		Target:            70,
+		ScaleDownCooldown: 600,
+	})
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.unit.autoscale.set",
+		StartCustomData: []map[string]interface{}{
+			{"name": ":app", "value": a.Name},
+			{"name": "process", "value": "web"},
+			{"name": "metric", "value": "cpu"},
+			{"name": "minUnits", "value": "1"},
+			{"name": "maxUnits", "value": "5"},
+			{"name": "target", "value": "70"},
+			{"name": "scaleDownCooldown", "value": "600"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestSetAppAutoScaleInvalid(c *check.C) {
+	s.newAutoScaleApp(c)
+	tests := []struct {
+		body, message string
+	}{
+		{body: "process=web&metric=cpu&minUnits=x&maxUnits=5&target=70", message: "invalid value for minUnits\n"},
+		{body: "process=web&metric=cpu&minUnits=1&maxUnits=5&target=x", message: "invalid value for target\n"},
+		{body: "process=web&metric=cpu&minUnits=1&maxUnits=5&target=70&scaleUpCooldown=x", message: "invalid value for scaleUpCooldown\n"},
+		{body: "process=web&metric=cpu&minUnits=5&maxUnits=1&target=70", message: "maximum units must be greater than or equal to minimum units\n"},
+		{body: "process=web&metric=rps&minUnits=1&maxUnits=5&target=70", message: "metric \"rps\" is not available, no router of the app is able to report requests per second\n"},
+	}
+	for _, tt := range tests {
+		request, err := http.NewRequest("POST", "/apps/swift/autoscale", strings.NewReader(tt.body))
+		c.Assert(err, check.IsNil)
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("Authorization", "b "+s.token.GetValue())
+		recorder := httptest.NewRecorder()
+		s.testServer.ServeHTTP(recorder, request)
+		c.Check(recorder.Code, check.Equals, http.StatusBadRequest, check.Commentf(tt.body))
+		c.Check(recorder.Body.String(), check.Equals, tt.message, check.Commentf(tt.body))
+	}
+}
+
+func (s *S) TestRemoveAppAutoScale(c *check.C) {
+	a := s.newAutoScaleApp(c)
+	err := a.SetAutoScale(app.AutoScale{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: app.AutoScaleMetricCPU, Target: 70})
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("DELETE", "/apps/swift/autoscale?process=web", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	_, err = a.GetAutoScale("web")
+	c.Assert(err, check.Equals, app.ErrAutoScaleNotFound)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.unit.autoscale.remove",
+		StartCustomData: []map[string]interface{}{
+			{"name": "process", "value": "web"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestRemoveAppAutoScaleNotFound(c *check.C) {
+	s.newAutoScaleApp(c)
+	request, err := http.NewRequest("DELETE", "/apps/swift/autoscale?process=web", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+	c.Assert(recorder.Body.String(), check.Equals, app.ErrAutoScaleNotFound.Error()+"\n")
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/builder"
+	"github.com/tsuru/tsuru/event/eventtest"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestAppBuildCacheInfo(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("GET", "/apps/swift/build-cache", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var cache image.BuildCache
+	err = json.NewDecoder(recorder.Body).Decode(&cache)
+	c.Assert(err, check.IsNil)
+	c.Assert(cache, check.DeepEquals, image.BuildCache{AppName: "swift", Path: "/home/application/cache"})
+}
+
+func (s *S) TestSetAppBuildCache(c *check.C) {
+	builder.Register("fake", &builder.MockBuilder{})
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("POST", "/apps/swift/build-cache", strings.NewReader("enabled=true"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	cache, err := a.BuildCache()
+	c.Assert(err, check.IsNil)
+	c.Assert(cache.Enabled, check.Equals, true)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.build-cache.set",
+		StartCustomData: []map[string]interface{}{
+			{"name": ":app", "value": a.Name},
+			{"name": "enabled", "value": "true"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestSetAppBuildCacheNotSupportedByBuilder(c *check.C) {
+	builder.Register("fake", &builder.MockBuilder{NoBuildCache: true})
+	defer builder.Register("fake", &builder.MockBuilder{})
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("POST", "/apps/swift/build-cache", strings.NewReader("enabled=true"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "build cache is not supported by the builder \"fake\" of the app\n")
+	cache, err := a.BuildCache()
+	c.Assert(err, check.IsNil)
+	c.Assert(cache.Enabled, check.Equals, false)
+}
+
+func (s *S) TestSetAppBuildCacheInvalid(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("POST", "/apps/swift/build-cache", strings.NewReader("enabled=maybe"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "invalid value for enabled\n")
+}
+
+func (s *S) TestPurgeAppBuildCache(c *check.C) {
+	builder.Register("fake", &builder.MockBuilder{})
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetBuildCache(true)
+	c.Assert(err, check.IsNil)
+	err = image.UpdateAppBuildCacheImage(a.Name, "tsuru/app-swift:build-cache")
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("DELETE", "/apps/swift/build-cache", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	cache, err := a.BuildCache()
+	c.Assert(err, check.IsNil)
+	c.Assert(cache.Enabled, check.Equals, true)
+	c.Assert(cache.Image, check.Equals, "")
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.build-cache.purge",
+		StartCustomData: []map[string]interface{}{
+			{"name": ":app", "value": a.Name},
+		},
+	}, eventtest.HasEvent)
+}
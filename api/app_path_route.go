@@ -0,0 +1,166 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+)
+
+// title: list app path routes
+// path: /apps/{app}/paths
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   204: No content
+//   401: Unauthorized
+//   404: App not found
+func listPathRoutes(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	a, err := getAppFromContext(r.URL.Query().Get(":app"), r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppReadRouter,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	routes, err := a.PathRoutes()
+	if err != nil {
+		return err
+	}
+	if len(routes) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(routes)
+}
+
+// title: add app path route
+// path: /apps/{app}/paths
+// method: POST
+// consume: application/x-www-form-urlencoded
+// responses:
+//   201: Path route added
+//   400: Invalid data
+//   401: Unauthorized
+//   404: App not found
+//   409: Path already claimed or host locked
+func addPathRoute(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	host := r.FormValue("host")
+	path := r.FormValue("path")
+	if host == "" || path == "" {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: "You must provide the host and the path."}
+	}
+	a, err := getAppFromContext(r.URL.Query().Get(":app"), r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdatePathAdd,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	owner, err := app.PathRouteHostOwner(host)
+	if err != nil {
+		if _, ok := err.(*errors.ValidationError); ok {
+			return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+		}
+		return err
+	}
+	// Mounting an app under a cname of another app requires permission in
+	// the app owning the cname as well.
+	if owner != "" && owner != a.Name {
+		var ownerApp *app.App
+		ownerApp, err = getApp(owner)
+		if err != nil {
+			return err
+		}
+		allowed = permission.Check(t, permission.PermAppUpdatePathAdd,
+			contextsForApp(ownerApp)...,
+		)
+		if !allowed {
+			return permission.ErrUnauthorized
+		}
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(a.Name),
+		Kind:       permission.PermAppUpdatePathAdd,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	err = a.AddPathRoute(host, path)
+	if err != nil {
+		if _, ok := err.(*errors.ValidationError); ok || err == app.ErrNoPathRouter {
+			return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+		}
+		if err == app.ErrPathRouteAlreadyClaimed || err == app.ErrPathRouteHostLocked {
+			return &errors.HTTP{Code: http.StatusConflict, Message: err.Error()}
+		}
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// title: remove app path route
+// path: /apps/{app}/paths
+// method: DELETE
+// responses:
+//   200: Path route removed
+//   400: Invalid data
+//   401: Unauthorized
+//   404: App or path route not found
+func removePathRoute(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	host := r.FormValue("host")
+	path := r.FormValue("path")
+	if host == "" || path == "" {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: "You must provide the host and the path."}
+	}
+	a, err := getAppFromContext(r.URL.Query().Get(":app"), r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdatePathRemove,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(a.Name),
+		Kind:       permission.PermAppUpdatePathRemove,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	err = a.RemovePathRoute(host, path)
+	if err == app.ErrPathRouteNotFound {
+		return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	if err == app.ErrPathRouteHostLocked {
+		return &errors.HTTP{Code: http.StatusConflict, Message: err.Error()}
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/router/routertest"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	authTypes "github.com/tsuru/tsuru/types/auth"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) createPathRouteApp(c *check.C, name, teamOwner string, cnames ...string) *app.App {
+	a := app.App{
+		Name:      name,
+		Platform:  "zend",
+		TeamOwner: teamOwner,
+		Routers:   []appTypes.AppRouter{{Name: "fake-path"}},
+		CName:     cnames,
+	}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	return &a
+}
+
+func (s *S) addPathRouteRequest(appName, body, token string) *httptest.ResponseRecorder {
+	request, _ := http.NewRequest("POST", "/1.7/apps/"+appName+"/paths", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+token)
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func (s *S) TestAddPathRoute(c *check.C) {
+	config.Set("routers:fake-path:type", "fake-path")
+	defer config.Unset("routers:fake-path")
+	defer routertest.PathRouter.Reset()
+	orders := s.createPathRouteApp(c, "orders", s.team.Name, "api.example.com")
+	users := s.createPathRouteApp(c, "users", s.team.Name)
+	recorder := s.addPathRouteRequest(users.Name, "host=api.example.com&path=/users", s.token.GetValue())
+	c.Assert(recorder.Code, check.Equals, http.StatusCreated, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(routertest.PathRouter.Paths, check.HasLen, 1)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(users.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.path.add",
+		StartCustomData: []map[string]interface{}{
+			{"name": "host", "value": "api.example.com"},
+			{"name": "path", "value": "/users"},
+		},
+	}, eventtest.HasEvent)
+	recorder = s.addPathRouteRequest(orders.Name, "host=api.example.com&path=/users/admin", s.token.GetValue())
+	c.Assert(recorder.Code, check.Equals, http.StatusConflict)
+	recorder = s.addPathRouteRequest(orders.Name, "host=api.example.com&path=users", s.token.GetValue())
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	recorder = s.addPathRouteRequest(orders.Name, "host=unknown.example.com&path=/orders", s.token.GetValue())
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	recorder = s.addPathRouteRequest(orders.Name, "host=api.example.com", s.token.GetValue())
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+}
+
+func (s *S) TestAddPathRouteOtherAppCNameUnauthorized(c *check.C) {
+	config.Set("routers:fake-path:type", "fake-path")
+	defer config.Unset("routers:fake-path")
+	defer routertest.PathRouter.Reset()
+	s.mockService.Team.OnFindByName = func(name string) (*authTypes.Team, error) {
+		return &authTypes.Team{Name: name}, nil
+	}
+	s.createPathRouteApp(c, "orders", "otherteam", "api.example.com")
+	users := s.createPathRouteApp(c, "users", s.team.Name)
+	token := userWithPermission(c, permission.Permission{
+		Scheme:  permission.PermAppUpdatePathAdd,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	})
+	recorder := s.addPathRouteRequest(users.Name, "host=api.example.com&path=/users", token.GetValue())
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+	c.Assert(routertest.PathRouter.Paths, check.HasLen, 0)
+}
+
+func (s *S) TestListPathRoutes(c *check.C) {
+	config.Set("routers:fake-path:type", "fake-path")
+	defer config.Unset("routers:fake-path")
+	defer routertest.PathRouter.Reset()
+	a := s.createPathRouteApp(c, "orders", s.team.Name, "api.example.com")
+	request, err := http.NewRequest("GET", "/1.7/apps/orders/paths", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNoContent)
+	err = a.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	var routes []app.PathRoute
+	err = json.Unmarshal(recorder.Body.Bytes(), &routes)
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.DeepEquals, []app.PathRoute{{App: "orders", Host: "api.example.com", Path: "/orders"}})
+}
+
+func (s *S) TestRemovePathRoute(c *check.C) {
+	config.Set("routers:fake-path:type", "fake-path")
+	defer config.Unset("routers:fake-path")
+	defer routertest.PathRouter.Reset()
+	a := s.createPathRouteApp(c, "orders", s.team.Name, "api.example.com")
+	err := a.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("DELETE", "/1.7/apps/orders/paths?host=api.example.com&path=/orders", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(routertest.PathRouter.Paths, check.HasLen, 0)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.path.remove",
+	}, eventtest.HasEvent)
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+)
+
+func postDeployFromForm(r *http.Request) (provision.TsuruYamlPostDeploy, error) {
+	var cfg provision.TsuruYamlPostDeploy
+	var err error
+	cfg.WindowSeconds, err = strconv.Atoi(r.FormValue("window"))
+	if err != nil {
+		return cfg, &errors.HTTP{Code: http.StatusBadRequest, Message: "invalid value for window"}
+	}
+	if v := r.FormValue("maxUnitFailures"); v != "" {
+		cfg.MaxUnitFailures, err = strconv.Atoi(v)
+		if err != nil {
+			return cfg, &errors.HTTP{Code: http.StatusBadRequest, Message: "invalid value for maxUnitFailures"}
+		}
+	}
+	if v := r.FormValue("maxErrorRate"); v != "" {
+		cfg.MaxErrorRate, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return cfg, &errors.HTTP{Code: http.StatusBadRequest, Message: "invalid value for maxErrorRate"}
+		}
+	}
+	return cfg, nil
+}
+
+// title: set app post-deploy window
+// path: /apps/{app}/post-deploy
+// method: POST
+// consume: application/x-www-form-urlencoded
+// responses:
+//   200: Post-deploy window set
+//   400: Invalid data
+//   401: Unauthorized
+//   404: App not found
+func setAppPostDeploy(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	cfg, err := postDeployFromForm(r)
+	if err != nil {
+		return err
+	}
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdatePostDeploySet,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(appName),
+		Kind:       permission.PermAppUpdatePostDeploySet,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	err = a.SetPostDeploy(cfg)
+	if _, ok := err.(*errors.ValidationError); ok {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	return err
+}
+
+// title: unset app post-deploy window
+// path: /apps/{app}/post-deploy
+// method: DELETE
+// responses:
+//   200: Post-deploy window unset
+//   401: Unauthorized
+//   404: App not found
+func unsetAppPostDeploy(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdatePostDeployUnset,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(appName),
+		Kind:       permission.PermAppUpdatePostDeployUnset,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.URL.Query()),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	return a.UnsetPostDeploy()
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/provision"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestSetAppPostDeploy(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader("window=300&maxUnitFailures=2")
+	request, err := http.NewRequest("POST", "/apps/swift/post-deploy", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	dbApp, err := app.GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.PostDeploy, check.DeepEquals, provision.TsuruYamlPostDeploy{
+		WindowSeconds:   300,
+		MaxUnitFailures: 2,
+	})
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.post-deploy.set",
+		StartCustomData: []map[string]interface{}{
+			{"name": ":app", "value": a.Name},
+			{"name": "window", "value": "300"},
+			{"name": "maxUnitFailures", "value": "2"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestSetAppPostDeployInvalid(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	tests := []struct {
+		body, message string
+	}{
+		{body: "window=abc", message: "invalid value for window\n"},
+		{body: "window=0", message: "post-deploy window must be greater than 0\n"},
+		{body: "window=60&maxErrorRate=200", message: "maximum error rate must be a percentage between 0 and 100\n"},
+		{body: "window=60&maxErrorRate=5.5", message: "maximum error rate is not available, no router of the app is able to report its error rate\n"},
+	}
+	for _, tt := range tests {
+		request, err := http.NewRequest("POST", "/apps/swift/post-deploy", strings.NewReader(tt.body))
+		c.Assert(err, check.IsNil)
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("Authorization", "b "+s.token.GetValue())
+		recorder := httptest.NewRecorder()
+		s.testServer.ServeHTTP(recorder, request)
+		c.Check(recorder.Code, check.Equals, http.StatusBadRequest)
+		c.Check(recorder.Body.String(), check.Equals, tt.message)
+	}
+}
+
+func (s *S) TestUnsetAppPostDeploy(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetPostDeploy(provision.TsuruYamlPostDeploy{WindowSeconds: 60})
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("DELETE", "/apps/swift/post-deploy", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	dbApp, err := app.GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.PostDeploy, check.DeepEquals, provision.TsuruYamlPostDeploy{})
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/event/eventtest"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) newProcessPlanApp(c *check.C) (*app.App, appTypes.Plan) {
+	bigPlan := appTypes.Plan{Name: "big", Memory: 536870912, CpuShare: 200}
+	defaultPlan, err := s.mockService.Plan.DefaultPlan()
+	c.Assert(err, check.IsNil)
+	s.mockService.Plan.OnList = func() ([]appTypes.Plan, error) {
+		return []appTypes.Plan{*defaultPlan, bigPlan}, nil
+	}
+	s.mockService.Plan.OnFindByName = func(name string) (*appTypes.Plan, error) {
+		if name == bigPlan.Name {
+			return &bigPlan, nil
+		}
+		return nil, appTypes.ErrPlanNotFound
+	}
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(a.Name, "tsuru/app-swift:v1")
+	c.Assert(err, check.IsNil)
+	err = image.SaveImageCustomData("tsuru/app-swift:v1", map[string]interface{}{
+		"processes": map[string][]string{"web": {"./web"}, "worker": {"./worker"}},
+	})
+	c.Assert(err, check.IsNil)
+	return &a, bigPlan
+}
+
+func (s *S) TestSetAppProcessPlan(c *check.C) {
+	a, bigPlan := s.newProcessPlanApp(c)
+	url := fmt.Sprintf("/apps/%s/processes/worker/plan", a.Name)
+	request, err := http.NewRequest("POST", url, strings.NewReader("plan=big"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/x-json-stream")
+	dbApp, err := app.GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.ProcessPlans, check.DeepEquals, map[string]appTypes.Plan{"worker": bigPlan})
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.plan.process.set",
+		StartCustomData: []map[string]interface{}{
+			{"name": ":app", "value": a.Name},
+			{"name": ":process", "value": "worker"},
+			{"name": "plan", "value": "big"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestSetAppProcessPlanInvalid(c *check.C) {
+	a, _ := s.newProcessPlanApp(c)
+	tests := []struct {
+		url, body, message string
+	}{
+		{url: "/apps/swift/processes/worker/plan", body: "", message: "you must provide the plan of the process\n"},
+		{url: "/apps/swift/processes/worker/plan", body: "plan=huge", message: appTypes.ErrPlanNotFound.Error() + "\n"},
+		{url: "/apps/swift/processes/other/plan", body: "plan=big", message: "process \"other\" not found in app\n"},
+	}
+	for _, tt := range tests {
+		request, err := http.NewRequest("POST", tt.url, strings.NewReader(tt.body))
+		c.Assert(err, check.IsNil)
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("Authorization", "b "+s.token.GetValue())
+		recorder := httptest.NewRecorder()
+		s.testServer.ServeHTTP(recorder, request)
+		c.Check(recorder.Code, check.Equals, http.StatusBadRequest)
+		c.Check(recorder.Body.String(), check.Equals, tt.message)
+	}
+	dbApp, err := app.GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.ProcessPlans, check.HasLen, 0)
+}
+
+func (s *S) TestUnsetAppProcessPlan(c *check.C) {
+	a, _ := s.newProcessPlanApp(c)
+	err := a.SetProcessPlan("worker", "big", nil)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/processes/worker/plan", a.Name)
+	request, err := http.NewRequest("DELETE", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	dbApp, err := app.GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.ProcessPlans, check.HasLen, 0)
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
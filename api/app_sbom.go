@@ -0,0 +1,56 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/permission"
+)
+
+// title: app image sbom
+// path: /apps/{app}/images/{version}/sbom
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   401: Unauthorized
+//   404: App, image or SBOM not found
+func appImageSBOM(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":app")
+	version := r.URL.Query().Get(":version")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppReadDeploy,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	imageName, err := image.GetAppImageBySuffix(appName, ":"+version)
+	if err != nil {
+		switch err.(type) {
+		case *image.ImageNotFoundErr, *image.InvalidVersionErr:
+			return &errors.HTTP{Code: http.StatusNotFound, Message: fmt.Sprintf("image version %q not found in app %q", version, appName)}
+		}
+		return err
+	}
+	sbom, err := image.GetImageSBOM(imageName)
+	if err != nil {
+		return err
+	}
+	if sbom == nil {
+		return &errors.HTTP{Code: http.StatusNotFound, Message: fmt.Sprintf("no SBOM available for image %q", imageName)}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(sbom)
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestAppImageSBOM(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(a.Name, "tsuru/app-swift:v1")
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(a.Name, "tsuru/app-swift:v11")
+	c.Assert(err, check.IsNil)
+	sbom := image.NewSBOM("tsuru/app-swift:v1", []image.SBOMComponent{
+		{Type: "library", Name: "bash", Version: "4.4", PURL: "pkg:deb/bash@4.4?arch=amd64"},
+	})
+	err = image.SaveImageProvenance("tsuru/app-swift:v1", nil, sbom)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("GET", "/apps/swift/images/v1/sbom", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var result image.SBOM
+	err = json.NewDecoder(recorder.Body).Decode(&result)
+	c.Assert(err, check.IsNil)
+	c.Assert(result.BOMFormat, check.Equals, "CycloneDX")
+	c.Assert(result.Components, check.DeepEquals, sbom.Components)
+}
+
+func (s *S) TestAppImageSBOMNotFound(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(a.Name, "tsuru/app-swift:v1")
+	c.Assert(err, check.IsNil)
+	tests := []struct {
+		version, message string
+	}{
+		{version: "v2", message: "image version \"v2\" not found in app \"swift\"\n"},
+		{version: "v1", message: "no SBOM available for image \"tsuru/app-swift:v1\"\n"},
+	}
+	for _, tt := range tests {
+		request, err := http.NewRequest("GET", "/apps/swift/images/"+tt.version+"/sbom", nil)
+		c.Assert(err, check.IsNil)
+		request.Header.Set("Authorization", "b "+s.token.GetValue())
+		recorder := httptest.NewRecorder()
+		s.testServer.ServeHTTP(recorder, request)
+		c.Check(recorder.Code, check.Equals, http.StatusNotFound)
+		c.Check(recorder.Body.String(), check.Equals, tt.message)
+	}
+}
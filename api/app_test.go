@@ -6264,3 +6264,113 @@ func (s *S) TestListCertificates(c *check.C) {
 		},
 	})
 }
+
+func (s *S) TestListEnvRevisions(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs:  []bind.EnvVar{{Name: "DATABASE_HOST", Value: "localhost", Public: true}},
+		Owner: s.user.Email,
+	})
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/env/revisions", a.Name)
+	request, err := http.NewRequest("GET", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var revisions []app.EnvRevision
+	err = json.NewDecoder(recorder.Body).Decode(&revisions)
+	c.Assert(err, check.IsNil)
+	c.Assert(revisions, check.HasLen, 2)
+	c.Assert(revisions[0].Revision, check.Equals, 2)
+	c.Assert(revisions[0].Owner, check.Equals, s.user.Email)
+	c.Assert(revisions[0].Changes, check.HasLen, 1)
+	c.Assert(revisions[0].Changes[0].Name, check.Equals, "DATABASE_HOST")
+	c.Assert(revisions[0].Changes[0].OldHash, check.Equals, "")
+	c.Assert(revisions[0].Changes[0].NewHash, check.Not(check.Equals), "")
+	c.Assert(strings.Contains(recorder.Body.String(), "localhost"), check.Equals, false)
+}
+
+func (s *S) TestListEnvRevisionsNoContent(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", Teams: []string{s.team.Name}}
+	err := s.conn.Apps().Insert(a)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/env/revisions", a.Name)
+	request, err := http.NewRequest("GET", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNoContent)
+}
+
+func (s *S) TestRollbackEnv(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{{Name: "DATABASE_HOST", Value: "localhost", Public: true}},
+	})
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{{Name: "DATABASE_HOST", Value: "remotehost", Public: true}},
+	})
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader("revision=2&noRestart=true")
+	url := fmt.Sprintf("/apps/%s/env/rollback", a.Name)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/x-json-stream")
+	c.Assert(recorder.Body.String(), check.Equals,
+		`{"Message":"---- Setting 1 new environment variables ----\n"}
+`)
+	dbApp, err := app.GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.Env["DATABASE_HOST"], check.DeepEquals, bind.EnvVar{Name: "DATABASE_HOST", Value: "localhost", Public: true})
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.env.rollback",
+		StartCustomData: []map[string]interface{}{
+			{"name": ":app", "value": a.Name},
+			{"name": "revision", "value": "2"},
+			{"name": "noRestart", "value": "true"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestRollbackEnvRevisionNotFound(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", Teams: []string{s.team.Name}}
+	err := s.conn.Apps().Insert(a)
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader("revision=10")
+	url := fmt.Sprintf("/apps/%s/env/rollback", a.Name)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+	c.Assert(recorder.Body.String(), check.Equals, app.ErrEnvRevisionNotFound.Error()+"\n")
+}
+
+func (s *S) TestRollbackEnvInvalidRevision(c *check.C) {
+	body := strings.NewReader("revision=abc")
+	request, err := http.NewRequest("POST", "/apps/swift/env/rollback", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+}
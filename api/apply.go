@@ -0,0 +1,176 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	tsuruIo "github.com/tsuru/tsuru/io"
+	"github.com/tsuru/tsuru/permission"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+	"github.com/tsuru/tsuru/volume"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var manifestChangePermissions = map[string]map[string]*permission.PermissionScheme{
+	app.ManifestKindDescription: {app.ManifestActionUpdate: permission.PermAppUpdateDescription},
+	app.ManifestKindPlan:        {app.ManifestActionUpdate: permission.PermAppUpdatePlan},
+	app.ManifestKindPool:        {app.ManifestActionUpdate: permission.PermAppUpdatePool},
+	app.ManifestKindTeamOwner:   {app.ManifestActionUpdate: permission.PermAppUpdateTeamowner},
+	app.ManifestKindTags:        {app.ManifestActionUpdate: permission.PermAppUpdateTags},
+	app.ManifestKindEnv: {
+		app.ManifestActionAdd:    permission.PermAppUpdateEnvSet,
+		app.ManifestActionUpdate: permission.PermAppUpdateEnvSet,
+		app.ManifestActionRemove: permission.PermAppUpdateEnvUnset,
+	},
+	app.ManifestKindRouter: {
+		app.ManifestActionAdd:    permission.PermAppUpdateRouterAdd,
+		app.ManifestActionUpdate: permission.PermAppUpdateRouterUpdate,
+		app.ManifestActionRemove: permission.PermAppUpdateRouterRemove,
+	},
+	app.ManifestKindCName: {
+		app.ManifestActionAdd:    permission.PermAppUpdateCnameAdd,
+		app.ManifestActionRemove: permission.PermAppUpdateCnameRemove,
+	},
+	app.ManifestKindService: {
+		app.ManifestActionAdd:    permission.PermAppUpdateBind,
+		app.ManifestActionRemove: permission.PermAppUpdateUnbind,
+	},
+	app.ManifestKindVolume: {
+		app.ManifestActionAdd:    permission.PermAppUpdateBindVolume,
+		app.ManifestActionUpdate: permission.PermAppUpdateBindVolume,
+		app.ManifestActionRemove: permission.PermAppUpdateUnbindVolume,
+	},
+	app.ManifestKindUnits: {
+		app.ManifestActionAdd:    permission.PermAppUpdateUnitAdd,
+		app.ManifestActionRemove: permission.PermAppUpdateUnitRemove,
+	},
+}
+
+func checkManifestPermissions(t auth.Token, a *app.App, changes []app.ManifestChange) error {
+	for _, change := range changes {
+		perm := manifestChangePermissions[change.Kind][change.Action]
+		if perm == nil || !permission.Check(t, perm, contextsForApp(a)...) {
+			return permission.ErrUnauthorized
+		}
+		switch change.Kind {
+		case app.ManifestKindService:
+			parts := strings.SplitN(change.Name, "/", 2)
+			instance, err := getServiceInstanceOrError(parts[0], parts[1])
+			if err != nil {
+				return err
+			}
+			instancePerm := permission.PermServiceInstanceUpdateBind
+			if change.Action == app.ManifestActionRemove {
+				instancePerm = permission.PermServiceInstanceUpdateUnbind
+			}
+			allowed := permission.Check(t, instancePerm,
+				append(permission.Contexts(permTypes.CtxTeam, instance.Teams),
+					permission.Context(permTypes.CtxServiceInstance, instance.Name),
+				)...,
+			)
+			if !allowed {
+				return permission.ErrUnauthorized
+			}
+		case app.ManifestKindVolume:
+			volumeName := strings.SplitN(change.Name, ":", 2)[0]
+			v, err := volume.Load(volumeName)
+			if err != nil {
+				if err == volume.ErrVolumeNotFound {
+					return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+				}
+				return err
+			}
+			volumePerm := permission.PermVolumeUpdateBind
+			if change.Action == app.ManifestActionRemove {
+				volumePerm = permission.PermVolumeUpdateUnbind
+			}
+			if !permission.Check(t, volumePerm, contextsForVolume(v)...) {
+				return permission.ErrUnauthorized
+			}
+		}
+	}
+	return nil
+}
+
+// title: app apply
+// path: /apps/{app}/apply
+// method: POST
+// consume: application/x-yaml
+// produce: application/x-json-stream
+// responses:
+//   200: Manifest applied
+//   400: Invalid manifest
+//   401: Unauthorized
+//   404: App not found
+func appApply(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	var manifest app.Manifest
+	err = yaml.Unmarshal(data, &manifest)
+	if err != nil {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdateApply,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	changes, err := a.DiffManifest(manifest)
+	if err != nil {
+		return err
+	}
+	err = checkManifestPermissions(t, &a, changes)
+	if err != nil {
+		return err
+	}
+	dry, _ := strconv.ParseBool(r.URL.Query().Get("dry"))
+	if dry {
+		w.Header().Set("Content-Type", "application/json")
+		return json.NewEncoder(w).Encode(changes)
+	}
+	noRestart, _ := strconv.ParseBool(r.URL.Query().Get("noRestart"))
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(appName),
+		Kind:       permission.PermAppUpdateApply,
+		Owner:      t,
+		CustomData: manifest.MaskPrivateEnvs(),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.DoneCustomData(err, changes) }()
+	w.Header().Set("Content-Type", "application/x-json-stream")
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	evt.SetLogWriter(writer)
+	_, err = a.Apply(app.ApplyArgs{
+		Manifest:  manifest,
+		NoRestart: noRestart,
+		Writer:    evt,
+		Event:     evt,
+		RequestID: requestIDHeader(r),
+	})
+	return err
+}
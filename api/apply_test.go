@@ -0,0 +1,115 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/permission"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestAppApplyDryRun(c *check.C) {
+	a := app.App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader(`
+description: new desc
+cnames:
+  - myapp.example.com
+envs:
+  - name: PASSWORD
+    value: secret
+    private: true
+`)
+	request, err := http.NewRequest("POST", "/1.7/apps/myapp/apply?dry=true", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-yaml")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var changes []app.ManifestChange
+	err = json.Unmarshal(recorder.Body.Bytes(), &changes)
+	c.Assert(err, check.IsNil)
+	c.Assert(changes, check.DeepEquals, []app.ManifestChange{
+		{Kind: "description", Action: "update", New: "new desc"},
+		{Kind: "env", Action: "add", Name: "PASSWORD", New: "*****"},
+		{Kind: "cname", Action: "add", Name: "myapp.example.com"},
+	})
+	dbApp, err := app.GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.Description, check.Equals, "")
+	c.Assert(dbApp.CName, check.HasLen, 0)
+}
+
+func (s *S) TestAppApply(c *check.C) {
+	a := app.App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader(`{"description": "new desc", "cnames": ["myapp.example.com"]}`)
+	request, err := http.NewRequest("POST", "/1.7/apps/myapp/apply", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/x-json-stream")
+	dbApp, err := app.GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.Description, check.Equals, "new desc")
+	c.Assert(dbApp.CName, check.DeepEquals, []string{"myapp.example.com"})
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.apply",
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestAppApplyInvalidManifest(c *check.C) {
+	a := app.App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader(`cnames: {invalid`)
+	request, err := http.NewRequest("POST", "/1.7/apps/myapp/apply", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-yaml")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+}
+
+func (s *S) TestAppApplyWithoutChangePermission(c *check.C) {
+	a := app.App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	token := userWithPermission(c, permission.Permission{
+		Scheme:  permission.PermAppUpdateApply,
+		Context: permission.Context(permTypes.CtxApp, a.Name),
+	}, permission.Permission{
+		Scheme:  permission.PermAppUpdateDescription,
+		Context: permission.Context(permTypes.CtxApp, a.Name),
+	})
+	body := strings.NewReader(`{"description": "new desc", "cnames": ["myapp.example.com"]}`)
+	request, err := http.NewRequest("POST", "/1.7/apps/myapp/apply", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+	dbApp, err := app.GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.Description, check.Equals, "")
+}
@@ -67,6 +67,9 @@ func build(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
 	opts.GetKind()
 	if t.GetAppName() != app.InternalAppName {
 		canBuild := permission.Check(t, permission.PermAppBuild, contextsForApp(instance)...)
+		if canBuild && opts.Dockerfile {
+			canBuild = permission.Check(t, permission.PermAppDeployDockerfile, contextsForApp(instance)...)
+		}
 		if !canBuild {
 			return &tsuruErrors.HTTP{Code: http.StatusForbidden, Message: "User does not have permission to do this action in this app"}
 		}
@@ -133,10 +136,27 @@ func prepareToBuild(r *http.Request) (opts app.DeployOptions, err error) {
 			}
 		}
 	}
+	var dockerfile bool
+	if dockerfileString := r.FormValue("dockerfile"); dockerfileString != "" {
+		dockerfile, err = strconv.ParseBool(dockerfileString)
+		if err != nil {
+			return opts, &tsuruErrors.HTTP{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			}
+		}
+	}
+	if dockerfile && file == nil {
+		return opts, &tsuruErrors.HTTP{
+			Code:    http.StatusBadRequest,
+			Message: "you must upload the build context to deploy from a Dockerfile.",
+		}
+	}
 	opts.FileSize = fileSize
 	opts.File = file
 	opts.ArchiveURL = archiveURL
 	opts.Image = image
 	opts.Build = build
+	opts.Dockerfile = dockerfile
 	return
 }
@@ -255,3 +255,55 @@ func (s *BuildSuite) TestBuildWithoutTag(c *check.C) {
 	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
 	c.Assert(recorder.Body.String(), check.Equals, "you must specify the image tag.\n")
 }
+
+func (s *BuildSuite) newDockerfileBuildRequest(c *check.C, appName string, token auth.Token) *http.Request {
+	url := fmt.Sprintf("/apps/%s/build?tag=mytag&dockerfile=true", appName)
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	file, err := writer.CreateFormFile("file", "context.tar.gz")
+	c.Assert(err, check.IsNil)
+	file.Write([]byte("FROM tsuru/python"))
+	writer.Close()
+	request, err := http.NewRequest(http.MethodPost, url, &body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "multipart/form-data; boundary="+writer.Boundary())
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	return request
+}
+
+func (s *BuildSuite) TestBuildDockerfile(c *check.C) {
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		c.Assert(opts.BuildFromFile, check.Equals, true)
+		return "tsuruteam/app-otherapp:mytag", nil
+	}
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	token := userWithPermission(c, permission.Permission{
+		Scheme:  permission.PermAppBuild,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	}, permission.Permission{
+		Scheme:  permission.PermAppDeployDockerfile,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	})
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, s.newDockerfileBuildRequest(c, a.Name, token))
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Body.String(), check.Equals, "tsuruteam/app-otherapp:mytag\nOK\n")
+}
+
+func (s *BuildSuite) TestBuildDockerfileWithoutPermission(c *check.C) {
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	token := userWithPermission(c, permission.Permission{
+		Scheme:  permission.PermAppBuild,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	})
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, s.newDockerfileBuildRequest(c, a.Name, token))
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+	c.Assert(recorder.Body.String(), check.Equals, "User does not have permission to do this action in this app\n")
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/permission"
+)
+
+// title: certificate inventory
+// path: /certificates
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   204: No content
+//   400: Invalid data
+//   401: Unauthorized
+func certificateInventory(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	filter := &app.Filter{}
+	if name := r.URL.Query().Get("app"); name != "" {
+		filter.Name = name
+	}
+	if pool := r.URL.Query().Get("pool"); pool != "" {
+		filter.Pool = pool
+	}
+	var expiringWithin time.Duration
+	if expiring := r.URL.Query().Get("expiring"); expiring != "" {
+		var err error
+		expiringWithin, err = time.ParseDuration(expiring)
+		if err != nil {
+			return &errors.HTTP{Code: http.StatusBadRequest, Message: "invalid expiring duration: " + expiring}
+		}
+	}
+	contexts := permission.ContextsForPermission(t, permission.PermAppReadCertificate)
+	if len(contexts) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	certs, err := app.CertificateInventory(appFilterByContext(contexts, filter))
+	if err != nil {
+		return err
+	}
+	if expiringWithin > 0 {
+		limit := time.Now().Add(expiringWithin)
+		var expiringCerts []app.CertificateInfo
+		for _, cert := range certs {
+			if cert.Error == "" && cert.NotAfter.Before(limit) {
+				expiringCerts = append(expiringCerts, cert)
+			}
+		}
+		certs = expiringCerts
+	}
+	if len(certs) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(certs)
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/permission"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestCertificateInventory(c *check.C) {
+	a := app.App{Name: "myapp", TeamOwner: s.team.Name, CName: []string{"app.io"}, Router: "fake-tls"}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetCertificate("app.io", testCert, testKey)
+	c.Assert(err, check.IsNil)
+	defer a.RemoveCertificate("app.io")
+	request, err := http.NewRequest("GET", "/certificates", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var certs []app.CertificateInfo
+	err = json.Unmarshal(recorder.Body.Bytes(), &certs)
+	c.Assert(err, check.IsNil)
+	c.Assert(certs, check.HasLen, 1)
+	c.Assert(certs[0].App, check.Equals, "myapp")
+	c.Assert(certs[0].Router, check.Equals, "fake-tls")
+	c.Assert(certs[0].CName, check.Equals, "app.io")
+	c.Assert(certs[0].NotAfter.IsZero(), check.Equals, false)
+	c.Assert(certs[0].Error, check.Equals, "")
+}
+
+func (s *S) TestCertificateInventoryExpiring(c *check.C) {
+	a := app.App{Name: "myapp", TeamOwner: s.team.Name, CName: []string{"app.io"}, Router: "fake-tls"}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetCertificate("app.io", testCert, testKey)
+	c.Assert(err, check.IsNil)
+	defer a.RemoveCertificate("app.io")
+	infos, err := a.CertificatesInfo()
+	c.Assert(err, check.IsNil)
+	c.Assert(infos, check.HasLen, 1)
+	untilExpiration := time.Until(infos[0].NotAfter)
+	request, err := http.NewRequest("GET", "/certificates?expiring="+(untilExpiration-time.Hour).String(), nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNoContent)
+	request, err = http.NewRequest("GET", "/certificates?expiring="+(untilExpiration+time.Hour).String(), nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	request, err = http.NewRequest("GET", "/certificates?expiring=invalid", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+}
+
+func (s *S) TestCertificateInventoryFilteredByPermission(c *check.C) {
+	a := app.App{Name: "myapp", TeamOwner: s.team.Name, CName: []string{"app.io"}, Router: "fake-tls"}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetCertificate("app.io", testCert, testKey)
+	c.Assert(err, check.IsNil)
+	defer a.RemoveCertificate("app.io")
+	token := userWithPermission(c, permission.Permission{
+		Scheme:  permission.PermAppReadCertificate,
+		Context: permission.Context(permTypes.CtxApp, "otherapp"),
+	})
+	request, err := http.NewRequest("GET", "/certificates", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNoContent)
+}
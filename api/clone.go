@@ -0,0 +1,202 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	tsuruIo "github.com/tsuru/tsuru/io"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/service"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+	"github.com/tsuru/tsuru/volume"
+)
+
+// checkClonePermissions checks whether the user is able to read the
+// configuration of the source app and to recreate it in the new app.
+func checkClonePermissions(t auth.Token, source, newApp *app.App, newInstances bool) error {
+	if !permission.Check(t, permission.PermAppCreate, permission.Context(permTypes.CtxTeam, newApp.TeamOwner)) ||
+		!permission.Check(t, permission.PermAppReadEnv, contextsForApp(source)...) {
+		return permission.ErrUnauthorized
+	}
+	instances, err := service.GetServiceInstancesBoundToApp(source.Name)
+	if err != nil {
+		return err
+	}
+	for _, si := range instances {
+		allowed := permission.Check(t, permission.PermServiceInstanceUpdateBind,
+			append(permission.Contexts(permTypes.CtxTeam, si.Teams),
+				permission.Context(permTypes.CtxServiceInstance, si.Name),
+			)...,
+		)
+		if newInstances {
+			allowed = permission.Check(t, permission.PermServiceInstanceCreate,
+				permission.Context(permTypes.CtxTeam, newApp.TeamOwner),
+			)
+		}
+		if !allowed {
+			return permission.ErrUnauthorized
+		}
+	}
+	volumes, err := volume.ListByApp(source.Name)
+	if err != nil {
+		return err
+	}
+	for i := range volumes {
+		if !permission.Check(t, permission.PermVolumeUpdateBind, contextsForVolume(&volumes[i])...) {
+			return permission.ErrUnauthorized
+		}
+	}
+	return nil
+}
+
+// title: app clone
+// path: /apps/{app}/clone
+// method: POST
+// consume: application/x-www-form-urlencoded
+// produce: application/x-json-stream
+// responses:
+//   200: App cloned
+//   400: Invalid data
+//   401: Unauthorized
+//   404: App not found
+//   409: App already exists
+func appClone(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	err = r.ParseForm()
+	if err != nil {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	sourceName := r.URL.Query().Get(":app")
+	source, err := getAppFromContext(sourceName, r)
+	if err != nil {
+		return err
+	}
+	name := r.FormValue("name")
+	if name == "" {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: "you must provide the name of the new app"}
+	}
+	_, err = app.GetByName(name)
+	if err == nil {
+		return &errors.HTTP{Code: http.StatusConflict, Message: app.ErrAppAlreadyExists.Error()}
+	}
+	if err != appTypes.ErrAppNotFound {
+		return err
+	}
+	newInstances, _ := strconv.ParseBool(r.FormValue("newServiceInstances"))
+	args := app.CloneArgs{
+		Name:                name,
+		Pool:                r.FormValue("pool"),
+		Plan:                r.FormValue("plan"),
+		TeamOwner:           r.FormValue("teamOwner"),
+		NewServiceInstances: newInstances,
+		RequestID:           requestIDHeader(r),
+	}
+	if args.TeamOwner == "" {
+		args.TeamOwner = source.TeamOwner
+	}
+	if args.Pool == "" {
+		args.Pool = source.Pool
+	}
+	newApp := app.App{Name: name, TeamOwner: args.TeamOwner, Teams: []string{args.TeamOwner}, Pool: args.Pool}
+	err = checkClonePermissions(t, &source, &newApp, newInstances)
+	if err != nil {
+		return err
+	}
+	args.User, err = auth.ConvertNewUser(t.User())
+	if err != nil {
+		return err
+	}
+	evt, err := event.New(&event.Opts{
+		Target:       appTarget(name),
+		ExtraTargets: []event.ExtraTarget{{Target: appTarget(source.Name)}},
+		Kind:         permission.PermAppCreate,
+		Owner:        t,
+		CustomData:   event.FormToCustomData(r.Form),
+		Allowed:      event.Allowed(permission.PermAppReadEvents, contextsForApp(&newApp)...),
+	})
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/x-json-stream")
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	evt.SetLogWriter(writer)
+	args.Writer = evt
+	args.Event = evt
+	clone, err := app.Clone(&source, args)
+	// The create event locks the new app, so it must be done before the
+	// clone is deployed.
+	evt.Done(err)
+	if err != nil {
+		return err
+	}
+	err = deployClone(t, &source, clone, writer)
+	if err != nil {
+		writer.Encode(tsuruIo.SimpleJsonMessage{Error: err.Error()})
+	}
+	return nil
+}
+
+// deployClone deploys the current image of the source app to its clone, if
+// there is any.
+func deployClone(t auth.Token, source, clone *app.App, w io.Writer) (err error) {
+	if _, err = image.AppCurrentImageName(source.Name); err != nil {
+		fmt.Fprintf(w, "---- App %q has no image to be deployed ----\n", source.Name)
+		return nil
+	}
+	opts := app.DeployOptions{
+		App:          clone,
+		SourceApp:    source.Name,
+		User:         t.GetUserName(),
+		OutputStream: w,
+	}
+	opts.GetKind()
+	if !permission.Check(t, permSchemeForDeploy(opts), contextsForApp(clone)...) {
+		fmt.Fprintf(w, "---- Not allowed to deploy app %q, skipping deploy ----\n", clone.Name)
+		return nil
+	}
+	requiresApproval, err := clone.DeployRequiresApproval()
+	if err != nil {
+		return err
+	}
+	if requiresApproval {
+		req, err := app.RequestDeploy(opts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "---- Deploys to app %q require approval, deploy request %s created ----\n", clone.Name, req.ID)
+		return nil
+	}
+	var imageID string
+	evt, err := event.New(&event.Opts{
+		Target:        appTarget(clone.Name),
+		ExtraTargets:  []event.ExtraTarget{{Target: appTarget(source.Name)}},
+		Kind:          permission.PermAppDeploy,
+		Owner:         t,
+		CustomData:    opts,
+		Allowed:       event.Allowed(permission.PermAppReadEvents, contextsForApp(clone)...),
+		AllowedCancel: event.Allowed(permission.PermAppUpdateEvents, contextsForApp(clone)...),
+		Cancelable:    true,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.DoneCustomData(err, map[string]string{"image": imageID}) }()
+	opts.Event = evt
+	imageID, err = app.Deploy(opts)
+	return err
+}
@@ -0,0 +1,181 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/builder"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/permission/permissiontest"
+	"github.com/tsuru/tsuru/provision"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+	check "gopkg.in/check.v1"
+)
+
+func (s *DeploySuite) cloneToken(c *check.C, schemes ...*permission.PermissionScheme) auth.Token {
+	perms := make([]permission.Permission, len(schemes))
+	for i, scheme := range schemes {
+		perms[i] = permission.Permission{
+			Scheme:  scheme,
+			Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+		}
+	}
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "cloner", perms...)
+	return token
+}
+
+func (s *DeploySuite) TestAppClone(c *check.C) {
+	source := app.App{Name: "source", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&source, s.user)
+	c.Assert(err, check.IsNil)
+	err = source.SetEnvs(bind.SetEnvArgs{Envs: []bind.EnvVar{{Name: "MY_ENV", Value: "val", Public: true}}})
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(source.Name, "127.0.0.1:5000/tsuru/app-source:v1")
+	c.Assert(err, check.IsNil)
+	var buildOpts *builder.BuildOpts
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		buildOpts = opts
+		return "127.0.0.1:5000/tsuru/app-clone:v1", nil
+	}
+	token := s.cloneToken(c, permission.PermAppCreate, permission.PermAppReadEnv, permission.PermAppDeploy)
+	request, err := http.NewRequest("POST", "/apps/source/clone", strings.NewReader("name=clone"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/x-json-stream")
+	clone, err := app.GetByName("clone")
+	c.Assert(err, check.IsNil)
+	c.Assert(clone.TeamOwner, check.Equals, s.team.Name)
+	c.Assert(clone.Envs()["MY_ENV"], check.DeepEquals, bind.EnvVar{Name: "MY_ENV", Value: "val", Public: true})
+	c.Assert(buildOpts, check.NotNil)
+	c.Assert(buildOpts.ImageID, check.Equals, "127.0.0.1:5000/tsuru/app-source:v1")
+	c.Assert(buildOpts.Promote, check.Equals, true)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget("clone"),
+		Owner:  token.GetUserName(),
+		Kind:   "app.create",
+		StartCustomData: []map[string]interface{}{
+			{"name": ":app", "value": "source"},
+			{"name": "name", "value": "clone"},
+		},
+	}, eventtest.HasEvent)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget("clone"),
+		Owner:  token.GetUserName(),
+		Kind:   "app.deploy",
+		StartCustomData: map[string]interface{}{
+			"kind":      "promote",
+			"sourceapp": "source",
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *DeploySuite) TestAppCloneDeployFailure(c *check.C) {
+	source := app.App{Name: "source", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&source, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(source.Name, "127.0.0.1:5000/tsuru/app-source:v1")
+	c.Assert(err, check.IsNil)
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		return "", errors.New("build failed")
+	}
+	token := s.cloneToken(c, permission.PermAppCreate, permission.PermAppReadEnv, permission.PermAppDeploy)
+	request, err := http.NewRequest("POST", "/apps/source/clone", strings.NewReader("name=clone"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Body.String(), check.Matches, `(?s).*"Error":".*build failed.*`)
+	_, err = app.GetByName("clone")
+	c.Assert(err, check.IsNil)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget("clone"),
+		Owner:  token.GetUserName(),
+		Kind:   "app.create",
+	}, eventtest.HasEvent)
+	c.Assert(eventtest.EventDesc{
+		Target:       appTarget("clone"),
+		Owner:        token.GetUserName(),
+		Kind:         "app.deploy",
+		ErrorMatches: ".*build failed.*",
+	}, eventtest.HasEvent)
+}
+
+func (s *DeploySuite) TestAppCloneWithoutImage(c *check.C) {
+	source := app.App{Name: "source", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&source, s.user)
+	c.Assert(err, check.IsNil)
+	token := s.cloneToken(c, permission.PermAppCreate, permission.PermAppReadEnv, permission.PermAppDeploy)
+	request, err := http.NewRequest("POST", "/apps/source/clone", strings.NewReader("name=clone"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Body.String(), check.Matches, `(?s).*App \\"source\\" has no image to be deployed.*`)
+	_, err = app.GetByName("clone")
+	c.Assert(err, check.IsNil)
+}
+
+func (s *DeploySuite) TestAppCloneWithoutName(c *check.C) {
+	source := app.App{Name: "source", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&source, s.user)
+	c.Assert(err, check.IsNil)
+	token := s.cloneToken(c, permission.PermAppCreate, permission.PermAppReadEnv)
+	request, err := http.NewRequest("POST", "/apps/source/clone", strings.NewReader("pool=pool1"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "you must provide the name of the new app\n")
+}
+
+func (s *DeploySuite) TestAppCloneAlreadyExists(c *check.C) {
+	source := app.App{Name: "source", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&source, s.user)
+	c.Assert(err, check.IsNil)
+	token := s.cloneToken(c, permission.PermAppCreate, permission.PermAppReadEnv)
+	request, err := http.NewRequest("POST", "/apps/source/clone", strings.NewReader("name=source"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusConflict)
+}
+
+func (s *DeploySuite) TestAppCloneWithoutReadEnvPermission(c *check.C) {
+	source := app.App{Name: "source", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&source, s.user)
+	c.Assert(err, check.IsNil)
+	token := s.cloneToken(c, permission.PermAppCreate)
+	request, err := http.NewRequest("POST", "/apps/source/clone", strings.NewReader("name=clone"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+	_, err = app.GetByName("clone")
+	c.Assert(err, check.NotNil)
+}
@@ -7,11 +7,14 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
 	"github.com/tsuru/tsuru/auth"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
@@ -20,12 +23,16 @@ import (
 	"github.com/tsuru/tsuru/repository"
 )
 
-const eventIDHeader = "X-Tsuru-Eventid"
+const (
+	eventIDHeader  = "X-Tsuru-Eventid"
+	jsonStreamType = "application/x-json-stream"
+)
 
 // title: app deploy
 // path: /apps/{appname}/deploy
 // method: POST
 // consume: application/x-www-form-urlencoded
+// produce: text/plain, application/x-json-stream
 // responses:
 //   200: OK
 //   400: Invalid data
@@ -40,7 +47,6 @@ func deploy(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
 		defer opts.File.Close()
 	}
 	commit := r.FormValue("commit")
-	w.Header().Set("Content-Type", "text")
 	appName := r.URL.Query().Get(":appname")
 	origin := r.FormValue("origin")
 	if opts.Image != "" {
@@ -94,6 +100,13 @@ func deploy(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
 			return &tsuruErrors.HTTP{Code: http.StatusForbidden, Message: "User does not have permission to do this action in this app"}
 		}
 	}
+	requiresApproval, err := instance.DeployRequiresApproval()
+	if err != nil {
+		return err
+	}
+	if requiresApproval {
+		return requestDeploy(w, opts)
+	}
 	var imageID string
 	evt, err := event.New(&event.Opts{
 		Target:        appTarget(appName),
@@ -107,14 +120,40 @@ func deploy(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
 	if err != nil {
 		return err
 	}
-	defer func() { evt.DoneCustomData(err, map[string]string{"image": imageID}) }()
+	// The response is only streamed once the event exists, so errors
+	// happening before are sent as regular HTTP errors.
+	var progress tsuruIo.ProgressWriter
+	if strings.Contains(r.Header.Get("Accept"), jsonStreamType) {
+		w.Header().Set("Content-Type", jsonStreamType)
+		progress = tsuruIo.NewJSONProgressWriter(w)
+	} else {
+		w.Header().Set("Content-Type", "text")
+	}
+	defer func() {
+		evt.DoneCustomData(err, map[string]string{"image": imageID})
+		if progress != nil {
+			// The final result carries the error, so it must not be
+			// written again as free text.
+			result := tsuruIo.ProgressMessage{Type: tsuruIo.ProgressResult, Image: imageID}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			progress.WriteProgress(result)
+			err = nil
+		}
+	}()
 	w.Header().Set(eventIDHeader, evt.UniqueID.Hex())
 	opts.Event = evt
-	writer := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "please wait...")
+	var output io.Writer = w
+	if progress != nil {
+		output = progress
+		evt.SetProgressWriter(progress)
+	}
+	writer := tsuruIo.NewKeepAliveWriter(output, 30*time.Second, "please wait...")
 	defer writer.Stop()
 	opts.OutputStream = writer
 	imageID, err = app.Deploy(opts)
-	if err == nil {
+	if err == nil && progress == nil {
 		fmt.Fprintln(w, "\nOK")
 	}
 	return err
@@ -134,6 +173,10 @@ func permSchemeForDeploy(opts app.DeployOptions) *permission.PermissionScheme {
 		return permission.PermAppDeployArchiveUrl
 	case app.DeployRollback:
 		return permission.PermAppDeployRollback
+	case app.DeployPromote:
+		return permission.PermAppDeployPromote
+	case app.DeployDockerfile:
+		return permission.PermAppDeployDockerfile
 	default:
 		return permission.PermAppDeploy
 	}
@@ -180,6 +223,7 @@ func diffDeploy(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 // produce: application/x-json-stream
 // responses:
 //   200: OK
+//   202: Deploy request created
 //   400: Invalid data
 //   403: Forbidden
 //   404: Not found
@@ -205,26 +249,122 @@ func deployRollback(w http.ResponseWriter, r *http.Request, t auth.Token) error
 			}
 		}
 	}
+	opts := app.DeployOptions{
+		App:      instance,
+		Image:    image,
+		User:     t.GetUserName(),
+		Origin:   origin,
+		Rollback: true,
+	}
+	opts.GetKind()
+	canRollback := permission.Check(t, permSchemeForDeploy(opts), contextsForApp(instance)...)
+	if !canRollback {
+		return &tsuruErrors.HTTP{Code: http.StatusForbidden, Message: permission.ErrUnauthorized.Error()}
+	}
+	requiresApproval, err := instance.DeployRequiresApproval()
+	if err != nil {
+		return err
+	}
+	if requiresApproval {
+		return requestDeploy(w, opts)
+	}
 	w.Header().Set("Content-Type", "application/x-json-stream")
 	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
 	defer keepAliveWriter.Stop()
 	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	opts.OutputStream = writer
+	var imageID string
+	evt, err := event.New(&event.Opts{
+		Target:        appTarget(appName),
+		Kind:          permission.PermAppDeploy,
+		Owner:         t,
+		CustomData:    opts,
+		Allowed:       event.Allowed(permission.PermAppReadEvents, contextsForApp(instance)...),
+		AllowedCancel: event.Allowed(permission.PermAppUpdateEvents, contextsForApp(instance)...),
+		Cancelable:    true,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.DoneCustomData(err, map[string]string{"image": imageID}) }()
+	opts.Event = evt
+	imageID, err = app.Deploy(opts)
+	if err != nil {
+		writer.Encode(tsuruIo.SimpleJsonMessage{Error: err.Error()})
+	}
+	return nil
+}
+
+// title: promote image
+// path: /apps/{appname}/deploy/promote
+// method: POST
+// consume: application/x-www-form-urlencoded
+// produce: application/x-json-stream
+// responses:
+//   200: OK
+//   202: Deploy request created
+//   400: Invalid data
+//   403: Forbidden
+//   404: Not found
+func deployPromote(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":appname")
+	instance, err := app.GetByName(appName)
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: fmt.Sprintf("App %s not found.", appName)}
+	}
+	sourceName := r.FormValue("source")
+	if sourceName == "" {
+		return &tsuruErrors.HTTP{
+			Code:    http.StatusBadRequest,
+			Message: "you cannot promote an image without a source app",
+		}
+	}
+	if sourceName == appName {
+		return &tsuruErrors.HTTP{
+			Code:    http.StatusBadRequest,
+			Message: "you cannot promote an image to its own app",
+		}
+	}
+	source, err := app.GetByName(sourceName)
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: fmt.Sprintf("App %s not found.", sourceName)}
+	}
 	opts := app.DeployOptions{
-		App:          instance,
-		OutputStream: writer,
-		Image:        image,
-		User:         t.GetUserName(),
-		Origin:       origin,
-		Rollback:     true,
+		App:       instance,
+		SourceApp: source.Name,
+		Image:     r.FormValue("version"),
+		User:      t.GetUserName(),
 	}
 	opts.GetKind()
-	canRollback := permission.Check(t, permSchemeForDeploy(opts), contextsForApp(instance)...)
-	if !canRollback {
+	canPromote := permission.Check(t, permSchemeForDeploy(opts), contextsForApp(instance)...) &&
+		permission.Check(t, permission.PermAppReadDeploy, contextsForApp(source)...)
+	if !canPromote {
 		return &tsuruErrors.HTTP{Code: http.StatusForbidden, Message: permission.ErrUnauthorized.Error()}
 	}
+	err = opts.ResolveSourceImage()
+	if err != nil {
+		switch err.(type) {
+		case *image.ImageNotFoundErr, *image.InvalidVersionErr:
+			return &tsuruErrors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+		}
+		return err
+	}
+	requiresApproval, err := instance.DeployRequiresApproval()
+	if err != nil {
+		return err
+	}
+	if requiresApproval {
+		return requestDeploy(w, opts)
+	}
+	w.Header().Set("Content-Type", "application/x-json-stream")
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	opts.OutputStream = writer
 	var imageID string
 	evt, err := event.New(&event.Opts{
 		Target:        appTarget(appName),
+		ExtraTargets:  []event.ExtraTarget{{Target: appTarget(source.Name)}},
 		Kind:          permission.PermAppDeploy,
 		Owner:         t,
 		CustomData:    opts,
@@ -236,6 +376,7 @@ func deployRollback(w http.ResponseWriter, r *http.Request, t auth.Token) error
 		return err
 	}
 	defer func() { evt.DoneCustomData(err, map[string]string{"image": imageID}) }()
+	w.Header().Set(eventIDHeader, evt.UniqueID.Hex())
 	opts.Event = evt
 	imageID, err = app.Deploy(opts)
 	if err != nil {
@@ -311,6 +452,7 @@ func deployInfo(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 // produce: application/x-json-stream
 // responses:
 //   200: OK
+//   202: Deploy request created
 //   400: Invalid data
 //   403: Forbidden
 //   404: Not found
@@ -327,21 +469,28 @@ func deployRebuild(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 			Message: "Invalid deployment origin",
 		}
 	}
-	w.Header().Set("Content-Type", "application/x-json-stream")
-	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
-	defer keepAliveWriter.Stop()
-	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
 	opts := app.DeployOptions{
-		App:          instance,
-		OutputStream: writer,
-		User:         t.GetUserName(),
-		Origin:       origin,
-		Kind:         app.DeployRebuild,
+		App:    instance,
+		User:   t.GetUserName(),
+		Origin: origin,
+		Kind:   app.DeployRebuild,
 	}
 	canDeploy := permission.Check(t, permSchemeForDeploy(opts), contextsForApp(instance)...)
 	if !canDeploy {
 		return &tsuruErrors.HTTP{Code: http.StatusForbidden, Message: permission.ErrUnauthorized.Error()}
 	}
+	requiresApproval, err := instance.DeployRequiresApproval()
+	if err != nil {
+		return err
+	}
+	if requiresApproval {
+		return requestDeploy(w, opts)
+	}
+	w.Header().Set("Content-Type", "application/x-json-stream")
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	opts.OutputStream = writer
 	var imageID string
 	evt, err := event.New(&event.Opts{
 		Target:        appTarget(appName),
@@ -0,0 +1,157 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/auth"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	tsuruIo "github.com/tsuru/tsuru/io"
+	"github.com/tsuru/tsuru/permission"
+)
+
+func requestDeploy(w http.ResponseWriter, opts app.DeployOptions) error {
+	req, err := app.RequestDeploy(opts)
+	if err != nil {
+		if _, ok := err.(*tsuruErrors.ValidationError); ok {
+			return &tsuruErrors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+		}
+		return err
+	}
+	w.Header().Set(eventIDHeader, req.ID)
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "Deploys to this app require approval. Deploy request %s created, it must be approved by another user until %s.\n",
+		req.ID, req.ExpireTime.Format(time.RFC3339))
+	return nil
+}
+
+func deployRequestError(err error) error {
+	switch err {
+	case app.ErrDeployRequestNotFound:
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	case app.ErrDeployRequestNotPending:
+		return &tsuruErrors.HTTP{Code: http.StatusConflict, Message: err.Error()}
+	case app.ErrDeployRequestSelfApproval:
+		return &tsuruErrors.HTTP{Code: http.StatusForbidden, Message: err.Error()}
+	}
+	return err
+}
+
+// title: list deploy requests
+// path: /apps/{appname}/deploy-requests
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   204: No content
+//   401: Unauthorized
+//   404: App not found
+func listDeployRequests(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":appname")
+	instance, err := app.GetByName(appName)
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	if !permission.Check(t, permission.PermAppReadDeploy, contextsForApp(instance)...) {
+		return permission.ErrUnauthorized
+	}
+	reqs, err := app.ListDeployRequests(appName)
+	if err != nil {
+		return err
+	}
+	if len(reqs) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(reqs)
+}
+
+// title: approve deploy request
+// path: /apps/{appname}/deploy-requests/{id}/approve
+// method: POST
+// produce: text/plain
+// responses:
+//   200: OK
+//   401: Unauthorized
+//   403: Requester can't approve own request
+//   404: App or deploy request not found
+//   409: Deploy request not pending
+func approveDeployRequest(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	appName := r.URL.Query().Get(":appname")
+	instance, err := app.GetByName(appName)
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	if !permission.Check(t, permission.PermAppDeployApprove, contextsForApp(instance)...) {
+		return permission.ErrUnauthorized
+	}
+	approver := t.GetUserName()
+	opts, reqEvt, err := app.ApproveDeployRequest(appName, r.URL.Query().Get(":id"), approver)
+	if err != nil {
+		return deployRequestError(err)
+	}
+	evt, err := event.New(&event.Opts{
+		Target:        appTarget(appName),
+		Kind:          permission.PermAppDeploy,
+		RawOwner:      event.Owner{Type: event.OwnerTypeUser, Name: opts.User},
+		CustomData:    opts,
+		Allowed:       event.Allowed(permission.PermAppReadEvents, contextsForApp(instance)...),
+		AllowedCancel: event.Allowed(permission.PermAppUpdateEvents, contextsForApp(instance)...),
+		Cancelable:    true,
+	})
+	if err != nil {
+		reqEvt.Done(err)
+		return err
+	}
+	var imageID string
+	defer func() {
+		evt.DoneCustomData(err, map[string]string{"image": imageID})
+		reqEvt.DoneCustomData(err, map[string]string{
+			"approvedBy": approver,
+			"deploy":     evt.UniqueID.Hex(),
+			"image":      imageID,
+		})
+	}()
+	w.Header().Set("Content-Type", "text")
+	w.Header().Set(eventIDHeader, evt.UniqueID.Hex())
+	opts.Event = evt
+	writer := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "please wait...")
+	defer writer.Stop()
+	opts.OutputStream = writer
+	imageID, err = app.Deploy(*opts)
+	if err == nil {
+		fmt.Fprintln(w, "\nOK")
+	}
+	return err
+}
+
+// title: reject deploy request
+// path: /apps/{appname}/deploy-requests/{id}/reject
+// method: POST
+// consume: application/x-www-form-urlencoded
+// responses:
+//   200: OK
+//   401: Unauthorized
+//   404: App or deploy request not found
+//   409: Deploy request not pending
+func rejectDeployRequest(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":appname")
+	instance, err := app.GetByName(appName)
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	if !permission.Check(t, permission.PermAppDeployApprove, contextsForApp(instance)...) {
+		return permission.ErrUnauthorized
+	}
+	err = app.RejectDeployRequest(appName, r.URL.Query().Get(":id"), t.GetUserName(), r.FormValue("reason"))
+	return deployRequestError(err)
+}
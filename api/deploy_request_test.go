@@ -0,0 +1,199 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/builder"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/permission/permissiontest"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/pool"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+	check "gopkg.in/check.v1"
+)
+
+func (s *DeploySuite) createDeployRequest(c *check.C) (*app.App, string) {
+	err := pool.PoolUpdate("pool1", pool.UpdatePoolOptions{Labels: map[string]string{pool.ProductionLabel: "true"}})
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "myapp", Platform: "python", TeamOwner: s.team.Name}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy", a.Name)
+	request, err := http.NewRequest("POST", url, strings.NewReader("image=127.0.0.1:5000/tsuru/otherapp"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusAccepted)
+	id := recorder.Header().Get(eventIDHeader)
+	c.Assert(recorder.Body.String(), check.Matches, "Deploys to this app require approval. Deploy request "+id+" created, .*\n")
+	return &a, id
+}
+
+func (s *DeploySuite) approverToken(c *check.C) auth.Token {
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "approver", permission.Permission{
+		Scheme:  permission.PermAppDeployApprove,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	})
+	return token
+}
+
+func (s *DeploySuite) TestDeployRequiresApproval(c *check.C) {
+	a, id := s.createDeployRequest(c)
+	evts, err := event.List(&event.Filter{KindNames: []string{permission.PermAppDeploy.FullName()}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 0)
+	request, err := http.NewRequest("GET", fmt.Sprintf("/apps/%s/deploy-requests", a.Name), nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	var reqs []app.DeployRequest
+	err = json.NewDecoder(recorder.Body).Decode(&reqs)
+	c.Assert(err, check.IsNil)
+	c.Assert(reqs, check.HasLen, 1)
+	c.Assert(reqs[0].ID, check.Equals, id)
+	c.Assert(reqs[0].User, check.Equals, s.token.GetUserName())
+	c.Assert(reqs[0].Image, check.Equals, "127.0.0.1:5000/tsuru/otherapp")
+}
+
+func (s *DeploySuite) TestApproveDeployRequest(c *check.C) {
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		return "tsuruteam/app-otherapp:mytag", nil
+	}
+	a, id := s.createDeployRequest(c)
+	url := fmt.Sprintf("/apps/%s/deploy-requests/%s/approve", a.Name, id)
+	request, err := http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+	approver := s.approverToken(c)
+	request.Header.Set("Authorization", "bearer "+approver.GetValue())
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Body.String(), check.Equals, "Builder deploy called\nOK\n")
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.deploy",
+		StartCustomData: map[string]interface{}{
+			"app.name": a.Name,
+			"kind":     "image",
+			"image":    "127.0.0.1:5000/tsuru/otherapp",
+		},
+		EndCustomData: map[string]interface{}{
+			"image": "app-image",
+		},
+	}, eventtest.HasEvent)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   app.DeployRequestEventKind,
+		EndCustomData: map[string]interface{}{
+			"approvedBy": approver.GetUserName(),
+			"image":      "app-image",
+		},
+	}, eventtest.HasEvent)
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusConflict)
+}
+
+func (s *DeploySuite) TestApproveDeployRequestNotFound(c *check.C) {
+	a := app.App{Name: "myapp", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy-requests/5b0d3a4fb1a2c30001a1b2c3/approve", a.Name)
+	request, err := http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.approverToken(c).GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
+
+func (s *DeploySuite) TestRejectDeployRequest(c *check.C) {
+	a, id := s.createDeployRequest(c)
+	url := fmt.Sprintf("/apps/%s/deploy-requests/%s/reject", a.Name, id)
+	request, err := http.NewRequest("POST", url, strings.NewReader("reason=freeze"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	approver := s.approverToken(c)
+	request.Header.Set("Authorization", "bearer "+approver.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(eventtest.EventDesc{
+		Target:       appTarget(a.Name),
+		Owner:        s.token.GetUserName(),
+		Kind:         app.DeployRequestEventKind,
+		ErrorMatches: "deploy request rejected by " + approver.GetUserName() + ": freeze",
+	}, eventtest.HasEvent)
+	reqs, err := app.ListDeployRequests(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(reqs, check.HasLen, 0)
+}
+
+func (s *DeploySuite) TestDeployRollbackRequiresApproval(c *check.C) {
+	err := pool.PoolUpdate("pool1", pool.UpdatePoolOptions{Labels: map[string]string{pool.ProductionLabel: "true"}})
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "myapp", Platform: "python", TeamOwner: s.team.Name}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy/rollback", a.Name)
+	request, err := http.NewRequest("POST", url, strings.NewReader("image=registry.somewhere/tsuru/app-myapp:v1"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusAccepted)
+	evts, err := event.List(&event.Filter{KindNames: []string{permission.PermAppDeploy.FullName()}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 0)
+	reqs, err := app.ListDeployRequests(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(reqs, check.HasLen, 1)
+	c.Assert(reqs[0].Kind, check.Equals, app.DeployRollback)
+	c.Assert(reqs[0].Image, check.Equals, "registry.somewhere/tsuru/app-myapp:v1")
+}
+
+func (s *DeploySuite) TestDeployRebuildRequiresApproval(c *check.C) {
+	err := pool.PoolUpdate("pool1", pool.UpdatePoolOptions{Labels: map[string]string{pool.ProductionLabel: "true"}})
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "myapp", Platform: "python", TeamOwner: s.team.Name}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy/rebuild", a.Name)
+	request, err := http.NewRequest("POST", url, strings.NewReader("origin=rebuild"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusAccepted)
+	evts, err := event.List(&event.Filter{KindNames: []string{permission.PermAppDeploy.FullName()}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 0)
+	reqs, err := app.ListDeployRequests(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(reqs, check.HasLen, 1)
+	c.Assert(reqs[0].Kind, check.Equals, app.DeployRebuild)
+}
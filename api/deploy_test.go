@@ -7,6 +7,7 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"mime/multipart"
@@ -26,6 +27,7 @@ import (
 	"github.com/tsuru/tsuru/db/dbtest"
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/event/eventtest"
+	tsuruIo "github.com/tsuru/tsuru/io"
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/permission/permissiontest"
 	"github.com/tsuru/tsuru/provision"
@@ -339,6 +341,118 @@ func (s *DeploySuite) TestDeployArchiveURL(c *check.C) {
 	}, eventtest.HasEvent)
 }
 
+func (s *DeploySuite) TestDeployJSONStream(c *check.C) {
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		return "tsuruteam/app-otherapp:mytag", nil
+	}
+	a := app.App{
+		Name:      "otherapp",
+		Platform:  "python",
+		TeamOwner: s.team.Name,
+		Router:    "fake",
+	}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy?:appname=%s", a.Name, a.Name)
+	request, err := http.NewRequest("POST", url, strings.NewReader("archive-url=http://something.tar.gz&user=fulano"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/x-json-stream")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/x-json-stream")
+	var msgs []tsuruIo.ProgressMessage
+	decoder := json.NewDecoder(recorder.Body)
+	for decoder.More() {
+		var msg tsuruIo.ProgressMessage
+		err = decoder.Decode(&msg)
+		c.Assert(err, check.IsNil)
+		msg.Timestamp = time.Time{}
+		msgs = append(msgs, msg)
+	}
+	var steps []string
+	var logs string
+	for _, msg := range msgs {
+		switch msg.Type {
+		case tsuruIo.ProgressStepStart, tsuruIo.ProgressStepEnd:
+			steps = append(steps, fmt.Sprintf("%s %s", msg.Type, msg.Phase))
+		case tsuruIo.ProgressLog:
+			logs += msg.Message
+		}
+	}
+	c.Assert(steps, check.DeepEquals, []string{
+		"step-start builder",
+		"step-end builder",
+		"step-start provisioner",
+		"step-end provisioner",
+		"step-start post-deploy",
+		"step-end post-deploy",
+		"step-start router",
+		"step-end router",
+	})
+	c.Assert(logs, check.Equals, "Builder deploy called")
+	c.Assert(msgs[len(msgs)-1], check.DeepEquals, tsuruIo.ProgressMessage{
+		Type:  tsuruIo.ProgressResult,
+		Image: "app-image",
+	})
+}
+
+func (s *DeploySuite) TestDeployJSONStreamError(c *check.C) {
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		return "", errors.New("build failed")
+	}
+	a := app.App{
+		Name:      "otherapp",
+		Platform:  "python",
+		TeamOwner: s.team.Name,
+		Router:    "fake",
+	}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy?:appname=%s", a.Name, a.Name)
+	request, err := http.NewRequest("POST", url, strings.NewReader("archive-url=http://something.tar.gz&user=fulano"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/x-json-stream")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	var msgs []tsuruIo.ProgressMessage
+	decoder := json.NewDecoder(recorder.Body)
+	for decoder.More() {
+		var msg tsuruIo.ProgressMessage
+		err = decoder.Decode(&msg)
+		c.Assert(err, check.IsNil)
+		msgs = append(msgs, msg)
+	}
+	c.Assert(msgs[1].Type, check.Equals, tsuruIo.ProgressStepEnd)
+	c.Assert(msgs[1].Phase, check.Equals, "builder")
+	c.Assert(msgs[1].Error, check.Equals, "build failed")
+	result := msgs[len(msgs)-1]
+	c.Assert(result.Type, check.Equals, tsuruIo.ProgressResult)
+	c.Assert(result.Error, check.Matches, "(?s).*build failed.*")
+}
+
+func (s *DeploySuite) TestDeployJSONStreamAppNotFound(c *check.C) {
+	url := "/apps/unknown/deploy?:appname=unknown"
+	request, err := http.NewRequest("POST", url, strings.NewReader("archive-url=http://something.tar.gz&user=fulano"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/x-json-stream")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Not(check.Equals), "application/x-json-stream")
+	c.Assert(recorder.Body.String(), check.Equals, "App not found\n")
+}
+
 func (s *DeploySuite) TestDeployUploadFile(c *check.C) {
 	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
 		return "tsuruteam/app-otherapp:mytag", nil
@@ -779,6 +893,21 @@ func (s *DeploySuite) TestDeployWithoutArchiveURL(c *check.C) {
 	c.Assert(message, check.Equals, "you must specify either the archive-url, a image url or upload a file.\n")
 }
 
+func (s *DeploySuite) TestDeployDockerfileWithoutUpload(c *check.C) {
+	a := app.App{Name: "abc", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("POST", "/apps/abc/deploy", strings.NewReader("archive-url=http://something.tar.gz&dockerfile=true"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "you must upload the build context to deploy from a Dockerfile.\n")
+}
+
 func (s *DeploySuite) TestPermSchemeForDeploy(c *check.C) {
 	var tests = []struct {
 		input    app.DeployOptions
@@ -800,6 +929,10 @@ func (s *DeploySuite) TestPermSchemeForDeploy(c *check.C) {
 			app.DeployOptions{File: ioutil.NopCloser(bytes.NewReader(nil)), Build: true},
 			permission.PermAppDeployBuild,
 		},
+		{
+			app.DeployOptions{File: ioutil.NopCloser(bytes.NewReader(nil)), Dockerfile: true},
+			permission.PermAppDeployDockerfile,
+		},
 		{
 			app.DeployOptions{},
 			permission.PermAppDeployArchiveUrl,
@@ -1403,6 +1536,128 @@ func (s *DeploySuite) TestDeployRebuildHandler(c *check.C) {
 	}, eventtest.HasEvent)
 }
 
+func (s *DeploySuite) TestDeployPromoteHandler(c *check.C) {
+	source := app.App{Name: "staging", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&source, s.user)
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "production", Platform: "python", TeamOwner: s.team.Name}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(source.Name, "127.0.0.1:5000/tsuru/app-staging:v1")
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(source.Name, "127.0.0.1:5000/tsuru/app-staging:v2")
+	c.Assert(err, check.IsNil)
+	var buildOpts *builder.BuildOpts
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		buildOpts = opts
+		return "127.0.0.1:5000/tsuru/app-production:v1", nil
+	}
+	v := url.Values{}
+	v.Set("source", source.Name)
+	v.Set("version", "v1")
+	u := fmt.Sprintf("/apps/%s/deploy/promote", a.Name)
+	request, err := http.NewRequest("POST", u, strings.NewReader(v.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/x-json-stream")
+	c.Assert(recorder.Body.String(), check.Equals, "{\"Message\":\"Builder deploy called\"}\n")
+	c.Assert(buildOpts, check.NotNil)
+	c.Assert(buildOpts.ImageID, check.Equals, "127.0.0.1:5000/tsuru/app-staging:v1")
+	c.Assert(buildOpts.Promote, check.Equals, true)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.deploy",
+		StartCustomData: map[string]interface{}{
+			"app.name":    a.Name,
+			"kind":        "promote",
+			"sourceapp":   source.Name,
+			"sourceimage": "127.0.0.1:5000/tsuru/app-staging:v1",
+			"image":       "v1",
+		},
+		EndCustomData: map[string]interface{}{
+			"image": "app-image",
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *DeploySuite) TestDeployPromoteHandlerInvalidVersion(c *check.C) {
+	source := app.App{Name: "staging", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&source, s.user)
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "production", Platform: "python", TeamOwner: s.team.Name}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(source.Name, "127.0.0.1:5000/tsuru/app-staging:v1")
+	c.Assert(err, check.IsNil)
+	v := url.Values{}
+	v.Set("source", source.Name)
+	v.Set("version", "v9")
+	u := fmt.Sprintf("/apps/%s/deploy/promote", a.Name)
+	request, err := http.NewRequest("POST", u, strings.NewReader(v.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, (&image.InvalidVersionErr{Image: "v9"}).Error()+"\n")
+}
+
+func (s *DeploySuite) TestDeployPromoteHandlerWithoutSource(c *check.C) {
+	a := app.App{Name: "production", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	u := fmt.Sprintf("/apps/%s/deploy/promote", a.Name)
+	request, err := http.NewRequest("POST", u, strings.NewReader("version=v1"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "you cannot promote an image without a source app\n")
+}
+
+func (s *DeploySuite) TestDeployPromoteHandlerSourceNotFound(c *check.C) {
+	a := app.App{Name: "production", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	u := fmt.Sprintf("/apps/%s/deploy/promote", a.Name)
+	request, err := http.NewRequest("POST", u, strings.NewReader("source=staging"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
+
+func (s *DeploySuite) TestDeployPromoteHandlerWithoutSourcePermission(c *check.C) {
+	source := app.App{Name: "staging", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&source, s.user)
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "production", Platform: "python", TeamOwner: s.team.Name}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "promoter", permission.Permission{
+		Scheme:  permission.PermAppDeployPromote,
+		Context: permission.Context(permTypes.CtxApp, a.Name),
+	})
+	u := fmt.Sprintf("/apps/%s/deploy/promote", a.Name)
+	request, err := http.NewRequest("POST", u, strings.NewReader("source=staging"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+}
+
 func (s *DeploySuite) TestRollbackUpdate(c *check.C) {
 	fakeApp := app.App{Name: "otherapp", TeamOwner: s.team.Name}
 	err := app.CreateApp(&fakeApp, s.user)
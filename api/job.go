@@ -0,0 +1,193 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	tsuruIo "github.com/tsuru/tsuru/io"
+	"github.com/tsuru/tsuru/permission"
+)
+
+// title: add job
+// path: /apps/{app}/jobs
+// method: POST
+// consume: application/x-www-form-urlencoded
+// responses:
+//   201: Job created
+//   400: Invalid data
+//   401: Unauthorized
+//   404: App not found
+//   409: Job already exists
+func addJob(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdateJobAdd,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	job := app.Job{
+		Name:     r.FormValue("name"),
+		Schedule: r.FormValue("schedule"),
+		Command:  r.FormValue("command"),
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(appName),
+		Kind:       permission.PermAppUpdateJobAdd,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	err = a.AddJob(job)
+	if err != nil {
+		if _, ok := err.(*errors.ValidationError); ok {
+			return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+		}
+		if err == app.ErrJobAlreadyExists {
+			return &errors.HTTP{Code: http.StatusConflict, Message: err.Error()}
+		}
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// title: list jobs
+// path: /apps/{app}/jobs
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   204: No content
+//   401: Unauthorized
+//   404: App not found
+func listJobs(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppReadJob,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	jobs, err := a.Jobs()
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(jobs)
+}
+
+// title: run job
+// path: /apps/{app}/jobs/{job}/run
+// method: POST
+// produce: application/x-json-stream
+// responses:
+//   200: Job run
+//   401: Unauthorized
+//   404: App or job not found
+//   409: Job already running
+func runJob(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdateJobRun,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	job, err := a.GetJob(r.URL.Query().Get(":job"))
+	if err == app.ErrJobNotFound {
+		return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	if err != nil {
+		return err
+	}
+	evt, err := event.New(&event.Opts{
+		Target:       appTarget(appName),
+		ExtraTargets: []event.ExtraTarget{{Target: app.JobTarget(a.Name, job.Name), Lock: true}},
+		DisableLock:  true,
+		Kind:         permission.PermAppUpdateJobRun,
+		Owner:        t,
+		CustomData:   job,
+		Allowed:      event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		if _, ok := err.(event.ErrEventLocked); ok {
+			return &errors.HTTP{Code: http.StatusConflict, Message: err.Error()}
+		}
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	w.Header().Set("Content-Type", "application/x-json-stream")
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	evt.SetLogWriter(writer)
+	return a.RunJob(job, evt)
+}
+
+// title: remove job
+// path: /apps/{app}/jobs/{job}
+// method: DELETE
+// responses:
+//   200: Job removed
+//   401: Unauthorized
+//   404: App or job not found
+func removeJob(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdateJobRemove,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	jobName := r.URL.Query().Get(":job")
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(appName),
+		Kind:       permission.PermAppUpdateJobRemove,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	err = a.RemoveJob(jobName)
+	if err == app.ErrJobNotFound {
+		return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	return err
+}
@@ -0,0 +1,188 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/event/eventtest"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestAddJob(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader("name=cleanup&schedule=@hourly&command=./cleanup.sh")
+	url := fmt.Sprintf("/apps/%s/jobs", a.Name)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusCreated)
+	job, err := a.GetJob("cleanup")
+	c.Assert(err, check.IsNil)
+	c.Assert(job.Schedule, check.Equals, "@hourly")
+	c.Assert(job.Command, check.Equals, "./cleanup.sh")
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.job.add",
+		StartCustomData: []map[string]interface{}{
+			{"name": ":app", "value": a.Name},
+			{"name": "name", "value": "cleanup"},
+			{"name": "schedule", "value": "@hourly"},
+			{"name": "command", "value": "./cleanup.sh"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestAddJobInvalidSchedule(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader("name=cleanup&schedule=60+*+*+*+*&command=./cleanup.sh")
+	url := fmt.Sprintf("/apps/%s/jobs", a.Name)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Matches, `invalid schedule .*minute field.*\n`)
+}
+
+func (s *S) TestAddJobAlreadyExists(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(app.Job{Name: "cleanup", Schedule: "@daily", Command: "./cleanup.sh"})
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader("name=cleanup&schedule=@hourly&command=./cleanup.sh")
+	url := fmt.Sprintf("/apps/%s/jobs", a.Name)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusConflict)
+}
+
+func (s *S) TestListJobs(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(app.Job{Name: "cleanup", Schedule: "@daily", Command: "./cleanup.sh"})
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/jobs", a.Name)
+	request, err := http.NewRequest("GET", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var jobs []app.Job
+	err = json.NewDecoder(recorder.Body).Decode(&jobs)
+	c.Assert(err, check.IsNil)
+	c.Assert(jobs, check.HasLen, 1)
+	c.Assert(jobs[0].Name, check.Equals, "cleanup")
+	c.Assert(jobs[0].Schedule, check.Equals, "@daily")
+}
+
+func (s *S) TestListJobsNoContent(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", Teams: []string{s.team.Name}}
+	err := s.conn.Apps().Insert(a)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/jobs", a.Name)
+	request, err := http.NewRequest("GET", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNoContent)
+}
+
+func (s *S) TestRunJob(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(app.Job{Name: "cleanup", Schedule: "@daily", Command: "./cleanup.sh"})
+	c.Assert(err, check.IsNil)
+	s.provisioner.PrepareOutput([]byte("cleaned"))
+	url := fmt.Sprintf("/apps/%s/jobs/cleanup/run", a.Name)
+	request, err := http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/x-json-stream")
+	c.Assert(recorder.Body.String(), check.Equals, `{"Message":"cleaned"}`+"\n")
+	c.Assert(s.provisioner.Execs("isolated"), check.HasLen, 1)
+	c.Assert(eventtest.EventDesc{
+		Target:     appTarget(a.Name),
+		Owner:      s.token.GetUserName(),
+		Kind:       "app.update.job.run",
+		LogMatches: "cleaned",
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestRunJobNotFound(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", Teams: []string{s.team.Name}}
+	err := s.conn.Apps().Insert(a)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/jobs/cleanup/run", a.Name)
+	request, err := http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
+
+func (s *S) TestRemoveJob(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(app.Job{Name: "cleanup", Schedule: "@daily", Command: "./cleanup.sh"})
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/jobs/cleanup", a.Name)
+	request, err := http.NewRequest("DELETE", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	_, err = a.GetJob("cleanup")
+	c.Assert(err, check.Equals, app.ErrJobNotFound)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.job.remove",
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestRemoveJobNotFound(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", Teams: []string{s.team.Name}}
+	err := s.conn.Apps().Insert(a)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/jobs/cleanup", a.Name)
+	request, err := http.NewRequest("DELETE", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
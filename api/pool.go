@@ -133,7 +133,7 @@ func addPoolHandler(w http.ResponseWriter, r *http.Request, t auth.Token) (err e
 			Message: err.Error(),
 		}
 	}
-	if err == pool.ErrPoolNameIsRequired {
+	if _, ok := err.(*terrors.ValidationError); ok || err == pool.ErrPoolNameIsRequired {
 		return &terrors.HTTP{
 			Code:    http.StatusBadRequest,
 			Message: err.Error(),
@@ -272,6 +272,7 @@ func removeTeamToPoolHandler(w http.ResponseWriter, r *http.Request, t auth.Toke
 // consume: application/x-www-form-urlencoded
 // responses:
 //   200: Pool updated
+//   400: Invalid data
 //   401: Unauthorized
 //   404: Pool not found
 //   409: Default pool already defined
@@ -308,6 +309,9 @@ func poolUpdateHandler(w http.ResponseWriter, r *http.Request, t auth.Token) (er
 	if err == pool.ErrPoolNotFound {
 		return &terrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
 	}
+	if _, ok := err.(*terrors.ValidationError); ok {
+		return &terrors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
 	if err == pool.ErrDefaultPoolAlreadyExists {
 		return &terrors.HTTP{
 			Code:    http.StatusConflict,
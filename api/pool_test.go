@@ -652,6 +652,60 @@ func (s *S) TestPoolUpdateNotFound(c *check.C) {
 	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
 }
 
+func (s *S) TestAddPoolWithLabels(c *check.C) {
+	b := bytes.NewBufferString("name=pool1&labels.production=true&labels.zone=a")
+	req, err := http.NewRequest(http.MethodPost, "/pools", b)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	rec := httptest.NewRecorder()
+	s.testServer.ServeHTTP(rec, req)
+	c.Assert(rec.Code, check.Equals, http.StatusCreated)
+	p, err := pool.GetPoolByName("pool1")
+	c.Assert(err, check.IsNil)
+	c.Assert(p.Labels, check.DeepEquals, map[string]string{"production": "true", "zone": "a"})
+	c.Assert(p.RequiresDeployApproval(), check.Equals, true)
+}
+
+func (s *S) TestPoolUpdateLabels(c *check.C) {
+	err := pool.AddPool(pool.AddPoolOptions{Name: "pool1", Labels: map[string]string{"zone": "a", "tier": "gold"}})
+	c.Assert(err, check.IsNil)
+	b := bytes.NewBufferString("labels.production=true&labels.tier=")
+	req, err := http.NewRequest(http.MethodPut, "/pools/pool1", b)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	rec := httptest.NewRecorder()
+	s.testServer.ServeHTTP(rec, req)
+	c.Assert(rec.Code, check.Equals, http.StatusOK)
+	p, err := pool.GetPoolByName("pool1")
+	c.Assert(err, check.IsNil)
+	c.Assert(p.Labels, check.DeepEquals, map[string]string{"production": "true", "zone": "a"})
+	c.Assert(p.RequiresDeployApproval(), check.Equals, true)
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypePool, Value: "pool1"},
+		Owner:  s.token.GetUserName(),
+		Kind:   "pool.update",
+		StartCustomData: []map[string]interface{}{
+			{"name": "labels.production", "value": "true"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestPoolUpdateInvalidLabel(c *check.C) {
+	err := pool.AddPool(pool.AddPoolOptions{Name: "pool1"})
+	c.Assert(err, check.IsNil)
+	b := bytes.NewBufferString("labels.$production=true")
+	req, err := http.NewRequest(http.MethodPut, "/pools/pool1", b)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	rec := httptest.NewRecorder()
+	s.testServer.ServeHTTP(rec, req)
+	c.Assert(rec.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(rec.Body.String(), check.Equals, "invalid pool label \"$production\"\n")
+}
+
 func (s *S) TestPoolConstraint(c *check.C) {
 	err := pool.SetPoolConstraint(&pool.PoolConstraint{PoolExpr: "*", Field: pool.ConstraintTypeRouter, Values: []string{"*"}})
 	c.Assert(err, check.IsNil)
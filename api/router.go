@@ -6,12 +6,18 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/ajg/form"
+	"github.com/tsuru/tsuru/app"
 	"github.com/tsuru/tsuru/auth"
 	"github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
+	tsuruIo "github.com/tsuru/tsuru/io"
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/provision/pool"
 	"github.com/tsuru/tsuru/router"
@@ -278,3 +284,154 @@ func listAppRouters(w http.ResponseWriter, r *http.Request, t auth.Token) error
 	}
 	return json.NewEncoder(w).Encode(routers)
 }
+
+// title: migrate apps between routers
+// path: /routers/migrate
+// method: POST
+// consume: application/x-www-form-urlencoded
+// produce: application/x-json-stream
+// responses:
+//   200: OK
+//   400: Invalid data
+//   401: Unauthorized
+//   404: App, pool or router not found
+func migrateRouter(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	err := r.ParseForm()
+	if err != nil {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	var params struct {
+		From string
+		To   string
+		App  string
+		Pool string
+		Dry  bool
+		Opts map[string]string
+	}
+	dec := form.NewDecoder(nil)
+	dec.IgnoreUnknownKeys(true)
+	dec.IgnoreCase(true)
+	err = dec.DecodeValues(&params, r.Form)
+	if err != nil {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if params.From == "" || params.To == "" {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: "You must provide the source and destination routers."}
+	}
+	if (params.App == "") == (params.Pool == "") {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: "You must provide either an app or a pool."}
+	}
+	opts := app.RouterMigrationOptions{
+		From:   params.From,
+		To:     appTypes.AppRouter{Name: params.To, Opts: params.Opts},
+		DryRun: params.Dry,
+	}
+	if timeout := r.FormValue("timeout"); timeout != "" {
+		opts.Timeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			return &errors.HTTP{Code: http.StatusBadRequest, Message: "invalid timeout: " + timeout}
+		}
+	}
+	for _, name := range []string{opts.From, opts.To.Name} {
+		_, err = router.Get(name)
+		if err != nil {
+			if _, isNotFound := err.(*router.ErrRouterNotFound); isNotFound {
+				return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+			}
+			return err
+		}
+	}
+	var apps []app.App
+	if params.App != "" {
+		var a app.App
+		a, err = getAppFromContext(params.App, r)
+		if err != nil {
+			return err
+		}
+		apps = append(apps, a)
+	} else {
+		_, err = pool.GetPoolByName(params.Pool)
+		if err != nil {
+			if err == pool.ErrPoolNotFound {
+				return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+			}
+			return err
+		}
+		apps, err = app.ListAppsForRouterMigration(&app.Filter{Pool: params.Pool}, opts.From)
+		if err != nil {
+			return err
+		}
+	}
+	pools := make(map[string]struct{})
+	for i := range apps {
+		if !permission.Check(t, permission.PermAppUpdateRouter, contextsForApp(&apps[i])...) {
+			return permission.ErrUnauthorized
+		}
+		if _, ok := pools[apps[i].Pool]; ok {
+			continue
+		}
+		pools[apps[i].Pool] = struct{}{}
+		var p *pool.Pool
+		p, err = pool.GetPoolByName(apps[i].Pool)
+		if err != nil {
+			return err
+		}
+		err = p.ValidateRouters([]appTypes.AppRouter{opts.To})
+		if err != nil {
+			if _, ok := err.(*errors.ValidationError); ok {
+				return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+			}
+			return err
+		}
+	}
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	w.Header().Set("Content-Type", "application/x-json-stream")
+	if len(apps) == 0 {
+		fmt.Fprintf(writer, "No apps using router %q found.\n", opts.From)
+		return nil
+	}
+	var failed []string
+	for i := range apps {
+		err = migrateAppRouter(&apps[i], opts, t, writer)
+		if err != nil {
+			fmt.Fprintf(writer, "Error migrating app %q: %v\n", apps[i].Name, err)
+			failed = append(failed, apps[i].Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("unable to migrate apps: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func migrateAppRouter(a *app.App, opts app.RouterMigrationOptions, t auth.Token, w io.Writer) (err error) {
+	if opts.DryRun {
+		return a.MigrateRouter(opts, w)
+	}
+	locked, err := app.AcquireApplicationLockWait(a.Name, t.GetUserName(), "router migration", lockWaitDuration)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return fmt.Errorf("app %q is locked", a.Name)
+	}
+	defer app.ReleaseApplicationLock(a.Name)
+	evt, err := event.New(&event.Opts{
+		Target: appTarget(a.Name),
+		Kind:   permission.PermAppUpdateRouter,
+		Owner:  t,
+		CustomData: map[string]interface{}{
+			"from": opts.From,
+			"to":   opts.To,
+		},
+		Allowed: event.Allowed(permission.PermAppReadEvents, contextsForApp(a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	evt.SetLogWriter(w)
+	return a.MigrateRouter(opts, evt)
+}
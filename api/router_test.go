@@ -12,6 +12,7 @@ import (
 
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/event/eventtest"
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/provision/pool"
 	"github.com/tsuru/tsuru/router"
@@ -361,3 +362,94 @@ func (s *S) TestRemoveAppRouterNotFound(c *check.C) {
 	s.testServer.ServeHTTP(recorder, request)
 	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
 }
+
+func (s *S) TestMigrateRouter(c *check.C) {
+	token := userWithPermission(c, permission.Permission{
+		Scheme:  permission.PermAppUpdateRouter,
+		Context: permission.Context(permTypes.CtxTeam, "tsuruteam"),
+	})
+	myapp := app.App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name, CName: []string{"myapp.io"}}
+	err := app.CreateApp(&myapp, s.user)
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader(`from=fake&to=fake-tls&pool=` + myapp.Pool)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/1.7/routers/migrate", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/x-json-stream")
+	c.Assert(recorder.Body.String(), check.Matches, `(?s).*running step switch-default.*`)
+	dbApp, err := app.GetByName(myapp.Name)
+	c.Assert(err, check.IsNil)
+	routers := dbApp.GetRouters()
+	c.Assert(routers, check.HasLen, 1)
+	c.Assert(routers[0].Name, check.Equals, "fake-tls")
+	c.Assert(routertest.TLSRouter.HasCNameFor(myapp.Name, "myapp.io"), check.Equals, true)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(myapp.Name),
+		Owner:  token.GetUserName(),
+		Kind:   "app.update.router",
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestMigrateRouterDryRun(c *check.C) {
+	myapp := app.App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := app.CreateApp(&myapp, s.user)
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader(`from=fake&to=fake-tls&app=myapp&dry=true`)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/1.7/routers/migrate", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(recorder.Body.String(), check.Matches, `(?s).*would run step add-router.*`)
+	dbApp, err := app.GetByName(myapp.Name)
+	c.Assert(err, check.IsNil)
+	routers := dbApp.GetRouters()
+	c.Assert(routers, check.HasLen, 1)
+	c.Assert(routers[0].Name, check.Equals, "fake")
+}
+
+func (s *S) TestMigrateRouterInvalidParams(c *check.C) {
+	tests := []struct {
+		body string
+		code int
+	}{
+		{body: "from=fake&app=myapp", code: http.StatusBadRequest},
+		{body: "from=fake&to=fake-tls", code: http.StatusBadRequest},
+		{body: "from=fake&to=fake-tls&app=myapp&pool=test1", code: http.StatusBadRequest},
+		{body: "from=fake&to=fake-tls&app=myapp&timeout=x", code: http.StatusBadRequest},
+		{body: "from=fake&to=fake-notfound&app=myapp", code: http.StatusNotFound},
+		{body: "from=fake&to=fake-tls&pool=notfound", code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest("POST", "/1.7/routers/migrate", strings.NewReader(tt.body))
+		c.Assert(err, check.IsNil)
+		request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		s.testServer.ServeHTTP(recorder, request)
+		c.Assert(recorder.Code, check.Equals, tt.code, check.Commentf("body %q: %q", tt.body, recorder.Body.String()))
+	}
+}
+
+func (s *S) TestMigrateRouterUnauthorized(c *check.C) {
+	token := userWithPermission(c, permission.Permission{
+		Scheme:  permission.PermAppUpdateRouter,
+		Context: permission.Context(permTypes.CtxTeam, "otherteam"),
+	})
+	myapp := app.App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := app.CreateApp(&myapp, s.user)
+	c.Assert(err, check.IsNil)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/1.7/routers/migrate", strings.NewReader("from=fake&to=fake-tls&app=myapp"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+}
@@ -42,6 +42,7 @@ import (
 	"github.com/tsuru/tsuru/provision/cluster"
 	"github.com/tsuru/tsuru/provision/nodecontainer"
 	"github.com/tsuru/tsuru/router"
+	"github.com/tsuru/tsuru/router/envoy"
 	"github.com/tsuru/tsuru/router/rebuild"
 	"github.com/tsuru/tsuru/service"
 	"github.com/tsuru/tsuru/servicemanager"
@@ -206,6 +207,9 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.0", "Get", "/apps/{app}", AuthorizationRequiredHandler(appInfo))
 	m.Add("1.0", "Post", "/apps/{app}/cname", AuthorizationRequiredHandler(setCName))
 	m.Add("1.0", "Delete", "/apps/{app}/cname", AuthorizationRequiredHandler(unsetCName))
+	m.Add("1.7", "GET", "/apps/{app}/paths", AuthorizationRequiredHandler(listPathRoutes))
+	m.Add("1.7", "POST", "/apps/{app}/paths", AuthorizationRequiredHandler(addPathRoute))
+	m.Add("1.7", "DELETE", "/apps/{app}/paths", AuthorizationRequiredHandler(removePathRoute))
 	runHandler := AuthorizationRequiredHandler(runCommand)
 	m.Add("1.0", "Post", "/apps/{app}/run", runHandler)
 	m.Add("1.0", "Post", "/apps/{app}/restart", AuthorizationRequiredHandler(restart))
@@ -218,6 +222,26 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.0", "Get", "/apps/{app}/env", AuthorizationRequiredHandler(getEnv))
 	m.Add("1.0", "Post", "/apps/{app}/env", AuthorizationRequiredHandler(setEnv))
 	m.Add("1.0", "Delete", "/apps/{app}/env", AuthorizationRequiredHandler(unsetEnv))
+	m.Add("1.7", "Get", "/apps/{app}/env/revisions", AuthorizationRequiredHandler(listEnvRevisions))
+	m.Add("1.7", "Post", "/apps/{app}/env/rollback", AuthorizationRequiredHandler(rollbackEnv))
+	m.Add("1.7", "Get", "/apps/{app}/jobs", AuthorizationRequiredHandler(listJobs))
+	m.Add("1.7", "Post", "/apps/{app}/jobs", AuthorizationRequiredHandler(addJob))
+	m.Add("1.7", "Post", "/apps/{app}/jobs/{job}/run", AuthorizationRequiredHandler(runJob))
+	m.Add("1.7", "Delete", "/apps/{app}/jobs/{job}", AuthorizationRequiredHandler(removeJob))
+	m.Add("1.7", "Get", "/apps/{app}/sleep-schedules", AuthorizationRequiredHandler(listAppSleepSchedules))
+	m.Add("1.7", "Post", "/apps/{app}/sleep-schedules", AuthorizationRequiredHandler(addAppSleepSchedule))
+	m.Add("1.7", "Delete", "/apps/{app}/sleep-schedules/{schedule}", AuthorizationRequiredHandler(removeAppSleepSchedule))
+	m.Add("1.7", "Get", "/apps/{app}/autoscale", AuthorizationRequiredHandler(listAppAutoScales))
+	m.Add("1.7", "Post", "/apps/{app}/autoscale", AuthorizationRequiredHandler(setAppAutoScale))
+	m.Add("1.7", "Delete", "/apps/{app}/autoscale", AuthorizationRequiredHandler(removeAppAutoScale))
+	m.Add("1.7", "Post", "/apps/{app}/processes/{process}/plan", AuthorizationRequiredHandler(setAppProcessPlan))
+	m.Add("1.7", "Delete", "/apps/{app}/processes/{process}/plan", AuthorizationRequiredHandler(unsetAppProcessPlan))
+	m.Add("1.7", "Post", "/apps/{app}/post-deploy", AuthorizationRequiredHandler(setAppPostDeploy))
+	m.Add("1.7", "Delete", "/apps/{app}/post-deploy", AuthorizationRequiredHandler(unsetAppPostDeploy))
+	m.Add("1.7", "Get", "/apps/{app}/build-cache", AuthorizationRequiredHandler(appBuildCacheInfo))
+	m.Add("1.7", "Post", "/apps/{app}/build-cache", AuthorizationRequiredHandler(setAppBuildCache))
+	m.Add("1.7", "Delete", "/apps/{app}/build-cache", AuthorizationRequiredHandler(purgeAppBuildCache))
+	m.Add("1.7", "Get", "/apps/{app}/images/{version}/sbom", AuthorizationRequiredHandler(appImageSBOM))
 	m.Add("1.0", "Get", "/apps", AuthorizationRequiredHandler(appList))
 	m.Add("1.0", "Post", "/apps", AuthorizationRequiredHandler(createApp))
 	forceDeleteLockHandler := AuthorizationRequiredHandler(forceDeleteLock)
@@ -234,19 +258,31 @@ func RunServer(dry bool) http.Handler {
 	logPostHandler := AuthorizationRequiredHandler(addLog)
 	m.Add("1.0", "Post", "/apps/{app}/log", logPostHandler)
 	m.Add("1.0", "Post", "/apps/{appname}/deploy/rollback", AuthorizationRequiredHandler(deployRollback))
+	m.Add("1.7", "Post", "/apps/{appname}/deploy/promote", AuthorizationRequiredHandler(deployPromote))
 	m.Add("1.4", "Put", "/apps/{appname}/deploy/rollback/update", AuthorizationRequiredHandler(deployRollbackUpdate))
 	m.Add("1.3", "Post", "/apps/{appname}/deploy/rebuild", AuthorizationRequiredHandler(deployRebuild))
+	m.Add("1.7", "Get", "/apps/{appname}/deploy-requests", AuthorizationRequiredHandler(listDeployRequests))
+	m.Add("1.7", "Post", "/apps/{appname}/deploy-requests/{id}/approve", AuthorizationRequiredHandler(approveDeployRequest))
+	m.Add("1.7", "Post", "/apps/{appname}/deploy-requests/{id}/reject", AuthorizationRequiredHandler(rejectDeployRequest))
 	m.Add("1.0", "Get", "/apps/{app}/metric/envs", AuthorizationRequiredHandler(appMetricEnvs))
 	m.Add("1.0", "Post", "/apps/{app}/routes", AuthorizationRequiredHandler(appRebuildRoutes))
 	m.Add("1.2", "Get", "/apps/{app}/certificate", AuthorizationRequiredHandler(listCertificates))
 	m.Add("1.2", "Put", "/apps/{app}/certificate", AuthorizationRequiredHandler(setCertificate))
 	m.Add("1.2", "Delete", "/apps/{app}/certificate", AuthorizationRequiredHandler(unsetCertificate))
+	m.Add("1.7", "Get", "/apps/{app}/certificate/acme", AuthorizationRequiredHandler(listACMECertificates))
+	m.Add("1.7", "Post", "/apps/{app}/certificate/acme", AuthorizationRequiredHandler(enableACMECertificate))
+	m.Add("1.7", "Delete", "/apps/{app}/certificate/acme", AuthorizationRequiredHandler(disableACMECertificate))
+	m.Add("1.7", "Get", "/.well-known/acme-challenge/{token}", http.HandlerFunc(acmeChallenge))
+	m.Add("1.7", "Get", "/certificates", AuthorizationRequiredHandler(certificateInventory))
 
 	m.Add("1.5", "Post", "/apps/{app}/routers", AuthorizationRequiredHandler(addAppRouter))
 	m.Add("1.5", "Put", "/apps/{app}/routers/{router}", AuthorizationRequiredHandler(updateAppRouter))
 	m.Add("1.5", "Delete", "/apps/{app}/routers/{router}", AuthorizationRequiredHandler(removeAppRouter))
 	m.Add("1.5", "Get", "/apps/{app}/routers", AuthorizationRequiredHandler(listAppRouters))
 
+	m.Add("1.7", "Post", "/apps/{app}/apply", AuthorizationRequiredHandler(appApply))
+	m.Add("1.7", "Post", "/apps/{app}/clone", AuthorizationRequiredHandler(appClone))
+
 	m.Add("1.0", "Post", "/node/status", AuthorizationRequiredHandler(setNodeStatus))
 
 	m.Add("1.0", "Get", "/deploys", AuthorizationRequiredHandler(deploysList))
@@ -338,6 +374,9 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.0", "Put", "/pools/{name}", AuthorizationRequiredHandler(poolUpdateHandler))
 	m.Add("1.0", "Post", "/pools/{name}/team", AuthorizationRequiredHandler(addTeamToPoolHandler))
 	m.Add("1.0", "Delete", "/pools/{name}/team", AuthorizationRequiredHandler(removeTeamToPoolHandler))
+	m.Add("1.7", "Get", "/pools/{name}/sleep-schedules", AuthorizationRequiredHandler(listPoolSleepSchedules))
+	m.Add("1.7", "Post", "/pools/{name}/sleep-schedules", AuthorizationRequiredHandler(addPoolSleepSchedule))
+	m.Add("1.7", "Delete", "/pools/{name}/sleep-schedules/{schedule}", AuthorizationRequiredHandler(removePoolSleepSchedule))
 
 	m.Add("1.3", "Get", "/constraints", AuthorizationRequiredHandler(poolConstraintList))
 	m.Add("1.3", "Put", "/constraints", AuthorizationRequiredHandler(poolConstraintSet))
@@ -402,6 +441,7 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.2", "DELETE", "/healing/node", AuthorizationRequiredHandler(nodeHealingDelete))
 	m.Add("1.3", "GET", "/healing", AuthorizationRequiredHandler(healingHistoryHandler))
 	m.Add("1.3", "GET", "/routers", AuthorizationRequiredHandler(listRouters))
+	m.Add("1.7", "POST", "/routers/migrate", AuthorizationRequiredHandler(migrateRouter))
 	m.Add("1.2", "GET", "/metrics", promhttp.Handler())
 
 	m.Add("1.3", "POST", "/provisioner/clusters", AuthorizationRequiredHandler(createCluster))
@@ -531,6 +571,18 @@ func appFinder(appName string) (rebuild.RebuildApp, error) {
 	return a, err
 }
 
+func rebuildAppsLister() ([]rebuild.RebuildApp, error) {
+	apps, err := app.List(nil)
+	if err != nil {
+		return nil, err
+	}
+	rebuildApps := make([]rebuild.RebuildApp, len(apps))
+	for i := range apps {
+		rebuildApps[i] = &apps[i]
+	}
+	return rebuildApps, nil
+}
+
 func bindAppsLister() ([]bind.App, error) {
 	apps, err := app.List(nil)
 	if err != nil {
@@ -584,6 +636,10 @@ func startServer(handler http.Handler) error {
 	}
 	defaultRouter, _ := router.Default()
 	fmt.Printf("Default router is %q.\n", defaultRouter)
+	err = envoy.Initialize()
+	if err != nil {
+		return err
+	}
 	repoManager, err := config.GetString("repo-manager")
 	if err != nil {
 		repoManager = "gandalf"
@@ -594,6 +650,10 @@ func startServer(handler http.Handler) error {
 	if err != nil {
 		return err
 	}
+	err = rebuild.InitializeReconciler(rebuildAppsLister)
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize routes reconciler")
+	}
 	scheme, err := getAuthScheme()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Warning: configuration didn't declare auth:scheme, using default scheme.")
@@ -619,6 +679,10 @@ func startServer(handler http.Handler) error {
 	if err != nil {
 		return err
 	}
+	err = autoscale.InitializeUnitScaler()
+	if err != nil {
+		return err
+	}
 	err = event.Initialize()
 	if err != nil {
 		return errors.Wrap(err, "unable to load events throttling config")
@@ -627,6 +691,26 @@ func startServer(handler http.Handler) error {
 	if err != nil {
 		return errors.Wrap(err, "unable to initialize old image gc")
 	}
+	err = app.InitializeJobScheduler()
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize job scheduler")
+	}
+	err = app.InitializeSleepScheduler()
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize sleep scheduler")
+	}
+	err = app.InitializePostDeployWatches()
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize post-deploy watches")
+	}
+	err = app.InitializeACMEManager()
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize acme manager")
+	}
+	err = app.InitializeCertificateChecker()
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize certificate checker")
+	}
 	err = service.InitializeSync(bindAppsLister)
 	if err != nil {
 		return err
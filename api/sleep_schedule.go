@@ -0,0 +1,241 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision/pool"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+)
+
+func sleepScheduleFromForm(r *http.Request) app.SleepSchedule {
+	return app.SleepSchedule{
+		Name:     r.FormValue("name"),
+		Process:  r.FormValue("process"),
+		Sleep:    r.FormValue("sleep"),
+		Wake:     r.FormValue("wake"),
+		TimeZone: r.FormValue("timezone"),
+		Proxy:    r.FormValue("proxy"),
+	}
+}
+
+func addSleepSchedule(w http.ResponseWriter, schedule app.SleepSchedule) error {
+	err := app.AddSleepSchedule(schedule)
+	if err != nil {
+		if _, ok := err.(*errors.ValidationError); ok {
+			return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+		}
+		if err == app.ErrSleepScheduleAlreadyExists {
+			return &errors.HTTP{Code: http.StatusConflict, Message: err.Error()}
+		}
+		if err == pool.ErrPoolNotFound {
+			return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+		}
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+func writeSleepSchedules(w http.ResponseWriter, appName, poolName string) error {
+	schedules, err := app.ListSleepSchedules(appName, poolName)
+	if err != nil {
+		return err
+	}
+	if len(schedules) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(schedules)
+}
+
+func removeSleepSchedule(appName, poolName, name string) error {
+	err := app.RemoveSleepSchedule(appName, poolName, name)
+	if err == app.ErrSleepScheduleNotFound {
+		return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	return err
+}
+
+// title: add app sleep schedule
+// path: /apps/{app}/sleep-schedules
+// method: POST
+// consume: application/x-www-form-urlencoded
+// responses:
+//   201: Sleep schedule created
+//   400: Invalid data
+//   401: Unauthorized
+//   404: App not found
+//   409: Sleep schedule already exists
+func addAppSleepSchedule(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdateSleepScheduleAdd,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	schedule := sleepScheduleFromForm(r)
+	schedule.App = a.Name
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(appName),
+		Kind:       permission.PermAppUpdateSleepScheduleAdd,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	return addSleepSchedule(w, schedule)
+}
+
+// title: list app sleep schedules
+// path: /apps/{app}/sleep-schedules
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   204: No content
+//   401: Unauthorized
+//   404: App not found
+func listAppSleepSchedules(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppRead,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	return writeSleepSchedules(w, a.Name, "")
+}
+
+// title: remove app sleep schedule
+// path: /apps/{app}/sleep-schedules/{schedule}
+// method: DELETE
+// responses:
+//   200: Sleep schedule removed
+//   401: Unauthorized
+//   404: App or sleep schedule not found
+func removeAppSleepSchedule(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	appName := r.URL.Query().Get(":app")
+	a, err := getAppFromContext(appName, r)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermAppUpdateSleepScheduleRemove,
+		contextsForApp(&a)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	name := r.URL.Query().Get(":schedule")
+	evt, err := event.New(&event.Opts{
+		Target:     appTarget(appName),
+		Kind:       permission.PermAppUpdateSleepScheduleRemove,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.URL.Query()),
+		Allowed:    event.Allowed(permission.PermAppReadEvents, contextsForApp(&a)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	return removeSleepSchedule(a.Name, "", name)
+}
+
+// title: add pool sleep schedule
+// path: /pools/{name}/sleep-schedules
+// method: POST
+// consume: application/x-www-form-urlencoded
+// responses:
+//   201: Sleep schedule created
+//   400: Invalid data
+//   401: Unauthorized
+//   404: Pool not found
+//   409: Sleep schedule already exists
+func addPoolSleepSchedule(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	poolName := r.URL.Query().Get(":name")
+	allowed := permission.Check(t, permission.PermPoolUpdateSleepScheduleAdd, permission.Context(permTypes.CtxPool, poolName))
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	schedule := sleepScheduleFromForm(r)
+	schedule.Pool = poolName
+	evt, err := event.New(&event.Opts{
+		Target:     event.Target{Type: event.TargetTypePool, Value: poolName},
+		Kind:       permission.PermPoolUpdateSleepScheduleAdd,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermPoolReadEvents, permission.Context(permTypes.CtxPool, poolName)),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	return addSleepSchedule(w, schedule)
+}
+
+// title: list pool sleep schedules
+// path: /pools/{name}/sleep-schedules
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   204: No content
+//   401: Unauthorized
+func listPoolSleepSchedules(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	poolName := r.URL.Query().Get(":name")
+	allowed := permission.Check(t, permission.PermPoolRead, permission.Context(permTypes.CtxPool, poolName))
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	return writeSleepSchedules(w, "", poolName)
+}
+
+// title: remove pool sleep schedule
+// path: /pools/{name}/sleep-schedules/{schedule}
+// method: DELETE
+// responses:
+//   200: Sleep schedule removed
+//   401: Unauthorized
+//   404: Sleep schedule not found
+func removePoolSleepSchedule(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	poolName := r.URL.Query().Get(":name")
+	allowed := permission.Check(t, permission.PermPoolUpdateSleepScheduleRemove, permission.Context(permTypes.CtxPool, poolName))
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	name := r.URL.Query().Get(":schedule")
+	evt, err := event.New(&event.Opts{
+		Target:     event.Target{Type: event.TargetTypePool, Value: poolName},
+		Kind:       permission.PermPoolUpdateSleepScheduleRemove,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.URL.Query()),
+		Allowed:    event.Allowed(permission.PermPoolReadEvents, permission.Context(permTypes.CtxPool, poolName)),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	return removeSleepSchedule("", poolName, name)
+}
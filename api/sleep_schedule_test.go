@@ -0,0 +1,161 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestAddAppSleepSchedule(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader("name=nights&sleep=0+20+*+*+1-5&wake=0+8+*+*+1-5&timezone=America/Sao_Paulo&proxy=http://proxy.example.com")
+	url := fmt.Sprintf("/apps/%s/sleep-schedules", a.Name)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusCreated)
+	schedules, err := app.ListSleepSchedules(a.Name, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(schedules, check.HasLen, 1)
+	c.Assert(schedules[0].Sleep, check.Equals, "0 20 * * 1-5")
+	c.Assert(schedules[0].Wake, check.Equals, "0 8 * * 1-5")
+	c.Assert(schedules[0].TimeZone, check.Equals, "America/Sao_Paulo")
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.sleep.schedule.add",
+		StartCustomData: []map[string]interface{}{
+			{"name": ":app", "value": a.Name},
+			{"name": "name", "value": "nights"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestAddAppSleepScheduleInvalid(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	body := strings.NewReader("name=nights&sleep=0+20+*+*+1-5&wake=0+8+*+*+1-5&timezone=Mars/Olympus&proxy=http://proxy.example.com")
+	url := fmt.Sprintf("/apps/%s/sleep-schedules", a.Name)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "invalid time zone: Mars/Olympus\n")
+}
+
+func (s *S) TestListAppSleepSchedules(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = app.AddSleepSchedule(app.SleepSchedule{Name: "nights", App: a.Name, Sleep: "@daily", Wake: "@hourly", Proxy: "http://proxy"})
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/sleep-schedules", a.Name)
+	request, err := http.NewRequest("GET", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var schedules []app.SleepSchedule
+	err = json.NewDecoder(recorder.Body).Decode(&schedules)
+	c.Assert(err, check.IsNil)
+	c.Assert(schedules, check.HasLen, 1)
+	c.Assert(schedules[0].Name, check.Equals, "nights")
+	c.Assert(schedules[0].App, check.Equals, a.Name)
+}
+
+func (s *S) TestRemoveAppSleepSchedule(c *check.C) {
+	a := app.App{Name: "swift", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = app.AddSleepSchedule(app.SleepSchedule{Name: "nights", App: a.Name, Sleep: "@daily", Wake: "@hourly", Proxy: "http://proxy"})
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/sleep-schedules/nights", a.Name)
+	request, err := http.NewRequest("DELETE", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	schedules, err := app.ListSleepSchedules(a.Name, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(schedules, check.HasLen, 0)
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
+
+func (s *S) TestAddPoolSleepSchedule(c *check.C) {
+	body := strings.NewReader("name=nights&sleep=@daily&wake=@hourly&proxy=http://proxy.example.com")
+	request, err := http.NewRequest("POST", "/pools/test1/sleep-schedules", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusCreated)
+	schedules, err := app.ListSleepSchedules("", "test1")
+	c.Assert(err, check.IsNil)
+	c.Assert(schedules, check.HasLen, 1)
+	c.Assert(schedules[0].Pool, check.Equals, "test1")
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypePool, Value: "test1"},
+		Owner:  s.token.GetUserName(),
+		Kind:   "pool.update.sleep.schedule.add",
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestAddPoolSleepSchedulePoolNotFound(c *check.C) {
+	body := strings.NewReader("name=nights&sleep=@daily&wake=@hourly&proxy=http://proxy.example.com")
+	request, err := http.NewRequest("POST", "/pools/unknown/sleep-schedules", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
+
+func (s *S) TestListPoolSleepSchedulesNoContent(c *check.C) {
+	request, err := http.NewRequest("GET", "/pools/test1/sleep-schedules", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNoContent)
+}
+
+func (s *S) TestRemovePoolSleepSchedule(c *check.C) {
+	err := app.AddSleepSchedule(app.SleepSchedule{Name: "nights", Pool: "test1", Sleep: "@daily", Wake: "@hourly", Proxy: "http://proxy"})
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("DELETE", "/pools/test1/sleep-schedules/nights", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	schedules, err := app.ListSleepSchedules("", "test1")
+	c.Assert(err, check.IsNil)
+	c.Assert(schedules, check.HasLen, 0)
+}
@@ -0,0 +1,496 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package acmetest provides a fake ACME server for tests, issuing
+// certificates signed by a throwaway CA after validating http-01
+// challenges.
+package acmetest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tsuru/tsuru/app/acme"
+)
+
+// Server is a fake ACME server. Challenges are validated synchronously when
+// accepted by the client, by calling Validate.
+type Server struct {
+	*httptest.Server
+
+	// Validate returns the key authorization served for token on domain.
+	// The default implementation fetches it from
+	// http://<domain>/.well-known/acme-challenge/<token>.
+	Validate func(domain, token string) (string, error)
+	// CertDuration is the validity of issued certificates, defaults to 90
+	// days.
+	CertDuration time.Duration
+	// BadNonces is the number of requests that will be rejected with a
+	// badNonce error before requests are accepted again.
+	BadNonces int
+
+	mu       sync.Mutex
+	caKey    *ecdsa.PrivateKey
+	caCert   *x509.Certificate
+	nextID   int
+	nonces   map[string]bool
+	accounts map[string]*ecdsa.PublicKey
+	orders   map[string]*order
+	authzs   map[string]*authz
+	certs    map[string][]byte
+	issued   []string
+}
+
+type order struct {
+	id      string
+	account string
+	domain  string
+	authz   string
+	status  string
+	cert    string
+}
+
+type authz struct {
+	id      string
+	account string
+	domain  string
+	token   string
+	status  string
+	err     *acme.Error
+}
+
+type jws struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+type jwsHeader struct {
+	Alg   string            `json:"alg"`
+	Nonce string            `json:"nonce"`
+	URL   string            `json:"url"`
+	JWK   map[string]string `json:"jwk"`
+	KID   string            `json:"kid"`
+}
+
+// NewServer starts a new fake ACME server. The directory is served at the
+// /directory path.
+func NewServer() *Server {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "acmetest CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		panic(err)
+	}
+	caCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{
+		CertDuration: 90 * 24 * time.Hour,
+		caKey:        caKey,
+		caCert:       caCert,
+		nonces:       map[string]bool{},
+		accounts:     map[string]*ecdsa.PublicKey{},
+		orders:       map[string]*order{},
+		authzs:       map[string]*authz{},
+		certs:        map[string][]byte{},
+	}
+	s.Validate = s.fetchKeyAuthorization
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// DirectoryURL returns the URL of the ACME directory.
+func (s *Server) DirectoryURL() string {
+	return s.URL + "/directory"
+}
+
+// CACertificate returns the PEM encoded certificate of the CA signing the
+// certificates issued by the server.
+func (s *Server) CACertificate() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.caCert.Raw})
+}
+
+// Issued returns the domains of the certificates issued so far, in order.
+func (s *Server) Issued() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.issued...)
+}
+
+func (s *Server) fetchKeyAuthorization(domain, token string) (string, error) {
+	rsp, err := http.Get("http://" + domain + acme.ChallengePath + token)
+	if err != nil {
+		return "", err
+	}
+	defer rsp.Body.Close()
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return "", err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", rsp.StatusCode)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprint(s.nextID)
+}
+
+func (s *Server) newNonce() string {
+	nonce := fmt.Sprintf("nonce-%s", s.newID())
+	s.nonces[nonce] = true
+	return nonce
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Replay-Nonce", s.newNonce())
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] == "directory" {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"newNonce":   s.URL + "/new-nonce",
+			"newAccount": s.URL + "/new-account",
+			"newOrder":   s.URL + "/new-order",
+		})
+		return
+	}
+	if parts[0] == "new-nonce" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeProblem(w, http.StatusMethodNotAllowed, "malformed", "method not allowed")
+		return
+	}
+	account, payload, problem := s.verify(r, parts[0] == "new-account")
+	if problem != nil {
+		writeJSON(w, problem.StatusCode, problem)
+		return
+	}
+	var id string
+	if len(parts) > 1 {
+		id = parts[1]
+	}
+	switch parts[0] {
+	case "new-account":
+		w.Header().Set("Location", account)
+		writeJSON(w, http.StatusCreated, map[string]string{"status": "valid"})
+	case "new-order":
+		s.newOrder(w, account, payload)
+	case "order":
+		s.getOrder(w, account, id)
+	case "authz":
+		s.getAuthz(w, account, id)
+	case "challenge":
+		s.acceptChallenge(w, account, id)
+	case "finalize":
+		s.finalize(w, account, id, payload)
+	case "cert":
+		cert, ok := s.certs[id]
+		if !ok {
+			writeProblem(w, http.StatusNotFound, "malformed", "certificate not found")
+			return
+		}
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		w.Write(cert)
+	default:
+		writeProblem(w, http.StatusNotFound, "malformed", "not found")
+	}
+}
+
+// verify checks the JWS in the request body, returning the URL of the
+// account that signed it and the decoded payload.
+func (s *Server) verify(r *http.Request, newAccount bool) (string, []byte, *acme.Error) {
+	var body jws
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		return "", nil, problem(http.StatusBadRequest, "malformed", err.Error())
+	}
+	headerData, err := base64.RawURLEncoding.DecodeString(body.Protected)
+	if err != nil {
+		return "", nil, problem(http.StatusBadRequest, "malformed", err.Error())
+	}
+	var header jwsHeader
+	err = json.Unmarshal(headerData, &header)
+	if err != nil {
+		return "", nil, problem(http.StatusBadRequest, "malformed", err.Error())
+	}
+	if !s.nonces[header.Nonce] {
+		return "", nil, problem(http.StatusBadRequest, "badNonce", "invalid nonce")
+	}
+	delete(s.nonces, header.Nonce)
+	if s.BadNonces > 0 {
+		s.BadNonces--
+		return "", nil, problem(http.StatusBadRequest, "badNonce", "nonce rejected")
+	}
+	if header.Alg != "ES256" {
+		return "", nil, problem(http.StatusBadRequest, "badSignatureAlgorithm", "only ES256 is supported")
+	}
+	if header.URL != s.URL+r.URL.Path {
+		return "", nil, problem(http.StatusUnauthorized, "unauthorized", "url mismatch")
+	}
+	var account string
+	var pub *ecdsa.PublicKey
+	if newAccount {
+		if header.JWK == nil {
+			return "", nil, problem(http.StatusBadRequest, "malformed", "jwk is required")
+		}
+		pub, err = parseJWK(header.JWK)
+		if err != nil {
+			return "", nil, problem(http.StatusBadRequest, "malformed", err.Error())
+		}
+		for url, key := range s.accounts {
+			if key.X.Cmp(pub.X) == 0 && key.Y.Cmp(pub.Y) == 0 {
+				account = url
+			}
+		}
+		if account == "" {
+			account = s.URL + "/account/" + s.newID()
+			s.accounts[account] = pub
+		}
+	} else {
+		account = header.KID
+		pub = s.accounts[account]
+		if pub == nil {
+			return "", nil, problem(http.StatusBadRequest, "accountDoesNotExist", "account not found")
+		}
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(body.Signature)
+	if err != nil || len(signature) != 64 {
+		return "", nil, problem(http.StatusBadRequest, "malformed", "invalid signature")
+	}
+	hash := sha256.Sum256([]byte(body.Protected + "." + body.Payload))
+	rInt := new(big.Int).SetBytes(signature[:32])
+	sInt := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(pub, hash[:], rInt, sInt) {
+		return "", nil, problem(http.StatusBadRequest, "malformed", "invalid signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body.Payload)
+	if err != nil {
+		return "", nil, problem(http.StatusBadRequest, "malformed", err.Error())
+	}
+	return account, payload, nil
+}
+
+func (s *Server) newOrder(w http.ResponseWriter, account string, payload []byte) {
+	var req struct {
+		Identifiers []struct {
+			Type  string `json:"type"`
+			Value string `json:"value"`
+		} `json:"identifiers"`
+	}
+	err := json.Unmarshal(payload, &req)
+	if err != nil || len(req.Identifiers) != 1 || req.Identifiers[0].Type != "dns" {
+		writeProblem(w, http.StatusBadRequest, "malformed", "a single dns identifier is supported")
+		return
+	}
+	a := &authz{
+		id:      s.newID(),
+		account: account,
+		domain:  req.Identifiers[0].Value,
+		token:   fmt.Sprintf("token-%s", s.newID()),
+		status:  "pending",
+	}
+	s.authzs[a.id] = a
+	o := &order{id: s.newID(), account: account, domain: a.domain, authz: a.id, status: "pending"}
+	s.orders[o.id] = o
+	w.Header().Set("Location", s.URL+"/order/"+o.id)
+	writeJSON(w, http.StatusCreated, s.orderJSON(o))
+}
+
+func (s *Server) orderJSON(o *order) map[string]interface{} {
+	if o.status == "pending" && s.authzs[o.authz].status == "valid" {
+		o.status = "ready"
+	}
+	if s.authzs[o.authz].status == "invalid" {
+		o.status = "invalid"
+	}
+	result := map[string]interface{}{
+		"status":         o.status,
+		"identifiers":    []map[string]string{{"type": "dns", "value": o.domain}},
+		"authorizations": []string{s.URL + "/authz/" + o.authz},
+		"finalize":       s.URL + "/finalize/" + o.id,
+	}
+	if o.cert != "" {
+		result["certificate"] = s.URL + "/cert/" + o.cert
+	}
+	return result
+}
+
+func (s *Server) authzJSON(a *authz) map[string]interface{} {
+	chal := map[string]interface{}{
+		"type":   "http-01",
+		"url":    s.URL + "/challenge/" + a.id,
+		"token":  a.token,
+		"status": a.status,
+	}
+	if a.err != nil {
+		chal["error"] = a.err
+	}
+	return map[string]interface{}{
+		"status":     a.status,
+		"identifier": map[string]string{"type": "dns", "value": a.domain},
+		"challenges": []interface{}{chal},
+	}
+}
+
+func (s *Server) getOrder(w http.ResponseWriter, account, id string) {
+	o, ok := s.orders[id]
+	if !ok || o.account != account {
+		writeProblem(w, http.StatusNotFound, "malformed", "order not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.orderJSON(o))
+}
+
+func (s *Server) getAuthz(w http.ResponseWriter, account, id string) {
+	a, ok := s.authzs[id]
+	if !ok || a.account != account {
+		writeProblem(w, http.StatusNotFound, "malformed", "authorization not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.authzJSON(a))
+}
+
+func (s *Server) acceptChallenge(w http.ResponseWriter, account, id string) {
+	a, ok := s.authzs[id]
+	if !ok || a.account != account {
+		writeProblem(w, http.StatusNotFound, "malformed", "challenge not found")
+		return
+	}
+	if a.status == "pending" {
+		expected := acme.KeyAuthorization(s.accounts[account], a.token)
+		keyAuth, err := s.Validate(a.domain, a.token)
+		if err == nil && keyAuth != expected {
+			err = fmt.Errorf("key authorization mismatch: expected %q, got %q", expected, keyAuth)
+		}
+		a.status = "valid"
+		if err != nil {
+			a.status = "invalid"
+			a.err = problem(http.StatusForbidden, "unauthorized", err.Error())
+		}
+	}
+	writeJSON(w, http.StatusOK, s.authzJSON(a)["challenges"].([]interface{})[0])
+}
+
+func (s *Server) finalize(w http.ResponseWriter, account, id string, payload []byte) {
+	o, ok := s.orders[id]
+	if !ok || o.account != account {
+		writeProblem(w, http.StatusNotFound, "malformed", "order not found")
+		return
+	}
+	if s.orderJSON(o)["status"] != "ready" {
+		writeProblem(w, http.StatusForbidden, "orderNotReady", "order is not ready")
+		return
+	}
+	var req struct {
+		CSR string `json:"csr"`
+	}
+	err := json.Unmarshal(payload, &req)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "malformed", err.Error())
+		return
+	}
+	der, err := base64.RawURLEncoding.DecodeString(req.CSR)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "badCSR", err.Error())
+		return
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err == nil {
+		err = csr.CheckSignature()
+	}
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "badCSR", err.Error())
+		return
+	}
+	if len(csr.DNSNames) != 1 || csr.DNSNames[0] != o.domain {
+		writeProblem(w, http.StatusBadRequest, "badCSR", "csr names do not match the order")
+		return
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: o.domain},
+		DNSNames:     []string{o.domain},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(s.CertDuration),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, s.caCert, csr.PublicKey, s.caKey)
+	if err != nil {
+		writeProblem(w, http.StatusInternalServerError, "serverInternal", err.Error())
+		return
+	}
+	o.cert = s.newID()
+	o.status = "valid"
+	s.certs[o.cert] = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), s.CACertificate()...)
+	s.issued = append(s.issued, o.domain)
+	writeJSON(w, http.StatusOK, s.orderJSON(o))
+}
+
+func parseJWK(jwk map[string]string) (*ecdsa.PublicKey, error) {
+	if jwk["kty"] != "EC" || jwk["crv"] != "P-256" {
+		return nil, fmt.Errorf("unsupported key")
+	}
+	x, err := base64.RawURLEncoding.DecodeString(jwk["x"])
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(jwk["y"])
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
+
+func problem(status int, kind, detail string) *acme.Error {
+	return &acme.Error{StatusCode: status, Type: "urn:ietf:params:acme:error:" + kind, Detail: detail}
+}
+
+func writeProblem(w http.ResponseWriter, status int, kind, detail string) {
+	writeJSON(w, status, problem(status, kind, detail))
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
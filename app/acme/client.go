@@ -0,0 +1,509 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package acme implements a minimal ACME (RFC 8555) client, able to register
+// an account and to issue certificates validated through the http-01
+// challenge.
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ChallengePath is the path prefix where http-01 challenges are served.
+	ChallengePath = "/.well-known/acme-challenge/"
+
+	challengeTypeHTTP01 = "http-01"
+	badNonceError       = "urn:ietf:params:acme:error:badNonce"
+
+	statusProcessing = "processing"
+	statusReady      = "ready"
+	statusValid      = "valid"
+	statusInvalid    = "invalid"
+
+	defaultPollInterval = time.Second
+)
+
+// ChallengeSolver makes the key authorization of an http-01 challenge
+// available at http://<domain>/.well-known/acme-challenge/<token>.
+type ChallengeSolver interface {
+	Present(domain, token, keyAuth string) error
+	CleanUp(domain, token string) error
+}
+
+// Error is a problem document returned by the ACME server.
+type Error struct {
+	StatusCode int    `json:"status"`
+	Type       string `json:"type"`
+	Detail     string `json:"detail"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("acme: %s: %s", e.Type, e.Detail)
+}
+
+// Client talks to an ACME server on behalf of the account identified by Key.
+// AccountURL is filled by Register and may be stored alongside the key to
+// avoid registering again.
+type Client struct {
+	DirectoryURL string
+	Key          *ecdsa.PrivateKey
+	AccountURL   string
+	Email        string
+	HTTPClient   *http.Client
+	PollInterval time.Duration
+
+	mu     sync.Mutex
+	dir    *directory
+	nonces []string
+}
+
+type directory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+type identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type order struct {
+	Status         string   `json:"status"`
+	Authorizations []string `json:"authorizations"`
+	Finalize       string   `json:"finalize"`
+	Certificate    string   `json:"certificate"`
+	Error          *Error   `json:"error"`
+}
+
+type authorization struct {
+	Status     string      `json:"status"`
+	Identifier identifier  `json:"identifier"`
+	Challenges []challenge `json:"challenges"`
+}
+
+type challenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Status string `json:"status"`
+	Error  *Error `json:"error"`
+}
+
+// GenerateKey generates a new key suitable for an ACME account.
+func GenerateKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// MarshalKey encodes key in PEM format.
+func MarshalKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParseKey decodes a key encoded by MarshalKey.
+func ParseKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("acme: invalid key")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+// Register creates the account on the ACME server, agreeing to its terms of
+// service. Registering an existing key returns the existing account.
+func (c *Client) Register(ctx context.Context) error {
+	dir, err := c.directory(ctx)
+	if err != nil {
+		return err
+	}
+	req := map[string]interface{}{"termsOfServiceAgreed": true}
+	if c.Email != "" {
+		req["contact"] = []string{"mailto:" + c.Email}
+	}
+	c.AccountURL = ""
+	rsp, err := c.post(ctx, dir.NewAccount, req)
+	if err != nil {
+		return errors.Wrap(err, "unable to register acme account")
+	}
+	rsp.Body.Close()
+	c.AccountURL = rsp.Header.Get("Location")
+	if c.AccountURL == "" {
+		return errors.New("acme: account location not returned by server")
+	}
+	return nil
+}
+
+// Issue issues a certificate for domain, presenting the http-01 challenge
+// with solver. It returns the certificate chain and its private key, both
+// PEM encoded.
+func (c *Client) Issue(ctx context.Context, domain string, solver ChallengeSolver) (certPEM, keyPEM []byte, err error) {
+	if c.AccountURL == "" {
+		err = c.Register(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	dir, err := c.directory(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	rsp, err := c.post(ctx, dir.NewOrder, map[string]interface{}{
+		"identifiers": []identifier{{Type: "dns", Value: domain}},
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to create acme order")
+	}
+	orderURL := rsp.Header.Get("Location")
+	var o order
+	err = decodeBody(rsp, &o)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, authzURL := range o.Authorizations {
+		err = c.authorize(ctx, authzURL, solver)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domain},
+		DNSNames: []string{domain},
+	}, certKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	rsp, err = c.post(ctx, o.Finalize, map[string]string{"csr": encode(csr)})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to finalize acme order")
+	}
+	err = decodeBody(rsp, &o)
+	if err != nil {
+		return nil, nil, err
+	}
+	for o.Status != statusValid {
+		if o.Status != statusProcessing && o.Status != statusReady {
+			return nil, nil, errors.Errorf("acme: order is %s: %v", o.Status, o.Error)
+		}
+		err = c.wait(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = c.get(ctx, orderURL, &o)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	rsp, err = c.post(ctx, o.Certificate, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to download certificate")
+	}
+	defer rsp.Body.Close()
+	certPEM, err = ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err = MarshalKey(certKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return certPEM, keyPEM, nil
+}
+
+func (c *Client) authorize(ctx context.Context, authzURL string, solver ChallengeSolver) error {
+	var authz authorization
+	err := c.get(ctx, authzURL, &authz)
+	if err != nil {
+		return err
+	}
+	if authz.Status == statusValid {
+		return nil
+	}
+	var chal *challenge
+	for i := range authz.Challenges {
+		if authz.Challenges[i].Type == challengeTypeHTTP01 {
+			chal = &authz.Challenges[i]
+			break
+		}
+	}
+	if chal == nil {
+		return errors.Errorf("acme: no %s challenge offered for %s", challengeTypeHTTP01, authz.Identifier.Value)
+	}
+	domain := authz.Identifier.Value
+	err = solver.Present(domain, chal.Token, KeyAuthorization(&c.Key.PublicKey, chal.Token))
+	if err != nil {
+		return errors.Wrapf(err, "unable to present challenge for %s", domain)
+	}
+	defer solver.CleanUp(domain, chal.Token)
+	rsp, err := c.post(ctx, chal.URL, struct{}{})
+	if err != nil {
+		return errors.Wrapf(err, "unable to accept challenge for %s", domain)
+	}
+	rsp.Body.Close()
+	for {
+		err = c.get(ctx, authzURL, &authz)
+		if err != nil {
+			return err
+		}
+		switch authz.Status {
+		case statusValid:
+			return nil
+		case statusInvalid:
+			for _, ch := range authz.Challenges {
+				if ch.Error != nil {
+					return errors.Wrapf(ch.Error, "challenge failed for %s", domain)
+				}
+			}
+			return errors.Errorf("acme: authorization for %s is invalid", domain)
+		}
+		err = c.wait(ctx)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) wait(ctx context.Context) error {
+	interval := c.PollInterval
+	if interval == 0 {
+		interval = defaultPollInterval
+	}
+	select {
+	case <-time.After(interval):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) directory(ctx context.Context) (*directory, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dir != nil {
+		return c.dir, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, c.DirectoryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get acme directory")
+	}
+	var dir directory
+	err = decodeBody(rsp, &dir)
+	if err != nil {
+		return nil, err
+	}
+	c.dir = &dir
+	return c.dir, nil
+}
+
+func (c *Client) nonce(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	if n := len(c.nonces); n > 0 {
+		nonce := c.nonces[n-1]
+		c.nonces = c.nonces[:n-1]
+		c.mu.Unlock()
+		return nonce, nil
+	}
+	c.mu.Unlock()
+	dir, err := c.directory(ctx)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodHead, dir.NewNonce, nil)
+	if err != nil {
+		return "", err
+	}
+	rsp, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get acme nonce")
+	}
+	rsp.Body.Close()
+	nonce := rsp.Header.Get("Replay-Nonce")
+	if nonce == "" {
+		return "", errors.New("acme: nonce not returned by server")
+	}
+	return nonce, nil
+}
+
+func (c *Client) saveNonce(rsp *http.Response) {
+	if nonce := rsp.Header.Get("Replay-Nonce"); nonce != "" {
+		c.mu.Lock()
+		c.nonces = append(c.nonces, nonce)
+		c.mu.Unlock()
+	}
+}
+
+func (c *Client) get(ctx context.Context, url string, result interface{}) error {
+	rsp, err := c.post(ctx, url, nil)
+	if err != nil {
+		return err
+	}
+	return decodeBody(rsp, result)
+}
+
+// post sends payload to url as a JWS signed by the account key. A nil payload
+// sends a POST-as-GET request. Requests rejected due to a bad nonce are
+// retried once.
+func (c *Client) post(ctx context.Context, url string, payload interface{}) (*http.Response, error) {
+	var data []byte
+	if payload != nil {
+		var err error
+		data, err = json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		nonce, err := c.nonce(ctx)
+		if err != nil {
+			return nil, err
+		}
+		body, err := c.sign(url, nonce, data)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/jose+json")
+		rsp, err := c.httpClient().Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		c.saveNonce(rsp)
+		if rsp.StatusCode < http.StatusBadRequest {
+			return rsp, nil
+		}
+		acmeErr := responseError(rsp)
+		if acmeErr.Type == badNonceError && attempt == 0 {
+			continue
+		}
+		return nil, acmeErr
+	}
+}
+
+func (c *Client) sign(url, nonce string, payload []byte) ([]byte, error) {
+	protected := map[string]interface{}{
+		"alg":   "ES256",
+		"nonce": nonce,
+		"url":   url,
+	}
+	if c.AccountURL == "" {
+		protected["jwk"] = jwk(&c.Key.PublicKey)
+	} else {
+		protected["kid"] = c.AccountURL
+	}
+	header, err := json.Marshal(protected)
+	if err != nil {
+		return nil, err
+	}
+	signingInput := encode(header) + "." + encode(payload)
+	hash := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, c.Key, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	signature := append(padded(r, 32), padded(s, 32)...)
+	return json.Marshal(map[string]string{
+		"protected": encode(header),
+		"payload":   encode(payload),
+		"signature": encode(signature),
+	})
+}
+
+func jwk(pub *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   encode(padded(pub.X, 32)),
+		"y":   encode(padded(pub.Y, 32)),
+	}
+}
+
+// jwkThumbprintInput returns the JSON used to compute the RFC 7638
+// thumbprint of pub, with the required members in lexicographic order.
+func jwkThumbprintInput(pub *ecdsa.PublicKey) string {
+	return fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":"%s","y":"%s"}`,
+		encode(padded(pub.X, 32)), encode(padded(pub.Y, 32)))
+}
+
+// KeyAuthorization returns the key authorization for token, computed with
+// the public key of the account.
+func KeyAuthorization(pub *ecdsa.PublicKey, token string) string {
+	thumbprint := sha256.Sum256([]byte(jwkThumbprintInput(pub)))
+	return token + "." + encode(thumbprint[:])
+}
+
+func padded(n *big.Int, size int) []byte {
+	data := n.Bytes()
+	if len(data) >= size {
+		return data
+	}
+	result := make([]byte, size)
+	copy(result[size-len(data):], data)
+	return result
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeBody(rsp *http.Response, result interface{}) error {
+	defer rsp.Body.Close()
+	if rsp.StatusCode >= http.StatusBadRequest {
+		return responseError(rsp)
+	}
+	return errors.Wrap(json.NewDecoder(rsp.Body).Decode(result), "unable to decode acme response")
+}
+
+func responseError(rsp *http.Response) *Error {
+	defer rsp.Body.Close()
+	data, _ := ioutil.ReadAll(io.LimitReader(rsp.Body, 1<<20))
+	acmeErr := &Error{}
+	if json.Unmarshal(data, acmeErr) != nil || acmeErr.Type == "" {
+		acmeErr.Type = "unknown"
+		acmeErr.Detail = string(data)
+	}
+	acmeErr.StatusCode = rsp.StatusCode
+	return acmeErr
+}
@@ -0,0 +1,169 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package acme_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app/acme"
+	"github.com/tsuru/tsuru/app/acme/acmetest"
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type S struct {
+	server *acmetest.Server
+	client *acme.Client
+	solver *fakeSolver
+}
+
+var _ = check.Suite(&S{})
+
+type fakeSolver struct {
+	sync.Mutex
+	challenges map[string]string
+	cleaned    []string
+}
+
+func (f *fakeSolver) Present(domain, token, keyAuth string) error {
+	f.Lock()
+	defer f.Unlock()
+	f.challenges[domain+"/"+token] = keyAuth
+	return nil
+}
+
+func (f *fakeSolver) CleanUp(domain, token string) error {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.challenges, domain+"/"+token)
+	f.cleaned = append(f.cleaned, domain)
+	return nil
+}
+
+func (f *fakeSolver) validate(domain, token string) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	keyAuth, ok := f.challenges[domain+"/"+token]
+	if !ok {
+		return "", errors.New("challenge not found")
+	}
+	return keyAuth, nil
+}
+
+func (s *S) SetUpTest(c *check.C) {
+	s.server = acmetest.NewServer()
+	s.solver = &fakeSolver{challenges: map[string]string{}}
+	s.server.Validate = s.solver.validate
+	key, err := acme.GenerateKey()
+	c.Assert(err, check.IsNil)
+	s.client = &acme.Client{
+		DirectoryURL: s.server.DirectoryURL(),
+		Key:          key,
+		Email:        "admin@example.com",
+		PollInterval: time.Millisecond,
+	}
+}
+
+func (s *S) TearDownTest(c *check.C) {
+	s.server.Close()
+}
+
+func (s *S) TestRegister(c *check.C) {
+	err := s.client.Register(context.Background())
+	c.Assert(err, check.IsNil)
+	accountURL := s.client.AccountURL
+	c.Assert(strings.HasPrefix(accountURL, s.server.URL+"/account/"), check.Equals, true)
+	err = s.client.Register(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(s.client.AccountURL, check.Equals, accountURL)
+}
+
+func (s *S) TestIssue(c *check.C) {
+	certPEM, keyPEM, err := s.client.Issue(context.Background(), "myapp.example.com", s.solver)
+	c.Assert(err, check.IsNil)
+	c.Assert(s.client.AccountURL, check.Not(check.Equals), "")
+	c.Assert(s.server.Issued(), check.DeepEquals, []string{"myapp.example.com"})
+	c.Assert(s.solver.challenges, check.HasLen, 0)
+	c.Assert(s.solver.cleaned, check.DeepEquals, []string{"myapp.example.com"})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	c.Assert(err, check.IsNil)
+	c.Assert(cert.Certificate, check.HasLen, 2)
+	x509Cert, err := x509.ParseCertificate(cert.Certificate[0])
+	c.Assert(err, check.IsNil)
+	c.Assert(x509Cert.VerifyHostname("myapp.example.com"), check.IsNil)
+	pool := x509.NewCertPool()
+	c.Assert(pool.AppendCertsFromPEM(s.server.CACertificate()), check.Equals, true)
+	_, err = x509Cert.Verify(x509.VerifyOptions{Roots: pool, DNSName: "myapp.example.com"})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestIssueServingChallengeOverHTTP(c *check.C) {
+	solver := &fakeSolver{challenges: map[string]string{}}
+	challengeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.URL.Path, acme.ChallengePath)
+		keyAuth, err := solver.validate(r.Host, token)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(keyAuth))
+	}))
+	defer challengeServer.Close()
+	server := acmetest.NewServer()
+	defer server.Close()
+	s.client.DirectoryURL = server.DirectoryURL()
+	domain := strings.TrimPrefix(challengeServer.URL, "http://")
+	_, _, err := s.client.Issue(context.Background(), domain, solver)
+	c.Assert(err, check.IsNil)
+	c.Assert(server.Issued(), check.DeepEquals, []string{domain})
+}
+
+func (s *S) TestIssueRetriesBadNonce(c *check.C) {
+	s.server.BadNonces = 1
+	_, _, err := s.client.Issue(context.Background(), "myapp.example.com", s.solver)
+	c.Assert(err, check.IsNil)
+	c.Assert(s.server.Issued(), check.DeepEquals, []string{"myapp.example.com"})
+}
+
+func (s *S) TestIssueChallengeFailure(c *check.C) {
+	s.server.Validate = func(domain, token string) (string, error) {
+		return "wrong", nil
+	}
+	_, _, err := s.client.Issue(context.Background(), "myapp.example.com", s.solver)
+	c.Assert(err, check.ErrorMatches, `challenge failed for myapp.example.com: acme: urn:ietf:params:acme:error:unauthorized: key authorization mismatch.*`)
+	c.Assert(s.server.Issued(), check.HasLen, 0)
+	c.Assert(s.solver.cleaned, check.DeepEquals, []string{"myapp.example.com"})
+}
+
+func (s *S) TestIssueServerError(c *check.C) {
+	s.client.AccountURL = s.server.URL + "/account/unknown"
+	_, _, err := s.client.Issue(context.Background(), "myapp.example.com", s.solver)
+	c.Assert(err, check.ErrorMatches, `unable to create acme order: acme: urn:ietf:params:acme:error:accountDoesNotExist: account not found`)
+	acmeErr, ok := errors.Cause(err).(*acme.Error)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(acmeErr.StatusCode, check.Equals, http.StatusBadRequest)
+}
+
+func (s *S) TestMarshalKey(c *check.C) {
+	data, err := acme.MarshalKey(s.client.Key)
+	c.Assert(err, check.IsNil)
+	key, err := acme.ParseKey(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(key.D.Cmp(s.client.Key.D), check.Equals, 0)
+	_, err = acme.ParseKey([]byte("invalid"))
+	c.Assert(err, check.NotNil)
+}
@@ -0,0 +1,553 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/api/shutdown"
+	"github.com/tsuru/tsuru/app/acme"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	tsuruNet "github.com/tsuru/tsuru/net"
+	"github.com/tsuru/tsuru/router"
+)
+
+const (
+	ACMEIssueEventKind = "acme-issue"
+	ACMERenewEventKind = "acme-renew"
+
+	ACMEStatusPending = "pending"
+	ACMEStatusIssued  = "issued"
+	ACMEStatusError   = "error"
+
+	acmeIssueTimeout = 10 * time.Minute
+)
+
+var (
+	ErrACMEDisabled                 = errors.New("acme is not configured")
+	ErrACMECertificateNotFound      = errors.New("acme certificate not found")
+	ErrACMECertificateAlreadyExists = errors.New("acme certificate already enabled")
+	ErrACMEChallengeNotFound        = errors.New("acme challenge not found")
+
+	// acmeTrigger wakes up the ACME manager running in this tsuru API
+	// instance, so new certificates are issued without waiting for the next
+	// interval.
+	acmeTrigger = make(chan struct{}, 1)
+)
+
+// ACMECertificate is an app cname with a certificate issued and renewed
+// through ACME.
+type ACMECertificate struct {
+	App         string    `json:"app"`
+	CName       string    `json:"cname"`
+	Status      string    `json:"status"`
+	NotAfter    time.Time `json:"notAfter"`
+	LastError   string    `json:"lastError,omitempty"`
+	LockedUntil time.Time `json:"-"`
+	// Migrating is set while a router migration, holding the lock of the
+	// app events, waits for the certificate to be issued again.
+	Migrating bool `json:"-"`
+}
+
+type acmeChallenge struct {
+	Token   string `bson:"_id"`
+	App     string
+	Domain  string
+	KeyAuth string
+}
+
+type acmeAccount struct {
+	DirectoryURL string `bson:"_id"`
+	Key          string
+	URL          string
+}
+
+func acmeDirectoryURL() string {
+	directoryURL, _ := config.GetString("acme:directory-url")
+	return directoryURL
+}
+
+// EnableACMECertificate enables the issuance of a certificate for the cname
+// of the app through ACME. The certificate is issued in background and
+// renewed before it expires.
+func EnableACMECertificate(a *App, cname string) error {
+	if acmeDirectoryURL() == "" {
+		return ErrACMEDisabled
+	}
+	err := a.validateNameForCert(cname)
+	if err != nil {
+		return &tsuruErrors.ValidationError{Message: err.Error()}
+	}
+	routers, err := (&acmeSolver{app: a}).challengeRouters()
+	if err != nil {
+		return err
+	}
+	if len(routers) == 0 {
+		return &tsuruErrors.ValidationError{Message: "no router of the app is able to answer acme challenges"}
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.ACMECertificates().Insert(ACMECertificate{
+		App:    a.Name,
+		CName:  cname,
+		Status: ACMEStatusPending,
+	})
+	if mgo.IsDup(err) {
+		return ErrACMECertificateAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	select {
+	case acmeTrigger <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// DisableACMECertificate stops renewing the certificate of the cname. The
+// certificate already installed in the app routers is kept.
+func DisableACMECertificate(appName, cname string) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.ACMECertificates().Remove(bson.M{"app": appName, "cname": cname})
+	if err == mgo.ErrNotFound {
+		return ErrACMECertificateNotFound
+	}
+	return err
+}
+
+// ListACMECertificates returns the cnames of the app with certificates
+// managed through ACME.
+func ListACMECertificates(appName string) ([]ACMECertificate, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var certs []ACMECertificate
+	err = conn.ACMECertificates().Find(bson.M{"app": appName}).Sort("cname").All(&certs)
+	if err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+// removeACMECertificates stops managing the certificates of the given cnames,
+// or of all cnames of the app when none is given.
+func (app *App) removeACMECertificates(cnames ...string) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	query := bson.M{"app": app.Name}
+	if len(cnames) > 0 {
+		query["cname"] = bson.M{"$in": cnames}
+	}
+	_, err = conn.ACMECertificates().RemoveAll(query)
+	return err
+}
+
+// reissueACMECertificate schedules a new issuance of the certificate of the
+// cname, installing it again in all the app routers. It returns false if the
+// certificate of the cname isn't managed through ACME.
+func reissueACMECertificate(appName, cname string) (bool, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	err = conn.ACMECertificates().Update(bson.M{"app": appName, "cname": cname}, bson.M{
+		"$set": bson.M{"status": ACMEStatusPending, "lockeduntil": time.Time{}, "migrating": true},
+	})
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	select {
+	case acmeTrigger <- struct{}{}:
+	default:
+	}
+	return true, nil
+}
+
+// finishACMEMigration unsets the Migrating flag set by
+// reissueACMECertificate, once the router migration stops waiting for the
+// certificates.
+func finishACMEMigration(appName string, cnames []string) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.ACMECertificates().UpdateAll(bson.M{"app": appName, "cname": bson.M{"$in": cnames}}, bson.M{
+		"$set": bson.M{"migrating": false},
+	})
+	return err
+}
+
+// GetACMEChallenge returns the key authorization of the http-01 challenge in
+// progress identified by token.
+func GetACMEChallenge(token string) (string, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	var challenge acmeChallenge
+	err = conn.ACMEChallenges().FindId(token).One(&challenge)
+	if err == mgo.ErrNotFound {
+		return "", ErrACMEChallengeNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return challenge.KeyAuth, nil
+}
+
+// acmeSolver presents http-01 challenges for the cnames of an app. The key
+// authorization is stored in the database, to be served by the tsuru API,
+// and also added to the app routers able to answer challenges themselves.
+type acmeSolver struct {
+	app *App
+}
+
+func (s *acmeSolver) challengeRouters() ([]router.ACMEChallengeRouter, error) {
+	var routers []router.ACMEChallengeRouter
+	for _, appRouter := range s.app.GetRouters() {
+		r, err := router.Get(appRouter.Name)
+		if err != nil {
+			return nil, err
+		}
+		if challengeRouter, ok := r.(router.ACMEChallengeRouter); ok {
+			routers = append(routers, challengeRouter)
+		}
+	}
+	return routers, nil
+}
+
+func (s *acmeSolver) Present(domain, token, keyAuth string) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.ACMEChallenges().Insert(acmeChallenge{Token: token, App: s.app.Name, Domain: domain, KeyAuth: keyAuth})
+	if err != nil {
+		return err
+	}
+	routers, err := s.challengeRouters()
+	if err != nil {
+		return err
+	}
+	for _, r := range routers {
+		err = r.AddACMEChallenge(s.app.Name, token, keyAuth)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *acmeSolver) CleanUp(domain, token string) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.ACMEChallenges().RemoveId(token)
+	if err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+	routers, err := s.challengeRouters()
+	if err != nil {
+		return err
+	}
+	for _, r := range routers {
+		err = r.RemoveACMEChallenge(s.app.Name, token)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InitializeACMEManager starts the manager responsible for issuing and
+// renewing the certificates enabled through ACME, if acme:directory-url is
+// set.
+func InitializeACMEManager() error {
+	directoryURL := acmeDirectoryURL()
+	if directoryURL == "" {
+		return nil
+	}
+	m := &acmeManager{directoryURL: directoryURL}
+	m.email, _ = config.GetString("acme:email")
+	m.interval, _ = config.GetDuration("acme:interval")
+	if m.interval <= 0 {
+		m.interval = 10 * time.Minute
+	}
+	m.renewBefore, _ = config.GetDuration("acme:renew-before")
+	if m.renewBefore <= 0 {
+		m.renewBefore = 30 * 24 * time.Hour
+	}
+	m.retryInterval, _ = config.GetDuration("acme:retry-interval")
+	if m.retryInterval <= 0 {
+		m.retryInterval = time.Hour
+	}
+	m.httpClient = tsuruNet.Dial15Full60ClientWithPool
+	caFile, _ := config.GetString("acme:ca-file")
+	if caFile != "" {
+		var err error
+		m.httpClient, err = httpClientWithCA(caFile)
+		if err != nil {
+			return err
+		}
+	}
+	m.start()
+	shutdown.Register(m)
+	return nil
+}
+
+func httpClientWithCA(caFile string) (*http.Client, error) {
+	data, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.Errorf("no certificates found in %s", caFile)
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+		Timeout: time.Minute,
+	}, nil
+}
+
+type acmeManager struct {
+	directoryURL  string
+	email         string
+	interval      time.Duration
+	renewBefore   time.Duration
+	retryInterval time.Duration
+	httpClient    *http.Client
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (m *acmeManager) start() {
+	var ctx context.Context
+	ctx, m.cancel = context.WithCancel(context.Background())
+	m.done = make(chan struct{})
+	go func() {
+		defer close(m.done)
+		for {
+			err := m.run(ctx, time.Now().UTC())
+			if err != nil {
+				log.Errorf("[acme manager] error issuing certificates: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-acmeTrigger:
+			case <-time.After(m.interval):
+			}
+		}
+	}()
+}
+
+func (m *acmeManager) Shutdown(ctx context.Context) error {
+	if m.cancel == nil {
+		return nil
+	}
+	m.cancel()
+	select {
+	case <-m.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (m *acmeManager) String() string {
+	return "acme manager"
+}
+
+// run issues the pending certificates and renews the ones expiring in less
+// than renewBefore.
+func (m *acmeManager) run(ctx context.Context, now time.Time) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var certs []ACMECertificate
+	err = conn.ACMECertificates().Find(bson.M{
+		"lockeduntil": bson.M{"$lt": now},
+		"$or": []bson.M{
+			{"status": bson.M{"$ne": ACMEStatusIssued}},
+			{"notafter": bson.M{"$lt": now.Add(m.renewBefore)}},
+		},
+	}).All(&certs)
+	if err != nil || len(certs) == 0 {
+		return err
+	}
+	client, err := m.client(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range certs {
+		cert := &certs[i]
+		// Only the tsuru API instance that successfully locks the
+		// certificate issues it.
+		err = conn.ACMECertificates().Update(bson.M{
+			"app":         cert.App,
+			"cname":       cert.CName,
+			"lockeduntil": cert.LockedUntil,
+		}, bson.M{"$set": bson.M{"lockeduntil": now.Add(acmeIssueTimeout)}})
+		if err == mgo.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		update := bson.M{"lockeduntil": time.Time{}, "lasterror": "", "status": ACMEStatusIssued}
+		notAfter, issueErr := m.issue(ctx, client, cert)
+		if issueErr != nil {
+			log.Errorf("[acme manager] error issuing certificate for %q in app %q: %v", cert.CName, cert.App, issueErr)
+			update = bson.M{"lockeduntil": now.Add(m.retryInterval), "lasterror": issueErr.Error(), "status": ACMEStatusError}
+		} else {
+			update["notafter"] = notAfter
+		}
+		err = conn.ACMECertificates().Update(bson.M{"app": cert.App, "cname": cert.CName}, bson.M{"$set": update})
+		if err != nil && err != mgo.ErrNotFound {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// issue requests a new certificate for the cname and installs it in the app
+// routers, returning its expiration date.
+func (m *acmeManager) issue(ctx context.Context, client *acme.Client, cert *ACMECertificate) (notAfter time.Time, err error) {
+	a, err := GetByName(cert.App)
+	if err != nil {
+		return notAfter, err
+	}
+	kind := ACMEIssueEventKind
+	if !cert.NotAfter.IsZero() {
+		kind = ACMERenewEventKind
+	}
+	// A router migration waiting for the certificate holds the lock of the
+	// app events, the event must not wait for it.
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		InternalKind: kind,
+		CustomData:   map[string]string{"cname": cert.CName},
+		Allowed:      a.internalEventAllowed(),
+		DisableLock:  cert.Migrating,
+	})
+	if err != nil {
+		return notAfter, err
+	}
+	defer func() { evt.Done(err) }()
+	ctx, cancel := context.WithTimeout(ctx, acmeIssueTimeout)
+	defer cancel()
+	evt.Logf("requesting certificate for %s from %s", cert.CName, m.directoryURL)
+	certPEM, keyPEM, err := client.Issue(ctx, cert.CName, &acmeSolver{app: a})
+	if err != nil {
+		return notAfter, err
+	}
+	tlsCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return notAfter, err
+	}
+	x509Cert, err := x509.ParseCertificate(tlsCert.Certificate[0])
+	if err != nil {
+		return notAfter, err
+	}
+	err = a.SetCertificate(cert.CName, string(certPEM), string(keyPEM))
+	if err != nil {
+		return notAfter, err
+	}
+	evt.Logf("certificate for %s installed, valid until %s", cert.CName, x509Cert.NotAfter.UTC().Format(time.RFC3339))
+	return x509Cert.NotAfter.UTC(), nil
+}
+
+// client returns an ACME client for the account stored in the database,
+// creating the account when it doesn't exist yet.
+func (m *acmeManager) client(ctx context.Context) (*acme.Client, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	client := &acme.Client{
+		DirectoryURL: m.directoryURL,
+		Email:        m.email,
+		HTTPClient:   m.httpClient,
+	}
+	var account acmeAccount
+	err = conn.ACMEAccounts().FindId(m.directoryURL).One(&account)
+	if err == nil {
+		client.Key, err = acme.ParseKey([]byte(account.Key))
+		if err != nil {
+			return nil, err
+		}
+		client.AccountURL = account.URL
+		return client, nil
+	}
+	if err != mgo.ErrNotFound {
+		return nil, err
+	}
+	client.Key, err = acme.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	err = client.Register(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := acme.MarshalKey(client.Key)
+	if err != nil {
+		return nil, err
+	}
+	err = conn.ACMEAccounts().Insert(acmeAccount{DirectoryURL: m.directoryURL, Key: string(keyPEM), URL: client.AccountURL})
+	if mgo.IsDup(err) {
+		// Another tsuru API instance created the account first.
+		return m.client(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/app/acme/acmetest"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/router/routertest"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) newACMEServer(c *check.C) *acmetest.Server {
+	server := acmetest.NewServer()
+	server.Validate = func(domain, token string) (string, error) {
+		if routertest.TLSRouter.Challenges[token] == "" {
+			return "", errors.New("challenge not found in router")
+		}
+		return GetACMEChallenge(token)
+	}
+	config.Set("acme:directory-url", server.DirectoryURL())
+	return server
+}
+
+func (s *S) createACMEApp(c *check.C) *App {
+	a := App{
+		Name:      "myapp",
+		TeamOwner: s.team.Name,
+		Routers:   []appTypes.AppRouter{{Name: "fake-tls"}},
+		CName:     []string{"myapp.example.com"},
+	}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	return &a
+}
+
+func (s *S) TestEnableACMECertificate(c *check.C) {
+	server := s.newACMEServer(c)
+	defer server.Close()
+	defer config.Unset("acme")
+	a := s.createACMEApp(c)
+	err := EnableACMECertificate(a, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	certs, err := ListACMECertificates(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(certs, check.HasLen, 1)
+	c.Assert(certs[0].CName, check.Equals, "myapp.example.com")
+	c.Assert(certs[0].Status, check.Equals, ACMEStatusPending)
+	err = EnableACMECertificate(a, "myapp.example.com")
+	c.Assert(err, check.Equals, ErrACMECertificateAlreadyExists)
+	err = EnableACMECertificate(a, "other.example.com")
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+}
+
+func (s *S) TestEnableACMECertificateDisabled(c *check.C) {
+	a := s.createACMEApp(c)
+	err := EnableACMECertificate(a, "myapp.example.com")
+	c.Assert(err, check.Equals, ErrACMEDisabled)
+}
+
+func (s *S) TestEnableACMECertificateWithoutChallengeRouter(c *check.C) {
+	server := s.newACMEServer(c)
+	defer server.Close()
+	defer config.Unset("acme")
+	a := App{Name: "myapp", TeamOwner: s.team.Name, CName: []string{"myapp.example.com"}}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = EnableACMECertificate(&a, "myapp.example.com")
+	c.Assert(err, check.DeepEquals, &tsuruErrors.ValidationError{Message: "no router of the app is able to answer acme challenges"})
+	certs, err := ListACMECertificates(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(certs, check.HasLen, 0)
+}
+
+func (s *S) TestDisableACMECertificate(c *check.C) {
+	server := s.newACMEServer(c)
+	defer server.Close()
+	defer config.Unset("acme")
+	a := s.createACMEApp(c)
+	err := EnableACMECertificate(a, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	err = DisableACMECertificate(a.Name, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	certs, err := ListACMECertificates(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(certs, check.HasLen, 0)
+	err = DisableACMECertificate(a.Name, "myapp.example.com")
+	c.Assert(err, check.Equals, ErrACMECertificateNotFound)
+}
+
+func (s *S) TestRemoveCNameDisablesACMECertificate(c *check.C) {
+	server := s.newACMEServer(c)
+	defer server.Close()
+	defer config.Unset("acme")
+	a := s.createACMEApp(c)
+	err := EnableACMECertificate(a, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	err = a.RemoveCName("myapp.example.com")
+	c.Assert(err, check.IsNil)
+	certs, err := ListACMECertificates(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(certs, check.HasLen, 0)
+}
+
+func (s *S) TestACMEManagerIssuesAndRenews(c *check.C) {
+	server := s.newACMEServer(c)
+	defer server.Close()
+	defer config.Unset("acme")
+	server.CertDuration = 10 * 24 * time.Hour
+	a := s.createACMEApp(c)
+	err := EnableACMECertificate(a, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	m := &acmeManager{directoryURL: server.DirectoryURL(), renewBefore: 7 * 24 * time.Hour, retryInterval: time.Hour}
+	now := time.Now().UTC()
+	err = m.run(context.Background(), now)
+	c.Assert(err, check.IsNil)
+	c.Assert(server.Issued(), check.DeepEquals, []string{"myapp.example.com"})
+	c.Assert(routertest.TLSRouter.Certs["myapp.example.com"], check.Not(check.Equals), "")
+	c.Assert(routertest.TLSRouter.Challenges, check.HasLen, 0)
+	certs, err := ListACMECertificates(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(certs[0].Status, check.Equals, ACMEStatusIssued)
+	c.Assert(certs[0].NotAfter.After(now.Add(9*24*time.Hour)), check.Equals, true)
+	c.Assert(eventtest.EventDesc{
+		Target:          event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		Kind:            ACMEIssueEventKind,
+		StartCustomData: map[string]interface{}{"cname": "myapp.example.com"},
+		LogMatches:      "certificate for myapp.example.com installed",
+	}, eventtest.HasEvent)
+	err = m.run(context.Background(), now.Add(time.Hour))
+	c.Assert(err, check.IsNil)
+	c.Assert(server.Issued(), check.HasLen, 1)
+	err = m.run(context.Background(), now.Add(4*24*time.Hour))
+	c.Assert(err, check.IsNil)
+	c.Assert(server.Issued(), check.DeepEquals, []string{"myapp.example.com", "myapp.example.com"})
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		Kind:   ACMERenewEventKind,
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestACMEManagerRecordsFailures(c *check.C) {
+	server := s.newACMEServer(c)
+	defer server.Close()
+	defer config.Unset("acme")
+	server.Validate = func(domain, token string) (string, error) {
+		return "", errors.New("connection refused")
+	}
+	a := s.createACMEApp(c)
+	err := EnableACMECertificate(a, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	m := &acmeManager{directoryURL: server.DirectoryURL(), renewBefore: 7 * 24 * time.Hour, retryInterval: time.Hour}
+	now := time.Now().UTC()
+	err = m.run(context.Background(), now)
+	c.Assert(err, check.IsNil)
+	certs, err := ListACMECertificates(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(certs[0].Status, check.Equals, ACMEStatusError)
+	c.Assert(certs[0].LastError, check.Matches, ".*connection refused.*")
+	c.Assert(eventtest.EventDesc{
+		Target:       event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		Kind:         ACMEIssueEventKind,
+		ErrorMatches: ".*connection refused.*",
+	}, eventtest.HasEvent)
+	c.Assert(routertest.TLSRouter.Challenges, check.HasLen, 0)
+	server.Validate = func(domain, token string) (string, error) {
+		return GetACMEChallenge(token)
+	}
+	err = m.run(context.Background(), now.Add(time.Minute))
+	c.Assert(err, check.IsNil)
+	c.Assert(server.Issued(), check.HasLen, 0)
+	err = m.run(context.Background(), now.Add(2*time.Hour))
+	c.Assert(err, check.IsNil)
+	c.Assert(server.Issued(), check.DeepEquals, []string{"myapp.example.com"})
+}
+
+func (s *S) TestACMEManagerReusesAccount(c *check.C) {
+	server := s.newACMEServer(c)
+	defer server.Close()
+	defer config.Unset("acme")
+	m := &acmeManager{directoryURL: server.DirectoryURL()}
+	client1, err := m.client(context.Background())
+	c.Assert(err, check.IsNil)
+	client2, err := m.client(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(client2.AccountURL, check.Equals, client1.AccountURL)
+	c.Assert(client2.Key.D.Cmp(client1.Key.D), check.Equals, 0)
+}
@@ -142,7 +142,7 @@ var createAppToken = action.Action{
 		if token, ok := ctx.FWResult.(*auth.Token); ok {
 			tokenValue = (*token).GetValue()
 		} else if app, ok := ctx.Params[0].(*App); ok {
-			if tokenVar, ok := app.Env["TSURU_APP_TOKEN"]; ok {
+			if tokenVar, err := app.getEnv("TSURU_APP_TOKEN"); err == nil {
 				tokenValue = tokenVar.Value
 			}
 		}
@@ -23,6 +23,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tsuru/tsuru/action"
 	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/app/envcrypt"
 	"github.com/tsuru/tsuru/app/image"
 	"github.com/tsuru/tsuru/auth"
 	"github.com/tsuru/tsuru/builder"
@@ -58,6 +59,8 @@ var (
 	ErrNoAccess          = errors.New("team does not have access to this app")
 	ErrCannotOrphanApp   = errors.New("cannot revoke access from this team, as it's the unique team with access to the app")
 	ErrDisabledPlatform  = errors.New("Disabled Platform, only admin users can create applications with the platform")
+
+	errEnvNotDeclared = errors.New("Environment variable not declared for this app.")
 )
 
 var (
@@ -96,6 +99,8 @@ type App struct {
 	TeamOwner       string
 	Owner           string
 	Plan            appTypes.Plan
+	ProcessPlans    map[string]appTypes.Plan
+	PostDeploy      provision.TsuruYamlPostDeploy
 	UpdatePlatform  bool
 	Lock            appTypes.AppLock
 	Pool            string
@@ -128,7 +133,7 @@ func (app *App) getBuilder() (builder.Builder, error) {
 	if err != nil {
 		return nil, err
 	}
-	app.builder, err = builder.GetForProvisioner(p)
+	app.builder, err = builder.GetForApp(app, p)
 	return app.builder, err
 }
 
@@ -211,6 +216,12 @@ func (app *App) MarshalJSON() ([]byte, error) {
 	result["deploys"] = app.Deploys
 	result["teamowner"] = app.TeamOwner
 	result["plan"] = plan
+	if len(app.ProcessPlans) > 0 {
+		result["processPlans"] = app.ProcessPlans
+	}
+	if app.PostDeploy.WindowSeconds > 0 {
+		result["postDeploy"] = app.PostDeploy
+	}
 	result["lock"] = app.Lock
 	result["tags"] = app.Tags
 	result["routers"] = routers
@@ -458,6 +469,7 @@ func (app *App) Update(updateData App, w io.Writer) (err error) {
 	if poolName != "" {
 		app.Pool = poolName
 		app.provisioner = nil
+		app.builder = nil
 		_, err = app.getPoolForApp(app.Pool)
 		if err != nil {
 			return err
@@ -693,6 +705,12 @@ func Delete(app *App, evt *event.Event, requestID string) error {
 	if err != nil {
 		log.Errorf("failed to remove image names from storage for app %s: %s", appName, err)
 	}
+	err = app.PurgeBuildCache()
+	if err != nil {
+		log.Errorf("failed to remove build cache for app %s: %s", appName, err)
+	} else if err = image.DeleteAppBuildCache(appName); err != nil {
+		log.Errorf("failed to remove build cache from storage for app %s: %s", appName, err)
+	}
 	err = app.unbind(evt, requestID)
 	if err != nil {
 		logErr("Unable to unbind app", err)
@@ -716,12 +734,34 @@ func Delete(app *App, evt *event.Event, requestID string) error {
 	if err != nil {
 		logErr("Unable to unbind volumes", err)
 	}
+	err = app.removeJobs()
+	if err != nil {
+		logErr("Unable to remove jobs", err)
+	}
+	err = app.removeAutoScales()
+	if err != nil {
+		logErr("Unable to remove autoscales", err)
+	}
+	err = app.removeSleepSchedules()
+	if err != nil {
+		logErr("Unable to remove sleep schedules", err)
+	}
+	err = app.removeACMECertificates()
+	if err != nil {
+		logErr("Unable to remove acme certificates", err)
+	}
+	err = app.removePathRoutes()
+	if err != nil {
+		logErr("Unable to remove path routes", err)
+	}
 	err = repository.Manager().RemoveRepository(appName)
 	if err != nil {
 		logErr("Unable to remove app from repository manager", err)
 	}
-	token := app.Env["TSURU_APP_TOKEN"].Value
-	err = AuthScheme.AppLogout(token)
+	tokenEnv, err := decryptEnv(app.Env["TSURU_APP_TOKEN"])
+	if err == nil {
+		err = AuthScheme.AppLogout(tokenEnv.Value)
+	}
 	if err != nil {
 		logErr("Unable to remove app token in destroy", err)
 	}
@@ -744,6 +784,9 @@ func Delete(app *App, evt *event.Event, requestID string) error {
 	if err == nil {
 		defer conn.Close()
 		err = conn.Apps().Remove(bson.M{"name": appName})
+		if err == nil {
+			_, err = conn.AppEnvRevisions().RemoveAll(bson.M{"app": appName})
+		}
 	}
 	if err != nil {
 		logErr("Unable to remove app from db", err)
@@ -801,6 +844,10 @@ func (app *App) AddUnits(n uint, process string, w io.Writer) error {
 	if n == 0 {
 		return errors.New("Cannot add zero units.")
 	}
+	err := app.checkEnvs()
+	if err != nil {
+		return err
+	}
 	units, err := app.Units()
 	if err != nil {
 		return err
@@ -875,7 +922,12 @@ func (app *App) SetUnitStatus(unitName string, status provision.Status) error {
 			if !ok {
 				return nil
 			}
-			return unitProv.SetUnitStatus(unit, status)
+			err = unitProv.SetUnitStatus(unit, status)
+			if err != nil {
+				return err
+			}
+			recordUnitStatus(app.Name, unit.Status, status)
+			return nil
 		}
 	}
 	return &provision.UnitNotFoundError{ID: unitName}
@@ -946,6 +998,16 @@ func UpdateNodeStatus(nodeData provision.NodeStatusData) ([]UpdateUnitsResult, e
 	if !ok {
 		return []UpdateUnitsResult{}, nil
 	}
+	prevUnits := map[string]provision.Unit{}
+	if hasDeployWatches() {
+		units, err := node.Units()
+		if err != nil {
+			log.Errorf("[update node status] unable to list units of node %q: %s", node.Address(), err)
+		}
+		for _, u := range units {
+			prevUnits[u.ID] = u
+		}
+	}
 	result := make([]UpdateUnitsResult, len(nodeData.Units))
 	for i, unitData := range nodeData.Units {
 		unit := provision.Unit{ID: unitData.ID, Name: unitData.Name}
@@ -954,6 +1016,9 @@ func UpdateNodeStatus(nodeData provision.NodeStatusData) ([]UpdateUnitsResult, e
 		if err != nil && !isNotFound {
 			return nil, err
 		}
+		if prev, ok := prevUnits[unitData.ID]; ok {
+			recordUnitStatus(prev.AppName, prev.Status, unitData.Status)
+		}
 		result[i] = UpdateUnitsResult{ID: unitData.ID, Found: !isNotFound}
 	}
 	return result, nil
@@ -1126,24 +1191,131 @@ func (app *App) getPoolForApp(poolName string) (string, error) {
 	return pool.Name, nil
 }
 
-// setEnv sets the given environment variable in the app.
-func (app *App) setEnv(env bind.EnvVar) {
+// setEnv sets the given environment variable in the app, encrypting its
+// value when env encryption is enabled.
+func (app *App) setEnv(env bind.EnvVar) error {
 	if app.Env == nil {
 		app.Env = make(map[string]bind.EnvVar)
 	}
-	app.Env[env.Name] = env
 	if env.Public {
 		app.Log(fmt.Sprintf("setting env %s with value %s", env.Name, env.Value), "tsuru", "api")
 	}
+	env, err := encryptEnv(env)
+	if err != nil {
+		return err
+	}
+	app.Env[env.Name] = env
+	return nil
 }
 
 // getEnv returns the environment variable if it's declared in the app. It will
 // return an error if the variable is not defined in this app.
 func (app *App) getEnv(name string) (bind.EnvVar, error) {
 	if env, ok := app.Env[name]; ok {
-		return env, nil
+		return decryptEnv(env)
+	}
+	return bind.EnvVar{}, errEnvNotDeclared
+}
+
+func encryptEnv(env bind.EnvVar) (bind.EnvVar, error) {
+	value, err := envcrypt.Encrypt(env.Value)
+	if err != nil {
+		return env, errors.Wrapf(err, "unable to encrypt env %q", env.Name)
+	}
+	env.Value = value
+	return env, nil
+}
+
+// decryptEnv returns the env with its value decrypted. The encrypted value is
+// never returned, an error is returned instead when it can't be decrypted.
+func decryptEnv(env bind.EnvVar) (bind.EnvVar, error) {
+	value, err := envcrypt.Decrypt(env.Value)
+	if err != nil {
+		return bind.EnvVar{}, errors.Wrapf(err, "unable to decrypt env %q", env.Name)
+	}
+	env.Value = value
+	return env, nil
+}
+
+var reencryptLockTimeout = time.Minute
+
+// ReencryptEnvs decrypts all environment variables of the app, including the
+// ones set by services and the ones stored in its env history, and encrypts
+// them again using the current key. It runs under the app lock and with the
+// envs loaded again from the database, so envs changed concurrently are not
+// overwritten.
+func (app *App) ReencryptEnvs() error {
+	locked, err := AcquireApplicationLockWait(app.Name, InternalAppName, "env reencryption", reencryptLockTimeout)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return errors.Errorf("unable to lock app %q to encrypt its envs", app.Name)
+	}
+	defer ReleaseApplicationLock(app.Name)
+	dbApp, err := GetByName(app.Name)
+	if err != nil {
+		return err
+	}
+	app.Env = dbApp.Env
+	app.ServiceEnvs = dbApp.ServiceEnvs
+	env := make(map[string]bind.EnvVar, len(app.Env))
+	for name, e := range app.Env {
+		value, err := envcrypt.Decrypt(e.Value)
+		if err != nil {
+			return errors.Wrapf(err, "unable to decrypt env %q", name)
+		}
+		e.Value = value
+		env[name], err = encryptEnv(e)
+		if err != nil {
+			return err
+		}
+	}
+	serviceEnvs := make([]bind.ServiceEnvVar, len(app.ServiceEnvs))
+	for i, e := range app.ServiceEnvs {
+		value, err := envcrypt.Decrypt(e.Value)
+		if err != nil {
+			return errors.Wrapf(err, "unable to decrypt env %q", e.Name)
+		}
+		e.Value = value
+		e.EnvVar, err = encryptEnv(e.EnvVar)
+		if err != nil {
+			return err
+		}
+		serviceEnvs[i] = e
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
 	}
-	return bind.EnvVar{}, errors.New("Environment variable not declared for this app.")
+	defer conn.Close()
+	err = conn.Apps().Update(bson.M{"name": app.Name}, bson.M{"$set": bson.M{"env": env, "serviceenvs": serviceEnvs}})
+	if err != nil {
+		return err
+	}
+	app.Env = env
+	app.ServiceEnvs = serviceEnvs
+	return app.reencryptEnvRevisions()
+}
+
+// ReencryptAllEnvs calls ReencryptEnvs for every app, it must be called after
+// enabling env encryption or rotating the encryption key.
+func ReencryptAllEnvs(w io.Writer) error {
+	if !envcrypt.Enabled() {
+		return envcrypt.ErrNotConfigured
+	}
+	apps, err := List(nil)
+	if err != nil {
+		return err
+	}
+	for i := range apps {
+		err = apps[i].ReencryptEnvs()
+		if err != nil {
+			return errors.Wrapf(err, "unable to encrypt envs for app %q", apps[i].Name)
+		}
+		fmt.Fprintf(w, "Encrypted envs for app %q.\n", apps[i].Name)
+	}
+	return nil
 }
 
 // validateNew checks app name format, pool and plan
@@ -1157,16 +1329,44 @@ func (app *App) validateNew() error {
 	return app.validate()
 }
 
-// validate checks app pool and plan
+// validate checks app pool, plan and platform
 func (app *App) validate() error {
 	err := app.validatePool()
 	if err != nil {
 		return err
 	}
-	return app.validatePlan()
+	err = app.validatePlan()
+	if err != nil {
+		return err
+	}
+	return app.validatePlatform()
+}
+
+// validatePlatform checks the app platform can be built in the provisioner
+// of the app pool.
+func (app *App) validatePlatform() error {
+	p, err := app.getProvisioner()
+	if err != nil {
+		return err
+	}
+	return builder.ValidatePlatform(app.Platform, p)
 }
 
 func (app *App) validatePlan() error {
+	err := app.validatePlanName(app.Plan.Name)
+	if err != nil {
+		return err
+	}
+	for _, plan := range app.ProcessPlans {
+		err = app.validatePlanName(plan.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (app *App) validatePlanName(planName string) error {
 	pool, err := pool.GetPoolByName(app.Pool)
 	if err != nil {
 		return err
@@ -1176,8 +1376,8 @@ func (app *App) validatePlan() error {
 		return err
 	}
 	planSet := set.FromSlice(plans)
-	if !planSet.Includes(app.Plan.Name) {
-		msg := fmt.Sprintf("App plan %q is not allowed on pool %q", app.Plan.Name, pool.Name)
+	if !planSet.Includes(planName) {
+		msg := fmt.Sprintf("App plan %q is not allowed on pool %q", planName, pool.Name)
 		return &tsuruErrors.ValidationError{Message: msg}
 	}
 	return nil
@@ -1256,11 +1456,17 @@ func (app *App) ValidateService(services ...string) error {
 
 // InstanceEnvs returns a map of environment variables that belongs to the
 // given service and service instance.
+// Variables that can't be decrypted are left out.
 func (app *App) InstanceEnvs(serviceName, instanceName string) map[string]bind.EnvVar {
 	envs := make(map[string]bind.EnvVar)
 	for _, env := range app.ServiceEnvs {
 		if env.ServiceName == serviceName && env.InstanceName == instanceName {
-			envs[env.Name] = env.EnvVar
+			e, err := decryptEnv(env.EnvVar)
+			if err != nil {
+				log.Errorf("[app %s] %v", app.Name, err)
+				continue
+			}
+			envs[env.Name] = e
 		}
 	}
 	return envs
@@ -1322,6 +1528,10 @@ func (app *App) Restart(process string, w io.Writer) error {
 		msg = fmt.Sprintf("---- Restarting the app %q ----", app.Name)
 	}
 	fmt.Fprintf(w, "%s\n", msg)
+	err := app.checkEnvs()
+	if err != nil {
+		return err
+	}
 	prov, err := app.getProvisioner()
 	if err != nil {
 		return err
@@ -1475,6 +1685,24 @@ func (app *App) GetCpuShare() int {
 	return app.Plan.CpuShare
 }
 
+// GetProcessMemory returns the memory limit (in bytes) for the units of the
+// given process, taking its plan override into account.
+func (app *App) GetProcessMemory(process string) int64 {
+	return app.processPlan(process).Memory
+}
+
+// GetProcessSwap returns the swap limit (in bytes) for the units of the given
+// process, taking its plan override into account.
+func (app *App) GetProcessSwap(process string) int64 {
+	return app.processPlan(process).Swap
+}
+
+// GetProcessCpuShare returns the cpu share for the units of the given
+// process, taking its plan override into account.
+func (app *App) GetProcessCpuShare(process string) int {
+	return app.processPlan(process).CpuShare
+}
+
 func (app *App) GetAddresses() ([]string, error) {
 	routers, err := app.GetRoutersWithAddr()
 	if err != nil {
@@ -1527,24 +1755,79 @@ func (app *App) GetDeploys() uint {
 	return app.Deploys
 }
 
-// Envs returns a map representing the apps environment variables.
+// Envs returns a map representing the apps environment variables. Variables
+// that can't be decrypted are left out, operations sending the envs to units
+// call DecryptedEnvs first so they fail instead.
 func (app *App) Envs() map[string]bind.EnvVar {
+	envs, err := app.envs(false)
+	if err != nil {
+		log.Errorf("[app %s] %v", app.Name, err)
+	}
+	return envs
+}
+
+// DecryptedEnvs returns a map representing the apps environment variables,
+// failing when any of them can't be decrypted.
+func (app *App) DecryptedEnvs() (map[string]bind.EnvVar, error) {
+	return app.envs(true)
+}
+
+// checkEnvs ensures all the envs of the app can be decrypted, it must be
+// called before any operation sending the envs to units.
+func (app *App) checkEnvs() error {
+	_, err := app.DecryptedEnvs()
+	return err
+}
+
+func (app *App) envs(strict bool) (map[string]bind.EnvVar, error) {
 	mergedEnvs := make(map[string]bind.EnvVar, len(app.Env)+len(app.ServiceEnvs)+1)
+	multiErr := tsuruErrors.NewMultiError()
 	for _, e := range app.Env {
-		mergedEnvs[e.Name] = e
+		decrypted, err := decryptEnv(e)
+		if err != nil {
+			multiErr.Add(err)
+			continue
+		}
+		mergedEnvs[e.Name] = decrypted
 	}
+	serviceEnvs := make([]bind.ServiceEnvVar, 0, len(app.ServiceEnvs))
 	for _, e := range app.ServiceEnvs {
+		decrypted, err := decryptEnv(e.EnvVar)
+		if err != nil {
+			multiErr.Add(err)
+			continue
+		}
+		e.EnvVar = decrypted
+		serviceEnvs = append(serviceEnvs, e)
 		mergedEnvs[e.Name] = e.EnvVar
 	}
-	mergedEnvs[TsuruServicesEnvVar] = serviceEnvsFromEnvVars(app.ServiceEnvs)
-	return mergedEnvs
+	mergedEnvs[TsuruServicesEnvVar] = serviceEnvsFromEnvVars(serviceEnvs)
+	err := multiErr.ToError()
+	if err != nil && strict {
+		return nil, err
+	}
+	return mergedEnvs, err
 }
 
-// SetEnvs saves a list of environment variables in the app.
+// SetEnvs saves a list of environment variables in the app, recording the
+// change in the app env history.
 func (app *App) SetEnvs(setEnvs bind.SetEnvArgs) error {
 	if len(setEnvs.Envs) == 0 {
 		return nil
 	}
+	oldEnv := app.copyEnv()
+	err := app.setEnvs(setEnvs)
+	if err != nil {
+		return err
+	}
+	app.recordEnvRevision(oldEnv, setEnvs.Owner, 0)
+	if setEnvs.ShouldRestart {
+		return app.restartIfUnits(setEnvs.Writer)
+	}
+	return nil
+}
+
+func (app *App) setEnvs(setEnvs bind.SetEnvArgs) error {
 	for _, env := range setEnvs.Envs {
 		err := validateEnv(env.Name)
 		if err != nil {
@@ -1555,29 +1838,39 @@ func (app *App) SetEnvs(setEnvs bind.SetEnvArgs) error {
 		fmt.Fprintf(setEnvs.Writer, "---- Setting %d new environment variables ----\n", len(setEnvs.Envs))
 	}
 	for _, env := range setEnvs.Envs {
-		app.setEnv(env)
+		err := app.setEnv(env)
+		if err != nil {
+			return err
+		}
 	}
 	conn, err := db.Conn()
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
-	err = conn.Apps().Update(bson.M{"name": app.Name}, bson.M{"$set": bson.M{"env": app.Env}})
-	if err != nil {
-		return err
-	}
-	if setEnvs.ShouldRestart {
-		return app.restartIfUnits(setEnvs.Writer)
-	}
-	return nil
+	return conn.Apps().Update(bson.M{"name": app.Name}, bson.M{"$set": bson.M{"env": app.Env}})
 }
 
 // UnsetEnvs removes environment variables from an app, serializing the
-// remaining list of environment variables to all units of the app.
+// remaining list of environment variables to all units of the app and
+// recording the change in the app env history.
 func (app *App) UnsetEnvs(unsetEnvs bind.UnsetEnvArgs) error {
 	if len(unsetEnvs.VariableNames) == 0 {
 		return nil
 	}
+	oldEnv := app.copyEnv()
+	err := app.unsetEnvs(unsetEnvs)
+	if err != nil {
+		return err
+	}
+	app.recordEnvRevision(oldEnv, unsetEnvs.Owner, 0)
+	if unsetEnvs.ShouldRestart {
+		return app.restartIfUnits(unsetEnvs.Writer)
+	}
+	return nil
+}
+
+func (app *App) unsetEnvs(unsetEnvs bind.UnsetEnvArgs) error {
 	if unsetEnvs.Writer != nil {
 		fmt.Fprintf(unsetEnvs.Writer, "---- Unsetting %d environment variables ----\n", len(unsetEnvs.VariableNames))
 	}
@@ -1589,14 +1882,7 @@ func (app *App) UnsetEnvs(unsetEnvs bind.UnsetEnvArgs) error {
 		return err
 	}
 	defer conn.Close()
-	err = conn.Apps().Update(bson.M{"name": app.Name}, bson.M{"$set": bson.M{"env": app.Env}})
-	if err != nil {
-		return err
-	}
-	if unsetEnvs.ShouldRestart {
-		return app.restartIfUnits(unsetEnvs.Writer)
-	}
-	return nil
+	return conn.Apps().Update(bson.M{"name": app.Name}, bson.M{"$set": bson.M{"env": app.Env}})
 }
 
 func (app *App) restartIfUnits(w io.Writer) error {
@@ -1607,6 +1893,10 @@ func (app *App) restartIfUnits(w io.Writer) error {
 	if len(units) == 0 {
 		return nil
 	}
+	err = app.checkEnvs()
+	if err != nil {
+		return err
+	}
 	prov, err := app.getProvisioner()
 	if err != nil {
 		return err
@@ -1639,7 +1929,14 @@ func (app *App) RemoveCName(cnames ...string) error {
 	}
 	err := action.NewPipeline(actions...).Execute(app, cnames)
 	rebuild.RoutesRebuildOrEnqueue(app.Name)
-	return err
+	if err != nil {
+		return err
+	}
+	err = app.removeACMECertificates(cnames...)
+	if err != nil {
+		return err
+	}
+	return revalidatePathRoutes(cnames...)
 }
 
 func serviceEnvsFromEnvVars(vars []bind.ServiceEnvVar) bind.EnvVar {
@@ -1679,7 +1976,14 @@ func (app *App) AddInstance(addArgs bind.AddInstanceArgs) error {
 	if addArgs.Writer != nil {
 		fmt.Fprintf(addArgs.Writer, "---- Setting %d new environment variables ----\n", len(addArgs.Envs)+1)
 	}
-	app.ServiceEnvs = append(app.ServiceEnvs, addArgs.Envs...)
+	for _, env := range addArgs.Envs {
+		var err error
+		env.EnvVar, err = encryptEnv(env.EnvVar)
+		if err != nil {
+			return err
+		}
+		app.ServiceEnvs = append(app.ServiceEnvs, env)
+	}
 	conn, err := db.Conn()
 	if err != nil {
 		return err
@@ -2077,7 +2381,11 @@ func Swap(app1, app2 *App, cnameOnly bool) error {
 	if err != nil {
 		return err
 	}
-	return updateCName(app2, r2)
+	err = updateCName(app2, r2)
+	if err != nil {
+		return err
+	}
+	return revalidatePathRoutes(append(append([]string{}, app1.CName...), app2.CName...)...)
 }
 
 // Start starts the app calling the provisioner.Start method and
@@ -2089,6 +2397,10 @@ func (app *App) Start(w io.Writer, process string) error {
 		msg = fmt.Sprintf("\n ---> Starting the app %q", app.Name)
 	}
 	fmt.Fprintf(w, "%s\n", msg)
+	err := app.checkEnvs()
+	if err != nil {
+		return err
+	}
 	prov, err := app.getProvisioner()
 	if err != nil {
 		return err
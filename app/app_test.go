@@ -14,6 +14,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"sync"
@@ -24,6 +25,7 @@ import (
 	"github.com/globalsign/mgo/bson"
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/app/envcrypt"
 	"github.com/tsuru/tsuru/app/image"
 	"github.com/tsuru/tsuru/auth"
 	"github.com/tsuru/tsuru/errors"
@@ -5550,3 +5552,143 @@ func (s *S) TestGetUUID(c *check.C) {
 	c.Assert(storedApp.UUID, check.Not(check.DeepEquals), "")
 	c.Assert(storedApp.UUID, check.DeepEquals, uuid)
 }
+
+func enableEnvEncryption(c *check.C) func() {
+	config.Set("env-encryption:provider", "keyfile")
+	config.Set("env-encryption:keyfile:path", filepath.Join(c.MkDir(), "keys.yaml"))
+	_, err := envcrypt.GenerateFirstKey()
+	c.Assert(err, check.IsNil)
+	return func() {
+		config.Unset("env-encryption")
+		envcrypt.Reset()
+	}
+}
+
+func (s *S) TestSetEnvsEncrypted(c *check.C) {
+	defer enableEnvEncryption(c)()
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{{Name: "DATABASE_PASSWORD", Value: "secret", Public: false}},
+	})
+	c.Assert(err, check.IsNil)
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	stored := dbApp.Env["DATABASE_PASSWORD"]
+	c.Assert(envcrypt.IsEncrypted(stored.Value), check.Equals, true)
+	c.Assert(stored.Public, check.Equals, false)
+	c.Assert(dbApp.Envs()["DATABASE_PASSWORD"], check.DeepEquals, bind.EnvVar{Name: "DATABASE_PASSWORD", Value: "secret", Public: false})
+}
+
+func (s *S) TestAddInstanceEncrypted(c *check.C) {
+	defer enableEnvEncryption(c)()
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddInstance(bind.AddInstanceArgs{
+		Envs: []bind.ServiceEnvVar{
+			{EnvVar: bind.EnvVar{Name: "DATABASE_HOST", Value: "localhost"}, ServiceName: "mysql", InstanceName: "mydb"},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.ServiceEnvs, check.HasLen, 1)
+	c.Assert(envcrypt.IsEncrypted(dbApp.ServiceEnvs[0].Value), check.Equals, true)
+	c.Assert(dbApp.InstanceEnvs("mysql", "mydb"), check.DeepEquals, map[string]bind.EnvVar{
+		"DATABASE_HOST": {Name: "DATABASE_HOST", Value: "localhost"},
+	})
+	envs := dbApp.Envs()
+	c.Assert(envs["DATABASE_HOST"].Value, check.Equals, "localhost")
+	c.Assert(envs[TsuruServicesEnvVar].Value, check.Equals, `{"mysql":[{"instance_name":"mydb","envs":{"DATABASE_HOST":"localhost"}}]}`)
+}
+
+func (s *S) TestReencryptAllEnvs(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{{Name: "PLAIN", Value: "value", Public: true}},
+	})
+	c.Assert(err, check.IsNil)
+	err = ReencryptAllEnvs(ioutil.Discard)
+	c.Assert(err, check.Equals, envcrypt.ErrNotConfigured)
+	defer enableEnvEncryption(c)()
+	buf := &bytes.Buffer{}
+	err = ReencryptAllEnvs(buf)
+	c.Assert(err, check.IsNil)
+	c.Assert(buf.String(), check.Equals, "Encrypted envs for app \"myapp\".\n")
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(envcrypt.IsEncrypted(dbApp.Env["PLAIN"].Value), check.Equals, true)
+	provider, err := envcrypt.Provider()
+	c.Assert(err, check.IsNil)
+	_, err = provider.(envcrypt.KeyRotator).RotateKey()
+	c.Assert(err, check.IsNil)
+	err = ReencryptAllEnvs(ioutil.Discard)
+	c.Assert(err, check.IsNil)
+	dbApp, err = GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	keyID, _ := envcrypt.KeyID(dbApp.Env["PLAIN"].Value)
+	c.Assert(keyID, check.Equals, "key2")
+	c.Assert(dbApp.Envs()["PLAIN"].Value, check.Equals, "value")
+}
+
+func (s *S) TestReencryptEnvsKeepsConcurrentChanges(c *check.C) {
+	defer enableEnvEncryption(c)()
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	stale, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{{Name: "NEW", Value: "value", Public: true}},
+	})
+	c.Assert(err, check.IsNil)
+	err = stale.ReencryptEnvs()
+	c.Assert(err, check.IsNil)
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.Envs()["NEW"].Value, check.Equals, "value")
+}
+
+func (s *S) TestReencryptEnvsAppLocked(c *check.C) {
+	defer enableEnvEncryption(c)()
+	oldTimeout := reencryptLockTimeout
+	reencryptLockTimeout = 100 * time.Millisecond
+	defer func() { reencryptLockTimeout = oldTimeout }()
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	locked, err := AcquireApplicationLock(a.Name, "someone", "deploy")
+	c.Assert(err, check.IsNil)
+	c.Assert(locked, check.Equals, true)
+	defer ReleaseApplicationLock(a.Name)
+	err = a.ReencryptEnvs()
+	c.Assert(err, check.ErrorMatches, `unable to lock app "myapp" to encrypt its envs`)
+}
+
+func (s *S) TestEnvsNotDecryptable(c *check.C) {
+	defer enableEnvEncryption(c)()
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	s.provisioner.AddUnits(&a, 1, "web", nil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{{Name: "DATABASE_PASSWORD", Value: "secret", Public: false}},
+	})
+	c.Assert(err, check.IsNil)
+	config.Set("env-encryption:keyfile:path", filepath.Join(c.MkDir(), "keys.yaml"))
+	_, err = envcrypt.GenerateFirstKey()
+	c.Assert(err, check.IsNil)
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	_, ok := dbApp.Envs()["DATABASE_PASSWORD"]
+	c.Assert(ok, check.Equals, false)
+	_, err = dbApp.DecryptedEnvs()
+	c.Assert(err, check.ErrorMatches, `unable to decrypt env "DATABASE_PASSWORD": .*`)
+	err = dbApp.Restart("", ioutil.Discard)
+	c.Assert(err, check.ErrorMatches, `unable to decrypt env "DATABASE_PASSWORD": .*`)
+	c.Assert(s.provisioner.Restarts(dbApp, ""), check.Equals, 0)
+}
@@ -0,0 +1,343 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/router"
+)
+
+const (
+	// AutoScaleMetricCPU scales units by their average cpu usage, the
+	// target is a percentage of one core.
+	AutoScaleMetricCPU = "cpu"
+	// AutoScaleMetricMemory scales units by their average memory usage,
+	// the target is in megabytes.
+	AutoScaleMetricMemory = "memory"
+	// AutoScaleMetricRequests scales units by the requests per second
+	// received by each unit, as reported by the app routers.
+	AutoScaleMetricRequests = "rps"
+
+	defaultAutoScaleCooldown = 5 * time.Minute
+)
+
+var ErrAutoScaleNotFound = errors.New("autoscale not found")
+
+// AutoScale describes how the number of units of an app process changes
+// according to a target metric. When the provisioner of the app is able to
+// scale units natively, cpu and memory settings are delegated to it.
+type AutoScale struct {
+	App      string  `json:"app"`
+	Process  string  `json:"process"`
+	MinUnits uint    `json:"minUnits"`
+	MaxUnits uint    `json:"maxUnits"`
+	Metric   string  `json:"metric"`
+	Target   float64 `json:"target"`
+	// ScaleUpCooldown and ScaleDownCooldown are the minimum times, in
+	// seconds, between a scale operation and the next scale up or down.
+	ScaleUpCooldown   int       `json:"scaleUpCooldown"`
+	ScaleDownCooldown int       `json:"scaleDownCooldown"`
+	LastScale         time.Time `json:"lastScale"`
+	Native            bool      `json:"native"`
+}
+
+func (as *AutoScale) validate(a *App) error {
+	switch as.Metric {
+	case AutoScaleMetricCPU, AutoScaleMetricMemory, AutoScaleMetricRequests:
+	default:
+		return &tsuruErrors.ValidationError{
+			Message: fmt.Sprintf("invalid metric %q, must be one of: %s, %s, %s", as.Metric,
+				AutoScaleMetricCPU, AutoScaleMetricMemory, AutoScaleMetricRequests),
+		}
+	}
+	if as.MinUnits == 0 {
+		return &tsuruErrors.ValidationError{Message: "minimum units must be greater than 0"}
+	}
+	if as.MaxUnits < as.MinUnits {
+		return &tsuruErrors.ValidationError{Message: "maximum units must be greater than or equal to minimum units"}
+	}
+	if as.Target <= 0 {
+		return &tsuruErrors.ValidationError{Message: "target must be greater than 0"}
+	}
+	if as.ScaleUpCooldown < 0 || as.ScaleDownCooldown < 0 {
+		return &tsuruErrors.ValidationError{Message: "cooldown must not be negative"}
+	}
+	processes, err := image.AllAppProcesses(a.Name)
+	if err != nil {
+		return &tsuruErrors.ValidationError{Message: "app must be deployed before setting autoscale"}
+	}
+	found := false
+	for _, p := range processes {
+		if p == as.Process {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return &tsuruErrors.ValidationError{Message: fmt.Sprintf("process %q not found in app", as.Process)}
+	}
+	if as.Metric == AutoScaleMetricRequests {
+		imgName, err := image.AppCurrentImageName(a.Name)
+		if err != nil {
+			return err
+		}
+		webProcess, err := image.GetImageWebProcessName(imgName)
+		if err != nil {
+			return err
+		}
+		if webProcess != as.Process {
+			return &tsuruErrors.ValidationError{
+				Message: fmt.Sprintf("metric %q is only available for the web process %q", as.Metric, webProcess),
+			}
+		}
+		statsRouters, err := a.statsRouters()
+		if err != nil {
+			return err
+		}
+		if len(statsRouters) == 0 {
+			return &tsuruErrors.ValidationError{
+				Message: fmt.Sprintf("metric %q is not available, no router of the app is able to report requests per second", as.Metric),
+			}
+		}
+	}
+	return nil
+}
+
+// Cooldown returns the minimum time between the last scale operation and a
+// new one in the given direction.
+func (as *AutoScale) Cooldown(scaleUp bool) time.Duration {
+	seconds := as.ScaleDownCooldown
+	if scaleUp {
+		seconds = as.ScaleUpCooldown
+	}
+	if seconds == 0 {
+		return defaultAutoScaleCooldown
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (as *AutoScale) nativeSpec() (provision.AutoScaleSpec, bool) {
+	spec := provision.AutoScaleSpec{
+		Process:  as.Process,
+		MinUnits: as.MinUnits,
+		MaxUnits: as.MaxUnits,
+	}
+	switch as.Metric {
+	case AutoScaleMetricCPU:
+		spec.CPU = int(as.Target)
+	case AutoScaleMetricMemory:
+		spec.Memory = int64(as.Target * 1024 * 1024)
+	default:
+		return spec, false
+	}
+	return spec, true
+}
+
+// SetAutoScale enables or updates the unit autoscale of an app process.
+func (app *App) SetAutoScale(as AutoScale) error {
+	as.App = app.Name
+	err := as.validate(app)
+	if err != nil {
+		return err
+	}
+	prov, err := app.getProvisioner()
+	if err != nil {
+		return err
+	}
+	old, err := app.GetAutoScale(as.Process)
+	if err != nil && err != ErrAutoScaleNotFound {
+		return err
+	}
+	if old != nil {
+		as.LastScale = old.LastScale
+	}
+	if asProv, ok := prov.(provision.AutoScaleProvisioner); ok {
+		spec, native := as.nativeSpec()
+		if native {
+			err = asProv.SetAutoScale(app, spec)
+		} else if old != nil && old.Native {
+			err = asProv.RemoveAutoScale(app, as.Process)
+		}
+		if err != nil {
+			return err
+		}
+		as.Native = native
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.AppAutoScales().Upsert(bson.M{"app": app.Name, "process": as.Process}, as)
+	return err
+}
+
+// AutoScales returns the unit autoscale settings of the app processes.
+func (app *App) AutoScales() ([]AutoScale, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var autoScales []AutoScale
+	err = conn.AppAutoScales().Find(bson.M{"app": app.Name}).Sort("process").All(&autoScales)
+	if err != nil {
+		return nil, err
+	}
+	return autoScales, nil
+}
+
+// GetAutoScale returns the unit autoscale settings of an app process.
+func (app *App) GetAutoScale(process string) (*AutoScale, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var as AutoScale
+	err = conn.AppAutoScales().Find(bson.M{"app": app.Name, "process": process}).One(&as)
+	if err == mgo.ErrNotFound {
+		return nil, ErrAutoScaleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &as, nil
+}
+
+// RemoveAutoScale disables the unit autoscale of an app process, keeping the
+// current number of units.
+func (app *App) RemoveAutoScale(process string) error {
+	as, err := app.GetAutoScale(process)
+	if err != nil {
+		return err
+	}
+	if as.Native {
+		prov, err := app.getProvisioner()
+		if err != nil {
+			return err
+		}
+		if asProv, ok := prov.(provision.AutoScaleProvisioner); ok {
+			err = asProv.RemoveAutoScale(app, process)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.AppAutoScales().Remove(bson.M{"app": app.Name, "process": process})
+}
+
+func (app *App) removeAutoScales() error {
+	autoScales, err := app.AutoScales()
+	if err != nil {
+		return err
+	}
+	for _, as := range autoScales {
+		err = app.RemoveAutoScale(as.Process)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListAutoScales returns the unit autoscale settings of all apps that are
+// scaled by tsuru itself.
+func ListAutoScales() ([]AutoScale, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var autoScales []AutoScale
+	err = conn.AppAutoScales().Find(bson.M{"native": false}).Sort("app", "process").All(&autoScales)
+	if err != nil {
+		return nil, err
+	}
+	return autoScales, nil
+}
+
+// ClaimAutoScale records now as the time of the last scale operation in the
+// autoscale of an app process, as long as it's still last. It returns false
+// when the process was scaled since last, by another tsuru API instance.
+func ClaimAutoScale(appName, process string, last, now time.Time) (bool, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	err = conn.AppAutoScales().Update(
+		bson.M{"app": appName, "process": process, "lastscale": last},
+		bson.M{"$set": bson.M{"lastscale": now}},
+	)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// UnitsMetrics returns the resource usage of the units of an app process.
+func (app *App) UnitsMetrics(process string) ([]provision.UnitMetrics, error) {
+	prov, err := app.getProvisioner()
+	if err != nil {
+		return nil, err
+	}
+	metricsProv, ok := prov.(provision.MetricsProvisioner)
+	if !ok {
+		return nil, provision.ProvisionerNotSupported{Prov: prov, Action: "reporting unit metrics"}
+	}
+	return metricsProv.UnitsMetrics(app, process)
+}
+
+// statsRouters returns the routers of the app able to report traffic
+// statistics.
+func (app *App) statsRouters() ([]router.StatsRouter, error) {
+	var statsRouters []router.StatsRouter
+	for _, appRouter := range app.GetRouters() {
+		r, err := router.Get(appRouter.Name)
+		if err != nil {
+			return nil, err
+		}
+		if statsRouter, ok := r.(router.StatsRouter); ok {
+			statsRouters = append(statsRouters, statsRouter)
+		}
+	}
+	return statsRouters, nil
+}
+
+// RequestsPerSecond returns the sum of the requests per second received by
+// the app in all its routers able to report it.
+func (app *App) RequestsPerSecond() (float64, error) {
+	statsRouters, err := app.statsRouters()
+	if err != nil {
+		return 0, err
+	}
+	if len(statsRouters) == 0 {
+		return 0, errors.Errorf("no router of app %q is able to report requests per second", app.Name)
+	}
+	var total float64
+	for _, statsRouter := range statsRouters {
+		rps, err := statsRouter.RequestsPerSecond(app.Name)
+		if err != nil {
+			return 0, err
+		}
+		total += rps
+	}
+	return total, nil
+}
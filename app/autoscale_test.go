@@ -0,0 +1,141 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"github.com/tsuru/tsuru/app/image"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	"github.com/tsuru/tsuru/router/routertest"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) newAutoScaleApp(c *check.C) *App {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name, Router: "fake-stats"}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	imgName := "tsuru/app-myapp:v1"
+	err = image.AppendAppImageName(a.Name, imgName)
+	c.Assert(err, check.IsNil)
+	err = image.SaveImageCustomData(imgName, map[string]interface{}{
+		"processes": map[string][]string{"web": {"./web"}, "worker": {"./worker"}},
+	})
+	c.Assert(err, check.IsNil)
+	return &a
+}
+
+func (s *S) TestSetAutoScale(c *check.C) {
+	a := s.newAutoScaleApp(c)
+	err := a.SetAutoScale(AutoScale{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: AutoScaleMetricCPU, Target: 70})
+	c.Assert(err, check.IsNil)
+	err = a.SetAutoScale(AutoScale{Process: "web", MinUnits: 2, MaxUnits: 10, Metric: AutoScaleMetricRequests, Target: 100})
+	c.Assert(err, check.IsNil)
+	autoScales, err := a.AutoScales()
+	c.Assert(err, check.IsNil)
+	c.Assert(autoScales, check.HasLen, 1)
+	c.Assert(autoScales[0], check.DeepEquals, AutoScale{
+		App:      a.Name,
+		Process:  "web",
+		MinUnits: 2,
+		MaxUnits: 10,
+		Metric:   AutoScaleMetricRequests,
+		Target:   100,
+	})
+	all, err := ListAutoScales()
+	c.Assert(err, check.IsNil)
+	c.Assert(all, check.HasLen, 1)
+}
+
+func (s *S) TestSetAutoScaleInvalid(c *check.C) {
+	a := s.newAutoScaleApp(c)
+	tests := []AutoScale{
+		{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: "disk", Target: 70},
+		{Process: "web", MinUnits: 0, MaxUnits: 5, Metric: AutoScaleMetricCPU, Target: 70},
+		{Process: "web", MinUnits: 5, MaxUnits: 1, Metric: AutoScaleMetricCPU, Target: 70},
+		{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: AutoScaleMetricCPU, Target: 0},
+		{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: AutoScaleMetricCPU, Target: 70, ScaleUpCooldown: -1},
+		{Process: "other", MinUnits: 1, MaxUnits: 5, Metric: AutoScaleMetricCPU, Target: 70},
+		{Process: "worker", MinUnits: 1, MaxUnits: 5, Metric: AutoScaleMetricRequests, Target: 70},
+	}
+	for _, as := range tests {
+		err := a.SetAutoScale(as)
+		c.Check(err, check.FitsTypeOf, &tsuruErrors.ValidationError{}, check.Commentf("%#v", as))
+	}
+	autoScales, err := a.AutoScales()
+	c.Assert(err, check.IsNil)
+	c.Assert(autoScales, check.HasLen, 0)
+}
+
+func (s *S) TestSetAutoScaleRequestsWithoutStatsRouter(c *check.C) {
+	a := s.newAutoScaleApp(c)
+	err := a.RemoveRouter("fake-stats")
+	c.Assert(err, check.IsNil)
+	err = a.AddRouter(appTypes.AppRouter{Name: "fake"})
+	c.Assert(err, check.IsNil)
+	err = a.SetAutoScale(AutoScale{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: AutoScaleMetricRequests, Target: 100})
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+	c.Assert(err, check.ErrorMatches, `.*no router of the app is able to report requests per second.*`)
+	err = a.SetAutoScale(AutoScale{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: AutoScaleMetricCPU, Target: 70})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestRequestsPerSecond(c *check.C) {
+	a := s.newAutoScaleApp(c)
+	routertest.StatsRouter.Requests[a.Name] = 42
+	rps, err := a.RequestsPerSecond()
+	c.Assert(err, check.IsNil)
+	c.Assert(rps, check.Equals, 42.0)
+}
+
+func (s *S) TestSetAutoScaleNative(c *check.C) {
+	p := &provisiontest.AutoScaleFakeProvisioner{FakeProvisioner: provisiontest.NewFakeProvisioner()}
+	a := s.newAutoScaleApp(c)
+	a.provisioner = p
+	err := a.SetAutoScale(AutoScale{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: AutoScaleMetricMemory, Target: 256})
+	c.Assert(err, check.IsNil)
+	spec, ok := p.AutoScale(a, "web")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(spec, check.DeepEquals, provision.AutoScaleSpec{Process: "web", MinUnits: 1, MaxUnits: 5, Memory: 256 * 1024 * 1024})
+	dbAs, err := a.GetAutoScale("web")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbAs.Native, check.Equals, true)
+	all, err := ListAutoScales()
+	c.Assert(err, check.IsNil)
+	c.Assert(all, check.HasLen, 0)
+	err = a.SetAutoScale(AutoScale{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: AutoScaleMetricRequests, Target: 10})
+	c.Assert(err, check.IsNil)
+	_, ok = p.AutoScale(a, "web")
+	c.Assert(ok, check.Equals, false)
+	err = a.SetAutoScale(AutoScale{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: AutoScaleMetricCPU, Target: 50})
+	c.Assert(err, check.IsNil)
+	err = a.RemoveAutoScale("web")
+	c.Assert(err, check.IsNil)
+	_, ok = p.AutoScale(a, "web")
+	c.Assert(ok, check.Equals, false)
+}
+
+func (s *S) TestRemoveAutoScale(c *check.C) {
+	a := s.newAutoScaleApp(c)
+	err := a.SetAutoScale(AutoScale{Process: "web", MinUnits: 1, MaxUnits: 5, Metric: AutoScaleMetricCPU, Target: 70})
+	c.Assert(err, check.IsNil)
+	err = a.RemoveAutoScale("web")
+	c.Assert(err, check.IsNil)
+	_, err = a.GetAutoScale("web")
+	c.Assert(err, check.Equals, ErrAutoScaleNotFound)
+	err = a.RemoveAutoScale("web")
+	c.Assert(err, check.Equals, ErrAutoScaleNotFound)
+}
+
+func (s *S) TestUnitsMetrics(c *check.C) {
+	a := s.newAutoScaleApp(c)
+	metrics := []provision.UnitMetrics{{ID: "u1", CPU: 50, Memory: 1024}}
+	err := s.provisioner.SetUnitsMetrics(a, "web", metrics)
+	c.Assert(err, check.IsNil)
+	result, err := a.UnitsMetrics("web")
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, metrics)
+}
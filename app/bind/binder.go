@@ -51,12 +51,14 @@ type SetEnvArgs struct {
 	Envs          []EnvVar
 	Writer        io.Writer
 	ShouldRestart bool
+	Owner         string
 }
 
 type UnsetEnvArgs struct {
 	VariableNames []string
 	Writer        io.Writer
 	ShouldRestart bool
+	Owner         string
 }
 
 type AddInstanceArgs struct {
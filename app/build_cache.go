@@ -0,0 +1,59 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/builder"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/registry"
+)
+
+// BuildCache returns the persistent build cache of the app.
+func (app *App) BuildCache() (*image.BuildCache, error) {
+	return image.GetAppBuildCache(app.Name)
+}
+
+// SetBuildCache enables or disables the persistent build cache of the app.
+// The cache can only be enabled when the builder of the app is able to use
+// it. Disabling the cache doesn't remove the cache image right away, it's
+// removed by the image garbage collector.
+func (app *App) SetBuildCache(enabled bool) error {
+	if enabled {
+		b, err := app.getBuilder()
+		if err != nil {
+			return err
+		}
+		if cacheBuilder, ok := b.(builder.BuildCacheBuilder); !ok || !cacheBuilder.SupportsBuildCache() {
+			return &tsuruErrors.ValidationError{
+				Message: fmt.Sprintf("build cache is not supported by the builder %q of the app", builder.NameOf(b)),
+			}
+		}
+	}
+	return image.SetAppBuildCacheEnabled(app.Name, enabled)
+}
+
+// PurgeBuildCache removes the cache image of the app from the provisioner and
+// from the registry, so the next build starts with an empty cache.
+func (app *App) PurgeBuildCache() error {
+	cache, err := app.BuildCache()
+	if err != nil {
+		return err
+	}
+	if cache.Image == "" {
+		return nil
+	}
+	err = app.CleanImage(cache.Image)
+	if err != nil {
+		return err
+	}
+	err = registry.RemoveImageIgnoreNotFound(cache.Image)
+	if err != nil {
+		return err
+	}
+	return image.RemoveAppBuildCacheImage(app.Name)
+}
@@ -0,0 +1,283 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app/image"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/router"
+)
+
+var defaultCanarySteps = []int{10, 50, 100}
+
+// CanaryOptions configures a canary deploy, where the new image runs as a
+// separate set of units and receives an increasing share of the traffic, one
+// step at a time. A single step of 100 results in a blue/green deploy.
+type CanaryOptions struct {
+	Steps    []int         `json:"steps"`
+	Interval time.Duration `json:"interval"`
+}
+
+func (o *CanaryOptions) validate() error {
+	if len(o.Steps) == 0 {
+		o.Steps = defaultCanarySteps
+	}
+	last := 0
+	for _, step := range o.Steps {
+		if step <= last || !router.ValidWeight(step) {
+			return errors.Errorf("invalid canary steps %v: each step must be greater than the previous one and at most 100", o.Steps)
+		}
+		last = step
+	}
+	if last != 100 {
+		o.Steps = append(o.Steps, 100)
+	}
+	return nil
+}
+
+type canaryBackend struct {
+	*App
+}
+
+func (b canaryBackend) GetName() string {
+	return router.CanaryBackendName(b.App.Name)
+}
+
+type canaryDeploy struct {
+	app       *App
+	opts      *CanaryOptions
+	prov      provision.CanaryDeployer
+	evt       *event.Event
+	routers   []router.Router
+	backend   canaryBackend
+	prevImage string
+}
+
+// ValidateCanaryDeploy checks whether canary deploys can run for the app,
+// which requires a provisioner able to run canary units and only routers
+// supporting weighted traffic splitting.
+func (app *App) ValidateCanaryDeploy() error {
+	prov, err := app.getProvisioner()
+	if err != nil {
+		return err
+	}
+	_, _, _, err = canaryProvisioner(prov)
+	if err != nil {
+		return err
+	}
+	_, err = app.canaryRouters()
+	return err
+}
+
+func canaryProvisioner(prov provision.Provisioner) (provision.BuilderDeploy, provision.CanaryDeployer, provision.RollbackableDeployer, error) {
+	deployer, ok := prov.(provision.BuilderDeploy)
+	if !ok {
+		return nil, nil, nil, provision.ProvisionerNotSupported{Prov: prov, Action: "canary deploys"}
+	}
+	canaryProv, ok := prov.(provision.CanaryDeployer)
+	if !ok {
+		return nil, nil, nil, provision.ProvisionerNotSupported{Prov: prov, Action: "canary deploys"}
+	}
+	rollbackProv, ok := prov.(provision.RollbackableDeployer)
+	if !ok {
+		return nil, nil, nil, provision.ProvisionerNotSupported{Prov: prov, Action: "canary deploys"}
+	}
+	return deployer, canaryProv, rollbackProv, nil
+}
+
+func (app *App) canaryRouters() ([]router.Router, error) {
+	var routers []router.Router
+	for _, appRouter := range app.GetRouters() {
+		r, err := router.Get(appRouter.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := r.(router.WeightedRouter); !ok {
+			return nil, errors.Errorf("router %q does not support weighted traffic splitting", appRouter.Name)
+		}
+		routers = append(routers, r)
+	}
+	return routers, nil
+}
+
+func canaryDeployToProvisioner(prov provision.Provisioner, opts *DeployOptions, evt *event.Event) (string, error) {
+	deployer, canaryProv, rollbackProv, err := canaryProvisioner(prov)
+	if err != nil {
+		return "", err
+	}
+	err = opts.Canary.validate()
+	if err != nil {
+		return "", err
+	}
+	routers, err := opts.App.canaryRouters()
+	if err != nil {
+		return "", err
+	}
+	d := &canaryDeploy{
+		app:     opts.App,
+		opts:    opts.Canary,
+		prov:    canaryProv,
+		evt:     evt,
+		routers: routers,
+		backend: canaryBackend{App: opts.App},
+	}
+	d.prevImage, err = image.AppCurrentImageName(opts.App.Name)
+	if err != nil {
+		return "", errors.Wrap(err, "canary deploy requires a previously deployed image")
+	}
+	imageID, err := builderDeploy(deployer, opts, evt)
+	if err != nil {
+		return "", err
+	}
+	err = d.start(imageID)
+	if err == nil {
+		evt.StepStarted(progressPhaseRouter, "shift-traffic")
+		err = d.shiftTraffic()
+		evt.StepFinished(progressPhaseRouter, "shift-traffic", err)
+	}
+	if err != nil {
+		d.abort(rollbackProv)
+		return "", err
+	}
+	fmt.Fprintf(evt, "\n---- Promoting canary image %q ----\n", imageID)
+	evt.StepStarted(progressPhaseProvisioner, prov.GetName())
+	result, err := deployer.Deploy(opts.App, imageID, evt)
+	evt.StepFinished(progressPhaseProvisioner, prov.GetName(), err)
+	if err != nil {
+		d.abort(rollbackProv)
+		return "", err
+	}
+	err = d.cleanup()
+	if err != nil {
+		log.Errorf("[canary deploy] unable to remove canary for app %q: %v", opts.App.Name, err)
+	}
+	return result, nil
+}
+
+func (d *canaryDeploy) start(imageID string) error {
+	fmt.Fprintf(d.evt, "\n---- Starting canary units for image %q ----\n", imageID)
+	units, err := d.prov.DeployCanary(d.app, imageID, d.evt)
+	if err != nil {
+		return err
+	}
+	addrs := make([]*url.URL, 0, len(units))
+	for _, u := range units {
+		if u.Address != nil {
+			addrs = append(addrs, u.Address)
+		}
+	}
+	for _, r := range d.routers {
+		err = r.AddBackend(d.backend)
+		if err != nil && err != router.ErrBackendExists {
+			return err
+		}
+		err = r.AddRoutes(d.backend.GetName(), addrs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *canaryDeploy) shiftTraffic() error {
+	for _, weight := range d.opts.Steps {
+		fmt.Fprintf(d.evt, " ---> Routing %d%% of traffic to canary units\n", weight)
+		err := d.setWeight(weight)
+		if err != nil {
+			return err
+		}
+		time.Sleep(d.opts.Interval)
+		err = d.checkHealth()
+		if err != nil {
+			return errors.Wrapf(err, "canary degraded with %d%% of traffic", weight)
+		}
+	}
+	return nil
+}
+
+func (d *canaryDeploy) setWeight(weight int) error {
+	for _, r := range d.routers {
+		err := r.(router.WeightedRouter).SetWeight(d.app.Name, d.backend.GetName(), weight)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *canaryDeploy) checkHealth() error {
+	units, err := d.prov.CanaryUnits(d.app)
+	if err != nil {
+		return err
+	}
+	if len(units) == 0 {
+		return errors.New("no canary units running")
+	}
+	for _, u := range units {
+		if u.Status != provision.StatusStarted && u.Status != provision.StatusStarting {
+			return errors.Errorf("unit %q is %s", u.ID, u.Status)
+		}
+	}
+	for _, r := range d.routers {
+		statusRouter, ok := r.(router.StatusRouter)
+		if !ok {
+			continue
+		}
+		status, detail, err := statusRouter.GetBackendStatus(d.backend.GetName())
+		if err != nil {
+			return err
+		}
+		if status != router.BackendStatusReady {
+			return errors.Errorf("router %q reports canary backend as %s: %s", r.GetName(), status, detail)
+		}
+	}
+	return nil
+}
+
+// cleanup removes the canary from the routers and the provisioner. It runs
+// every step even when some of them fail, returning all the errors.
+func (d *canaryDeploy) cleanup() error {
+	multi := tsuruErrors.NewMultiError()
+	for _, r := range d.routers {
+		err := r.(router.WeightedRouter).SetWeight(d.app.Name, d.backend.GetName(), 0)
+		if err != nil && err != router.ErrBackendNotFound {
+			multi.Add(err)
+		}
+		err = r.RemoveBackend(d.backend.GetName())
+		if err != nil && err != router.ErrBackendNotFound {
+			multi.Add(err)
+		}
+	}
+	err := router.Remove(d.backend.GetName())
+	if err != nil && err != mgo.ErrNotFound {
+		multi.Add(err)
+	}
+	err = d.prov.RemoveCanary(d.app, d.evt)
+	if err != nil {
+		multi.Add(err)
+	}
+	return multi.ToError()
+}
+
+func (d *canaryDeploy) abort(prov provision.RollbackableDeployer) {
+	fmt.Fprintf(d.evt, "\n---- Canary failed, rolling back to image %q ----\n", d.prevImage)
+	err := d.cleanup()
+	if err != nil {
+		log.Errorf("[canary deploy] unable to remove canary for app %q: %v", d.app.Name, err)
+	}
+	_, err = prov.Rollback(d.app, d.prevImage, d.evt)
+	if err != nil {
+		log.Errorf("[canary deploy] unable to rollback app %q to image %q: %v", d.app.Name, d.prevImage, err)
+	}
+}
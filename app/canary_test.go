@@ -0,0 +1,151 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/tsuru/tsuru/app/image"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/router/routertest"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) newCanaryApp(c *check.C, routerName string) (*App, *event.Event) {
+	a := App{
+		Name:      "myapp",
+		Platform:  "zend",
+		Teams:     []string{s.team.Name},
+		TeamOwner: s.team.Name,
+		Router:    routerName,
+	}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(a.Name, "registry.somewhere/tsuru/app-myapp:v1")
+	c.Assert(err, check.IsNil)
+	s.provisioner.AddUnits(&a, 2, "web", nil)
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: a.Name},
+		Kind:     permission.PermAppDeploy,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	return &a, evt
+}
+
+func (s *S) TestCanaryOptionsValidate(c *check.C) {
+	opts := CanaryOptions{}
+	c.Assert(opts.validate(), check.IsNil)
+	c.Assert(opts.Steps, check.DeepEquals, []int{10, 50, 100})
+	opts = CanaryOptions{Steps: []int{25, 50}}
+	c.Assert(opts.validate(), check.IsNil)
+	c.Assert(opts.Steps, check.DeepEquals, []int{25, 50, 100})
+	opts = CanaryOptions{Steps: []int{50, 25}}
+	c.Assert(opts.validate(), check.ErrorMatches, `invalid canary steps .*`)
+	opts = CanaryOptions{Steps: []int{50, 120}}
+	c.Assert(opts.validate(), check.ErrorMatches, `invalid canary steps .*`)
+}
+
+func (s *S) TestDeployCanary(c *check.C) {
+	a, evt := s.newCanaryApp(c, "fake-weighted")
+	writer := &bytes.Buffer{}
+	_, err := Deploy(DeployOptions{
+		App:          a,
+		Image:        "myimage",
+		OutputStream: writer,
+		Event:        evt,
+		Canary:       &CanaryOptions{Steps: []int{20, 50}},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(routertest.WeightedRouter.History[a.Name], check.DeepEquals, []int{20, 50, 100, 0})
+	c.Assert(routertest.WeightedRouter.HasBackend(a.Name+"-canary"), check.Equals, false)
+	c.Assert(s.provisioner.CanaryImage(a), check.Equals, "")
+	c.Assert(writer.String(), check.Matches, `(?s).*Routing 50% of traffic to canary units.*Promoting canary image.*`)
+	c.Assert(writer.String(), check.Not(check.Matches), `(?s).*Rollback deploy called.*`)
+}
+
+func (s *S) TestDeployCanaryRollbackWhenDegraded(c *check.C) {
+	a, evt := s.newCanaryApp(c, "fake-weighted")
+	writer := &bytes.Buffer{}
+	s.provisioner.PrepareFailure("CanaryUnits", errors.New("units are gone"))
+	_, err := Deploy(DeployOptions{
+		App:          a,
+		Image:        "myimage",
+		OutputStream: writer,
+		Event:        evt,
+		Canary:       &CanaryOptions{Steps: []int{20, 50}},
+	})
+	c.Assert(err, check.ErrorMatches, `(?s).*canary degraded with 20% of traffic: units are gone.*`)
+	c.Assert(routertest.WeightedRouter.History[a.Name], check.DeepEquals, []int{20, 0})
+	c.Assert(routertest.WeightedRouter.HasBackend(a.Name+"-canary"), check.Equals, false)
+	c.Assert(s.provisioner.CanaryImage(a), check.Equals, "")
+	c.Assert(writer.String(), check.Matches, `(?s).*Canary failed, rolling back to image "registry.somewhere/tsuru/app-myapp:v1".*Rollback deploy called.*`)
+}
+
+func (s *S) TestDeployCanaryRouterNotWeighted(c *check.C) {
+	a, evt := s.newCanaryApp(c, "fake")
+	_, err := Deploy(DeployOptions{
+		App:          a,
+		Image:        "myimage",
+		OutputStream: &bytes.Buffer{},
+		Event:        evt,
+		Canary:       &CanaryOptions{},
+	})
+	c.Assert(err, check.ErrorMatches, `(?s).*router "fake" does not support weighted traffic splitting.*`)
+}
+
+func (s *S) TestCanaryCleanupRunsAllSteps(c *check.C) {
+	a, evt := s.newCanaryApp(c, "fake-weighted")
+	routers, err := a.canaryRouters()
+	c.Assert(err, check.IsNil)
+	d := &canaryDeploy{
+		app:     a,
+		opts:    &CanaryOptions{},
+		prov:    s.provisioner,
+		evt:     evt,
+		routers: routers,
+		backend: canaryBackend{App: a},
+	}
+	err = d.start("registry.somewhere/tsuru/app-myapp:v2")
+	c.Assert(err, check.IsNil)
+	c.Assert(s.provisioner.CanaryImage(a), check.Equals, "registry.somewhere/tsuru/app-myapp:v2")
+	routertest.WeightedRouter.FailForIp(a.Name + "-canary")
+	defer routertest.WeightedRouter.RemoveFailForIp(a.Name + "-canary")
+	err = d.cleanup()
+	c.Assert(err, check.NotNil)
+	multi, ok := err.(*tsuruErrors.MultiError)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(multi.Len(), check.Equals, 2)
+	c.Assert(s.provisioner.CanaryImage(a), check.Equals, "")
+}
+
+type nonCanaryProvisioner struct {
+	provision.Provisioner
+}
+
+func (s *S) TestValidateCanaryDeploy(c *check.C) {
+	a, _ := s.newCanaryApp(c, "fake-weighted")
+	c.Assert(a.ValidateCanaryDeploy(), check.IsNil)
+	a.Router = "fake"
+	a.Routers = nil
+	c.Assert(a.ValidateCanaryDeploy(), check.ErrorMatches, `router "fake" does not support weighted traffic splitting`)
+}
+
+func (s *S) TestValidateCanaryDeployProvisionerNotSupported(c *check.C) {
+	provision.Register("non-canary", func() (provision.Provisioner, error) {
+		return &nonCanaryProvisioner{Provisioner: s.provisioner}, nil
+	})
+	defer provision.Unregister("non-canary")
+	oldProvisioner := provision.DefaultProvisioner
+	provision.DefaultProvisioner = "non-canary"
+	defer func() { provision.DefaultProvisioner = oldProvisioner }()
+	a := App{Name: "myapp", Platform: "zend", TeamOwner: s.team.Name, Router: "fake-weighted"}
+	c.Assert(a.ValidateCanaryDeploy(), check.ErrorMatches, `provisioner "fake" does not support canary deploys`)
+}
@@ -0,0 +1,280 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/api/shutdown"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/router"
+)
+
+const CertificateExpiringEventKind = "certificate-expiring"
+
+var (
+	certificateExpirySeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tsuru_certificate_expiry_seconds",
+		Help: "The number of seconds until the expiration of certificates expiring within the configured window, negative for expired ones.",
+	}, []string{"app", "router", "cname"})
+
+	certificatesExpiring = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tsuru_certificates_expiring",
+		Help: "The number of certificates expiring within the configured window found in the last check.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(certificateExpirySeconds, certificatesExpiring)
+}
+
+// CertificateInfo describes the certificate installed in a router for a name
+// of an app. Error is set when the certificate couldn't be retrieved or
+// parsed.
+type CertificateInfo struct {
+	App       string    `json:"app"`
+	Router    string    `json:"router"`
+	CName     string    `json:"cname"`
+	Subject   string    `json:"subject,omitempty"`
+	DNSNames  []string  `json:"dnsNames,omitempty"`
+	Issuer    string    `json:"issuer,omitempty"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func parseCertificateInfo(info *CertificateInfo, data string) error {
+	var block *pem.Block
+	rest := []byte(data)
+	for {
+		block, rest = pem.Decode(rest)
+		if block == nil || block.Type == "CERTIFICATE" {
+			break
+		}
+	}
+	if block == nil {
+		return errors.New("no certificate found in PEM data")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	info.Subject = cert.Subject.String()
+	info.DNSNames = cert.DNSNames
+	info.Issuer = cert.Issuer.String()
+	info.NotBefore = cert.NotBefore.UTC()
+	info.NotAfter = cert.NotAfter.UTC()
+	return nil
+}
+
+// CertificatesInfo returns the certificates installed for the addresses and
+// cnames of the app in all its routers supporting TLS.
+func (app *App) CertificatesInfo() ([]CertificateInfo, error) {
+	addrs, err := app.GetAddresses()
+	if err != nil {
+		return nil, err
+	}
+	names := append(addrs, app.CName...)
+	var result []CertificateInfo
+	for _, appRouter := range app.GetRouters() {
+		r, err := router.Get(appRouter.Name)
+		if err != nil {
+			return nil, err
+		}
+		tlsRouter, ok := r.(router.TLSRouter)
+		if !ok {
+			continue
+		}
+		for _, n := range names {
+			cert, err := tlsRouter.GetCertificate(app, n)
+			if err == router.ErrCertificateNotFound || (err == nil && cert == "") {
+				continue
+			}
+			info := CertificateInfo{App: app.Name, Router: appRouter.Name, CName: n}
+			if err == nil {
+				err = parseCertificateInfo(&info, cert)
+			}
+			if err != nil {
+				info.Error = err.Error()
+			}
+			result = append(result, info)
+		}
+	}
+	return result, nil
+}
+
+// CertificateInventory returns the certificates of all apps matching filter,
+// sorted by expiration date. Apps whose certificates couldn't be listed are
+// reported with the Error field set.
+func CertificateInventory(filter *Filter) ([]CertificateInfo, error) {
+	apps, err := List(filter)
+	if err != nil {
+		return nil, err
+	}
+	var result []CertificateInfo
+	for i := range apps {
+		infos, err := apps[i].CertificatesInfo()
+		if err != nil {
+			result = append(result, CertificateInfo{App: apps[i].Name, Error: err.Error()})
+			continue
+		}
+		result = append(result, infos...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].NotAfter.Before(result[j].NotAfter)
+	})
+	return result, nil
+}
+
+// InitializeCertificateChecker starts the checker looking for certificates
+// expiring within certificate-checker:expiry-window. It exports prometheus
+// gauges for them and records an event, once per certificate, in the app
+// they belong to.
+func InitializeCertificateChecker() error {
+	disabled, _ := config.GetBool("certificate-checker:disabled")
+	if disabled {
+		return nil
+	}
+	checker := &certificateChecker{}
+	checker.interval, _ = config.GetDuration("certificate-checker:interval")
+	if checker.interval <= 0 {
+		checker.interval = time.Hour
+	}
+	checker.window, _ = config.GetDuration("certificate-checker:expiry-window")
+	if checker.window <= 0 {
+		checker.window = 30 * 24 * time.Hour
+	}
+	checker.start()
+	shutdown.Register(checker)
+	return nil
+}
+
+type certificateChecker struct {
+	interval time.Duration
+	window   time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (c *certificateChecker) start() {
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		for {
+			err := c.check(time.Now().UTC())
+			if err != nil {
+				log.Errorf("[certificate checker] error checking certificates: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.interval):
+			}
+		}
+	}()
+}
+
+func (c *certificateChecker) Shutdown(ctx context.Context) error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (c *certificateChecker) String() string {
+	return "certificate checker"
+}
+
+func (c *certificateChecker) check(now time.Time) error {
+	infos, err := CertificateInventory(nil)
+	if err != nil {
+		return err
+	}
+	certificateExpirySeconds.Reset()
+	var expiring int
+	for i := range infos {
+		info := &infos[i]
+		if info.Error != "" {
+			log.Errorf("[certificate checker] unable to check certificate for %q in app %q: %s", info.CName, info.App, info.Error)
+			continue
+		}
+		if info.NotAfter.After(now.Add(c.window)) {
+			continue
+		}
+		expiring++
+		certificateExpirySeconds.WithLabelValues(info.App, info.Router, info.CName).Set(info.NotAfter.Sub(now).Seconds())
+		err = notifyCertificateExpiring(info)
+		if err != nil {
+			log.Errorf("[certificate checker] unable to notify expiring certificate for %q in app %q: %v", info.CName, info.App, err)
+		}
+	}
+	certificatesExpiring.Set(float64(expiring))
+	return nil
+}
+
+// notifyCertificateExpiring records an event for the expiring certificate,
+// unless one was already recorded for it by any tsuru API instance. The
+// marker claiming the notification is removed if the event can't be created,
+// so it's retried in the next check.
+func notifyCertificateExpiring(info *CertificateInfo) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	id := fmt.Sprintf("%s/%s/%s/%d", info.App, info.Router, info.CName, info.NotAfter.Unix())
+	err = conn.CertificateAlerts().Insert(struct {
+		ID        string `bson:"_id"`
+		CreatedAt time.Time
+	}{ID: id, CreatedAt: time.Now().UTC()})
+	if mgo.IsDup(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	evt, err := newCertificateExpiringEvent(info)
+	if err != nil {
+		if rmErr := conn.CertificateAlerts().RemoveId(id); rmErr != nil {
+			log.Errorf("[certificate checker] unable to remove notification marker %q: %v", id, rmErr)
+		}
+		return err
+	}
+	evt.Logf("certificate for %s in router %s expires at %s", info.CName, info.Router, info.NotAfter.Format(time.RFC3339))
+	return evt.Done(nil)
+}
+
+func newCertificateExpiringEvent(info *CertificateInfo) (*event.Event, error) {
+	a, err := GetByName(info.App)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		InternalKind: CertificateExpiringEventKind,
+		CustomData:   info,
+		Allowed:      a.internalEventAllowed(),
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"io/ioutil"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/router/routertest"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	"gopkg.in/check.v1"
+)
+
+var certificateNotAfter = time.Date(2027, time.January, 10, 20, 33, 11, 0, time.UTC)
+
+func (s *S) createAppWithCertificate(c *check.C) *App {
+	cert, err := ioutil.ReadFile("testdata/certificate.crt")
+	c.Assert(err, check.IsNil)
+	key, err := ioutil.ReadFile("testdata/private.key")
+	c.Assert(err, check.IsNil)
+	a := App{
+		Name:      "my-test-app",
+		TeamOwner: s.team.Name,
+		Routers:   []appTypes.AppRouter{{Name: "fake-tls"}},
+		CName:     []string{"app.io"},
+	}
+	err = CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetCertificate("app.io", string(cert), string(key))
+	c.Assert(err, check.IsNil)
+	return &a
+}
+
+func (s *S) TestCertificatesInfo(c *check.C) {
+	a := s.createAppWithCertificate(c)
+	defer a.RemoveCertificate("app.io")
+	infos, err := a.CertificatesInfo()
+	c.Assert(err, check.IsNil)
+	c.Assert(infos, check.DeepEquals, []CertificateInfo{{
+		App:       a.Name,
+		Router:    "fake-tls",
+		CName:     "app.io",
+		Subject:   "CN=app.io,O=Tsuru,L=Rio de Janeiro,ST=Rio de Janeiro,C=BR",
+		Issuer:    "CN=app.io,O=Tsuru,L=Rio de Janeiro,ST=Rio de Janeiro,C=BR",
+		NotBefore: time.Date(2017, time.January, 12, 20, 33, 11, 0, time.UTC),
+		NotAfter:  certificateNotAfter,
+	}})
+}
+
+func (s *S) TestCertificatesInfoInvalidCertificate(c *check.C) {
+	a := s.createAppWithCertificate(c)
+	defer a.RemoveCertificate("app.io")
+	routertest.TLSRouter.Certs["app.io"] = "invalid"
+	infos, err := a.CertificatesInfo()
+	c.Assert(err, check.IsNil)
+	c.Assert(infos, check.HasLen, 1)
+	c.Assert(infos[0].Error, check.Equals, "no certificate found in PEM data")
+}
+
+func (s *S) TestCertificateInventory(c *check.C) {
+	a := s.createAppWithCertificate(c)
+	defer a.RemoveCertificate("app.io")
+	other := App{Name: "other-app", TeamOwner: s.team.Name}
+	err := CreateApp(&other, s.user)
+	c.Assert(err, check.IsNil)
+	infos, err := CertificateInventory(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(infos, check.HasLen, 1)
+	c.Assert(infos[0].App, check.Equals, a.Name)
+	c.Assert(infos[0].CName, check.Equals, "app.io")
+	infos, err = CertificateInventory(&Filter{Name: other.Name})
+	c.Assert(err, check.IsNil)
+	c.Assert(infos, check.HasLen, 0)
+}
+
+func (s *S) TestCertificateCheckerNotifiesExpiringCertificates(c *check.C) {
+	a := s.createAppWithCertificate(c)
+	defer a.RemoveCertificate("app.io")
+	checker := &certificateChecker{window: 30 * 24 * time.Hour}
+	err := checker.check(certificateNotAfter.Add(-60 * 24 * time.Hour))
+	c.Assert(err, check.IsNil)
+	var dtoMetric dto.Metric
+	certificatesExpiring.Write(&dtoMetric)
+	c.Assert(dtoMetric.Gauge.GetValue(), check.Equals, 0.0)
+	now := certificateNotAfter.Add(-10 * 24 * time.Hour)
+	err = checker.check(now)
+	c.Assert(err, check.IsNil)
+	certificatesExpiring.Write(&dtoMetric)
+	c.Assert(dtoMetric.Gauge.GetValue(), check.Equals, 1.0)
+	certificateExpirySeconds.WithLabelValues(a.Name, "fake-tls", "app.io").Write(&dtoMetric)
+	c.Assert(dtoMetric.Gauge.GetValue(), check.Equals, (10 * 24 * time.Hour).Seconds())
+	c.Assert(eventtest.EventDesc{
+		Target:     event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		Kind:       CertificateExpiringEventKind,
+		LogMatches: "certificate for app.io in router fake-tls expires at 2027-01-10T20:33:11Z",
+	}, eventtest.HasEvent)
+	err = checker.check(now.Add(time.Hour))
+	c.Assert(err, check.IsNil)
+	n, err := s.conn.Events().Find(bson.M{"kind.name": CertificateExpiringEventKind}).Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 1)
+}
+
+func (s *S) TestNotifyCertificateExpiringRemovesMarkerOnFailure(c *check.C) {
+	info := &CertificateInfo{App: "unknown", Router: "fake-tls", CName: "app.io", NotAfter: certificateNotAfter}
+	err := notifyCertificateExpiring(info)
+	c.Assert(err, check.Equals, appTypes.ErrAppNotFound)
+	n, err := s.conn.CertificateAlerts().Find(nil).Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 0)
+}
@@ -0,0 +1,189 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/service"
+	appTypes "github.com/tsuru/tsuru/types/app"
+)
+
+// CloneArgs holds the options used to clone an app. Pool, Plan and TeamOwner
+// default to the values of the source app when empty.
+type CloneArgs struct {
+	Name                string
+	Pool                string
+	Plan                string
+	TeamOwner           string
+	NewServiceInstances bool
+	User                *auth.User
+	Writer              io.Writer
+	Event               *event.Event
+	RequestID           string
+}
+
+// Clone creates a new app with the configuration of the source app: platform,
+// plan and process plans, description, tags, routers and their options, environment variables,
+// volumes and service bindings. CNames are never copied, as they can't be
+// shared between apps. When NewServiceInstances is set, a new instance of the
+// same service and plan is created for each instance bound to the source app
+// instead of sharing it.
+//
+// The new app is removed if its configuration can't be copied. Deploying the
+// source app image is left to the caller, see DeployOptions.SourceApp.
+func Clone(source *App, args CloneArgs) (*App, error) {
+	if args.Event == nil {
+		return nil, errors.New("missing event in clone args")
+	}
+	if args.Writer == nil {
+		args.Writer = ioutil.Discard
+	}
+	newApp := &App{
+		Name:        args.Name,
+		Platform:    source.Platform,
+		Description: source.Description,
+		Tags:        append([]string{}, source.Tags...),
+		Pool:        args.Pool,
+		Plan:        appTypes.Plan{Name: args.Plan},
+		TeamOwner:   args.TeamOwner,
+		Routers:     cloneRouters(source.GetRouters()),
+	}
+	if len(source.ProcessPlans) > 0 {
+		newApp.ProcessPlans = make(map[string]appTypes.Plan, len(source.ProcessPlans))
+		for process, plan := range source.ProcessPlans {
+			newApp.ProcessPlans[process] = plan
+		}
+	}
+	if newApp.Platform != "" && source.PlatformVersion != "" && source.PlatformVersion != "latest" {
+		newApp.Platform = fmt.Sprintf("%s:%s", source.Platform, source.PlatformVersion)
+	}
+	if newApp.Pool == "" {
+		newApp.Pool = source.Pool
+	}
+	if newApp.Plan.Name == "" {
+		newApp.Plan.Name = source.Plan.Name
+	}
+	if newApp.TeamOwner == "" {
+		newApp.TeamOwner = source.TeamOwner
+	}
+	fmt.Fprintf(args.Writer, "---- Creating app %q as a clone of %q ----\n", newApp.Name, source.Name)
+	err := CreateApp(newApp, args.User)
+	if err != nil {
+		return nil, err
+	}
+	manifest, newInstances, err := source.cloneManifest(newApp, args)
+	if err == nil {
+		_, err = newApp.Apply(ApplyArgs{
+			Manifest:  manifest,
+			NoRestart: true,
+			Writer:    args.Writer,
+			Event:     args.Event,
+			RequestID: args.RequestID,
+		})
+	}
+	if err != nil {
+		removeClone(newApp, newInstances, args)
+		return nil, err
+	}
+	return newApp, nil
+}
+
+func cloneRouters(routers []appTypes.AppRouter) []appTypes.AppRouter {
+	result := make([]appTypes.AppRouter, len(routers))
+	for i, r := range routers {
+		result[i] = appTypes.AppRouter{Name: r.Name}
+		if r.Opts != nil {
+			result[i].Opts = make(map[string]string, len(r.Opts))
+			for k, v := range r.Opts {
+				result[i].Opts[k] = v
+			}
+		}
+	}
+	return result
+}
+
+// cloneManifest returns the manifest with the envs, volumes and services of
+// the app, to be applied to its clone. The service instances created for the
+// clone are also returned.
+func (app *App) cloneManifest(newApp *App, args CloneArgs) (Manifest, []service.ServiceInstance, error) {
+	m := Manifest{
+		Envs:     []ManifestEnv{},
+		Services: []ManifestService{},
+	}
+	for _, e := range app.Env {
+		if isInternalEnv(e.Name) {
+			continue
+		}
+		e, err := decryptEnv(e)
+		if err != nil {
+			return m, nil, err
+		}
+		m.Envs = append(m.Envs, ManifestEnv{Name: e.Name, Value: e.Value, Private: !e.Public})
+	}
+	volumes, err := app.currentManifestVolumes()
+	if err != nil {
+		return m, nil, err
+	}
+	m.Volumes = append([]ManifestVolume{}, volumes...)
+	instances, err := service.GetServiceInstancesBoundToApp(app.Name)
+	if err != nil {
+		return m, nil, err
+	}
+	var newInstances []service.ServiceInstance
+	for _, si := range instances {
+		if args.NewServiceInstances {
+			var newSi *service.ServiceInstance
+			newSi, err = createCloneServiceInstance(si, newApp, args)
+			if err != nil {
+				return m, newInstances, err
+			}
+			newInstances = append(newInstances, *newSi)
+			si = *newSi
+		}
+		m.Services = append(m.Services, ManifestService{Service: si.ServiceName, Instance: si.Name})
+	}
+	return m, newInstances, nil
+}
+
+func createCloneServiceInstance(si service.ServiceInstance, newApp *App, args CloneArgs) (*service.ServiceInstance, error) {
+	srv, err := service.Get(si.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	newSi := service.ServiceInstance{
+		Name:        fmt.Sprintf("%s-%s", si.Name, newApp.Name),
+		PlanName:    si.PlanName,
+		TeamOwner:   newApp.TeamOwner,
+		Description: si.Description,
+		Tags:        si.Tags,
+		Parameters:  si.Parameters,
+	}
+	fmt.Fprintf(args.Writer, "---- Creating service instance %q ----\n", manifestServiceName(srv.Name, newSi.Name))
+	err = service.CreateServiceInstance(newSi, &srv, args.Event, args.RequestID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create service instance %q", newSi.Name)
+	}
+	return service.GetServiceInstance(srv.Name, newSi.Name)
+}
+
+func removeClone(newApp *App, newInstances []service.ServiceInstance, args CloneArgs) {
+	err := Delete(newApp, args.Event, args.RequestID)
+	if err != nil {
+		log.Errorf("[clone] unable to remove app %q: %v", newApp.Name, err)
+	}
+	for i := range newInstances {
+		err = service.DeleteInstance(&newInstances[i], args.Event, args.RequestID)
+		if err != nil {
+			log.Errorf("[clone] unable to remove service instance %q: %v", newInstances[i].Name, err)
+		}
+	}
+}
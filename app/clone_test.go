@@ -0,0 +1,195 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision/pool"
+	"github.com/tsuru/tsuru/service"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) newCloneEvent(c *check.C, appName string) *event.Event {
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: event.TargetTypeApp, Value: appName},
+		Kind:     permission.PermAppCreate,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	return evt
+}
+
+func (s *S) newCloneSource(c *check.C, serviceURL string) *App {
+	source := App{
+		Name:        "source",
+		Platform:    "python",
+		TeamOwner:   s.team.Name,
+		Description: "my app",
+		Tags:        []string{"tag1"},
+		Routers:     []appTypes.AppRouter{{Name: "fake", Opts: map[string]string{"opt1": "val1"}}},
+	}
+	err := CreateApp(&source, s.user)
+	c.Assert(err, check.IsNil)
+	err = source.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{
+			{Name: "PUBLIC_ENV", Value: "public", Public: true},
+			{Name: "PRIVATE_ENV", Value: "secret", Public: false},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = source.AddCName("source.example.com")
+	c.Assert(err, check.IsNil)
+	err = service.Create(service.Service{
+		Name:       "mysql",
+		Endpoint:   map[string]string{"production": serviceURL},
+		Password:   "abcde",
+		OwnerTeams: []string{s.team.Name},
+	})
+	c.Assert(err, check.IsNil)
+	err = s.conn.ServiceInstances().Insert(service.ServiceInstance{
+		Name:        "mydb",
+		ServiceName: "mysql",
+		PlanName:    "small",
+		Apps:        []string{source.Name},
+		Teams:       []string{s.team.Name},
+		TeamOwner:   s.team.Name,
+	})
+	c.Assert(err, check.IsNil)
+	return &source
+}
+
+func newCloneServiceServer() (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Method == http.MethodPost && r.URL.Path == "/resources" {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	return server, &requests
+}
+
+func (s *S) TestClone(c *check.C) {
+	server, requests := newCloneServiceServer()
+	defer server.Close()
+	source := s.newCloneSource(c, server.URL)
+	buf := new(bytes.Buffer)
+	clone, err := Clone(source, CloneArgs{
+		Name:   "clone",
+		User:   s.user,
+		Writer: buf,
+		Event:  s.newCloneEvent(c, "clone"),
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(clone.Name, check.Equals, "clone")
+	dbApp, err := GetByName("clone")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.Platform, check.Equals, "python")
+	c.Assert(dbApp.Pool, check.Equals, source.Pool)
+	c.Assert(dbApp.Plan.Name, check.Equals, source.Plan.Name)
+	c.Assert(dbApp.TeamOwner, check.Equals, s.team.Name)
+	c.Assert(dbApp.Description, check.Equals, "my app")
+	c.Assert(dbApp.Tags, check.DeepEquals, []string{"tag1"})
+	c.Assert(dbApp.CName, check.HasLen, 0)
+	c.Assert(dbApp.GetRouters(), check.DeepEquals, []appTypes.AppRouter{{Name: "fake", Opts: map[string]string{"opt1": "val1"}}})
+	envs := dbApp.Envs()
+	c.Assert(envs["PUBLIC_ENV"], check.DeepEquals, bind.EnvVar{Name: "PUBLIC_ENV", Value: "public", Public: true})
+	c.Assert(envs["PRIVATE_ENV"], check.DeepEquals, bind.EnvVar{Name: "PRIVATE_ENV", Value: "secret", Public: false})
+	si, err := service.GetServiceInstance("mysql", "mydb")
+	c.Assert(err, check.IsNil)
+	c.Assert(si.Apps, check.DeepEquals, []string{"source", "clone"})
+	c.Assert(*requests, check.DeepEquals, []string{"POST /resources/mydb/bind-app"})
+	c.Assert(buf.String(), check.Matches, `(?s)---- Creating app "clone" as a clone of "source" ----.*`)
+}
+
+func (s *S) TestCloneWithOptions(c *check.C) {
+	server, _ := newCloneServiceServer()
+	defer server.Close()
+	source := s.newCloneSource(c, server.URL)
+	err := pool.AddPool(pool.AddPoolOptions{Name: "pool2", Public: true})
+	c.Assert(err, check.IsNil)
+	clone, err := Clone(source, CloneArgs{
+		Name:  "clone",
+		Pool:  "pool2",
+		User:  s.user,
+		Event: s.newCloneEvent(c, "clone"),
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(clone.Pool, check.Equals, "pool2")
+	c.Assert(clone.Plan.Name, check.Equals, s.defaultPlan.Name)
+	c.Assert(source.Pool, check.Equals, s.Pool)
+}
+
+func (s *S) TestCloneNewServiceInstances(c *check.C) {
+	server, requests := newCloneServiceServer()
+	defer server.Close()
+	source := s.newCloneSource(c, server.URL)
+	_, err := Clone(source, CloneArgs{
+		Name:                "clone",
+		NewServiceInstances: true,
+		User:                s.user,
+		Event:               s.newCloneEvent(c, "clone"),
+	})
+	c.Assert(err, check.IsNil)
+	si, err := service.GetServiceInstance("mysql", "mydb")
+	c.Assert(err, check.IsNil)
+	c.Assert(si.Apps, check.DeepEquals, []string{"source"})
+	newSi, err := service.GetServiceInstance("mysql", "mydb-clone")
+	c.Assert(err, check.IsNil)
+	c.Assert(newSi.PlanName, check.Equals, "small")
+	c.Assert(newSi.TeamOwner, check.Equals, s.team.Name)
+	c.Assert(newSi.Apps, check.DeepEquals, []string{"clone"})
+	c.Assert(*requests, check.DeepEquals, []string{"POST /resources", "POST /resources/mydb-clone/bind-app"})
+}
+
+func (s *S) TestCloneRemovesAppOnFailure(c *check.C) {
+	server, _ := newCloneServiceServer()
+	defer server.Close()
+	source := s.newCloneSource(c, server.URL)
+	err := s.conn.Apps().Update(bson.M{"name": source.Name}, bson.M{"$set": bson.M{"env.INVALID ENV": bind.EnvVar{Name: "INVALID ENV", Value: "x"}}})
+	c.Assert(err, check.IsNil)
+	source, err = GetByName(source.Name)
+	c.Assert(err, check.IsNil)
+	_, err = Clone(source, CloneArgs{
+		Name:  "clone",
+		User:  s.user,
+		Event: s.newCloneEvent(c, "clone"),
+	})
+	c.Assert(err, check.ErrorMatches, `unable to add env "INVALID ENV".*`)
+	_, err = GetByName("clone")
+	c.Assert(err, check.Equals, appTypes.ErrAppNotFound)
+}
+
+func (s *S) TestCloneAppAlreadyExists(c *check.C) {
+	server, _ := newCloneServiceServer()
+	defer server.Close()
+	source := s.newCloneSource(c, server.URL)
+	_, err := Clone(source, CloneArgs{
+		Name:  source.Name,
+		User:  s.user,
+		Event: s.newCloneEvent(c, source.Name),
+	})
+	c.Assert(err, check.FitsTypeOf, &appTypes.AppCreationError{})
+}
+
+func (s *S) TestCloneWithoutEvent(c *check.C) {
+	_, err := Clone(&App{Name: "source"}, CloneArgs{Name: "clone", User: s.user})
+	c.Assert(err, check.ErrorMatches, "missing event in clone args")
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	check "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func (s *S) TestParseInvalid(c *check.C) {
+	tests := []struct {
+		spec string
+		err  string
+	}{
+		{spec: "* * * *", err: `invalid schedule "\* \* \* \*": expected 5 fields, got 4`},
+		{spec: "60 * * * *", err: `invalid schedule .*: invalid value "60" in minute field, must be between 0 and 59`},
+		{spec: "* * 0 * *", err: `invalid schedule .*: invalid value "0" in day of month field, must be between 1 and 31`},
+		{spec: "*/0 * * * *", err: `invalid schedule .*: invalid step "0" in minute field`},
+		{spec: "10-5 * * * *", err: `invalid schedule .*: invalid range "10-5" in minute field`},
+		{spec: "* * * foo *", err: `invalid schedule .*: invalid value "foo" in month field, must be between 1 and 12`},
+		{spec: "@every 5m", err: `invalid schedule .*: expected 5 fields, got 2`},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.spec)
+		c.Check(err, check.ErrorMatches, tt.err, check.Commentf("spec %q", tt.spec))
+	}
+}
+
+func (s *S) TestNext(c *check.C) {
+	base := time.Date(2018, time.May, 15, 10, 30, 20, 0, time.UTC)
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{spec: "* * * * *", expected: time.Date(2018, time.May, 15, 10, 31, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", expected: time.Date(2018, time.May, 15, 10, 45, 0, 0, time.UTC)},
+		{spec: "5,20 * * * *", expected: time.Date(2018, time.May, 15, 11, 5, 0, 0, time.UTC)},
+		{spec: "0 9-17 * * *", expected: time.Date(2018, time.May, 15, 11, 0, 0, 0, time.UTC)},
+		{spec: "30 2 * * *", expected: time.Date(2018, time.May, 16, 2, 30, 0, 0, time.UTC)},
+		{spec: "0 0 1 * *", expected: time.Date(2018, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * sun", expected: time.Date(2018, time.May, 20, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", expected: time.Date(2018, time.May, 20, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * mon-fri", expected: time.Date(2018, time.May, 16, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1 jan *", expected: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 20 * 3", expected: time.Date(2018, time.May, 16, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", expected: time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", expected: time.Date(2018, time.May, 15, 11, 0, 0, 0, time.UTC)},
+		{spec: "@daily", expected: time.Date(2018, time.May, 16, 0, 0, 0, 0, time.UTC)},
+		{spec: "@weekly", expected: time.Date(2018, time.May, 20, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", expected: time.Time{}},
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.spec)
+		c.Assert(err, check.IsNil, check.Commentf("spec %q", tt.spec))
+		c.Check(schedule.Next(base), check.DeepEquals, tt.expected, check.Commentf("spec %q", tt.spec))
+	}
+}
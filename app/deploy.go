@@ -5,8 +5,11 @@
 package app
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"hash"
 	"io"
+	"io/ioutil"
 	"regexp"
 	"strings"
 	"time"
@@ -38,6 +41,8 @@ const (
 	DeployUpload       DeployKind = "upload"
 	DeployUploadBuild  DeployKind = "uploadbuild"
 	DeployRebuild      DeployKind = "rebuild"
+	DeployPromote      DeployKind = "promote"
+	DeployDockerfile   DeployKind = "dockerfile"
 )
 
 var reImageVersion = regexp.MustCompile("v[0-9]+$")
@@ -57,6 +62,7 @@ type DeployData struct {
 	RemoveDate  time.Time `bson:",omitempty"`
 	Diff        string
 	Message     string
+	Provenance  *image.ImageProvenance `bson:"-" json:",omitempty"`
 }
 
 func findValidImages(apps ...App) (set.Set, error) {
@@ -112,7 +118,14 @@ func GetDeploy(id string) (*DeployData, error) {
 	if err != nil {
 		return nil, err
 	}
-	return eventToDeployData(evt, nil, true), nil
+	data := eventToDeployData(evt, nil, true)
+	if data.Image != "" {
+		data.Provenance, err = image.GetImageProvenance(data.Image)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
 }
 
 func eventToDeployData(evt *event.Event, validImages set.Set, full bool) *DeployData {
@@ -154,6 +167,16 @@ func eventToDeployData(evt *event.Event, validImages set.Set, full bool) *Deploy
 	return data
 }
 
+// withoutPlatform returns whether deploys of the kind provide their own image,
+// not depending on the platform of the app.
+func (k DeployKind) withoutPlatform() bool {
+	switch k {
+	case DeployImage, DeployRollback, DeployPromote, DeployDockerfile:
+		return true
+	}
+	return false
+}
+
 type DeployOptions struct {
 	App          *App
 	Commit       string
@@ -164,12 +187,18 @@ type DeployOptions struct {
 	OutputStream io.Writer     `bson:"-"`
 	User         string
 	Image        string
+	SourceApp    string
+	SourceImage  string
 	Origin       string
 	Rollback     bool
 	Build        bool
+	Dockerfile   bool
 	Event        *event.Event `bson:"-"`
 	Kind         DeployKind
 	Message      string
+	Canary       *CanaryOptions `bson:",omitempty"`
+	provenance   *image.ImageProvenance
+	sbom         *image.SBOM
 }
 
 func (o *DeployOptions) GetOrigin() string {
@@ -189,10 +218,16 @@ func (o *DeployOptions) GetKind() (kind DeployKind) {
 	if o.Rollback {
 		return DeployRollback
 	}
+	if o.SourceApp != "" {
+		return DeployPromote
+	}
 	if o.Image != "" {
 		return DeployImage
 	}
 	if o.File != nil {
+		if o.Dockerfile {
+			return DeployDockerfile
+		}
 		if o.Build {
 			return DeployUploadBuild
 		}
@@ -216,7 +251,7 @@ func Build(opts DeployOptions) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if opts.App.GetPlatform() == "" {
+	if opts.App.GetPlatform() == "" && opts.Kind != DeployDockerfile {
 		return "", errors.Errorf("can't build app without platform")
 	}
 	builder, ok := prov.(provision.BuilderDeploy)
@@ -269,12 +304,39 @@ func Deploy(opts DeployOptions) (string, error) {
 		}
 		opts.Image = imageName
 	}
+	if opts.SourceApp != "" {
+		err := opts.ResolveSourceImage()
+		if err != nil {
+			return "", err
+		}
+		opts.Image = opts.SourceImage
+	}
 	logWriter := LogWriter{App: opts.App}
 	logWriter.Async()
 	defer logWriter.Close()
 	opts.Event.SetLogWriter(io.MultiWriter(&tsuruIo.NoErrorWriter{Writer: opts.OutputStream}, &logWriter))
+	err := opts.App.checkEnvs()
+	if err != nil {
+		return "", err
+	}
+	prevImage, _ := image.AppCurrentImageName(opts.App.Name)
 	imageID, err := deployToProvisioner(&opts, opts.Event)
+	if err == nil && opts.provenance != nil {
+		saveProvenance(&opts, imageID)
+	}
+	if err == nil && opts.Kind != DeployRollback {
+		opts.Event.StepStarted(progressPhasePostDeploy, "watch")
+		watchErr := startPostDeployWatch(&opts, prevImage, opts.Event)
+		if watchErr != nil {
+			fmt.Fprintf(opts.Event, "\n---- Unable to start the post-deploy window, the deploy will not be watched: %v ----\n", watchErr)
+			log.Errorf("[post-deploy] unable to watch deploy of app %q: %v", opts.App.Name, watchErr)
+		}
+		opts.Event.StepFinished(progressPhasePostDeploy, "watch", watchErr)
+	}
+	opts.Event.StepStarted(progressPhaseRouter, "rebuild-routes")
 	rebuild.RoutesRebuildOrEnqueue(opts.App.Name)
+	opts.Event.StepFinished(progressPhaseRouter, "rebuild-routes", nil)
+	reportUnitsStatus(opts.App, opts.Event)
 	quotaErr := opts.App.fixQuota()
 	if quotaErr != nil {
 		log.Errorf("WARNING: unable to ensure quota is up-to-date after deploy: %v", quotaErr)
@@ -293,7 +355,11 @@ func Deploy(opts DeployOptions) (string, error) {
 	if err != nil {
 		log.Errorf("WARNING: couldn't increment deploy count, deploy opts: %#v", opts)
 	}
-	if opts.Kind == DeployImage || opts.Kind == DeployRollback {
+	err = opts.App.updateJobs()
+	if err != nil {
+		log.Errorf("WARNING: unable to update jobs after deploy: %v", err)
+	}
+	if opts.Kind.withoutPlatform() {
 		if !opts.App.UpdatePlatform {
 			opts.App.SetUpdatePlatform(true)
 		}
@@ -303,6 +369,31 @@ func Deploy(opts DeployOptions) (string, error) {
 	return imageID, nil
 }
 
+// ResolveSourceImage sets SourceImage to the image of SourceApp matching the
+// version in Image, or to its current image when no version is given. The
+// image is kept once resolved, so a deploy requested for approval runs the
+// image promoted at the time of the request.
+func (o *DeployOptions) ResolveSourceImage() error {
+	if o.SourceApp == "" || o.SourceImage != "" {
+		return nil
+	}
+	imageName, err := promotedImageName(o.SourceApp, o.Image)
+	if err != nil {
+		return err
+	}
+	o.SourceImage = imageName
+	return nil
+}
+
+// promotedImageName returns the image of the source app matching version, or
+// its current image when version is empty.
+func promotedImageName(sourceApp, version string) (string, error) {
+	if version == "" {
+		return image.AppCurrentImageName(sourceApp)
+	}
+	return image.GetAppImageBySuffix(sourceApp, version)
+}
+
 func RollbackUpdate(appName, imageID, reason string, disableRollback bool) error {
 	imgName, err := image.GetAppImageBySuffix(appName, imageID)
 	if err != nil {
@@ -319,21 +410,30 @@ func deployToProvisioner(opts *DeployOptions, evt *event.Event) (string, error)
 	if opts.Kind == "" {
 		opts.GetKind()
 	}
-	if (opts.App.GetPlatform() == "") && ((opts.Kind != DeployImage) && (opts.Kind != DeployRollback)) {
+	if opts.App.GetPlatform() == "" && !opts.Kind.withoutPlatform() {
 		return "", errors.Errorf("can't deploy app without platform, if it's not an image or rollback")
 	}
 
+	if opts.Canary != nil && opts.Kind != DeployRollback {
+		return canaryDeployToProvisioner(prov, opts, evt)
+	}
 	if opts.Kind != DeployRollback {
 		if deployer, ok := prov.(provision.BuilderDeploy); ok {
 			imageID, err := builderDeploy(deployer, opts, evt)
 			if err != nil {
 				return "", err
 			}
-			return deployer.Deploy(opts.App, imageID, evt)
+			evt.StepStarted(progressPhaseProvisioner, prov.GetName())
+			imageID, err = deployer.Deploy(opts.App, imageID, evt)
+			evt.StepFinished(progressPhaseProvisioner, prov.GetName(), err)
+			return imageID, err
 		}
 	} else {
 		if deployer, ok := prov.(provision.RollbackableDeployer); ok {
-			return deployer.Rollback(opts.App, opts.Image, evt)
+			evt.StepStarted(progressPhaseProvisioner, prov.GetName())
+			imageID, err := deployer.Rollback(opts.App, opts.Image, evt)
+			evt.StepFinished(progressPhaseProvisioner, prov.GetName(), err)
+			return imageID, err
 		}
 	}
 	return "", provision.ProvisionerNotSupported{Prov: prov, Action: fmt.Sprintf("%s deploy", opts.Kind)}
@@ -342,23 +442,41 @@ func deployToProvisioner(opts *DeployOptions, evt *event.Event) (string, error)
 func builderDeploy(prov provision.BuilderDeploy, opts *DeployOptions, evt *event.Event) (string, error) {
 	isRebuild := opts.Kind == DeployRebuild
 	buildOpts := builder.BuildOpts{
-		BuildFromFile: opts.Build,
+		BuildFromFile: opts.Build || opts.Kind == DeployDockerfile,
 		ArchiveURL:    opts.ArchiveURL,
 		ArchiveFile:   opts.File,
 		ArchiveSize:   opts.FileSize,
 		Rebuild:       isRebuild,
 		ImageID:       opts.Image,
 		Tag:           opts.BuildTag,
+		Promote:       opts.Kind == DeployPromote,
+	}
+	var archiveHash hash.Hash
+	if opts.File != nil {
+		archiveHash = sha256.New()
+		buildOpts.ArchiveFile = io.TeeReader(opts.File, archiveHash)
 	}
-	builder, err := opts.App.getBuilder()
+	appBuilder, err := opts.App.getBuilder()
 	if err != nil {
 		return "", err
 	}
-	img, err := builder.Build(prov, opts.App, evt, &buildOpts)
+	builderName := builder.NameOf(appBuilder)
+	evt.StepStarted(progressPhaseBuilder, builderName)
+	img, err := appBuilder.Build(prov, opts.App, evt, &buildOpts)
+	evt.StepFinished(progressPhaseBuilder, builderName, err)
 	if buildOpts.IsTsuruBuilderImage {
 		opts.Kind = DeployBuildedImage
 	}
-	return img, err
+	if err != nil || opts.Kind == DeployPromote {
+		return img, err
+	}
+	archiveDigest := buildOpts.ArchiveDigest
+	if archiveHash != nil {
+		io.Copy(ioutil.Discard, buildOpts.ArchiveFile)
+		archiveDigest = fmt.Sprintf("sha256:%x", archiveHash.Sum(nil))
+	}
+	recordProvenance(appBuilder, prov, opts, &buildOpts, img, archiveDigest, evt)
+	return img, nil
 }
 
 func ValidateOrigin(origin string) bool {
@@ -0,0 +1,40 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"github.com/tsuru/tsuru/event"
+	tsuruIo "github.com/tsuru/tsuru/io"
+	"github.com/tsuru/tsuru/log"
+)
+
+// Phases of a deploy reported in its structured progress stream.
+const (
+	progressPhaseBuilder     = "builder"
+	progressPhaseProvisioner = "provisioner"
+	progressPhaseRouter      = "router"
+	progressPhasePostDeploy  = "post-deploy"
+)
+
+// reportUnitsStatus sends the status of each unit of the app to the
+// structured progress stream of the deploy.
+func reportUnitsStatus(app *App, evt *event.Event) {
+	if !evt.HasProgressWriter() {
+		return
+	}
+	units, err := app.Units()
+	if err != nil {
+		log.Errorf("[deploy] unable to list units of app %q to report their status: %s", app.Name, err)
+		return
+	}
+	for _, u := range units {
+		evt.Progress(tsuruIo.ProgressMessage{
+			Type:    tsuruIo.ProgressUnitStatus,
+			Unit:    u.ID,
+			Process: u.ProcessName,
+			Status:  u.Status.String(),
+		})
+	}
+}
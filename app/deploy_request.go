@@ -0,0 +1,204 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/provision/pool"
+)
+
+const (
+	DeployRequestEventKind = "deploy-request"
+
+	defaultDeployRequestExpiration = 24 * time.Hour
+)
+
+var (
+	ErrDeployRequestNotFound     = errors.New("deploy request not found")
+	ErrDeployRequestNotPending   = errors.New("deploy request is not pending, it was already approved, rejected or expired")
+	ErrDeployRequestSelfApproval = errors.New("deploy request must be approved by a user other than its requester")
+)
+
+// DeployRequest is a deploy waiting for the approval of a second user. Deploy
+// requests are stored as pending events, holding the deploy options.
+type DeployRequest struct {
+	ID          string     `json:"id"`
+	App         string     `json:"app"`
+	User        string     `json:"user"`
+	Kind        DeployKind `json:"kind"`
+	Image       string     `json:"image,omitempty"`
+	SourceApp   string     `json:"sourceApp,omitempty"`
+	SourceImage string     `json:"sourceImage,omitempty"`
+	ArchiveURL  string     `json:"archiveURL,omitempty"`
+	Commit      string     `json:"commit,omitempty"`
+	Origin      string     `json:"origin,omitempty"`
+	Message     string     `json:"message,omitempty"`
+	Timestamp   time.Time  `json:"timestamp"`
+	ExpireTime  time.Time  `json:"expireTime"`
+}
+
+// DeployRequiresApproval returns whether deploys to the app must be approved
+// by a second user before running.
+func (app *App) DeployRequiresApproval() (bool, error) {
+	p, err := pool.GetPoolByName(app.Pool)
+	if err != nil {
+		return false, err
+	}
+	return p.RequiresDeployApproval(), nil
+}
+
+// RequestDeploy stores the deploy as a request waiting for approval. Only
+// deploys referencing an image or an archive can be requested, as uploaded
+// files are not kept until the approval. Promoted images are resolved here,
+// so the approval deploys the image promoted when the request was made.
+func RequestDeploy(opts DeployOptions) (*DeployRequest, error) {
+	if opts.File != nil {
+		return nil, &tsuruErrors.ValidationError{
+			Message: "deploys to this app require approval and uploaded files can't be approved, deploy an image or an archive url instead",
+		}
+	}
+	err := opts.ResolveSourceImage()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Kind == "" {
+		opts.GetKind()
+	}
+	expiration, _ := config.GetDuration("deploy:approval:expiration")
+	if expiration <= 0 {
+		expiration = defaultDeployRequestExpiration
+	}
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeApp, Value: opts.App.Name},
+		InternalKind: DeployRequestEventKind,
+		RawOwner:     event.Owner{Type: event.OwnerTypeUser, Name: opts.User},
+		CustomData:   opts,
+		Allowed:      opts.App.internalEventAllowed(),
+		Pending:      true,
+		ExpireTime:   time.Now().Add(expiration),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deployRequestFromEvent(evt), nil
+}
+
+func deployRequestFromEvent(evt *event.Event) *DeployRequest {
+	req := &DeployRequest{
+		ID:         evt.UniqueID.Hex(),
+		App:        evt.Target.Value,
+		User:       evt.Owner.Name,
+		Timestamp:  evt.StartTime,
+		ExpireTime: evt.ExpireTime,
+	}
+	var opts DeployOptions
+	if err := evt.StartData(&opts); err == nil {
+		req.Kind = opts.Kind
+		req.Image = opts.Image
+		req.SourceApp = opts.SourceApp
+		req.SourceImage = opts.SourceImage
+		req.ArchiveURL = opts.ArchiveURL
+		req.Commit = opts.Commit
+		req.Origin = opts.GetOrigin()
+		req.Message = opts.Message
+	}
+	return req
+}
+
+// ListDeployRequests returns the deploy requests of the app waiting for
+// approval.
+func ListDeployRequests(appName string) ([]DeployRequest, error) {
+	running := true
+	evts, err := event.List(&event.Filter{
+		Target:    event.Target{Type: event.TargetTypeApp, Value: appName},
+		KindNames: []string{DeployRequestEventKind},
+		Running:   &running,
+		Raw:       bson.M{"pending": true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	reqs := make([]DeployRequest, len(evts))
+	for i := range evts {
+		reqs[i] = *deployRequestFromEvent(&evts[i])
+	}
+	return reqs, nil
+}
+
+func getDeployRequestEvent(appName, id string) (*event.Event, error) {
+	if !bson.IsObjectIdHex(id) {
+		return nil, ErrDeployRequestNotFound
+	}
+	evt, err := event.GetByID(bson.ObjectIdHex(id))
+	if err == event.ErrEventNotFound {
+		return nil, ErrDeployRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if evt.Kind.Name != DeployRequestEventKind || evt.Target.Type != event.TargetTypeApp || evt.Target.Value != appName {
+		return nil, ErrDeployRequestNotFound
+	}
+	return evt, nil
+}
+
+func claimDeployRequest(evt *event.Event) error {
+	err := evt.Claim()
+	if err == event.ErrNotPending {
+		return ErrDeployRequestNotPending
+	}
+	return err
+}
+
+// ApproveDeployRequest claims the deploy request on behalf of the approver,
+// returning the deploy options to be used for running the deploy and the
+// request event, which must be finished once the deploy is done.
+func ApproveDeployRequest(appName, id, approver string) (*DeployOptions, *event.Event, error) {
+	evt, err := getDeployRequestEvent(appName, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if evt.Owner.Type == event.OwnerTypeUser && evt.Owner.Name == approver {
+		return nil, nil, ErrDeployRequestSelfApproval
+	}
+	err = claimDeployRequest(evt)
+	if err != nil {
+		return nil, nil, err
+	}
+	var opts DeployOptions
+	err = evt.StartData(&opts)
+	if err == nil {
+		opts.App, err = GetByName(appName)
+	}
+	if err != nil {
+		evt.Done(err)
+		return nil, nil, err
+	}
+	return &opts, evt, nil
+}
+
+// RejectDeployRequest finishes the deploy request without running the
+// deploy.
+func RejectDeployRequest(appName, id, user, reason string) error {
+	evt, err := getDeployRequestEvent(appName, id)
+	if err != nil {
+		return err
+	}
+	err = claimDeployRequest(evt)
+	if err != nil {
+		return err
+	}
+	msg := "deploy request rejected by " + user
+	if reason != "" {
+		msg += ": " + reason
+	}
+	return evt.DoneCustomData(errors.New(msg), map[string]string{"rejectedBy": user})
+}
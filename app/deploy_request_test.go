@@ -0,0 +1,163 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"io/ioutil"
+	"time"
+
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/builder"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/pool"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) newDeployRequestApp(c *check.C) *App {
+	err := pool.PoolUpdate(s.Pool, pool.UpdatePoolOptions{Labels: map[string]string{pool.ProductionLabel: "true"}})
+	c.Assert(err, check.IsNil)
+	a := App{Name: "myapp", Platform: "zend", TeamOwner: s.team.Name}
+	err = CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	return &a
+}
+
+func (s *S) TestDeployRequiresApproval(c *check.C) {
+	a := App{Name: "myapp", Platform: "zend", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	requires, err := a.DeployRequiresApproval()
+	c.Assert(err, check.IsNil)
+	c.Assert(requires, check.Equals, false)
+	err = pool.PoolUpdate(s.Pool, pool.UpdatePoolOptions{Labels: map[string]string{pool.ProductionLabel: "true"}})
+	c.Assert(err, check.IsNil)
+	requires, err = a.DeployRequiresApproval()
+	c.Assert(err, check.IsNil)
+	c.Assert(requires, check.Equals, true)
+}
+
+func (s *S) TestRequestDeploy(c *check.C) {
+	a := s.newDeployRequestApp(c)
+	req, err := RequestDeploy(DeployOptions{App: a, Image: "myimage", User: "requester@example.com"})
+	c.Assert(err, check.IsNil)
+	c.Assert(req.App, check.Equals, a.Name)
+	c.Assert(req.User, check.Equals, "requester@example.com")
+	c.Assert(req.Kind, check.Equals, DeployImage)
+	c.Assert(req.Image, check.Equals, "myimage")
+	c.Assert(req.ExpireTime.After(req.Timestamp.Add(23*time.Hour)), check.Equals, true)
+	reqs, err := ListDeployRequests(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(reqs, check.HasLen, 1)
+	c.Assert(reqs[0].ID, check.Equals, req.ID)
+	c.Assert(reqs[0].Image, check.Equals, "myimage")
+	evt, err := event.GetByHexID(req.ID)
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Pending, check.Equals, true)
+	c.Assert(evt.Kind.Name, check.Equals, DeployRequestEventKind)
+}
+
+func (s *S) TestRequestDeployUploadNotSupported(c *check.C) {
+	a := s.newDeployRequestApp(c)
+	_, err := RequestDeploy(DeployOptions{App: a, File: ioutil.NopCloser(&bytes.Buffer{}), User: "requester@example.com"})
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+}
+
+func (s *S) TestApproveDeployRequest(c *check.C) {
+	a := s.newDeployRequestApp(c)
+	req, err := RequestDeploy(DeployOptions{App: a, ArchiveURL: "https://example.com/app.tar.gz", User: "requester@example.com"})
+	c.Assert(err, check.IsNil)
+	_, _, err = ApproveDeployRequest(a.Name, req.ID, "requester@example.com")
+	c.Assert(err, check.Equals, ErrDeployRequestSelfApproval)
+	opts, evt, err := ApproveDeployRequest(a.Name, req.ID, "approver@example.com")
+	c.Assert(err, check.IsNil)
+	c.Assert(opts.App.Name, check.Equals, a.Name)
+	c.Assert(opts.ArchiveURL, check.Equals, "https://example.com/app.tar.gz")
+	c.Assert(opts.User, check.Equals, "requester@example.com")
+	c.Assert(opts.Kind, check.Equals, DeployArchiveURL)
+	_, _, err = ApproveDeployRequest(a.Name, req.ID, "other@example.com")
+	c.Assert(err, check.Equals, ErrDeployRequestNotPending)
+	err = evt.Done(nil)
+	c.Assert(err, check.IsNil)
+	reqs, err := ListDeployRequests(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(reqs, check.HasLen, 0)
+}
+
+func (s *S) TestApproveDeployRequestPromotesImageOfRequest(c *check.C) {
+	a := s.newDeployRequestApp(c)
+	err := image.AppendAppImageName("staging", "registry.tsuru.io/tsuru/app-staging:v1")
+	c.Assert(err, check.IsNil)
+	req, err := RequestDeploy(DeployOptions{App: a, SourceApp: "staging", User: "requester@example.com"})
+	c.Assert(err, check.IsNil)
+	c.Assert(req.Kind, check.Equals, DeployPromote)
+	c.Assert(req.SourceImage, check.Equals, "registry.tsuru.io/tsuru/app-staging:v1")
+	err = image.AppendAppImageName("staging", "registry.tsuru.io/tsuru/app-staging:v2")
+	c.Assert(err, check.IsNil)
+	var buildOpts *builder.BuildOpts
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		buildOpts = opts
+		return "registry.tsuru.io/tsuru/app-myapp:v1", nil
+	}
+	opts, evt, err := ApproveDeployRequest(a.Name, req.ID, "approver@example.com")
+	c.Assert(err, check.IsNil)
+	c.Assert(opts.SourceImage, check.Equals, "registry.tsuru.io/tsuru/app-staging:v1")
+	deployEvt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: a.Name},
+		Kind:     permission.PermAppDeploy,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: opts.User},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	opts.Event = deployEvt
+	opts.OutputStream = ioutil.Discard
+	_, err = Deploy(*opts)
+	c.Assert(err, check.IsNil)
+	c.Assert(buildOpts, check.NotNil)
+	c.Assert(buildOpts.ImageID, check.Equals, "registry.tsuru.io/tsuru/app-staging:v1")
+	c.Assert(buildOpts.Promote, check.Equals, true)
+	err = deployEvt.Done(nil)
+	c.Assert(err, check.IsNil)
+	err = evt.Done(nil)
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestRequestDeployPromoteInvalidVersion(c *check.C) {
+	a := s.newDeployRequestApp(c)
+	err := image.AppendAppImageName("staging", "registry.tsuru.io/tsuru/app-staging:v1")
+	c.Assert(err, check.IsNil)
+	_, err = RequestDeploy(DeployOptions{App: a, SourceApp: "staging", Image: "v9", User: "requester@example.com"})
+	c.Assert(err, check.FitsTypeOf, &image.InvalidVersionErr{})
+	reqs, err := ListDeployRequests(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(reqs, check.HasLen, 0)
+}
+
+func (s *S) TestApproveDeployRequestNotFound(c *check.C) {
+	a := s.newDeployRequestApp(c)
+	_, _, err := ApproveDeployRequest(a.Name, "invalid", "approver@example.com")
+	c.Assert(err, check.Equals, ErrDeployRequestNotFound)
+	req, err := RequestDeploy(DeployOptions{App: a, Image: "myimage", User: "requester@example.com"})
+	c.Assert(err, check.IsNil)
+	_, _, err = ApproveDeployRequest("otherapp", req.ID, "approver@example.com")
+	c.Assert(err, check.Equals, ErrDeployRequestNotFound)
+}
+
+func (s *S) TestRejectDeployRequest(c *check.C) {
+	a := s.newDeployRequestApp(c)
+	req, err := RequestDeploy(DeployOptions{App: a, Image: "myimage", User: "requester@example.com"})
+	c.Assert(err, check.IsNil)
+	err = RejectDeployRequest(a.Name, req.ID, "approver@example.com", "not today")
+	c.Assert(err, check.IsNil)
+	evt, err := event.GetByHexID(req.ID)
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, false)
+	c.Assert(evt.Error, check.Equals, "deploy request rejected by approver@example.com: not today")
+	err = RejectDeployRequest(a.Name, req.ID, "approver@example.com", "")
+	c.Assert(err, check.Equals, ErrDeployRequestNotPending)
+}
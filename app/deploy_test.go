@@ -352,6 +352,73 @@ func (s *S) TestDeployAppImage(c *check.C) {
 	c.Assert(updatedApp.UpdatePlatform, check.Equals, true)
 }
 
+func (s *S) TestDeployAppPromote(c *check.C) {
+	a := App{Name: "production", Platform: "django", TeamOwner: s.team.Name, Router: "fake"}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName("staging", "registry.tsuru.io/tsuru/app-staging:v1")
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName("staging", "registry.tsuru.io/tsuru/app-staging:v2")
+	c.Assert(err, check.IsNil)
+	var buildOpts []builder.BuildOpts
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		buildOpts = append(buildOpts, *opts)
+		return "registry.tsuru.io/tsuru/app-production:v1", nil
+	}
+	for _, version := range []string{"v1", ""} {
+		evt, err := event.New(&event.Opts{
+			Target:   event.Target{Type: "app", Value: a.Name},
+			Kind:     permission.PermAppDeploy,
+			RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+			Allowed:  event.Allowed(permission.PermApp),
+		})
+		c.Assert(err, check.IsNil)
+		opts := DeployOptions{
+			App:          &a,
+			SourceApp:    "staging",
+			Image:        version,
+			OutputStream: ioutil.Discard,
+			Event:        evt,
+		}
+		c.Assert(opts.GetKind(), check.Equals, DeployPromote)
+		_, err = Deploy(opts)
+		c.Assert(err, check.IsNil)
+		err = evt.Done(nil)
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(buildOpts, check.HasLen, 2)
+	c.Assert(buildOpts[0].ImageID, check.Equals, "registry.tsuru.io/tsuru/app-staging:v1")
+	c.Assert(buildOpts[0].Promote, check.Equals, true)
+	c.Assert(buildOpts[1].ImageID, check.Equals, "registry.tsuru.io/tsuru/app-staging:v2")
+	c.Assert(buildOpts[1].Promote, check.Equals, true)
+	var updatedApp App
+	s.conn.Apps().Find(bson.M{"name": a.Name}).One(&updatedApp)
+	c.Assert(updatedApp.UpdatePlatform, check.Equals, true)
+}
+
+func (s *S) TestDeployAppPromoteInvalidVersion(c *check.C) {
+	a := App{Name: "production", Platform: "django", TeamOwner: s.team.Name, Router: "fake"}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName("staging", "registry.tsuru.io/tsuru/app-staging:v1")
+	c.Assert(err, check.IsNil)
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: a.Name},
+		Kind:     permission.PermAppDeploy,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	_, err = Deploy(DeployOptions{
+		App:          &a,
+		SourceApp:    "staging",
+		Image:        "v9",
+		OutputStream: ioutil.Discard,
+		Event:        evt,
+	})
+	c.Assert(err, check.FitsTypeOf, &image.InvalidVersionErr{})
+}
+
 func (s *S) TestDeployAppWithUpdatedPlatform(c *check.C) {
 	a := App{
 		Name:           "some-app",
@@ -945,6 +1012,10 @@ func (s *S) TestDeployKind(c *check.C) {
 			DeployOptions{File: ioutil.NopCloser(bytes.NewBuffer(nil)), Build: true},
 			DeployUploadBuild,
 		},
+		{
+			DeployOptions{File: ioutil.NopCloser(bytes.NewBuffer(nil)), Dockerfile: true},
+			DeployDockerfile,
+		},
 		{
 			DeployOptions{Commit: "abcef48439"},
 			DeployGit,
@@ -0,0 +1,251 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package envcrypt provides envelope encryption for application environment
+// variables. Each value is encrypted with a random data key, which is then
+// wrapped by a master key managed by a pluggable KeyProvider.
+package envcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+)
+
+const (
+	configPrefix    = "env-encryption"
+	encryptedPrefix = "tsuru-enc:v1:"
+	dataKeySize     = 32
+)
+
+var (
+	ErrNotConfigured    = errors.New("env encryption is not configured")
+	ErrInvalidEncrypted = errors.New("invalid encrypted value")
+	ErrNoKey            = errors.New(`no env encryption key found, run "tsurud env-key-rotate" to generate the first key`)
+)
+
+// KeyProvider manages the master keys used to wrap the data keys of
+// encrypted values.
+type KeyProvider interface {
+	// CurrentKeyID returns the ID of the key used to wrap new data keys.
+	CurrentKeyID() (string, error)
+
+	// WrapKey encrypts a data key using the master key with the given ID.
+	WrapKey(keyID string, dataKey []byte) ([]byte, error)
+
+	// UnwrapKey decrypts a data key previously wrapped by the master key
+	// with the given ID.
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// KeyRotator is a KeyProvider able to generate a new master key, which
+// becomes the current key. Previous keys must remain available for unwrapping.
+type KeyRotator interface {
+	RotateKey() (string, error)
+}
+
+type providerFactory func(configPrefix string) (KeyProvider, error)
+
+type keyGenerator func(configPrefix string) (string, error)
+
+var (
+	providers     = make(map[string]providerFactory)
+	generators    = make(map[string]keyGenerator)
+	providerMut   sync.Mutex
+	providerName  string
+	providerCache KeyProvider
+)
+
+// Register registers a new key provider.
+func Register(name string, factory providerFactory) {
+	providers[name] = factory
+}
+
+// RegisterKeyGenerator registers the function generating the first key of a
+// provider, for providers failing with ErrNoKey until a key exists.
+func RegisterKeyGenerator(name string, generator keyGenerator) {
+	generators[name] = generator
+}
+
+// GenerateFirstKey generates the first key of the configured provider.
+func GenerateFirstKey() (string, error) {
+	name, _ := config.GetString(configPrefix + ":provider")
+	if name == "" {
+		return "", ErrNotConfigured
+	}
+	generator, ok := generators[name]
+	if !ok {
+		return "", errors.Errorf("env encryption provider %q does not generate keys", name)
+	}
+	providerMut.Lock()
+	defer providerMut.Unlock()
+	providerName = ""
+	providerCache = nil
+	return generator(configPrefix + ":" + name)
+}
+
+// Enabled returns true if a key provider is configured, meaning that new
+// values will be encrypted.
+func Enabled() bool {
+	name, _ := config.GetString(configPrefix + ":provider")
+	return name != ""
+}
+
+// Provider returns the configured key provider.
+func Provider() (KeyProvider, error) {
+	name, _ := config.GetString(configPrefix + ":provider")
+	if name == "" {
+		return nil, ErrNotConfigured
+	}
+	providerMut.Lock()
+	defer providerMut.Unlock()
+	if providerCache != nil && providerName == name {
+		return providerCache, nil
+	}
+	factory, ok := providers[name]
+	if !ok {
+		return nil, errors.Errorf("unknown env encryption provider: %q", name)
+	}
+	provider, err := factory(configPrefix + ":" + name)
+	if err != nil {
+		return nil, err
+	}
+	providerName = name
+	providerCache = provider
+	return provider, nil
+}
+
+// Reset discards the cached key provider, forcing it to be created again
+// from the current configuration.
+func Reset() {
+	providerMut.Lock()
+	defer providerMut.Unlock()
+	providerName = ""
+	providerCache = nil
+}
+
+// IsEncrypted returns true if value was produced by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Encrypt encrypts value using the current key of the configured provider.
+// Values are returned unchanged when encryption is not enabled.
+func Encrypt(value string) (string, error) {
+	if !Enabled() {
+		return value, nil
+	}
+	provider, err := Provider()
+	if err != nil {
+		return "", err
+	}
+	keyID, err := provider.CurrentKeyID()
+	if err != nil {
+		return "", err
+	}
+	dataKey := make([]byte, dataKeySize)
+	_, err = io.ReadFull(rand.Reader, dataKey)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := provider.WrapKey(keyID, dataKey)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to wrap data key with key %q", keyID)
+	}
+	sealed, err := seal(dataKey, []byte(value))
+	if err != nil {
+		return "", err
+	}
+	return encryptedPrefix + strings.Join([]string{
+		keyID,
+		base64.StdEncoding.EncodeToString(wrapped),
+		base64.StdEncoding.EncodeToString(sealed),
+	}, ":"), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt. Values that are not
+// encrypted are returned unchanged.
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if len(parts) != 3 {
+		return "", ErrInvalidEncrypted
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidEncrypted
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidEncrypted
+	}
+	provider, err := Provider()
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := provider.UnwrapKey(parts[0], wrapped)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to unwrap data key with key %q", parts[0])
+	}
+	plain, err := open(dataKey, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// KeyID returns the ID of the master key used to encrypt value.
+func KeyID(value string) (string, bool) {
+	if !IsEncrypted(value) {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(value, encryptedPrefix), ":", 2)
+	return parts[0], true
+}
+
+func seal(key, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrInvalidEncrypted
+	}
+	nonce, data := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return nil, ErrInvalidEncrypted
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envcrypt
+
+import (
+	"strings"
+
+	"github.com/tsuru/config"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestEncryptDecrypt(c *check.C) {
+	encrypted, err := Encrypt("my secret")
+	c.Assert(err, check.IsNil)
+	c.Assert(IsEncrypted(encrypted), check.Equals, true)
+	c.Assert(strings.Contains(encrypted, "my secret"), check.Equals, false)
+	keyID, ok := KeyID(encrypted)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(keyID, check.Equals, "key1")
+	decrypted, err := Decrypt(encrypted)
+	c.Assert(err, check.IsNil)
+	c.Assert(decrypted, check.Equals, "my secret")
+}
+
+func (s *S) TestEncryptUsesRandomDataKeys(c *check.C) {
+	encrypted1, err := Encrypt("my secret")
+	c.Assert(err, check.IsNil)
+	encrypted2, err := Encrypt("my secret")
+	c.Assert(err, check.IsNil)
+	c.Assert(encrypted1, check.Not(check.Equals), encrypted2)
+}
+
+func (s *S) TestEncryptNotEnabled(c *check.C) {
+	config.Unset("env-encryption")
+	c.Assert(Enabled(), check.Equals, false)
+	value, err := Encrypt("my secret")
+	c.Assert(err, check.IsNil)
+	c.Assert(value, check.Equals, "my secret")
+}
+
+func (s *S) TestDecryptPlainValue(c *check.C) {
+	value, err := Decrypt("plain value")
+	c.Assert(err, check.IsNil)
+	c.Assert(value, check.Equals, "plain value")
+}
+
+func (s *S) TestDecryptNotConfigured(c *check.C) {
+	encrypted, err := Encrypt("my secret")
+	c.Assert(err, check.IsNil)
+	config.Unset("env-encryption")
+	Reset()
+	_, err = Decrypt(encrypted)
+	c.Assert(err, check.Equals, ErrNotConfigured)
+}
+
+func (s *S) TestDecryptInvalidValue(c *check.C) {
+	_, err := Decrypt(encryptedPrefix + "key1:invalid")
+	c.Assert(err, check.Equals, ErrInvalidEncrypted)
+	encrypted, err := Encrypt("my secret")
+	c.Assert(err, check.IsNil)
+	_, err = Decrypt(encrypted[:len(encrypted)-4] + "AAA=")
+	c.Assert(err, check.Equals, ErrInvalidEncrypted)
+}
+
+func (s *S) TestProviderUnknown(c *check.C) {
+	config.Set("env-encryption:provider", "unknown")
+	_, err := Provider()
+	c.Assert(err, check.ErrorMatches, `unknown env encryption provider: "unknown"`)
+}
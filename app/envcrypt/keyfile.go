@@ -0,0 +1,228 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envcrypt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const keyfileProviderName = "keyfile"
+
+func init() {
+	Register(keyfileProviderName, createKeyfileProvider)
+	RegisterKeyGenerator(keyfileProviderName, generateKeyfile)
+}
+
+type keyfileData struct {
+	Current string            `yaml:"current"`
+	Keys    map[string]string `yaml:"keys"`
+}
+
+// keyfileProvider stores master keys in a local YAML file, created by
+// "tsurud env-key-rotate". The file is loaded again whenever it changes, so
+// keys rotated by other processes are seen.
+type keyfileProvider struct {
+	mu      sync.RWMutex
+	path    string
+	stat    os.FileInfo
+	current string
+	keys    map[string][]byte
+}
+
+var _ KeyRotator = &keyfileProvider{}
+
+func createKeyfileProvider(configPrefix string) (KeyProvider, error) {
+	path, err := config.GetString(configPrefix + ":path")
+	if err != nil {
+		return nil, err
+	}
+	p := &keyfileProvider{path: path}
+	err = p.load()
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, errors.Wrapf(ErrNoKey, "keyfile %q not found", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// generateKeyfile creates the keyfile with its first key. The keyfile must be
+// shared by all tsuru API instances, so it's never created implicitly.
+func generateKeyfile(configPrefix string) (string, error) {
+	path, err := config.GetString(configPrefix + ":path")
+	if err != nil {
+		return "", err
+	}
+	if _, err = os.Stat(path); err == nil {
+		return "", errors.Errorf("keyfile %q already exists", path)
+	}
+	p := &keyfileProvider{path: path}
+	return p.RotateKey()
+}
+
+func (p *keyfileProvider) load() error {
+	stat, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	var data keyfileData
+	err = yaml.Unmarshal(content, &data)
+	if err != nil {
+		return errors.Wrapf(err, "unable to parse keyfile %q", p.path)
+	}
+	keys := make(map[string][]byte, len(data.Keys))
+	for id, rawKey := range data.Keys {
+		if id == "" || strings.Contains(id, ":") {
+			return errors.Errorf("invalid key id %q in keyfile %q", id, p.path)
+		}
+		key, err := base64.StdEncoding.DecodeString(rawKey)
+		if err != nil || len(key) != dataKeySize {
+			return errors.Errorf("invalid key %q in keyfile %q: must be %d base64 encoded bytes", id, p.path, dataKeySize)
+		}
+		keys[id] = key
+	}
+	if _, ok := keys[data.Current]; !ok {
+		return errors.Errorf("current key %q not found in keyfile %q", data.Current, p.path)
+	}
+	p.current = data.Current
+	p.keys = keys
+	p.stat = stat
+	return nil
+}
+
+// refresh loads the keyfile again if it was changed since the last load.
+func (p *keyfileProvider) refresh() error {
+	stat, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	p.mu.RLock()
+	changed := p.stat == nil || !stat.ModTime().Equal(p.stat.ModTime()) || stat.Size() != p.stat.Size()
+	p.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.load()
+}
+
+func (p *keyfileProvider) save() error {
+	data := keyfileData{
+		Current: p.current,
+		Keys:    make(map[string]string, len(p.keys)),
+	}
+	for id, key := range p.keys {
+		data.Keys[id] = base64.StdEncoding.EncodeToString(key)
+	}
+	content, err := yaml.Marshal(data)
+	if err != nil {
+		return err
+	}
+	tmpPath := p.path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, content, 0600)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpPath, p.path)
+	if err != nil {
+		return err
+	}
+	p.stat, err = os.Stat(p.path)
+	return err
+}
+
+func (p *keyfileProvider) CurrentKeyID() (string, error) {
+	err := p.refresh()
+	if err != nil {
+		return "", err
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current, nil
+}
+
+func (p *keyfileProvider) key(keyID string) ([]byte, error) {
+	err := p.refresh()
+	if err != nil {
+		return nil, err
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, errors.Errorf("key %q not found in keyfile %q", keyID, p.path)
+	}
+	return key, nil
+}
+
+func (p *keyfileProvider) WrapKey(keyID string, dataKey []byte) ([]byte, error) {
+	key, err := p.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return seal(key, dataKey)
+}
+
+func (p *keyfileProvider) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	key, err := p.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return open(key, wrapped)
+}
+
+// RotateKey generates a new random key and stores it in the keyfile as the
+// current key. The keyfile is loaded again first, so keys added by other
+// processes are kept.
+func (p *keyfileProvider) RotateKey() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	err := p.load()
+	if err != nil && !os.IsNotExist(errors.Cause(err)) {
+		return "", err
+	}
+	key := make([]byte, dataKeySize)
+	_, err = io.ReadFull(rand.Reader, key)
+	if err != nil {
+		return "", err
+	}
+	if p.keys == nil {
+		p.keys = make(map[string][]byte)
+	}
+	var keyID string
+	for i := len(p.keys) + 1; ; i++ {
+		keyID = fmt.Sprintf("key%d", i)
+		if _, ok := p.keys[keyID]; !ok {
+			break
+		}
+	}
+	previous := p.current
+	p.keys[keyID] = key
+	p.current = keyID
+	err = p.save()
+	if err != nil {
+		delete(p.keys, keyID)
+		p.current = previous
+		return "", err
+	}
+	return keyID, nil
+}
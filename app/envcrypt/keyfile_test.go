@@ -0,0 +1,95 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envcrypt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestKeyfileProviderMissingKeyfile(c *check.C) {
+	config.Set("env-encryption:keyfile:path", filepath.Join(s.dir, "missing.yaml"))
+	_, err := Provider()
+	c.Assert(errors.Cause(err), check.Equals, ErrNoKey)
+	_, err = Encrypt("my secret")
+	c.Assert(errors.Cause(err), check.Equals, ErrNoKey)
+	_, err = os.Stat(filepath.Join(s.dir, "missing.yaml"))
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+}
+
+func (s *S) TestGenerateFirstKeyKeyfileExists(c *check.C) {
+	_, err := GenerateFirstKey()
+	c.Assert(err, check.ErrorMatches, `keyfile ".*keys.yaml" already exists`)
+}
+
+func (s *S) TestKeyfileProviderGenerateFirstKey(c *check.C) {
+	provider, err := Provider()
+	c.Assert(err, check.IsNil)
+	keyID, err := provider.CurrentKeyID()
+	c.Assert(err, check.IsNil)
+	c.Assert(keyID, check.Equals, "key1")
+	info, err := os.Stat(filepath.Join(s.dir, "keys.yaml"))
+	c.Assert(err, check.IsNil)
+	c.Assert(info.Mode().Perm(), check.Equals, os.FileMode(0600))
+}
+
+func (s *S) TestKeyfileProviderRotateKey(c *check.C) {
+	encrypted, err := Encrypt("my secret")
+	c.Assert(err, check.IsNil)
+	provider, err := Provider()
+	c.Assert(err, check.IsNil)
+	keyID, err := provider.(KeyRotator).RotateKey()
+	c.Assert(err, check.IsNil)
+	c.Assert(keyID, check.Equals, "key2")
+	Reset()
+	reencrypted, err := Encrypt("my secret")
+	c.Assert(err, check.IsNil)
+	keyID, _ = KeyID(reencrypted)
+	c.Assert(keyID, check.Equals, "key2")
+	decrypted, err := Decrypt(encrypted)
+	c.Assert(err, check.IsNil)
+	c.Assert(decrypted, check.Equals, "my secret")
+}
+
+func (s *S) TestKeyfileProviderReloadsRotatedKeyfile(c *check.C) {
+	provider, err := Provider()
+	c.Assert(err, check.IsNil)
+	other, err := createKeyfileProvider("env-encryption:keyfile")
+	c.Assert(err, check.IsNil)
+	keyID, err := other.(KeyRotator).RotateKey()
+	c.Assert(err, check.IsNil)
+	c.Assert(keyID, check.Equals, "key2")
+	keyID, err = provider.CurrentKeyID()
+	c.Assert(err, check.IsNil)
+	c.Assert(keyID, check.Equals, "key2")
+	wrapped, err := other.WrapKey("key2", make([]byte, dataKeySize))
+	c.Assert(err, check.IsNil)
+	dataKey, err := provider.UnwrapKey("key2", wrapped)
+	c.Assert(err, check.IsNil)
+	c.Assert(dataKey, check.DeepEquals, make([]byte, dataKeySize))
+}
+
+func (s *S) TestKeyfileProviderInvalidKeyfile(c *check.C) {
+	path := filepath.Join(s.dir, "invalid.yaml")
+	err := ioutil.WriteFile(path, []byte("current: key1\nkeys:\n  key1: c2hvcnQ=\n"), 0600)
+	c.Assert(err, check.IsNil)
+	config.Set("env-encryption:keyfile:path", path)
+	_, err = Provider()
+	c.Assert(err, check.ErrorMatches, `invalid key "key1" in keyfile .*`)
+}
+
+func (s *S) TestKeyfileProviderMissingCurrentKey(c *check.C) {
+	path := filepath.Join(s.dir, "invalid.yaml")
+	err := ioutil.WriteFile(path, []byte("current: key2\nkeys: {}\n"), 0600)
+	c.Assert(err, check.IsNil)
+	config.Set("env-encryption:keyfile:path", path)
+	_, err = Provider()
+	c.Assert(err, check.ErrorMatches, `current key "key2" not found in keyfile .*`)
+}
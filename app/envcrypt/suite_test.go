@@ -0,0 +1,40 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envcrypt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tsuru/config"
+	check "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct {
+	dir string
+}
+
+var _ = check.Suite(&S{})
+
+func (s *S) SetUpTest(c *check.C) {
+	var err error
+	s.dir, err = ioutil.TempDir("", "envcrypt")
+	c.Assert(err, check.IsNil)
+	config.Set("env-encryption:provider", "keyfile")
+	config.Set("env-encryption:keyfile:path", filepath.Join(s.dir, "keys.yaml"))
+	Reset()
+	_, err = GenerateFirstKey()
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TearDownTest(c *check.C) {
+	config.Unset("env-encryption")
+	Reset()
+	os.RemoveAll(s.dir)
+}
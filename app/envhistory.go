@@ -0,0 +1,323 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/log"
+)
+
+var ErrEnvRevisionNotFound = errors.New("env revision not found")
+
+const envHashKeyID = "env-history"
+
+var (
+	envHashKeyMu    sync.Mutex
+	envHashKeyCache []byte
+)
+
+// EnvRevision is a snapshot of the environment variables of an app, taken
+// every time they're changed.
+type EnvRevision struct {
+	App       string                 `json:"app"`
+	Revision  int                    `json:"revision"`
+	Timestamp time.Time              `json:"timestamp"`
+	Owner     string                 `json:"owner"`
+	Rollback  int                    `json:"rollback,omitempty"`
+	Changes   []EnvChange            `json:"changes"`
+	Envs      map[string]bind.EnvVar `json:"-"`
+}
+
+// EnvChange describes the change of a single environment variable in a
+// revision. Values are never exposed, only their HMACs, keyed by a secret
+// stored in the database, and an empty hash means the variable was not set.
+type EnvChange struct {
+	Name    string `json:"name"`
+	OldHash string `json:"oldHash,omitempty"`
+	NewHash string `json:"newHash,omitempty"`
+}
+
+// EnvRollbackArgs are the arguments used to roll back the environment
+// variables of an app to a previous revision.
+type EnvRollbackArgs struct {
+	Revision      int
+	Writer        io.Writer
+	ShouldRestart bool
+	Owner         string
+}
+
+// envHashKey returns the key used to hash values in the env history,
+// generating it on first use. Hashes are keyed so users reading the history
+// can't brute-force low-entropy values.
+func envHashKey() ([]byte, error) {
+	envHashKeyMu.Lock()
+	defer envHashKeyMu.Unlock()
+	if envHashKeyCache != nil {
+		return envHashKeyCache, nil
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	key := make([]byte, sha256.Size)
+	_, err = io.ReadFull(rand.Reader, key)
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.AppEnvHashKeys().Upsert(bson.M{"_id": envHashKeyID}, bson.M{"$setOnInsert": bson.M{"key": key}})
+	if err != nil && !mgo.IsDup(err) {
+		return nil, err
+	}
+	var stored struct {
+		Key []byte
+	}
+	err = conn.AppEnvHashKeys().FindId(envHashKeyID).One(&stored)
+	if err != nil {
+		return nil, err
+	}
+	envHashKeyCache = stored.Key
+	return envHashKeyCache, nil
+}
+
+func envValueHash(key []byte, env bind.EnvVar) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(env.Value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (app *App) copyEnv() map[string]bind.EnvVar {
+	env := make(map[string]bind.EnvVar, len(app.Env))
+	for name, e := range app.Env {
+		env[name] = e
+	}
+	return env
+}
+
+func diffEnvs(oldEnv, newEnv map[string]bind.EnvVar) ([]EnvChange, error) {
+	names := make(map[string]struct{}, len(oldEnv)+len(newEnv))
+	for name := range oldEnv {
+		names[name] = struct{}{}
+	}
+	for name := range newEnv {
+		names[name] = struct{}{}
+	}
+	key, err := envHashKey()
+	if err != nil {
+		return nil, err
+	}
+	var changes []EnvChange
+	for name := range names {
+		var change EnvChange
+		var err error
+		oldVar, hasOld := oldEnv[name]
+		if hasOld {
+			oldVar, err = decryptEnv(oldVar)
+			if err != nil {
+				return nil, err
+			}
+			change.OldHash = envValueHash(key, oldVar)
+		}
+		newVar, hasNew := newEnv[name]
+		if hasNew {
+			newVar, err = decryptEnv(newVar)
+			if err != nil {
+				return nil, err
+			}
+			change.NewHash = envValueHash(key, newVar)
+		}
+		if hasOld && hasNew && oldVar == newVar {
+			continue
+		}
+		change.Name = name
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes, nil
+}
+
+// recordEnvRevision stores the current environment variables of the app as a
+// new revision, along with the changes made since oldEnv. Failures are only
+// logged, as the variables are already stored in the app at this point.
+func (app *App) recordEnvRevision(oldEnv map[string]bind.EnvVar, owner string, rollback int) {
+	err := app.addEnvRevision(oldEnv, owner, rollback)
+	if err != nil {
+		log.Errorf("unable to record env revision for app %q: %v", app.Name, err)
+	}
+}
+
+func (app *App) addEnvRevision(oldEnv map[string]bind.EnvVar, owner string, rollback int) error {
+	changes, err := diffEnvs(oldEnv, app.Env)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var last EnvRevision
+	err = conn.AppEnvRevisions().Find(bson.M{"app": app.Name}).Sort("-revision").One(&last)
+	if err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+	return conn.AppEnvRevisions().Insert(EnvRevision{
+		App:       app.Name,
+		Revision:  last.Revision + 1,
+		Timestamp: time.Now().UTC(),
+		Owner:     owner,
+		Rollback:  rollback,
+		Changes:   changes,
+		Envs:      app.copyEnv(),
+	})
+}
+
+// reencryptEnvRevisions decrypts the envs stored in all revisions of the app
+// env history and encrypts them again using the current key, so revisions can
+// still be rolled back to once previous keys are gone.
+func (app *App) reencryptEnvRevisions() error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var revisions []EnvRevision
+	err = conn.AppEnvRevisions().Find(bson.M{"app": app.Name}).All(&revisions)
+	if err != nil {
+		return err
+	}
+	for _, rev := range revisions {
+		envs := make(map[string]bind.EnvVar, len(rev.Envs))
+		for name, e := range rev.Envs {
+			e, err = decryptEnv(e)
+			if err != nil {
+				return errors.Wrapf(err, "revision %d", rev.Revision)
+			}
+			envs[name], err = encryptEnv(e)
+			if err != nil {
+				return err
+			}
+		}
+		err = conn.AppEnvRevisions().Update(bson.M{"app": app.Name, "revision": rev.Revision}, bson.M{"$set": bson.M{"envs": envs}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListEnvRevisions returns the env history of the app, newest revisions
+// first.
+func ListEnvRevisions(appName string) ([]EnvRevision, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var revisions []EnvRevision
+	err = conn.AppEnvRevisions().Find(bson.M{"app": appName}).Select(bson.M{"envs": 0}).Sort("-revision").All(&revisions)
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// GetEnvRevision returns the given revision of the env history of the app.
+func GetEnvRevision(appName string, revision int) (*EnvRevision, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var rev EnvRevision
+	err = conn.AppEnvRevisions().Find(bson.M{"app": appName, "revision": revision}).One(&rev)
+	if err == mgo.ErrNotFound {
+		return nil, ErrEnvRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// RollbackEnvs sets the environment variables of the app back to the ones
+// stored in the given revision, restarting the app only once. The rollback is
+// recorded as a new revision.
+func (app *App) RollbackEnvs(args EnvRollbackArgs) error {
+	rev, err := GetEnvRevision(app.Name, args.Revision)
+	if err != nil {
+		return err
+	}
+	var toSet []bind.EnvVar
+	for name, e := range rev.Envs {
+		target, err := decryptEnv(e)
+		if err != nil {
+			return err
+		}
+		if current, ok := app.Env[name]; ok {
+			current, err = decryptEnv(current)
+			if err != nil {
+				return err
+			}
+			if current == target {
+				continue
+			}
+		}
+		toSet = append(toSet, target)
+	}
+	var toUnset []string
+	for name := range app.Env {
+		if _, ok := rev.Envs[name]; !ok {
+			toUnset = append(toUnset, name)
+		}
+	}
+	if len(toSet) == 0 && len(toUnset) == 0 {
+		if args.Writer != nil {
+			fmt.Fprintf(args.Writer, "---- Environment variables already match revision %d ----\n", args.Revision)
+		}
+		return nil
+	}
+	sort.Slice(toSet, func(i, j int) bool {
+		return toSet[i].Name < toSet[j].Name
+	})
+	sort.Strings(toUnset)
+	oldEnv := app.copyEnv()
+	if len(toSet) > 0 {
+		err = app.setEnvs(bind.SetEnvArgs{Envs: toSet, Writer: args.Writer})
+		if err != nil {
+			return err
+		}
+	}
+	if len(toUnset) > 0 {
+		err = app.unsetEnvs(bind.UnsetEnvArgs{VariableNames: toUnset, Writer: args.Writer})
+		if err != nil {
+			return err
+		}
+	}
+	app.recordEnvRevision(oldEnv, args.Owner, args.Revision)
+	if args.ShouldRestart {
+		return app.restartIfUnits(args.Writer)
+	}
+	return nil
+}
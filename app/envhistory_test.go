@@ -0,0 +1,202 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/app/envcrypt"
+	check "gopkg.in/check.v1"
+)
+
+func envTestHash(c *check.C, value string) string {
+	key, err := envHashKey()
+	c.Assert(err, check.IsNil)
+	return envValueHash(key, bind.EnvVar{Value: value})
+}
+
+func (s *S) TestEnvValueHashIsKeyed(c *check.C) {
+	plainSum := sha256.Sum256([]byte("secret"))
+	c.Assert(envTestHash(c, "secret"), check.Not(check.Equals), hex.EncodeToString(plainSum[:]))
+	c.Assert(envTestHash(c, "secret"), check.Equals, envTestHash(c, "secret"))
+	c.Assert(envValueHash([]byte("other key"), bind.EnvVar{Value: "secret"}), check.Not(check.Equals), envTestHash(c, "secret"))
+}
+
+func (s *S) TestSetEnvsRecordsRevision(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs:  []bind.EnvVar{{Name: "DATABASE_HOST", Value: "localhost", Public: true}},
+		Owner: "admin@tsuru.io",
+	})
+	c.Assert(err, check.IsNil)
+	err = a.UnsetEnvs(bind.UnsetEnvArgs{
+		VariableNames: []string{"DATABASE_HOST"},
+		Owner:         "other@tsuru.io",
+	})
+	c.Assert(err, check.IsNil)
+	revisions, err := ListEnvRevisions(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(revisions, check.HasLen, 3)
+	c.Assert(revisions[0].Revision, check.Equals, 3)
+	c.Assert(revisions[0].Owner, check.Equals, "other@tsuru.io")
+	c.Assert(revisions[0].Envs, check.IsNil)
+	c.Assert(revisions[0].Changes, check.DeepEquals, []EnvChange{
+		{Name: "DATABASE_HOST", OldHash: envTestHash(c, "localhost")},
+	})
+	c.Assert(revisions[1].Revision, check.Equals, 2)
+	c.Assert(revisions[1].Owner, check.Equals, "admin@tsuru.io")
+	c.Assert(revisions[1].Changes, check.DeepEquals, []EnvChange{
+		{Name: "DATABASE_HOST", NewHash: envTestHash(c, "localhost")},
+	})
+	rev, err := GetEnvRevision(a.Name, 2)
+	c.Assert(err, check.IsNil)
+	c.Assert(rev.Envs["DATABASE_HOST"], check.DeepEquals, bind.EnvVar{Name: "DATABASE_HOST", Value: "localhost", Public: true})
+	c.Assert(rev.Envs["TSURU_APPNAME"].Value, check.Equals, a.Name)
+}
+
+func (s *S) TestSetEnvsWithoutChangesDoesNotRecordRevision(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	envs := []bind.EnvVar{{Name: "DATABASE_HOST", Value: "localhost", Public: true}}
+	err = a.SetEnvs(bind.SetEnvArgs{Envs: envs})
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{Envs: envs})
+	c.Assert(err, check.IsNil)
+	revisions, err := ListEnvRevisions(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(revisions, check.HasLen, 2)
+}
+
+func (s *S) TestGetEnvRevisionNotFound(c *check.C) {
+	_, err := GetEnvRevision("myapp", 1)
+	c.Assert(err, check.Equals, ErrEnvRevisionNotFound)
+}
+
+func (s *S) TestRollbackEnvs(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	s.provisioner.AddUnits(&a, 1, "web", nil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{
+			{Name: "DATABASE_HOST", Value: "localhost", Public: true},
+			{Name: "DATABASE_PASSWORD", Value: "secret"},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{
+			{Name: "DATABASE_HOST", Value: "remotehost", Public: true},
+			{Name: "DATABASE_USER", Value: "root", Public: true},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = a.UnsetEnvs(bind.UnsetEnvArgs{VariableNames: []string{"DATABASE_PASSWORD"}})
+	c.Assert(err, check.IsNil)
+	var buf bytes.Buffer
+	err = a.RollbackEnvs(EnvRollbackArgs{
+		Revision:      2,
+		ShouldRestart: true,
+		Writer:        &buf,
+		Owner:         "admin@tsuru.io",
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(buf.String(), check.Equals, "---- Setting 2 new environment variables ----\n"+
+		"---- Unsetting 1 environment variables ----\nrestarting app")
+	c.Assert(s.provisioner.Restarts(&a, ""), check.Equals, 1)
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.Env["DATABASE_HOST"], check.DeepEquals, bind.EnvVar{Name: "DATABASE_HOST", Value: "localhost", Public: true})
+	c.Assert(dbApp.Env["DATABASE_PASSWORD"], check.DeepEquals, bind.EnvVar{Name: "DATABASE_PASSWORD", Value: "secret"})
+	_, ok := dbApp.Env["DATABASE_USER"]
+	c.Assert(ok, check.Equals, false)
+	revisions, err := ListEnvRevisions(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(revisions, check.HasLen, 5)
+	c.Assert(revisions[0].Revision, check.Equals, 5)
+	c.Assert(revisions[0].Rollback, check.Equals, 2)
+	c.Assert(revisions[0].Owner, check.Equals, "admin@tsuru.io")
+	c.Assert(revisions[0].Changes, check.HasLen, 3)
+}
+
+func (s *S) TestRollbackEnvsNoChanges(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{{Name: "DATABASE_HOST", Value: "localhost", Public: true}},
+	})
+	c.Assert(err, check.IsNil)
+	var buf bytes.Buffer
+	err = a.RollbackEnvs(EnvRollbackArgs{Revision: 2, ShouldRestart: true, Writer: &buf})
+	c.Assert(err, check.IsNil)
+	c.Assert(buf.String(), check.Equals, "---- Environment variables already match revision 2 ----\n")
+	revisions, err := ListEnvRevisions(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(revisions, check.HasLen, 2)
+}
+
+func (s *S) TestRollbackEnvsEncrypted(c *check.C) {
+	defer enableEnvEncryption(c)()
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{{Name: "DATABASE_PASSWORD", Value: "secret"}},
+	})
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{{Name: "DATABASE_PASSWORD", Value: "newsecret"}},
+	})
+	c.Assert(err, check.IsNil)
+	revisions, err := ListEnvRevisions(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(revisions[0].Changes, check.DeepEquals, []EnvChange{{
+		Name:    "DATABASE_PASSWORD",
+		OldHash: envTestHash(c, "secret"),
+		NewHash: envTestHash(c, "newsecret"),
+	}})
+	rev, err := GetEnvRevision(a.Name, 2)
+	c.Assert(err, check.IsNil)
+	c.Assert(envcrypt.IsEncrypted(rev.Envs["DATABASE_PASSWORD"].Value), check.Equals, true)
+	err = a.RollbackEnvs(EnvRollbackArgs{Revision: 2})
+	c.Assert(err, check.IsNil)
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.Envs()["DATABASE_PASSWORD"].Value, check.Equals, "secret")
+}
+
+func (s *S) TestReencryptEnvsRevisions(c *check.C) {
+	defer enableEnvEncryption(c)()
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{
+		Envs: []bind.EnvVar{{Name: "DATABASE_PASSWORD", Value: "secret"}},
+	})
+	c.Assert(err, check.IsNil)
+	revisions, err := ListEnvRevisions(a.Name)
+	c.Assert(err, check.IsNil)
+	revision := revisions[0].Revision
+	provider, err := envcrypt.Provider()
+	c.Assert(err, check.IsNil)
+	_, err = provider.(envcrypt.KeyRotator).RotateKey()
+	c.Assert(err, check.IsNil)
+	err = a.ReencryptEnvs()
+	c.Assert(err, check.IsNil)
+	rev, err := GetEnvRevision(a.Name, revision)
+	c.Assert(err, check.IsNil)
+	keyID, _ := envcrypt.KeyID(rev.Envs["DATABASE_PASSWORD"].Value)
+	c.Assert(keyID, check.Equals, "key2")
+	e, err := decryptEnv(rev.Envs["DATABASE_PASSWORD"])
+	c.Assert(err, check.IsNil)
+	c.Assert(e.Value, check.Equals, "secret")
+}
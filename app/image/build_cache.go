@@ -0,0 +1,133 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package image
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/db/storage"
+)
+
+const defaultBuildCachePath = "/home/application/cache"
+
+// BuildCache is the persistent build cache of an app. When enabled, the
+// content of the cache directory is saved in the cache image after each build
+// and restored in the next one.
+type BuildCache struct {
+	AppName   string    `bson:"_id" json:"app"`
+	Enabled   bool      `json:"enabled"`
+	Image     string    `json:"image,omitempty"`
+	Path      string    `bson:"-" json:"path"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+// BuildCachePath returns the directory, inside the build container, persisted
+// between builds of apps with the build cache enabled.
+func BuildCachePath() string {
+	path, _ := config.GetString("docker:build-cache:path")
+	if path == "" {
+		path = defaultBuildCachePath
+	}
+	return path
+}
+
+// AppBuildCacheImageName returns the name of the image holding the build
+// cache of the app.
+func AppBuildCacheImageName(appName, teamOwner string) string {
+	return appBasicBuilderImageName(appName, teamOwner) + ":build-cache"
+}
+
+// GetAppBuildCache returns the build cache of the app, which is disabled if it
+// was never enabled.
+func GetAppBuildCache(appName string) (*BuildCache, error) {
+	coll, err := appBuildCacheColl()
+	if err != nil {
+		return nil, err
+	}
+	defer coll.Close()
+	cache := BuildCache{AppName: appName}
+	err = coll.FindId(appName).One(&cache)
+	if err != nil && err != mgo.ErrNotFound {
+		return nil, err
+	}
+	cache.Path = BuildCachePath()
+	return &cache, nil
+}
+
+// SetAppBuildCacheEnabled enables or disables the build cache of the app. The
+// cache image is kept when the cache is disabled, until it's collected.
+func SetAppBuildCacheEnabled(appName string, enabled bool) error {
+	coll, err := appBuildCacheColl()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	_, err = coll.UpsertId(appName, bson.M{"$set": bson.M{"enabled": enabled}})
+	return err
+}
+
+// UpdateAppBuildCacheImage records imageName as the cache image of the app.
+func UpdateAppBuildCacheImage(appName, imageName string) error {
+	coll, err := appBuildCacheColl()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	_, err = coll.UpsertId(appName, bson.M{"$set": bson.M{"image": imageName, "updatedat": time.Now().UTC()}})
+	return err
+}
+
+// RemoveAppBuildCacheImage forgets the cache image of the app, so the next
+// build starts with an empty cache.
+func RemoveAppBuildCacheImage(appName string) error {
+	coll, err := appBuildCacheColl()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	err = coll.UpdateId(appName, bson.M{"$unset": bson.M{"image": "", "updatedat": ""}})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// DeleteAppBuildCache removes every information about the build cache of the
+// app.
+func DeleteAppBuildCache(appName string) error {
+	coll, err := appBuildCacheColl()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	err = coll.RemoveId(appName)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func listAppBuildCaches() ([]BuildCache, error) {
+	coll, err := appBuildCacheColl()
+	if err != nil {
+		return nil, err
+	}
+	defer coll.Close()
+	var caches []BuildCache
+	err = coll.Find(nil).All(&caches)
+	return caches, err
+}
+
+func appBuildCacheColl() (*storage.Collection, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Collection("builder_app_cache"), nil
+}
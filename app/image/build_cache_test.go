@@ -0,0 +1,85 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package image
+
+import (
+	"github.com/tsuru/config"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestGetAppBuildCacheDefault(c *check.C) {
+	cache, err := GetAppBuildCache("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(cache, check.DeepEquals, &BuildCache{AppName: "myapp", Path: "/home/application/cache"})
+}
+
+func (s *S) TestGetAppBuildCacheCustomPath(c *check.C) {
+	config.Set("docker:build-cache:path", "/var/cache/build")
+	defer config.Unset("docker:build-cache:path")
+	cache, err := GetAppBuildCache("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(cache.Path, check.Equals, "/var/cache/build")
+}
+
+func (s *S) TestAppBuildCacheImageName(c *check.C) {
+	c.Assert(AppBuildCacheImageName("myapp", "team"), check.Equals, "team/app-myapp:build-cache")
+	c.Assert(AppBuildCacheImageName("myapp", ""), check.Equals, "tsuru/app-myapp:build-cache")
+	config.Set("docker:registry", "localhost:3030")
+	defer config.Unset("docker:registry")
+	c.Assert(AppBuildCacheImageName("myapp", "team"), check.Equals, "localhost:3030/team/app-myapp:build-cache")
+}
+
+func (s *S) TestSetAppBuildCacheEnabled(c *check.C) {
+	err := SetAppBuildCacheEnabled("myapp", true)
+	c.Assert(err, check.IsNil)
+	err = UpdateAppBuildCacheImage("myapp", "tsuru/app-myapp:build-cache")
+	c.Assert(err, check.IsNil)
+	err = SetAppBuildCacheEnabled("myapp", false)
+	c.Assert(err, check.IsNil)
+	cache, err := GetAppBuildCache("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(cache.Enabled, check.Equals, false)
+	c.Assert(cache.Image, check.Equals, "tsuru/app-myapp:build-cache")
+	c.Assert(cache.UpdatedAt.IsZero(), check.Equals, false)
+}
+
+func (s *S) TestRemoveAppBuildCacheImage(c *check.C) {
+	err := SetAppBuildCacheEnabled("myapp", true)
+	c.Assert(err, check.IsNil)
+	err = UpdateAppBuildCacheImage("myapp", "tsuru/app-myapp:build-cache")
+	c.Assert(err, check.IsNil)
+	err = RemoveAppBuildCacheImage("myapp")
+	c.Assert(err, check.IsNil)
+	cache, err := GetAppBuildCache("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(cache, check.DeepEquals, &BuildCache{AppName: "myapp", Enabled: true, Path: "/home/application/cache"})
+	err = RemoveAppBuildCacheImage("otherapp")
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestListAllAppImagesWithBuildCache(c *check.C) {
+	err := AppendAppImageName("myapp", "tsuru/app-myapp:v1")
+	c.Assert(err, check.IsNil)
+	err = UpdateAppBuildCacheImage("myapp", "tsuru/app-myapp:build-cache")
+	c.Assert(err, check.IsNil)
+	err = SetAppBuildCacheEnabled("myapp", true)
+	c.Assert(err, check.IsNil)
+	err = UpdateAppBuildCacheImage("removedapp", "tsuru/app-removedapp:build-cache")
+	c.Assert(err, check.IsNil)
+	err = SetAppBuildCacheEnabled("emptyapp", true)
+	c.Assert(err, check.IsNil)
+	images, err := ListAllAppImages()
+	c.Assert(err, check.IsNil)
+	c.Assert(images, check.DeepEquals, map[string]AllAppImages{
+		"myapp": {
+			DeployImages:      []string{"tsuru/app-myapp:v1"},
+			BuildCacheImage:   "tsuru/app-myapp:build-cache",
+			BuildCacheEnabled: true,
+		},
+		"removedapp": {
+			BuildCacheImage: "tsuru/app-removedapp:build-cache",
+		},
+	})
+}
@@ -76,6 +76,25 @@ func CleanImage(appName string, imgName string, removeFromRegistry bool) {
 	cleanImageForApp(a, appName, imgName, removeFromRegistry)
 }
 
+// removeBuildCache removes the build cache image of an app from the
+// provisioner, when the app still exists, and from the registry.
+func removeBuildCache(a *app.App, appName string, imgName string) error {
+	if a != nil {
+		err := a.CleanImage(imgName)
+		if err != nil {
+			return err
+		}
+	}
+	err := registry.RemoveImageIgnoreNotFound(imgName)
+	if err != nil {
+		return err
+	}
+	if a == nil {
+		return image.DeleteAppBuildCache(appName)
+	}
+	return image.RemoveAppBuildCacheImage(appName)
+}
+
 func cleanImageForApp(a *app.App, appName string, imgName string, removeFromRegistry bool) {
 	shouldRemove := true
 	defer func() {
@@ -128,8 +147,21 @@ func removeOldImages() error {
 			if err != nil {
 				multi.Add(err)
 			}
+			if appImages.BuildCacheImage != "" {
+				err = removeBuildCache(nil, appName, appImages.BuildCacheImage)
+				if err != nil {
+					multi.Add(err)
+				}
+			}
 			continue
 		}
+		if appImages.BuildCacheImage != "" && !appImages.BuildCacheEnabled {
+			log.Debugf("[image gc] build cache of app %q disabled, removing image %q", appName, appImages.BuildCacheImage)
+			err = removeBuildCache(a, appName, appImages.BuildCacheImage)
+			if err != nil {
+				multi.Add(err)
+			}
+		}
 		limit := len(appImages.DeployImages) - historySize
 		for i, imgName := range appImages.DeployImages {
 			if i == len(appImages.DeployImages)-1 {
@@ -347,3 +347,75 @@ func (s *S) TestGCStartWithAppStressNotFound(c *check.C) {
 		u.Host + "/tsuru/app-myapp:v11-builder",
 	})
 }
+
+func (s *S) TestGCRemovesBuildCacheOfRemovedApp(c *check.C) {
+	var regDeleteCalls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.Header().Set("Docker-Content-Digest", "sha256:cache")
+			return
+		}
+		if r.Method == "DELETE" {
+			regDeleteCalls = append(regDeleteCalls, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	err := image.UpdateAppBuildCacheImage("myapp", fmt.Sprintf("%s/myteam/app-myapp:build-cache", u.Host))
+	c.Assert(err, check.IsNil)
+	gc := &imgGC{once: &sync.Once{}}
+	gc.start()
+	err = gc.Shutdown(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(regDeleteCalls, check.DeepEquals, []string{"/v2/myteam/app-myapp/manifests/sha256:cache"})
+	images, err := image.ListAllAppImages()
+	c.Assert(err, check.IsNil)
+	c.Assert(images, check.HasLen, 0)
+}
+
+func (s *S) TestGCRemovesDisabledBuildCache(c *check.C) {
+	var regDeleteCalls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.Header().Set("Docker-Content-Digest", "sha256:cache")
+			return
+		}
+		if r.Method == "DELETE" {
+			regDeleteCalls = append(regDeleteCalls, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	var nodeDeleteCalls []string
+	nodeSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			nodeDeleteCalls = append(nodeDeleteCalls, r.URL.Path)
+		}
+	}))
+	defer nodeSrv.Close()
+	err := provisiontest.ProvisionerInstance.AddNode(provision.AddNodeOptions{
+		Address: nodeSrv.URL,
+		Pool:    "p1",
+	})
+	c.Assert(err, check.IsNil)
+	s.mockService.Team.OnList = func() ([]authTypes.Team, error) {
+		return []authTypes.Team{{Name: s.team}}, nil
+	}
+	a := &app.App{Name: "myapp", TeamOwner: s.team, Pool: "p1"}
+	err = app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	cacheImage := fmt.Sprintf("%s/myteam/app-myapp:build-cache", u.Host)
+	err = image.UpdateAppBuildCacheImage("myapp", cacheImage)
+	c.Assert(err, check.IsNil)
+	err = a.SetBuildCache(false)
+	c.Assert(err, check.IsNil)
+	gc := &imgGC{once: &sync.Once{}}
+	gc.start()
+	err = gc.Shutdown(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(regDeleteCalls, check.DeepEquals, []string{"/v2/myteam/app-myapp/manifests/sha256:cache"})
+	c.Assert(nodeDeleteCalls, check.DeepEquals, []string{"/images/" + cacheImage})
+	cache, err := a.BuildCache()
+	c.Assert(err, check.IsNil)
+	c.Assert(cache.Image, check.Equals, "")
+}
@@ -52,6 +52,7 @@ type ImageMetadata struct {
 	ExposedPort     string
 	DisableRollback bool
 	Reason          string
+	Provenance      *ImageProvenance `bson:",omitempty"`
 }
 
 type appImages struct {
@@ -80,16 +81,18 @@ func (i *ImageMetadata) Save() error {
 // in all other cases the app image name will be returned.
 func GetBuildImage(app provision.App) (string, error) {
 	if usePlatformImage(app) {
-		return getPlatformImage(app)
+		return GetPlatformImage(app)
 	}
 	appImageName, err := AppCurrentImageName(app.GetName())
 	if err != nil {
-		return getPlatformImage(app)
+		return GetPlatformImage(app)
 	}
 	return appImageName, nil
 }
 
-func getPlatformImage(app provision.App) (string, error) {
+// GetPlatformImage returns the image of the platform, in the version used by
+// the app.
+func GetPlatformImage(app provision.App) (string, error) {
 	version := app.GetPlatformVersion()
 	if version != "latest" {
 		return servicemanager.PlatformImage.FindImage(app.GetPlatform(), version)
@@ -147,6 +150,32 @@ func SaveImageCustomData(imageName string, customData map[string]interface{}) er
 	return data.Save()
 }
 
+// CopyImageMetadata stores the metadata of srcImage (processes, tsuru.yaml
+// data, exposed port, provenance and SBOM) under dstImage, used when an image
+// built for an app is promoted to another app without being rebuilt.
+func CopyImageMetadata(srcImage, dstImage string) error {
+	data, err := GetImageMetaData(srcImage)
+	if err != nil {
+		return err
+	}
+	newData := ImageMetadata{
+		Name:        dstImage,
+		CustomData:  data.CustomData,
+		Processes:   data.Processes,
+		ExposedPort: data.ExposedPort,
+		Provenance:  data.Provenance,
+	}
+	err = newData.Save()
+	if err != nil {
+		return err
+	}
+	sbom, err := GetImageSBOM(srcImage)
+	if err != nil || sbom == nil {
+		return err
+	}
+	return SaveImageProvenance(dstImage, data.Provenance, sbom)
+}
+
 func GetImageMetaData(imageName string) (ImageMetadata, error) {
 	coll, err := imageCustomDataColl()
 	if err != nil {
@@ -282,8 +311,10 @@ func AppendAppImageName(appName, imageID string) error {
 }
 
 type AllAppImages struct {
-	DeployImages  []string
-	BuilderImages []string
+	DeployImages      []string
+	BuilderImages     []string
+	BuildCacheImage   string
+	BuildCacheEnabled bool
 }
 
 func ListAllAppImages() (map[string]AllAppImages, error) {
@@ -320,6 +351,19 @@ func ListAllAppImages() (map[string]AllAppImages, error) {
 		appData.BuilderImages = img.Images
 		ret[img.AppName] = appData
 	}
+	caches, err := listAppBuildCaches()
+	if err != nil {
+		return nil, err
+	}
+	for _, cache := range caches {
+		if cache.Image == "" {
+			continue
+		}
+		appData := ret[cache.AppName]
+		appData.BuildCacheImage = cache.Image
+		appData.BuildCacheEnabled = cache.Enabled
+		ret[cache.AppName] = appData
+	}
 	return ret, nil
 }
 
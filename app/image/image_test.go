@@ -303,6 +303,37 @@ func (s *S) TestSaveImageCustomData(c *check.C) {
 	})
 }
 
+func (s *S) TestCopyImageMetadata(c *check.C) {
+	img1 := "tsuru/app-staging:v1"
+	customData1 := map[string]interface{}{
+		"exposedPort": "3434",
+		"hooks": map[string]interface{}{
+			"build": []string{"./build.sh"},
+		},
+		"processes": map[string]interface{}{
+			"web":    "python myapp.py",
+			"worker": "someworker",
+		},
+	}
+	err := SaveImageCustomData(img1, customData1)
+	c.Assert(err, check.IsNil)
+	err = UpdateAppImageRollback(img1, "broken", true)
+	c.Assert(err, check.IsNil)
+	err = CopyImageMetadata(img1, "tsuru/app-production:v3")
+	c.Assert(err, check.IsNil)
+	imageMetaData, err := GetImageMetaData("tsuru/app-production:v3")
+	c.Assert(err, check.IsNil)
+	c.Check(imageMetaData.ExposedPort, check.Equals, "3434")
+	c.Check(imageMetaData.DisableRollback, check.Equals, false)
+	c.Check(imageMetaData.Processes, check.DeepEquals, map[string][]string{
+		"web":    {"python myapp.py"},
+		"worker": {"someworker"},
+	})
+	yamlData, err := GetImageTsuruYamlData("tsuru/app-production:v3")
+	c.Assert(err, check.IsNil)
+	c.Check(yamlData.Hooks.Build, check.DeepEquals, []string{"./build.sh"})
+}
+
 func (s *S) TestSaveImageCustomDataProcfile(c *check.C) {
 	img1 := "tsuru/app-myapp:v1"
 	customData1 := map[string]interface{}{
@@ -0,0 +1,80 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package image
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+)
+
+// ImageProvenance describes how an image was built: its source, the builder
+// used and the platform image it was built from.
+type ImageProvenance struct {
+	Builder             string    `json:"builder"`
+	Kind                string    `json:"kind"`
+	SourceArchiveDigest string    `json:"sourceArchiveDigest,omitempty"`
+	SourceArchiveURL    string    `json:"sourceArchiveURL,omitempty"`
+	SourceImage         string    `json:"sourceImage,omitempty"`
+	Commit              string    `json:"commit,omitempty"`
+	PlatformImage       string    `json:"platformImage,omitempty"`
+	PlatformImageDigest string    `json:"platformImageDigest,omitempty"`
+	BuiltAt             time.Time `json:"builtAt"`
+}
+
+// SaveImageProvenance stores the provenance and the SBOM of the image along
+// with its metadata. Nil values are ignored.
+func SaveImageProvenance(imageName string, provenance *ImageProvenance, sbom *SBOM) error {
+	if imageName == "" {
+		return errors.New("image name is mandatory")
+	}
+	update := bson.M{}
+	if provenance != nil {
+		update["provenance"] = provenance
+	}
+	if sbom != nil {
+		update["sbom"] = sbom
+	}
+	if len(update) == 0 {
+		return nil
+	}
+	coll, err := imageCustomDataColl()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	_, err = coll.UpsertId(imageName, bson.M{"$set": update})
+	return err
+}
+
+// GetImageProvenance returns the provenance of the image, or nil if it's
+// unknown.
+func GetImageProvenance(imageName string) (*ImageProvenance, error) {
+	data, err := GetImageMetaData(imageName)
+	if err != nil {
+		return nil, err
+	}
+	return data.Provenance, nil
+}
+
+// GetImageSBOM returns the software bill of materials of the image, or nil if
+// none was generated when it was built.
+func GetImageSBOM(imageName string) (*SBOM, error) {
+	coll, err := imageCustomDataColl()
+	if err != nil {
+		return nil, err
+	}
+	defer coll.Close()
+	var data struct {
+		SBOM *SBOM
+	}
+	err = coll.FindId(imageName).Select(bson.M{"sbom": 1}).One(&data)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return data.SBOM, err
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package image
+
+import (
+	"time"
+
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestSaveImageProvenance(c *check.C) {
+	imageData := ImageMetadata{
+		Name:      "tsuru/app-myapp:v1",
+		Processes: map[string][]string{"web": {"python app.py"}},
+	}
+	err := imageData.Save()
+	c.Assert(err, check.IsNil)
+	provenance := &ImageProvenance{
+		Builder:             "docker",
+		Kind:                "git",
+		SourceArchiveDigest: "sha256:abc",
+		Commit:              "1ee1f1084927b3a5db59c9033bc5c4abefb7b93c",
+		PlatformImage:       "tsuru/python:v1",
+		PlatformImageDigest: "tsuru/python@sha256:def",
+		BuiltAt:             time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC),
+	}
+	sbom := NewSBOM("tsuru/app-myapp:v1", []SBOMComponent{{Type: "library", Name: "bash", Version: "4.4"}})
+	err = SaveImageProvenance("tsuru/app-myapp:v1", provenance, sbom)
+	c.Assert(err, check.IsNil)
+	data, err := GetImageMetaData("tsuru/app-myapp:v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(data.Processes, check.DeepEquals, map[string][]string{"web": {"python app.py"}})
+	c.Assert(data.Provenance.BuiltAt.Equal(provenance.BuiltAt), check.Equals, true)
+	data.Provenance.BuiltAt = provenance.BuiltAt
+	c.Assert(data.Provenance, check.DeepEquals, provenance)
+	dbSBOM, err := GetImageSBOM("tsuru/app-myapp:v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbSBOM.Components, check.DeepEquals, sbom.Components)
+	c.Assert(dbSBOM.Metadata.Timestamp.Equal(sbom.Metadata.Timestamp), check.Equals, true)
+}
+
+func (s *S) TestGetImageSBOMNotFound(c *check.C) {
+	sbom, err := GetImageSBOM("tsuru/app-myapp:v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(sbom, check.IsNil)
+	err = SaveImageCustomData("tsuru/app-myapp:v1", map[string]interface{}{})
+	c.Assert(err, check.IsNil)
+	sbom, err = GetImageSBOM("tsuru/app-myapp:v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(sbom, check.IsNil)
+}
+
+func (s *S) TestCopyImageMetadataWithProvenance(c *check.C) {
+	err := SaveImageCustomData("tsuru/app-staging:v1", map[string]interface{}{})
+	c.Assert(err, check.IsNil)
+	provenance := &ImageProvenance{Builder: "docker", Kind: "git"}
+	sbom := NewSBOM("tsuru/app-staging:v1", []SBOMComponent{{Type: "library", Name: "bash", Version: "4.4"}})
+	err = SaveImageProvenance("tsuru/app-staging:v1", provenance, sbom)
+	c.Assert(err, check.IsNil)
+	err = CopyImageMetadata("tsuru/app-staging:v1", "tsuru/app-production:v1")
+	c.Assert(err, check.IsNil)
+	dbProvenance, err := GetImageProvenance("tsuru/app-production:v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbProvenance, check.DeepEquals, provenance)
+	dbSBOM, err := GetImageSBOM("tsuru/app-production:v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbSBOM.Components, check.DeepEquals, sbom.Components)
+}
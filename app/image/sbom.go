@@ -0,0 +1,210 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sbomFormat      = "CycloneDX"
+	sbomSpecVersion = "1.4"
+
+	// SBOMScopeProperty is the metadata property telling what the components
+	// of the SBOM cover. Only the packages of the operating system are
+	// listed, dependencies installed by the language package managers of the
+	// app, like pip or npm, are not.
+	SBOMScopeProperty = "tsuru:sbom:scope"
+	SBOMScopeOS       = "os-packages"
+)
+
+// SBOM is the software bill of materials of an image, in the CycloneDX JSON
+// format. It covers the packages of the operating system of the image only.
+type SBOM struct {
+	BOMFormat   string          `json:"bomFormat"`
+	SpecVersion string          `json:"specVersion"`
+	Version     int             `json:"version"`
+	Metadata    SBOMMetadata    `json:"metadata"`
+	Components  []SBOMComponent `json:"components"`
+}
+
+type SBOMMetadata struct {
+	Timestamp  time.Time      `json:"timestamp"`
+	Tools      []SBOMTool     `json:"tools,omitempty"`
+	Component  SBOMComponent  `json:"component"`
+	Properties []SBOMProperty `json:"properties,omitempty"`
+}
+
+type SBOMProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type SBOMTool struct {
+	Vendor string `json:"vendor"`
+	Name   string `json:"name"`
+}
+
+type SBOMComponent struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+// NewSBOM returns the SBOM of the image containing the given components,
+// sorted by name.
+func NewSBOM(imageName string, components []SBOMComponent) *SBOM {
+	sort.SliceStable(components, func(i, j int) bool {
+		if components[i].Name == components[j].Name {
+			return components[i].Version < components[j].Version
+		}
+		return components[i].Name < components[j].Name
+	})
+	if components == nil {
+		components = []SBOMComponent{}
+	}
+	return &SBOM{
+		BOMFormat:   sbomFormat,
+		SpecVersion: sbomSpecVersion,
+		Version:     1,
+		Metadata: SBOMMetadata{
+			Timestamp:  time.Now().UTC().Truncate(time.Second),
+			Tools:      []SBOMTool{{Vendor: "tsuru", Name: "tsuru"}},
+			Component:  SBOMComponent{Type: "container", Name: imageName},
+			Properties: []SBOMProperty{{Name: SBOMScopeProperty, Value: SBOMScopeOS}},
+		},
+		Components: components,
+	}
+}
+
+// SBOMPackageDatabases maps the path of the package databases of the
+// supported distributions to the function parsing them.
+var SBOMPackageDatabases = map[string]func([]byte) []SBOMComponent{
+	"/var/lib/dpkg/status":  ParseDpkgStatus,
+	"/lib/apk/db/installed": ParseApkInstalled,
+}
+
+// SBOMPackageDatabasePaths returns the sorted paths of the package databases
+// in SBOMPackageDatabases.
+func SBOMPackageDatabasePaths() []string {
+	paths := make([]string, 0, len(SBOMPackageDatabases))
+	for p := range SBOMPackageDatabases {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// NewSBOMFromArchive generates the SBOM of the image from the package
+// databases found in a tar archive of files copied from its root.
+func NewSBOMFromArchive(imageName string, archive io.Reader) (*SBOM, error) {
+	files := make(map[string][]byte)
+	reader := tar.NewReader(archive)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+		name := path.Join("/", header.Name)
+		if _, ok := SBOMPackageDatabases[name]; !ok {
+			continue
+		}
+		files[name], err = ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var components []SBOMComponent
+	for _, p := range SBOMPackageDatabasePaths() {
+		if data, ok := files[p]; ok {
+			components = append(components, SBOMPackageDatabases[p](data)...)
+		}
+	}
+	return NewSBOM(imageName, components), nil
+}
+
+// ParseDpkgStatus returns the packages installed according to the status
+// database of dpkg.
+func ParseDpkgStatus(data []byte) []SBOMComponent {
+	var components []SBOMComponent
+	for _, fields := range parsePackageStanzas(data, ": ") {
+		if fields["Package"] == "" || !strings.HasSuffix(fields["Status"], " installed") {
+			continue
+		}
+		components = append(components, packageComponent("deb", fields["Package"], fields["Version"], fields["Architecture"]))
+	}
+	return components
+}
+
+// ParseApkInstalled returns the packages installed according to the database
+// of apk.
+func ParseApkInstalled(data []byte) []SBOMComponent {
+	var components []SBOMComponent
+	for _, fields := range parsePackageStanzas(data, ":") {
+		if fields["P"] == "" {
+			continue
+		}
+		components = append(components, packageComponent("apk", fields["P"], fields["V"], fields["A"]))
+	}
+	return components
+}
+
+func packageComponent(purlType, name, version, arch string) SBOMComponent {
+	purl := fmt.Sprintf("pkg:%s/%s", purlType, url.PathEscape(name))
+	if version != "" {
+		purl += "@" + url.PathEscape(version)
+	}
+	if arch != "" {
+		purl += "?arch=" + url.QueryEscape(arch)
+	}
+	return SBOMComponent{Type: "library", Name: name, Version: version, PURL: purl}
+}
+
+// parsePackageStanzas parses databases made of blank line separated stanzas
+// of "<key><sep><value>" lines, ignoring continuation lines.
+func parsePackageStanzas(data []byte, sep string) []map[string]string {
+	var stanzas []map[string]string
+	current := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				stanzas = append(stanzas, current)
+				current = map[string]string{}
+			}
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		parts := strings.SplitN(line, sep, 2)
+		if len(parts) == 2 {
+			current[parts[0]] = strings.TrimSpace(parts[1])
+		}
+	}
+	if len(current) > 0 {
+		stanzas = append(stanzas, current)
+	}
+	return stanzas
+}
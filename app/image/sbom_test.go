@@ -0,0 +1,99 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestParseDpkgStatus(c *check.C) {
+	data := `Package: libc6
+Status: install ok installed
+Priority: optional
+Architecture: amd64
+Version: 2.27-3ubuntu1
+Description: GNU C Library: Shared libraries
+ Contains the standard libraries that are used by nearly all programs on
+ the system.
+
+Package: removed-pkg
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 1.0
+
+Package: tzdata
+Status: install ok installed
+Architecture: all
+Version: 2018e-0ubuntu0.18.04
+`
+	c.Assert(ParseDpkgStatus([]byte(data)), check.DeepEquals, []SBOMComponent{
+		{Type: "library", Name: "libc6", Version: "2.27-3ubuntu1", PURL: "pkg:deb/libc6@2.27-3ubuntu1?arch=amd64"},
+		{Type: "library", Name: "tzdata", Version: "2018e-0ubuntu0.18.04", PURL: "pkg:deb/tzdata@2018e-0ubuntu0.18.04?arch=all"},
+	})
+}
+
+func (s *S) TestParseApkInstalled(c *check.C) {
+	data := `C:Q1Ef8Y7o2JzFGnXYu1HcORwQXnaEI=
+P:musl
+V:1.1.19-r10
+A:x86_64
+T:the musl c library (libc) implementation
+
+C:Q1vJ5kDrN3Bc5A4fcZB3dgHMGvH2E=
+P:busybox
+V:1.28.4-r0
+A:x86_64
+`
+	c.Assert(ParseApkInstalled([]byte(data)), check.DeepEquals, []SBOMComponent{
+		{Type: "library", Name: "musl", Version: "1.1.19-r10", PURL: "pkg:apk/musl@1.1.19-r10?arch=x86_64"},
+		{Type: "library", Name: "busybox", Version: "1.28.4-r0", PURL: "pkg:apk/busybox@1.28.4-r0?arch=x86_64"},
+	})
+}
+
+func (s *S) TestNewSBOM(c *check.C) {
+	sbom := NewSBOM("tsuru/app-myapp:v1", []SBOMComponent{
+		{Type: "library", Name: "zlib1g", Version: "1.2.11"},
+		{Type: "library", Name: "bash", Version: "4.4"},
+	})
+	c.Assert(sbom.BOMFormat, check.Equals, "CycloneDX")
+	c.Assert(sbom.SpecVersion, check.Equals, "1.4")
+	c.Assert(sbom.Version, check.Equals, 1)
+	c.Assert(sbom.Metadata.Timestamp.IsZero(), check.Equals, false)
+	c.Assert(sbom.Metadata.Component, check.DeepEquals, SBOMComponent{Type: "container", Name: "tsuru/app-myapp:v1"})
+	c.Assert(sbom.Metadata.Properties, check.DeepEquals, []SBOMProperty{{Name: "tsuru:sbom:scope", Value: "os-packages"}})
+	c.Assert(sbom.Components, check.DeepEquals, []SBOMComponent{
+		{Type: "library", Name: "bash", Version: "4.4"},
+		{Type: "library", Name: "zlib1g", Version: "1.2.11"},
+	})
+	c.Assert(NewSBOM("tsuru/app-myapp:v1", nil).Components, check.DeepEquals, []SBOMComponent{})
+}
+
+func (s *S) TestNewSBOMFromArchive(c *check.C) {
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	files := map[string]string{
+		"lib/apk/db/installed": "P:musl\nV:1.1.19-r10\nA:x86_64\n",
+		"etc/hostname":         "myhost",
+	}
+	for name, content := range files {
+		err := writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		c.Assert(err, check.IsNil)
+		_, err = writer.Write([]byte(content))
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(writer.Close(), check.IsNil)
+	sbom, err := NewSBOMFromArchive("tsuru/app-myapp:v1", &buf)
+	c.Assert(err, check.IsNil)
+	c.Assert(sbom.Metadata.Component.Name, check.Equals, "tsuru/app-myapp:v1")
+	c.Assert(sbom.Components, check.DeepEquals, []SBOMComponent{
+		{Type: "library", Name: "musl", Version: "1.1.19-r10", PURL: "pkg:apk/musl@1.1.19-r10?arch=x86_64"},
+	})
+	sbom, err = NewSBOMFromArchive("tsuru/app-myapp:v1", &bytes.Buffer{})
+	c.Assert(err, check.IsNil)
+	c.Assert(sbom.Components, check.DeepEquals, []SBOMComponent{})
+}
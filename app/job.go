@@ -0,0 +1,425 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/api/shutdown"
+	"github.com/tsuru/tsuru/app/cron"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+	"github.com/tsuru/tsuru/validation"
+)
+
+const (
+	jobRunEventKind      = "job-run"
+	jobSchedulerInterval = 10 * time.Second
+)
+
+var (
+	ErrJobNotFound      = errors.New("job not found")
+	ErrJobAlreadyExists = errors.New("job already exists")
+)
+
+// Job is a command run periodically for an app, following a cron schedule.
+// Each run uses the current image and environment variables of the app and
+// is recorded as an event, along with its output.
+type Job struct {
+	Name         string    `json:"name"`
+	App          string    `json:"app"`
+	Schedule     string    `json:"schedule"`
+	Command      string    `json:"command"`
+	LastSchedule time.Time `json:"lastSchedule"`
+}
+
+func (j *Job) validate() error {
+	if !validation.ValidateName(j.Name) {
+		msg := "Invalid job name, job name should have at most 40 " +
+			"characters, containing only lower case letters, numbers or dashes, " +
+			"starting with a letter."
+		return &tsuruErrors.ValidationError{Message: msg}
+	}
+	if strings.TrimSpace(j.Command) == "" {
+		return &tsuruErrors.ValidationError{Message: "job command is required"}
+	}
+	_, err := cron.Parse(j.Schedule)
+	if err != nil {
+		return &tsuruErrors.ValidationError{Message: err.Error()}
+	}
+	return nil
+}
+
+func (j *Job) provisionJob() provision.Job {
+	return provision.Job{
+		Name:     j.Name,
+		Schedule: j.Schedule,
+		Cmds:     cmdsForExec(j.Command),
+	}
+}
+
+// JobTarget returns the event target used to lock runs of the job.
+func JobTarget(appName, jobName string) event.Target {
+	return event.Target{Type: event.TargetTypeJob, Value: appName + "/" + jobName}
+}
+
+func (app *App) internalEventAllowed() event.AllowedPermission {
+	return event.Allowed(permission.PermAppReadEvents, append(permission.Contexts(permTypes.CtxTeam, app.Teams),
+		permission.Context(permTypes.CtxApp, app.Name),
+		permission.Context(permTypes.CtxPool, app.Pool),
+	)...)
+}
+
+// AddJob validates and stores a new job for the app. When the app provisioner
+// is able to schedule jobs natively, the job is also created in the
+// provisioner.
+func (app *App) AddJob(job Job) error {
+	job.App = app.Name
+	job.LastSchedule = time.Now().UTC()
+	err := job.validate()
+	if err != nil {
+		return err
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.AppJobs().Insert(job)
+	if mgo.IsDup(err) {
+		return ErrJobAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	prov, err := app.getProvisioner()
+	if err == nil {
+		if jobProv, ok := prov.(provision.JobProvisioner); ok {
+			err = jobProv.EnsureJob(app, job.provisionJob())
+		}
+	}
+	if err != nil {
+		conn.AppJobs().Remove(bson.M{"app": app.Name, "name": job.Name})
+		return err
+	}
+	return nil
+}
+
+// Jobs returns the jobs of the app.
+func (app *App) Jobs() ([]Job, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var jobs []Job
+	err = conn.AppJobs().Find(bson.M{"app": app.Name}).Sort("name").All(&jobs)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// GetJob returns the job of the app with the given name.
+func (app *App) GetJob(name string) (*Job, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var job Job
+	err = conn.AppJobs().Find(bson.M{"app": app.Name, "name": name}).One(&job)
+	if err == mgo.ErrNotFound {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// RemoveJob removes the job from the app, and from the provisioner when it
+// schedules jobs natively.
+func (app *App) RemoveJob(name string) error {
+	job, err := app.GetJob(name)
+	if err != nil {
+		return err
+	}
+	prov, err := app.getProvisioner()
+	if err != nil {
+		return err
+	}
+	if jobProv, ok := prov.(provision.JobProvisioner); ok {
+		err = jobProv.RemoveJob(app, job.provisionJob())
+		if err != nil {
+			return err
+		}
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.AppJobRuns().RemoveAll(bson.M{"app": app.Name, "job": name})
+	if err != nil {
+		return err
+	}
+	return conn.AppJobs().Remove(bson.M{"app": app.Name, "name": name})
+}
+
+// updateJobs updates all jobs of the app in the provisioner, it must be called
+// whenever the image of the app changes so that native jobs use it.
+func (app *App) updateJobs() error {
+	prov, err := app.getProvisioner()
+	if err != nil {
+		return err
+	}
+	jobProv, ok := prov.(provision.JobProvisioner)
+	if !ok {
+		return nil
+	}
+	jobs, err := app.Jobs()
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		err = jobProv.EnsureJob(app, job.provisionJob())
+		if err != nil {
+			return errors.Wrapf(err, "unable to update job %q", job.Name)
+		}
+	}
+	return nil
+}
+
+func (app *App) removeJobs() error {
+	jobs, err := app.Jobs()
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		err = app.RemoveJob(job.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunJob runs the job once, in an isolated unit, writing its output to w.
+func (app *App) RunJob(job *Job, w io.Writer) error {
+	return app.Run(job.Command, w, provision.RunArgs{Isolated: true})
+}
+
+// InitializeJobScheduler starts the scheduler responsible for running jobs
+// of apps whose provisioner doesn't schedule jobs natively, and for recording
+// the runs of the ones that do.
+func InitializeJobScheduler() error {
+	s := &jobScheduler{}
+	s.periodicWorker = periodicWorker{
+		name:     "job scheduler",
+		interval: jobSchedulerInterval,
+		run:      s.runJobs,
+		once:     &sync.Once{},
+	}
+	s.start()
+	shutdown.Register(s)
+	return nil
+}
+
+type jobScheduler struct {
+	periodicWorker
+}
+
+func (s *jobScheduler) runJobs(now time.Time) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	var jobs []Job
+	err = conn.AppJobs().Find(nil).All(&jobs)
+	conn.Close()
+	if err != nil {
+		return err
+	}
+	apps := map[string]*App{}
+	for i := range jobs {
+		job := &jobs[i]
+		a, ok := apps[job.App]
+		if !ok {
+			a, err = GetByName(job.App)
+			if err != nil {
+				log.Errorf("[job scheduler] unable to get app %q: %v", job.App, err)
+				continue
+			}
+			apps[job.App] = a
+		}
+		prov, err := a.getProvisioner()
+		if err != nil {
+			log.Errorf("[job scheduler] unable to get provisioner for app %q: %v", a.Name, err)
+			continue
+		}
+		if jobProv, ok := prov.(provision.JobProvisioner); ok {
+			err = recordJobRuns(a, job, jobProv)
+		} else {
+			err = s.scheduleJob(a, job, now)
+		}
+		if err != nil {
+			log.Errorf("[job scheduler] error processing job %q of app %q: %v", job.Name, a.Name, err)
+		}
+	}
+	return nil
+}
+
+// scheduleJob runs the job in background if it's due. Missed activations,
+// caused for instance by tsuru being down, result in a single run.
+func (s *jobScheduler) scheduleJob(a *App, job *Job, now time.Time) error {
+	schedule, err := cron.Parse(job.Schedule)
+	if err != nil {
+		return err
+	}
+	var due time.Time
+	for next := schedule.Next(job.LastSchedule); !next.IsZero() && !next.After(now); next = schedule.Next(next) {
+		due = next
+	}
+	if due.IsZero() {
+		return nil
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// Only the tsuru API instance that successfully updates the last
+	// schedule runs the job.
+	err = conn.AppJobs().Update(
+		bson.M{"app": job.App, "name": job.Name, "lastschedule": job.LastSchedule},
+		bson.M{"$set": bson.M{"lastschedule": due}},
+	)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	job.LastSchedule = due
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		runErr := runScheduledJob(a, job)
+		if runErr != nil {
+			log.Errorf("[job scheduler] error running job %q of app %q: %v", job.Name, a.Name, runErr)
+		}
+	}()
+	return nil
+}
+
+func runScheduledJob(a *App, job *Job) (err error) {
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		ExtraTargets: []event.ExtraTarget{{Target: JobTarget(a.Name, job.Name), Lock: true}},
+		DisableLock:  true,
+		InternalKind: jobRunEventKind,
+		CustomData:   job,
+		Allowed:      a.internalEventAllowed(),
+	})
+	if err != nil {
+		if _, ok := err.(event.ErrEventLocked); ok {
+			log.Debugf("[job scheduler] skipping job %q of app %q, previous run still running", job.Name, a.Name)
+			return nil
+		}
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	return a.RunJob(job, evt)
+}
+
+type jobRunClaim struct {
+	ID        string `bson:"_id"`
+	App       string
+	Job       string
+	ClaimedAt time.Time
+}
+
+// claimJobRun atomically records that the run is being stored as an event,
+// returning false when another tsuru API instance already claimed it.
+func claimJobRun(appName, jobName, runName string) (bool, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	err = conn.AppJobRuns().Insert(jobRunClaim{
+		ID:        appName + "/" + jobName + "/" + runName,
+		App:       appName,
+		Job:       jobName,
+		ClaimedAt: time.Now().UTC(),
+	})
+	if mgo.IsDup(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func releaseJobRun(appName, jobName, runName string) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.AppJobRuns().RemoveId(appName + "/" + jobName + "/" + runName)
+}
+
+// recordJobRuns stores the runs of a job scheduled natively by the
+// provisioner as events. The scheduler runs in every tsuru API instance, so
+// each run is claimed in the database before being stored.
+func recordJobRuns(a *App, job *Job, prov provision.JobProvisioner) error {
+	runs, err := prov.JobRuns(a, job.provisionJob())
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		claimed, err := claimJobRun(a.Name, job.Name, run.Name)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		var evt event.Event
+		evt.UniqueID = bson.NewObjectId()
+		evt.Target = event.Target{Type: event.TargetTypeApp, Value: a.Name}
+		evt.ExtraTargets = []event.ExtraTarget{{Target: JobTarget(a.Name, job.Name)}}
+		evt.Kind = event.Kind{Type: event.KindTypeInternal, Name: jobRunEventKind}
+		evt.Owner = event.Owner{Type: event.OwnerTypeInternal}
+		evt.StartTime = run.StartTime
+		evt.EndTime = run.EndTime
+		evt.Log = run.Output
+		evt.Allowed = a.internalEventAllowed()
+		if !run.Succeeded {
+			evt.Error = fmt.Sprintf("job run %q failed", run.Name)
+		}
+		err = evt.RawInsert(job, nil, nil)
+		if err != nil {
+			releaseErr := releaseJobRun(a.Name, job.Name, run.Name)
+			if releaseErr != nil {
+				log.Errorf("[job scheduler] unable to release run %q of job %q: %v", run.Name, job.Name, releaseErr)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"time"
+
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestAddJob(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(Job{Name: "cleanup", Schedule: "*/5 * * * *", Command: "./cleanup.sh"})
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(Job{Name: "backup", Schedule: "@daily", Command: "./backup.sh"})
+	c.Assert(err, check.IsNil)
+	jobs, err := a.Jobs()
+	c.Assert(err, check.IsNil)
+	c.Assert(jobs, check.HasLen, 2)
+	c.Assert(jobs[0].Name, check.Equals, "backup")
+	c.Assert(jobs[0].App, check.Equals, a.Name)
+	c.Assert(jobs[1].Name, check.Equals, "cleanup")
+	c.Assert(jobs[1].Schedule, check.Equals, "*/5 * * * *")
+	c.Assert(jobs[1].Command, check.Equals, "./cleanup.sh")
+	c.Assert(jobs[1].LastSchedule.IsZero(), check.Equals, false)
+}
+
+func (s *S) TestAddJobAlreadyExists(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(Job{Name: "cleanup", Schedule: "@hourly", Command: "./cleanup.sh"})
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(Job{Name: "cleanup", Schedule: "@daily", Command: "./other.sh"})
+	c.Assert(err, check.Equals, ErrJobAlreadyExists)
+}
+
+func (s *S) TestAddJobInvalid(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	tests := []Job{
+		{Name: "Invalid_Name", Schedule: "@hourly", Command: "./cleanup.sh"},
+		{Name: "cleanup", Schedule: "@hourly", Command: " "},
+		{Name: "cleanup", Schedule: "* * *", Command: "./cleanup.sh"},
+	}
+	for _, job := range tests {
+		err = a.AddJob(job)
+		c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+	}
+	jobs, err := a.Jobs()
+	c.Assert(err, check.IsNil)
+	c.Assert(jobs, check.HasLen, 0)
+}
+
+func (s *S) TestRemoveJob(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(Job{Name: "cleanup", Schedule: "@hourly", Command: "./cleanup.sh"})
+	c.Assert(err, check.IsNil)
+	err = a.RemoveJob("cleanup")
+	c.Assert(err, check.IsNil)
+	_, err = a.GetJob("cleanup")
+	c.Assert(err, check.Equals, ErrJobNotFound)
+	err = a.RemoveJob("cleanup")
+	c.Assert(err, check.Equals, ErrJobNotFound)
+}
+
+func (s *S) TestDeleteRemovesJobs(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(Job{Name: "cleanup", Schedule: "@hourly", Command: "./cleanup.sh"})
+	c.Assert(err, check.IsNil)
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: a.Name},
+		Kind:     permission.PermAppDelete,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	err = Delete(&a, evt, "")
+	c.Assert(err, check.IsNil)
+	n, err := s.conn.AppJobs().Find(nil).Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 0)
+}
+
+func (s *S) TestSchedulerRunsDueJob(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(Job{Name: "cleanup", Schedule: "@hourly", Command: "./cleanup.sh"})
+	c.Assert(err, check.IsNil)
+	lastSchedule := time.Date(2018, time.May, 15, 10, 0, 0, 0, time.UTC)
+	err = s.conn.AppJobs().Update(map[string]string{"app": a.Name, "name": "cleanup"},
+		map[string]interface{}{"$set": map[string]interface{}{"lastschedule": lastSchedule}})
+	c.Assert(err, check.IsNil)
+	s.provisioner.PrepareOutput([]byte("cleaned"))
+	sched := &jobScheduler{}
+	err = sched.runJobs(time.Date(2018, time.May, 15, 12, 30, 0, 0, time.UTC))
+	c.Assert(err, check.IsNil)
+	sched.wg.Wait()
+	job, err := a.GetJob("cleanup")
+	c.Assert(err, check.IsNil)
+	c.Assert(job.LastSchedule.Equal(time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC)), check.Equals, true)
+	execs := s.provisioner.Execs("isolated")
+	c.Assert(execs, check.HasLen, 1)
+	c.Assert(execs[0].Cmds, check.DeepEquals, cmdsForExec("./cleanup.sh"))
+	c.Assert(eventtest.EventDesc{
+		Target:       event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		Kind:         jobRunEventKind,
+		LogMatches:   "cleaned",
+		ExtraTargets: []event.ExtraTarget{{Target: JobTarget(a.Name, "cleanup"), Lock: true}},
+	}, eventtest.HasEvent)
+	err = sched.runJobs(time.Date(2018, time.May, 15, 12, 59, 0, 0, time.UTC))
+	c.Assert(err, check.IsNil)
+	sched.wg.Wait()
+	c.Assert(s.provisioner.Execs("isolated"), check.HasLen, 1)
+}
+
+func (s *S) TestSchedulerRunsJobOnlyOnce(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(Job{Name: "cleanup", Schedule: "@hourly", Command: "./cleanup.sh"})
+	c.Assert(err, check.IsNil)
+	job, err := a.GetJob("cleanup")
+	c.Assert(err, check.IsNil)
+	now := job.LastSchedule.Add(2 * time.Hour)
+	outdated := *job
+	sched := &jobScheduler{}
+	err = sched.scheduleJob(&a, job, now)
+	c.Assert(err, check.IsNil)
+	err = sched.scheduleJob(&a, &outdated, now)
+	c.Assert(err, check.IsNil)
+	sched.wg.Wait()
+	c.Assert(s.provisioner.Execs("isolated"), check.HasLen, 1)
+}
+
+type fakeJobProvisioner struct {
+	runs []provision.JobRun
+}
+
+func (p *fakeJobProvisioner) EnsureJob(provision.App, provision.Job) error {
+	return nil
+}
+
+func (p *fakeJobProvisioner) RemoveJob(provision.App, provision.Job) error {
+	return nil
+}
+
+func (p *fakeJobProvisioner) JobRuns(provision.App, provision.Job) ([]provision.JobRun, error) {
+	return p.runs, nil
+}
+
+func (s *S) TestRecordJobRunsOnlyOnce(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddJob(Job{Name: "cleanup", Schedule: "@hourly", Command: "./cleanup.sh"})
+	c.Assert(err, check.IsNil)
+	job, err := a.GetJob("cleanup")
+	c.Assert(err, check.IsNil)
+	start := time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC)
+	prov := &fakeJobProvisioner{runs: []provision.JobRun{
+		{Name: "myapp-job-cleanup-1", StartTime: start, EndTime: start.Add(time.Minute), Succeeded: true, Output: "cleaned"},
+	}}
+	// Both calls simulate tsuru API instances seeing the same finished run.
+	err = recordJobRuns(&a, job, prov)
+	c.Assert(err, check.IsNil)
+	err = recordJobRuns(&a, job, prov)
+	c.Assert(err, check.IsNil)
+	evts, err := event.List(&event.Filter{KindNames: []string{jobRunEventKind}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].Log, check.Equals, "cleaned")
+	err = a.RemoveJob("cleanup")
+	c.Assert(err, check.IsNil)
+	n, err := s.conn.AppJobRuns().Find(nil).Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 0)
+}
@@ -0,0 +1,861 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/action"
+	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/service"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	"github.com/tsuru/tsuru/volume"
+)
+
+const (
+	ManifestActionAdd    = "add"
+	ManifestActionRemove = "remove"
+	ManifestActionUpdate = "update"
+
+	ManifestKindDescription = "description"
+	ManifestKindPlan        = "plan"
+	ManifestKindPool        = "pool"
+	ManifestKindTeamOwner   = "teamowner"
+	ManifestKindTags        = "tags"
+	ManifestKindEnv         = "env"
+	ManifestKindRouter      = "router"
+	ManifestKindCName       = "cname"
+	ManifestKindService     = "service"
+	ManifestKindVolume      = "volume"
+	ManifestKindUnits       = "units"
+
+	maskedEnvValue = "*****"
+)
+
+// Manifest describes the desired state of an app. Fields that are not set
+// in the manifest are not managed by Apply and keep their current values. An
+// empty, but set, list means that every item of that kind must be removed.
+type Manifest struct {
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	Plan        string               `json:"plan,omitempty" yaml:"plan,omitempty"`
+	Pool        string               `json:"pool,omitempty" yaml:"pool,omitempty"`
+	TeamOwner   string               `json:"teamowner,omitempty" yaml:"teamowner,omitempty"`
+	Tags        []string             `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Envs        []ManifestEnv        `json:"envs,omitempty" yaml:"envs,omitempty"`
+	Routers     []appTypes.AppRouter `json:"routers,omitempty" yaml:"routers,omitempty"`
+	CNames      []string             `json:"cnames,omitempty" yaml:"cnames,omitempty"`
+	Services    []ManifestService    `json:"services,omitempty" yaml:"services,omitempty"`
+	Volumes     []ManifestVolume     `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+	Units       map[string]uint      `json:"units,omitempty" yaml:"units,omitempty"`
+}
+
+type ManifestEnv struct {
+	Name    string `json:"name" yaml:"name"`
+	Value   string `json:"value" yaml:"value"`
+	Private bool   `json:"private,omitempty" yaml:"private,omitempty"`
+}
+
+type ManifestService struct {
+	Service    string                    `json:"service" yaml:"service"`
+	Instance   string                    `json:"instance" yaml:"instance"`
+	Parameters service.BindAppParameters `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+type ManifestVolume struct {
+	Volume     string `json:"volume" yaml:"volume"`
+	MountPoint string `json:"mountpoint" yaml:"mountpoint"`
+	ReadOnly   bool   `json:"readonly,omitempty" yaml:"readonly,omitempty"`
+}
+
+// ManifestChange is a single difference between the current state of an app
+// and the state described by a manifest.
+type ManifestChange struct {
+	Kind   string      `json:"kind"`
+	Action string      `json:"action"`
+	Name   string      `json:"name,omitempty"`
+	Old    interface{} `json:"old,omitempty"`
+	New    interface{} `json:"new,omitempty"`
+}
+
+func (c ManifestChange) String() string {
+	name := c.Kind
+	if c.Name != "" {
+		name = fmt.Sprintf("%s %q", c.Kind, c.Name)
+	}
+	return fmt.Sprintf("%s %s", c.Action, name)
+}
+
+// MaskPrivateEnvs returns a copy of the manifest with the values of private
+// environment variables hidden, suitable to be stored in events.
+func (m Manifest) MaskPrivateEnvs() Manifest {
+	if m.Envs == nil {
+		return m
+	}
+	envs := make([]ManifestEnv, len(m.Envs))
+	for i, e := range m.Envs {
+		if e.Private {
+			e.Value = maskedEnvValue
+		}
+		envs[i] = e
+	}
+	m.Envs = envs
+	return m
+}
+
+type ApplyArgs struct {
+	Manifest  Manifest
+	DryRun    bool
+	NoRestart bool
+	Writer    io.Writer
+	Event     *event.Event
+	RequestID string
+}
+
+// isInternalEnv reports whether the env is managed by tsuru itself, those
+// are never removed by a manifest.
+func isInternalEnv(name string) bool {
+	return strings.HasPrefix(name, "TSURU_")
+}
+
+// DiffManifest returns the list of changes needed to converge the app to the
+// state described in the manifest.
+func (app *App) DiffManifest(m Manifest) ([]ManifestChange, error) {
+	var changes []ManifestChange
+	changes = append(changes, app.diffManifestFields(m)...)
+	envChanges, err := app.diffManifestEnvs(m)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, envChanges...)
+	changes = append(changes, app.diffManifestRouters(m)...)
+	changes = append(changes, app.diffManifestCNames(m)...)
+	serviceChanges, err := app.diffManifestServices(m)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, serviceChanges...)
+	volumeChanges, err := app.diffManifestVolumes(m)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, volumeChanges...)
+	unitChanges, err := app.diffManifestUnits(m)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, unitChanges...)
+	return changes, nil
+}
+
+func (app *App) diffManifestFields(m Manifest) []ManifestChange {
+	var changes []ManifestChange
+	addUpdate := func(kind, old, new string) {
+		if new != "" && new != old {
+			changes = append(changes, ManifestChange{Kind: kind, Action: ManifestActionUpdate, Old: old, New: new})
+		}
+	}
+	addUpdate(ManifestKindDescription, app.Description, m.Description)
+	addUpdate(ManifestKindPlan, app.Plan.Name, m.Plan)
+	addUpdate(ManifestKindPool, app.Pool, m.Pool)
+	addUpdate(ManifestKindTeamOwner, app.TeamOwner, m.TeamOwner)
+	if m.Tags != nil {
+		tags := processTags(m.Tags)
+		current := app.Tags
+		if current == nil {
+			current = []string{}
+		}
+		if !reflect.DeepEqual(tags, current) {
+			changes = append(changes, ManifestChange{Kind: ManifestKindTags, Action: ManifestActionUpdate, Old: app.Tags, New: tags})
+		}
+	}
+	return changes
+}
+
+func (app *App) diffManifestEnvs(m Manifest) ([]ManifestChange, error) {
+	if m.Envs == nil {
+		return nil, nil
+	}
+	var changes []ManifestChange
+	wanted := make(map[string]bool, len(m.Envs))
+	for _, e := range m.Envs {
+		wanted[e.Name] = true
+		newValue := e.Value
+		if e.Private {
+			newValue = maskedEnvValue
+		}
+		current, err := app.getEnv(e.Name)
+		if err == errEnvNotDeclared {
+			changes = append(changes, ManifestChange{Kind: ManifestKindEnv, Action: ManifestActionAdd, Name: e.Name, New: newValue})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if current.Value == e.Value && current.Public == !e.Private {
+			continue
+		}
+		oldValue := current.Value
+		if !current.Public {
+			oldValue = maskedEnvValue
+		}
+		changes = append(changes, ManifestChange{Kind: ManifestKindEnv, Action: ManifestActionUpdate, Name: e.Name, Old: oldValue, New: newValue})
+	}
+	var names []string
+	for name := range app.Env {
+		if !wanted[name] && !isInternalEnv(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		current, err := app.getEnv(name)
+		if err != nil {
+			return nil, err
+		}
+		oldValue := current.Value
+		if !current.Public {
+			oldValue = maskedEnvValue
+		}
+		changes = append(changes, ManifestChange{Kind: ManifestKindEnv, Action: ManifestActionRemove, Name: name, Old: oldValue})
+	}
+	return changes, nil
+}
+
+func (app *App) diffManifestRouters(m Manifest) []ManifestChange {
+	if m.Routers == nil {
+		return nil
+	}
+	var changes []ManifestChange
+	current := map[string]appTypes.AppRouter{}
+	for _, r := range app.GetRouters() {
+		current[r.Name] = r
+	}
+	wanted := map[string]bool{}
+	for _, r := range m.Routers {
+		wanted[r.Name] = true
+		existing, ok := current[r.Name]
+		if !ok {
+			changes = append(changes, ManifestChange{Kind: ManifestKindRouter, Action: ManifestActionAdd, Name: r.Name, New: r.Opts})
+			continue
+		}
+		if len(existing.Opts) == 0 && len(r.Opts) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(existing.Opts, r.Opts) {
+			changes = append(changes, ManifestChange{Kind: ManifestKindRouter, Action: ManifestActionUpdate, Name: r.Name, Old: existing.Opts, New: r.Opts})
+		}
+	}
+	for _, r := range app.GetRouters() {
+		if !wanted[r.Name] {
+			changes = append(changes, ManifestChange{Kind: ManifestKindRouter, Action: ManifestActionRemove, Name: r.Name, Old: r.Opts})
+		}
+	}
+	return changes
+}
+
+func (app *App) diffManifestCNames(m Manifest) []ManifestChange {
+	if m.CNames == nil {
+		return nil
+	}
+	var changes []ManifestChange
+	current := map[string]bool{}
+	for _, cname := range app.CName {
+		current[cname] = true
+	}
+	wanted := map[string]bool{}
+	for _, cname := range m.CNames {
+		wanted[cname] = true
+		if !current[cname] {
+			changes = append(changes, ManifestChange{Kind: ManifestKindCName, Action: ManifestActionAdd, Name: cname})
+		}
+	}
+	for _, cname := range app.CName {
+		if !wanted[cname] {
+			changes = append(changes, ManifestChange{Kind: ManifestKindCName, Action: ManifestActionRemove, Name: cname})
+		}
+	}
+	return changes
+}
+
+func manifestServiceName(serviceName, instanceName string) string {
+	return serviceName + "/" + instanceName
+}
+
+func (app *App) diffManifestServices(m Manifest) ([]ManifestChange, error) {
+	if m.Services == nil {
+		return nil, nil
+	}
+	instances, err := service.GetServiceInstancesBoundToApp(app.Name)
+	if err != nil {
+		return nil, err
+	}
+	var changes []ManifestChange
+	current := map[string]bool{}
+	for _, si := range instances {
+		current[manifestServiceName(si.ServiceName, si.Name)] = true
+	}
+	wanted := map[string]bool{}
+	for _, s := range m.Services {
+		name := manifestServiceName(s.Service, s.Instance)
+		wanted[name] = true
+		if !current[name] {
+			changes = append(changes, ManifestChange{Kind: ManifestKindService, Action: ManifestActionAdd, Name: name})
+		}
+	}
+	for _, si := range instances {
+		name := manifestServiceName(si.ServiceName, si.Name)
+		if !wanted[name] {
+			changes = append(changes, ManifestChange{Kind: ManifestKindService, Action: ManifestActionRemove, Name: name})
+		}
+	}
+	return changes, nil
+}
+
+func manifestVolumeName(volumeName, mountPoint string) string {
+	return volumeName + ":" + mountPoint
+}
+
+func (app *App) currentManifestVolumes() ([]ManifestVolume, error) {
+	volumes, err := volume.ListByApp(app.Name)
+	if err != nil {
+		return nil, err
+	}
+	var result []ManifestVolume
+	for _, v := range volumes {
+		binds, err := v.LoadBindsForApp(app.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range binds {
+			result = append(result, ManifestVolume{
+				Volume:     v.Name,
+				MountPoint: b.ID.MountPoint,
+				ReadOnly:   b.ReadOnly,
+			})
+		}
+	}
+	return result, nil
+}
+
+func (app *App) diffManifestVolumes(m Manifest) ([]ManifestChange, error) {
+	if m.Volumes == nil {
+		return nil, nil
+	}
+	currentVolumes, err := app.currentManifestVolumes()
+	if err != nil {
+		return nil, err
+	}
+	var changes []ManifestChange
+	current := map[string]ManifestVolume{}
+	for _, v := range currentVolumes {
+		current[manifestVolumeName(v.Volume, v.MountPoint)] = v
+	}
+	wanted := map[string]bool{}
+	for _, v := range m.Volumes {
+		name := manifestVolumeName(v.Volume, v.MountPoint)
+		wanted[name] = true
+		existing, ok := current[name]
+		if !ok {
+			changes = append(changes, ManifestChange{Kind: ManifestKindVolume, Action: ManifestActionAdd, Name: name, New: v})
+			continue
+		}
+		if existing.ReadOnly != v.ReadOnly {
+			changes = append(changes, ManifestChange{Kind: ManifestKindVolume, Action: ManifestActionUpdate, Name: name, Old: existing, New: v})
+		}
+	}
+	for _, v := range currentVolumes {
+		name := manifestVolumeName(v.Volume, v.MountPoint)
+		if !wanted[name] {
+			changes = append(changes, ManifestChange{Kind: ManifestKindVolume, Action: ManifestActionRemove, Name: name, Old: v})
+		}
+	}
+	return changes, nil
+}
+
+func (app *App) diffManifestUnits(m Manifest) ([]ManifestChange, error) {
+	if m.Units == nil {
+		return nil, nil
+	}
+	units, err := app.Units()
+	if err != nil {
+		return nil, err
+	}
+	current := map[string]uint{}
+	for _, u := range units {
+		current[u.ProcessName]++
+	}
+	processes := make([]string, 0, len(m.Units))
+	for process := range m.Units {
+		processes = append(processes, process)
+	}
+	sort.Strings(processes)
+	var changes []ManifestChange
+	for _, process := range processes {
+		wanted := m.Units[process]
+		if wanted == current[process] {
+			continue
+		}
+		action := ManifestActionAdd
+		if wanted < current[process] {
+			action = ManifestActionRemove
+		}
+		changes = append(changes, ManifestChange{Kind: ManifestKindUnits, Action: action, Name: process, Old: current[process], New: wanted})
+	}
+	return changes, nil
+}
+
+// Apply converges the app to the state described in the manifest, reusing
+// the same operations available in the API for each kind of change. Every
+// step is rolled back if a later one fails. When DryRun is set, the list of
+// changes is returned without touching the app.
+func (app *App) Apply(args ApplyArgs) ([]ManifestChange, error) {
+	changes, err := app.DiffManifest(args.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	if args.DryRun || len(changes) == 0 {
+		return changes, nil
+	}
+	if args.Writer == nil {
+		args.Writer = ioutil.Discard
+	}
+	pipelineArgs := &applyPipelineArgs{
+		app:     app,
+		args:    args,
+		changes: changes,
+	}
+	err = action.NewPipeline(
+		&applyUpdateApp,
+		&applyRouters,
+		&applyCNames,
+		&applyEnvs,
+		&applyVolumes,
+		&applyServices,
+		&applyUnits,
+		&applyRestart,
+	).Execute(pipelineArgs)
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+type applyPipelineArgs struct {
+	app     *App
+	args    ApplyArgs
+	changes []ManifestChange
+}
+
+func (a *applyPipelineArgs) changesFor(kind string) []ManifestChange {
+	var result []ManifestChange
+	for _, c := range a.changes {
+		if c.Kind == kind {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func (a *applyPipelineArgs) hasChanges(kinds ...string) bool {
+	for _, kind := range kinds {
+		if len(a.changesFor(kind)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// applyStep is a single reversible operation executed while applying a
+// manifest.
+type applyStep struct {
+	change ManifestChange
+	do     func() error
+	undo   func() error
+}
+
+type applySteps []applyStep
+
+// run executes every step, undoing the ones already executed when one of
+// them fails.
+func (steps applySteps) run(w io.Writer) (applySteps, error) {
+	for i, step := range steps {
+		fmt.Fprintf(w, "---- Applying: %s ----\n", step.change)
+		err := step.do()
+		if err != nil {
+			steps[:i].rollback()
+			return nil, errors.Wrapf(err, "unable to %s", step.change)
+		}
+	}
+	return steps, nil
+}
+
+func (steps applySteps) rollback() {
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].undo == nil {
+			continue
+		}
+		err := steps[i].undo()
+		if err != nil {
+			log.Errorf("[apply] unable to rollback %s: %v", steps[i].change, err)
+		}
+	}
+}
+
+func applyStepsForward(kind string, build func(*applyPipelineArgs, []ManifestChange) (applySteps, error)) action.Forward {
+	return func(ctx action.FWContext) (action.Result, error) {
+		args := ctx.Params[0].(*applyPipelineArgs)
+		changes := args.changesFor(kind)
+		if len(changes) == 0 {
+			return applySteps(nil), nil
+		}
+		steps, err := build(args, changes)
+		if err != nil {
+			return nil, err
+		}
+		return steps.run(args.args.Writer)
+	}
+}
+
+func applyStepsBackward(ctx action.BWContext) {
+	if steps, ok := ctx.FWResult.(applySteps); ok {
+		steps.rollback()
+	}
+}
+
+var applyUpdateApp = action.Action{
+	Name: "apply-update-app",
+	Forward: func(ctx action.FWContext) (action.Result, error) {
+		args := ctx.Params[0].(*applyPipelineArgs)
+		if !args.hasChanges(ManifestKindDescription, ManifestKindPlan, ManifestKindPool, ManifestKindTeamOwner, ManifestKindTags) {
+			return nil, nil
+		}
+		m := args.args.Manifest
+		oldApp := App{
+			Description: args.app.Description,
+			Plan:        appTypes.Plan{Name: args.app.Plan.Name},
+			Pool:        args.app.Pool,
+			TeamOwner:   args.app.TeamOwner,
+			Tags:        append([]string{}, args.app.Tags...),
+		}
+		updateData := App{
+			Description: m.Description,
+			Plan:        appTypes.Plan{Name: m.Plan},
+			Pool:        m.Pool,
+			TeamOwner:   m.TeamOwner,
+			Tags:        m.Tags,
+		}
+		fmt.Fprintf(args.args.Writer, "---- Applying: update app %q ----\n", args.app.Name)
+		err := args.app.Update(updateData, args.args.Writer)
+		if err != nil {
+			return nil, err
+		}
+		return &oldApp, nil
+	},
+	Backward: func(ctx action.BWContext) {
+		oldApp, ok := ctx.FWResult.(*App)
+		if !ok || oldApp == nil {
+			return
+		}
+		args := ctx.Params[0].(*applyPipelineArgs)
+		err := args.app.Update(*oldApp, args.args.Writer)
+		if err != nil {
+			log.Errorf("[apply] unable to rollback app %q update: %v", args.app.Name, err)
+		}
+	},
+	MinParams: 1,
+}
+
+var applyRouters = action.Action{
+	Name: "apply-routers",
+	Forward: applyStepsForward(ManifestKindRouter, func(args *applyPipelineArgs, changes []ManifestChange) (applySteps, error) {
+		a := args.app
+		var steps applySteps
+		for _, c := range changes {
+			c := c
+			var newOpts, oldOpts map[string]string
+			if c.New != nil {
+				newOpts = c.New.(map[string]string)
+			}
+			if c.Old != nil {
+				oldOpts = c.Old.(map[string]string)
+			}
+			switch c.Action {
+			case ManifestActionAdd:
+				steps = append(steps, applyStep{
+					change: c,
+					do:     func() error { return a.AddRouter(appTypes.AppRouter{Name: c.Name, Opts: newOpts}) },
+					undo:   func() error { return a.RemoveRouter(c.Name) },
+				})
+			case ManifestActionUpdate:
+				steps = append(steps, applyStep{
+					change: c,
+					do:     func() error { return a.UpdateRouter(appTypes.AppRouter{Name: c.Name, Opts: newOpts}) },
+					undo:   func() error { return a.UpdateRouter(appTypes.AppRouter{Name: c.Name, Opts: oldOpts}) },
+				})
+			case ManifestActionRemove:
+				steps = append(steps, applyStep{
+					change: c,
+					do:     func() error { return a.RemoveRouter(c.Name) },
+					undo:   func() error { return a.AddRouter(appTypes.AppRouter{Name: c.Name, Opts: oldOpts}) },
+				})
+			}
+		}
+		return steps, nil
+	}),
+	Backward:  applyStepsBackward,
+	MinParams: 1,
+}
+
+var applyCNames = action.Action{
+	Name: "apply-cnames",
+	Forward: applyStepsForward(ManifestKindCName, func(args *applyPipelineArgs, changes []ManifestChange) (applySteps, error) {
+		a := args.app
+		var steps applySteps
+		for _, c := range changes {
+			c := c
+			switch c.Action {
+			case ManifestActionAdd:
+				steps = append(steps, applyStep{
+					change: c,
+					do:     func() error { return a.AddCName(c.Name) },
+					undo:   func() error { return a.RemoveCName(c.Name) },
+				})
+			case ManifestActionRemove:
+				steps = append(steps, applyStep{
+					change: c,
+					do:     func() error { return a.RemoveCName(c.Name) },
+					undo:   func() error { return a.AddCName(c.Name) },
+				})
+			}
+		}
+		return steps, nil
+	}),
+	Backward:  applyStepsBackward,
+	MinParams: 1,
+}
+
+var applyEnvs = action.Action{
+	Name: "apply-envs",
+	Forward: applyStepsForward(ManifestKindEnv, func(args *applyPipelineArgs, changes []ManifestChange) (applySteps, error) {
+		a := args.app
+		var owner string
+		if args.args.Event != nil {
+			owner = args.args.Event.Owner.Name
+		}
+		wanted := map[string]ManifestEnv{}
+		for _, e := range args.args.Manifest.Envs {
+			wanted[e.Name] = e
+		}
+		var steps applySteps
+		for _, c := range changes {
+			c := c
+			old, getErr := a.getEnv(c.Name)
+			if getErr != nil && getErr != errEnvNotDeclared {
+				return nil, getErr
+			}
+			hasOld := getErr == nil
+			restoreOld := func() error {
+				if !hasOld {
+					return a.UnsetEnvs(bind.UnsetEnvArgs{VariableNames: []string{c.Name}, Owner: owner})
+				}
+				return a.SetEnvs(bind.SetEnvArgs{Envs: []bind.EnvVar{old}, Owner: owner})
+			}
+			if c.Action == ManifestActionRemove {
+				steps = append(steps, applyStep{
+					change: c,
+					do: func() error {
+						return a.UnsetEnvs(bind.UnsetEnvArgs{VariableNames: []string{c.Name}, Owner: owner})
+					},
+					undo: restoreOld,
+				})
+				continue
+			}
+			e := wanted[c.Name]
+			steps = append(steps, applyStep{
+				change: c,
+				do: func() error {
+					return a.SetEnvs(bind.SetEnvArgs{
+						Envs:  []bind.EnvVar{{Name: e.Name, Value: e.Value, Public: !e.Private}},
+						Owner: owner,
+					})
+				},
+				undo: restoreOld,
+			})
+		}
+		return steps, nil
+	}),
+	Backward:  applyStepsBackward,
+	MinParams: 1,
+}
+
+var applyVolumes = action.Action{
+	Name: "apply-volumes",
+	Forward: applyStepsForward(ManifestKindVolume, func(args *applyPipelineArgs, changes []ManifestChange) (applySteps, error) {
+		a := args.app
+		var steps applySteps
+		for _, c := range changes {
+			c := c
+			var newBind, oldBind ManifestVolume
+			if c.New != nil {
+				newBind = c.New.(ManifestVolume)
+			}
+			if c.Old != nil {
+				oldBind = c.Old.(ManifestVolume)
+			}
+			bindVolume := func(b ManifestVolume) error {
+				v, err := volume.Load(b.Volume)
+				if err != nil {
+					return err
+				}
+				return v.BindApp(a.Name, b.MountPoint, b.ReadOnly)
+			}
+			unbindVolume := func(b ManifestVolume) error {
+				v, err := volume.Load(b.Volume)
+				if err != nil {
+					return err
+				}
+				return v.UnbindApp(a.Name, b.MountPoint)
+			}
+			switch c.Action {
+			case ManifestActionAdd:
+				steps = append(steps, applyStep{
+					change: c,
+					do:     func() error { return bindVolume(newBind) },
+					undo:   func() error { return unbindVolume(newBind) },
+				})
+			case ManifestActionUpdate:
+				steps = append(steps, applyStep{
+					change: c,
+					do: func() error {
+						err := unbindVolume(oldBind)
+						if err != nil {
+							return err
+						}
+						return bindVolume(newBind)
+					},
+					undo: func() error {
+						err := unbindVolume(newBind)
+						if err != nil {
+							return err
+						}
+						return bindVolume(oldBind)
+					},
+				})
+			case ManifestActionRemove:
+				steps = append(steps, applyStep{
+					change: c,
+					do:     func() error { return unbindVolume(oldBind) },
+					undo:   func() error { return bindVolume(oldBind) },
+				})
+			}
+		}
+		return steps, nil
+	}),
+	Backward:  applyStepsBackward,
+	MinParams: 1,
+}
+
+var applyServices = action.Action{
+	Name: "apply-services",
+	Forward: applyStepsForward(ManifestKindService, func(args *applyPipelineArgs, changes []ManifestChange) (applySteps, error) {
+		a := args.app
+		params := map[string]service.BindAppParameters{}
+		for _, s := range args.args.Manifest.Services {
+			params[manifestServiceName(s.Service, s.Instance)] = s.Parameters
+		}
+		var steps applySteps
+		for _, c := range changes {
+			c := c
+			parts := strings.SplitN(c.Name, "/", 2)
+			instance, err := service.GetServiceInstance(parts[0], parts[1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to find service instance %q", c.Name)
+			}
+			bindInstance := func(p service.BindAppParameters) error {
+				err := a.ValidateService(instance.ServiceName)
+				if err != nil {
+					return err
+				}
+				return instance.BindApp(a, p, false, args.args.Writer, args.args.Event, args.args.RequestID)
+			}
+			unbindInstance := func() error {
+				return instance.UnbindApp(service.UnbindAppArgs{
+					App:       a,
+					Restart:   false,
+					Event:     args.args.Event,
+					RequestID: args.args.RequestID,
+				})
+			}
+			switch c.Action {
+			case ManifestActionAdd:
+				steps = append(steps, applyStep{
+					change: c,
+					do:     func() error { return bindInstance(params[c.Name]) },
+					undo:   unbindInstance,
+				})
+			case ManifestActionRemove:
+				steps = append(steps, applyStep{
+					change: c,
+					do:     unbindInstance,
+					undo:   func() error { return bindInstance(nil) },
+				})
+			}
+		}
+		return steps, nil
+	}),
+	Backward:  applyStepsBackward,
+	MinParams: 1,
+}
+
+var applyUnits = action.Action{
+	Name: "apply-units",
+	Forward: applyStepsForward(ManifestKindUnits, func(args *applyPipelineArgs, changes []ManifestChange) (applySteps, error) {
+		a := args.app
+		w := args.args.Writer
+		var steps applySteps
+		for _, c := range changes {
+			c := c
+			oldCount, newCount := c.Old.(uint), c.New.(uint)
+			if c.Action == ManifestActionAdd {
+				delta := newCount - oldCount
+				steps = append(steps, applyStep{
+					change: c,
+					do:     func() error { return a.AddUnits(delta, c.Name, w) },
+					undo:   func() error { return a.RemoveUnits(delta, c.Name, w) },
+				})
+				continue
+			}
+			delta := oldCount - newCount
+			steps = append(steps, applyStep{
+				change: c,
+				do:     func() error { return a.RemoveUnits(delta, c.Name, w) },
+				undo:   func() error { return a.AddUnits(delta, c.Name, w) },
+			})
+		}
+		return steps, nil
+	}),
+	Backward:  applyStepsBackward,
+	MinParams: 1,
+}
+
+var applyRestart = action.Action{
+	Name: "apply-restart",
+	Forward: func(ctx action.FWContext) (action.Result, error) {
+		args := ctx.Params[0].(*applyPipelineArgs)
+		if args.args.NoRestart || !args.hasChanges(ManifestKindEnv, ManifestKindService, ManifestKindVolume) {
+			return nil, nil
+		}
+		return nil, args.app.restartIfUnits(args.app.withLogWriter(args.args.Writer))
+	},
+	MinParams: 1,
+}
@@ -0,0 +1,178 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+
+	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/router/routertest"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestDiffManifestNoChanges(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name, Description: "desc"}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	changes, err := a.DiffManifest(Manifest{
+		Description: "desc",
+		Routers:     []appTypes.AppRouter{{Name: "fake"}},
+		CNames:      []string{},
+		Envs:        []ManifestEnv{},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(changes, check.HasLen, 0)
+}
+
+func (s *S) TestDiffManifestFields(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name, Description: "desc", Tags: []string{"a"}}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	changes, err := a.DiffManifest(Manifest{
+		Description: "new desc",
+		Tags:        []string{"a", "b", " b "},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(changes, check.DeepEquals, []ManifestChange{
+		{Kind: ManifestKindDescription, Action: ManifestActionUpdate, Old: "desc", New: "new desc"},
+		{Kind: ManifestKindTags, Action: ManifestActionUpdate, Old: []string{"a"}, New: []string{"a", "b"}},
+	})
+}
+
+func (s *S) TestDiffManifestEnvs(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetEnvs(bind.SetEnvArgs{Envs: []bind.EnvVar{
+		{Name: "KEEP", Value: "1", Public: true},
+		{Name: "CHANGE", Value: "old", Public: true},
+		{Name: "SECRET", Value: "s3cr3t", Public: false},
+		{Name: "REMOVE", Value: "x", Public: true},
+	}})
+	c.Assert(err, check.IsNil)
+	changes, err := a.DiffManifest(Manifest{
+		Envs: []ManifestEnv{
+			{Name: "KEEP", Value: "1"},
+			{Name: "CHANGE", Value: "new"},
+			{Name: "SECRET", Value: "other", Private: true},
+			{Name: "NEW", Value: "y"},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(changes, check.DeepEquals, []ManifestChange{
+		{Kind: ManifestKindEnv, Action: ManifestActionUpdate, Name: "CHANGE", Old: "old", New: "new"},
+		{Kind: ManifestKindEnv, Action: ManifestActionUpdate, Name: "SECRET", Old: "*****", New: "*****"},
+		{Kind: ManifestKindEnv, Action: ManifestActionAdd, Name: "NEW", New: "y"},
+		{Kind: ManifestKindEnv, Action: ManifestActionRemove, Name: "REMOVE", Old: "x"},
+	})
+}
+
+func (s *S) TestDiffManifestRoutersAndCNames(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddCName("old.example.com")
+	c.Assert(err, check.IsNil)
+	changes, err := a.DiffManifest(Manifest{
+		Routers: []appTypes.AppRouter{{Name: "fake-tls"}},
+		CNames:  []string{"new.example.com"},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(changes, check.DeepEquals, []ManifestChange{
+		{Kind: ManifestKindRouter, Action: ManifestActionAdd, Name: "fake-tls", New: map[string]string(nil)},
+		{Kind: ManifestKindRouter, Action: ManifestActionRemove, Name: "fake", Old: map[string]string(nil)},
+		{Kind: ManifestKindCName, Action: ManifestActionAdd, Name: "new.example.com"},
+		{Kind: ManifestKindCName, Action: ManifestActionRemove, Name: "old.example.com"},
+	})
+}
+
+func (s *S) TestDiffManifestUnits(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	s.provisioner.AddUnits(&a, 3, "web", nil)
+	s.provisioner.AddUnits(&a, 1, "worker", nil)
+	changes, err := a.DiffManifest(Manifest{
+		Units: map[string]uint{"web": 1, "worker": 2},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(changes, check.DeepEquals, []ManifestChange{
+		{Kind: ManifestKindUnits, Action: ManifestActionRemove, Name: "web", Old: uint(3), New: uint(1)},
+		{Kind: ManifestKindUnits, Action: ManifestActionAdd, Name: "worker", Old: uint(1), New: uint(2)},
+	})
+}
+
+func (s *S) TestApplyDryRun(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	changes, err := a.Apply(ApplyArgs{
+		Manifest: Manifest{
+			Description: "new desc",
+			CNames:      []string{"myapp.example.com"},
+		},
+		DryRun: true,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(changes, check.HasLen, 2)
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.Description, check.Equals, "")
+	c.Assert(dbApp.CName, check.HasLen, 0)
+}
+
+func (s *S) TestApply(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	s.provisioner.AddUnits(&a, 1, "web", nil)
+	buf := new(bytes.Buffer)
+	changes, err := a.Apply(ApplyArgs{
+		Manifest: Manifest{
+			Description: "new desc",
+			Envs:        []ManifestEnv{{Name: "MY_ENV", Value: "val"}},
+			Routers:     []appTypes.AppRouter{{Name: "fake"}, {Name: "fake-tls"}},
+			CNames:      []string{"myapp.example.com"},
+			Units:       map[string]uint{"web": 2},
+		},
+		Writer: buf,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(changes, check.HasLen, 5)
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.Description, check.Equals, "new desc")
+	c.Assert(dbApp.Env["MY_ENV"], check.DeepEquals, bind.EnvVar{Name: "MY_ENV", Value: "val", Public: true})
+	c.Assert(dbApp.CName, check.DeepEquals, []string{"myapp.example.com"})
+	c.Assert(dbApp.GetRouters(), check.HasLen, 2)
+	units, err := dbApp.Units()
+	c.Assert(err, check.IsNil)
+	c.Assert(units, check.HasLen, 2)
+	c.Assert(s.provisioner.Restarts(&a, ""), check.Equals, 1)
+	c.Assert(buf.String(), check.Matches, `(?s).*---- Applying: add env "MY_ENV" ----.*`)
+}
+
+func (s *S) TestApplyRollbackOnFailure(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name, Description: "desc"}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	_, err = a.Apply(ApplyArgs{
+		Manifest: Manifest{
+			Description: "new desc",
+			Routers:     []appTypes.AppRouter{{Name: "fake"}, {Name: "fake-tls"}},
+			CNames:      []string{"myapp.example.com"},
+			Envs:        []ManifestEnv{{Name: "MY ENV", Value: "val"}},
+		},
+		Writer: new(bytes.Buffer),
+	})
+	c.Assert(err, check.ErrorMatches, `unable to add env "MY ENV": Invalid environment variable name: 'MY ENV'`)
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.Description, check.Equals, "desc")
+	c.Assert(dbApp.CName, check.HasLen, 0)
+	c.Assert(dbApp.GetRouters(), check.DeepEquals, []appTypes.AppRouter{{Name: "fake"}})
+	c.Assert(routertest.TLSRouter.HasBackend(a.Name), check.Equals, false)
+}
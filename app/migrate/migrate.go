@@ -7,6 +7,7 @@ package migrate
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 
 	"github.com/globalsign/mgo/bson"
@@ -143,3 +144,9 @@ func MigrateAppPlanIDToPlanName() error {
 	}
 	return nil
 }
+
+// MigrateEncryptAppEnvs encrypts the environment variables of all apps using
+// the configured env encryption provider.
+func MigrateEncryptAppEnvs() error {
+	return app.ReencryptAllEnvs(os.Stdout)
+}
@@ -0,0 +1,375 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/router"
+	appTypes "github.com/tsuru/tsuru/types/app"
+)
+
+var (
+	ErrPathRouteAlreadyClaimed = errors.New("path already claimed by another app")
+	ErrPathRouteNotFound       = errors.New("path route not found")
+	ErrNoPathRouter            = errors.New("no router with path routing support")
+	ErrPathRouteHostLocked     = errors.New("path routes of the host are being changed by another operation, try again later")
+
+	pathRouteRegexp = regexp.MustCompile(`^(/[a-zA-Z0-9._~-]+)+$`)
+
+	// pathRouteLockWait is how long an operation waits for the lock of a
+	// host, and pathRouteLockExpiration the age after which a lock is
+	// considered left behind by a failed tsuru API instance.
+	pathRouteLockWait       = 10 * time.Second
+	pathRouteLockExpiration = time.Minute
+)
+
+// PathRoute is a path prefix of a hostname claimed by an app. Requests to the
+// hostname whose path starts with the prefix are sent to the app, allowing
+// multiple apps to be mounted under the same hostname.
+type PathRoute struct {
+	App  string `json:"app"`
+	Host string `json:"host"`
+	Path string `json:"path"`
+}
+
+func sharedDomains() []string {
+	domains, _ := config.GetList("path-routing:shared-domains")
+	return domains
+}
+
+// PathRouteHostOwner returns the name of the app with host as a cname, or an
+// empty string if host is one of the domains in path-routing:shared-domains,
+// where any app is allowed to claim paths.
+func PathRouteHostOwner(host string) (string, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	var owner App
+	err = conn.Apps().Find(bson.M{"cname": host}).Select(bson.M{"name": 1}).One(&owner)
+	if err == nil {
+		return owner.Name, nil
+	}
+	if err != mgo.ErrNotFound {
+		return "", err
+	}
+	for _, domain := range sharedDomains() {
+		if domain == host {
+			return "", nil
+		}
+	}
+	return "", &tsuruErrors.ValidationError{
+		Message: "host must be a cname of an app or a shared domain",
+	}
+}
+
+// lockPathRouteHost acquires the lock document of host, serializing the
+// changes to its path routes across all tsuru API instances. It returns the
+// function releasing the lock.
+func lockPathRouteHost(host string) (func(), error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	timeout := time.After(pathRouteLockWait)
+	for {
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		_, err = conn.PathRouteHosts().Upsert(bson.M{
+			"_id": host,
+			"$or": []bson.M{
+				{"locked": false},
+				{"lockedat": bson.M{"$lt": now.Add(-pathRouteLockExpiration)}},
+			},
+		}, bson.M{"$set": bson.M{"locked": true, "lockedat": now}})
+		if err == nil {
+			return func() { unlockPathRouteHost(host, now) }, nil
+		}
+		if !mgo.IsDup(err) {
+			return nil, err
+		}
+		select {
+		case <-timeout:
+			return nil, ErrPathRouteHostLocked
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func unlockPathRouteHost(host string, lockedAt time.Time) {
+	conn, err := db.Conn()
+	if err != nil {
+		log.Errorf("unable to unlock path routes of host %q: %v", host, err)
+		return
+	}
+	defer conn.Close()
+	err = conn.PathRouteHosts().Update(bson.M{"_id": host, "lockedat": lockedAt}, bson.M{
+		"$set": bson.M{"locked": false},
+	})
+	if err != nil && err != mgo.ErrNotFound {
+		log.Errorf("unable to unlock path routes of host %q: %v", host, err)
+	}
+}
+
+// isPathPrefix returns true if the requests matching path b would also match
+// the prefix a.
+func isPathPrefix(a, b string) bool {
+	return a == b || strings.HasPrefix(b, a+"/")
+}
+
+func (app *App) pathRouters() ([]router.PathRouter, error) {
+	var routers []router.PathRouter
+	for _, appRouter := range app.GetRouters() {
+		r, err := router.Get(appRouter.Name)
+		if err != nil {
+			return nil, err
+		}
+		if pathRouter, ok := r.(router.PathRouter); ok {
+			routers = append(routers, pathRouter)
+		}
+	}
+	if len(routers) == 0 {
+		return nil, ErrNoPathRouter
+	}
+	return routers, nil
+}
+
+// AddPathRoute claims the path prefix of host for the app, in all its routers
+// supporting path routing. The host must be a cname of some app or a shared
+// domain. A path can't be claimed if it overlaps a path claimed by another
+// app on the same host, so an app never receives requests meant for a
+// subpath of another app. Claims of the same host are serialized through
+// the lock of the host.
+func (app *App) AddPathRoute(host, path string) error {
+	if !pathRouteRegexp.MatchString(path) {
+		return &tsuruErrors.ValidationError{Message: "invalid path, it must start with / and contain only letters, numbers and the characters . _ ~ -"}
+	}
+	routers, err := app.pathRouters()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockPathRouteHost(host)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	owner, err := PathRouteHostOwner(host)
+	if err != nil {
+		return err
+	}
+	if owner != "" && owner != app.Name {
+		err = app.checkSharesPathRouter(owner)
+		if err != nil {
+			return err
+		}
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var existing []PathRoute
+	err = conn.PathRoutes().Find(bson.M{"host": host, "app": bson.M{"$ne": app.Name}}).All(&existing)
+	if err != nil {
+		return err
+	}
+	for _, r := range existing {
+		if isPathPrefix(r.Path, path) || isPathPrefix(path, r.Path) {
+			return ErrPathRouteAlreadyClaimed
+		}
+	}
+	route := PathRoute{App: app.Name, Host: host, Path: path}
+	err = conn.PathRoutes().Insert(route)
+	if mgo.IsDup(err) {
+		return ErrPathRouteAlreadyClaimed
+	}
+	if err != nil {
+		return err
+	}
+	for i, r := range routers {
+		err = r.AddPathRoute(app.Name, host, path)
+		if err == nil || err == router.ErrPathRouteExists {
+			continue
+		}
+		for _, added := range routers[:i] {
+			rollbackErr := added.RemovePathRoute(app.Name, host, path)
+			if rollbackErr != nil {
+				log.Errorf("unable to remove path route rolling back add path route: %v", rollbackErr)
+			}
+		}
+		rollbackErr := conn.PathRoutes().Remove(route)
+		if rollbackErr != nil {
+			log.Errorf("unable to remove path route from db rolling back add path route: %v", rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// checkSharesPathRouter ensures the app has a router with path routing
+// support in common with the owner app of a host, the one serving the
+// requests to the host.
+func (app *App) checkSharesPathRouter(owner string) error {
+	ownerApp, err := GetByName(owner)
+	if err != nil {
+		return err
+	}
+	ownerRouters := map[string]struct{}{}
+	for _, appRouter := range ownerApp.GetRouters() {
+		ownerRouters[appRouter.Name] = struct{}{}
+	}
+	for _, appRouter := range app.GetRouters() {
+		if _, ok := ownerRouters[appRouter.Name]; !ok {
+			continue
+		}
+		r, err := router.Get(appRouter.Name)
+		if err != nil {
+			return err
+		}
+		if _, ok := r.(router.PathRouter); ok {
+			return nil
+		}
+	}
+	return &tsuruErrors.ValidationError{
+		Message: fmt.Sprintf("app must share a router with path routing support with app %q, the owner of the host", owner),
+	}
+}
+
+// RemovePathRoute releases the path prefix of host claimed by the app. The
+// path is removed from the routers before its claim is released, so it's
+// never routed to the app while claimed by another one.
+func (app *App) RemovePathRoute(host, path string) error {
+	unlock, err := lockPathRouteHost(host)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return app.removePathRoute(host, path)
+}
+
+// removePathRoute releases the path prefix of host claimed by the app,
+// expecting the lock of the host to be held by the caller.
+func (app *App) removePathRoute(host, path string) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	route := PathRoute{App: app.Name, Host: host, Path: path}
+	n, err := conn.PathRoutes().Find(route).Count()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrPathRouteNotFound
+	}
+	routers, err := app.pathRouters()
+	if err != nil && err != ErrNoPathRouter {
+		return err
+	}
+	for _, r := range routers {
+		err = r.RemovePathRoute(app.Name, host, path)
+		if err != nil && err != router.ErrPathRouteNotFound {
+			return err
+		}
+	}
+	err = conn.PathRoutes().Remove(route)
+	if err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// PathRoutes returns the path prefixes claimed by the app.
+func (app *App) PathRoutes() ([]PathRoute, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var routes []PathRoute
+	err = conn.PathRoutes().Find(bson.M{"app": app.Name}).Sort("host", "path").All(&routes)
+	if err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// revalidatePathRoutes releases the path routes of the hosts no longer
+// allowed to be claimed, like cnames removed from their apps.
+func revalidatePathRoutes(hosts ...string) error {
+	for _, host := range hosts {
+		err := revalidatePathRoutesOfHost(host)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func revalidatePathRoutesOfHost(host string) error {
+	unlock, err := lockPathRouteHost(host)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	_, err = PathRouteHostOwner(host)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*tsuruErrors.ValidationError); !ok {
+		return err
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var routes []PathRoute
+	err = conn.PathRoutes().Find(bson.M{"host": host}).All(&routes)
+	if err != nil {
+		return err
+	}
+	for _, route := range routes {
+		a, err := GetByName(route.App)
+		if err == appTypes.ErrAppNotFound {
+			err = conn.PathRoutes().Remove(route)
+			if err != nil && err != mgo.ErrNotFound {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = a.removePathRoute(route.Host, route.Path)
+		if err != nil && err != ErrPathRouteNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+func (app *App) removePathRoutes() error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.PathRoutes().RemoveAll(bson.M{"app": app.Name})
+	return err
+}
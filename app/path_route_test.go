@@ -0,0 +1,237 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/config"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/router"
+	"github.com/tsuru/tsuru/router/routertest"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) createPathRouteApp(c *check.C, name string, cnames ...string) *App {
+	a := App{
+		Name:      name,
+		TeamOwner: s.team.Name,
+		Routers:   []appTypes.AppRouter{{Name: "fake-path"}},
+		CName:     cnames,
+	}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	return &a
+}
+
+func (s *S) setUpPathRouter(c *check.C) func() {
+	config.Set("routers:fake-path:type", "fake-path")
+	return func() {
+		config.Unset("routers:fake-path")
+		routertest.PathRouter.Reset()
+	}
+}
+
+func (s *S) TestAddPathRoute(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	a := s.createPathRouteApp(c, "orders", "api.example.com")
+	err := a.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	c.Assert(routertest.PathRouter.Paths, check.DeepEquals, map[router.PathRoute]string{
+		{Host: "api.example.com", Path: "/orders"}: a.Name,
+	})
+	routes, err := a.PathRoutes()
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.DeepEquals, []PathRoute{{App: a.Name, Host: "api.example.com", Path: "/orders"}})
+}
+
+func (s *S) TestAddPathRouteOtherAppCName(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	s.createPathRouteApp(c, "orders", "api.example.com")
+	users := s.createPathRouteApp(c, "users")
+	err := users.AddPathRoute("api.example.com", "/users")
+	c.Assert(err, check.IsNil)
+	routes, err := users.PathRoutes()
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.DeepEquals, []PathRoute{{App: users.Name, Host: "api.example.com", Path: "/users"}})
+}
+
+func (s *S) TestAddPathRouteConflict(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	orders := s.createPathRouteApp(c, "orders", "api.example.com")
+	users := s.createPathRouteApp(c, "users")
+	err := orders.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	err = orders.AddPathRoute("api.example.com", "/orders/v2")
+	c.Assert(err, check.IsNil)
+	err = users.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.Equals, ErrPathRouteAlreadyClaimed)
+	err = users.AddPathRoute("api.example.com", "/orders/admin")
+	c.Assert(err, check.Equals, ErrPathRouteAlreadyClaimed)
+	err = orders.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.Equals, ErrPathRouteAlreadyClaimed)
+	err = users.AddPathRoute("api.example.com", "/ordersx")
+	c.Assert(err, check.IsNil)
+	err = orders.AddPathRoute("api.example.com", "/ordersx/v2")
+	c.Assert(err, check.Equals, ErrPathRouteAlreadyClaimed)
+}
+
+func (s *S) TestAddPathRouteInvalid(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	a := s.createPathRouteApp(c, "orders", "api.example.com")
+	for _, path := range []string{"orders", "/", "/orders/", "/orders?x=1", "//orders"} {
+		err := a.AddPathRoute("api.example.com", path)
+		c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{}, check.Commentf("path %q", path))
+	}
+	err := a.AddPathRoute("unknown.example.com", "/orders")
+	c.Assert(err, check.ErrorMatches, "host must be a cname of an app or a shared domain")
+	c.Assert(routertest.PathRouter.Paths, check.HasLen, 0)
+}
+
+func (s *S) TestAddPathRouteSharedDomain(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	config.Set("path-routing:shared-domains", []interface{}{"apis.example.com"})
+	defer config.Unset("path-routing")
+	a := s.createPathRouteApp(c, "orders")
+	err := a.AddPathRoute("apis.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	owner, err := PathRouteHostOwner("apis.example.com")
+	c.Assert(err, check.IsNil)
+	c.Assert(owner, check.Equals, "")
+}
+
+func (s *S) TestAddPathRouteNoPathRouter(c *check.C) {
+	a := App{Name: "orders", TeamOwner: s.team.Name, CName: []string{"api.example.com"}}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.Equals, ErrNoPathRouter)
+	routes, err := a.PathRoutes()
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.HasLen, 0)
+}
+
+func (s *S) TestRemovePathRoute(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	a := s.createPathRouteApp(c, "orders", "api.example.com")
+	err := a.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	err = a.RemovePathRoute("api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	c.Assert(routertest.PathRouter.Paths, check.HasLen, 0)
+	routes, err := a.PathRoutes()
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.HasLen, 0)
+	err = a.RemovePathRoute("api.example.com", "/orders")
+	c.Assert(err, check.Equals, ErrPathRouteNotFound)
+}
+
+func (s *S) TestAddPathRouteOwnerWithoutSharedPathRouter(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	owner := App{Name: "orders", TeamOwner: s.team.Name, CName: []string{"api.example.com"}}
+	err := CreateApp(&owner, s.user)
+	c.Assert(err, check.IsNil)
+	users := s.createPathRouteApp(c, "users")
+	err = users.AddPathRoute("api.example.com", "/users")
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+	c.Assert(err, check.ErrorMatches, `app must share a router with path routing support with app "orders", the owner of the host`)
+	c.Assert(routertest.PathRouter.Paths, check.HasLen, 0)
+	routes, err := users.PathRoutes()
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.HasLen, 0)
+}
+
+func (s *S) TestRemovePathRouteKeepsClaimOnRouterFailure(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	a := s.createPathRouteApp(c, "orders", "api.example.com")
+	err := a.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	err = routertest.PathRouter.RemoveBackend(a.Name)
+	c.Assert(err, check.IsNil)
+	err = a.RemovePathRoute("api.example.com", "/orders")
+	c.Assert(err, check.Equals, router.ErrBackendNotFound)
+	routes, err := a.PathRoutes()
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.DeepEquals, []PathRoute{{App: a.Name, Host: "api.example.com", Path: "/orders"}})
+}
+
+func (s *S) TestRemovePathRouteHostLocked(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	defer func(wait time.Duration) { pathRouteLockWait = wait }(pathRouteLockWait)
+	pathRouteLockWait = 200 * time.Millisecond
+	a := s.createPathRouteApp(c, "orders", "api.example.com")
+	err := a.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	err = s.conn.PathRouteHosts().UpdateId("api.example.com", bson.M{
+		"$set": bson.M{"locked": true, "lockedat": time.Now().UTC()},
+	})
+	c.Assert(err, check.IsNil)
+	err = a.RemovePathRoute("api.example.com", "/orders")
+	c.Assert(err, check.Equals, ErrPathRouteHostLocked)
+	c.Assert(routertest.PathRouter.Paths, check.HasLen, 1)
+}
+
+func (s *S) TestAddPathRouteHostLocked(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	defer func(wait time.Duration) { pathRouteLockWait = wait }(pathRouteLockWait)
+	pathRouteLockWait = 200 * time.Millisecond
+	a := s.createPathRouteApp(c, "orders", "api.example.com")
+	err := s.conn.PathRouteHosts().Insert(bson.M{"_id": "api.example.com", "locked": true, "lockedat": time.Now().UTC()})
+	c.Assert(err, check.IsNil)
+	err = a.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.Equals, ErrPathRouteHostLocked)
+	c.Assert(routertest.PathRouter.Paths, check.HasLen, 0)
+	err = s.conn.PathRouteHosts().UpdateId("api.example.com", bson.M{
+		"$set": bson.M{"lockedat": time.Now().UTC().Add(-2 * pathRouteLockExpiration)},
+	})
+	c.Assert(err, check.IsNil)
+	err = a.AddPathRoute("api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	var lock struct{ Locked bool }
+	err = s.conn.PathRouteHosts().FindId("api.example.com").One(&lock)
+	c.Assert(err, check.IsNil)
+	c.Assert(lock.Locked, check.Equals, false)
+}
+
+func (s *S) TestRemoveCNameRemovesPathRoutes(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	orders := s.createPathRouteApp(c, "orders", "api.example.com", "orders.example.com")
+	users := s.createPathRouteApp(c, "users")
+	err := users.AddPathRoute("api.example.com", "/users")
+	c.Assert(err, check.IsNil)
+	err = users.AddPathRoute("orders.example.com", "/users")
+	c.Assert(err, check.IsNil)
+	err = orders.RemoveCName("api.example.com")
+	c.Assert(err, check.IsNil)
+	routes, err := users.PathRoutes()
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.DeepEquals, []PathRoute{{App: users.Name, Host: "orders.example.com", Path: "/users"}})
+	c.Assert(routertest.PathRouter.Paths, check.DeepEquals, map[router.PathRoute]string{
+		{Host: "orders.example.com", Path: "/users"}: users.Name,
+	})
+}
+
+func (s *S) TestRemoveCNameKeepsPathRoutesOfSharedDomain(c *check.C) {
+	defer s.setUpPathRouter(c)()
+	config.Set("path-routing:shared-domains", []interface{}{"apis.example.com"})
+	defer config.Unset("path-routing")
+	orders := s.createPathRouteApp(c, "orders", "apis.example.com")
+	err := orders.AddPathRoute("apis.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	err = orders.RemoveCName("apis.example.com")
+	c.Assert(err, check.IsNil)
+	routes, err := orders.PathRoutes()
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.HasLen, 1)
+}
+
+func (s *S) TestIsPathPrefix(c *check.C) {
+	c.Assert(isPathPrefix("/orders", "/orders"), check.Equals, true)
+	c.Assert(isPathPrefix("/orders", "/orders/v2"), check.Equals, true)
+	c.Assert(isPathPrefix("/orders", "/ordersx"), check.Equals, false)
+	c.Assert(isPathPrefix("/orders/v2", "/orders"), check.Equals, false)
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tsuru/tsuru/log"
+)
+
+// periodicWorker calls run in background every interval until it's shut
+// down. Tasks started by run in their own goroutines must be tracked in wg,
+// so Shutdown waits for them to finish.
+type periodicWorker struct {
+	name     string
+	interval time.Duration
+	run      func(now time.Time) error
+	once     *sync.Once
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+func (w *periodicWorker) start() {
+	w.once.Do(func() {
+		w.stopCh = make(chan struct{})
+		go w.spin()
+	})
+}
+
+func (w *periodicWorker) Shutdown(ctx context.Context) error {
+	if w.stopCh == nil {
+		return nil
+	}
+	w.stopCh <- struct{}{}
+	w.stopCh = nil
+	w.once = &sync.Once{}
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (w *periodicWorker) String() string {
+	return w.name
+}
+
+func (w *periodicWorker) spin() {
+	for {
+		err := w.run(time.Now().UTC())
+		if err != nil {
+			log.Errorf("[%s] errors running: %v", w.name, err)
+		}
+		select {
+		case <-w.stopCh:
+			return
+		case <-time.After(w.interval):
+		}
+	}
+}
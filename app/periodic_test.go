@@ -0,0 +1,46 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestPeriodicWorker(c *check.C) {
+	runCh := make(chan struct{}, 10)
+	taskDone := false
+	w := &periodicWorker{
+		name:     "test worker",
+		interval: time.Millisecond,
+		once:     &sync.Once{},
+	}
+	w.run = func(now time.Time) error {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			time.Sleep(10 * time.Millisecond)
+			taskDone = true
+		}()
+		select {
+		case runCh <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+	c.Assert(w.String(), check.Equals, "test worker")
+	w.start()
+	w.start()
+	<-runCh
+	<-runCh
+	err := w.Shutdown(context.Background())
+	c.Assert(err, check.IsNil)
+	c.Assert(taskDone, check.Equals, true)
+	err = w.Shutdown(context.Background())
+	c.Assert(err, check.IsNil)
+}
@@ -0,0 +1,516 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/api/shutdown"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/router/rebuild"
+)
+
+const (
+	postDeployEventKind         = "post-deploy"
+	postDeployRollbackEventKind = "post-deploy-rollback"
+)
+
+var (
+	// postDeployCheckInterval is the time between checks of the unit
+	// failures and the error rate of an app during its post-deploy window.
+	postDeployCheckInterval = 10 * time.Second
+
+	// postDeployClaimTTL is how long the claim of an API instance over a
+	// post-deploy window lasts without being renewed. Windows whose claim
+	// expires are resumed by other instances.
+	postDeployClaimTTL = time.Minute
+
+	// postDeployResumeInterval is the time between searches for post-deploy
+	// windows left unobserved, e.g. by a restarted API instance.
+	postDeployResumeInterval = time.Minute
+
+	// postDeployObservers tracks the post-deploy windows being observed in
+	// background.
+	postDeployObservers sync.WaitGroup
+
+	errDeployWatchReplaced  = errors.New("post-deploy window replaced by a new deploy")
+	errDeployWatchClaimLost = errors.New("post-deploy window claimed by another instance")
+)
+
+// deployWatch is an open post-deploy window of an app, where unit failures
+// reported through SetUnitStatus and UpdateNodeStatus are accounted. It holds
+// everything needed to resume its observation in any API instance.
+type deployWatch struct {
+	App            string `bson:"_id"`
+	Image          string
+	PrevImage      string
+	Until          time.Time
+	UnitFailures   int
+	Config         provision.TsuruYamlPostDeploy
+	CheckErrorRate bool
+	ClaimedBy      string
+	ClaimedUntil   time.Time
+}
+
+func validatePostDeploy(cfg provision.TsuruYamlPostDeploy) error {
+	if cfg.WindowSeconds <= 0 {
+		return &tsuruErrors.ValidationError{Message: "post-deploy window must be greater than 0"}
+	}
+	if cfg.MaxUnitFailures < 0 {
+		return &tsuruErrors.ValidationError{Message: "maximum unit failures must not be negative"}
+	}
+	if cfg.MaxErrorRate < 0 || cfg.MaxErrorRate > 100 {
+		return &tsuruErrors.ValidationError{Message: "maximum error rate must be a percentage between 0 and 100"}
+	}
+	return nil
+}
+
+// SetPostDeploy configures the post-deploy window of the app, overriding the
+// one in the tsuru.yaml of the deployed images.
+func (app *App) SetPostDeploy(cfg provision.TsuruYamlPostDeploy) error {
+	err := validatePostDeploy(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.MaxErrorRate > 0 {
+		statsRouters, err := app.statsRouters()
+		if err != nil {
+			return err
+		}
+		if len(statsRouters) == 0 {
+			return &tsuruErrors.ValidationError{Message: "maximum error rate is not available, no router of the app is able to report its error rate"}
+		}
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.Apps().Update(bson.M{"name": app.Name}, bson.M{"$set": bson.M{"postdeploy": cfg}})
+	if err != nil {
+		return err
+	}
+	app.PostDeploy = cfg
+	return nil
+}
+
+// UnsetPostDeploy removes the post-deploy window configured in the app,
+// falling back to the one in the tsuru.yaml of the deployed images.
+func (app *App) UnsetPostDeploy() error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.Apps().Update(bson.M{"name": app.Name}, bson.M{"$unset": bson.M{"postdeploy": ""}})
+	if err != nil {
+		return err
+	}
+	app.PostDeploy = provision.TsuruYamlPostDeploy{}
+	return nil
+}
+
+func (app *App) postDeployConfig(imageID string) (provision.TsuruYamlPostDeploy, error) {
+	if app.PostDeploy.WindowSeconds > 0 {
+		return app.PostDeploy, nil
+	}
+	yamlData, err := image.GetImageTsuruYamlData(imageID)
+	if err != nil {
+		return provision.TsuruYamlPostDeploy{}, err
+	}
+	return yamlData.PostDeploy, nil
+}
+
+// ErrorRate returns the highest error rate, as a percentage of the requests,
+// reported by the routers of the app able to report it.
+func (app *App) ErrorRate() (float64, error) {
+	statsRouters, err := app.statsRouters()
+	if err != nil {
+		return 0, err
+	}
+	if len(statsRouters) == 0 {
+		return 0, errors.Errorf("no router of app %q is able to report its error rate", app.Name)
+	}
+	var rate float64
+	for _, statsRouter := range statsRouters {
+		routerRate, err := statsRouter.ErrorRate(app.Name)
+		if err != nil {
+			return 0, err
+		}
+		if routerRate > rate {
+			rate = routerRate
+		}
+	}
+	return rate, nil
+}
+
+// startPostDeployWatch opens the post-deploy window of the new image and
+// starts observing it in background, so the deploy event, which locks the
+// app, is not held during the window. The result of the observation is
+// recorded in its own event.
+func startPostDeployWatch(opts *DeployOptions, prevImage string, evt *event.Event) error {
+	newImage, err := image.AppCurrentImageName(opts.App.Name)
+	if err != nil {
+		return err
+	}
+	cfg, err := opts.App.postDeployConfig(newImage)
+	if err != nil {
+		return err
+	}
+	if cfg.WindowSeconds <= 0 {
+		return nil
+	}
+	if prevImage == "" || prevImage == newImage {
+		fmt.Fprintf(evt, "\n---- No previous image to roll back to, skipping post-deploy window ----\n")
+		return nil
+	}
+	prov, err := opts.App.getProvisioner()
+	if err != nil {
+		return err
+	}
+	rollbackProv, ok := prov.(provision.RollbackableDeployer)
+	if !ok {
+		return provision.ProvisionerNotSupported{Prov: prov, Action: "post-deploy rollback"}
+	}
+	checkErrorRate := cfg.MaxErrorRate > 0
+	if checkErrorRate {
+		if _, err = opts.App.ErrorRate(); err != nil {
+			fmt.Fprintf(evt, " ---> Unable to check the error rate during the post-deploy window: %v\n", err)
+			checkErrorRate = false
+		}
+	}
+	window := time.Duration(cfg.WindowSeconds) * time.Second
+	now := time.Now().UTC()
+	watch := deployWatch{
+		App:            opts.App.Name,
+		Image:          newImage,
+		PrevImage:      prevImage,
+		Until:          now.Add(window),
+		Config:         cfg,
+		CheckErrorRate: checkErrorRate,
+		ClaimedBy:      bson.NewObjectId().Hex(),
+		ClaimedUntil:   now.Add(postDeployClaimTTL),
+	}
+	err = startDeployWatch(watch)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(evt, "\n---- Watching app %q for %s after deploy, the result is recorded in a %q event ----\n", opts.App.Name, window, postDeployEventKind)
+	goObservePostDeploy(opts.App, watch, rollbackProv)
+	return nil
+}
+
+func goObservePostDeploy(app *App, watch deployWatch, prov provision.RollbackableDeployer) {
+	postDeployObservers.Add(1)
+	go func() {
+		defer postDeployObservers.Done()
+		observeErr := observePostDeploy(app, watch, prov)
+		if observeErr != nil {
+			log.Errorf("[post-deploy] %v", observeErr)
+		}
+	}()
+}
+
+// observePostDeploy checks the app until the end of the post-deploy window,
+// rolling it back to the previous image if the thresholds configured for the
+// window are exceeded. The observation stops when a new deploy replaces the
+// window or when another instance claims it.
+func observePostDeploy(app *App, watch deployWatch, prov provision.RollbackableDeployer) (err error) {
+	claimLost := false
+	defer func() {
+		if !claimLost {
+			stopDeployWatch(watch)
+		}
+	}()
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeApp, Value: app.Name},
+		InternalKind: postDeployEventKind,
+		DisableLock:  true,
+		CustomData:   watch,
+		Allowed:      app.internalEventAllowed(),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	fmt.Fprintf(evt, "---- Watching image %q of app %q until %s ----\n", watch.Image, app.Name, watch.Until.Format(time.RFC3339))
+	var reason string
+	for time.Now().Before(watch.Until) {
+		time.Sleep(postDeployCheckInterval)
+		if reason == "" {
+			var checkErr error
+			reason, checkErr = checkDeployWatch(app, &watch)
+			if checkErr == errDeployWatchReplaced {
+				fmt.Fprintf(evt, " ---> App deployed again, post-deploy window of image %q finished\n", watch.Image)
+				return nil
+			}
+			if checkErr == errDeployWatchClaimLost {
+				claimLost = true
+				fmt.Fprintf(evt, " ---> Post-deploy window of image %q claimed by another instance\n", watch.Image)
+				return nil
+			}
+			if checkErr != nil {
+				log.Errorf("[post-deploy] unable to check app %q: %v", app.Name, checkErr)
+				continue
+			}
+			if reason == "" {
+				continue
+			}
+		}
+		err = rollbackPostDeploy(prov, app, watch.Image, watch.PrevImage, reason, evt)
+		if _, ok := errors.Cause(err).(event.ErrEventLocked); ok {
+			fmt.Fprintf(evt, " ---> Unable to roll back, app %q is locked, trying again\n", app.Name)
+			continue
+		}
+		return err
+	}
+	if reason != "" {
+		return errors.Errorf("unable to roll back app %q after post-deploy check failed with %s: app remained locked until the end of the window", app.Name, reason)
+	}
+	fmt.Fprintf(evt, " ---> Post-deploy window finished without errors\n")
+	return nil
+}
+
+// checkDeployWatch renews the claim over the post-deploy window and returns
+// the reason to roll the app back, if any.
+func checkDeployWatch(app *App, current *deployWatch) (string, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	var watch deployWatch
+	err = conn.DeployWatches().FindId(app.Name).One(&watch)
+	if err == mgo.ErrNotFound {
+		return "", errDeployWatchReplaced
+	}
+	if err != nil {
+		return "", err
+	}
+	if watch.Image != current.Image {
+		return "", errDeployWatchReplaced
+	}
+	claimedUntil := time.Now().UTC().Add(postDeployClaimTTL)
+	err = conn.DeployWatches().Update(
+		bson.M{"_id": current.App, "image": current.Image, "claimedby": current.ClaimedBy},
+		bson.M{"$set": bson.M{"claimeduntil": claimedUntil}},
+	)
+	if err == mgo.ErrNotFound {
+		return "", errDeployWatchClaimLost
+	}
+	if err != nil {
+		return "", err
+	}
+	current.ClaimedUntil = claimedUntil
+	cfg := current.Config
+	if cfg.MaxUnitFailures > 0 && watch.UnitFailures > cfg.MaxUnitFailures {
+		return fmt.Sprintf("%d unit failures, the maximum is %d", watch.UnitFailures, cfg.MaxUnitFailures), nil
+	}
+	if current.CheckErrorRate {
+		rate, err := app.ErrorRate()
+		if err != nil {
+			return "", err
+		}
+		if rate > cfg.MaxErrorRate {
+			return fmt.Sprintf("error rate of %.2f%%, the maximum is %.2f%%", rate, cfg.MaxErrorRate), nil
+		}
+	}
+	return "", nil
+}
+
+// rollbackPostDeploy rolls the app back to prevImage in an event locking the
+// app, failing with event.ErrEventLocked if another operation is running.
+func rollbackPostDeploy(prov provision.RollbackableDeployer, app *App, newImage, prevImage, reason string, w io.Writer) (err error) {
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeApp, Value: app.Name},
+		InternalKind: postDeployRollbackEventKind,
+		CustomData:   map[string]string{"image": prevImage, "reason": reason},
+		Allowed:      app.internalEventAllowed(),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	evt.SetLogWriter(w)
+	fmt.Fprintf(evt, "\n---- Post-deploy check failed with %s, rolling back to image %q ----\n", reason, prevImage)
+	_, err = prov.Rollback(app, prevImage, evt)
+	if err != nil {
+		return errors.Wrapf(err, "unable to rollback app %q to image %q after post-deploy check failed with %s", app.Name, prevImage, reason)
+	}
+	rebuild.RoutesRebuildOrEnqueue(app.Name)
+	err = image.UpdateAppImageRollback(newImage, "post-deploy check failed with "+reason, true)
+	if err != nil {
+		log.Errorf("[post-deploy] unable to disable rollback to image %q: %v", newImage, err)
+	}
+	return errors.Errorf("deploy rolled back to image %q: post-deploy check failed with %s", prevImage, reason)
+}
+
+func startDeployWatch(watch deployWatch) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.DeployWatches().UpsertId(watch.App, watch)
+	return err
+}
+
+// stopDeployWatch removes the post-deploy window, unless it was already
+// replaced by a new deploy of the app or claimed by another instance.
+func stopDeployWatch(watch deployWatch) {
+	conn, err := db.Conn()
+	if err != nil {
+		log.Errorf("[post-deploy] unable to remove watch of app %q: %v", watch.App, err)
+		return
+	}
+	defer conn.Close()
+	err = conn.DeployWatches().Remove(bson.M{"_id": watch.App, "image": watch.Image, "claimedby": watch.ClaimedBy})
+	if err != nil && err != mgo.ErrNotFound {
+		log.Errorf("[post-deploy] unable to remove watch of app %q: %v", watch.App, err)
+	}
+}
+
+// claimDeployWatch claims a post-deploy window whose previous claim expired,
+// so only one API instance observes it.
+func claimDeployWatch(conn *db.Storage, watch *deployWatch, now time.Time) (bool, error) {
+	claimedBy := bson.NewObjectId().Hex()
+	claimedUntil := now.Add(postDeployClaimTTL)
+	err := conn.DeployWatches().Update(
+		bson.M{"_id": watch.App, "image": watch.Image, "claimedby": watch.ClaimedBy, "claimeduntil": bson.M{"$lt": now}},
+		bson.M{"$set": bson.M{"claimedby": claimedBy, "claimeduntil": claimedUntil}},
+	)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	watch.ClaimedBy = claimedBy
+	watch.ClaimedUntil = claimedUntil
+	return true, nil
+}
+
+// InitializePostDeployWatches starts resuming the observation of open
+// post-deploy windows left unobserved, e.g. by a restarted API instance.
+func InitializePostDeployWatches() error {
+	r := &postDeployResumer{}
+	r.periodicWorker = periodicWorker{
+		name:     "post-deploy watches",
+		interval: postDeployResumeInterval,
+		run:      r.resumeWatches,
+		once:     &sync.Once{},
+	}
+	r.start()
+	shutdown.Register(r)
+	return nil
+}
+
+type postDeployResumer struct {
+	periodicWorker
+}
+
+func (r *postDeployResumer) resumeWatches(now time.Time) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.DeployWatches().RemoveAll(bson.M{"until": bson.M{"$lte": now}})
+	if err != nil {
+		return err
+	}
+	var watches []deployWatch
+	err = conn.DeployWatches().Find(bson.M{"claimeduntil": bson.M{"$lt": now}}).All(&watches)
+	if err != nil {
+		return err
+	}
+	for i := range watches {
+		err = resumeDeployWatch(conn, &watches[i], now)
+		if err != nil {
+			log.Errorf("[post-deploy] unable to resume watch of app %q: %v", watches[i].App, err)
+		}
+	}
+	return nil
+}
+
+func resumeDeployWatch(conn *db.Storage, watch *deployWatch, now time.Time) error {
+	if watch.PrevImage == "" || watch.Config.WindowSeconds <= 0 {
+		return nil
+	}
+	claimed, err := claimDeployWatch(conn, watch, now)
+	if err != nil || !claimed {
+		return err
+	}
+	app, err := GetByName(watch.App)
+	if err != nil {
+		return err
+	}
+	prov, err := app.getProvisioner()
+	if err != nil {
+		return err
+	}
+	rollbackProv, ok := prov.(provision.RollbackableDeployer)
+	if !ok {
+		return provision.ProvisionerNotSupported{Prov: prov, Action: "post-deploy rollback"}
+	}
+	goObservePostDeploy(app, *watch, rollbackProv)
+	return nil
+}
+
+// unitFailed returns whether a unit going from the prev to the next status
+// should be accounted as a failure during a post-deploy window.
+func unitFailed(prev, next provision.Status) bool {
+	if next == prev {
+		return false
+	}
+	if next == provision.StatusError {
+		return true
+	}
+	unexpectedStop := next == provision.StatusStopped || next == provision.StatusCreated
+	return unexpectedStop && (prev == provision.StatusStarted || prev == provision.StatusStarting)
+}
+
+// recordUnitStatus accounts a unit status transition in the open post-deploy
+// window of the app, if there is one.
+func recordUnitStatus(appName string, prev, next provision.Status) {
+	if appName == "" || !unitFailed(prev, next) {
+		return
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		log.Errorf("[post-deploy] unable to record unit failure of app %q: %v", appName, err)
+		return
+	}
+	defer conn.Close()
+	err = conn.DeployWatches().Update(
+		bson.M{"_id": appName, "until": bson.M{"$gt": time.Now()}},
+		bson.M{"$inc": bson.M{"unitfailures": 1}},
+	)
+	if err != nil && err != mgo.ErrNotFound {
+		log.Errorf("[post-deploy] unable to record unit failure of app %q: %v", appName, err)
+	}
+}
+
+// hasDeployWatches returns whether any post-deploy window is open.
+func hasDeployWatches() bool {
+	conn, err := db.Conn()
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	n, err := conn.DeployWatches().Find(bson.M{"until": bson.M{"$gt": time.Now()}}).Count()
+	return err == nil && n > 0
+}
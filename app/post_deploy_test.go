@@ -0,0 +1,343 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/builder"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/router/routertest"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) newPostDeployApp(c *check.C, cfg provision.TsuruYamlPostDeploy) (*App, DeployOptions) {
+	a := App{
+		Name:      "myapp",
+		Platform:  "zend",
+		TeamOwner: s.team.Name,
+		Router:    "fake-stats",
+	}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetPostDeploy(cfg)
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(a.Name, "registry.somewhere/tsuru/app-myapp:v1")
+	c.Assert(err, check.IsNil)
+	s.provisioner.AddUnits(&a, 2, "web", nil)
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		imgName := "registry.somewhere/tsuru/app-myapp:v2"
+		err := image.AppendAppImageName(app.GetName(), imgName)
+		c.Assert(err, check.IsNil)
+		err = image.SaveImageCustomData(imgName, map[string]interface{}{"processes": map[string]interface{}{"web": "run"}})
+		c.Assert(err, check.IsNil)
+		return imgName, nil
+	}
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: a.Name},
+		Kind:     permission.PermAppDeploy,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	return &a, DeployOptions{
+		App:          &a,
+		Image:        "registry.somewhere/tsuru/app-myapp:v2",
+		OutputStream: new(bytes.Buffer),
+		Event:        evt,
+	}
+}
+
+func setPostDeployCheckInterval(interval time.Duration) func() {
+	old := postDeployCheckInterval
+	postDeployCheckInterval = interval
+	return func() { postDeployCheckInterval = old }
+}
+
+func (s *S) postDeployEvent(c *check.C, appName string) *event.Event {
+	postDeployObservers.Wait()
+	evts, err := event.List(&event.Filter{
+		Target:    event.Target{Type: event.TargetTypeApp, Value: appName},
+		KindNames: []string{postDeployEventKind},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	return &evts[0]
+}
+
+func (s *S) TestDeployPostDeployWindowSucceeds(c *check.C) {
+	defer setPostDeployCheckInterval(100 * time.Millisecond)()
+	a, opts := s.newPostDeployApp(c, provision.TsuruYamlPostDeploy{WindowSeconds: 1, MaxUnitFailures: 1, MaxErrorRate: 5})
+	routertest.StatsRouter.Errors[a.Name] = 1
+	_, err := Deploy(opts)
+	c.Assert(err, check.IsNil)
+	err = opts.Event.Done(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(opts.OutputStream.(*bytes.Buffer).String(), check.Matches, `(?s).*Watching app "myapp" for 1s after deploy.*`)
+	evt := s.postDeployEvent(c, a.Name)
+	c.Assert(evt.Error, check.Equals, "")
+	c.Assert(evt.Log, check.Matches, `(?s).*Post-deploy window finished without errors.*`)
+	n, err := s.conn.DeployWatches().FindId(a.Name).Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 0)
+}
+
+func (s *S) TestDeployPostDeployDoesNotHoldDeployEvent(c *check.C) {
+	defer setPostDeployCheckInterval(100 * time.Millisecond)()
+	a, opts := s.newPostDeployApp(c, provision.TsuruYamlPostDeploy{WindowSeconds: 1})
+	_, err := Deploy(opts)
+	c.Assert(err, check.IsNil)
+	err = opts.Event.Done(nil)
+	c.Assert(err, check.IsNil)
+	n, err := s.conn.DeployWatches().FindId(a.Name).Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 1)
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: a.Name},
+		Kind:     permission.PermAppUpdateEnvSet,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	err = evt.Done(nil)
+	c.Assert(err, check.IsNil)
+	s.postDeployEvent(c, a.Name)
+}
+
+func (s *S) TestDeployPostDeployRollbackOnUnitFailures(c *check.C) {
+	defer setPostDeployCheckInterval(100 * time.Millisecond)()
+	a, opts := s.newPostDeployApp(c, provision.TsuruYamlPostDeploy{WindowSeconds: 2, MaxUnitFailures: 1})
+	_, err := Deploy(opts)
+	c.Assert(err, check.IsNil)
+	err = opts.Event.Done(nil)
+	c.Assert(err, check.IsNil)
+	units, err := a.Units()
+	c.Assert(err, check.IsNil)
+	for _, u := range units {
+		a.SetUnitStatus(u.ID, provision.StatusError)
+	}
+	evt := s.postDeployEvent(c, a.Name)
+	c.Assert(evt.Error, check.Matches, `(?s).*deploy rolled back to image "registry.somewhere/tsuru/app-myapp:v1": post-deploy check failed with 2 unit failures, the maximum is 1.*`)
+	c.Assert(evt.Log, check.Matches, `(?s).*Rollback deploy called.*`)
+	metadata, err := image.GetImageMetaData("registry.somewhere/tsuru/app-myapp:v2")
+	c.Assert(err, check.IsNil)
+	c.Assert(metadata.DisableRollback, check.Equals, true)
+	c.Assert(metadata.Reason, check.Equals, "post-deploy check failed with 2 unit failures, the maximum is 1")
+	evts, err := event.List(&event.Filter{KindNames: []string{postDeployRollbackEventKind}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].Running, check.Equals, false)
+}
+
+func (s *S) TestDeployPostDeployRollbackWaitsForLock(c *check.C) {
+	defer setPostDeployCheckInterval(100 * time.Millisecond)()
+	a, opts := s.newPostDeployApp(c, provision.TsuruYamlPostDeploy{WindowSeconds: 2, MaxErrorRate: 5})
+	routertest.StatsRouter.Errors[a.Name] = 12.5
+	_, err := Deploy(opts)
+	c.Assert(err, check.IsNil)
+	time.Sleep(300 * time.Millisecond)
+	err = opts.Event.Done(nil)
+	c.Assert(err, check.IsNil)
+	evt := s.postDeployEvent(c, a.Name)
+	c.Assert(evt.Error, check.Matches, `(?s).*post-deploy check failed with error rate of 12.50%, the maximum is 5.00%.*`)
+	c.Assert(evt.Log, check.Matches, `(?s).*Unable to roll back, app "myapp" is locked, trying again.*`)
+	metadata, err := image.GetImageMetaData("registry.somewhere/tsuru/app-myapp:v2")
+	c.Assert(err, check.IsNil)
+	c.Assert(metadata.DisableRollback, check.Equals, true)
+}
+
+func (s *S) TestDeployPostDeployReplacedByNewDeploy(c *check.C) {
+	defer setPostDeployCheckInterval(100 * time.Millisecond)()
+	a, opts := s.newPostDeployApp(c, provision.TsuruYamlPostDeploy{WindowSeconds: 2, MaxUnitFailures: 1})
+	_, err := Deploy(opts)
+	c.Assert(err, check.IsNil)
+	err = opts.Event.Done(nil)
+	c.Assert(err, check.IsNil)
+	err = startDeployWatch(deployWatch{App: a.Name, Image: "registry.somewhere/tsuru/app-myapp:v3", Until: time.Now().Add(time.Minute)})
+	c.Assert(err, check.IsNil)
+	evt := s.postDeployEvent(c, a.Name)
+	c.Assert(evt.Error, check.Equals, "")
+	c.Assert(evt.Log, check.Matches, `(?s).*App deployed again, post-deploy window of image "registry.somewhere/tsuru/app-myapp:v2" finished.*`)
+	var watch deployWatch
+	err = s.conn.DeployWatches().FindId(a.Name).One(&watch)
+	c.Assert(err, check.IsNil)
+	c.Assert(watch.Image, check.Equals, "registry.somewhere/tsuru/app-myapp:v3")
+}
+
+func (s *S) TestDeployPostDeployFromTsuruYaml(c *check.C) {
+	defer setPostDeployCheckInterval(100 * time.Millisecond)()
+	a, opts := s.newPostDeployApp(c, provision.TsuruYamlPostDeploy{WindowSeconds: 1})
+	err := a.UnsetPostDeploy()
+	c.Assert(err, check.IsNil)
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		imgName := "registry.somewhere/tsuru/app-myapp:v2"
+		err := image.AppendAppImageName(app.GetName(), imgName)
+		c.Assert(err, check.IsNil)
+		err = image.SaveImageCustomData(imgName, map[string]interface{}{
+			"processes":   map[string]interface{}{"web": "run"},
+			"post_deploy": map[string]interface{}{"window_seconds": 1, "max_error_rate": 5.0},
+		})
+		c.Assert(err, check.IsNil)
+		return imgName, nil
+	}
+	routertest.StatsRouter.Errors[a.Name] = 50
+	_, err = Deploy(opts)
+	c.Assert(err, check.IsNil)
+	err = opts.Event.Done(nil)
+	c.Assert(err, check.IsNil)
+	evt := s.postDeployEvent(c, a.Name)
+	c.Assert(evt.Error, check.Matches, `(?s).*post-deploy check failed with error rate of 50.00%.*`)
+}
+
+func (s *S) TestDeployPostDeployWithoutPreviousImage(c *check.C) {
+	a, opts := s.newPostDeployApp(c, provision.TsuruYamlPostDeploy{WindowSeconds: 60, MaxUnitFailures: 1})
+	err := image.DeleteAllAppImageNames(a.Name)
+	c.Assert(err, check.IsNil)
+	_, err = Deploy(opts)
+	c.Assert(err, check.IsNil)
+	c.Assert(opts.OutputStream.(*bytes.Buffer).String(), check.Matches, `(?s).*No previous image to roll back to.*`)
+}
+
+func (s *S) TestSetPostDeployErrorRateWithoutStatsRouter(c *check.C) {
+	a := App{Name: "myapp", Platform: "zend", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetPostDeploy(provision.TsuruYamlPostDeploy{WindowSeconds: 60, MaxErrorRate: 5})
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+	c.Assert(err, check.ErrorMatches, "maximum error rate is not available, no router of the app is able to report its error rate")
+	err = a.SetPostDeploy(provision.TsuruYamlPostDeploy{WindowSeconds: 60, MaxUnitFailures: 1})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestSetPostDeployInvalid(c *check.C) {
+	a := App{Name: "myapp", Platform: "zend", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	tests := []provision.TsuruYamlPostDeploy{
+		{WindowSeconds: 0},
+		{WindowSeconds: 10, MaxUnitFailures: -1},
+		{WindowSeconds: 10, MaxErrorRate: 101},
+	}
+	for _, cfg := range tests {
+		err = a.SetPostDeploy(cfg)
+		c.Check(err, check.FitsTypeOf, &tsuruErrors.ValidationError{}, check.Commentf("%#v", cfg))
+	}
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.PostDeploy, check.DeepEquals, provision.TsuruYamlPostDeploy{})
+}
+
+func (s *S) TestUnitFailed(c *check.C) {
+	tests := []struct {
+		prev, next provision.Status
+		expected   bool
+	}{
+		{provision.StatusStarted, provision.StatusError, true},
+		{provision.StatusStarting, provision.StatusStopped, true},
+		{provision.StatusStarted, provision.StatusCreated, true},
+		{provision.StatusError, provision.StatusError, false},
+		{provision.StatusStopped, provision.StatusStopped, false},
+		{provision.StatusStarting, provision.StatusStarted, false},
+		{provision.StatusAsleep, provision.StatusStopped, false},
+	}
+	for _, tt := range tests {
+		c.Check(unitFailed(tt.prev, tt.next), check.Equals, tt.expected, check.Commentf("%s -> %s", tt.prev, tt.next))
+	}
+}
+
+func (s *S) TestResumePostDeployWatches(c *check.C) {
+	defer setPostDeployCheckInterval(100 * time.Millisecond)()
+	a, _ := s.newPostDeployApp(c, provision.TsuruYamlPostDeploy{WindowSeconds: 1, MaxUnitFailures: 1})
+	err := image.AppendAppImageName(a.Name, "registry.somewhere/tsuru/app-myapp:v2")
+	c.Assert(err, check.IsNil)
+	now := time.Now().UTC()
+	err = startDeployWatch(deployWatch{
+		App:          a.Name,
+		Image:        "registry.somewhere/tsuru/app-myapp:v2",
+		PrevImage:    "registry.somewhere/tsuru/app-myapp:v1",
+		Until:        now.Add(time.Second),
+		Config:       provision.TsuruYamlPostDeploy{WindowSeconds: 1, MaxUnitFailures: 1},
+		ClaimedBy:    "dead-instance",
+		ClaimedUntil: now.Add(-time.Second),
+	})
+	c.Assert(err, check.IsNil)
+	r := &postDeployResumer{}
+	err = r.resumeWatches(now)
+	c.Assert(err, check.IsNil)
+	evt := s.postDeployEvent(c, a.Name)
+	c.Assert(evt.Error, check.Equals, "")
+	c.Assert(evt.Log, check.Matches, `(?s).*Post-deploy window finished without errors.*`)
+	n, err := s.conn.DeployWatches().FindId(a.Name).Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 0)
+}
+
+func (s *S) TestResumePostDeployWatchesSkipsClaimedWatches(c *check.C) {
+	a, _ := s.newPostDeployApp(c, provision.TsuruYamlPostDeploy{WindowSeconds: 60, MaxUnitFailures: 1})
+	now := time.Now().UTC()
+	watch := deployWatch{
+		App:          a.Name,
+		Image:        "registry.somewhere/tsuru/app-myapp:v2",
+		PrevImage:    "registry.somewhere/tsuru/app-myapp:v1",
+		Until:        now.Add(time.Minute),
+		Config:       provision.TsuruYamlPostDeploy{WindowSeconds: 60, MaxUnitFailures: 1},
+		ClaimedBy:    "other-instance",
+		ClaimedUntil: now.Add(time.Minute),
+	}
+	err := startDeployWatch(watch)
+	c.Assert(err, check.IsNil)
+	r := &postDeployResumer{}
+	err = r.resumeWatches(now)
+	c.Assert(err, check.IsNil)
+	postDeployObservers.Wait()
+	evts, err := event.List(&event.Filter{KindNames: []string{postDeployEventKind}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 0)
+	var dbWatch deployWatch
+	err = s.conn.DeployWatches().FindId(a.Name).One(&dbWatch)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbWatch.ClaimedBy, check.Equals, "other-instance")
+	claimed, err := claimDeployWatch(s.conn, &watch, now.Add(2*time.Minute))
+	c.Assert(err, check.IsNil)
+	c.Assert(claimed, check.Equals, true)
+	claimed, err = claimDeployWatch(s.conn, &dbWatch, now.Add(2*time.Minute))
+	c.Assert(err, check.IsNil)
+	c.Assert(claimed, check.Equals, false)
+}
+
+func (s *S) TestResumePostDeployWatchesRemovesExpiredWatches(c *check.C) {
+	now := time.Now().UTC()
+	err := startDeployWatch(deployWatch{App: "myapp", Image: "registry.somewhere/tsuru/app-myapp:v2", Until: now.Add(-time.Second)})
+	c.Assert(err, check.IsNil)
+	r := &postDeployResumer{}
+	err = r.resumeWatches(now)
+	c.Assert(err, check.IsNil)
+	n, err := s.conn.DeployWatches().FindId("myapp").Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 0)
+}
+
+func (s *S) TestDeployPostDeployStopsWhenClaimLost(c *check.C) {
+	defer setPostDeployCheckInterval(100 * time.Millisecond)()
+	a, opts := s.newPostDeployApp(c, provision.TsuruYamlPostDeploy{WindowSeconds: 2, MaxUnitFailures: 1})
+	_, err := Deploy(opts)
+	c.Assert(err, check.IsNil)
+	err = opts.Event.Done(nil)
+	c.Assert(err, check.IsNil)
+	err = s.conn.DeployWatches().UpdateId(a.Name, bson.M{"$set": bson.M{"claimedby": "other-instance"}})
+	c.Assert(err, check.IsNil)
+	evt := s.postDeployEvent(c, a.Name)
+	c.Assert(evt.Error, check.Equals, "")
+	c.Assert(evt.Log, check.Matches, `(?s).*claimed by another instance.*`)
+	n, err := s.conn.DeployWatches().FindId(a.Name).Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 1)
+}
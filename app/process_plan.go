@@ -0,0 +1,93 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/servicemanager"
+	appTypes "github.com/tsuru/tsuru/types/app"
+)
+
+var ErrProcessPlanNotFound = errors.New("process plan not found")
+
+// processPlan returns the plan used by the units of the given process: its
+// override, if there is one, or the app plan.
+func (app *App) processPlan(process string) appTypes.Plan {
+	if plan, ok := app.ProcessPlans[process]; ok {
+		return plan
+	}
+	return app.Plan
+}
+
+// SetProcessPlan overrides the plan of the units of an app process, leaving
+// the remaining processes with the app plan. The units of the process are
+// restarted so the new limits take effect.
+func (app *App) SetProcessPlan(process, planName string, w io.Writer) error {
+	if process == "" {
+		return &tsuruErrors.ValidationError{Message: "process is required to set a process plan"}
+	}
+	processes, err := image.AllAppProcesses(app.Name)
+	if err != nil {
+		return &tsuruErrors.ValidationError{Message: "app must be deployed before setting a process plan"}
+	}
+	found := false
+	for _, p := range processes {
+		if p == process {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return &tsuruErrors.ValidationError{Message: fmt.Sprintf("process %q not found in app", process)}
+	}
+	plan, err := servicemanager.Plan.FindByName(planName)
+	if err != nil {
+		return err
+	}
+	err = app.validatePlanName(plan.Name)
+	if err != nil {
+		return err
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.Apps().Update(bson.M{"name": app.Name}, bson.M{"$set": bson.M{"processplans." + process: *plan}})
+	if err != nil {
+		return err
+	}
+	if app.ProcessPlans == nil {
+		app.ProcessPlans = make(map[string]appTypes.Plan)
+	}
+	app.ProcessPlans[process] = *plan
+	return app.Restart(process, w)
+}
+
+// UnsetProcessPlan removes the plan override of an app process, which goes
+// back to using the app plan after being restarted.
+func (app *App) UnsetProcessPlan(process string, w io.Writer) error {
+	if _, ok := app.ProcessPlans[process]; !ok {
+		return ErrProcessPlanNotFound
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.Apps().Update(bson.M{"name": app.Name}, bson.M{"$unset": bson.M{"processplans." + process: ""}})
+	if err != nil {
+		return err
+	}
+	delete(app.ProcessPlans, process)
+	return app.Restart(process, w)
+}
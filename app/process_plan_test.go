@@ -0,0 +1,114 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+
+	"github.com/tsuru/tsuru/app/image"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) newProcessPlanApp(c *check.C) (*App, appTypes.Plan) {
+	bigPlan := appTypes.Plan{Name: "big", Memory: 536870912, Swap: 1024, CpuShare: 200}
+	s.mockService.Plan.OnList = func() ([]appTypes.Plan, error) {
+		return []appTypes.Plan{s.defaultPlan, bigPlan}, nil
+	}
+	s.mockService.Plan.OnFindByName = func(name string) (*appTypes.Plan, error) {
+		for _, p := range []appTypes.Plan{s.defaultPlan, bigPlan} {
+			if p.Name == name {
+				return &p, nil
+			}
+		}
+		return nil, appTypes.ErrPlanNotFound
+	}
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	imgName := "tsuru/app-myapp:v1"
+	err = image.AppendAppImageName(a.Name, imgName)
+	c.Assert(err, check.IsNil)
+	err = image.SaveImageCustomData(imgName, map[string]interface{}{
+		"processes": map[string][]string{"web": {"./web"}, "worker": {"./worker"}},
+	})
+	c.Assert(err, check.IsNil)
+	return &a, bigPlan
+}
+
+func (s *S) TestSetProcessPlan(c *check.C) {
+	a, bigPlan := s.newProcessPlanApp(c)
+	buf := new(bytes.Buffer)
+	err := a.SetProcessPlan("worker", "big", buf)
+	c.Assert(err, check.IsNil)
+	c.Assert(a.ProcessPlans, check.DeepEquals, map[string]appTypes.Plan{"worker": bigPlan})
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.ProcessPlans, check.DeepEquals, map[string]appTypes.Plan{"worker": bigPlan})
+	c.Assert(dbApp.GetProcessMemory("worker"), check.Equals, bigPlan.Memory)
+	c.Assert(dbApp.GetProcessSwap("worker"), check.Equals, bigPlan.Swap)
+	c.Assert(dbApp.GetProcessCpuShare("worker"), check.Equals, bigPlan.CpuShare)
+	c.Assert(dbApp.GetProcessMemory("web"), check.Equals, s.defaultPlan.Memory)
+	c.Assert(s.provisioner.Restarts(a, "worker"), check.Equals, 1)
+	c.Assert(s.provisioner.Restarts(a, "web"), check.Equals, 0)
+}
+
+func (s *S) TestSetProcessPlanInvalid(c *check.C) {
+	a, _ := s.newProcessPlanApp(c)
+	err := a.SetProcessPlan("other", "big", nil)
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+	err = a.SetProcessPlan("", "big", nil)
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+	err = a.SetProcessPlan("web", "unknown", nil)
+	c.Assert(err, check.Equals, appTypes.ErrPlanNotFound)
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.ProcessPlans, check.HasLen, 0)
+}
+
+func (s *S) TestSetProcessPlanNotAllowedOnPool(c *check.C) {
+	a, _ := s.newProcessPlanApp(c)
+	s.mockService.Plan.OnList = func() ([]appTypes.Plan, error) {
+		return []appTypes.Plan{s.defaultPlan}, nil
+	}
+	err := a.SetProcessPlan("web", "big", nil)
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+	c.Assert(err, check.ErrorMatches, `App plan "big" is not allowed on pool .*`)
+}
+
+func (s *S) TestUnsetProcessPlan(c *check.C) {
+	a, _ := s.newProcessPlanApp(c)
+	err := a.SetProcessPlan("worker", "big", nil)
+	c.Assert(err, check.IsNil)
+	err = a.UnsetProcessPlan("worker", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(a.ProcessPlans, check.HasLen, 0)
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.ProcessPlans, check.HasLen, 0)
+	c.Assert(dbApp.GetProcessMemory("worker"), check.Equals, s.defaultPlan.Memory)
+	c.Assert(s.provisioner.Restarts(a, "worker"), check.Equals, 2)
+}
+
+func (s *S) TestUnsetProcessPlanNotFound(c *check.C) {
+	a, _ := s.newProcessPlanApp(c)
+	err := a.UnsetProcessPlan("worker", nil)
+	c.Assert(err, check.Equals, ErrProcessPlanNotFound)
+}
+
+func (s *S) TestGetProcessMemory(c *check.C) {
+	a := App{
+		Plan:         appTypes.Plan{Memory: 10, Swap: 20, CpuShare: 30},
+		ProcessPlans: map[string]appTypes.Plan{"worker": {Memory: 100, Swap: 200, CpuShare: 300}},
+	}
+	c.Assert(a.GetProcessMemory("web"), check.Equals, int64(10))
+	c.Assert(a.GetProcessSwap("web"), check.Equals, int64(20))
+	c.Assert(a.GetProcessCpuShare("web"), check.Equals, 30)
+	c.Assert(a.GetProcessMemory("worker"), check.Equals, int64(100))
+	c.Assert(a.GetProcessSwap("worker"), check.Equals, int64(200))
+	c.Assert(a.GetProcessCpuShare("worker"), check.Equals, 300)
+	c.Assert(a.GetProcessMemory(""), check.Equals, int64(10))
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/builder"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/provision"
+)
+
+// recordProvenance describes the image built by b in the deploy, generating
+// its SBOM when the builder is able to. Failures are reported in w, they never
+// fail the deploy.
+func recordProvenance(b builder.Builder, prov provision.BuilderDeploy, opts *DeployOptions, buildOpts *builder.BuildOpts, img, archiveDigest string, w io.Writer) {
+	provenance := &image.ImageProvenance{
+		Builder:             builder.NameOf(b),
+		Kind:                string(opts.Kind),
+		SourceArchiveDigest: archiveDigest,
+		SourceArchiveURL:    opts.ArchiveURL,
+		SourceImage:         opts.Image,
+		Commit:              opts.Commit,
+		PlatformImage:       buildOpts.PlatformImage,
+		BuiltAt:             time.Now().UTC(),
+	}
+	if provenance.PlatformImage == "" && !opts.Kind.withoutPlatform() {
+		platformImage, err := image.GetPlatformImage(opts.App)
+		if err != nil {
+			log.Errorf("[provenance] unable to get platform image of app %q: %v", opts.App.Name, err)
+		}
+		provenance.PlatformImage = platformImage
+	}
+	opts.provenance = provenance
+	provBuilder, ok := b.(builder.ProvenanceBuilder)
+	if !ok {
+		return
+	}
+	if provenance.PlatformImage != "" {
+		digest, err := provBuilder.ImageDigest(prov, opts.App, provenance.PlatformImage)
+		if err != nil {
+			log.Errorf("[provenance] unable to get digest of image %q: %v", provenance.PlatformImage, err)
+		}
+		provenance.PlatformImageDigest = digest
+	}
+	fmt.Fprintf(w, "\n---- Generating SBOM of image %q ----\n", img)
+	sbom, err := provBuilder.ImageSBOM(prov, opts.App, img)
+	if err != nil {
+		fmt.Fprintf(w, " ---> Unable to generate SBOM: %v\n", err)
+		return
+	}
+	fmt.Fprintf(w, " ---> %d packages found\n", len(sbom.Components))
+	opts.sbom = sbom
+}
+
+// saveProvenance stores the provenance and SBOM recorded during the build
+// along with the metadata of the deployed image.
+func saveProvenance(opts *DeployOptions, imageName string) {
+	if opts.sbom != nil {
+		opts.sbom.Metadata.Component.Name = imageName
+	}
+	err := image.SaveImageProvenance(imageName, opts.provenance, opts.sbom)
+	if err != nil {
+		log.Errorf("[provenance] unable to save provenance of image %q: %v", imageName, err)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/builder"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestDeployRecordsImageProvenance(c *check.C) {
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		opts.PlatformImage = "tsuru/django:v2"
+		return "registry.somewhere/" + s.team.Name + "/app-some-app:v1-builder", nil
+	}
+	defer func() { s.builder.OnBuild = nil }()
+	a := App{Name: "some-app", Platform: "django", TeamOwner: s.team.Name, Router: "fake"}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: a.Name},
+		Kind:     permission.PermAppDeploy,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	imageID, err := Deploy(DeployOptions{
+		App:          &a,
+		File:         ioutil.NopCloser(strings.NewReader("my file")),
+		FileSize:     7,
+		Commit:       "1ee1f1084927b3a5db59c9033bc5c4abefb7b93c",
+		OutputStream: ioutil.Discard,
+		Event:        evt,
+	})
+	c.Assert(err, check.IsNil)
+	provenance, err := image.GetImageProvenance(imageID)
+	c.Assert(err, check.IsNil)
+	c.Assert(provenance, check.NotNil)
+	c.Assert(provenance.BuiltAt.IsZero(), check.Equals, false)
+	c.Assert(*provenance, check.DeepEquals, image.ImageProvenance{
+		Builder:             "fake",
+		Kind:                string(DeployUpload),
+		SourceArchiveDigest: fmt.Sprintf("sha256:%x", sha256.Sum256([]byte("my file"))),
+		Commit:              "1ee1f1084927b3a5db59c9033bc5c4abefb7b93c",
+		PlatformImage:       "tsuru/django:v2",
+		BuiltAt:             provenance.BuiltAt,
+	})
+	sbom, err := image.GetImageSBOM(imageID)
+	c.Assert(err, check.IsNil)
+	c.Assert(sbom, check.IsNil)
+}
+
+func (s *S) TestGetDeployWithProvenance(c *check.C) {
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: "myapp"},
+		Kind:     permission.PermAppDeploy,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	err = evt.DoneCustomData(nil, map[string]string{"image": "tsuru/app-myapp:v1"})
+	c.Assert(err, check.IsNil)
+	provenance := &image.ImageProvenance{Builder: "docker", Kind: "git", Commit: "abc"}
+	err = image.SaveImageProvenance("tsuru/app-myapp:v1", provenance, nil)
+	c.Assert(err, check.IsNil)
+	deploy, err := GetDeploy(evt.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(deploy.Provenance, check.NotNil)
+	c.Assert(deploy.Provenance.Commit, check.Equals, "abc")
+	c.Assert(deploy.Provenance.Builder, check.Equals, "docker")
+}
@@ -0,0 +1,426 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/router"
+	appTypes "github.com/tsuru/tsuru/types/app"
+)
+
+const (
+	RouterMigrationStatusRunning = "running"
+	RouterMigrationStatusDone    = "done"
+	RouterMigrationStatusError   = "error"
+
+	routerMigrationStepAddRouter        = "add-router"
+	routerMigrationStepCopyCNames       = "copy-cnames"
+	routerMigrationStepCopyCertificates = "copy-certificates"
+	routerMigrationStepWaitReady        = "wait-ready"
+	routerMigrationStepSwitchDefault    = "switch-default"
+	routerMigrationStepRemoveOld        = "remove-old"
+
+	defaultRouterMigrationTimeout = 10 * time.Minute
+)
+
+var (
+	routerMigrationSteps = []string{
+		routerMigrationStepAddRouter,
+		routerMigrationStepCopyCNames,
+		routerMigrationStepCopyCertificates,
+		routerMigrationStepWaitReady,
+		routerMigrationStepSwitchDefault,
+		routerMigrationStepRemoveOld,
+	}
+
+	routerMigrationPollInterval = 5 * time.Second
+)
+
+// RouterMigrationOptions configures the migration of an app from the router
+// From to the router To. Timeout limits how long the migration waits for the
+// new backend to be ready and for certificates to be installed in it.
+type RouterMigrationOptions struct {
+	From    string
+	To      appTypes.AppRouter
+	DryRun  bool
+	Timeout time.Duration
+}
+
+// RouterMigration is the progress of the migration of an app between
+// routers. Steps holds the steps already completed, which are skipped when
+// an interrupted migration is run again.
+type RouterMigration struct {
+	App       string    `bson:"_id" json:"app"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Steps     []string  `json:"steps"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (m *RouterMigration) stepDone(step string) bool {
+	for _, s := range m.Steps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRouterMigration returns the last migration between routers of the app,
+// or nil if the app was never migrated.
+func GetRouterMigration(appName string) (*RouterMigration, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var m RouterMigration
+	err = conn.RouterMigrations().FindId(appName).One(&m)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListAppsForRouterMigration returns the apps matching filter that use the
+// router, or that have an unfinished migration from it.
+func ListAppsForRouterMigration(filter *Filter, routerName string) ([]App, error) {
+	apps, err := List(filter)
+	if err != nil {
+		return nil, err
+	}
+	var result []App
+	for _, a := range apps {
+		if a.hasRouter(routerName) {
+			result = append(result, a)
+			continue
+		}
+		m, err := GetRouterMigration(a.Name)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil && m.From == routerName && m.Status != RouterMigrationStatusDone {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (app *App) hasRouter(name string) bool {
+	for _, r := range app.GetRouters() {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrateRouter moves the app from the router opts.From to the router
+// opts.To without downtime: the new backend is added and receives the cnames
+// and certificates of the app, and only after it's ready the new router
+// becomes the default one and the old backend is removed.
+//
+// The progress is stored after each step, so running the migration again
+// after a failure resumes it. With opts.DryRun the steps are only reported
+// to w.
+func (app *App) MigrateRouter(opts RouterMigrationOptions, w io.Writer) error {
+	if opts.From == "" || opts.To.Name == "" {
+		return errors.New("both source and destination routers are required")
+	}
+	if opts.From == opts.To.Name {
+		return errors.New("source and destination routers must be different")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultRouterMigrationTimeout
+	}
+	migration, err := GetRouterMigration(app.Name)
+	if err != nil {
+		return err
+	}
+	if migration != nil && migration.Status != RouterMigrationStatusDone {
+		if migration.From != opts.From || migration.To != opts.To.Name {
+			return errors.Errorf("app %q has an unfinished migration from router %q to %q", app.Name, migration.From, migration.To)
+		}
+		fmt.Fprintf(w, "---- Resuming migration of app %q from router %q to %q ----\n", app.Name, opts.From, opts.To.Name)
+	} else {
+		if !app.hasRouter(opts.From) {
+			return &router.ErrRouterNotFound{Name: opts.From}
+		}
+		if app.hasRouter(opts.To.Name) {
+			return errors.Errorf("app %q already uses router %q", app.Name, opts.To.Name)
+		}
+		now := time.Now().UTC()
+		migration = &RouterMigration{
+			App:       app.Name,
+			From:      opts.From,
+			To:        opts.To.Name,
+			Status:    RouterMigrationStatusRunning,
+			StartedAt: now,
+			UpdatedAt: now,
+		}
+		fmt.Fprintf(w, "---- Migrating app %q from router %q to %q ----\n", app.Name, opts.From, opts.To.Name)
+	}
+	oldRouter, err := router.Get(opts.From)
+	if err != nil {
+		return err
+	}
+	newRouter, err := router.Get(opts.To.Name)
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		for _, step := range routerMigrationSteps {
+			if !migration.stepDone(step) {
+				fmt.Fprintf(w, " ---> would run step %s\n", step)
+			}
+		}
+		return nil
+	}
+	m := &routerMigration{
+		app:       app,
+		opts:      opts,
+		w:         w,
+		oldRouter: oldRouter,
+		newRouter: newRouter,
+	}
+	steps := map[string]func() error{
+		routerMigrationStepAddRouter:        m.addRouter,
+		routerMigrationStepCopyCNames:       m.copyCNames,
+		routerMigrationStepCopyCertificates: m.copyCertificates,
+		routerMigrationStepWaitReady:        m.waitReady,
+		routerMigrationStepSwitchDefault:    m.switchDefault,
+		routerMigrationStepRemoveOld:        m.removeOld,
+	}
+	migration.Status = RouterMigrationStatusRunning
+	migration.Error = ""
+	err = migration.save()
+	if err != nil {
+		return err
+	}
+	for _, step := range routerMigrationSteps {
+		if migration.stepDone(step) {
+			fmt.Fprintf(w, " ---> skipping step %s, already done\n", step)
+			continue
+		}
+		fmt.Fprintf(w, " ---> running step %s\n", step)
+		err = steps[step]()
+		if err != nil {
+			migration.Status = RouterMigrationStatusError
+			migration.Error = fmt.Sprintf("%s: %v", step, err)
+			if saveErr := migration.save(); saveErr != nil {
+				return errors.Wrapf(saveErr, "unable to save router migration progress after error: %v", err)
+			}
+			return errors.Wrapf(err, "step %s failed", step)
+		}
+		migration.Steps = append(migration.Steps, step)
+		err = migration.save()
+		if err != nil {
+			return err
+		}
+	}
+	migration.Status = RouterMigrationStatusDone
+	err = migration.save()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "---- App %q migrated to router %q ----\n", app.Name, opts.To.Name)
+	return nil
+}
+
+func (m *RouterMigration) save() error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	m.UpdatedAt = time.Now().UTC()
+	_, err = conn.RouterMigrations().UpsertId(m.App, m)
+	return err
+}
+
+type routerMigration struct {
+	app       *App
+	opts      RouterMigrationOptions
+	w         io.Writer
+	oldRouter router.Router
+	newRouter router.Router
+}
+
+func (m *routerMigration) addRouter() error {
+	if m.app.hasRouter(m.opts.To.Name) {
+		fmt.Fprintf(m.w, "     router %q already added\n", m.opts.To.Name)
+		return nil
+	}
+	return m.app.AddRouter(m.opts.To)
+}
+
+func (m *routerMigration) copyCNames() error {
+	if len(m.app.CName) == 0 {
+		return nil
+	}
+	cnameRouter, ok := m.newRouter.(router.CNameRouter)
+	if !ok {
+		return errors.Errorf("router %q does not support cnames", m.opts.To.Name)
+	}
+	for _, cname := range m.app.CName {
+		err := cnameRouter.SetCName(cname, m.app.Name)
+		if err != nil && err != router.ErrCNameExists {
+			return err
+		}
+		fmt.Fprintf(m.w, "     cname %s added\n", cname)
+	}
+	return nil
+}
+
+// copyCertificates ensures the new router has a certificate for every cname
+// with a certificate in the old one. Routers don't expose private keys, so
+// certificates issued through ACME are issued again, while the other ones
+// must be set again by the user before the migration is resumed.
+func (m *routerMigration) copyCertificates() error {
+	oldTLS, ok := m.oldRouter.(router.TLSRouter)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, cname := range m.app.CName {
+		cert, err := oldTLS.GetCertificate(m.app, cname)
+		if err == router.ErrCertificateNotFound || (err == nil && cert == "") {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		names = append(names, cname)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	newTLS, ok := m.newRouter.(router.TLSRouter)
+	if !ok {
+		return errors.Errorf("router %q does not support tls, unable to migrate certificates for %s", m.opts.To.Name, strings.Join(names, ", "))
+	}
+	missing, err := m.missingCertificates(newTLS, names)
+	if err != nil {
+		return err
+	}
+	var manual, reissued []string
+	defer func() {
+		if len(reissued) == 0 {
+			return
+		}
+		if err := finishACMEMigration(m.app.Name, reissued); err != nil {
+			log.Errorf("[router migration] unable to finish acme migration for app %q: %v", m.app.Name, err)
+		}
+	}()
+	for _, cname := range missing {
+		ok, err := reissueACMECertificate(m.app.Name, cname)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			manual = append(manual, cname)
+			continue
+		}
+		reissued = append(reissued, cname)
+		fmt.Fprintf(m.w, "     acme certificate for %s scheduled to be issued again\n", cname)
+	}
+	if len(manual) > 0 {
+		return errors.Errorf("certificates for %s can't be copied, set them again in the app and run the migration again", strings.Join(manual, ", "))
+	}
+	deadline := time.Now().Add(m.opts.Timeout)
+	for len(missing) > 0 {
+		if time.Now().After(deadline) {
+			return errors.Errorf("timeout waiting for certificates for %s in router %q", strings.Join(missing, ", "), m.opts.To.Name)
+		}
+		time.Sleep(routerMigrationPollInterval)
+		missing, err = m.missingCertificates(newTLS, missing)
+		if err != nil {
+			return err
+		}
+	}
+	for _, cname := range names {
+		fmt.Fprintf(m.w, "     certificate for %s installed\n", cname)
+	}
+	return nil
+}
+
+func (m *routerMigration) missingCertificates(tlsRouter router.TLSRouter, names []string) ([]string, error) {
+	var missing []string
+	for _, cname := range names {
+		cert, err := tlsRouter.GetCertificate(m.app, cname)
+		if err == router.ErrCertificateNotFound || (err == nil && cert == "") {
+			missing = append(missing, cname)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return missing, nil
+}
+
+func (m *routerMigration) waitReady() error {
+	statusRouter, ok := m.newRouter.(router.StatusRouter)
+	if !ok {
+		fmt.Fprintf(m.w, "     router %q does not report backend status, assuming ready\n", m.opts.To.Name)
+		return nil
+	}
+	deadline := time.Now().Add(m.opts.Timeout)
+	for {
+		status, detail, err := statusRouter.GetBackendStatus(m.app.Name)
+		if err != nil {
+			return err
+		}
+		if status == router.BackendStatusReady {
+			fmt.Fprintf(m.w, "     backend ready in router %q\n", m.opts.To.Name)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("timeout waiting for backend in router %q to be ready: %s", m.opts.To.Name, detail)
+		}
+		time.Sleep(routerMigrationPollInterval)
+	}
+}
+
+// switchDefault makes the new router the first one of the app, the one used
+// as the app address.
+func (m *routerMigration) switchDefault() error {
+	routers := m.app.GetRouters()
+	var newRouter *appTypes.AppRouter
+	others := make([]appTypes.AppRouter, 0, len(routers))
+	for i := range routers {
+		if routers[i].Name == m.opts.To.Name {
+			newRouter = &routers[i]
+			continue
+		}
+		others = append(others, routers[i])
+	}
+	if newRouter == nil {
+		return &router.ErrRouterNotFound{Name: m.opts.To.Name}
+	}
+	return m.app.updateRoutersDB(append([]appTypes.AppRouter{*newRouter}, others...))
+}
+
+func (m *routerMigration) removeOld() error {
+	if !m.app.hasRouter(m.opts.From) {
+		fmt.Fprintf(m.w, "     router %q already removed\n", m.opts.From)
+		return nil
+	}
+	return m.app.RemoveRouter(m.opts.From)
+}
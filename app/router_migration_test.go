@@ -0,0 +1,225 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/router"
+	"github.com/tsuru/tsuru/router/routertest"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) createAppForRouterMigration(c *check.C) *App {
+	a := App{
+		Name:      "my-test-app",
+		TeamOwner: s.team.Name,
+		Routers:   []appTypes.AppRouter{{Name: "fake"}},
+		CName:     []string{"my-test-app.io"},
+	}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	return &a
+}
+
+func routerNames(routers []appTypes.AppRouter) []string {
+	names := make([]string, len(routers))
+	for i := range routers {
+		names[i] = routers[i].Name
+	}
+	return names
+}
+
+func (s *S) TestMigrateRouter(c *check.C) {
+	a := s.createAppForRouterMigration(c)
+	var buf bytes.Buffer
+	err := a.MigrateRouter(RouterMigrationOptions{From: "fake", To: appTypes.AppRouter{Name: "fake-tls"}}, &buf)
+	c.Assert(err, check.IsNil)
+	c.Assert(routerNames(a.GetRouters()), check.DeepEquals, []string{"fake-tls"})
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(routerNames(dbApp.GetRouters()), check.DeepEquals, []string{"fake-tls"})
+	c.Assert(routertest.FakeRouter.HasBackend(a.Name), check.Equals, false)
+	c.Assert(routertest.TLSRouter.HasBackend(a.Name), check.Equals, true)
+	c.Assert(routertest.TLSRouter.HasCNameFor(a.Name, "my-test-app.io"), check.Equals, true)
+	migration, err := GetRouterMigration(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(migration.Status, check.Equals, RouterMigrationStatusDone)
+	c.Assert(migration.Steps, check.DeepEquals, routerMigrationSteps)
+	c.Assert(buf.String(), check.Matches, `(?s).*App "my-test-app" migrated to router "fake-tls".*`)
+}
+
+func (s *S) TestMigrateRouterDryRun(c *check.C) {
+	a := s.createAppForRouterMigration(c)
+	var buf bytes.Buffer
+	err := a.MigrateRouter(RouterMigrationOptions{From: "fake", To: appTypes.AppRouter{Name: "fake-tls"}, DryRun: true}, &buf)
+	c.Assert(err, check.IsNil)
+	c.Assert(buf.String(), check.Matches, `(?s).*would run step add-router.*would run step remove-old.*`)
+	c.Assert(routerNames(a.GetRouters()), check.DeepEquals, []string{"fake"})
+	c.Assert(routertest.TLSRouter.HasBackend(a.Name), check.Equals, false)
+	migration, err := GetRouterMigration(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(migration, check.IsNil)
+}
+
+func (s *S) TestMigrateRouterResume(c *check.C) {
+	config.Set("routers:fake-status:type", "fake-status")
+	defer config.Unset("routers:fake-status")
+	defer routertest.StatusRouter.Reset()
+	oldInterval := routerMigrationPollInterval
+	routerMigrationPollInterval = time.Millisecond
+	defer func() { routerMigrationPollInterval = oldInterval }()
+	a := s.createAppForRouterMigration(c)
+	routertest.StatusRouter.Status = router.BackendStatusNotReady
+	routertest.StatusRouter.StatusDetail = "waiting for units"
+	opts := RouterMigrationOptions{From: "fake", To: appTypes.AppRouter{Name: "fake-status"}, Timeout: 10 * time.Millisecond}
+	var buf bytes.Buffer
+	err := a.MigrateRouter(opts, &buf)
+	c.Assert(err, check.ErrorMatches, `step wait-ready failed: timeout waiting for backend in router "fake-status" to be ready: waiting for units`)
+	c.Assert(routerNames(a.GetRouters()), check.DeepEquals, []string{"fake", "fake-status"})
+	migration, err := GetRouterMigration(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(migration.Status, check.Equals, RouterMigrationStatusError)
+	c.Assert(migration.Steps, check.DeepEquals, []string{"add-router", "copy-cnames", "copy-certificates"})
+	err = a.MigrateRouter(RouterMigrationOptions{From: "fake", To: appTypes.AppRouter{Name: "fake-tls"}}, &buf)
+	c.Assert(err, check.ErrorMatches, `app "my-test-app" has an unfinished migration from router "fake" to "fake-status"`)
+	routertest.StatusRouter.Status = router.BackendStatusReady
+	buf.Reset()
+	err = a.MigrateRouter(opts, &buf)
+	c.Assert(err, check.IsNil)
+	c.Assert(buf.String(), check.Matches, `(?s).*Resuming migration.*skipping step add-router, already done.*running step wait-ready.*`)
+	c.Assert(routerNames(a.GetRouters()), check.DeepEquals, []string{"fake-status"})
+	c.Assert(routertest.FakeRouter.HasBackend(a.Name), check.Equals, false)
+	migration, err = GetRouterMigration(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(migration.Status, check.Equals, RouterMigrationStatusDone)
+}
+
+// migrationTLSRouter is a tls router with its own certificates, unlike the
+// routers created by routertest, which share them between router names.
+type migrationTLSRouter struct {
+	router.Router
+	router.CNameRouter
+	mu    sync.Mutex
+	certs map[string]string
+}
+
+func (r *migrationTLSRouter) AddCertificate(app router.App, cname, certificate, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.certs[cname] = certificate
+	return nil
+}
+
+func (r *migrationTLSRouter) RemoveCertificate(app router.App, cname string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.certs, cname)
+	return nil
+}
+
+func (r *migrationTLSRouter) GetCertificate(app router.App, cname string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cert, ok := r.certs[cname]
+	if !ok {
+		return "", router.ErrCertificateNotFound
+	}
+	return cert, nil
+}
+
+func (s *S) TestMigrateRouterReissuesACMECertificate(c *check.C) {
+	server := s.newACMEServer(c)
+	defer server.Close()
+	defer config.Unset("acme")
+	newRouter := &migrationTLSRouter{Router: &routertest.FakeRouter, CNameRouter: &routertest.FakeRouter, certs: map[string]string{}}
+	router.Register("migration-tls", func(name, prefix string) (router.Router, error) {
+		return newRouter, nil
+	})
+	defer router.Unregister("migration-tls")
+	config.Set("routers:migration-tls:type", "migration-tls")
+	defer config.Unset("routers:migration-tls")
+	oldInterval := routerMigrationPollInterval
+	routerMigrationPollInterval = time.Millisecond
+	defer func() { routerMigrationPollInterval = oldInterval }()
+	a := s.createACMEApp(c)
+	err := EnableACMECertificate(a, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	m := &acmeManager{directoryURL: server.DirectoryURL(), renewBefore: 7 * 24 * time.Hour, retryInterval: time.Hour}
+	err = m.run(context.Background(), time.Now().UTC())
+	c.Assert(err, check.IsNil)
+	c.Assert(server.Issued(), check.HasLen, 1)
+	select {
+	case <-acmeTrigger:
+	default:
+	}
+	// The migration runs in an event locking the app, as in the api, while
+	// the manager issues the certificate again.
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		Kind:     permission.PermAppUpdateRouter,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	defer evt.Done(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	managerDone := make(chan struct{})
+	go func() {
+		defer close(managerDone)
+		select {
+		case <-acmeTrigger:
+			m.run(ctx, time.Now().UTC())
+		case <-ctx.Done():
+		}
+	}()
+	opts := RouterMigrationOptions{From: "fake-tls", To: appTypes.AppRouter{Name: "migration-tls"}, Timeout: 10 * time.Second}
+	err = a.MigrateRouter(opts, evt)
+	c.Assert(err, check.IsNil)
+	<-managerDone
+	c.Assert(server.Issued(), check.HasLen, 2)
+	cert, err := newRouter.GetCertificate(a, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	c.Assert(cert, check.Not(check.Equals), "")
+	c.Assert(routerNames(a.GetRouters()), check.DeepEquals, []string{"migration-tls"})
+	c.Assert(eventtest.EventDesc{
+		Target:     event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		Kind:       ACMEIssueEventKind,
+		LogMatches: "certificate for myapp.example.com installed",
+	}, eventtest.HasEvent)
+	certs, err := ListACMECertificates(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(certs[0].Status, check.Equals, ACMEStatusIssued)
+	c.Assert(certs[0].Migrating, check.Equals, false)
+}
+
+func (s *S) TestMigrateRouterInvalidRouters(c *check.C) {
+	a := s.createAppForRouterMigration(c)
+	var buf bytes.Buffer
+	err := a.MigrateRouter(RouterMigrationOptions{From: "fake", To: appTypes.AppRouter{Name: "fake"}}, &buf)
+	c.Assert(err, check.ErrorMatches, "source and destination routers must be different")
+	err = a.MigrateRouter(RouterMigrationOptions{From: "fake-tls", To: appTypes.AppRouter{Name: "fake"}}, &buf)
+	c.Assert(err, check.FitsTypeOf, &router.ErrRouterNotFound{})
+}
+
+func (s *S) TestListAppsForRouterMigration(c *check.C) {
+	a := s.createAppForRouterMigration(c)
+	other := App{Name: "other-app", TeamOwner: s.team.Name, Routers: []appTypes.AppRouter{{Name: "fake-tls"}}}
+	err := CreateApp(&other, s.user)
+	c.Assert(err, check.IsNil)
+	apps, err := ListAppsForRouterMigration(&Filter{Pool: s.Pool}, "fake")
+	c.Assert(err, check.IsNil)
+	c.Assert(apps, check.HasLen, 1)
+	c.Assert(apps[0].Name, check.Equals, a.Name)
+}
@@ -0,0 +1,339 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/api/shutdown"
+	"github.com/tsuru/tsuru/app/cron"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/pool"
+	"github.com/tsuru/tsuru/validation"
+)
+
+const (
+	SleepScheduleSleepEventKind = "scheduled-sleep"
+	SleepScheduleWakeEventKind  = "scheduled-wake"
+
+	sleepSchedulerInterval = 30 * time.Second
+)
+
+var (
+	ErrSleepScheduleNotFound      = errors.New("sleep schedule not found")
+	ErrSleepScheduleAlreadyExists = errors.New("sleep schedule already exists")
+)
+
+// SleepSchedule puts an app, or all apps in a pool, to sleep and wakes them
+// up following cron expressions evaluated in the schedule time zone. Only one
+// of App and Pool is set.
+type SleepSchedule struct {
+	Name     string    `json:"name"`
+	App      string    `json:"app,omitempty"`
+	Pool     string    `json:"pool,omitempty"`
+	Process  string    `json:"process,omitempty"`
+	Sleep    string    `json:"sleep"`
+	Wake     string    `json:"wake"`
+	TimeZone string    `json:"timeZone"`
+	Proxy    string    `json:"proxy"`
+	LastRun  time.Time `json:"lastRun"`
+}
+
+func (s *SleepSchedule) validate() error {
+	if !validation.ValidateName(s.Name) {
+		msg := "Invalid sleep schedule name, sleep schedule name should have at most 40 " +
+			"characters, containing only lower case letters, numbers or dashes, " +
+			"starting with a letter."
+		return &tsuruErrors.ValidationError{Message: msg}
+	}
+	if (s.App == "") == (s.Pool == "") {
+		return &tsuruErrors.ValidationError{Message: "sleep schedule must target either an app or a pool"}
+	}
+	for _, spec := range []string{s.Sleep, s.Wake} {
+		_, err := cron.Parse(spec)
+		if err != nil {
+			return &tsuruErrors.ValidationError{Message: err.Error()}
+		}
+	}
+	if _, err := time.LoadLocation(s.TimeZone); err != nil {
+		return &tsuruErrors.ValidationError{Message: "invalid time zone: " + s.TimeZone}
+	}
+	if s.Proxy == "" {
+		s.Proxy, _ = config.GetString("sleep:proxy")
+	}
+	if s.Proxy == "" {
+		return &tsuruErrors.ValidationError{Message: "sleep schedule proxy is required"}
+	}
+	if u, err := url.Parse(s.Proxy); err != nil || u.Host == "" {
+		return &tsuruErrors.ValidationError{Message: "invalid proxy url: " + s.Proxy}
+	}
+	return nil
+}
+
+// nextAction returns the latest action of the schedule due until now, if
+// any. When both sleep and wake activations were missed, only the most
+// recent one is considered.
+func (s *SleepSchedule) nextAction(now time.Time) (string, time.Time, error) {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	var action string
+	var due time.Time
+	for kind, spec := range map[string]string{SleepScheduleSleepEventKind: s.Sleep, SleepScheduleWakeEventKind: s.Wake} {
+		schedule, err := cron.Parse(spec)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		for next := schedule.Next(s.LastRun.In(loc)); !next.IsZero() && !next.After(now); next = schedule.Next(next) {
+			if next.After(due) {
+				action, due = kind, next
+			}
+		}
+	}
+	return action, due, nil
+}
+
+func (s *SleepSchedule) query() bson.M {
+	return bson.M{"app": s.App, "pool": s.Pool, "name": s.Name}
+}
+
+// AddSleepSchedule validates and stores a new sleep schedule.
+func AddSleepSchedule(s SleepSchedule) error {
+	if s.TimeZone == "" {
+		s.TimeZone = "UTC"
+	}
+	s.LastRun = time.Now().UTC()
+	err := s.validate()
+	if err != nil {
+		return err
+	}
+	if s.App != "" {
+		_, err = GetByName(s.App)
+	} else {
+		_, err = pool.GetPoolByName(s.Pool)
+	}
+	if err != nil {
+		return err
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.SleepSchedules().Insert(s)
+	if mgo.IsDup(err) {
+		return ErrSleepScheduleAlreadyExists
+	}
+	return err
+}
+
+// ListSleepSchedules returns the sleep schedules of an app or of a pool.
+func ListSleepSchedules(appName, poolName string) ([]SleepSchedule, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var schedules []SleepSchedule
+	err = conn.SleepSchedules().Find(bson.M{"app": appName, "pool": poolName}).Sort("name").All(&schedules)
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// RemoveSleepSchedule removes the sleep schedule with the given name from an
+// app or a pool.
+func RemoveSleepSchedule(appName, poolName, name string) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.SleepSchedules().Remove(bson.M{"app": appName, "pool": poolName, "name": name})
+	if err == mgo.ErrNotFound {
+		return ErrSleepScheduleNotFound
+	}
+	return err
+}
+
+func (app *App) removeSleepSchedules() error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.SleepSchedules().RemoveAll(bson.M{"app": app.Name})
+	return err
+}
+
+// InitializeSleepScheduler starts the scheduler responsible for putting apps
+// to sleep and waking them up according to their sleep schedules and the
+// ones of their pools.
+func InitializeSleepScheduler() error {
+	s := &sleepScheduler{}
+	s.periodicWorker = periodicWorker{
+		name:     "sleep scheduler",
+		interval: sleepSchedulerInterval,
+		run:      s.runSchedules,
+		once:     &sync.Once{},
+	}
+	s.start()
+	shutdown.Register(s)
+	return nil
+}
+
+type sleepScheduler struct {
+	periodicWorker
+}
+
+func (s *sleepScheduler) runSchedules(now time.Time) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var schedules []SleepSchedule
+	err = conn.SleepSchedules().Find(nil).All(&schedules)
+	if err != nil {
+		return err
+	}
+	for i := range schedules {
+		schedule := &schedules[i]
+		err = s.runSchedule(conn, schedule, now)
+		if err != nil {
+			log.Errorf("[sleep scheduler] error processing sleep schedule %q: %v", schedule.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *sleepScheduler) runSchedule(conn *db.Storage, schedule *SleepSchedule, now time.Time) error {
+	action, due, err := schedule.nextAction(now)
+	if err != nil || action == "" {
+		return err
+	}
+	// Only the tsuru API instance that successfully updates the last run
+	// applies the schedule.
+	query := schedule.query()
+	query["lastrun"] = schedule.LastRun
+	err = conn.SleepSchedules().Update(query, bson.M{"$set": bson.M{"lastrun": due.UTC()}})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var apps []App
+	if schedule.App != "" {
+		var a *App
+		a, err = GetByName(schedule.App)
+		if err == nil {
+			apps = []App{*a}
+		}
+	} else {
+		apps, err = List(&Filter{Pool: schedule.Pool})
+	}
+	if err != nil {
+		restoreSleepScheduleLastRun(schedule, due)
+		return err
+	}
+	var runWg sync.WaitGroup
+	var failed int32
+	for i := range apps {
+		a := &apps[i]
+		runWg.Add(1)
+		go func() {
+			defer runWg.Done()
+			runErr := runSleepSchedule(a, schedule, action)
+			if runErr != nil {
+				atomic.AddInt32(&failed, 1)
+				log.Errorf("[sleep scheduler] error running sleep schedule %q on app %q: %v", schedule.Name, a.Name, runErr)
+			}
+		}()
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		runWg.Wait()
+		if atomic.LoadInt32(&failed) > 0 {
+			restoreSleepScheduleLastRun(schedule, due)
+		}
+	}()
+	return nil
+}
+
+// restoreSleepScheduleLastRun reverts the last run of the schedule when it
+// failed to be applied to any app, so the action is retried on the next run
+// of the scheduler. Running it again is harmless for the apps already put to
+// sleep or woken up, as only units in the opposite state are affected.
+func restoreSleepScheduleLastRun(schedule *SleepSchedule, due time.Time) {
+	conn, err := db.Conn()
+	if err != nil {
+		log.Errorf("[sleep scheduler] unable to restore last run of sleep schedule %q: %v", schedule.Name, err)
+		return
+	}
+	defer conn.Close()
+	query := schedule.query()
+	query["lastrun"] = due.UTC()
+	err = conn.SleepSchedules().Update(query, bson.M{"$set": bson.M{"lastrun": schedule.LastRun}})
+	if err != nil && err != mgo.ErrNotFound {
+		log.Errorf("[sleep scheduler] unable to restore last run of sleep schedule %q: %v", schedule.Name, err)
+	}
+}
+
+// runSleepSchedule puts the app to sleep or wakes it up, holding the app
+// lock. Apps are only woken up if they are asleep, so that apps stopped by
+// their teams remain stopped.
+func runSleepSchedule(a *App, schedule *SleepSchedule, action string) (err error) {
+	units, err := a.Units()
+	if err != nil {
+		return err
+	}
+	status := provision.StatusStarted
+	if action == SleepScheduleWakeEventKind {
+		status = provision.StatusAsleep
+	}
+	var found bool
+	for _, u := range units {
+		if (schedule.Process == "" || u.ProcessName == schedule.Process) && u.Status == status {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		InternalKind: action,
+		CustomData:   schedule,
+		Allowed:      a.internalEventAllowed(),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	if action == SleepScheduleWakeEventKind {
+		return a.Start(evt, schedule.Process)
+	}
+	proxyURL, err := url.Parse(schedule.Proxy)
+	if err != nil {
+		return err
+	}
+	return a.Sleep(evt, schedule.Process, proxyURL)
+}
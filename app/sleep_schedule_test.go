@@ -0,0 +1,192 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"time"
+
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision/pool"
+	"github.com/tsuru/tsuru/router/routertest"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) setSleepScheduleLastRun(c *check.C, appName, poolName, name string, lastRun time.Time) {
+	err := s.conn.SleepSchedules().Update(map[string]string{"app": appName, "pool": poolName, "name": name},
+		map[string]interface{}{"$set": map[string]interface{}{"lastrun": lastRun}})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestAddSleepSchedule(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = AddSleepSchedule(SleepSchedule{Name: "nights", App: a.Name, Sleep: "0 20 * * 1-5", Wake: "0 8 * * 1-5", TimeZone: "America/Sao_Paulo", Proxy: "http://proxy.example.com"})
+	c.Assert(err, check.IsNil)
+	err = AddSleepSchedule(SleepSchedule{Name: "nights", Pool: s.Pool, Sleep: "0 22 * * *", Wake: "0 6 * * *", Proxy: "http://proxy.example.com"})
+	c.Assert(err, check.IsNil)
+	schedules, err := ListSleepSchedules(a.Name, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(schedules, check.HasLen, 1)
+	c.Assert(schedules[0].Sleep, check.Equals, "0 20 * * 1-5")
+	c.Assert(schedules[0].TimeZone, check.Equals, "America/Sao_Paulo")
+	c.Assert(schedules[0].LastRun.IsZero(), check.Equals, false)
+	schedules, err = ListSleepSchedules("", s.Pool)
+	c.Assert(err, check.IsNil)
+	c.Assert(schedules, check.HasLen, 1)
+	c.Assert(schedules[0].TimeZone, check.Equals, "UTC")
+	err = AddSleepSchedule(SleepSchedule{Name: "nights", App: a.Name, Sleep: "@daily", Wake: "@hourly", Proxy: "http://proxy.example.com"})
+	c.Assert(err, check.Equals, ErrSleepScheduleAlreadyExists)
+}
+
+func (s *S) TestAddSleepScheduleInvalid(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	tests := []SleepSchedule{
+		{Name: "Invalid_Name", App: a.Name, Sleep: "@daily", Wake: "@hourly", Proxy: "http://proxy"},
+		{Name: "nights", Sleep: "@daily", Wake: "@hourly", Proxy: "http://proxy"},
+		{Name: "nights", App: a.Name, Pool: s.Pool, Sleep: "@daily", Wake: "@hourly", Proxy: "http://proxy"},
+		{Name: "nights", App: a.Name, Sleep: "* *", Wake: "@hourly", Proxy: "http://proxy"},
+		{Name: "nights", App: a.Name, Sleep: "@daily", Wake: "@hourly", TimeZone: "Mars/Olympus", Proxy: "http://proxy"},
+		{Name: "nights", App: a.Name, Sleep: "@daily", Wake: "@hourly"},
+	}
+	for _, schedule := range tests {
+		err = AddSleepSchedule(schedule)
+		c.Check(err, check.FitsTypeOf, &tsuruErrors.ValidationError{}, check.Commentf("%#v", schedule))
+	}
+	err = AddSleepSchedule(SleepSchedule{Name: "nights", Pool: "unknown", Sleep: "@daily", Wake: "@hourly", Proxy: "http://proxy"})
+	c.Assert(err, check.Equals, pool.ErrPoolNotFound)
+}
+
+func (s *S) TestRemoveSleepSchedule(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = AddSleepSchedule(SleepSchedule{Name: "nights", App: a.Name, Sleep: "@daily", Wake: "@hourly", Proxy: "http://proxy"})
+	c.Assert(err, check.IsNil)
+	err = RemoveSleepSchedule(a.Name, "", "nights")
+	c.Assert(err, check.IsNil)
+	err = RemoveSleepSchedule(a.Name, "", "nights")
+	c.Assert(err, check.Equals, ErrSleepScheduleNotFound)
+}
+
+func (s *S) TestSleepScheduleNextAction(c *check.C) {
+	schedule := SleepSchedule{
+		Sleep:    "0 20 * * *",
+		Wake:     "0 8 * * *",
+		TimeZone: "America/Sao_Paulo",
+		LastRun:  time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC),
+	}
+	action, _, err := schedule.nextAction(time.Date(2018, time.May, 15, 22, 0, 0, 0, time.UTC))
+	c.Assert(err, check.IsNil)
+	c.Assert(action, check.Equals, "")
+	action, due, err := schedule.nextAction(time.Date(2018, time.May, 15, 23, 0, 0, 0, time.UTC))
+	c.Assert(err, check.IsNil)
+	c.Assert(action, check.Equals, SleepScheduleSleepEventKind)
+	c.Assert(due.Equal(time.Date(2018, time.May, 15, 23, 0, 0, 0, time.UTC)), check.Equals, true)
+	action, due, err = schedule.nextAction(time.Date(2018, time.May, 16, 12, 0, 0, 0, time.UTC))
+	c.Assert(err, check.IsNil)
+	c.Assert(action, check.Equals, SleepScheduleWakeEventKind)
+	c.Assert(due.Equal(time.Date(2018, time.May, 16, 11, 0, 0, 0, time.UTC)), check.Equals, true)
+}
+
+func (s *S) TestSleepSchedulerSleepsAndWakesApp(c *check.C) {
+	a := App{
+		Name:      "myapp",
+		Platform:  "go",
+		TeamOwner: s.team.Name,
+		Routers:   []appTypes.AppRouter{{Name: "fake"}},
+	}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	routertest.FakeRouter.AddBackend(&a)
+	err = a.AddUnits(1, "web", nil)
+	c.Assert(err, check.IsNil)
+	err = AddSleepSchedule(SleepSchedule{Name: "nights", Pool: s.Pool, Sleep: "0 20 * * *", Wake: "0 8 * * *", Proxy: "http://proxy.example.com"})
+	c.Assert(err, check.IsNil)
+	s.setSleepScheduleLastRun(c, "", s.Pool, "nights", time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC))
+	sched := &sleepScheduler{}
+	err = sched.runSchedules(time.Date(2018, time.May, 15, 20, 1, 0, 0, time.UTC))
+	c.Assert(err, check.IsNil)
+	sched.wg.Wait()
+	c.Assert(s.provisioner.Sleeps(&a, ""), check.Equals, 1)
+	c.Assert(routertest.FakeRouter.HasRoute(a.Name, "http://proxy.example.com"), check.Equals, true)
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		Kind:   SleepScheduleSleepEventKind,
+	}, eventtest.HasEvent)
+	err = sched.runSchedules(time.Date(2018, time.May, 15, 20, 30, 0, 0, time.UTC))
+	c.Assert(err, check.IsNil)
+	sched.wg.Wait()
+	c.Assert(s.provisioner.Sleeps(&a, ""), check.Equals, 1)
+	err = sched.runSchedules(time.Date(2018, time.May, 16, 8, 1, 0, 0, time.UTC))
+	c.Assert(err, check.IsNil)
+	sched.wg.Wait()
+	c.Assert(s.provisioner.Starts(&a, ""), check.Equals, 1)
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		Kind:   SleepScheduleWakeEventKind,
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestSleepSchedulerDoesNotWakeStoppedApp(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddUnits(1, "web", nil)
+	c.Assert(err, check.IsNil)
+	err = AddSleepSchedule(SleepSchedule{Name: "mornings", App: a.Name, Sleep: "0 20 * * *", Wake: "0 8 * * *", Proxy: "http://proxy.example.com"})
+	c.Assert(err, check.IsNil)
+	s.setSleepScheduleLastRun(c, a.Name, "", "mornings", time.Date(2018, time.May, 15, 0, 0, 0, 0, time.UTC))
+	sched := &sleepScheduler{}
+	err = sched.runSchedules(time.Date(2018, time.May, 15, 8, 1, 0, 0, time.UTC))
+	c.Assert(err, check.IsNil)
+	sched.wg.Wait()
+	c.Assert(s.provisioner.Starts(&a, ""), check.Equals, 0)
+	schedules, err := ListSleepSchedules(a.Name, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(schedules[0].LastRun.Equal(time.Date(2018, time.May, 15, 8, 0, 0, 0, time.UTC)), check.Equals, true)
+}
+
+func (s *S) TestSleepSchedulerRetriesFailedApps(c *check.C) {
+	a := App{Name: "myapp", Platform: "go", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddUnits(1, "web", nil)
+	c.Assert(err, check.IsNil)
+	err = AddSleepSchedule(SleepSchedule{Name: "nights", App: a.Name, Sleep: "0 20 * * *", Wake: "0 8 * * *", Proxy: "http://proxy.example.com"})
+	c.Assert(err, check.IsNil)
+	lastRun := time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC)
+	s.setSleepScheduleLastRun(c, a.Name, "", "nights", lastRun)
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		Kind:     permission.PermAppUpdateEnvSet,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	sched := &sleepScheduler{}
+	err = sched.runSchedules(time.Date(2018, time.May, 15, 20, 1, 0, 0, time.UTC))
+	c.Assert(err, check.IsNil)
+	sched.wg.Wait()
+	c.Assert(s.provisioner.Sleeps(&a, ""), check.Equals, 0)
+	schedules, err := ListSleepSchedules(a.Name, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(schedules[0].LastRun.Equal(lastRun), check.Equals, true)
+	err = evt.Done(nil)
+	c.Assert(err, check.IsNil)
+	err = sched.runSchedules(time.Date(2018, time.May, 15, 20, 2, 0, 0, time.UTC))
+	c.Assert(err, check.IsNil)
+	sched.wg.Wait()
+	c.Assert(s.provisioner.Sleeps(&a, ""), check.Equals, 1)
+	schedules, err = ListSleepSchedules(a.Name, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(schedules[0].LastRun.Equal(time.Date(2018, time.May, 15, 20, 0, 0, 0, time.UTC)), check.Equals, true)
+}
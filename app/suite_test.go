@@ -98,6 +98,8 @@ func (s *S) SetUpSuite(c *check.C) {
 	config.Set("queue:mongo-polling-interval", 0.01)
 	config.Set("docker:registry", "registry.somewhere")
 	config.Set("routers:fake-tls:type", "fake-tls")
+	config.Set("routers:fake-weighted:type", "fake-weighted")
+	config.Set("routers:fake-stats:type", "fake-stats")
 	config.Set("auth:hash-cost", bcrypt.MinCost)
 	s.conn, err = db.Conn()
 	c.Assert(err, check.IsNil)
@@ -127,11 +129,15 @@ func (s *S) SetUpTest(c *check.C) {
 	routertest.HCRouter.Reset()
 	routertest.TLSRouter.Reset()
 	routertest.OptsRouter.Reset()
+	routertest.WeightedRouter.Reset()
+	routertest.StatsRouter.Reset()
 	queue.ResetQueue()
 	routertest.FakeRouter.Reset()
 	routertest.HCRouter.Reset()
 	routertest.TLSRouter.Reset()
 	routertest.OptsRouter.Reset()
+	routertest.WeightedRouter.Reset()
+	routertest.StatsRouter.Reset()
 	pool.ResetCache()
 	err := rebuild.RegisterTask(func(appName string) (rebuild.RebuildApp, error) {
 		a, err := GetByName(appName)
@@ -52,8 +52,9 @@ func (a *memoryScaler) nodesMemoryData(pool string, nodes []provision.Node) (map
 			if err != nil {
 				return nil, errors.Wrapf(err, "couldn't find container app (%s)", unit.AppName)
 			}
-			data.containersMemory[unit.ID] = a.Plan.Memory
-			data.reserved += a.Plan.Memory
+			memory := a.GetProcessMemory(unit.ProcessName)
+			data.containersMemory[unit.ID] = memory
+			data.reserved += memory
 		}
 		data.available = data.maxMemory - data.reserved
 	}
@@ -0,0 +1,287 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package autoscale
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/api/shutdown"
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/permission"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+)
+
+const (
+	UnitEventKind = "unit-autoscale"
+
+	// unitScaleTolerance is the maximum relative difference between the
+	// metric value and the target that doesn't trigger scaling.
+	unitScaleTolerance = 0.1
+)
+
+// UnitScaleDecision is the custom data of events recording unit autoscale
+// operations.
+type UnitScaleDecision struct {
+	Process      string
+	Metric       string
+	Target       float64
+	Value        float64
+	CurrentUnits uint
+	DesiredUnits uint
+	Reason       string
+}
+
+type unitScaler struct {
+	runInterval time.Duration
+	done        chan bool
+	running     bool
+	now         func() time.Time
+}
+
+// InitializeUnitScaler starts scaling units of app processes with autoscale
+// settings handled by tsuru itself.
+func InitializeUnitScaler() error {
+	s := newUnitScaler()
+	shutdown.Register(s)
+	s.running = true
+	go s.run()
+	return nil
+}
+
+func newUnitScaler() *unitScaler {
+	runInterval, _ := config.GetInt("autoscale:units:run-interval")
+	s := &unitScaler{
+		runInterval: time.Duration(runInterval) * time.Second,
+		done:        make(chan bool),
+		now:         time.Now,
+	}
+	if s.runInterval == 0 {
+		s.runInterval = 30 * time.Second
+	}
+	return s
+}
+
+func (s *unitScaler) run() {
+	for {
+		err := s.runOnce()
+		if err != nil {
+			log.Errorf("[unit autoscale] %v", err)
+		}
+		select {
+		case <-s.done:
+			return
+		case <-time.After(s.runInterval):
+		}
+	}
+}
+
+func (s *unitScaler) Shutdown(ctx context.Context) error {
+	if !s.running {
+		return nil
+	}
+	s.done <- true
+	s.running = false
+	return nil
+}
+
+func (s *unitScaler) String() string {
+	return "unit auto scale"
+}
+
+func (s *unitScaler) runOnce() error {
+	autoScales, err := app.ListAutoScales()
+	if err != nil {
+		return errors.Wrap(err, "unable to list autoscales")
+	}
+	for i := range autoScales {
+		as := &autoScales[i]
+		err = s.scale(as)
+		if err != nil {
+			log.Errorf("[unit autoscale] error scaling process %q of app %q: %v", as.Process, as.App, err)
+		}
+	}
+	return nil
+}
+
+func (s *unitScaler) scale(as *app.AutoScale) error {
+	a, err := app.GetByName(as.App)
+	if err != nil {
+		return err
+	}
+	current, err := processUnits(a, as.Process)
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		// Stopped or sleeping processes are not scaled.
+		return nil
+	}
+	decision, err := s.decide(a, as, current)
+	if err != nil {
+		return err
+	}
+	if decision.DesiredUnits == decision.CurrentUnits {
+		return nil
+	}
+	scaleUp := decision.DesiredUnits > decision.CurrentUnits
+	if s.now().Sub(as.LastScale) < as.Cooldown(scaleUp) {
+		log.Debugf("[unit autoscale] skipping process %q of app %q, in cooldown", as.Process, as.App)
+		return nil
+	}
+	return s.apply(a, as, decision)
+}
+
+func processUnits(a *app.App, process string) (uint, error) {
+	units, err := a.Units()
+	if err != nil {
+		return 0, err
+	}
+	var current uint
+	for _, u := range units {
+		if u.ProcessName == process {
+			current++
+		}
+	}
+	return current, nil
+}
+
+func (s *unitScaler) decide(a *app.App, as *app.AutoScale, current uint) (*UnitScaleDecision, error) {
+	value, err := metricValue(a, as, current)
+	if err != nil {
+		return nil, err
+	}
+	decision := &UnitScaleDecision{
+		Process:      as.Process,
+		Metric:       as.Metric,
+		Target:       as.Target,
+		Value:        value,
+		CurrentUnits: current,
+		DesiredUnits: current,
+	}
+	ratio := value / as.Target
+	if math.Abs(ratio-1) > unitScaleTolerance {
+		decision.DesiredUnits = uint(math.Ceil(float64(current) * ratio))
+		decision.Reason = "metric value out of target"
+	}
+	if decision.DesiredUnits < as.MinUnits {
+		decision.DesiredUnits = as.MinUnits
+		decision.Reason = "units below minimum"
+	}
+	if decision.DesiredUnits > as.MaxUnits {
+		decision.DesiredUnits = as.MaxUnits
+		if current > as.MaxUnits {
+			decision.Reason = "units above maximum"
+		}
+	}
+	return decision, nil
+}
+
+// metricValue returns the value of the autoscale metric, averaged by unit.
+func metricValue(a *app.App, as *app.AutoScale, current uint) (float64, error) {
+	if as.Metric == app.AutoScaleMetricRequests {
+		rps, err := a.RequestsPerSecond()
+		if err != nil {
+			return 0, err
+		}
+		return rps / float64(current), nil
+	}
+	metrics, err := a.UnitsMetrics(as.Process)
+	if err != nil {
+		return 0, err
+	}
+	if len(metrics) == 0 {
+		return 0, errors.New("no unit metrics available")
+	}
+	var total float64
+	for _, m := range metrics {
+		if as.Metric == app.AutoScaleMetricMemory {
+			total += float64(m.Memory) / (1024 * 1024)
+		} else {
+			total += m.CPU
+		}
+	}
+	return total / float64(len(metrics)), nil
+}
+
+func (s *unitScaler) apply(a *app.App, as *app.AutoScale, decision *UnitScaleDecision) (err error) {
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		InternalKind: UnitEventKind,
+		CustomData:   decision,
+		Allowed: event.Allowed(permission.PermAppReadEvents, append(permission.Contexts(permTypes.CtxTeam, a.Teams),
+			permission.Context(permTypes.CtxApp, a.Name),
+			permission.Context(permTypes.CtxPool, a.Pool),
+		)...),
+	})
+	if err != nil {
+		if _, ok := err.(event.ErrEventLocked); ok {
+			log.Debugf("[unit autoscale] skipping process %q of app %q, app locked", as.Process, as.App)
+			return nil
+		}
+		return err
+	}
+	var skipped bool
+	defer func() {
+		if skipped {
+			evt.Abort()
+			return
+		}
+		evt.DoneCustomData(err, decision)
+	}()
+	// The decision was made before locking the app events, the units may
+	// have been changed since then.
+	current, err := processUnits(a, as.Process)
+	if err != nil {
+		return err
+	}
+	if current != decision.CurrentUnits {
+		if current == 0 {
+			skipped = true
+			return nil
+		}
+		decision, err = s.decide(a, as, current)
+		if err != nil {
+			return err
+		}
+		if decision.DesiredUnits == decision.CurrentUnits {
+			skipped = true
+			return nil
+		}
+	}
+	// Only one tsuru API instance claims the scale, the others see the
+	// updated last scale time.
+	now := s.now().UTC()
+	claimed, err := app.ClaimAutoScale(a.Name, as.Process, as.LastScale, now)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		log.Debugf("[unit autoscale] skipping process %q of app %q, already scaled", as.Process, as.App)
+		skipped = true
+		return nil
+	}
+	evt.Logf("scaling process %q from %d to %d units, %s: %s is %.2f, target %.2f",
+		decision.Process, decision.CurrentUnits, decision.DesiredUnits, decision.Reason,
+		decision.Metric, decision.Value, decision.Target)
+	if decision.DesiredUnits > decision.CurrentUnits {
+		err = a.AddUnits(decision.DesiredUnits-decision.CurrentUnits, as.Process, evt)
+	} else {
+		err = a.RemoveUnits(decision.CurrentUnits-decision.DesiredUnits, as.Process, evt)
+	}
+	if err != nil {
+		// Failed scales don't start the cooldown.
+		if _, releaseErr := app.ClaimAutoScale(a.Name, as.Process, now, as.LastScale); releaseErr != nil {
+			log.Errorf("[unit autoscale] unable to restore last scale of process %q of app %q: %v", as.Process, as.App, releaseErr)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package autoscale
+
+import (
+	"time"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/provision"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) insertAutoScale(c *check.C, as app.AutoScale) {
+	err := s.conn.AppAutoScales().Insert(as)
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestUnitScalerRunOnceScalesUp(c *check.C) {
+	s.insertAutoScale(c, app.AutoScale{App: "myapp", Process: "web", MinUnits: 1, MaxUnits: 5, Metric: app.AutoScaleMetricCPU, Target: 50})
+	err := s.p.AddUnits(s.appInstance, 2, "web", nil)
+	c.Assert(err, check.IsNil)
+	err = s.p.SetUnitsMetrics(s.appInstance, "web", []provision.UnitMetrics{{ID: "u1", CPU: 100}, {ID: "u2", CPU: 100}})
+	c.Assert(err, check.IsNil)
+	scaler := newUnitScaler()
+	now := time.Date(2018, 5, 10, 12, 0, 0, 0, time.UTC)
+	scaler.now = func() time.Time { return now }
+	err = scaler.runOnce()
+	c.Assert(err, check.IsNil)
+	units, err := s.p.Units(s.appInstance)
+	c.Assert(err, check.IsNil)
+	c.Assert(units, check.HasLen, 4)
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeApp, Value: "myapp"},
+		Kind:   UnitEventKind,
+		StartCustomData: map[string]interface{}{
+			"process":      "web",
+			"metric":       "cpu",
+			"currentunits": 2,
+			"desiredunits": 4,
+		},
+	}, eventtest.HasEvent)
+	var dbAs app.AutoScale
+	err = s.conn.AppAutoScales().Find(nil).One(&dbAs)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbAs.LastScale.Equal(now), check.Equals, true)
+}
+
+func (s *S) TestUnitScalerRunOnceScalesDown(c *check.C) {
+	s.insertAutoScale(c, app.AutoScale{App: "myapp", Process: "web", MinUnits: 2, MaxUnits: 5, Metric: app.AutoScaleMetricMemory, Target: 100})
+	err := s.p.AddUnits(s.appInstance, 4, "web", nil)
+	c.Assert(err, check.IsNil)
+	err = s.p.SetUnitsMetrics(s.appInstance, "web", []provision.UnitMetrics{{ID: "u1", Memory: 10 * 1024 * 1024}})
+	c.Assert(err, check.IsNil)
+	err = newUnitScaler().runOnce()
+	c.Assert(err, check.IsNil)
+	units, err := s.p.Units(s.appInstance)
+	c.Assert(err, check.IsNil)
+	c.Assert(units, check.HasLen, 2)
+}
+
+func (s *S) TestUnitScalerRunOnceInCooldown(c *check.C) {
+	now := time.Date(2018, 5, 10, 12, 0, 0, 0, time.UTC)
+	s.insertAutoScale(c, app.AutoScale{
+		App:             "myapp",
+		Process:         "web",
+		MinUnits:        1,
+		MaxUnits:        5,
+		Metric:          app.AutoScaleMetricCPU,
+		Target:          50,
+		ScaleUpCooldown: 60,
+		LastScale:       now.Add(-30 * time.Second),
+	})
+	err := s.p.AddUnits(s.appInstance, 2, "web", nil)
+	c.Assert(err, check.IsNil)
+	err = s.p.SetUnitsMetrics(s.appInstance, "web", []provision.UnitMetrics{{ID: "u1", CPU: 100}})
+	c.Assert(err, check.IsNil)
+	scaler := newUnitScaler()
+	scaler.now = func() time.Time { return now }
+	err = scaler.runOnce()
+	c.Assert(err, check.IsNil)
+	units, err := s.p.Units(s.appInstance)
+	c.Assert(err, check.IsNil)
+	c.Assert(units, check.HasLen, 2)
+	now = now.Add(time.Minute)
+	err = scaler.runOnce()
+	c.Assert(err, check.IsNil)
+	units, err = s.p.Units(s.appInstance)
+	c.Assert(err, check.IsNil)
+	c.Assert(units, check.HasLen, 4)
+}
+
+func (s *S) TestUnitScalerScaleAlreadyScaledByOtherInstance(c *check.C) {
+	s.insertAutoScale(c, app.AutoScale{App: "myapp", Process: "web", MinUnits: 1, MaxUnits: 5, Metric: app.AutoScaleMetricCPU, Target: 50})
+	err := s.p.AddUnits(s.appInstance, 2, "web", nil)
+	c.Assert(err, check.IsNil)
+	err = s.p.SetUnitsMetrics(s.appInstance, "web", []provision.UnitMetrics{{ID: "u1", CPU: 100}})
+	c.Assert(err, check.IsNil)
+	autoScales, err := app.ListAutoScales()
+	c.Assert(err, check.IsNil)
+	c.Assert(autoScales, check.HasLen, 1)
+	now := time.Date(2018, 5, 10, 12, 0, 0, 0, time.UTC)
+	claimed, err := app.ClaimAutoScale("myapp", "web", autoScales[0].LastScale, now)
+	c.Assert(err, check.IsNil)
+	c.Assert(claimed, check.Equals, true)
+	scaler := newUnitScaler()
+	scaler.now = func() time.Time { return now }
+	err = scaler.scale(&autoScales[0])
+	c.Assert(err, check.IsNil)
+	units, err := s.p.Units(s.appInstance)
+	c.Assert(err, check.IsNil)
+	c.Assert(units, check.HasLen, 2)
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeApp, Value: "myapp"},
+		Kind:   UnitEventKind,
+	}, check.Not(eventtest.HasEvent))
+}
+
+func (s *S) TestUnitScalerApplyRecomputesChangedUnits(c *check.C) {
+	s.insertAutoScale(c, app.AutoScale{App: "myapp", Process: "web", MinUnits: 1, MaxUnits: 5, Metric: app.AutoScaleMetricCPU, Target: 50})
+	err := s.p.AddUnits(s.appInstance, 4, "web", nil)
+	c.Assert(err, check.IsNil)
+	err = s.p.SetUnitsMetrics(s.appInstance, "web", []provision.UnitMetrics{{ID: "u1", CPU: 100}})
+	c.Assert(err, check.IsNil)
+	autoScales, err := app.ListAutoScales()
+	c.Assert(err, check.IsNil)
+	a, err := app.GetByName("myapp")
+	c.Assert(err, check.IsNil)
+	scaler := newUnitScaler()
+	stale := &UnitScaleDecision{Process: "web", Metric: app.AutoScaleMetricCPU, Target: 50, Value: 100, CurrentUnits: 2, DesiredUnits: 4}
+	err = scaler.apply(a, &autoScales[0], stale)
+	c.Assert(err, check.IsNil)
+	units, err := s.p.Units(s.appInstance)
+	c.Assert(err, check.IsNil)
+	c.Assert(units, check.HasLen, 5)
+}
+
+func (s *S) TestUnitScalerRunOnceIgnoresStoppedProcess(c *check.C) {
+	s.insertAutoScale(c, app.AutoScale{App: "myapp", Process: "web", MinUnits: 1, MaxUnits: 5, Metric: app.AutoScaleMetricCPU, Target: 50})
+	err := s.p.SetUnitsMetrics(s.appInstance, "web", []provision.UnitMetrics{{ID: "u1", CPU: 100}})
+	c.Assert(err, check.IsNil)
+	err = newUnitScaler().runOnce()
+	c.Assert(err, check.IsNil)
+	units, err := s.p.Units(s.appInstance)
+	c.Assert(err, check.IsNil)
+	c.Assert(units, check.HasLen, 0)
+}
+
+func (s *S) TestUnitScalerDecide(c *check.C) {
+	a, err := app.GetByName("myapp")
+	c.Assert(err, check.IsNil)
+	scaler := newUnitScaler()
+	tests := []struct {
+		cpu      float64
+		current  uint
+		expected uint
+		reason   string
+	}{
+		{cpu: 50, current: 2, expected: 2},
+		{cpu: 54, current: 2, expected: 2},
+		{cpu: 100, current: 2, expected: 4, reason: "metric value out of target"},
+		{cpu: 500, current: 2, expected: 5, reason: "metric value out of target"},
+		{cpu: 10, current: 4, expected: 1, reason: "metric value out of target"},
+		{cpu: 50, current: 7, expected: 5, reason: "units above maximum"},
+	}
+	as := &app.AutoScale{App: "myapp", Process: "web", MinUnits: 1, MaxUnits: 5, Metric: app.AutoScaleMetricCPU, Target: 50}
+	for _, tt := range tests {
+		err = s.p.SetUnitsMetrics(s.appInstance, "web", []provision.UnitMetrics{{ID: "u1", CPU: tt.cpu}})
+		c.Assert(err, check.IsNil)
+		decision, err := scaler.decide(a, as, tt.current)
+		c.Assert(err, check.IsNil)
+		c.Check(decision.DesiredUnits, check.Equals, tt.expected, check.Commentf("%#v", tt))
+		c.Check(decision.Reason, check.Equals, tt.reason, check.Commentf("%#v", tt))
+	}
+}
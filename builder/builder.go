@@ -7,9 +7,12 @@ package builder
 import (
 	"archive/tar"
 	"bytes"
+	"crypto/sha256"
+	"fmt"
 	"io"
 
 	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app/image"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/provision"
@@ -23,12 +26,19 @@ type BuildOpts struct {
 	Rebuild             bool
 	Redeploy            bool
 	IsTsuruBuilderImage bool
+	Promote             bool
 	ArchiveURL          string
 	ArchiveFile         io.Reader
 	ArchiveTarFile      io.ReadCloser
 	ArchiveSize         int64
 	ImageID             string
 	Tag                 string
+	// PlatformImage is set by builders building apps from an image other
+	// than the image of the app platform.
+	PlatformImage string
+	// ArchiveDigest is set by builders downloading the archive from
+	// ArchiveURL.
+	ArchiveDigest string
 }
 
 // Builder is the basic interface of this package.
@@ -38,6 +48,10 @@ type Builder interface {
 
 var builders = make(map[string]Builder)
 
+// PlatformArgBuilder is the platform arg used to choose the builder managing
+// a platform.
+const PlatformArgBuilder = "builder"
+
 // PlatformBuilder is a builder where administrators can manage
 // platforms (automatically adding, removing and updating platforms).
 type PlatformBuilder interface {
@@ -46,6 +60,30 @@ type PlatformBuilder interface {
 	PlatformRemove(name string) error
 }
 
+// PlatformOwnerBuilder is a platform builder that only manages the platforms
+// explicitly added to it, through the "builder" platform arg, and that must
+// build the apps using them, regardless of the provisioner of the app, as
+// long as the provisioner is supported by the builder.
+type PlatformOwnerBuilder interface {
+	PlatformBuilder
+	OwnsPlatform(name string) (bool, error)
+	SupportsProvisioner(p provision.Provisioner) bool
+}
+
+// ProvenanceBuilder is a builder able to describe the images it builds,
+// generating their software bill of materials and resolving the digest of the
+// images they're built from.
+type ProvenanceBuilder interface {
+	ImageSBOM(p provision.BuilderDeploy, app provision.App, imageName string) (*image.SBOM, error)
+	ImageDigest(p provision.BuilderDeploy, app provision.App, imageName string) (string, error)
+}
+
+// BuildCacheBuilder is a builder able to persist a directory between the
+// builds of an app, restoring it in the next build.
+type BuildCacheBuilder interface {
+	SupportsBuildCache() bool
+}
+
 // Register registers a new builder in the Builder registry.
 func Register(name string, builder Builder) {
 	builders[name] = builder
@@ -64,6 +102,72 @@ func GetForProvisioner(p provision.Provisioner) (Builder, error) {
 	return builder, err
 }
 
+// GetForApp gets the builder required by the app: the builder owning its
+// platform, if there is one, or the builder required by the provisioner.
+func GetForApp(app provision.App, p provision.Provisioner) (Builder, error) {
+	ownerBuilder, err := platformOwner(app.GetPlatform())
+	if err != nil {
+		return nil, err
+	}
+	if ownerBuilder != nil {
+		return ownerBuilder.(Builder), nil
+	}
+	return GetForProvisioner(p)
+}
+
+// ValidatePlatform returns a validation error if the builder owning the
+// platform doesn't support the provisioner.
+func ValidatePlatform(platform string, p provision.Provisioner) error {
+	ownerBuilder, err := platformOwner(platform)
+	if err != nil {
+		return err
+	}
+	if ownerBuilder != nil && !ownerBuilder.SupportsProvisioner(p) {
+		return &tsuruErrors.ValidationError{
+			Message: fmt.Sprintf("platform %q is not supported by the provisioner %q of the app pool", platform, p.GetName()),
+		}
+	}
+	return nil
+}
+
+// platformOwner returns the builder owning the platform, or nil if no builder
+// owns it.
+func platformOwner(platform string) (PlatformOwnerBuilder, error) {
+	if platform == "" {
+		return nil, nil
+	}
+	for _, b := range builders {
+		ownerBuilder, ok := b.(PlatformOwnerBuilder)
+		if !ok {
+			continue
+		}
+		owns, err := ownerBuilder.OwnsPlatform(platform)
+		if err != nil {
+			return nil, err
+		}
+		if owns {
+			return ownerBuilder, nil
+		}
+	}
+	return nil, nil
+}
+
+// ArchiveDigest returns the digest of the app source archive recorded in the
+// provenance of the images built from it.
+func ArchiveDigest(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+// NameOf returns the name the builder was registered with.
+func NameOf(b Builder) string {
+	for name, registered := range builders {
+		if registered == b {
+			return name
+		}
+	}
+	return ""
+}
+
 // get gets the named builder from the registry.
 func get(name string) (Builder, error) {
 	b, ok := builders[name]
@@ -82,20 +186,58 @@ func Registry() ([]Builder, error) {
 	return registry, nil
 }
 
+// platformBuilders returns the builders able to manage a platform: the one
+// named in its "builder" arg or owning it, if any, or every platform builder
+// not restricted to its own platforms.
+func platformBuilders(name string, args map[string]string) ([]PlatformBuilder, error) {
+	if builderName := args[PlatformArgBuilder]; builderName != "" {
+		b, err := get(builderName)
+		if err != nil {
+			return nil, err
+		}
+		platformBuilder, ok := b.(PlatformBuilder)
+		if !ok {
+			return nil, errors.Errorf("builder %q is not able to manage platforms", builderName)
+		}
+		return []PlatformBuilder{platformBuilder}, nil
+	}
+	var result []PlatformBuilder
+	for _, b := range builders {
+		platformBuilder, ok := b.(PlatformBuilder)
+		if !ok {
+			continue
+		}
+		ownerBuilder, ok := b.(PlatformOwnerBuilder)
+		if !ok {
+			result = append(result, platformBuilder)
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		owns, err := ownerBuilder.OwnsPlatform(name)
+		if err != nil {
+			return nil, err
+		}
+		if owns {
+			return []PlatformBuilder{ownerBuilder}, nil
+		}
+	}
+	return result, nil
+}
+
 func PlatformAdd(opts appTypes.PlatformOptions) error {
-	builders, err := Registry()
+	builders, err := platformBuilders("", opts.Args)
 	if err != nil {
 		return err
 	}
 	multiErr := tsuruErrors.NewMultiError()
-	for _, b := range builders {
-		if platformBuilder, ok := b.(PlatformBuilder); ok {
-			err = platformBuilder.PlatformAdd(opts)
-			if err == nil {
-				return nil
-			}
-			multiErr.Add(err)
+	for _, platformBuilder := range builders {
+		err = platformBuilder.PlatformAdd(opts)
+		if err == nil {
+			return nil
 		}
+		multiErr.Add(err)
 	}
 	if multiErr.Len() > 0 {
 		return multiErr
@@ -104,19 +246,17 @@ func PlatformAdd(opts appTypes.PlatformOptions) error {
 }
 
 func PlatformUpdate(opts appTypes.PlatformOptions) error {
-	builders, err := Registry()
+	builders, err := platformBuilders(opts.Name, nil)
 	if err != nil {
 		return err
 	}
 	multiErr := tsuruErrors.NewMultiError()
-	for _, b := range builders {
-		if platformBuilder, ok := b.(PlatformBuilder); ok {
-			err = platformBuilder.PlatformUpdate(opts)
-			if err == nil {
-				return nil
-			}
-			multiErr.Add(err)
+	for _, platformBuilder := range builders {
+		err = platformBuilder.PlatformUpdate(opts)
+		if err == nil {
+			return nil
 		}
+		multiErr.Add(err)
 	}
 	if multiErr.Len() > 0 {
 		return multiErr
@@ -125,19 +265,17 @@ func PlatformUpdate(opts appTypes.PlatformOptions) error {
 }
 
 func PlatformRemove(name string) error {
-	builders, err := Registry()
+	builders, err := platformBuilders(name, nil)
 	if err != nil {
 		return err
 	}
 	multiErr := tsuruErrors.NewMultiError()
-	for _, b := range builders {
-		if platformBuilder, ok := b.(PlatformBuilder); ok {
-			err = platformBuilder.PlatformRemove(name)
-			if err == nil {
-				return nil
-			}
-			multiErr.Add(err)
+	for _, platformBuilder := range builders {
+		err = platformBuilder.PlatformRemove(name)
+		if err == nil {
+			return nil
 		}
+		multiErr.Add(err)
 	}
 	if multiErr.Len() > 0 {
 		return multiErr
@@ -8,6 +8,9 @@ import (
 	"errors"
 	"testing"
 
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/provisiontest"
 	appTypes "github.com/tsuru/tsuru/types/app"
 	check "gopkg.in/check.v1"
 )
@@ -148,3 +151,137 @@ func (s S) TestPlatformRemoveNoBuilder(c *check.C) {
 	err := PlatformRemove("platform-name")
 	c.Assert(err, check.ErrorMatches, "No builder available")
 }
+
+func (s S) TestPlatformAddWithBuilderArg(c *check.C) {
+	b1 := MockBuilder{
+		OnPlatformAdd: callPlatformWithError,
+	}
+	var added bool
+	b2 := MockPlatformOwnerBuilder{
+		MockBuilder: MockBuilder{
+			OnPlatformAdd: func(appTypes.PlatformOptions) error {
+				added = true
+				return nil
+			},
+		},
+	}
+	Register("builder1", &b1)
+	Register("builder2", &b2)
+	err := PlatformAdd(appTypes.PlatformOptions{Args: map[string]string{"builder": "builder2"}})
+	c.Assert(err, check.IsNil)
+	c.Assert(added, check.Equals, true)
+}
+
+func (s S) TestPlatformAddWithUnknownBuilderArg(c *check.C) {
+	Register("builder1", &MockBuilder{})
+	err := PlatformAdd(appTypes.PlatformOptions{Args: map[string]string{"builder": "other"}})
+	c.Assert(err, check.ErrorMatches, `unknown builder: "other"`)
+}
+
+func (s S) TestPlatformAddIgnoresPlatformOwnerBuilders(c *check.C) {
+	b1 := MockBuilder{
+		OnPlatformAdd: callPlatformWithError,
+	}
+	b2 := MockPlatformOwnerBuilder{}
+	Register("builder1", &b1)
+	Register("builder2", &b2)
+	err := PlatformAdd(appTypes.PlatformOptions{})
+	c.Assert(err, check.ErrorMatches, "(?s).*something is wrong.*")
+}
+
+func (s S) TestPlatformUpdateOwnedPlatform(c *check.C) {
+	b1 := MockBuilder{
+		OnPlatformUpdate: callPlatformWithError,
+	}
+	var updated bool
+	b2 := MockPlatformOwnerBuilder{
+		MockBuilder: MockBuilder{
+			OnPlatformUpdate: func(appTypes.PlatformOptions) error {
+				updated = true
+				return nil
+			},
+		},
+		OnOwnsPlatform: func(name string) (bool, error) {
+			return name == "myplatform", nil
+		},
+	}
+	Register("builder1", &b1)
+	Register("builder2", &b2)
+	err := PlatformUpdate(appTypes.PlatformOptions{Name: "myplatform"})
+	c.Assert(err, check.IsNil)
+	c.Assert(updated, check.Equals, true)
+}
+
+func (s S) TestPlatformRemoveOwnedPlatform(c *check.C) {
+	var removed []string
+	b1 := MockBuilder{
+		OnPlatformRemove: func(name string) error {
+			removed = append(removed, "builder1")
+			return nil
+		},
+	}
+	b2 := MockPlatformOwnerBuilder{
+		MockBuilder: MockBuilder{
+			OnPlatformRemove: func(name string) error {
+				removed = append(removed, "builder2")
+				return nil
+			},
+		},
+		OnOwnsPlatform: func(name string) (bool, error) {
+			return name == "myplatform", nil
+		},
+	}
+	Register("builder1", &b1)
+	Register("builder2", &b2)
+	err := PlatformRemove("myplatform")
+	c.Assert(err, check.IsNil)
+	c.Assert(removed, check.DeepEquals, []string{"builder2"})
+	removed = nil
+	err = PlatformRemove("otherplatform")
+	c.Assert(err, check.IsNil)
+	c.Assert(removed, check.DeepEquals, []string{"builder1"})
+}
+
+func (s S) TestGetForApp(c *check.C) {
+	b1 := MockBuilder{}
+	b2 := MockPlatformOwnerBuilder{
+		OnOwnsPlatform: func(name string) (bool, error) {
+			return name == "myplatform", nil
+		},
+	}
+	Register("fake", &b1)
+	Register("owner", &b2)
+	p := provisiontest.ProvisionerInstance
+	got, err := GetForApp(provisiontest.NewFakeApp("myapp", "myplatform", 1), p)
+	c.Assert(err, check.IsNil)
+	c.Assert(got, check.Equals, &b2)
+	got, err = GetForApp(provisiontest.NewFakeApp("myapp", "python", 1), p)
+	c.Assert(err, check.IsNil)
+	c.Assert(got, check.Equals, &b1)
+}
+
+func (s S) TestValidatePlatform(c *check.C) {
+	b1 := MockBuilder{}
+	b2 := MockPlatformOwnerBuilder{
+		OnOwnsPlatform: func(name string) (bool, error) {
+			return name == "myplatform", nil
+		},
+		OnSupportsProvisioner: func(p provision.Provisioner) bool {
+			return p.GetName() == "supported"
+		},
+	}
+	Register("fake", &b1)
+	Register("owner", &b2)
+	p := provisiontest.ProvisionerInstance
+	err := ValidatePlatform("myplatform", p)
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+	c.Assert(err, check.ErrorMatches, `platform "myplatform" is not supported by the provisioner "fake" of the app pool`)
+	err = ValidatePlatform("python", p)
+	c.Assert(err, check.IsNil)
+	err = ValidatePlatform("", p)
+	c.Assert(err, check.IsNil)
+}
+
+func (s S) TestArchiveDigest(c *check.C) {
+	c.Assert(ArchiveDigest([]byte("my archive data")), check.Equals, "sha256:660332fc752d5799a56a2afd4668a17a5030e3699fe84bd50ee51463e96dc1f3")
+}
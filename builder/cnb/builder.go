@@ -0,0 +1,375 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cnb implements a builder using Cloud Native Buildpacks to build
+// the source code of apps, running the lifecycle of the builder image
+// configured in the app platform.
+package cnb
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/builder"
+	"github.com/tsuru/tsuru/event"
+	tsuruIo "github.com/tsuru/tsuru/io"
+	"github.com/tsuru/tsuru/net"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/dockercommon"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	lifecyclePath    = "/cnb/lifecycle"
+	workspacePath    = "/workspace"
+	layersPath       = "/layers"
+	archiveDirPath   = "/tmp"
+	archiveName      = "archive.tar.gz"
+	cacheTag         = "cnb-cache"
+	buildMetadataKey = "io.buildpacks.build.metadata"
+)
+
+var (
+	_ builder.Builder              = &cnbBuilder{}
+	_ builder.PlatformOwnerBuilder = &cnbBuilder{}
+	_ builder.ProvenanceBuilder    = &cnbBuilder{}
+)
+
+type cnbBuilder struct{}
+
+func init() {
+	builder.Register("cnb", &cnbBuilder{})
+}
+
+func (b *cnbBuilder) Build(prov provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+	if opts.ImageID != "" || opts.BuildFromFile {
+		return buildWithProvisionerBuilder(prov, app, evt, opts)
+	}
+	if opts.Rebuild {
+		return "", errors.New("rebuild is not supported by the cnb builder")
+	}
+	archive, err := readArchive(opts)
+	if err != nil {
+		return "", err
+	}
+	if opts.ArchiveFile == nil {
+		opts.ArchiveDigest = builder.ArchiveDigest(archive)
+	}
+	builderImage, err := builderImageForPlatform(app.GetPlatform())
+	if err != nil {
+		return "", err
+	}
+	opts.PlatformImage = builderImage
+	client, err := builderClient(prov, app)
+	if err != nil {
+		return "", err
+	}
+	buildingImage, err := image.AppNewBuilderImageName(app.GetName(), app.GetTeamOwner(), opts.Tag)
+	if err != nil {
+		return "", err
+	}
+	err = runLifecycle(client, builderImage, buildingImage, archive, evt)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(evt, "---- Inspecting image %q ----\n", buildingImage)
+	cont, _, err := client.PullAndCreateContainer(docker.CreateContainerOptions{
+		Config: &docker.Config{Image: buildingImage},
+	}, evt)
+	if err != nil {
+		return "", err
+	}
+	client.RemoveContainer(docker.RemoveContainerOptions{ID: cont.ID, Force: true})
+	imageInspect, err := client.InspectImage(buildingImage)
+	if err != nil {
+		return "", err
+	}
+	processes, err := processesFromImage(imageInspect)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range processes {
+		fmt.Fprintf(evt, "  ---> Process %q found with commands: %q\n", k, v)
+	}
+	tsuruYaml, err := tsuruYamlFromArchive(archive)
+	if err != nil {
+		return "", err
+	}
+	newImage, err := pushImageToRegistry(client, app, buildingImage, evt)
+	if err != nil {
+		return "", err
+	}
+	customData, err := tsuruYaml.ToCustomData()
+	if err != nil {
+		return "", err
+	}
+	imageData := image.ImageMetadata{
+		Name:       newImage,
+		Processes:  processes,
+		CustomData: customData,
+	}
+	err = imageData.Save()
+	if err != nil {
+		return "", err
+	}
+	return newImage, nil
+}
+
+func (b *cnbBuilder) ImageSBOM(prov provision.BuilderDeploy, app provision.App, imageName string) (*image.SBOM, error) {
+	client, err := builderClient(prov, app)
+	if err != nil {
+		return nil, err
+	}
+	return dockercommon.ImageSBOM(client, imageName)
+}
+
+func (b *cnbBuilder) ImageDigest(prov provision.BuilderDeploy, app provision.App, imageName string) (string, error) {
+	client, err := builderClient(prov, app)
+	if err != nil {
+		return "", err
+	}
+	return dockercommon.ImageDigest(client, imageName)
+}
+
+// SupportsProvisioner returns whether the lifecycle can run in containers
+// created by the provisioner, apps using cnb platforms are not allowed in
+// pools of other provisioners.
+func (b *cnbBuilder) SupportsProvisioner(p provision.Provisioner) bool {
+	_, ok := p.(provision.BuilderDeployDockerClient)
+	return ok
+}
+
+func builderClient(prov provision.BuilderDeploy, app provision.App) (provision.BuilderDockerClient, error) {
+	p, ok := prov.(provision.BuilderDeployDockerClient)
+	if !ok {
+		return nil, errors.New("provisioner not supported: doesn't implement docker builder")
+	}
+	return p.GetClient(app)
+}
+
+// buildWithProvisionerBuilder handles the deploys not building the app source,
+// like image deploys, using the builder required by the provisioner.
+func buildWithProvisionerBuilder(prov provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+	p, ok := prov.(provision.Provisioner)
+	if !ok {
+		return "", errors.New("provisioner not supported")
+	}
+	b, err := builder.GetForProvisioner(p)
+	if err != nil {
+		return "", err
+	}
+	return b.Build(prov, app, evt, opts)
+}
+
+func readArchive(opts *builder.BuildOpts) ([]byte, error) {
+	var archive io.Reader
+	if opts.ArchiveFile != nil {
+		archive = opts.ArchiveFile
+	} else if opts.ArchiveURL != "" {
+		resp, err := net.Dial15Full300Client.Get(opts.ArchiveURL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		archive = resp.Body
+	} else {
+		return nil, errors.New("no valid files found")
+	}
+	data, err := ioutil.ReadAll(archive)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("archive file is empty")
+	}
+	return data, nil
+}
+
+// runLifecycle runs the detect, build and export phases of the lifecycle in
+// the builder image, exporting the app image to buildingImage. Layers are
+// cached per app, in an image next to the one being built.
+func runLifecycle(client provision.BuilderDockerClient, builderImage, buildingImage string, archive []byte, evt *event.Event) error {
+	repo, _ := image.SplitImageName(buildingImage)
+	cacheImage := fmt.Sprintf("%s:%s", repo, cacheTag)
+	fmt.Fprintf(evt, "---- Building image %q with builder %q ----\n", buildingImage, builderImage)
+	createOpts := docker.CreateContainerOptions{
+		Config: &docker.Config{
+			AttachStdout: true,
+			AttachStderr: true,
+			Image:        builderImage,
+			Entrypoint:   []string{"/bin/sh", "-c"},
+			Cmd:          []string{lifecycleCmd(path.Join(archiveDirPath, archiveName), buildingImage, cacheImage)},
+			Env:          lifecycleEnvs(),
+		},
+	}
+	cont, _, err := client.PullAndCreateContainer(createOpts, evt)
+	if err != nil {
+		return err
+	}
+	defer client.RemoveContainer(docker.RemoveContainerOptions{ID: cont.ID, Force: true})
+	uploadOpts := docker.UploadToContainerOptions{
+		InputStream: dockercommon.AddDeployTarFile(bytes.NewReader(archive), int64(len(archive)), archiveName),
+		Path:        archiveDirPath,
+	}
+	err = client.UploadToContainer(cont.ID, uploadOpts)
+	if err != nil {
+		return err
+	}
+	attachOpts := docker.AttachToContainerOptions{
+		Container:    cont.ID,
+		OutputStream: evt,
+		ErrorStream:  evt,
+		Stream:       true,
+		Stdout:       true,
+		Stderr:       true,
+		Success:      make(chan struct{}),
+	}
+	waiter, err := client.AttachToContainerNonBlocking(attachOpts)
+	if err != nil {
+		return err
+	}
+	<-attachOpts.Success
+	close(attachOpts.Success)
+	err = client.StartContainer(cont.ID, nil)
+	if err != nil {
+		return err
+	}
+	waiter.Wait()
+	status, err := client.WaitContainer(cont.ID)
+	if err != nil {
+		return err
+	}
+	if status != 0 {
+		return errors.Errorf("cnb lifecycle exited with status %d", status)
+	}
+	return nil
+}
+
+func lifecycleCmd(archivePath, buildingImage, cacheImage string) string {
+	phases := []string{
+		fmt.Sprintf("mkdir -p %[1]s && tar -xzf %[2]s -C %[1]s", workspacePath, archivePath),
+		fmt.Sprintf("%s/detector -app %s -layers %s", lifecyclePath, workspacePath, layersPath),
+		fmt.Sprintf("%s/analyzer -layers %s -cache-image %s %s", lifecyclePath, layersPath, cacheImage, buildingImage),
+		fmt.Sprintf("%s/restorer -layers %s -cache-image %s", lifecyclePath, layersPath, cacheImage),
+		fmt.Sprintf("%s/builder -app %s -layers %s", lifecyclePath, workspacePath, layersPath),
+		fmt.Sprintf("%s/exporter -app %s -layers %s -cache-image %s %s", lifecyclePath, workspacePath, layersPath, cacheImage, buildingImage),
+	}
+	return strings.Join(phases, " && ")
+}
+
+// lifecycleEnvs returns the environment variables giving the lifecycle access
+// to the tsuru registry.
+func lifecycleEnvs() []string {
+	registry, _ := config.GetString("docker:registry")
+	username, _ := config.GetString("docker:registry-auth:username")
+	password, _ := config.GetString("docker:registry-auth:password")
+	if registry == "" || (username == "" && password == "") {
+		return nil
+	}
+	auth, _ := json.Marshal(map[string]string{
+		registry: "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)),
+	})
+	return []string{"CNB_REGISTRY_AUTH=" + string(auth)}
+}
+
+// processesFromImage maps the process types in the build metadata of an image
+// exported by the lifecycle to commands running them through its launcher.
+func processesFromImage(img *docker.Image) (map[string][]string, error) {
+	var metadata struct {
+		Processes []struct {
+			Type string `json:"type"`
+		} `json:"processes"`
+	}
+	if img.Config != nil && img.Config.Labels[buildMetadataKey] != "" {
+		err := json.Unmarshal([]byte(img.Config.Labels[buildMetadataKey]), &metadata)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse the %q label", buildMetadataKey)
+		}
+	}
+	if len(metadata.Processes) == 0 {
+		return nil, errors.New("no process types found in the build metadata of the image")
+	}
+	processes := make(map[string][]string, len(metadata.Processes))
+	for _, p := range metadata.Processes {
+		processes[p.Type] = []string{path.Join(lifecyclePath, "launcher"), p.Type}
+	}
+	return processes, nil
+}
+
+// tsuruYamlFromArchive reads the tsuru.yaml in the root of the app source.
+func tsuruYamlFromArchive(archive []byte) (*provision.TsuruYamlData, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the app source archive")
+	}
+	names := []string{"tsuru.yml", "tsuru.yaml", "app.yml", "app.yaml"}
+	files := make(map[string][]byte)
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read the app source archive")
+		}
+		name := path.Clean(header.Name)
+		for _, n := range names {
+			if name == n {
+				files[name], err = ioutil.ReadAll(tarReader)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	var tsuruYamlData provision.TsuruYamlData
+	for _, n := range names {
+		if data, ok := files[n]; ok {
+			err = yaml.Unmarshal(data, &tsuruYamlData)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return &tsuruYamlData, nil
+}
+
+func pushImageToRegistry(client provision.BuilderDockerClient, app provision.App, imageID string, evt *event.Event) (string, error) {
+	newImage, err := image.AppNewImageName(app.GetName())
+	if err != nil {
+		return "", err
+	}
+	repo, tag := image.SplitImageName(newImage)
+	err = client.TagImage(imageID, docker.TagImageOptions{Repo: repo, Tag: tag, Force: true})
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(evt, "---- Pushing image %q to tsuru ----\n", newImage)
+	pushOpts := docker.PushImageOptions{
+		Name:              repo,
+		Tag:               tag,
+		OutputStream:      &tsuruIo.DockerErrorCheckWriter{W: evt},
+		InactivityTimeout: net.StreamInactivityTimeout,
+		RawJSONStream:     true,
+	}
+	err = client.PushImage(pushOpts, dockercommon.RegistryAuthConfig(newImage))
+	if err != nil {
+		return "", err
+	}
+	return newImage, nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cnb
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/builder"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	check "gopkg.in/check.v1"
+)
+
+func sourceArchive(c *check.C, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))})
+		c.Assert(err, check.IsNil)
+		_, err = tarWriter.Write([]byte(content))
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(tarWriter.Close(), check.IsNil)
+	c.Assert(gzipWriter.Close(), check.IsNil)
+	return buf.Bytes()
+}
+
+func (s *S) TestBuild(c *check.C) {
+	err := s.provisioner.AddNode(provision.AddNodeOptions{Address: s.server.URL()})
+	c.Assert(err, check.IsNil)
+	err = s.b.PlatformAdd(appTypes.PlatformOptions{Name: "python", Data: []byte("FROM cnbs/sample-builder:bionic")})
+	c.Assert(err, check.IsNil)
+	a := &app.App{Name: "myapp", Platform: "python", TeamOwner: s.team.Name}
+	err = app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	u, _ := url.Parse(s.server.URL())
+	config.Set("docker:registry", u.Host)
+	defer config.Unset("docker:registry")
+	buildingImage := u.Host + "/" + s.team.Name + "/app-myapp:v1-builder"
+	var createdCmd []string
+	s.server.CustomHandler("/containers/create", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var contConfig docker.Config
+		json.Unmarshal(body, &contConfig)
+		if contConfig.Image == "cnbs/sample-builder:bionic" {
+			createdCmd = contConfig.Cmd
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		s.server.DefaultHandler().ServeHTTP(w, r)
+	}))
+	s.server.CustomHandler("/containers/.*/attach", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	s.server.CustomHandler("/containers/.*/wait", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]int{"StatusCode": 0})
+	}))
+	s.server.CustomHandler(fmt.Sprintf("/images/%s/json", buildingImage), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := docker.Image{
+			Config: &docker.Config{
+				Labels: map[string]string{
+					"io.buildpacks.build.metadata": `{"processes": [{"type": "web", "command": "python app.py"}, {"type": "worker", "command": "python worker.py"}]}`,
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	evt, err := event.New(&event.Opts{
+		Target:  event.Target{Type: event.TargetTypeApp, Value: a.GetName()},
+		Kind:    permission.PermAppDeploy,
+		Owner:   s.token,
+		Allowed: event.Allowed(permission.PermAppDeploy),
+	})
+	c.Assert(err, check.IsNil)
+	archive := sourceArchive(c, map[string]string{
+		"app.py":     "print('hello')",
+		"tsuru.yaml": "healthcheck:\n  path: /health\n",
+	})
+	bopts := builder.BuildOpts{
+		ArchiveFile: bytes.NewReader(archive),
+		ArchiveSize: int64(len(archive)),
+	}
+	imgID, err := s.b.Build(s.provisioner, a, evt, &bopts)
+	c.Assert(err, check.IsNil)
+	c.Assert(imgID, check.Equals, u.Host+"/tsuru/app-myapp:v1")
+	c.Assert(createdCmd, check.HasLen, 1)
+	c.Assert(createdCmd[0], check.Matches, `.*/cnb/lifecycle/detector .*/cnb/lifecycle/builder .*/cnb/lifecycle/exporter .*-cache-image `+u.Host+"/"+s.team.Name+`/app-myapp:cnb-cache .*`)
+	imd, err := image.GetImageMetaData(imgID)
+	c.Assert(err, check.IsNil)
+	c.Assert(imd.Processes, check.DeepEquals, map[string][]string{
+		"web":    {"/cnb/lifecycle/launcher", "web"},
+		"worker": {"/cnb/lifecycle/launcher", "worker"},
+	})
+	yamlData, err := image.GetImageTsuruYamlData(imgID)
+	c.Assert(err, check.IsNil)
+	c.Assert(yamlData.Healthcheck.Path, check.Equals, "/health")
+}
+
+func (s *S) TestBuildPlatformNotFound(c *check.C) {
+	err := s.provisioner.AddNode(provision.AddNodeOptions{Address: s.server.URL()})
+	c.Assert(err, check.IsNil)
+	a := &app.App{Name: "myapp", Platform: "python", TeamOwner: s.team.Name}
+	evt, err := event.New(&event.Opts{
+		Target:  event.Target{Type: event.TargetTypeApp, Value: a.GetName()},
+		Kind:    permission.PermAppDeploy,
+		Owner:   s.token,
+		Allowed: event.Allowed(permission.PermAppDeploy),
+	})
+	c.Assert(err, check.IsNil)
+	bopts := builder.BuildOpts{
+		ArchiveFile: strings.NewReader("my upload data"),
+	}
+	_, err = s.b.Build(s.provisioner, a, evt, &bopts)
+	c.Assert(err, check.ErrorMatches, `platform "python" not found in the cnb builder`)
+}
+
+func (s *S) TestBuildRebuildNotSupported(c *check.C) {
+	a := &app.App{Name: "myapp", Platform: "python", TeamOwner: s.team.Name}
+	_, err := s.b.Build(s.provisioner, a, nil, &builder.BuildOpts{Rebuild: true})
+	c.Assert(err, check.ErrorMatches, "rebuild is not supported by the cnb builder")
+}
+
+func (s *S) TestSupportsProvisioner(c *check.C) {
+	c.Assert(s.b.SupportsProvisioner(s.provisioner), check.Equals, true)
+	withoutDockerClient := struct{ provision.Provisioner }{s.provisioner}
+	c.Assert(s.b.SupportsProvisioner(withoutDockerClient), check.Equals, false)
+}
+
+func (s *S) TestProcessesFromImage(c *check.C) {
+	img := &docker.Image{Config: &docker.Config{Labels: map[string]string{
+		"io.buildpacks.build.metadata": `{"processes": [{"type": "web", "command": "bundle exec rails s"}]}`,
+	}}}
+	processes, err := processesFromImage(img)
+	c.Assert(err, check.IsNil)
+	c.Assert(processes, check.DeepEquals, map[string][]string{"web": {"/cnb/lifecycle/launcher", "web"}})
+	_, err = processesFromImage(&docker.Image{Config: &docker.Config{}})
+	c.Assert(err, check.ErrorMatches, "no process types found in the build metadata of the image")
+}
+
+func (s *S) TestTsuruYamlFromArchive(c *check.C) {
+	archive := sourceArchive(c, map[string]string{
+		"./app.yaml": "hooks:\n  restart:\n    before:\n      - echo before\n",
+		"Procfile":   "web: python app.py",
+	})
+	yamlData, err := tsuruYamlFromArchive(archive)
+	c.Assert(err, check.IsNil)
+	c.Assert(yamlData.Hooks.Restart.Before, check.DeepEquals, []string{"echo before"})
+	_, err = tsuruYamlFromArchive([]byte("not an archive"))
+	c.Assert(err, check.ErrorMatches, "unable to read the app source archive.*")
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cnb
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/globalsign/mgo"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/db"
+	appTypes "github.com/tsuru/tsuru/types/app"
+)
+
+// cnbPlatform maps a platform to the builder image used to build the apps
+// using it.
+type cnbPlatform struct {
+	Name  string `bson:"_id"`
+	Image string
+}
+
+// PlatformAdd maps the platform to the builder image in the FROM instruction
+// of its Dockerfile, which must not have any other instruction.
+func (b *cnbBuilder) PlatformAdd(opts appTypes.PlatformOptions) error {
+	return savePlatform(opts)
+}
+
+func (b *cnbBuilder) PlatformUpdate(opts appTypes.PlatformOptions) error {
+	return savePlatform(opts)
+}
+
+func (b *cnbBuilder) PlatformRemove(name string) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.CNBPlatforms().RemoveId(name)
+	if err == mgo.ErrNotFound {
+		return errors.Errorf("platform %q not found in the cnb builder", name)
+	}
+	return err
+}
+
+func (b *cnbBuilder) OwnsPlatform(name string) (bool, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	n, err := conn.CNBPlatforms().FindId(name).Count()
+	return n > 0, err
+}
+
+func savePlatform(opts appTypes.PlatformOptions) error {
+	builderImage, err := builderImageFromDockerfile(opts.Data)
+	if err != nil {
+		return err
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.CNBPlatforms().UpsertId(opts.Name, cnbPlatform{Name: opts.Name, Image: builderImage})
+	if err != nil {
+		return err
+	}
+	if opts.Output != nil {
+		fmt.Fprintf(opts.Output, "---- Platform %q will build apps with builder image %q ----\n", opts.Name, builderImage)
+	}
+	return nil
+}
+
+func builderImageForPlatform(name string) (string, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	var platform cnbPlatform
+	err = conn.CNBPlatforms().FindId(name).One(&platform)
+	if err == mgo.ErrNotFound {
+		return "", errors.Errorf("platform %q not found in the cnb builder", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return platform.Image, nil
+}
+
+func builderImageFromDockerfile(data []byte) (string, error) {
+	var builderImage string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if builderImage != "" || len(fields) != 2 || strings.ToUpper(fields[0]) != "FROM" {
+			return "", errors.New("the Dockerfile of cnb platforms must have only the FROM instruction with the builder image")
+		}
+		builderImage = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if builderImage == "" {
+		return "", errors.New("the Dockerfile of cnb platforms must have the FROM instruction with the builder image")
+	}
+	return builderImage, nil
+}
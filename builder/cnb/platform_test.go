@@ -0,0 +1,64 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cnb
+
+import (
+	"bytes"
+
+	appTypes "github.com/tsuru/tsuru/types/app"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestPlatformAdd(c *check.C) {
+	var out bytes.Buffer
+	err := s.b.PlatformAdd(appTypes.PlatformOptions{
+		Name:   "python",
+		Data:   []byte("# buildpacks\nFROM cnbs/sample-builder:bionic\n"),
+		Output: &out,
+	})
+	c.Assert(err, check.IsNil)
+	img, err := builderImageForPlatform("python")
+	c.Assert(err, check.IsNil)
+	c.Assert(img, check.Equals, "cnbs/sample-builder:bionic")
+	owns, err := s.b.OwnsPlatform("python")
+	c.Assert(err, check.IsNil)
+	c.Assert(owns, check.Equals, true)
+	c.Assert(out.String(), check.Matches, `(?s).*builder image "cnbs/sample-builder:bionic".*`)
+}
+
+func (s *S) TestPlatformAddInvalidDockerfile(c *check.C) {
+	err := s.b.PlatformAdd(appTypes.PlatformOptions{
+		Name: "python",
+		Data: []byte("FROM cnbs/sample-builder:bionic\nRUN echo hi\n"),
+	})
+	c.Assert(err, check.ErrorMatches, "the Dockerfile of cnb platforms must have only the FROM instruction with the builder image")
+	err = s.b.PlatformAdd(appTypes.PlatformOptions{Name: "python"})
+	c.Assert(err, check.ErrorMatches, "the Dockerfile of cnb platforms must have the FROM instruction with the builder image")
+	owns, err := s.b.OwnsPlatform("python")
+	c.Assert(err, check.IsNil)
+	c.Assert(owns, check.Equals, false)
+}
+
+func (s *S) TestPlatformUpdate(c *check.C) {
+	err := s.b.PlatformAdd(appTypes.PlatformOptions{Name: "python", Data: []byte("FROM cnbs/sample-builder:alpine")})
+	c.Assert(err, check.IsNil)
+	err = s.b.PlatformUpdate(appTypes.PlatformOptions{Name: "python", Data: []byte("FROM cnbs/sample-builder:bionic")})
+	c.Assert(err, check.IsNil)
+	img, err := builderImageForPlatform("python")
+	c.Assert(err, check.IsNil)
+	c.Assert(img, check.Equals, "cnbs/sample-builder:bionic")
+}
+
+func (s *S) TestPlatformRemove(c *check.C) {
+	err := s.b.PlatformAdd(appTypes.PlatformOptions{Name: "python", Data: []byte("FROM cnbs/sample-builder:bionic")})
+	c.Assert(err, check.IsNil)
+	err = s.b.PlatformRemove("python")
+	c.Assert(err, check.IsNil)
+	owns, err := s.b.OwnsPlatform("python")
+	c.Assert(err, check.IsNil)
+	c.Assert(owns, check.Equals, false)
+	err = s.b.PlatformRemove("python")
+	c.Assert(err, check.ErrorMatches, `platform "python" not found in the cnb builder`)
+}
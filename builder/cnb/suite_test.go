@@ -0,0 +1,110 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cnb
+
+import (
+	"testing"
+
+	dtesting "github.com/fsouza/go-dockerclient/testing"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/auth/native"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/db/dbtest"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/pool"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	"github.com/tsuru/tsuru/router/routertest"
+	servicemock "github.com/tsuru/tsuru/servicemanager/mock"
+	_ "github.com/tsuru/tsuru/storage/mongodb"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	authTypes "github.com/tsuru/tsuru/types/auth"
+	"github.com/tsuru/tsuru/types/quota"
+	"golang.org/x/crypto/bcrypt"
+	check "gopkg.in/check.v1"
+)
+
+type S struct {
+	b           *cnbBuilder
+	conn        *db.Storage
+	user        *auth.User
+	team        *authTypes.Team
+	token       auth.Token
+	provisioner *provisiontest.FakeProvisioner
+	server      *dtesting.DockerServer
+	mockService servicemock.MockService
+}
+
+var _ = check.Suite(&S{})
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+func (s *S) SetUpSuite(c *check.C) {
+	config.Set("log:disable-syslog", true)
+	config.Set("auth:hash-cost", bcrypt.MinCost)
+	config.Set("database:url", "127.0.0.1:27017?maxPoolSize=100")
+	config.Set("database:name", "builder_cnb_tests_s")
+	config.Set("routers:fake:type", "fake")
+	config.Set("routers:fake:default", true)
+	var err error
+	s.conn, err = db.Conn()
+	c.Assert(err, check.IsNil)
+	s.provisioner = provisiontest.ProvisionerInstance
+	provision.DefaultProvisioner = "fake"
+}
+
+func (s *S) TearDownSuite(c *check.C) {
+	s.conn.Apps().Database.DropDatabase()
+	s.conn.Close()
+}
+
+func (s *S) SetUpTest(c *check.C) {
+	routertest.FakeRouter.Reset()
+	s.provisioner.Reset()
+	err := dbtest.ClearAllCollections(s.conn.Apps().Database)
+	c.Assert(err, check.IsNil)
+	err = pool.AddPool(pool.AddPoolOptions{
+		Name:        "thepool",
+		Default:     true,
+		Provisioner: "fake",
+	})
+	c.Assert(err, check.IsNil)
+	s.b = &cnbBuilder{}
+	s.server, err = dtesting.NewServer("127.0.0.1:0", nil, nil)
+	c.Assert(err, check.IsNil)
+	s.user = &auth.User{Email: "whiskeyjack@genabackis.com", Password: "123456", Quota: quota.UnlimitedQuota}
+	nativeScheme := auth.ManagedScheme(native.NativeScheme{})
+	app.AuthScheme = nativeScheme
+	_, err = nativeScheme.Create(s.user)
+	c.Assert(err, check.IsNil)
+	s.team = &authTypes.Team{Name: "admin"}
+	s.token, err = nativeScheme.Login(map[string]string{"email": s.user.Email, "password": "123456"})
+	c.Assert(err, check.IsNil)
+	servicemock.SetMockService(&s.mockService)
+	s.mockService.Team.OnList = func() ([]authTypes.Team, error) {
+		return []authTypes.Team{{Name: s.team.Name}}, nil
+	}
+	s.mockService.Team.OnFindByName = func(_ string) (*authTypes.Team, error) {
+		return &authTypes.Team{Name: s.team.Name}, nil
+	}
+	plan := appTypes.Plan{
+		Name:     "default",
+		Default:  true,
+		CpuShare: 100,
+	}
+	s.mockService.Plan.OnList = func() ([]appTypes.Plan, error) {
+		return []appTypes.Plan{plan}, nil
+	}
+	s.mockService.Plan.OnDefaultPlan = func() (*appTypes.Plan, error) {
+		return &plan, nil
+	}
+}
+
+func (s *S) TearDownTest(c *check.C) {
+	s.server.Stop()
+}
@@ -35,6 +35,8 @@ type runContainerActionsArgs struct {
 	exposedPort   string
 	event         *event.Event
 	tarFile       io.Reader
+	// buildCache is the build cache of the app, nil when it's disabled.
+	buildCache *image.BuildCache
 }
 
 func checkCanceled(evt *event.Event) error {
@@ -122,6 +124,26 @@ var uploadToContainer = action.Action{
 	},
 }
 
+var restoreBuildCache = action.Action{
+	Name: "restore-build-cache",
+	Forward: func(ctx action.FWContext) (action.Result, error) {
+		args := ctx.Params[0].(runContainerActionsArgs)
+		c := ctx.Previous.(container.Container)
+		if args.buildCache == nil || args.buildCache.Image == "" {
+			return c, nil
+		}
+		fmt.Fprintf(args.writer, " ---> Restoring build cache from %s\n", args.buildCache.Image)
+		err := copyBuildCache(args.client, args.buildCache, c.ID)
+		if err != nil {
+			fmt.Fprintf(args.writer, " ---> Unable to restore build cache, building without it: %s\n", err)
+			log.Errorf("error restoring build cache %q in container %s - %s", args.buildCache.Image, c.ID, err)
+		}
+		return c, nil
+	},
+	Backward: func(ctx action.BWContext) {
+	},
+}
+
 var startContainer = action.Action{
 	Name: "start-container",
 	Forward: func(ctx action.FWContext) (action.Result, error) {
@@ -224,6 +246,30 @@ var followLogsAndCommit = action.Action{
 	MinParams: 1,
 }
 
+var saveBuildCache = action.Action{
+	Name: "save-build-cache",
+	Forward: func(ctx action.FWContext) (action.Result, error) {
+		args := ctx.Params[0].(runContainerActionsArgs)
+		if args.buildCache == nil {
+			return ctx.Previous, nil
+		}
+		cacheImage := image.AppBuildCacheImageName(args.app.GetName(), args.app.GetTeamOwner())
+		fmt.Fprintf(args.writer, " ---> Saving build cache to %s\n", cacheImage)
+		err := commitBuildCache(args.client, args.app.GetName(), args.buildingImage, cacheImage, args.buildCache.Path)
+		if err == nil {
+			err = image.UpdateAppBuildCacheImage(args.app.GetName(), cacheImage)
+		}
+		if err != nil {
+			fmt.Fprintf(args.writer, " ---> Unable to save build cache: %s\n", err)
+			log.Errorf("error saving build cache of app %s - %s", args.app.GetName(), err)
+		}
+		return ctx.Previous, nil
+	},
+	Backward: func(ctx action.BWContext) {
+	},
+	MinParams: 1,
+}
+
 var updateAppBuilderImage = action.Action{
 	Name: "update-app-builder-image",
 	Forward: func(ctx action.FWContext) (action.Result, error) {
@@ -25,7 +25,10 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
-var _ builder.Builder = &dockerBuilder{}
+var (
+	_ builder.Builder           = &dockerBuilder{}
+	_ builder.ProvenanceBuilder = &dockerBuilder{}
+)
 
 const (
 	defaultArchiveName = "archive.tar.gz"
@@ -65,13 +68,13 @@ func (b *dockerBuilder) Build(prov provision.BuilderDeploy, app provision.App, e
 		return "", errors.New("provisioner not supported: doesn't implement docker builder")
 	}
 	archiveFullPath := fmt.Sprintf("%s/%s", defaultArchivePath, defaultArchiveName)
-	if opts.BuildFromFile {
-		return "", errors.New("build image from Dockerfile is not yet supported")
-	}
 	client, err := p.GetClient(app)
 	if err != nil {
 		return "", err
 	}
+	if opts.BuildFromFile {
+		return dockerfileBuild(client, app, opts, evt)
+	}
 	var tarFile io.ReadCloser
 	if opts.ArchiveFile != nil && opts.ArchiveSize != 0 {
 		tarFile = dockercommon.AddDeployTarFile(opts.ArchiveFile, opts.ArchiveSize, defaultArchiveName)
@@ -83,10 +86,15 @@ func (b *dockerBuilder) Build(prov provision.BuilderDeploy, app provision.App, e
 		}
 		defer client.RemoveContainer(docker.RemoveContainerOptions{ID: rcont.ID, Force: true})
 	} else if opts.ArchiveURL != "" {
-		tarFile, err = downloadFromURL(opts.ArchiveURL)
+		var data []byte
+		data, err = downloadFromURL(opts.ArchiveURL)
 		if err != nil {
 			return "", err
 		}
+		opts.ArchiveDigest = builder.ArchiveDigest(data)
+		tarFile = ioutil.NopCloser(bytes.NewReader(data))
+	} else if opts.ImageID != "" && opts.Promote {
+		return promoteImage(client, app, opts.ImageID, evt)
 	} else if opts.ImageID != "" {
 		return imageBuild(client, app, opts, evt)
 	} else {
@@ -145,10 +153,14 @@ func imageBuild(client provision.BuilderDockerClient, app provision.App, opts *b
 	if err != nil {
 		return "", err
 	}
+	customData, err := yaml.ToCustomData()
+	if err != nil {
+		return "", err
+	}
 	imageData := image.ImageMetadata{
 		Name:       newImage,
 		Processes:  procfile,
-		CustomData: tsuruYamlToCustomData(yaml),
+		CustomData: customData,
 	}
 	for k := range imageInspect.Config.ExposedPorts {
 		imageData.ExposedPort = string(k)
@@ -160,6 +172,72 @@ func imageBuild(client provision.BuilderDockerClient, app provision.App, opts *b
 	return newImage, nil
 }
 
+// dockerfileBuild builds the image described by the Dockerfile in the uploaded
+// context archive and deploys it the same way an image deploy does, reading
+// the Procfile and tsuru.yaml from the built image.
+func dockerfileBuild(client provision.BuilderDockerClient, app provision.App, opts *builder.BuildOpts, evt *event.Event) (string, error) {
+	if opts.ArchiveFile == nil {
+		return "", errors.New("build image from Dockerfile requires an uploaded context archive")
+	}
+	buildingImage, err := image.AppNewBuilderImageName(app.GetName(), app.GetTeamOwner(), opts.Tag)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(evt, "---- Building image %q from Dockerfile ----\n", buildingImage)
+	client.SetTimeout(0)
+	buildOpts := docker.BuildImageOptions{
+		Name:              buildingImage,
+		Pull:              true,
+		RmTmpContainer:    true,
+		InputStream:       opts.ArchiveFile,
+		OutputStream:      &tsuruIo.DockerErrorCheckWriter{W: evt},
+		InactivityTimeout: net.StreamInactivityTimeout,
+		RawJSONStream:     true,
+	}
+	err = client.BuildImage(buildOpts)
+	if err != nil {
+		return "", err
+	}
+	repo, tag := image.SplitImageName(buildingImage)
+	pushOpts := docker.PushImageOptions{
+		Name:              repo,
+		Tag:               tag,
+		OutputStream:      &tsuruIo.DockerErrorCheckWriter{W: evt},
+		InactivityTimeout: net.StreamInactivityTimeout,
+		RawJSONStream:     true,
+	}
+	err = client.PushImage(pushOpts, dockercommon.RegistryAuthConfig(buildingImage))
+	if err != nil {
+		return "", err
+	}
+	opts.ImageID = buildingImage
+	return imageBuild(client, app, opts, evt)
+}
+
+// promoteImage retags an image already built for another app and pushes it
+// to the app's repository, copying the source image metadata instead of
+// inspecting and rebuilding it.
+func promoteImage(client provision.BuilderDockerClient, app provision.App, imageID string, evt *event.Event) (string, error) {
+	fmt.Fprintf(evt, "---- Pulling image %q ----\n", imageID)
+	createOptions := docker.CreateContainerOptions{
+		Config: &docker.Config{Image: imageID},
+	}
+	cont, _, err := client.PullAndCreateContainer(createOptions, evt)
+	if err != nil {
+		return "", err
+	}
+	removeContainer(client, cont.ID)
+	newImage, err := pushImageToRegistry(client, app, imageID, evt)
+	if err != nil {
+		return "", err
+	}
+	err = image.CopyImageMetadata(imageID, newImage)
+	if err != nil {
+		return "", err
+	}
+	return newImage, nil
+}
+
 func pushImageToRegistry(client provision.BuilderDockerClient, app provision.App, imageID string, evt *event.Event) (string, error) {
 	newImage, err := image.AppNewImageName(app.GetName())
 	if err != nil {
@@ -206,17 +284,6 @@ func loadTsuruYaml(client provision.BuilderDockerClient, app provision.App, imag
 	return &tsuruYamlData, containerID, err
 }
 
-func tsuruYamlToCustomData(yaml *provision.TsuruYamlData) map[string]interface{} {
-	if yaml == nil {
-		return nil
-	}
-
-	return map[string]interface{}{
-		"healthcheck": yaml.Healthcheck,
-		"hooks":       yaml.Hooks,
-	}
-}
-
 func runBuildHooks(client provision.BuilderDockerClient, app provision.App, imageID string, evt *event.Event, tsuruYamlData *provision.TsuruYamlData) (string, error) {
 	if tsuruYamlData == nil || len(tsuruYamlData.Hooks.Build) == 0 {
 		return "", nil
@@ -312,20 +379,47 @@ func downloadFromContainer(client provision.BuilderDockerClient, app provision.A
 	return archiveFile, cont, nil
 }
 
-func downloadFromURL(url string) (io.ReadCloser, error) {
-	var out bytes.Buffer
+func downloadFromURL(url string) ([]byte, error) {
 	client := net.Dial15Full300Client
 	resp, err := client.Get(url)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	s, err := io.Copy(&out, resp.Body)
+	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
-	if s == 0 {
+	if len(data) == 0 {
 		return nil, errors.New("archive file is empty")
 	}
-	return ioutil.NopCloser(&out), nil
+	return data, nil
+}
+
+func (b *dockerBuilder) SupportsBuildCache() bool {
+	return true
+}
+
+func (b *dockerBuilder) ImageSBOM(prov provision.BuilderDeploy, app provision.App, imageName string) (*image.SBOM, error) {
+	client, err := dockerClient(prov, app)
+	if err != nil {
+		return nil, err
+	}
+	return dockercommon.ImageSBOM(client, imageName)
+}
+
+func (b *dockerBuilder) ImageDigest(prov provision.BuilderDeploy, app provision.App, imageName string) (string, error) {
+	client, err := dockerClient(prov, app)
+	if err != nil {
+		return "", err
+	}
+	return dockercommon.ImageDigest(client, imageName)
+}
+
+func dockerClient(prov provision.BuilderDeploy, app provision.App) (provision.BuilderDockerClient, error) {
+	p, ok := prov.(provision.BuilderDeployDockerClient)
+	if !ok {
+		return nil, errors.New("provisioner not supported: doesn't implement docker builder")
+	}
+	return p.GetClient(app)
 }
@@ -5,13 +5,16 @@
 package docker
 
 import (
+	"archive/tar"
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"regexp"
 	"strings"
 	"sync/atomic"
@@ -55,6 +58,7 @@ func (s *S) TestBuilderArchiveURL(c *check.C) {
 	imgID, err := s.b.Build(s.provisioner, a, evt, &bopts)
 	c.Assert(err, check.IsNil)
 	c.Assert(imgID, check.Equals, s.team.Name+"/app-myapp:v1-builder")
+	c.Assert(bopts.ArchiveDigest, check.Equals, "sha256:660332fc752d5799a56a2afd4668a17a5030e3699fe84bd50ee51463e96dc1f3")
 }
 
 func (s *S) TestBuilderArchiveURLEmptyFile(c *check.C) {
@@ -109,6 +113,95 @@ func (s *S) TestBuilderArchiveFile(c *check.C) {
 	c.Assert(imgID, check.Equals, s.team.Name+"/app-myapp:v1-builder")
 }
 
+func (s *S) TestBuilderArchiveFileWithBuildCache(c *check.C) {
+	stopCh := s.stopContainers(s.server.URL(), 2)
+	defer func() { <-stopCh }()
+	opts := provision.AddNodeOptions{Address: s.server.URL()}
+	err := s.provisioner.AddNode(opts)
+	c.Assert(err, check.IsNil)
+	a := &app.App{Name: "myapp", Platform: "whitespace", TeamOwner: s.team.Name}
+	err = app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.SetBuildCache(true)
+	c.Assert(err, check.IsNil)
+	var downloads []string
+	s.server.CustomHandler("/containers/.*/archive", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		downloads = append(downloads, r.URL.Query().Get("path"))
+		w.Header().Set("Content-Type", "application/x-tar")
+		tarWriter := tar.NewWriter(w)
+		tarWriter.WriteHeader(&tar.Header{Name: "cache/", Mode: 0755, Typeflag: tar.TypeDir})
+		tarWriter.WriteHeader(&tar.Header{Name: "cache/pip.log", Mode: 0644, Size: 2})
+		tarWriter.Write([]byte("ok"))
+		tarWriter.Close()
+	}))
+	defer s.server.CustomHandler("/containers/.*/archive", s.server.DefaultHandler())
+	cacheImage := s.team.Name + "/app-myapp:build-cache"
+	for i := 1; i <= 2; i++ {
+		evt, err := event.New(&event.Opts{
+			Target:  event.Target{Type: event.TargetTypeApp, Value: a.GetName()},
+			Kind:    permission.PermAppDeploy,
+			Owner:   s.token,
+			Allowed: event.Allowed(permission.PermAppDeploy),
+		})
+		c.Assert(err, check.IsNil)
+		var output bytes.Buffer
+		evt.SetLogWriter(&output)
+		buf := strings.NewReader("my upload data")
+		bopts := builder.BuildOpts{
+			ArchiveFile: ioutil.NopCloser(buf),
+			ArchiveSize: int64(buf.Len()),
+			Tag:         fmt.Sprintf("v%d", i),
+		}
+		imgID, err := s.b.Build(s.provisioner, a, evt, &bopts)
+		c.Assert(err, check.IsNil)
+		c.Assert(imgID, check.Equals, fmt.Sprintf("%s/app-myapp:v%d-builder", s.team.Name, i))
+		c.Assert(output.String(), check.Matches, "(?s).*Saving build cache to "+cacheImage+".*")
+		if i == 2 {
+			c.Assert(output.String(), check.Matches, "(?s).*Restoring build cache from "+cacheImage+".*")
+		}
+	}
+	cache, err := a.BuildCache()
+	c.Assert(err, check.IsNil)
+	c.Assert(cache.Image, check.Equals, cacheImage)
+	client, err := docker.NewClient(s.server.URL())
+	c.Assert(err, check.IsNil)
+	_, err = client.InspectImage(cacheImage)
+	c.Assert(err, check.IsNil)
+	c.Assert(downloads, check.DeepEquals, []string{"/home/application/cache", "/home/application/cache", "/home/application/cache"})
+}
+
+func (s *S) TestWriteBuildCacheContext(c *check.C) {
+	cacheFile, err := ioutil.TempFile("", "build-cache")
+	c.Assert(err, check.IsNil)
+	defer os.Remove(cacheFile.Name())
+	defer cacheFile.Close()
+	_, err = cacheFile.WriteString("cache archive")
+	c.Assert(err, check.IsNil)
+	var buf bytes.Buffer
+	err = writeBuildCacheContext(&buf, cacheFile, "myapp", "/home/application/cache")
+	c.Assert(err, check.IsNil)
+	files := map[string]string{}
+	reader := tar.NewReader(&buf)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.IsNil)
+		data, err := ioutil.ReadAll(reader)
+		c.Assert(err, check.IsNil)
+		files[header.Name] = string(data)
+	}
+	c.Assert(files, check.DeepEquals, map[string]string{
+		"Dockerfile":      "FROM scratch\nADD build-cache.tar /home/application/\nLABEL io.tsuru.build-cache.app=\"myapp\"\n",
+		"build-cache.tar": "cache archive",
+	})
+}
+
 func (s *S) TestBuilderImageID(c *check.C) {
 	opts := provision.AddNodeOptions{Address: s.server.URL()}
 	err := s.provisioner.AddNode(opts)
@@ -173,6 +266,119 @@ func (s *S) TestBuilderImageID(c *check.C) {
 	c.Assert(atomic.LoadInt32(&containerDeleteCount), check.Equals, int32(2))
 }
 
+func (s *S) TestBuilderDockerfile(c *check.C) {
+	opts := provision.AddNodeOptions{Address: s.server.URL()}
+	err := s.provisioner.AddNode(opts)
+	c.Assert(err, check.IsNil)
+	a := &app.App{Name: "myapp", TeamOwner: s.team.Name}
+	err = app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	u, _ := url.Parse(s.server.URL())
+	config.Set("docker:registry", u.Host)
+	defer config.Unset("docker:registry")
+	buildingImage := u.Host + "/" + s.team.Name + "/app-myapp:v1-builder"
+	var buildCalled bool
+	s.server.CustomHandler("/build", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buildCalled = true
+		c.Check(r.URL.Query().Get("t"), check.Equals, buildingImage)
+		s.server.DefaultHandler().ServeHTTP(w, r)
+	}))
+	s.server.CustomHandler("/containers/.*/attach", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "cannot hijack connection", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+		w.WriteHeader(http.StatusOK)
+		conn, _, cErr := hijacker.Hijack()
+		if cErr != nil {
+			http.Error(w, cErr.Error(), http.StatusInternalServerError)
+			return
+		}
+		outStream := stdcopy.NewStdWriter(conn, stdcopy.Stdout)
+		fmt.Fprintf(outStream, "web: ./start")
+		conn.Close()
+	}))
+	evt, err := event.New(&event.Opts{
+		Target:  event.Target{Type: event.TargetTypeApp, Value: a.GetName()},
+		Kind:    permission.PermAppDeploy,
+		Owner:   s.token,
+		Allowed: event.Allowed(permission.PermAppDeploy),
+	})
+	c.Assert(err, check.IsNil)
+	bopts := builder.BuildOpts{
+		BuildFromFile: true,
+		ArchiveFile:   builder.CompressDockerFile([]byte("FROM tsuru/python")),
+	}
+	imgID, err := s.b.Build(s.provisioner, a, evt, &bopts)
+	c.Assert(err, check.IsNil)
+	c.Assert(buildCalled, check.Equals, true)
+	c.Assert(imgID, check.Equals, u.Host+"/tsuru/app-myapp:v1")
+	imd, err := image.GetImageMetaData(imgID)
+	c.Assert(err, check.IsNil)
+	c.Assert(imd.Processes, check.DeepEquals, map[string][]string{"web": {"./start"}})
+}
+
+func (s *S) TestBuilderDockerfileWithoutArchive(c *check.C) {
+	a := &app.App{Name: "myapp", TeamOwner: s.team.Name}
+	evt, err := event.New(&event.Opts{
+		Target:  event.Target{Type: event.TargetTypeApp, Value: a.GetName()},
+		Kind:    permission.PermAppDeploy,
+		Owner:   s.token,
+		Allowed: event.Allowed(permission.PermAppDeploy),
+	})
+	c.Assert(err, check.IsNil)
+	bopts := builder.BuildOpts{BuildFromFile: true}
+	imgID, err := s.b.Build(s.provisioner, a, evt, &bopts)
+	c.Assert(err, check.ErrorMatches, "build image from Dockerfile requires an uploaded context archive")
+	c.Assert(imgID, check.Equals, "")
+}
+
+func (s *S) TestBuilderPromoteImage(c *check.C) {
+	opts := provision.AddNodeOptions{Address: s.server.URL()}
+	err := s.provisioner.AddNode(opts)
+	c.Assert(err, check.IsNil)
+	a := &app.App{Name: "myapp", Platform: "whitespace", TeamOwner: s.team.Name}
+	err = app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	u, _ := url.Parse(s.server.URL())
+	config.Set("docker:registry", u.Host)
+	defer config.Unset("docker:registry")
+	srcImage := u.Host + "/tsuru/app-staging:v3"
+	err = image.SaveImageCustomData(srcImage, map[string]interface{}{
+		"exposedPort": "8888/tcp",
+		"processes":   map[string]interface{}{"web": "python app.py"},
+	})
+	c.Assert(err, check.IsNil)
+	var containerDeleteCount int32
+	s.server.CustomHandler("/containers/[^/]+$", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			atomic.AddInt32(&containerDeleteCount, 1)
+		}
+		s.server.DefaultHandler().ServeHTTP(w, r)
+	}))
+	evt, err := event.New(&event.Opts{
+		Target:  event.Target{Type: event.TargetTypeApp, Value: a.GetName()},
+		Kind:    permission.PermAppDeploy,
+		Owner:   s.token,
+		Allowed: event.Allowed(permission.PermAppDeploy),
+	})
+	c.Assert(err, check.IsNil)
+	bopts := builder.BuildOpts{
+		ImageID: srcImage,
+		Promote: true,
+	}
+	imgID, err := s.b.Build(s.provisioner, a, evt, &bopts)
+	c.Assert(err, check.IsNil)
+	c.Assert(imgID, check.Equals, u.Host+"/tsuru/app-myapp:v1")
+	imd, err := image.GetImageMetaData(imgID)
+	c.Assert(err, check.IsNil)
+	c.Assert(imd.Processes, check.DeepEquals, map[string][]string{"web": {"python app.py"}})
+	c.Assert(imd.ExposedPort, check.Equals, "8888/tcp")
+	c.Assert(atomic.LoadInt32(&containerDeleteCount), check.Equals, int32(1))
+}
+
 func (s *S) TestBuilderImageIDWithMoreThanOnePort(c *check.C) {
 	opts := provision.AddNodeOptions{Address: s.server.URL()}
 	err := s.provisioner.AddNode(opts)
@@ -655,3 +861,25 @@ func (s *S) TestBuilderImageBuilded(c *check.C) {
 	c.Assert(imgID, check.Equals, u.Host+"/tsuru/app-myapp:v1")
 	c.Assert(bopts.IsTsuruBuilderImage, check.Equals, true)
 }
+
+func (s *S) TestBuilderImageSBOMAndDigest(c *check.C) {
+	opts := provision.AddNodeOptions{Address: s.server.URL()}
+	err := s.provisioner.AddNode(opts)
+	c.Assert(err, check.IsNil)
+	client, err := docker.NewClient(s.server.URL())
+	c.Assert(err, check.IsNil)
+	err = s.newFakeImage(client, "tsuru/python:latest", nil)
+	c.Assert(err, check.IsNil)
+	a := &app.App{Name: "myapp", Platform: "python", TeamOwner: s.team.Name}
+	err = app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	sbom, err := s.b.ImageSBOM(s.provisioner, a, "tsuru/python:latest")
+	c.Assert(err, check.IsNil)
+	c.Assert(sbom.Metadata.Component.Name, check.Equals, "tsuru/python:latest")
+	c.Assert(sbom.Components, check.DeepEquals, []image.SBOMComponent{})
+	img, err := client.InspectImage("tsuru/python:latest")
+	c.Assert(err, check.IsNil)
+	digest, err := s.b.ImageDigest(s.provisioner, a, "tsuru/python:latest")
+	c.Assert(err, check.IsNil)
+	c.Assert(digest, check.Equals, img.ID)
+}
@@ -5,18 +5,24 @@
 package docker
 
 import (
+	"archive/tar"
 	"crypto"
 	"crypto/rand"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"path"
 	"time"
 
+	"github.com/fsouza/go-dockerclient"
 	"github.com/pkg/errors"
 	"github.com/tsuru/tsuru/action"
 	"github.com/tsuru/tsuru/app/image"
 	"github.com/tsuru/tsuru/event"
+	tsuruIo "github.com/tsuru/tsuru/io"
 	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/net"
 	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/provision/docker/container"
 	"github.com/tsuru/tsuru/provision/dockercommon"
@@ -31,8 +37,10 @@ func (b *dockerBuilder) buildPipeline(p provision.BuilderDeployDockerClient, cli
 	actions := []*action.Action{
 		&createContainer,
 		&uploadToContainer,
+		&restoreBuildCache,
 		&startContainer,
 		&followLogsAndCommit,
+		&saveBuildCache,
 		&updateAppBuilderImage,
 	}
 	pipeline := action.NewPipeline(actions...)
@@ -50,6 +58,13 @@ func (b *dockerBuilder) buildPipeline(p provision.BuilderDeployDockerClient, cli
 	if evt == nil {
 		writer = ioutil.Discard
 	}
+	buildCache, err := image.GetAppBuildCache(app.GetName())
+	if err != nil {
+		return "", log.WrapError(errors.Wrapf(err, "error getting build cache for app %s", app.GetName()))
+	}
+	if !buildCache.Enabled {
+		buildCache = nil
+	}
 	args := runContainerActionsArgs{
 		app:           app,
 		imageID:       imageName,
@@ -61,6 +76,10 @@ func (b *dockerBuilder) buildPipeline(p provision.BuilderDeployDockerClient, cli
 		provisioner:   p,
 		tarFile:       tarFile,
 		isDeploy:      true,
+		buildCache:    buildCache,
+	}
+	if evt != nil {
+		pipeline.SetObserver(evt)
 	}
 	err = container.RunPipelineWithRetry(pipeline, args)
 	if err != nil {
@@ -70,6 +89,108 @@ func (b *dockerBuilder) buildPipeline(p provision.BuilderDeployDockerClient, cli
 	return buildingImage, nil
 }
 
+// copyBuildCache copies the cache directory from the build cache image to the
+// build container.
+func copyBuildCache(client provision.BuilderDockerClient, cache *image.BuildCache, contID string) error {
+	cacheCont, _, err := client.PullAndCreateContainer(docker.CreateContainerOptions{
+		// the cache image has no command, the container is never started.
+		Config: &docker.Config{Image: cache.Image, Cmd: []string{"/bin/true"}},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	defer client.RemoveContainer(docker.RemoveContainerOptions{ID: cacheCont.ID, Force: true})
+	reader, err := dockercommon.DownloadFromContainer(client, cacheCont.ID, cache.Path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return client.UploadToContainer(contID, docker.UploadToContainerOptions{
+		InputStream: reader,
+		Path:        path.Dir(cache.Path),
+	})
+}
+
+// commitBuildCache creates the build cache image with only the cache
+// directory of the image generated by the build, so the cache image doesn't
+// carry the app and its platform. The cache image is labeled with the app
+// name so it never shares its manifest with another cache image, allowing
+// each one to be removed from the registry independently.
+func commitBuildCache(client provision.BuilderDockerClient, appName, buildingImage, cacheImage, cachePath string) error {
+	cont, _, err := client.PullAndCreateContainer(docker.CreateContainerOptions{
+		Config: &docker.Config{Image: buildingImage},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	defer client.RemoveContainer(docker.RemoveContainerOptions{ID: cont.ID, Force: true})
+	cacheFile, err := ioutil.TempFile("", "build-cache")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(cacheFile.Name())
+	defer cacheFile.Close()
+	err = client.DownloadFromContainer(cont.ID, docker.DownloadFromContainerOptions{
+		OutputStream: cacheFile,
+		Path:         cachePath,
+	})
+	if err != nil {
+		return err
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeBuildCacheContext(writer, cacheFile, appName, cachePath))
+	}()
+	defer reader.Close()
+	err = client.BuildImage(docker.BuildImageOptions{
+		Name:              cacheImage,
+		RmTmpContainer:    true,
+		InputStream:       reader,
+		OutputStream:      &tsuruIo.DockerErrorCheckWriter{W: ioutil.Discard},
+		InactivityTimeout: net.StreamInactivityTimeout,
+		RawJSONStream:     true,
+	})
+	if err != nil {
+		return err
+	}
+	repository, tag := image.SplitImageName(cacheImage)
+	return dockercommon.PushImage(client, repository, tag, dockercommon.RegistryAuthConfig(repository))
+}
+
+// writeBuildCacheContext writes the context used to build the cache image: a
+// Dockerfile adding the archive of the cache directory, downloaded from the
+// build container, to an empty image. The archive is added, instead of being
+// copied, so the owners of the files in the archive are kept.
+func writeBuildCacheContext(w io.Writer, cacheFile *os.File, appName, cachePath string) error {
+	info, err := cacheFile.Stat()
+	if err != nil {
+		return err
+	}
+	_, err = cacheFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	dockerfile := fmt.Sprintf("FROM scratch\nADD build-cache.tar %s/\nLABEL io.tsuru.build-cache.app=%q\n", path.Dir(cachePath), appName)
+	tarWriter := tar.NewWriter(w)
+	err = tarWriter.WriteHeader(&tar.Header{Name: "Dockerfile", Mode: 0644, Size: int64(len(dockerfile))})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(tarWriter, dockerfile)
+	if err != nil {
+		return err
+	}
+	err = tarWriter.WriteHeader(&tar.Header{Name: "build-cache.tar", Mode: 0644, Size: info.Size()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tarWriter, cacheFile)
+	if err != nil {
+		return err
+	}
+	return tarWriter.Close()
+}
+
 func randomString() string {
 	h := crypto.MD5.New()
 	h.Write([]byte(time.Now().Format(time.RFC3339Nano)))
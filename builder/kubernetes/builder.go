@@ -5,6 +5,7 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strings"
@@ -14,9 +15,13 @@ import (
 	"github.com/tsuru/tsuru/builder"
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/registry"
 )
 
-var _ builder.Builder = &kubernetesBuilder{}
+var (
+	_ builder.Builder           = &kubernetesBuilder{}
+	_ builder.ProvenanceBuilder = &kubernetesBuilder{}
+)
 
 type kubernetesBuilder struct{}
 
@@ -29,9 +34,6 @@ func (b *kubernetesBuilder) Build(prov provision.BuilderDeploy, app provision.Ap
 	if !ok {
 		return "", errors.New("provisioner not supported")
 	}
-	if opts.BuildFromFile {
-		return "", errors.New("build image from Dockerfile is not yet supported")
-	}
 	if opts.ArchiveURL != "" {
 		return "", errors.New("build image from ArchiveURL is not yet supported by kubernetes builder")
 	}
@@ -39,6 +41,12 @@ func (b *kubernetesBuilder) Build(prov provision.BuilderDeploy, app provision.Ap
 	if err != nil {
 		return "", err
 	}
+	if opts.BuildFromFile {
+		return dockerfileBuild(client, app, opts, evt)
+	}
+	if opts.ImageID != "" && opts.Promote {
+		return promoteImage(client, app, opts.ImageID, evt)
+	}
 	if opts.ImageID != "" {
 		return imageBuild(client, app, opts.ImageID, evt)
 	}
@@ -57,6 +65,29 @@ func (b *kubernetesBuilder) Build(prov provision.BuilderDeploy, app provision.Ap
 	return imageID, nil
 }
 
+func (b *kubernetesBuilder) ImageSBOM(prov provision.BuilderDeploy, app provision.App, imageName string) (*image.SBOM, error) {
+	p, ok := prov.(provision.BuilderDeployKubeClient)
+	if !ok {
+		return nil, errors.New("provisioner not supported")
+	}
+	client, err := p.GetClient(app)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := client.DownloadFilesFromImage(app, imageName, image.SBOMPackageDatabasePaths())
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	return image.NewSBOMFromArchive(imageName, archive)
+}
+
+// ImageDigest returns the digest of the image in the registry, images built
+// by the kubernetes builder are never stored in the docker daemon of tsuru.
+func (b *kubernetesBuilder) ImageDigest(prov provision.BuilderDeploy, app provision.App, imageName string) (string, error) {
+	return registry.ImageDigest(imageName)
+}
+
 func imageBuild(client provision.BuilderKubeClient, a provision.App, imageID string, evt *event.Event) (string, error) {
 	if !strings.Contains(imageID, ":") {
 		imageID = fmt.Sprintf("%s:latest", imageID)
@@ -85,10 +116,14 @@ func imageBuild(client provision.BuilderKubeClient, a provision.App, imageID str
 	for k, v := range procfile {
 		fmt.Fprintf(evt, " ---> Process %q found with commands: %q\n", k, v)
 	}
+	customData, err := tsuruYaml.ToCustomData()
+	if err != nil {
+		return "", err
+	}
 	imageData := image.ImageMetadata{
 		Name:       newImage,
 		Processes:  procfile,
-		CustomData: tsuruYamlToCustomData(tsuruYaml),
+		CustomData: customData,
 	}
 	for k := range imageInspect.Config.ExposedPorts {
 		imageData.ExposedPort = string(k)
@@ -100,14 +135,45 @@ func imageBuild(client provision.BuilderKubeClient, a provision.App, imageID str
 	return newImage, nil
 }
 
-func tsuruYamlToCustomData(yaml *provision.TsuruYamlData) map[string]interface{} {
-	if yaml == nil {
-		return nil
+// dockerfileBuild builds the image described by the Dockerfile in the uploaded
+// context archive and deploys it the same way an image deploy does, reading
+// the Procfile and tsuru.yaml from the built image.
+func dockerfileBuild(client provision.BuilderKubeClient, a provision.App, opts *builder.BuildOpts, evt *event.Event) (string, error) {
+	if opts.ArchiveFile == nil {
+		return "", errors.New("build image from Dockerfile requires an uploaded context archive")
+	}
+	buildingImage, err := image.AppNewBuilderImageName(a.GetName(), a.GetTeamOwner(), opts.Tag)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(evt, "---- Building image %q from Dockerfile ----\n", buildingImage)
+	ctx, cancel := evt.CancelableContext(context.Background())
+	err = client.BuildImage(a.GetName(), buildingImage, opts.ArchiveFile, evt, ctx)
+	cancel()
+	if err != nil {
+		return "", err
+	}
+	return imageBuild(client, a, buildingImage, evt)
+}
+
+// promoteImage retags an image already built for another app and pushes it
+// to the app's repository, copying the source image metadata instead of
+// inspecting and rebuilding it.
+func promoteImage(client provision.BuilderKubeClient, a provision.App, imageID string, evt *event.Event) (string, error) {
+	fmt.Fprintf(evt, "---- Promoting image %q ----\n", imageID)
+	newImage, err := image.AppNewImageName(a.GetName())
+	if err != nil {
+		return "", err
+	}
+	_, _, _, err = client.ImageTagPushAndInspect(a, imageID, newImage)
+	if err != nil {
+		return "", err
 	}
-	return map[string]interface{}{
-		"healthcheck": yaml.Healthcheck,
-		"hooks":       yaml.Hooks,
+	err = image.CopyImageMetadata(imageID, newImage)
+	if err != nil {
+		return "", err
 	}
+	return newImage, nil
 }
 
 func downloadFromContainer(client provision.BuilderKubeClient, app provision.App, evt *event.Event) (io.ReadCloser, error) {
@@ -12,12 +12,14 @@ import (
 
 var _ Builder = &MockBuilder{}
 var _ PlatformBuilder = &MockBuilder{}
+var _ PlatformOwnerBuilder = &MockPlatformOwnerBuilder{}
 
 type MockBuilder struct {
 	OnBuild          func(provision.BuilderDeploy, provision.App, *event.Event, *BuildOpts) (string, error)
 	OnPlatformAdd    func(appTypes.PlatformOptions) error
 	OnPlatformUpdate func(appTypes.PlatformOptions) error
 	OnPlatformRemove func(string) error
+	NoBuildCache     bool
 }
 
 func (b *MockBuilder) Build(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *BuildOpts) (string, error) {
@@ -27,6 +29,10 @@ func (b *MockBuilder) Build(p provision.BuilderDeploy, app provision.App, evt *e
 	return b.OnBuild(p, app, evt, opts)
 }
 
+func (b *MockBuilder) SupportsBuildCache() bool {
+	return !b.NoBuildCache
+}
+
 func (b *MockBuilder) PlatformAdd(opts appTypes.PlatformOptions) error {
 	if b.OnPlatformAdd == nil {
 		return nil
@@ -47,3 +53,23 @@ func (b *MockBuilder) PlatformRemove(name string) error {
 	}
 	return b.OnPlatformRemove(name)
 }
+
+type MockPlatformOwnerBuilder struct {
+	MockBuilder
+	OnOwnsPlatform        func(string) (bool, error)
+	OnSupportsProvisioner func(provision.Provisioner) bool
+}
+
+func (b *MockPlatformOwnerBuilder) OwnsPlatform(name string) (bool, error) {
+	if b.OnOwnsPlatform == nil {
+		return false, nil
+	}
+	return b.OnOwnsPlatform(name)
+}
+
+func (b *MockPlatformOwnerBuilder) SupportsProvisioner(p provision.Provisioner) bool {
+	if b.OnSupportsProvisioner == nil {
+		return true
+	}
+	return b.OnSupportsProvisioner(p)
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/gnuflag"
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/envcrypt"
+	"github.com/tsuru/tsuru/cmd"
+)
+
+type envKeyRotateCmd struct {
+	fs            *gnuflag.FlagSet
+	reencryptOnly bool
+}
+
+func (*envKeyRotateCmd) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "env-key-rotate",
+		Usage: "env-key-rotate [--reencrypt-only]",
+		Desc: `Generates a new key in the configured env encryption provider and
+encrypts the environment variables of all apps again using it. Previous keys
+are kept by the provider so values can still be decrypted until they're
+encrypted again. When the provider has no keys yet, the first key is generated.
+
+When the provider is not able to generate keys, the key must be rotated
+externally and the --reencrypt-only flag must be used.`,
+	}
+}
+
+func (c *envKeyRotateCmd) Run(context *cmd.Context, client *cmd.Client) error {
+	provider, err := envcrypt.Provider()
+	if errors.Cause(err) == envcrypt.ErrNoKey && !c.reencryptOnly {
+		keyID, err := envcrypt.GenerateFirstKey()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(context.Stdout, "First encryption key %q generated.\n", keyID)
+		return app.ReencryptAllEnvs(context.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+	if !c.reencryptOnly {
+		rotator, ok := provider.(envcrypt.KeyRotator)
+		if !ok {
+			return errors.New("env encryption provider does not support key rotation, rotate the key externally and use --reencrypt-only")
+		}
+		keyID, err := rotator.RotateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(context.Stdout, "New encryption key %q generated.\n", keyID)
+	}
+	return app.ReencryptAllEnvs(context.Stdout)
+}
+
+func (c *envKeyRotateCmd) Flags() *gnuflag.FlagSet {
+	if c.fs == nil {
+		c.fs = gnuflag.NewFlagSet("env-key-rotate", gnuflag.ExitOnError)
+		c.fs.BoolVar(&c.reencryptOnly, "reencrypt-only", false, "Do not generate a new key, only encrypt values again with the current key")
+	}
+	return c.fs
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/google/gops/agent"
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/api"
+	_ "github.com/tsuru/tsuru/builder/cnb"
 	_ "github.com/tsuru/tsuru/builder/docker"
 	_ "github.com/tsuru/tsuru/builder/kubernetes"
 	"github.com/tsuru/tsuru/cmd"
@@ -35,6 +36,7 @@ func buildManager() *cmd.Manager {
 	m.Register(&tsurudCommand{Command: gandalfSyncCmd{}})
 	m.Register(&tsurudCommand{Command: createRootUserCmd{}})
 	m.Register(&tsurudCommand{Command: &migrationListCmd{}})
+	m.Register(&tsurudCommand{Command: &envKeyRotateCmd{}})
 	return m
 }
 
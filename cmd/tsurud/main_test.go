@@ -65,3 +65,12 @@ func (s *S) TestGandalfSyncCmdIsRegistered(c *check.C) {
 	c.Assert(ok, check.Equals, true)
 	c.Assert(sync.Command, check.FitsTypeOf, gandalfSyncCmd{})
 }
+
+func (s *S) TestEnvKeyRotateCmdIsRegistered(c *check.C) {
+	manager := buildManager()
+	cmd, ok := manager.Commands["env-key-rotate"]
+	c.Assert(ok, check.Equals, true)
+	rotate, ok := cmd.(*tsurudCommand)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(rotate.Command, check.FitsTypeOf, &envKeyRotateCmd{})
+}
@@ -99,6 +99,10 @@ func init() {
 	if err != nil {
 		log.Fatalf("unable to register migration: %s", err)
 	}
+	err = migration.RegisterOptional("encrypt-app-envs", appMigrate.MigrateEncryptAppEnvs)
+	if err != nil {
+		log.Fatalf("unable to register migration: %s", err)
+	}
 }
 
 func getProvisioner() (string, error) {
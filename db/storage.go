@@ -13,6 +13,7 @@ package db
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/globalsign/mgo"
 	"github.com/tsuru/config"
@@ -238,3 +239,114 @@ func (s *Storage) VolumeBinds() *storage.Collection {
 	c := s.Collection("volume_binds")
 	return c
 }
+
+// AppEnvRevisions returns the collection storing the history of environment
+// variable changes of apps.
+func (s *Storage) AppEnvRevisions() *storage.Collection {
+	revisionIndex := mgo.Index{Key: []string{"app", "revision"}, Unique: true}
+	c := s.Collection("app_env_revisions")
+	c.EnsureIndex(revisionIndex)
+	return c
+}
+
+// AppEnvHashKeys returns the collection storing the key used to hash values
+// in the env history of apps.
+func (s *Storage) AppEnvHashKeys() *storage.Collection {
+	return s.Collection("app_env_hash_keys")
+}
+
+// AppJobs returns the collection storing the scheduled jobs of apps.
+func (s *Storage) AppJobs() *storage.Collection {
+	nameIndex := mgo.Index{Key: []string{"app", "name"}, Unique: true}
+	c := s.Collection("app_jobs")
+	c.EnsureIndex(nameIndex)
+	return c
+}
+
+// AppJobRuns returns the collection recording the runs of jobs scheduled by
+// provisioners which were already stored as events. Records expire after a
+// week, when the provisioner no longer keeps the runs.
+func (s *Storage) AppJobRuns() *storage.Collection {
+	c := s.Collection("app_job_runs")
+	c.EnsureIndex(mgo.Index{Key: []string{"app", "job"}})
+	c.EnsureIndex(mgo.Index{Key: []string{"claimedat"}, ExpireAfter: 7 * 24 * time.Hour})
+	return c
+}
+
+// AppAutoScales returns the collection storing the unit autoscale settings of
+// app processes.
+func (s *Storage) AppAutoScales() *storage.Collection {
+	processIndex := mgo.Index{Key: []string{"app", "process"}, Unique: true}
+	c := s.Collection("app_autoscales")
+	c.EnsureIndex(processIndex)
+	return c
+}
+
+// SleepSchedules returns the collection storing the sleep and wake schedules
+// of apps and pools.
+func (s *Storage) SleepSchedules() *storage.Collection {
+	nameIndex := mgo.Index{Key: []string{"app", "pool", "name"}, Unique: true}
+	c := s.Collection("sleep_schedules")
+	c.EnsureIndex(nameIndex)
+	return c
+}
+
+// DeployWatches returns the collection storing the post-deploy observation
+// windows currently open, one per app.
+func (s *Storage) DeployWatches() *storage.Collection {
+	return s.Collection("deploy_watches")
+}
+
+// CNBPlatforms returns the collection mapping the platforms managed by the
+// Cloud Native Buildpacks builder to their builder images.
+func (s *Storage) CNBPlatforms() *storage.Collection {
+	return s.Collection("cnb_platforms")
+}
+
+// ACMECertificates returns the collection storing the app cnames with
+// certificates issued through ACME.
+func (s *Storage) ACMECertificates() *storage.Collection {
+	cnameIndex := mgo.Index{Key: []string{"app", "cname"}, Unique: true}
+	c := s.Collection("acme_certificates")
+	c.EnsureIndex(cnameIndex)
+	return c
+}
+
+// ACMEChallenges returns the collection storing the ACME http-01 challenges
+// in progress, keyed by token.
+func (s *Storage) ACMEChallenges() *storage.Collection {
+	return s.Collection("acme_challenges")
+}
+
+// ACMEAccounts returns the collection storing the ACME account keys, keyed by
+// directory URL.
+func (s *Storage) ACMEAccounts() *storage.Collection {
+	return s.Collection("acme_accounts")
+}
+
+// CertificateAlerts returns the collection recording the expiring
+// certificates already notified through events.
+func (s *Storage) CertificateAlerts() *storage.Collection {
+	return s.Collection("certificate_alerts")
+}
+
+// RouterMigrations returns the collection storing the progress of the
+// migrations of apps between routers, keyed by app name.
+func (s *Storage) RouterMigrations() *storage.Collection {
+	return s.Collection("router_migrations")
+}
+
+// PathRouteHosts returns the collection storing the locks serializing changes
+// to the path routes of each hostname.
+func (s *Storage) PathRouteHosts() *storage.Collection {
+	return s.Collection("path_route_hosts")
+}
+
+// PathRoutes returns the collection storing the path prefixes of hostnames
+// claimed by apps.
+func (s *Storage) PathRoutes() *storage.Collection {
+	pathIndex := mgo.Index{Key: []string{"host", "path"}, Unique: true}
+	c := s.Collection("path_routes")
+	c.EnsureIndex(pathIndex)
+	return c
+}
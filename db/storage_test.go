@@ -115,6 +115,46 @@ func (s *S) TestApps(c *check.C) {
 	c.Assert(apps, HasUniqueIndex, []string{"name"})
 }
 
+func (s *S) TestAppEnvRevisions(c *check.C) {
+	strg, err := Conn()
+	c.Assert(err, check.IsNil)
+	defer strg.Close()
+	revisions := strg.AppEnvRevisions()
+	revisionsc := strg.Collection("app_env_revisions")
+	c.Assert(revisions, check.DeepEquals, revisionsc)
+	c.Assert(revisions, HasUniqueIndex, []string{"app", "revision"})
+}
+
+func (s *S) TestAppJobs(c *check.C) {
+	strg, err := Conn()
+	c.Assert(err, check.IsNil)
+	defer strg.Close()
+	jobs := strg.AppJobs()
+	jobsc := strg.Collection("app_jobs")
+	c.Assert(jobs, check.DeepEquals, jobsc)
+	c.Assert(jobs, HasUniqueIndex, []string{"app", "name"})
+}
+
+func (s *S) TestAppAutoScales(c *check.C) {
+	strg, err := Conn()
+	c.Assert(err, check.IsNil)
+	defer strg.Close()
+	autoScales := strg.AppAutoScales()
+	autoScalesc := strg.Collection("app_autoscales")
+	c.Assert(autoScales, check.DeepEquals, autoScalesc)
+	c.Assert(autoScales, HasUniqueIndex, []string{"app", "process"})
+}
+
+func (s *S) TestSleepSchedules(c *check.C) {
+	strg, err := Conn()
+	c.Assert(err, check.IsNil)
+	defer strg.Close()
+	schedules := strg.SleepSchedules()
+	schedulesc := strg.Collection("sleep_schedules")
+	c.Assert(schedules, check.DeepEquals, schedulesc)
+	c.Assert(schedules, HasUniqueIndex, []string{"app", "pool", "name"})
+}
+
 func (s *S) TestServices(c *check.C) {
 	strg, err := Conn()
 	c.Assert(err, check.IsNil)
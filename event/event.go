@@ -22,6 +22,7 @@ import (
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/auth"
 	internalConfig "github.com/tsuru/tsuru/config"
+	tsuruIo "github.com/tsuru/tsuru/io"
 	"github.com/tsuru/tsuru/db"
 	"github.com/tsuru/tsuru/db/storage"
 	"github.com/tsuru/tsuru/log"
@@ -66,6 +67,7 @@ var (
 	errInvalidQuery = errors.New("invalid query")
 
 	ErrNotCancelable          = errors.New("event is not cancelable")
+	ErrNotPending             = errors.New("event is not pending")
 	ErrCancelAlreadyRequested = errors.New("event cancel already requested")
 	ErrEventNotFound          = errors.New("event not found")
 	ErrNoTarget               = ErrValidation("event target is mandatory")
@@ -75,6 +77,7 @@ var (
 	ErrNoInternalKind         = ErrValidation("event internal kind is mandatory")
 	ErrNoAllowed              = errors.New("event allowed is mandatory")
 	ErrNoAllowedCancel        = errors.New("event allowed cancel is mandatory for cancelable events")
+	ErrNoExpireTime           = ErrValidation("event expire time is mandatory for pending events")
 	ErrInvalidOwner           = ErrValidation("event owner must not be set on internal events")
 	ErrInvalidKind            = ErrValidation("event kind must not be set on internal events")
 	ErrInvalidTargetType      = errors.New("invalid event target type")
@@ -107,6 +110,7 @@ var (
 	TargetTypeCluster         = TargetType("cluster")
 	TargetTypeVolume          = TargetType("volume")
 	TargetTypeWebhook         = TargetType("webhook")
+	TargetTypeJob             = TargetType("job")
 )
 
 const (
@@ -208,6 +212,8 @@ type eventData struct {
 	CancelInfo      cancelInfo
 	Cancelable      bool
 	Running         bool
+	Pending         bool
+	ExpireTime      time.Time `bson:",omitempty"`
 	Allowed         AllowedPermission
 	AllowedCancel   AllowedPermission
 }
@@ -267,6 +273,8 @@ func GetTargetType(t string) (TargetType, error) {
 		return TargetTypeVolume, nil
 	case "webhook":
 		return TargetTypeWebhook, nil
+	case "job":
+		return TargetTypeJob, nil
 	}
 	return TargetType(""), ErrInvalidTargetType
 }
@@ -365,8 +373,9 @@ func getThrottling(t *Target, k *Kind, allTargets bool) *ThrottlingSpec {
 
 type Event struct {
 	eventData
-	logBuffer *safe.Buffer
-	logWriter io.Writer
+	logBuffer      *safe.Buffer
+	logWriter      io.Writer
+	progressWriter tsuruIo.ProgressWriter
 }
 
 type ExtraTarget struct {
@@ -386,6 +395,10 @@ type Opts struct {
 	Cancelable    bool
 	Allowed       AllowedPermission
 	AllowedCancel AllowedPermission
+	// Pending events are waiting for some user action, they don't lock their
+	// targets and remain running until claimed or until ExpireTime.
+	Pending    bool
+	ExpireTime time.Time
 }
 
 func Allowed(scheme *permission.PermissionScheme, contexts ...permTypes.PermissionContext) AllowedPermission {
@@ -843,6 +856,9 @@ func newEvt(opts *Opts) (evt *Event, err error) {
 	if opts.Cancelable && opts.AllowedCancel.Scheme == "" && len(opts.AllowedCancel.Contexts) == 0 {
 		return nil, ErrNoAllowedCancel
 	}
+	if opts.Pending && opts.ExpireTime.IsZero() {
+		return nil, ErrNoExpireTime
+	}
 	if opts.Kind == nil {
 		if opts.InternalKind == "" {
 			return nil, ErrNoKind
@@ -893,11 +909,16 @@ func newEvt(opts *Opts) (evt *Event, err error) {
 	}
 	uniqID := bson.NewObjectId()
 	var id eventID
-	if opts.DisableLock {
+	disableLock := opts.DisableLock || opts.Pending
+	if disableLock {
 		id.ObjId = uniqID
 	} else {
 		id.Target = opts.Target
 	}
+	var expireTime time.Time
+	if opts.Pending {
+		expireTime = opts.ExpireTime.UTC()
+	}
 	evt = &Event{eventData: eventData{
 		ID:              id,
 		UniqueID:        uniqID,
@@ -909,6 +930,8 @@ func newEvt(opts *Opts) (evt *Event, err error) {
 		StartCustomData: raw,
 		LockUpdateTime:  now,
 		Running:         true,
+		Pending:         opts.Pending,
+		ExpireTime:      expireTime,
 		Cancelable:      opts.Cancelable,
 		Allowed:         opts.Allowed,
 		AllowedCancel:   opts.AllowedCancel,
@@ -918,7 +941,7 @@ func newEvt(opts *Opts) (evt *Event, err error) {
 	for i := 0; i < maxRetries+1; i++ {
 		err = coll.Insert(evt.eventData)
 		if err == nil {
-			err = checkLocked(evt, opts.DisableLock)
+			err = checkLocked(evt, disableLock)
 			if err != nil {
 				evt.Abort()
 				return nil, err
@@ -928,6 +951,12 @@ func newEvt(opts *Opts) (evt *Event, err error) {
 				evt.Done(err)
 				return nil, err
 			}
+			if opts.Pending {
+				// Pending events are not running in this process until
+				// claimed.
+				eventCurrent.WithLabelValues(k.Name).Dec()
+				return evt, nil
+			}
 			updater.add(id)
 			return evt, nil
 		}
@@ -1155,6 +1184,44 @@ func (e *Event) AckCancel() (bool, error) {
 	return err == nil, err
 }
 
+// Claim takes ownership of a pending event, which becomes a regular running
+// event that must be finished with Done. Only one caller is able to claim a
+// pending event and expired events can't be claimed.
+func (e *Event) Claim() error {
+	if !e.Pending || !e.Running {
+		return ErrNotPending
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	coll := conn.Events()
+	now := time.Now().UTC()
+	change := mgo.Change{
+		Update: bson.M{"$set": bson.M{
+			"pending":        false,
+			"lockupdatetime": now,
+		}},
+		ReturnNew: true,
+	}
+	_, err = coll.Find(bson.M{
+		"_id":        e.ID,
+		"running":    true,
+		"pending":    true,
+		"expiretime": bson.M{"$gt": now},
+	}).Apply(change, &e.eventData)
+	if err == mgo.ErrNotFound {
+		return ErrNotPending
+	}
+	if err != nil {
+		return err
+	}
+	eventCurrent.WithLabelValues(e.Kind.Name).Inc()
+	updater.add(e.ID)
+	return nil
+}
+
 func (e *Event) StartData(value interface{}) error {
 	if e.StartCustomData.Kind == 0 {
 		return nil
@@ -337,6 +337,87 @@ func (s *S) TestNewDoneDisableLock(c *check.C) {
 	c.Assert(&evts[0], check.DeepEquals, expected)
 }
 
+func (s *S) TestNewPending(c *check.C) {
+	lockEvt, err := New(&Opts{
+		Target:  Target{Type: "app", Value: "myapp"},
+		Kind:    permission.PermAppUpdateEnvSet,
+		Owner:   s.token,
+		Allowed: Allowed(permission.PermAppReadEvents),
+	})
+	c.Assert(err, check.IsNil)
+	defer lockEvt.Done(nil)
+	expire := time.Now().Add(time.Hour)
+	evt, err := New(&Opts{
+		Target:     Target{Type: "app", Value: "myapp"},
+		Kind:       permission.PermAppDeploy,
+		Owner:      s.token,
+		Allowed:    Allowed(permission.PermAppReadEvents),
+		Pending:    true,
+		ExpireTime: expire,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.ID, check.DeepEquals, eventID{ObjId: evt.UniqueID})
+	c.Assert(evt.Running, check.Equals, true)
+	c.Assert(evt.Pending, check.Equals, true)
+	dbEvt, err := GetByID(evt.UniqueID)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbEvt.Pending, check.Equals, true)
+	c.Assert(dbEvt.ExpireTime.Unix(), check.Equals, expire.Unix())
+}
+
+func (s *S) TestNewPendingWithoutExpireTime(c *check.C) {
+	_, err := New(&Opts{
+		Target:  Target{Type: "app", Value: "myapp"},
+		Kind:    permission.PermAppDeploy,
+		Owner:   s.token,
+		Allowed: Allowed(permission.PermAppReadEvents),
+		Pending: true,
+	})
+	c.Assert(err, check.Equals, ErrNoExpireTime)
+}
+
+func (s *S) TestEventClaim(c *check.C) {
+	evt, err := New(&Opts{
+		Target:     Target{Type: "app", Value: "myapp"},
+		Kind:       permission.PermAppDeploy,
+		Owner:      s.token,
+		Allowed:    Allowed(permission.PermAppReadEvents),
+		Pending:    true,
+		ExpireTime: time.Now().Add(time.Hour),
+	})
+	c.Assert(err, check.IsNil)
+	other, err := GetByID(evt.UniqueID)
+	c.Assert(err, check.IsNil)
+	err = evt.Claim()
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Pending, check.Equals, false)
+	c.Assert(evt.Running, check.Equals, true)
+	err = other.Claim()
+	c.Assert(err, check.Equals, ErrNotPending)
+	err = evt.Claim()
+	c.Assert(err, check.Equals, ErrNotPending)
+	err = evt.Done(nil)
+	c.Assert(err, check.IsNil)
+	dbEvt, err := GetByID(evt.UniqueID)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbEvt.Running, check.Equals, false)
+	c.Assert(dbEvt.Pending, check.Equals, false)
+}
+
+func (s *S) TestEventClaimExpired(c *check.C) {
+	evt, err := New(&Opts{
+		Target:     Target{Type: "app", Value: "myapp"},
+		Kind:       permission.PermAppDeploy,
+		Owner:      s.token,
+		Allowed:    Allowed(permission.PermAppReadEvents),
+		Pending:    true,
+		ExpireTime: time.Now().Add(-time.Minute),
+	})
+	c.Assert(err, check.IsNil)
+	err = evt.Claim()
+	c.Assert(err, check.Equals, ErrNotPending)
+}
+
 func (s *S) TestNewLockExpired(c *check.C) {
 	oldLockExpire := lockExpireTimeout
 	lockExpireTimeout = time.Millisecond
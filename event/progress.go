@@ -0,0 +1,67 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package event
+
+import (
+	tsuruIo "github.com/tsuru/tsuru/io"
+	"github.com/tsuru/tsuru/log"
+)
+
+const pipelinePhase = "pipeline"
+
+// SetProgressWriter sets the writer receiving the typed progress messages of
+// the event. Events without a progress writer discard them.
+func (e *Event) SetProgressWriter(w tsuruIo.ProgressWriter) {
+	e.progressWriter = w
+}
+
+// HasProgressWriter returns whether the event has a progress writer set.
+func (e *Event) HasProgressWriter() bool {
+	return e != nil && e.progressWriter != nil
+}
+
+// Progress sends msg to the progress writer of the event.
+func (e *Event) Progress(msg tsuruIo.ProgressMessage) {
+	if !e.HasProgressWriter() {
+		return
+	}
+	err := e.progressWriter.WriteProgress(msg)
+	if err != nil {
+		log.Debugf("[events] unable to write progress message of event %s: %s", e.UniqueID.Hex(), err)
+	}
+}
+
+// StepStarted reports the start of step, which is part of phase.
+func (e *Event) StepStarted(phase, step string) {
+	e.Progress(tsuruIo.ProgressMessage{Type: tsuruIo.ProgressStepStart, Phase: phase, Step: step})
+}
+
+// StepFinished reports the end of step, which is part of phase, failed if err
+// isn't nil.
+func (e *Event) StepFinished(phase, step string, err error) {
+	msg := tsuruIo.ProgressMessage{Type: tsuruIo.ProgressStepEnd, Phase: phase, Step: step}
+	if err != nil {
+		msg.Error = err.Error()
+	}
+	e.Progress(msg)
+}
+
+// ActionStarted implements action.Observer, reporting the start of each
+// action of a pipeline.
+func (e *Event) ActionStarted(name string, index, total int) {
+	e.Progress(tsuruIo.ProgressMessage{
+		Type:    tsuruIo.ProgressStepStart,
+		Phase:   pipelinePhase,
+		Step:    name,
+		Current: index + 1,
+		Total:   total,
+	})
+}
+
+// ActionFinished implements action.Observer, reporting the end of each action
+// of a pipeline.
+func (e *Event) ActionFinished(name string, err error) {
+	e.StepFinished(pipelinePhase, name, err)
+}
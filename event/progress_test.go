@@ -0,0 +1,51 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package event
+
+import (
+	"errors"
+
+	tsuruIo "github.com/tsuru/tsuru/io"
+	"gopkg.in/check.v1"
+)
+
+type recordingProgressWriter struct {
+	msgs []tsuruIo.ProgressMessage
+}
+
+func (w *recordingProgressWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (w *recordingProgressWriter) WriteProgress(msg tsuruIo.ProgressMessage) error {
+	w.msgs = append(w.msgs, msg)
+	return nil
+}
+
+func (s *S) TestEventProgress(c *check.C) {
+	var w recordingProgressWriter
+	evt := &Event{}
+	c.Assert(evt.HasProgressWriter(), check.Equals, false)
+	evt.StepStarted("builder", "docker")
+	c.Assert(w.msgs, check.HasLen, 0)
+	evt.SetProgressWriter(&w)
+	c.Assert(evt.HasProgressWriter(), check.Equals, true)
+	evt.StepStarted("builder", "docker")
+	evt.StepFinished("builder", "docker", errors.New("failed"))
+	evt.ActionStarted("create-container", 0, 3)
+	evt.ActionFinished("create-container", nil)
+	c.Assert(w.msgs, check.DeepEquals, []tsuruIo.ProgressMessage{
+		{Type: tsuruIo.ProgressStepStart, Phase: "builder", Step: "docker"},
+		{Type: tsuruIo.ProgressStepEnd, Phase: "builder", Step: "docker", Error: "failed"},
+		{Type: tsuruIo.ProgressStepStart, Phase: "pipeline", Step: "create-container", Current: 1, Total: 3},
+		{Type: tsuruIo.ProgressStepEnd, Phase: "pipeline", Step: "create-container"},
+	})
+}
+
+func (s *S) TestEventProgressNilEvent(c *check.C) {
+	var evt *Event
+	c.Assert(evt.HasProgressWriter(), check.Equals, false)
+	evt.StepStarted("builder", "docker")
+}
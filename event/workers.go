@@ -58,12 +58,33 @@ func (l *eventCleaner) tryCleaning() error {
 	var allData []eventData
 	err = coll.Find(bson.M{
 		"running":        true,
+		"pending":        bson.M{"$ne": true},
 		"lockupdatetime": bson.M{"$lt": now.Add(-lockExpireTimeout)},
 	}).All(&allData)
-	conn.Close()
 	if err != nil {
+		conn.Close()
 		return errors.Wrap(err, "[events] [event cleaner] error updating expired events")
 	}
+	var pendingData []eventData
+	err = coll.Find(bson.M{
+		"running":    true,
+		"pending":    true,
+		"expiretime": bson.M{"$lt": now},
+	}).All(&pendingData)
+	conn.Close()
+	if err != nil {
+		return errors.Wrap(err, "[events] [event cleaner] error updating expired pending events")
+	}
+	for _, evtData := range pendingData {
+		evt := Event{eventData: evtData}
+		evt.Init()
+		err = evt.Done(errors.Errorf("pending event expired at %v", evt.ExpireTime))
+		if err != nil {
+			log.Errorf("[events] [event cleaner] error marking evt as done: %v", err)
+		} else {
+			eventsExpired.WithLabelValues(evt.Kind.Name).Inc()
+		}
+	}
 	for _, evtData := range allData {
 		evt := Event{eventData: evtData}
 		evt.Init()
@@ -211,3 +211,40 @@ func (s *S) TestEventCleaner(c *check.C) {
 	c.Assert(evts[0].Running, check.Equals, false)
 	c.Assert(evts[0].Error, check.Matches, `event expired, no update for .*ms`)
 }
+
+func (s *S) TestEventCleanerPendingEvent(c *check.C) {
+	cleaner.stop()
+	oldEventCleanerInterval := eventCleanerInterval
+	eventCleanerInterval = time.Millisecond
+	oldLockExpire := lockExpireTimeout
+	lockExpireTimeout = 100 * time.Millisecond
+	defer func() {
+		eventCleanerInterval = oldEventCleanerInterval
+		lockExpireTimeout = oldLockExpire
+	}()
+	_, err := New(&Opts{
+		Target:     Target{Type: "app", Value: "myapp"},
+		Kind:       permission.PermAppDeploy,
+		Owner:      s.token,
+		Allowed:    Allowed(permission.PermAppReadEvents),
+		Pending:    true,
+		ExpireTime: time.Now().Add(300 * time.Millisecond),
+	})
+	c.Assert(err, check.IsNil)
+	time.Sleep(120 * time.Millisecond)
+	cleaner.start()
+	cleaner.stop()
+	evts, err := All()
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].Running, check.Equals, true)
+	c.Assert(evts[0].Pending, check.Equals, true)
+	time.Sleep(200 * time.Millisecond)
+	cleaner.start()
+	cleaner.stop()
+	evts, err = All()
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].Running, check.Equals, false)
+	c.Assert(evts[0].Error, check.Matches, `pending event expired at .*`)
+}
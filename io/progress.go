@@ -0,0 +1,78 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package io
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type ProgressType string
+
+const (
+	ProgressStepStart  = ProgressType("step-start")
+	ProgressStepEnd    = ProgressType("step-end")
+	ProgressProgress   = ProgressType("progress")
+	ProgressUnitStatus = ProgressType("unit-status")
+	ProgressLog        = ProgressType("log")
+	ProgressResult     = ProgressType("result")
+)
+
+// ProgressMessage is a message of the structured progress stream of an
+// operation. Each message is sent as a JSON document in its own line.
+type ProgressMessage struct {
+	Type      ProgressType `json:"type"`
+	Phase     string       `json:"phase,omitempty"`
+	Step      string       `json:"step,omitempty"`
+	Message   string       `json:"message,omitempty"`
+	Current   int          `json:"current,omitempty"`
+	Total     int          `json:"total,omitempty"`
+	Unit      string       `json:"unit,omitempty"`
+	Process   string       `json:"process,omitempty"`
+	Status    string       `json:"status,omitempty"`
+	Image     string       `json:"image,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// ProgressWriter is implemented by writers accepting typed progress messages
+// besides free-text output.
+type ProgressWriter interface {
+	io.Writer
+	WriteProgress(msg ProgressMessage) error
+}
+
+type jsonProgressWriter struct {
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+// NewJSONProgressWriter returns a ProgressWriter encoding each message as a
+// JSON document in w. Free-text output is sent as log messages.
+func NewJSONProgressWriter(w io.Writer) ProgressWriter {
+	return &jsonProgressWriter{encoder: json.NewEncoder(w)}
+}
+
+func (w *jsonProgressWriter) Write(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	err := w.WriteProgress(ProgressMessage{Type: ProgressLog, Message: string(data)})
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *jsonProgressWriter) WriteProgress(msg ProgressMessage) error {
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now().UTC()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.encoder.Encode(msg)
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package io
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestJSONProgressWriter(c *check.C) {
+	var buf bytes.Buffer
+	w := NewJSONProgressWriter(&buf)
+	n, err := w.Write([]byte("some output\n"))
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 12)
+	n, err = w.Write(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 0)
+	now := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	err = w.WriteProgress(ProgressMessage{Type: ProgressStepStart, Phase: "builder", Step: "docker", Timestamp: now})
+	c.Assert(err, check.IsNil)
+	decoder := json.NewDecoder(&buf)
+	var msgs []ProgressMessage
+	for decoder.More() {
+		var msg ProgressMessage
+		err = decoder.Decode(&msg)
+		c.Assert(err, check.IsNil)
+		msgs = append(msgs, msg)
+	}
+	c.Assert(msgs, check.HasLen, 2)
+	c.Assert(msgs[0].Type, check.Equals, ProgressLog)
+	c.Assert(msgs[0].Message, check.Equals, "some output\n")
+	c.Assert(msgs[0].Timestamp.IsZero(), check.Equals, false)
+	c.Assert(msgs[1], check.DeepEquals, ProgressMessage{Type: ProgressStepStart, Phase: "builder", Step: "docker", Timestamp: now})
+}
@@ -18,25 +18,34 @@ var (
 	PermAppCreate                        = PermissionRegistry.get("app.create")                          // [global team]
 	PermAppDelete                        = PermissionRegistry.get("app.delete")                          // [global app team pool]
 	PermAppDeploy                        = PermissionRegistry.get("app.deploy")                          // [global app team pool]
+	PermAppDeployApprove                 = PermissionRegistry.get("app.deploy.approve")                  // [global app team pool]
 	PermAppDeployArchiveUrl              = PermissionRegistry.get("app.deploy.archive-url")              // [global app team pool]
 	PermAppDeployBuild                   = PermissionRegistry.get("app.deploy.build")                    // [global app team pool]
+	PermAppDeployDockerfile              = PermissionRegistry.get("app.deploy.dockerfile")               // [global app team pool]
 	PermAppDeployGit                     = PermissionRegistry.get("app.deploy.git")                      // [global app team pool]
 	PermAppDeployImage                   = PermissionRegistry.get("app.deploy.image")                    // [global app team pool]
+	PermAppDeployPromote                 = PermissionRegistry.get("app.deploy.promote")                  // [global app team pool]
 	PermAppDeployRollback                = PermissionRegistry.get("app.deploy.rollback")                 // [global app team pool]
 	PermAppDeployUpload                  = PermissionRegistry.get("app.deploy.upload")                   // [global app team pool]
 	PermAppRead                          = PermissionRegistry.get("app.read")                            // [global app team pool]
+	PermAppReadBuildCache                = PermissionRegistry.get("app.read.build-cache")                // [global app team pool]
 	PermAppReadCertificate               = PermissionRegistry.get("app.read.certificate")                // [global app team pool]
 	PermAppReadDeploy                    = PermissionRegistry.get("app.read.deploy")                     // [global app team pool]
 	PermAppReadEnv                       = PermissionRegistry.get("app.read.env")                        // [global app team pool]
 	PermAppReadEvents                    = PermissionRegistry.get("app.read.events")                     // [global app team pool]
+	PermAppReadJob                       = PermissionRegistry.get("app.read.job")                        // [global app team pool]
 	PermAppReadLog                       = PermissionRegistry.get("app.read.log")                        // [global app team pool]
 	PermAppReadMetric                    = PermissionRegistry.get("app.read.metric")                     // [global app team pool]
 	PermAppReadRouter                    = PermissionRegistry.get("app.read.router")                     // [global app team pool]
 	PermAppRun                           = PermissionRegistry.get("app.run")                             // [global app team pool]
 	PermAppRunShell                      = PermissionRegistry.get("app.run.shell")                       // [global app team pool]
 	PermAppUpdate                        = PermissionRegistry.get("app.update")                          // [global app team pool]
+	PermAppUpdateApply                   = PermissionRegistry.get("app.update.apply")                    // [global app team pool]
 	PermAppUpdateBind                    = PermissionRegistry.get("app.update.bind")                     // [global app team pool]
 	PermAppUpdateBindVolume              = PermissionRegistry.get("app.update.bind-volume")              // [global app team pool]
+	PermAppUpdateBuildCache              = PermissionRegistry.get("app.update.build-cache")              // [global app team pool]
+	PermAppUpdateBuildCachePurge         = PermissionRegistry.get("app.update.build-cache.purge")        // [global app team pool]
+	PermAppUpdateBuildCacheSet           = PermissionRegistry.get("app.update.build-cache.set")          // [global app team pool]
 	PermAppUpdateCertificate             = PermissionRegistry.get("app.update.certificate")              // [global app team pool]
 	PermAppUpdateCertificateSet          = PermissionRegistry.get("app.update.certificate.set")          // [global app team pool]
 	PermAppUpdateCertificateUnset        = PermissionRegistry.get("app.update.certificate.unset")        // [global app team pool]
@@ -47,15 +56,29 @@ var (
 	PermAppUpdateDeployRollback          = PermissionRegistry.get("app.update.deploy.rollback")          // [global app team pool]
 	PermAppUpdateDescription             = PermissionRegistry.get("app.update.description")              // [global app team pool]
 	PermAppUpdateEnv                     = PermissionRegistry.get("app.update.env")                      // [global app team pool]
+	PermAppUpdateEnvRollback             = PermissionRegistry.get("app.update.env.rollback")             // [global app team pool]
 	PermAppUpdateEnvSet                  = PermissionRegistry.get("app.update.env.set")                  // [global app team pool]
 	PermAppUpdateEnvUnset                = PermissionRegistry.get("app.update.env.unset")                // [global app team pool]
 	PermAppUpdateEvents                  = PermissionRegistry.get("app.update.events")                   // [global app team pool]
 	PermAppUpdateGrant                   = PermissionRegistry.get("app.update.grant")                    // [global app team pool]
 	PermAppUpdateImageReset              = PermissionRegistry.get("app.update.image-reset")              // [global app team pool]
+	PermAppUpdateJob                     = PermissionRegistry.get("app.update.job")                      // [global app team pool]
+	PermAppUpdateJobAdd                  = PermissionRegistry.get("app.update.job.add")                  // [global app team pool]
+	PermAppUpdateJobRemove               = PermissionRegistry.get("app.update.job.remove")               // [global app team pool]
+	PermAppUpdateJobRun                  = PermissionRegistry.get("app.update.job.run")                  // [global app team pool]
 	PermAppUpdateLog                     = PermissionRegistry.get("app.update.log")                      // [global app team pool]
+	PermAppUpdatePath                    = PermissionRegistry.get("app.update.path")                     // [global app team pool]
+	PermAppUpdatePathAdd                 = PermissionRegistry.get("app.update.path.add")                 // [global app team pool]
+	PermAppUpdatePathRemove              = PermissionRegistry.get("app.update.path.remove")              // [global app team pool]
 	PermAppUpdatePlan                    = PermissionRegistry.get("app.update.plan")                     // [global app team pool]
+	PermAppUpdatePlanProcess             = PermissionRegistry.get("app.update.plan.process")             // [global app team pool]
+	PermAppUpdatePlanProcessSet          = PermissionRegistry.get("app.update.plan.process.set")         // [global app team pool]
+	PermAppUpdatePlanProcessUnset        = PermissionRegistry.get("app.update.plan.process.unset")       // [global app team pool]
 	PermAppUpdatePlatform                = PermissionRegistry.get("app.update.platform")                 // [global app team pool]
 	PermAppUpdatePool                    = PermissionRegistry.get("app.update.pool")                     // [global app team pool]
+	PermAppUpdatePostDeploy              = PermissionRegistry.get("app.update.post-deploy")              // [global app team pool]
+	PermAppUpdatePostDeploySet           = PermissionRegistry.get("app.update.post-deploy.set")          // [global app team pool]
+	PermAppUpdatePostDeployUnset         = PermissionRegistry.get("app.update.post-deploy.unset")        // [global app team pool]
 	PermAppUpdateRestart                 = PermissionRegistry.get("app.update.restart")                  // [global app team pool]
 	PermAppUpdateRevoke                  = PermissionRegistry.get("app.update.revoke")                   // [global app team pool]
 	PermAppUpdateRouter                  = PermissionRegistry.get("app.update.router")                   // [global app team pool]
@@ -63,6 +86,9 @@ var (
 	PermAppUpdateRouterRemove            = PermissionRegistry.get("app.update.router.remove")            // [global app team pool]
 	PermAppUpdateRouterUpdate            = PermissionRegistry.get("app.update.router.update")            // [global app team pool]
 	PermAppUpdateSleep                   = PermissionRegistry.get("app.update.sleep")                    // [global app team pool]
+	PermAppUpdateSleepSchedule           = PermissionRegistry.get("app.update.sleep.schedule")           // [global app team pool]
+	PermAppUpdateSleepScheduleAdd        = PermissionRegistry.get("app.update.sleep.schedule.add")       // [global app team pool]
+	PermAppUpdateSleepScheduleRemove     = PermissionRegistry.get("app.update.sleep.schedule.remove")    // [global app team pool]
 	PermAppUpdateStart                   = PermissionRegistry.get("app.update.start")                    // [global app team pool]
 	PermAppUpdateStop                    = PermissionRegistry.get("app.update.stop")                     // [global app team pool]
 	PermAppUpdateSwap                    = PermissionRegistry.get("app.update.swap")                     // [global app team pool]
@@ -72,6 +98,9 @@ var (
 	PermAppUpdateUnbindVolume            = PermissionRegistry.get("app.update.unbind-volume")            // [global app team pool]
 	PermAppUpdateUnit                    = PermissionRegistry.get("app.update.unit")                     // [global app team pool]
 	PermAppUpdateUnitAdd                 = PermissionRegistry.get("app.update.unit.add")                 // [global app team pool]
+	PermAppUpdateUnitAutoscale           = PermissionRegistry.get("app.update.unit.autoscale")           // [global app team pool]
+	PermAppUpdateUnitAutoscaleRemove     = PermissionRegistry.get("app.update.unit.autoscale.remove")    // [global app team pool]
+	PermAppUpdateUnitAutoscaleSet        = PermissionRegistry.get("app.update.unit.autoscale.set")       // [global app team pool]
 	PermAppUpdateUnitRegister            = PermissionRegistry.get("app.update.unit.register")            // [global app team pool]
 	PermAppUpdateUnitRemove              = PermissionRegistry.get("app.update.unit.remove")              // [global app team pool]
 	PermAppUpdateUnitStatus              = PermissionRegistry.get("app.update.unit.status")              // [global app team pool]
@@ -143,6 +172,10 @@ var (
 	PermPoolUpdateConstraints            = PermissionRegistry.get("pool.update.constraints")             // [global pool]
 	PermPoolUpdateConstraintsSet         = PermissionRegistry.get("pool.update.constraints.set")         // [global pool]
 	PermPoolUpdateLogs                   = PermissionRegistry.get("pool.update.logs")                    // [global pool]
+	PermPoolUpdateSleep                  = PermissionRegistry.get("pool.update.sleep")                   // [global pool]
+	PermPoolUpdateSleepSchedule          = PermissionRegistry.get("pool.update.sleep.schedule")          // [global pool]
+	PermPoolUpdateSleepScheduleAdd       = PermissionRegistry.get("pool.update.sleep.schedule.add")      // [global pool]
+	PermPoolUpdateSleepScheduleRemove    = PermissionRegistry.get("pool.update.sleep.schedule.remove")   // [global pool]
 	PermPoolUpdateTeam                   = PermissionRegistry.get("pool.update.team")                    // [global pool]
 	PermPoolUpdateTeamAdd                = PermissionRegistry.get("pool.update.team.add")                // [global pool]
 	PermPoolUpdateTeamRemove             = PermissionRegistry.get("pool.update.team.remove")             // [global pool]
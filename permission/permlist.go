@@ -23,10 +23,15 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"app.update.unit.remove",
 	"app.update.unit.register",
 	"app.update.unit.status",
+	"app.update.unit.autoscale.set",
+	"app.update.unit.autoscale.remove",
 	"app.update.env.set",
 	"app.update.env.unset",
+	"app.update.env.rollback",
 	"app.update.restart",
 	"app.update.sleep",
+	"app.update.sleep.schedule.add",
+	"app.update.sleep.schedule.remove",
 	"app.update.start",
 	"app.update.stop",
 	"app.update.swap",
@@ -35,7 +40,15 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"app.update.teamowner",
 	"app.update.cname.add",
 	"app.update.cname.remove",
+	"app.update.path.add",
+	"app.update.path.remove",
 	"app.update.plan",
+	"app.update.plan.process.set",
+	"app.update.plan.process.unset",
+	"app.update.post-deploy.set",
+	"app.update.post-deploy.unset",
+	"app.update.build-cache.set",
+	"app.update.build-cache.purge",
 	"app.update.platform",
 	"app.update.bind",
 	"app.update.bind-volume",
@@ -49,11 +62,18 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"app.update.router.add",
 	"app.update.router.update",
 	"app.update.router.remove",
+	"app.update.apply",
+	"app.update.job.add",
+	"app.update.job.remove",
+	"app.update.job.run",
 	"app.deploy",
 	"app.deploy.archive-url",
+	"app.deploy.approve",
 	"app.deploy.build",
+	"app.deploy.dockerfile",
 	"app.deploy.git",
 	"app.deploy.image",
+	"app.deploy.promote",
 	"app.deploy.rollback",
 	"app.deploy.upload",
 	"app.read",
@@ -64,6 +84,8 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"app.read.metric",
 	"app.read.log",
 	"app.read.certificate",
+	"app.read.job",
+	"app.read.build-cache",
 	"app.delete",
 	"app.run",
 	"app.run.shell",
@@ -190,6 +212,8 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"pool.update.constraints.set",
 	"pool.read.constraints",
 	"pool.update.logs",
+	"pool.update.sleep.schedule.add",
+	"pool.update.sleep.schedule.remove",
 	"pool.delete",
 ).add(
 	"debug",
@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/globalsign/mgo/bson"
@@ -18,6 +19,7 @@ import (
 	"github.com/tsuru/tsuru/action"
 	"github.com/tsuru/tsuru/app/image"
 	"github.com/tsuru/tsuru/event"
+	tsuruIo "github.com/tsuru/tsuru/io"
 	"github.com/tsuru/tsuru/log"
 	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/provision/docker/container"
@@ -371,6 +373,7 @@ var bindAndHealthcheck = action.Action{
 			}
 		}
 		fmt.Fprintf(writer, "\n---- Binding and checking %d new %s ----\n", len(newContainers), pluralize("unit", len(newContainers)))
+		var checked int32
 		return newContainers, runInContainers(newContainers, func(c *container.Container, toRollback chan *container.Container) error {
 			unit := c.AsUnit(args.app)
 			err := args.app.BindUnit(&unit)
@@ -378,17 +381,32 @@ var bindAndHealthcheck = action.Action{
 				return err
 			}
 			toRollback <- c
-			if doHealthcheck && c.ProcessName == webProcessName {
-				err = runHealthcheck(c, writer)
+			if doHealthcheck {
+				probed, err := args.provisioner.runReadinessProbe(c, writer)
 				if err != nil {
 					return err
 				}
+				if !probed && c.ProcessName == webProcessName {
+					err = runHealthcheck(c, writer)
+					if err != nil {
+						return err
+					}
+				}
 			}
 			err = args.provisioner.runRestartAfterHooks(c, writer)
 			if err != nil {
 				return err
 			}
 			fmt.Fprintf(writer, " ---> Bound and checked unit %s [%s]\n", c.ShortID(), c.ProcessName)
+			args.event.Progress(tsuruIo.ProgressMessage{
+				Type:    tsuruIo.ProgressProgress,
+				Phase:   "provisioner",
+				Step:    "bind-and-healthcheck",
+				Unit:    c.ShortID(),
+				Process: c.ProcessName,
+				Current: int(atomic.AddInt32(&checked, 1)),
+				Total:   len(newContainers),
+			})
 			return nil
 		}, func(c *container.Container) {
 			unit := c.AsUnit(args.app)
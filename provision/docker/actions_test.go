@@ -1179,6 +1179,61 @@ func (s *S) TestBindAndHealthcheckForwardHealthcheckError(c *check.C) {
 	c.Assert(fakeApp.HasBind(&u2), check.Equals, false)
 }
 
+func (s *S) TestBindAndHealthcheckForwardReadinessProbeError(c *check.C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hc" {
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	dbApp := &app.App{Name: "myapp"}
+	err := s.conn.Apps().Insert(dbApp)
+	c.Assert(err, check.IsNil)
+	imageName := "tsuru/app-" + dbApp.Name
+	customData := map[string]interface{}{
+		"healthcheck": map[string]interface{}{
+			"path": "/hc",
+		},
+		"probes": map[string]interface{}{
+			"web": map[string]interface{}{
+				"readiness": map[string]interface{}{
+					"path":              "/ready",
+					"failure_threshold": 1,
+				},
+			},
+		},
+		"processes": map[string]interface{}{
+			"web": "python start_app.py",
+		},
+	}
+	err = newFakeImage(s.p, imageName, customData)
+	c.Assert(err, check.IsNil)
+	fakeApp := provisiontest.NewFakeApp(dbApp.Name, "python", 0)
+	s.p.Provision(fakeApp)
+	defer s.p.Destroy(fakeApp)
+	buf := safe.NewBuffer(nil)
+	args := changeUnitsPipelineArgs{
+		app:         fakeApp,
+		provisioner: s.p,
+		writer:      buf,
+		toAdd:       map[string]*containersToAdd{"web": {Quantity: 1}},
+		imageID:     "tsuru/app-" + dbApp.Name,
+	}
+	containers, err := addContainersWithHost(&args)
+	c.Assert(err, check.IsNil)
+	c.Assert(containers, check.HasLen, 1)
+	url, _ := url.Parse(server.URL)
+	host, port, _ := net.SplitHostPort(url.Host)
+	containers[0].HostAddr = host
+	containers[0].HostPort = port
+	context := action.FWContext{Params: []interface{}{args}, Previous: containers}
+	_, err = bindAndHealthcheck.Forward(context)
+	c.Assert(err, check.ErrorMatches, `readiness probe fail\(.*?\): wrong status code, expected 2xx or 3xx, got: 503`)
+	u1 := containers[0].AsUnit(fakeApp)
+	c.Assert(fakeApp.HasBind(&u1), check.Equals, false)
+}
+
 func (s *S) TestBindAndHealthcheckForwardRestartError(c *check.C) {
 	s.server.CustomHandler("/exec/.*/json", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
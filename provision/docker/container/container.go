@@ -9,6 +9,7 @@ import (
 	"crypto"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"net/url"
 	"strings"
@@ -75,7 +76,8 @@ type ContainerStateClient interface {
 }
 
 const (
-	maxStartRetries = 4
+	maxStartRetries    = 4
+	defaultStopTimeout = 10
 )
 
 func RunPipelineWithRetry(pipe *action.Pipeline, args interface{}) error {
@@ -414,12 +416,7 @@ func (c *Container) Sleep(client provision.BuilderDockerClient, limiter provisio
 	if c.Status != provision.StatusStarted.String() && c.Status != provision.StatusStarting.String() {
 		return errors.Errorf("container %s is not starting or started", c.ID)
 	}
-	done := limiter.Start(c.HostAddr)
-	err := client.StopContainer(c.ID, 10)
-	done()
-	if err != nil {
-		log.Errorf("error on stop container %s: %s", c.ID, err)
-	}
+	c.stop(client, limiter)
 	return c.SetStatus(client, provision.StatusAsleep, true)
 }
 
@@ -427,14 +424,56 @@ func (c *Container) Stop(client provision.BuilderDockerClient, limiter provision
 	if c.Status == provision.StatusStopped.String() {
 		return nil
 	}
+	c.stop(client, limiter)
+	c.SetStatus(client, provision.StatusStopped, true)
+	return nil
+}
+
+// stop runs the pre_stop hooks of the container and stops it, waiting for the
+// grace period declared in tsuru.yaml before killing it.
+func (c *Container) stop(client provision.BuilderDockerClient, limiter provision.ActionLimiter) {
+	timeout := uint(defaultStopTimeout)
+	if c.Image != "" {
+		yamlData, err := image.GetImageTsuruYamlData(c.Image)
+		if err != nil {
+			log.Errorf("unable to get tsuru.yaml data for container %s: %s", c.ID, err)
+		}
+		if yamlData.Hooks.GracePeriodSeconds > 0 {
+			timeout = uint(yamlData.Hooks.GracePeriodSeconds)
+		}
+		c.runPreStopHooks(client, yamlData.Hooks.PreStop, time.Duration(timeout)*time.Second)
+	}
 	done := limiter.Start(c.HostAddr)
-	err := client.StopContainer(c.ID, 10)
+	err := client.StopContainer(c.ID, timeout)
 	done()
 	if err != nil {
 		log.Errorf("error on stop container %s: %s", c.ID, err)
 	}
-	c.SetStatus(client, provision.StatusStopped, true)
-	return nil
+}
+
+// runPreStopHooks runs the pre_stop hooks inside the container, giving up on
+// them once the grace period is over. Failures are logged and don't prevent
+// the container from being stopped.
+func (c *Container) runPreStopHooks(client provision.BuilderDockerClient, cmds []string, gracePeriod time.Duration) {
+	if len(cmds) == 0 {
+		return
+	}
+	timeout := time.After(gracePeriod)
+	for _, cmd := range cmds {
+		errCh := make(chan error, 1)
+		go func(cmd string) {
+			errCh <- c.Exec(client, nil, ioutil.Discard, ioutil.Discard, Pty{}, "/bin/sh", "-lc", cmd)
+		}(cmd)
+		select {
+		case err := <-errCh:
+			if err != nil {
+				log.Errorf("error running pre_stop hook %q in container %s: %s", cmd, c.ID, err)
+			}
+		case <-timeout:
+			log.Errorf("pre_stop hooks of container %s did not finish within the grace period of %s", c.ID, gracePeriod)
+			return
+		}
+	}
 }
 
 type StartArgs struct {
@@ -450,12 +489,13 @@ func (c *Container) hostConfig(app provision.App, isDeploy bool) (*docker.HostCo
 	sharedIsolation, _ := config.GetBool("docker:sharedfs:app-isolation")
 	sharedSalt, _ := config.GetString("docker:sharedfs:salt")
 	hostConfig := docker.HostConfig{
-		CPUShares: int64(app.GetCpuShare()),
+		CPUShares: int64(app.GetProcessCpuShare(c.ProcessName)),
 	}
 
 	if !isDeploy {
-		hostConfig.Memory = app.GetMemory()
-		hostConfig.MemorySwap = app.GetMemory() + app.GetSwap()
+		memory := app.GetProcessMemory(c.ProcessName)
+		hostConfig.Memory = memory
+		hostConfig.MemorySwap = memory + app.GetProcessSwap(c.ProcessName)
 		hostConfig.RestartPolicy = docker.AlwaysRestart()
 		hostConfig.PortBindings = map[docker.Port][]docker.PortBinding{
 			docker.Port(c.ExposedPort): {{HostIP: "", HostPort: ""}},
@@ -24,12 +24,14 @@ import (
 	"github.com/tsuru/tsuru/action"
 	"github.com/tsuru/tsuru/app"
 	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/app/image"
 	"github.com/tsuru/tsuru/db"
 	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/provision/docker/types"
 	"github.com/tsuru/tsuru/provision/dockercommon"
 	"github.com/tsuru/tsuru/provision/provisiontest"
 	"github.com/tsuru/tsuru/router/routertest"
+	appTypes "github.com/tsuru/tsuru/types/app"
 	"gopkg.in/check.v1"
 )
 
@@ -152,6 +154,40 @@ func (s *S) TestContainerCreate(c *check.C) {
 	c.Assert(cont.Status, check.Equals, "created")
 }
 
+func (s *S) TestContainerCreateWithProcessPlan(c *check.C) {
+	app := provisiontest.NewFakeApp("app-name", "brainfuck", 1)
+	app.Memory = 15
+	app.Swap = 15
+	app.CpuShare = 50
+	app.ProcessPlans = map[string]appTypes.Plan{
+		"worker": {Name: "big", Memory: 100, Swap: 50, CpuShare: 200},
+	}
+	img := "tsuru/brainfuck:latest"
+	s.cli.PullImage(docker.PullImageOptions{Repository: img}, docker.AuthConfiguration{})
+	cont := Container{Container: types.Container{
+		Name:        "myName",
+		AppName:     app.GetName(),
+		Type:        app.GetPlatform(),
+		Status:      "created",
+		ProcessName: "worker",
+		ExposedPort: "8888/tcp",
+	}}
+	err := cont.Create(&CreateArgs{
+		App:      app,
+		ImageID:  img,
+		Commands: []string{"docker", "run"},
+		Client:   s.cli,
+	})
+	c.Assert(err, check.IsNil)
+	defer s.removeTestContainer(&cont)
+	dcli, _ := docker.NewClient(s.server.URL())
+	container, err := dcli.InspectContainer(cont.ID)
+	c.Assert(err, check.IsNil)
+	c.Assert(container.HostConfig.Memory, check.Equals, int64(100))
+	c.Assert(container.HostConfig.MemorySwap, check.Equals, int64(150))
+	c.Assert(container.HostConfig.CPUShares, check.Equals, int64(200))
+}
+
 func (s *S) TestContainerCreateCustomLog(c *check.C) {
 	client, err := docker.NewClient(s.server.URL())
 	c.Assert(err, check.IsNil)
@@ -808,6 +844,48 @@ func (s *S) TestContainerStop(c *check.C) {
 	c.Assert(cont.Status, check.Equals, provision.StatusStopped.String())
 }
 
+func (s *S) TestContainerStopRunsPreStopHooks(c *check.C) {
+	var urls struct {
+		items []url.URL
+		sync.Mutex
+	}
+	s.server.SetHook(func(r *http.Request) {
+		urls.Lock()
+		urls.items = append(urls.items, *r.URL)
+		urls.Unlock()
+	})
+	defer s.server.SetHook(nil)
+	cont, err := s.newContainer(newContainerOpts{}, nil)
+	c.Assert(err, check.IsNil)
+	defer s.removeTestContainer(cont)
+	err = image.SaveImageCustomData(cont.Image, map[string]interface{}{
+		"hooks": map[string]interface{}{
+			"pre_stop":             []string{"drain"},
+			"grace_period_seconds": 42,
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = s.cli.StartContainer(cont.ID, nil)
+	c.Assert(err, check.IsNil)
+	err = cont.Stop(s.cli, s.limiter)
+	c.Assert(err, check.IsNil)
+	urls.Lock()
+	defer urls.Unlock()
+	var execIndex, stopIndex int
+	for i, u := range urls.items {
+		if strings.HasSuffix(u.Path, "/containers/"+cont.ID+"/exec") {
+			execIndex = i
+		}
+		if strings.HasSuffix(u.Path, "/containers/"+cont.ID+"/stop") {
+			stopIndex = i
+			c.Assert(u.Query().Get("t"), check.Equals, "42")
+		}
+	}
+	c.Assert(execIndex > 0, check.Equals, true)
+	c.Assert(stopIndex > execIndex, check.Equals, true)
+	c.Assert(cont.Status, check.Equals, provision.StatusStopped.String())
+}
+
 func (s *S) TestContainerSleep(c *check.C) {
 	cont, err := s.newContainer(newContainerOpts{}, nil)
 	c.Assert(err, check.IsNil)
@@ -827,6 +905,77 @@ func (s *S) TestContainerSleep(c *check.C) {
 	c.Assert(cont.Status, check.Equals, provision.StatusAsleep.String())
 }
 
+func (s *S) TestContainerSleepRunsPreStopHooks(c *check.C) {
+	var urls struct {
+		items []url.URL
+		sync.Mutex
+	}
+	s.server.SetHook(func(r *http.Request) {
+		urls.Lock()
+		urls.items = append(urls.items, *r.URL)
+		urls.Unlock()
+	})
+	defer s.server.SetHook(nil)
+	cont, err := s.newContainer(newContainerOpts{}, nil)
+	c.Assert(err, check.IsNil)
+	defer s.removeTestContainer(cont)
+	err = image.SaveImageCustomData(cont.Image, map[string]interface{}{
+		"hooks": map[string]interface{}{
+			"pre_stop":             []string{"drain"},
+			"grace_period_seconds": 42,
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = s.cli.StartContainer(cont.ID, nil)
+	c.Assert(err, check.IsNil)
+	cont.Status = provision.StatusStarted.String()
+	err = cont.Sleep(s.cli, s.limiter)
+	c.Assert(err, check.IsNil)
+	urls.Lock()
+	defer urls.Unlock()
+	var execIndex, stopIndex int
+	for i, u := range urls.items {
+		if strings.HasSuffix(u.Path, "/containers/"+cont.ID+"/exec") {
+			execIndex = i
+		}
+		if strings.HasSuffix(u.Path, "/containers/"+cont.ID+"/stop") {
+			stopIndex = i
+			c.Assert(u.Query().Get("t"), check.Equals, "42")
+		}
+	}
+	c.Assert(execIndex > 0, check.Equals, true)
+	c.Assert(stopIndex > execIndex, check.Equals, true)
+	c.Assert(cont.Status, check.Equals, provision.StatusAsleep.String())
+}
+
+func (s *S) TestContainerStopPreStopHooksBoundedByGracePeriod(c *check.C) {
+	cont, err := s.newContainer(newContainerOpts{}, nil)
+	c.Assert(err, check.IsNil)
+	defer s.removeTestContainer(cont)
+	err = image.SaveImageCustomData(cont.Image, map[string]interface{}{
+		"hooks": map[string]interface{}{
+			"pre_stop":             []string{"sleep 3600"},
+			"grace_period_seconds": 1,
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = s.cli.StartContainer(cont.ID, nil)
+	c.Assert(err, check.IsNil)
+	release := make(chan struct{})
+	defer close(release)
+	s.server.SetHook(func(r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/start") && strings.Contains(r.URL.Path, "/exec/") {
+			<-release
+		}
+	})
+	defer s.server.SetHook(nil)
+	start := time.Now()
+	err = cont.Stop(s.cli, s.limiter)
+	c.Assert(err, check.IsNil)
+	c.Assert(time.Since(start) < 5*time.Second, check.Equals, true)
+	c.Assert(cont.Status, check.Equals, provision.StatusStopped.String())
+}
+
 func (s *S) TestContainerSleepNotStarted(c *check.C) {
 	cont, err := s.newContainer(newContainerOpts{}, nil)
 	c.Assert(err, check.IsNil)
@@ -124,6 +124,9 @@ func (p *dockerProvisioner) runReplaceUnitsPipeline(w io.Writer, a provision.App
 			&provisionUnbindOldUnits,
 		)
 	}
+	if evt != nil {
+		pipeline.SetObserver(evt)
+	}
 	err = pipeline.Execute(args)
 	if err != nil {
 		return nil, err
@@ -152,6 +155,9 @@ func (p *dockerProvisioner) runCreateUnitsPipeline(w io.Writer, a provision.App,
 		&setRouterHealthcheck,
 		&updateAppImage,
 	)
+	if evt != nil {
+		pipeline.SetObserver(evt)
+	}
 	err := pipeline.Execute(args)
 	if err != nil {
 		return nil, err
@@ -75,6 +75,9 @@ func (p *dockerProvisioner) deployPipeline(app provision.App, imageID string, co
 		provisioner:   p,
 		event:         evt,
 	}
+	if evt != nil {
+		pipeline.SetObserver(evt)
+	}
 	err = container.RunPipelineWithRetry(pipeline, args)
 	if err != nil {
 		log.Errorf("error on execute deploy pipeline for app %s - %s", app.GetName(), err)
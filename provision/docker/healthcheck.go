@@ -11,6 +11,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -109,3 +110,87 @@ func runHealthcheck(cont *container.Container, w io.Writer) error {
 		time.Sleep(sleepTime)
 	}
 }
+
+// runReadinessProbe waits for the readiness probe declared in tsuru.yaml for
+// the process of the container to succeed. It returns false, without probing,
+// when the process has no readiness probe.
+func (p *dockerProvisioner) runReadinessProbe(cont *container.Container, w io.Writer) (bool, error) {
+	yamlData, err := image.GetImageTsuruYamlData(cont.Image)
+	if err != nil {
+		return false, err
+	}
+	probe := yamlData.ProcessProbes(cont.ProcessName).Readiness
+	if probe == nil {
+		return false, nil
+	}
+	check := p.probeCheck(cont, probe)
+	if probe.InitialDelaySeconds > 0 {
+		time.Sleep(time.Duration(probe.InitialDelaySeconds) * time.Second)
+	}
+	sleepTime := 3 * time.Second
+	if probe.PeriodSeconds > 0 {
+		sleepTime = time.Duration(probe.PeriodSeconds) * time.Second
+	}
+	failureThreshold := probe.FailureThreshold
+	if failureThreshold == 0 {
+		failureThreshold = 3
+	}
+	for failures := 1; ; failures++ {
+		err = check()
+		if err == nil {
+			fmt.Fprintf(w, " ---> readiness probe successful(%s)\n", cont.ShortID())
+			return true, nil
+		}
+		err = errors.Wrapf(err, "readiness probe fail(%s)", cont.ShortID())
+		if failures >= failureThreshold {
+			return true, err
+		}
+		fmt.Fprintf(w, " ---> %s. Trying again in %s\n", err.Error(), sleepTime)
+		time.Sleep(sleepTime)
+	}
+}
+
+// probeCheck returns a function running the probe once. HTTP probes are
+// requested through the host port of the container, unless they target a port
+// other than the one exposed, in which case they're requested from inside the
+// container.
+func (p *dockerProvisioner) probeCheck(cont *container.Container, probe *provision.TsuruYamlProbe) func() error {
+	timeout := time.Duration(probe.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = time.Second
+	}
+	scheme := probe.Scheme
+	if scheme == "" {
+		scheme = provision.DefaultHealthcheckScheme
+	}
+	path := strings.TrimLeft(probe.Path, "/")
+	cmd := probe.Command
+	exposedPort := strings.TrimSuffix(cont.ExposedPort, "/tcp")
+	if len(cmd) == 0 && probe.Port != 0 && strconv.Itoa(probe.Port) != exposedPort {
+		url := fmt.Sprintf("%s://localhost:%d/%s", strings.ToLower(scheme), probe.Port, path)
+		cmd = []string{"curl", "-ksSf", "-o", "/dev/null", "-m", strconv.Itoa(int(timeout / time.Second)), url}
+	}
+	if len(cmd) > 0 {
+		return func() error {
+			return cont.Exec(p.ClusterClient(), nil, ioutil.Discard, ioutil.Discard, container.Pty{}, cmd...)
+		}
+	}
+	url := fmt.Sprintf("%s://%s:%s/%s", strings.ToLower(scheme), cont.HostAddr, cont.HostPort, path)
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		rsp, err := net.Dial15Full60ClientNoKeepAliveNoRedirectInsecure.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		rsp.Body.Close()
+		if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusBadRequest {
+			return errors.Errorf("wrong status code, expected 2xx or 3xx, got: %d", rsp.StatusCode)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/pkg/errors"
+	"github.com/tsuru/docker-cluster/cluster"
+	"github.com/tsuru/tsuru/net"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/docker/container"
+)
+
+const statsTimeout = 10 * time.Second
+
+var _ provision.MetricsProvisioner = &dockerProvisioner{}
+
+func (p *dockerProvisioner) UnitsMetrics(a provision.App, process string) ([]provision.UnitMetrics, error) {
+	containers, err := p.listContainersByProcess(a.GetName(), process)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := p.Cluster().UnfilteredNodes()
+	if err != nil {
+		return nil, err
+	}
+	nodeSet := map[string]*cluster.Node{}
+	for i := range nodes {
+		nodeSet[net.URLToHost(nodes[i].Address)] = &nodes[i]
+	}
+	var metrics []provision.UnitMetrics
+	for _, c := range containers {
+		if !c.Available() {
+			continue
+		}
+		n := nodeSet[c.HostAddr]
+		if n == nil {
+			return nil, errors.Errorf("node not found for container %q", c.ID)
+		}
+		m, err := containerMetrics(n, &c)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func containerMetrics(n *cluster.Node, c *container.Container) (provision.UnitMetrics, error) {
+	client, err := n.Client()
+	if err != nil {
+		return provision.UnitMetrics{}, err
+	}
+	statsCh := make(chan *docker.Stats, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.Stats(docker.StatsOptions{
+			ID:      c.ID,
+			Stats:   statsCh,
+			Stream:  false,
+			Timeout: statsTimeout,
+		})
+	}()
+	stats, ok := <-statsCh
+	err = <-errCh
+	if err != nil {
+		return provision.UnitMetrics{}, errors.Wrapf(err, "unable to get stats for container %q", c.ID)
+	}
+	if !ok || stats == nil {
+		return provision.UnitMetrics{}, errors.Errorf("no stats for container %q", c.ID)
+	}
+	memory := stats.MemoryStats.Usage
+	if cache := stats.MemoryStats.Stats.Cache; cache < memory {
+		memory -= cache
+	}
+	return provision.UnitMetrics{
+		ID:     c.ID,
+		CPU:    cpuPercent(stats),
+		Memory: int64(memory),
+	}, nil
+}
+
+// cpuPercent calculates the cpu usage of a container between the two samples
+// in stats, in percentage of one core, the same way as the docker cli.
+func cpuPercent(stats *docker.Stats) float64 {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemCPUUsage) - float64(stats.PreCPUStats.SystemCPUUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	return cpuDelta / systemDelta * onlineCPUs * 100
+}
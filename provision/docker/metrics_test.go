@@ -0,0 +1,71 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestUnitsMetrics(c *check.C) {
+	cont1, err := s.newContainer(&newContainerOpts{AppName: "myapp", ProcessName: "web", Status: provision.StatusStarted.String()}, nil)
+	c.Assert(err, check.IsNil)
+	defer s.removeTestContainer(cont1)
+	cont2, err := s.newContainer(&newContainerOpts{AppName: "myapp", ProcessName: "web", Status: provision.StatusStopped.String()}, nil)
+	c.Assert(err, check.IsNil)
+	defer s.removeTestContainer(cont2)
+	cont3, err := s.newContainer(&newContainerOpts{AppName: "myapp", ProcessName: "worker", Status: provision.StatusStarted.String()}, nil)
+	c.Assert(err, check.IsNil)
+	defer s.removeTestContainer(cont3)
+	s.server.PrepareStats(cont1.ID, func(string) docker.Stats {
+		var stats docker.Stats
+		stats.CPUStats.CPUUsage.TotalUsage = 300
+		stats.CPUStats.SystemCPUUsage = 2000
+		stats.CPUStats.OnlineCPUs = 2
+		stats.PreCPUStats.CPUUsage.TotalUsage = 100
+		stats.PreCPUStats.SystemCPUUsage = 1000
+		stats.MemoryStats.Usage = 5000
+		stats.MemoryStats.Stats.Cache = 1000
+		return stats
+	})
+	a := provisiontest.NewFakeApp("myapp", "python", 0)
+	metrics, err := s.p.UnitsMetrics(a, "web")
+	c.Assert(err, check.IsNil)
+	c.Assert(metrics, check.DeepEquals, []provision.UnitMetrics{
+		{ID: cont1.ID, CPU: 40, Memory: 4000},
+	})
+}
+
+func (s *S) TestUnitsMetricsNoContainers(c *check.C) {
+	a := provisiontest.NewFakeApp("myapp", "python", 0)
+	metrics, err := s.p.UnitsMetrics(a, "web")
+	c.Assert(err, check.IsNil)
+	c.Assert(metrics, check.HasLen, 0)
+}
+
+func (s *S) TestCPUPercent(c *check.C) {
+	tests := []struct {
+		total, preTotal, system, preSystem, online uint64
+		percpu                                     []uint64
+		expected                                   float64
+	}{
+		{total: 300, preTotal: 100, system: 2000, preSystem: 1000, online: 2, expected: 40},
+		{total: 300, preTotal: 100, system: 2000, preSystem: 1000, percpu: []uint64{1, 2, 3, 4}, expected: 80},
+		{total: 100, preTotal: 100, system: 2000, preSystem: 1000, online: 2, expected: 0},
+		{total: 300, preTotal: 100, system: 1000, preSystem: 1000, online: 2, expected: 0},
+	}
+	for _, tt := range tests {
+		var stats docker.Stats
+		stats.CPUStats.CPUUsage.TotalUsage = tt.total
+		stats.CPUStats.CPUUsage.PercpuUsage = tt.percpu
+		stats.CPUStats.SystemCPUUsage = tt.system
+		stats.CPUStats.OnlineCPUs = tt.online
+		stats.PreCPUStats.CPUUsage.TotalUsage = tt.preTotal
+		stats.PreCPUStats.SystemCPUUsage = tt.preSystem
+		c.Check(cpuPercent(&stats), check.Equals, tt.expected, check.Commentf("%#v", tt))
+	}
+}
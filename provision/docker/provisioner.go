@@ -42,6 +42,8 @@ import (
 	"github.com/tsuru/tsuru/provision/nodecontainer"
 	"github.com/tsuru/tsuru/queue"
 	_ "github.com/tsuru/tsuru/router/api"
+	_ "github.com/tsuru/tsuru/router/configfile"
+	_ "github.com/tsuru/tsuru/router/envoy"
 	_ "github.com/tsuru/tsuru/router/galeb"
 	_ "github.com/tsuru/tsuru/router/galebv2"
 	_ "github.com/tsuru/tsuru/router/hipache"
@@ -403,6 +405,10 @@ func (p *dockerProvisioner) deploy(a provision.App, imageID string, evt *event.E
 	if err := checkCanceled(evt); err != nil {
 		return err
 	}
+	err := validateTsuruYaml(imageID)
+	if err != nil {
+		return err
+	}
 	containers, err := p.listContainersByApp(a.GetName())
 	if err != nil {
 		return err
@@ -499,6 +505,26 @@ func (p *dockerProvisioner) Destroy(app provision.App) error {
 	return pipeline.Execute(args)
 }
 
+// validateTsuruYaml rejects the tsuru.yaml settings the docker provisioner is
+// not able to honour: liveness probes, as docker doesn't restart unhealthy
+// containers, and additional ports, as only the port of the process is
+// exposed.
+func validateTsuruYaml(imageID string) error {
+	yamlData, err := image.GetImageTsuruYamlData(imageID)
+	if err != nil {
+		return err
+	}
+	for process, probes := range yamlData.Probes {
+		if probes.Liveness != nil {
+			return &tsuruErrors.ValidationError{Message: fmt.Sprintf("liveness probe of process %q is not supported by the docker provisioner", process)}
+		}
+	}
+	if len(yamlData.Ports) > 0 {
+		return &tsuruErrors.ValidationError{Message: "ports are not supported by the docker provisioner"}
+	}
+	return nil
+}
+
 func (p *dockerProvisioner) runRestartAfterHooks(cont *container.Container, w io.Writer) error {
 	yamlData, err := image.GetImageTsuruYamlData(cont.Image)
 	if err != nil {
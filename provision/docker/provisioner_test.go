@@ -3214,3 +3214,35 @@ func (s *S) TestRebalanceNodesDry(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(containers, check.HasLen, 4)
 }
+
+func (s *S) TestValidateTsuruYaml(c *check.C) {
+	err := image.SaveImageCustomData("tsuru/app-myapp:v1", map[string]interface{}{
+		"processes": map[string]interface{}{"web": "python myapp.py"},
+		"probes": map[string]interface{}{
+			"web": map[string]interface{}{"readiness": map[string]interface{}{"path": "/ready"}},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = validateTsuruYaml("tsuru/app-myapp:v1")
+	c.Assert(err, check.IsNil)
+	err = image.SaveImageCustomData("tsuru/app-myapp:v2", map[string]interface{}{
+		"processes": map[string]interface{}{"web": "python myapp.py"},
+		"probes": map[string]interface{}{
+			"web": map[string]interface{}{"liveness": map[string]interface{}{"path": "/alive"}},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = validateTsuruYaml("tsuru/app-myapp:v2")
+	c.Assert(err, check.FitsTypeOf, &errors.ValidationError{})
+	c.Assert(err, check.ErrorMatches, `liveness probe of process "web" is not supported by the docker provisioner`)
+	err = image.SaveImageCustomData("tsuru/app-myapp:v3", map[string]interface{}{
+		"processes": map[string]interface{}{"web": "python myapp.py"},
+		"ports": map[string]interface{}{
+			"web": []interface{}{map[string]interface{}{"name": "http", "port": 8080}},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = validateTsuruYaml("tsuru/app-myapp:v3")
+	c.Assert(err, check.FitsTypeOf, &errors.ValidationError{})
+	c.Assert(err, check.ErrorMatches, `ports are not supported by the docker provisioner`)
+}
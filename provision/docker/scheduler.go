@@ -58,7 +58,7 @@ func (s *segregatedScheduler) Schedule(c *cluster.Cluster, opts *docker.CreateCo
 		return cluster.Node{}, &container.SchedulerError{Base: err}
 	}
 	nodes = filterNodes(nodes, filterNodesMap)
-	nodes, err = s.filterByMemoryUsage(a, nodes, s.maxMemoryRatio, s.TotalMemoryMetadata)
+	nodes, err = s.filterByMemoryUsage(a, schedOpts.ProcessName, nodes, s.maxMemoryRatio, s.TotalMemoryMetadata)
 	if err != nil {
 		return cluster.Node{}, &container.SchedulerError{Base: err}
 	}
@@ -111,7 +111,7 @@ func (s *segregatedScheduler) updateContainerName(opts *docker.CreateContainerOp
 	return nil
 }
 
-func (s *segregatedScheduler) filterByMemoryUsage(a *app.App, nodes []cluster.Node, maxMemoryRatio float32, TotalMemoryMetadata string) ([]cluster.Node, error) {
+func (s *segregatedScheduler) filterByMemoryUsage(a *app.App, process string, nodes []cluster.Node, maxMemoryRatio float32, TotalMemoryMetadata string) ([]cluster.Node, error) {
 	if maxMemoryRatio == 0 || TotalMemoryMetadata == "" {
 		return nodes, nil
 	}
@@ -129,8 +129,9 @@ func (s *segregatedScheduler) filterByMemoryUsage(a *app.App, nodes []cluster.No
 		if err != nil {
 			return nil, err
 		}
-		hostReserved[cont.HostAddr] += contApp.Plan.Memory
+		hostReserved[cont.HostAddr] += contApp.GetProcessMemory(cont.ProcessName)
 	}
+	memory := a.GetProcessMemory(process)
 	megabyte := float64(1024 * 1024)
 	nodeList := make([]cluster.Node, 0, len(nodes))
 	for _, node := range nodes {
@@ -139,10 +140,10 @@ func (s *segregatedScheduler) filterByMemoryUsage(a *app.App, nodes []cluster.No
 		if totalMemory != 0 {
 			maxMemory := totalMemory * float64(maxMemoryRatio)
 			host := net.URLToHost(node.Address)
-			nodeReserved := hostReserved[host] + a.Plan.Memory
+			nodeReserved := hostReserved[host] + memory
 			if nodeReserved > int64(maxMemory) {
 				shouldAdd = false
-				tryingToReserveMB := float64(a.Plan.Memory) / megabyte
+				tryingToReserveMB := float64(memory) / megabyte
 				reservedMB := float64(hostReserved[host]) / megabyte
 				limitMB := maxMemory / megabyte
 				log.Errorf("Node %q has reached its memory limit. "+
@@ -161,7 +162,7 @@ func (s *segregatedScheduler) filterByMemoryUsage(a *app.App, nodes []cluster.No
 			autoScaleEnabled = rule.Enabled
 		}
 		errMsg := fmt.Sprintf("no nodes found with enough memory for container of %q: %0.4fMB",
-			a.Name, float64(a.Plan.Memory)/megabyte)
+			a.Name, float64(memory)/megabyte)
 		if autoScaleEnabled {
 			// Allow going over quota temporarily because auto-scale will be
 			// able to detect this and automatically add a new nodes.
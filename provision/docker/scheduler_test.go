@@ -379,6 +379,58 @@ func (s *S) TestSchedulerScheduleWithMemoryAwareness(c *check.C) {
 	c.Assert(node, check.DeepEquals, cluster.Node{})
 }
 
+func (s *S) TestSchedulerScheduleWithMemoryAwarenessWithProcessPlan(c *check.C) {
+	logBuf := bytes.NewBuffer(nil)
+	log.SetLogger(log.NewWriterLogger(logBuf, false))
+	defer log.SetLogger(nil)
+	app1 := app.App{
+		Name:         "skyrim",
+		Plan:         appTypes.Plan{Memory: 10000},
+		ProcessPlans: map[string]appTypes.Plan{"worker": {Memory: 60000}},
+		Pool:         "mypool",
+	}
+	err := s.conn.Apps().Insert(app1)
+	c.Assert(err, check.IsNil)
+	segSched := segregatedScheduler{
+		maxMemoryRatio:      0.8,
+		TotalMemoryMetadata: "totalMemory",
+		provisioner:         s.p,
+	}
+	err = pool.AddPool(pool.AddPoolOptions{Name: "mypool"})
+	c.Assert(err, check.IsNil)
+	server1, err := testing.NewServer("127.0.0.1:0", nil, nil)
+	c.Assert(err, check.IsNil)
+	defer server1.Stop()
+	clusterInstance, err := cluster.New(&segSched, &cluster.MapStorage{}, "",
+		cluster.Node{Address: server1.URL(), Metadata: map[string]string{
+			"totalMemory": "100000",
+			"pool":        "mypool",
+		}},
+	)
+	c.Assert(err, check.Equals, nil)
+	s.p.cluster = clusterInstance
+	contColl := s.p.Collection()
+	defer contColl.Close()
+	err = contColl.Insert(container.Container{Container: types.Container{
+		ID: "pre1", Name: "existingUnit1", AppName: "skyrim", ProcessName: "worker", HostAddr: "127.0.0.1",
+	}})
+	c.Assert(err, check.IsNil)
+	cont := container.Container{Container: types.Container{ID: "web1", Name: "web1", AppName: "skyrim"}}
+	err = contColl.Insert(cont)
+	c.Assert(err, check.IsNil)
+	opts := docker.CreateContainerOptions{Name: cont.Name}
+	node, err := segSched.Schedule(clusterInstance, &opts, &container.SchedulerOpts{AppName: "skyrim", ProcessName: "web"})
+	c.Assert(err, check.IsNil)
+	c.Assert(node.Address, check.Equals, server1.URL())
+	cont = container.Container{Container: types.Container{ID: "worker1", Name: "worker1", AppName: "skyrim"}}
+	err = contColl.Insert(cont)
+	c.Assert(err, check.IsNil)
+	opts = docker.CreateContainerOptions{Name: cont.Name}
+	node, err = segSched.Schedule(clusterInstance, &opts, &container.SchedulerOpts{AppName: "skyrim", ProcessName: "worker"})
+	c.Assert(err, check.ErrorMatches, `.*no nodes found with enough memory for container of "skyrim": 0.0572MB.*`)
+	c.Assert(node, check.DeepEquals, cluster.Node{})
+}
+
 func (s *S) TestSchedulerScheduleWithMemoryAwarenessWithAutoScale(c *check.C) {
 	config.Set("docker:scheduler:total-memory-metadata", "memory")
 	defer config.Unset("docker:scheduler:total-memory-metadata")
@@ -0,0 +1,93 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockercommon
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/provision"
+)
+
+// ImageSBOM generates the software bill of materials of the image from the
+// package databases found in it.
+func ImageSBOM(client provision.BuilderDockerClient, imageName string) (*image.SBOM, error) {
+	cont, _, err := client.PullAndCreateContainer(docker.CreateContainerOptions{
+		Config: &docker.Config{Image: imageName},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer client.RemoveContainer(docker.RemoveContainerOptions{ID: cont.ID, Force: true})
+	var components []image.SBOMComponent
+	for _, path := range image.SBOMPackageDatabasePaths() {
+		data, err := readFileFromContainer(client, cont.ID, path)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			components = append(components, image.SBOMPackageDatabases[path](data)...)
+		}
+	}
+	return image.NewSBOM(imageName, components), nil
+}
+
+// ImageDigest returns the repository digest of the image, pulling it if
+// needed, falling back to its ID when the image was never pushed.
+func ImageDigest(client provision.BuilderDockerClient, imageName string) (string, error) {
+	img, err := client.InspectImage(imageName)
+	if err == docker.ErrNoSuchImage {
+		var cont *docker.Container
+		cont, _, err = client.PullAndCreateContainer(docker.CreateContainerOptions{
+			Config: &docker.Config{Image: imageName},
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+		client.RemoveContainer(docker.RemoveContainerOptions{ID: cont.ID, Force: true})
+		img, err = client.InspectImage(imageName)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(img.RepoDigests) > 0 {
+		return img.RepoDigests[0], nil
+	}
+	return img.ID, nil
+}
+
+// readFileFromContainer returns the content of the file in the container, or
+// nil if it doesn't exist.
+func readFileFromContainer(client provision.BuilderDockerClient, contID, path string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := client.DownloadFromContainer(contID, docker.DownloadFromContainerOptions{
+		OutputStream: &buf,
+		Path:         path,
+	})
+	if err != nil {
+		if e, ok := err.(*docker.Error); ok && e.Status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	reader := tar.NewReader(&buf)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg || header.Typeflag == tar.TypeRegA {
+			return ioutil.ReadAll(reader)
+		}
+	}
+}
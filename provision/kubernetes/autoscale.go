@@ -0,0 +1,105 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/provision"
+	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ provision.AutoScaleProvisioner = &kubernetesProvisioner{}
+
+func hpaNameForApp(a provision.App, process string) string {
+	return appProcessName(a, process)
+}
+
+func (p *kubernetesProvisioner) SetAutoScale(a provision.App, spec provision.AutoScaleSpec) error {
+	client, err := clusterForPool(a.GetPool())
+	if err != nil {
+		return err
+	}
+	ns, err := client.AppNamespace(a)
+	if err != nil {
+		return err
+	}
+	labelSet, err := provision.ServiceLabels(provision.ServiceLabelsOpts{
+		App:     a,
+		Process: spec.Process,
+		ServiceLabelExtendedOpts: provision.ServiceLabelExtendedOpts{
+			Prefix:      tsuruLabelPrefix,
+			Provisioner: provisionerName,
+		},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	labels, annotations := provision.SplitServiceLabelsAnnotations(labelSet)
+	metric := autoscalingv2beta1.MetricSpec{
+		Type:     autoscalingv2beta1.ResourceMetricSourceType,
+		Resource: &autoscalingv2beta1.ResourceMetricSource{},
+	}
+	if spec.CPU > 0 {
+		metric.Resource.Name = apiv1.ResourceCPU
+		metric.Resource.TargetAverageValue = resource.NewMilliQuantity(int64(spec.CPU)*10, resource.DecimalSI)
+	} else {
+		metric.Resource.Name = apiv1.ResourceMemory
+		metric.Resource.TargetAverageValue = resource.NewQuantity(spec.Memory, resource.BinarySI)
+	}
+	minReplicas := int32(spec.MinUnits)
+	hpaSpec := autoscalingv2beta1.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingv2beta1.CrossVersionObjectReference{
+			APIVersion: "apps/v1beta2",
+			Kind:       "Deployment",
+			Name:       deploymentNameForApp(a, spec.Process),
+		},
+		MinReplicas: &minReplicas,
+		MaxReplicas: int32(spec.MaxUnits),
+		Metrics:     []autoscalingv2beta1.MetricSpec{metric},
+	}
+	name := hpaNameForApp(a, spec.Process)
+	hpa, err := client.AutoscalingV2beta1().HorizontalPodAutoscalers(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return errors.WithStack(err)
+		}
+		hpa = &autoscalingv2beta1.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   ns,
+				Labels:      labels.ToLabels(),
+				Annotations: annotations.ToLabels(),
+			},
+			Spec: hpaSpec,
+		}
+		_, err = client.AutoscalingV2beta1().HorizontalPodAutoscalers(ns).Create(hpa)
+		return errors.WithStack(err)
+	}
+	hpa.Labels = labels.ToLabels()
+	hpa.Annotations = annotations.ToLabels()
+	hpa.Spec = hpaSpec
+	_, err = client.AutoscalingV2beta1().HorizontalPodAutoscalers(ns).Update(hpa)
+	return errors.WithStack(err)
+}
+
+func (p *kubernetesProvisioner) RemoveAutoScale(a provision.App, process string) error {
+	client, err := clusterForPool(a.GetPool())
+	if err != nil {
+		return err
+	}
+	ns, err := client.AppNamespace(a)
+	if err != nil {
+		return err
+	}
+	err = client.AutoscalingV2beta1().HorizontalPodAutoscalers(ns).Delete(hpaNameForApp(a, process), &metav1.DeleteOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
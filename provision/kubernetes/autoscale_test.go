@@ -0,0 +1,71 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	"gopkg.in/check.v1"
+	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (s *S) TestSetAutoScale(c *check.C) {
+	a := provisiontest.NewFakeApp("myapp", "python", 0)
+	err := s.p.Provision(a)
+	c.Assert(err, check.IsNil)
+	err = s.p.SetAutoScale(a, provision.AutoScaleSpec{Process: "web", MinUnits: 2, MaxUnits: 10, CPU: 70})
+	c.Assert(err, check.IsNil)
+	ns, err := s.client.AppNamespace(a)
+	c.Assert(err, check.IsNil)
+	hpa, err := s.client.AutoscalingV2beta1().HorizontalPodAutoscalers(ns).Get("myapp-web", metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(hpa.Labels["tsuru.io/app-name"], check.Equals, "myapp")
+	c.Assert(hpa.Labels["tsuru.io/app-process"], check.Equals, "web")
+	c.Assert(hpa.Spec.ScaleTargetRef, check.DeepEquals, autoscalingv2beta1.CrossVersionObjectReference{
+		APIVersion: "apps/v1beta2",
+		Kind:       "Deployment",
+		Name:       "myapp-web",
+	})
+	c.Assert(*hpa.Spec.MinReplicas, check.Equals, int32(2))
+	c.Assert(hpa.Spec.MaxReplicas, check.Equals, int32(10))
+	c.Assert(hpa.Spec.Metrics, check.HasLen, 1)
+	metric := hpa.Spec.Metrics[0]
+	c.Assert(metric.Type, check.Equals, autoscalingv2beta1.ResourceMetricSourceType)
+	c.Assert(metric.Resource.Name, check.Equals, apiv1.ResourceCPU)
+	c.Assert(metric.Resource.TargetAverageValue.Cmp(resource.MustParse("700m")), check.Equals, 0)
+	err = s.p.SetAutoScale(a, provision.AutoScaleSpec{Process: "web", MinUnits: 1, MaxUnits: 3, Memory: 256 * 1024 * 1024})
+	c.Assert(err, check.IsNil)
+	hpa, err = s.client.AutoscalingV2beta1().HorizontalPodAutoscalers(ns).Get("myapp-web", metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(*hpa.Spec.MinReplicas, check.Equals, int32(1))
+	c.Assert(hpa.Spec.MaxReplicas, check.Equals, int32(3))
+	c.Assert(hpa.Spec.Metrics, check.HasLen, 1)
+	metric = hpa.Spec.Metrics[0]
+	c.Assert(metric.Resource.Name, check.Equals, apiv1.ResourceMemory)
+	c.Assert(metric.Resource.TargetAverageValue.Cmp(resource.MustParse("256Mi")), check.Equals, 0)
+	hpaList, err := s.client.AutoscalingV2beta1().HorizontalPodAutoscalers(ns).List(metav1.ListOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(hpaList.Items, check.HasLen, 1)
+}
+
+func (s *S) TestRemoveAutoScale(c *check.C) {
+	a := provisiontest.NewFakeApp("myapp", "python", 0)
+	err := s.p.Provision(a)
+	c.Assert(err, check.IsNil)
+	err = s.p.SetAutoScale(a, provision.AutoScaleSpec{Process: "web", MinUnits: 1, MaxUnits: 5, CPU: 50})
+	c.Assert(err, check.IsNil)
+	err = s.p.RemoveAutoScale(a, "web")
+	c.Assert(err, check.IsNil)
+	ns, err := s.client.AppNamespace(a)
+	c.Assert(err, check.IsNil)
+	_, err = s.client.AutoscalingV2beta1().HorizontalPodAutoscalers(ns).Get("myapp-web", metav1.GetOptions{})
+	c.Assert(k8sErrors.IsNotFound(err), check.Equals, true)
+	err = s.p.RemoveAutoScale(a, "web")
+	c.Assert(err, check.IsNil)
+}
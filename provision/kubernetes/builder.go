@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
 	docker "github.com/fsouza/go-dockerclient"
 	"github.com/pkg/errors"
@@ -104,6 +105,35 @@ func (c *KubeClient) DownloadFromContainer(app provision.App, imageName string)
 	return reader, nil
 }
 
+// DownloadFilesFromImage returns a tar stream with the files in paths found in
+// the image, missing files are ignored.
+func (c *KubeClient) DownloadFilesFromImage(app provision.App, imageName string, paths []string) (io.ReadCloser, error) {
+	client, err := clusterForPool(app.GetPool())
+	if err != nil {
+		return nil, err
+	}
+	script := fmt.Sprintf(`files=""; for f in %s; do [ -f "$f" ] && files="$files $f"; done; [ -z "$files" ] || tar -cf - $files 2>/dev/null`, strings.Join(paths, " "))
+	reader, writer := io.Pipe()
+	stderr := &bytes.Buffer{}
+	go func() {
+		opts := execOpts{
+			client: client,
+			app:    app,
+			image:  imageName,
+			cmds:   []string{"/bin/sh", "-c", script},
+			stdout: writer,
+			stderr: stderr,
+		}
+		err := runIsolatedCmdPod(client, opts)
+		if err != nil {
+			writer.CloseWithError(errors.Wrapf(err, "error reading files, stderr: %q", stderr.String()))
+		} else {
+			writer.Close()
+		}
+	}()
+	return reader, nil
+}
+
 func (c *KubeClient) BuildImage(name string, image string, inputStream io.Reader, output io.Writer, ctx context.Context) error {
 	buildPodName := fmt.Sprintf("%s-image-build", name)
 	client, err := clusterForPoolOrAny("")
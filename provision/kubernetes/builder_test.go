@@ -367,3 +367,18 @@ func (s *S) TestDownloadFromContainer(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(archive, check.DeepEquals, expectedFile)
 }
+
+func (s *S) TestDownloadFilesFromImage(c *check.C) {
+	expectedFile := []byte("tar content")
+	s.mock.LogHook = func(w io.Writer, r *http.Request) {
+		w.Write(expectedFile)
+	}
+	a, _, rollback := s.mock.DefaultReactions(c)
+	defer rollback()
+	client := KubeClient{}
+	archiveReader, err := client.DownloadFilesFromImage(a, "tsuru/app-myapp:tag1", []string{"/var/lib/dpkg/status"})
+	c.Assert(err, check.IsNil)
+	archive, err := ioutil.ReadAll(archiveReader)
+	c.Assert(err, check.IsNil)
+	c.Assert(archive, check.DeepEquals, expectedFile)
+}
@@ -25,6 +25,8 @@ import (
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/app/image"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	tsuruIo "github.com/tsuru/tsuru/io"
 	"github.com/tsuru/tsuru/log"
 	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/provision/dockercommon"
@@ -384,6 +386,63 @@ type hcResult struct {
 	readiness *apiv1.Probe
 }
 
+// probesFromTsuruYaml returns the probes declared for a process in
+// tsuru.yaml, falling back to the probes in base for the ones not declared.
+func probesFromTsuruYaml(probes provision.TsuruYamlProbes, port int, base hcResult) hcResult {
+	if probes.Readiness != nil {
+		base.readiness = probeFromTsuruYaml(probes.Readiness, port)
+	}
+	if probes.Liveness != nil {
+		base.liveness = probeFromTsuruYaml(probes.Liveness, port)
+	}
+	return base
+}
+
+func probeFromTsuruYaml(p *provision.TsuruYamlProbe, port int) *apiv1.Probe {
+	probe := &apiv1.Probe{
+		InitialDelaySeconds: int32(p.InitialDelaySeconds),
+		PeriodSeconds:       int32(p.PeriodSeconds),
+		TimeoutSeconds:      int32(p.TimeoutSeconds),
+		FailureThreshold:    int32(p.FailureThreshold),
+	}
+	if len(p.Command) > 0 {
+		probe.Handler.Exec = &apiv1.ExecAction{Command: p.Command}
+		return probe
+	}
+	if p.Port != 0 {
+		port = p.Port
+	}
+	scheme := p.Scheme
+	if scheme == "" {
+		scheme = provision.DefaultHealthcheckScheme
+	}
+	probe.Handler.HTTPGet = &apiv1.HTTPGetAction{
+		Path:   "/" + strings.TrimPrefix(p.Path, "/"),
+		Port:   intstr.FromInt(port),
+		Scheme: apiv1.URIScheme(strings.ToUpper(scheme)),
+	}
+	return probe
+}
+
+// containerPortsForProcess returns the port of the process followed by the
+// ports declared for it in tsuru.yaml.
+func containerPortsForProcess(yamlData provision.TsuruYamlData, process string, port int) []apiv1.ContainerPort {
+	ports := []apiv1.ContainerPort{{ContainerPort: int32(port)}}
+	for _, p := range yamlData.Ports[process] {
+		if p.Port == port {
+			ports[0].Name = p.Name
+			ports[0].Protocol = apiv1.Protocol(strings.ToUpper(p.Protocol))
+			continue
+		}
+		ports = append(ports, apiv1.ContainerPort{
+			Name:          p.Name,
+			ContainerPort: int32(p.Port),
+			Protocol:      apiv1.Protocol(strings.ToUpper(p.Protocol)),
+		})
+	}
+	return ports
+}
+
 func probesFromHC(hc provision.TsuruYamlHealthcheck, port int) (hcResult, error) {
 	var result hcResult
 	if hc.Path == "" {
@@ -537,6 +596,7 @@ func createAppDeployment(client *ClusterClient, oldDeployment *v1beta2.Deploymen
 			return nil, nil, nil, err
 		}
 	}
+	hcData = probesFromTsuruYaml(yamlData.ProcessProbes(process), portInt, hcData)
 	var lifecycle *apiv1.Lifecycle
 	if len(yamlData.Hooks.Restart.After) > 0 {
 		hookCmds := []string{
@@ -551,6 +611,24 @@ func createAppDeployment(client *ClusterClient, oldDeployment *v1beta2.Deploymen
 			},
 		}
 	}
+	if len(yamlData.Hooks.PreStop) > 0 {
+		if lifecycle == nil {
+			lifecycle = &apiv1.Lifecycle{}
+		}
+		lifecycle.PreStop = &apiv1.Handler{
+			Exec: &apiv1.ExecAction{
+				Command: []string{
+					"sh", "-c",
+					strings.Join(yamlData.Hooks.PreStop, " && "),
+				},
+			},
+		}
+	}
+	var gracePeriod *int64
+	if yamlData.Hooks.GracePeriodSeconds > 0 {
+		seconds := int64(yamlData.Hooks.GracePeriodSeconds)
+		gracePeriod = &seconds
+	}
 	maxSurge := intstr.FromString("100%")
 	maxUnavailable := intstr.FromInt(0)
 	nodeSelector := provision.NodeLabels(provision.NodeLabelsOpts{
@@ -564,7 +642,7 @@ func createAppDeployment(client *ClusterClient, oldDeployment *v1beta2.Deploymen
 		return nil, nil, nil, errors.WithMessage(err, "misconfigured cluster overcommit factor")
 	}
 	resourceRequests := apiv1.ResourceList{}
-	memory := a.GetMemory()
+	memory := a.GetProcessMemory(process)
 	if memory != 0 {
 		resourceLimits[apiv1.ResourceMemory] = *resource.NewQuantity(memory, resource.BinarySI)
 		resourceRequests[apiv1.ResourceMemory] = *resource.NewQuantity(memory/overcommit, resource.BinarySI)
@@ -621,10 +699,11 @@ func createAppDeployment(client *ClusterClient, oldDeployment *v1beta2.Deploymen
 					SecurityContext: &apiv1.PodSecurityContext{
 						RunAsUser: uid,
 					},
-					RestartPolicy: apiv1.RestartPolicyAlways,
-					NodeSelector:  nodeSelector,
-					Volumes:       volumes,
-					Subdomain:     headlessServiceNameForApp(a, process),
+					RestartPolicy:                 apiv1.RestartPolicyAlways,
+					NodeSelector:                  nodeSelector,
+					Volumes:                       volumes,
+					Subdomain:                     headlessServiceNameForApp(a, process),
+					TerminationGracePeriodSeconds: gracePeriod,
 					Containers: []apiv1.Container{
 						{
 							Name:           depName,
@@ -638,10 +717,8 @@ func createAppDeployment(client *ClusterClient, oldDeployment *v1beta2.Deploymen
 								Requests: resourceRequests,
 							},
 							VolumeMounts: mounts,
-							Ports: []apiv1.ContainerPort{
-								{ContainerPort: int32(portInt)},
-							},
-							Lifecycle: lifecycle,
+							Ports:        containerPortsForProcess(yamlData, process, portInt),
+							Lifecycle:    lifecycle,
 						},
 					},
 				},
@@ -851,6 +928,16 @@ func monitorDeployment(ctx context.Context, client *ClusterClient, dep *v1beta2.
 		readyUnits := dep.Status.UpdatedReplicas - dep.Status.UnavailableReplicas
 		if oldReadyUnits != readyUnits && readyUnits >= 0 {
 			fmt.Fprintf(w, " ---> %d of %d new units ready\n", readyUnits, specReplicas)
+			if evt, ok := w.(*event.Event); ok {
+				evt.Progress(tsuruIo.ProgressMessage{
+					Type:    tsuruIo.ProgressProgress,
+					Phase:   "provisioner",
+					Step:    "update-units",
+					Process: processName,
+					Current: int(readyUnits),
+					Total:   int(specReplicas),
+				})
+			}
 		}
 		pendingTermination := dep.Status.Replicas - dep.Status.UpdatedReplicas
 		if oldPendingTermination != pendingTermination && pendingTermination > 0 {
@@ -818,6 +818,91 @@ func (s *S) TestServiceManagerDeployServiceWithRestartHooks(c *check.C) {
 	c.Assert(cmd[2], check.Matches, `.*before cmd1 && before cmd2 && exec proc2$`)
 }
 
+func (s *S) TestServiceManagerDeployServiceWithProbesPortsAndPreStop(c *check.C) {
+	waitDep := s.mock.DeploymentReactions(c)
+	defer waitDep()
+	m := serviceManager{client: s.clusterClient}
+	a := &app.App{Name: "myapp", TeamOwner: s.team.Name}
+	err := app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.SaveImageCustomData("myimg", map[string]interface{}{
+		"processes": map[string]interface{}{
+			"web":    "proc1",
+			"worker": "proc2",
+		},
+		"hooks": provision.TsuruYamlHooks{
+			PreStop:            []string{"drain", "sleep 5"},
+			GracePeriodSeconds: 60,
+		},
+		"healthcheck": provision.TsuruYamlHealthcheck{
+			Path:        "/hc",
+			UseInRouter: true,
+		},
+		"probes": map[string]provision.TsuruYamlProbes{
+			"web": {
+				Liveness: &provision.TsuruYamlProbe{Path: "/alive", PeriodSeconds: 5},
+			},
+			"worker": {
+				Readiness: &provision.TsuruYamlProbe{Command: []string{"ready"}, FailureThreshold: 2},
+			},
+		},
+		"ports": map[string][]provision.TsuruYamlPort{
+			"web": {
+				{Name: "http", Port: 8888},
+				{Name: "metrics", Port: 9100, Protocol: "tcp"},
+			},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = servicecommon.RunServicePipeline(&m, a, "myimg", servicecommon.ProcessSpec{
+		"web":    servicecommon.ProcessState{Start: true},
+		"worker": servicecommon.ProcessState{Start: true},
+	}, nil)
+	c.Assert(err, check.IsNil)
+	waitDep()
+	ns, err := s.client.AppNamespace(a)
+	c.Assert(err, check.IsNil)
+	expectedLifecycle := &apiv1.Lifecycle{
+		PreStop: &apiv1.Handler{
+			Exec: &apiv1.ExecAction{
+				Command: []string{"sh", "-c", "drain && sleep 5"},
+			},
+		},
+	}
+	dep, err := s.client.Clientset.AppsV1beta2().Deployments(ns).Get("myapp-web", metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	podSpec := dep.Spec.Template.Spec
+	c.Assert(*podSpec.TerminationGracePeriodSeconds, check.Equals, int64(60))
+	c.Assert(podSpec.Containers[0].Lifecycle, check.DeepEquals, expectedLifecycle)
+	c.Assert(podSpec.Containers[0].Ports, check.DeepEquals, []apiv1.ContainerPort{
+		{Name: "http", ContainerPort: 8888},
+		{Name: "metrics", ContainerPort: 9100, Protocol: apiv1.ProtocolTCP},
+	})
+	c.Assert(podSpec.Containers[0].ReadinessProbe.HTTPGet.Path, check.Equals, "/hc")
+	c.Assert(podSpec.Containers[0].LivenessProbe, check.DeepEquals, &apiv1.Probe{
+		PeriodSeconds: 5,
+		Handler: apiv1.Handler{
+			HTTPGet: &apiv1.HTTPGetAction{
+				Path:   "/alive",
+				Port:   intstr.FromInt(8888),
+				Scheme: apiv1.URISchemeHTTP,
+			},
+		},
+	})
+	dep, err = s.client.Clientset.AppsV1beta2().Deployments(ns).Get("myapp-worker", metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	podSpec = dep.Spec.Template.Spec
+	c.Assert(podSpec.Containers[0].Lifecycle, check.DeepEquals, expectedLifecycle)
+	c.Assert(podSpec.Containers[0].Ports, check.DeepEquals, []apiv1.ContainerPort{{ContainerPort: 8888}})
+	c.Assert(podSpec.Containers[0].LivenessProbe, check.IsNil)
+	c.Assert(podSpec.Containers[0].ReadinessProbe, check.DeepEquals, &apiv1.Probe{
+		FailureThreshold: 2,
+		Handler: apiv1.Handler{
+			Exec: &apiv1.ExecAction{Command: []string{"ready"}},
+		},
+	})
+}
+
 func (s *S) TestServiceManagerDeployServiceWithRegistryAuth(c *check.C) {
 	config.Set("docker:registry", "myreg.com")
 	config.Set("docker:registry-auth:username", "user")
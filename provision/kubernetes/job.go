@@ -0,0 +1,275 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/provision"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// cronJobNameMaxLen is shorter than other names because kubernetes
+	// appends a suffix to the name of each job created by a cron job.
+	cronJobNameMaxLen = 52
+	// jobsHistoryLimit must be large enough to keep runs finished while
+	// tsuru is not able to record them.
+	jobsHistoryLimit = 10
+)
+
+var _ provision.JobProvisioner = &kubernetesProvisioner{}
+
+func cronJobNameForApp(a provision.App, jobName string) string {
+	name := validKubeName(a.GetName())
+	jobName = validKubeName(jobName)
+	cronJobName := fmt.Sprintf("%s-job-%s", name, jobName)
+	if len(cronJobName) > cronJobNameMaxLen {
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(jobName)))
+		maxLen := cronJobNameMaxLen - len(name) - len("-job-")
+		if maxLen > 0 && len(hash) > maxLen {
+			hash = hash[:maxLen]
+		}
+		cronJobName = fmt.Sprintf("%s-job-%s", name, hash)
+	}
+	return cronJobName
+}
+
+func jobLabels(a provision.App, job provision.Job) (*provision.LabelSet, error) {
+	return provision.ServiceLabels(provision.ServiceLabelsOpts{
+		App: a,
+		ServiceLabelExtendedOpts: provision.ServiceLabelExtendedOpts{
+			Prefix:        tsuruLabelPrefix,
+			Provisioner:   provisionerName,
+			IsIsolatedRun: true,
+			JobName:       job.Name,
+		},
+	})
+}
+
+func (p *kubernetesProvisioner) EnsureJob(a provision.App, job provision.Job) error {
+	client, err := clusterForPool(a.GetPool())
+	if err != nil {
+		return err
+	}
+	err = ensureNamespaceForApp(client, a)
+	if err != nil {
+		return err
+	}
+	err = ensureServiceAccountForApp(client, a)
+	if err != nil {
+		return err
+	}
+	ns, err := client.AppNamespace(a)
+	if err != nil {
+		return err
+	}
+	imageName, err := image.AppCurrentImageName(a.GetName())
+	if err != nil {
+		return err
+	}
+	pullSecrets, err := getImagePullSecrets(client, ns, imageName)
+	if err != nil {
+		return err
+	}
+	labelSet, err := jobLabels(a, job)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	jobLabelSet, annotations := provision.SplitServiceLabelsAnnotations(labelSet)
+	nodeSelector := provision.NodeLabels(provision.NodeLabelsOpts{
+		Pool:   a.GetPool(),
+		Prefix: tsuruLabelPrefix,
+	}).ToNodeByPoolSelector()
+	var envs []apiv1.EnvVar
+	for _, envData := range provision.EnvsForApp(a, "", false) {
+		envs = append(envs, apiv1.EnvVar{Name: envData.Name, Value: envData.Value})
+	}
+	name := cronJobNameForApp(a, job.Name)
+	backoffLimit := int32(0)
+	historyLimit := int32(jobsHistoryLimit)
+	spec := batchv1beta1.CronJobSpec{
+		Schedule:                   job.Schedule,
+		ConcurrencyPolicy:          batchv1beta1.ForbidConcurrent,
+		SuccessfulJobsHistoryLimit: &historyLimit,
+		FailedJobsHistoryLimit:     &historyLimit,
+		JobTemplate: batchv1beta1.JobTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      jobLabelSet.ToLabels(),
+				Annotations: annotations.ToLabels(),
+			},
+			Spec: batchv1.JobSpec{
+				BackoffLimit: &backoffLimit,
+				Template: apiv1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels:      jobLabelSet.ToLabels(),
+						Annotations: annotations.ToLabels(),
+					},
+					Spec: apiv1.PodSpec{
+						ImagePullSecrets:   pullSecrets,
+						ServiceAccountName: serviceAccountNameForApp(a),
+						NodeSelector:       nodeSelector,
+						RestartPolicy:      apiv1.RestartPolicyNever,
+						Containers: []apiv1.Container{
+							{
+								Name:    name,
+								Image:   imageName,
+								Command: job.Cmds,
+								Env:     envs,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	cronJob, err := client.BatchV1beta1().CronJobs(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return errors.WithStack(err)
+		}
+		cronJob = &batchv1beta1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   ns,
+				Labels:      jobLabelSet.ToLabels(),
+				Annotations: annotations.ToLabels(),
+			},
+			Spec: spec,
+		}
+		_, err = client.BatchV1beta1().CronJobs(ns).Create(cronJob)
+		return errors.WithStack(err)
+	}
+	cronJob.Labels = jobLabelSet.ToLabels()
+	cronJob.Annotations = annotations.ToLabels()
+	cronJob.Spec = spec
+	_, err = client.BatchV1beta1().CronJobs(ns).Update(cronJob)
+	return errors.WithStack(err)
+}
+
+func (p *kubernetesProvisioner) RemoveJob(a provision.App, job provision.Job) error {
+	client, err := clusterForPool(a.GetPool())
+	if err != nil {
+		return err
+	}
+	ns, err := client.AppNamespace(a)
+	if err != nil {
+		return err
+	}
+	err = client.BatchV1beta1().CronJobs(ns).Delete(cronJobNameForApp(a, job.Name), &metav1.DeleteOptions{
+		PropagationPolicy: propagationPtr(metav1.DeletePropagationForeground),
+	})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// JobRuns returns the finished kubernetes jobs created by the cron job,
+// removing them afterwards so that each run is returned only once.
+func (p *kubernetesProvisioner) JobRuns(a provision.App, job provision.Job) ([]provision.JobRun, error) {
+	client, err := clusterForPool(a.GetPool())
+	if err != nil {
+		return nil, err
+	}
+	ns, err := client.AppNamespace(a)
+	if err != nil {
+		return nil, err
+	}
+	labelSet, err := jobLabels(a, job)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	jobList, err := client.BatchV1().Jobs(ns).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set(labelSet.ToJobSelector())).String(),
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var runs []provision.JobRun
+	for _, kubeJob := range jobList.Items {
+		run, finished := jobRunFromJob(&kubeJob)
+		if !finished {
+			continue
+		}
+		run.Output = jobOutput(client, ns, &kubeJob)
+		err = client.BatchV1().Jobs(ns).Delete(kubeJob.Name, &metav1.DeleteOptions{
+			PropagationPolicy: propagationPtr(metav1.DeletePropagationForeground),
+		})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			log.Errorf("[kubernetes] unable to remove finished job %q, it will be processed again: %v", kubeJob.Name, err)
+			continue
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartTime.Before(runs[j].StartTime)
+	})
+	return runs, nil
+}
+
+func jobRunFromJob(kubeJob *batchv1.Job) (provision.JobRun, bool) {
+	run := provision.JobRun{Name: kubeJob.Name}
+	if kubeJob.Status.StartTime != nil {
+		run.StartTime = kubeJob.Status.StartTime.Time
+	}
+	for _, cond := range kubeJob.Status.Conditions {
+		if cond.Status != apiv1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			run.Succeeded = true
+		case batchv1.JobFailed:
+		default:
+			continue
+		}
+		run.EndTime = cond.LastTransitionTime.Time
+		if kubeJob.Status.CompletionTime != nil {
+			run.EndTime = kubeJob.Status.CompletionTime.Time
+		}
+		return run, true
+	}
+	return run, false
+}
+
+func jobOutput(client *ClusterClient, ns string, kubeJob *batchv1.Job) string {
+	var buf bytes.Buffer
+	selector, err := metav1.LabelSelectorAsSelector(kubeJob.Spec.Selector)
+	if err != nil {
+		fmt.Fprintf(&buf, "unable to get job pods: %v\n", err)
+		return buf.String()
+	}
+	pods, err := client.CoreV1().Pods(ns).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		fmt.Fprintf(&buf, "unable to get job pods: %v\n", err)
+		return buf.String()
+	}
+	for _, pod := range pods.Items {
+		stream, err := client.CoreV1().Pods(ns).GetLogs(pod.Name, &apiv1.PodLogOptions{}).Stream()
+		if err != nil {
+			fmt.Fprintf(&buf, "unable to get logs for pod %q: %v\n", pod.Name, err)
+			continue
+		}
+		_, err = io.Copy(&buf, stream)
+		stream.Close()
+		if err != nil {
+			fmt.Fprintf(&buf, "unable to read logs for pod %q: %v\n", pod.Name, err)
+		}
+	}
+	return buf.String()
+}
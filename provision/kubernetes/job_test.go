@@ -0,0 +1,151 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	"gopkg.in/check.v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (s *S) newJobApp(c *check.C) *provisiontest.FakeApp {
+	a := provisiontest.NewFakeApp("myapp", "python", 0)
+	err := s.p.Provision(a)
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(a.GetName(), "tsuru/app-myapp:v1")
+	c.Assert(err, check.IsNil)
+	return a
+}
+
+func (s *S) createJob(c *check.C, a provision.App, job provision.Job, name string, start time.Time, cond *batchv1.JobCondition) {
+	ns, err := s.client.AppNamespace(a)
+	c.Assert(err, check.IsNil)
+	labelSet, err := jobLabels(a, job)
+	c.Assert(err, check.IsNil)
+	kubeJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    labelSet.ToLabels(),
+		},
+		Status: batchv1.JobStatus{
+			StartTime: &metav1.Time{Time: start},
+		},
+	}
+	if cond != nil {
+		kubeJob.Status.Conditions = []batchv1.JobCondition{*cond}
+	}
+	_, err = s.client.BatchV1().Jobs(ns).Create(kubeJob)
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestCronJobNameForApp(c *check.C) {
+	a := provisiontest.NewFakeApp("myapp", "python", 0)
+	c.Assert(cronJobNameForApp(a, "cleanup"), check.Equals, "myapp-job-cleanup")
+	name := cronJobNameForApp(a, strings.Repeat("x", 60))
+	c.Assert(len(name) <= cronJobNameMaxLen, check.Equals, true)
+	c.Assert(strings.HasPrefix(name, "myapp-job-"), check.Equals, true)
+	c.Assert(cronJobNameForApp(a, strings.Repeat("y", 60)), check.Not(check.Equals), name)
+}
+
+func (s *S) TestEnsureJob(c *check.C) {
+	a := s.newJobApp(c)
+	job := provision.Job{Name: "cleanup", Schedule: "0 * * * *", Cmds: []string{"/bin/sh", "-c", "./cleanup.sh"}}
+	err := s.p.EnsureJob(a, job)
+	c.Assert(err, check.IsNil)
+	ns, err := s.client.AppNamespace(a)
+	c.Assert(err, check.IsNil)
+	cronJob, err := s.client.BatchV1beta1().CronJobs(ns).Get("myapp-job-cleanup", metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(cronJob.Spec.Schedule, check.Equals, "0 * * * *")
+	c.Assert(cronJob.Spec.ConcurrencyPolicy, check.Equals, batchv1beta1.ForbidConcurrent)
+	c.Assert(*cronJob.Spec.SuccessfulJobsHistoryLimit, check.Equals, int32(jobsHistoryLimit))
+	c.Assert(cronJob.Labels["tsuru.io/job-name"], check.Equals, "cleanup")
+	c.Assert(cronJob.Labels["tsuru.io/app-name"], check.Equals, "myapp")
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	c.Assert(podSpec.RestartPolicy, check.Equals, apiv1.RestartPolicyNever)
+	c.Assert(podSpec.ServiceAccountName, check.Equals, "app-myapp")
+	c.Assert(podSpec.Containers, check.HasLen, 1)
+	c.Assert(podSpec.Containers[0].Image, check.Equals, "tsuru/app-myapp:v1")
+	c.Assert(podSpec.Containers[0].Command, check.DeepEquals, job.Cmds)
+	err = image.AppendAppImageName(a.GetName(), "tsuru/app-myapp:v2")
+	c.Assert(err, check.IsNil)
+	job.Schedule = "*/5 * * * *"
+	err = s.p.EnsureJob(a, job)
+	c.Assert(err, check.IsNil)
+	cronJob, err = s.client.BatchV1beta1().CronJobs(ns).Get("myapp-job-cleanup", metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(cronJob.Spec.Schedule, check.Equals, "*/5 * * * *")
+	c.Assert(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image, check.Equals, "tsuru/app-myapp:v2")
+}
+
+func (s *S) TestRemoveJob(c *check.C) {
+	a := s.newJobApp(c)
+	job := provision.Job{Name: "cleanup", Schedule: "0 * * * *", Cmds: []string{"./cleanup.sh"}}
+	err := s.p.EnsureJob(a, job)
+	c.Assert(err, check.IsNil)
+	err = s.p.RemoveJob(a, job)
+	c.Assert(err, check.IsNil)
+	ns, err := s.client.AppNamespace(a)
+	c.Assert(err, check.IsNil)
+	_, err = s.client.BatchV1beta1().CronJobs(ns).Get("myapp-job-cleanup", metav1.GetOptions{})
+	c.Assert(k8sErrors.IsNotFound(err), check.Equals, true)
+	err = s.p.RemoveJob(a, job)
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestJobRuns(c *check.C) {
+	a := s.newJobApp(c)
+	job := provision.Job{Name: "cleanup", Schedule: "0 * * * *", Cmds: []string{"./cleanup.sh"}}
+	otherJob := provision.Job{Name: "other", Schedule: "0 * * * *", Cmds: []string{"./other.sh"}}
+	start := time.Date(2018, time.May, 15, 10, 0, 0, 0, time.UTC)
+	s.createJob(c, a, job, "myapp-job-cleanup-2", start.Add(time.Hour), &batchv1.JobCondition{
+		Type:               batchv1.JobFailed,
+		Status:             apiv1.ConditionTrue,
+		LastTransitionTime: metav1.Time{Time: start.Add(time.Hour + time.Minute)},
+	})
+	s.createJob(c, a, job, "myapp-job-cleanup-1", start, &batchv1.JobCondition{
+		Type:               batchv1.JobComplete,
+		Status:             apiv1.ConditionTrue,
+		LastTransitionTime: metav1.Time{Time: start.Add(time.Minute)},
+	})
+	s.createJob(c, a, job, "myapp-job-cleanup-3", start.Add(2*time.Hour), nil)
+	s.createJob(c, a, otherJob, "myapp-job-other-1", start, &batchv1.JobCondition{
+		Type:   batchv1.JobComplete,
+		Status: apiv1.ConditionTrue,
+	})
+	runs, err := s.p.JobRuns(a, job)
+	c.Assert(err, check.IsNil)
+	c.Assert(runs, check.HasLen, 2)
+	c.Assert(runs[0].Name, check.Equals, "myapp-job-cleanup-1")
+	c.Assert(runs[0].Succeeded, check.Equals, true)
+	c.Assert(runs[0].StartTime.Equal(start), check.Equals, true)
+	c.Assert(runs[0].EndTime.Equal(start.Add(time.Minute)), check.Equals, true)
+	c.Assert(runs[1].Name, check.Equals, "myapp-job-cleanup-2")
+	c.Assert(runs[1].Succeeded, check.Equals, false)
+	ns, err := s.client.AppNamespace(a)
+	c.Assert(err, check.IsNil)
+	jobList, err := s.client.BatchV1().Jobs(ns).List(metav1.ListOptions{})
+	c.Assert(err, check.IsNil)
+	var remaining []string
+	for _, j := range jobList.Items {
+		remaining = append(remaining, j.Name)
+	}
+	sort.Strings(remaining)
+	c.Assert(remaining, check.DeepEquals, []string{"myapp-job-cleanup-3", "myapp-job-other-1"})
+	runs, err = s.p.JobRuns(a, job)
+	c.Assert(err, check.IsNil)
+	c.Assert(runs, check.HasLen, 0)
+}
@@ -30,6 +30,8 @@ var (
 	LabelAppPool            = "app-pool"
 	labelAppPlatform        = "app-platform"
 
+	labelJobName = "job-name"
+
 	labelNodeContainerName = "node-container-name"
 	labelNodeContainerPool = "node-container-pool"
 
@@ -72,6 +74,10 @@ func (s *LabelSet) ToAppSelector() map[string]string {
 	return withPrefix(subMap(s.Labels, labelAppName), s.Prefix)
 }
 
+func (s *LabelSet) ToJobSelector() map[string]string {
+	return withPrefix(subMap(s.Labels, labelAppName, labelJobName), s.Prefix)
+}
+
 func (s *LabelSet) ToNodeContainerSelector() map[string]string {
 	return withPrefix(subMap(s.Labels, labelNodeContainerName, labelNodeContainerPool), s.Prefix)
 }
@@ -207,6 +213,10 @@ func (s *LabelSet) IsIsolatedRun() bool {
 	return s.getBoolLabel(labelIsIsolatedRun)
 }
 
+func (s *LabelSet) JobName() string {
+	return s.getLabel(labelJobName)
+}
+
 func (s *LabelSet) SetRestarts(count int) {
 	s.addLabel(labelRestarts, strconv.Itoa(count))
 }
@@ -268,6 +278,7 @@ type ServiceLabelExtendedOpts struct {
 	IsIsolatedRun bool
 	IsBuild       bool
 	Builder       string
+	JobName       string
 }
 
 func ExtendServiceLabels(set *LabelSet, opts ServiceLabelExtendedOpts) {
@@ -281,6 +292,9 @@ func ExtendServiceLabels(set *LabelSet, opts ServiceLabelExtendedOpts) {
 	set.Labels[labelIsIsolatedRun] = strconv.FormatBool(opts.IsIsolatedRun)
 	set.Labels[labelIsBuild] = strconv.FormatBool(opts.IsBuild)
 	set.Labels[labelBuilder] = opts.Builder
+	if opts.JobName != "" {
+		set.Labels[labelJobName] = opts.JobName
+	}
 }
 
 func ServiceLabels(opts ServiceLabelsOpts) (*LabelSet, error) {
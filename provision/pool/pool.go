@@ -35,10 +35,15 @@ var (
 	ErrPoolHasNoPlan                  = errors.New("no plan found for pool")
 )
 
+// ProductionLabel is the pool label marking pools whose apps require deploy
+// approvals.
+const ProductionLabel = "production"
+
 type Pool struct {
 	Name        string `bson:"_id"`
 	Default     bool
 	Provisioner string
+	Labels      map[string]string `bson:",omitempty"`
 }
 
 type AddPoolOptions struct {
@@ -47,12 +52,23 @@ type AddPoolOptions struct {
 	Default     bool
 	Force       bool
 	Provisioner string
+	Labels      map[string]string
 }
 
 type UpdatePoolOptions struct {
 	Default *bool
 	Public  *bool
 	Force   bool
+	// Labels are merged into the current labels of the pool, labels with
+	// an empty value are removed.
+	Labels map[string]string
+}
+
+// RequiresDeployApproval returns whether deploys to apps in the pool must be
+// approved by a second user, which is the case for pools labeled as
+// production.
+func (p *Pool) RequiresDeployApproval() bool {
+	return p.Labels[ProductionLabel] == "true"
 }
 
 func (p *Pool) GetProvisioner() (provision.Provisioner, error) {
@@ -259,6 +275,7 @@ func (p *Pool) MarshalJSON() ([]byte, error) {
 	result["public"] = teams.AllowsAll()
 	result["default"] = p.Default
 	result["provisioner"] = p.Provisioner
+	result["labels"] = p.Labels
 	result["teams"] = resolvedConstraints[ConstraintTypeTeam]
 	result["allowed"] = resolvedConstraints
 	return json.Marshal(&result)
@@ -274,11 +291,30 @@ func (p *Pool) validate() error {
 			"starting with a letter."
 		return &tsuruErrors.ValidationError{Message: msg}
 	}
+	return validateLabels(p.Labels)
+}
+
+func validateLabels(labels map[string]string) error {
+	for k := range labels {
+		if k == "" || strings.ContainsAny(k, ".$") {
+			return &tsuruErrors.ValidationError{Message: fmt.Sprintf("invalid pool label %q", k)}
+		}
+	}
 	return nil
 }
 
 func AddPool(opts AddPoolOptions) error {
-	pool := Pool{Name: opts.Name, Default: opts.Default, Provisioner: opts.Provisioner}
+	var labels map[string]string
+	for k, v := range opts.Labels {
+		if v == "" {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[k] = v
+	}
+	pool := Pool{Name: opts.Name, Default: opts.Default, Provisioner: opts.Provisioner, Labels: labels}
 	if err := pool.validate(); err != nil {
 		return err
 	}
@@ -507,6 +543,10 @@ func PoolUpdate(name string, opts UpdatePoolOptions) error {
 	if err != nil {
 		return err
 	}
+	err = validateLabels(opts.Labels)
+	if err != nil {
+		return err
+	}
 	if opts.Default != nil && *opts.Default {
 		err = changeDefaultPool(opts.Force)
 		if err != nil {
@@ -517,6 +557,14 @@ func PoolUpdate(name string, opts UpdatePoolOptions) error {
 	if opts.Default != nil {
 		query["default"] = *opts.Default
 	}
+	unset := bson.M{}
+	for k, v := range opts.Labels {
+		if v == "" {
+			unset["labels."+k] = ""
+		} else {
+			query["labels."+k] = v
+		}
+	}
 	if (opts.Public != nil && *opts.Public) || (opts.Default != nil && *opts.Default) {
 		errConstraint := SetPoolConstraint(&PoolConstraint{PoolExpr: name, Field: ConstraintTypeTeam, Values: []string{"*"}})
 		if errConstraint != nil {
@@ -529,10 +577,17 @@ func PoolUpdate(name string, opts UpdatePoolOptions) error {
 			return err
 		}
 	}
-	if len(query) == 0 {
+	update := bson.M{}
+	if len(query) > 0 {
+		update["$set"] = query
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if len(update) == 0 {
 		return nil
 	}
-	err = conn.Pools().UpdateId(name, bson.M{"$set": query})
+	err = conn.Pools().UpdateId(name, update)
 	if err == mgo.ErrNotFound {
 		return ErrPoolNotFound
 	}
@@ -381,6 +381,29 @@ func (s *S) TestPoolUpdate(c *check.C) {
 	c.Assert(constraint.AllowsAll(), check.Equals, true)
 }
 
+func (s *S) TestPoolUpdateLabels(c *check.C) {
+	err := AddPool(AddPoolOptions{Name: "pool1", Labels: map[string]string{"zone": "a", "tier": "gold", "empty": ""}})
+	c.Assert(err, check.IsNil)
+	p, err := GetPoolByName("pool1")
+	c.Assert(err, check.IsNil)
+	c.Assert(p.Labels, check.DeepEquals, map[string]string{"zone": "a", "tier": "gold"})
+	err = PoolUpdate("pool1", UpdatePoolOptions{Labels: map[string]string{ProductionLabel: "true", "tier": ""}})
+	c.Assert(err, check.IsNil)
+	p, err = GetPoolByName("pool1")
+	c.Assert(err, check.IsNil)
+	c.Assert(p.Labels, check.DeepEquals, map[string]string{"zone": "a", ProductionLabel: "true"})
+	c.Assert(p.RequiresDeployApproval(), check.Equals, true)
+}
+
+func (s *S) TestPoolInvalidLabels(c *check.C) {
+	err := AddPool(AddPoolOptions{Name: "pool1", Labels: map[string]string{"a.b": "c"}})
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+	err = AddPool(AddPoolOptions{Name: "pool1"})
+	c.Assert(err, check.IsNil)
+	err = PoolUpdate("pool1", UpdatePoolOptions{Labels: map[string]string{"$set": "x"}})
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+}
+
 func (s *S) TestPoolUpdateToDefault(c *check.C) {
 	opts := AddPoolOptions{
 		Name:    "pool1",
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/fsouza/go-dockerclient"
+	"github.com/globalsign/mgo/bson"
 	"github.com/pkg/errors"
 	"github.com/tsuru/tsuru/app/bind"
 	"github.com/tsuru/tsuru/event"
@@ -227,6 +228,13 @@ type App interface {
 	GetSwap() int64
 	GetCpuShare() int
 
+	// GetProcessMemory, GetProcessSwap and GetProcessCpuShare return the
+	// resource limits for the units of a process, which may override the
+	// limits of the app.
+	GetProcessMemory(process string) int64
+	GetProcessSwap(process string) int64
+	GetProcessCpuShare(process string) int
+
 	GetUpdatePlatform() bool
 
 	GetRouters() []appTypes.AppRouter
@@ -256,6 +264,15 @@ type RollbackableDeployer interface {
 	Rollback(App, string, *event.Event) (string, error)
 }
 
+// CanaryDeployer is a provisioner able to run an image as a separate set of
+// units, alongside the units currently serving the app, allowing traffic to
+// be gradually shifted to the new image.
+type CanaryDeployer interface {
+	DeployCanary(App, string, *event.Event) ([]Unit, error)
+	CanaryUnits(App) ([]Unit, error)
+	RemoveCanary(App, *event.Event) error
+}
+
 type BuilderDockerClient interface {
 	PullAndCreateContainer(opts docker.CreateContainerOptions, w io.Writer) (*docker.Container, string, error)
 	RemoveContainer(opts docker.RemoveContainerOptions) error
@@ -291,6 +308,7 @@ type BuilderKubeClient interface {
 	BuildImage(name, image string, inputStream io.Reader, output io.Writer, ctx context.Context) error
 	ImageTagPushAndInspect(App, string, string) (*docker.Image, string, *TsuruYamlData, error)
 	DownloadFromContainer(App, string) (io.ReadCloser, error)
+	DownloadFilesFromImage(App, string, []string) (io.ReadCloser, error)
 }
 
 // BuilderDeploy is a provisioner that allows deploy builded image.
@@ -373,6 +391,74 @@ type ExecutableProvisioner interface {
 	ExecuteCommand(opts ExecOptions) error
 }
 
+// Job is a command run periodically in an isolated unit, using the current
+// image and environment variables of an app.
+type Job struct {
+	Name     string
+	Schedule string
+	Cmds     []string
+}
+
+// JobRun is a finished run of a job scheduled by a JobProvisioner.
+type JobRun struct {
+	Name      string
+	StartTime time.Time
+	EndTime   time.Time
+	Succeeded bool
+	Output    string
+}
+
+// JobProvisioner is a provisioner able to schedule app jobs natively. Jobs of
+// apps in other provisioners are scheduled by tsuru itself and run as isolated
+// commands through ExecutableProvisioner.
+type JobProvisioner interface {
+	// EnsureJob creates or updates the job, using the current image of the
+	// app.
+	EnsureJob(App, Job) error
+
+	// RemoveJob removes the job and all its runs.
+	RemoveJob(App, Job) error
+
+	// JobRuns returns the runs of the job finished since the last call,
+	// along with their output.
+	JobRuns(App, Job) ([]JobRun, error)
+}
+
+// UnitMetrics is the resource usage of a unit.
+type UnitMetrics struct {
+	ID string
+	// CPU is the cpu usage of the unit, in percentage of one core.
+	CPU float64
+	// Memory is the memory used by the unit, in bytes.
+	Memory int64
+}
+
+// MetricsProvisioner is a provisioner able to report the resource usage of
+// the units of an app.
+type MetricsProvisioner interface {
+	UnitsMetrics(app App, process string) ([]UnitMetrics, error)
+}
+
+// AutoScaleSpec describes how the units of an app process must be scaled
+// according to their resource usage. Only one of CPU and Memory is set.
+type AutoScaleSpec struct {
+	Process  string
+	MinUnits uint
+	MaxUnits uint
+	// CPU is the target average cpu usage of units, in percentage of one
+	// core.
+	CPU int
+	// Memory is the target average memory usage of units, in bytes.
+	Memory int64
+}
+
+// AutoScaleProvisioner is a provisioner able to scale units natively. Units
+// of apps in other provisioners are scaled by tsuru itself.
+type AutoScaleProvisioner interface {
+	SetAutoScale(App, AutoScaleSpec) error
+	RemoveAutoScale(app App, process string) error
+}
+
 // SleepableProvisioner is a provisioner that allows putting applications to
 // sleep.
 type SleepableProvisioner interface {
@@ -674,13 +760,88 @@ func (e *Error) Error() string {
 }
 
 type TsuruYamlData struct {
-	Hooks       TsuruYamlHooks       `bson:",omitempty"`
-	Healthcheck TsuruYamlHealthcheck `bson:",omitempty"`
+	Hooks       TsuruYamlHooks             `bson:",omitempty"`
+	Healthcheck TsuruYamlHealthcheck       `bson:",omitempty"`
+	PostDeploy  TsuruYamlPostDeploy        `json:"post_deploy" yaml:"post_deploy" bson:"post_deploy,omitempty"`
+	Probes      map[string]TsuruYamlProbes `json:"probes" yaml:"probes" bson:"probes,omitempty"`
+	Ports       map[string][]TsuruYamlPort `json:"ports" yaml:"ports" bson:"ports,omitempty"`
+}
+
+// TsuruYamlProbes holds the probes of a process. The readiness probe decides
+// when a unit starts receiving requests, the liveness probe when a unit must
+// be restarted. The web process falls back to the healthcheck when it has no
+// probes declared.
+type TsuruYamlProbes struct {
+	Readiness *TsuruYamlProbe `bson:",omitempty"`
+	Liveness  *TsuruYamlProbe `bson:",omitempty"`
+}
+
+// TsuruYamlProbe is either an HTTP probe, requesting Path on Port, or a
+// command probe, running Command inside the unit. Port defaults to the port
+// of the process.
+type TsuruYamlProbe struct {
+	Path                string   `bson:",omitempty"`
+	Scheme              string   `bson:",omitempty"`
+	Port                int      `bson:",omitempty"`
+	Command             []string `bson:",omitempty"`
+	InitialDelaySeconds int      `json:"initial_delay_seconds" yaml:"initial_delay_seconds" bson:"initial_delay_seconds,omitempty"`
+	PeriodSeconds       int      `json:"period_seconds" yaml:"period_seconds" bson:"period_seconds,omitempty"`
+	TimeoutSeconds      int      `json:"timeout_seconds" yaml:"timeout_seconds" bson:"timeout_seconds,omitempty"`
+	FailureThreshold    int      `json:"failure_threshold" yaml:"failure_threshold" bson:"failure_threshold,omitempty"`
+}
+
+// TsuruYamlPort is a port exposed by the units of a process.
+type TsuruYamlPort struct {
+	Name     string `bson:",omitempty"`
+	Port     int    `bson:",omitempty"`
+	Protocol string `bson:",omitempty"`
+}
+
+// ToCustomData returns the tsuru.yaml data in the form stored in the custom
+// data of the images built from it.
+func (y *TsuruYamlData) ToCustomData() (map[string]interface{}, error) {
+	if y == nil {
+		return nil, nil
+	}
+	data, err := bson.Marshal(y)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var customData bson.M
+	err = bson.Unmarshal(data, &customData)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return customData, nil
+}
+
+// ProcessProbes returns the probes declared for the process.
+func (y *TsuruYamlData) ProcessProbes(process string) TsuruYamlProbes {
+	if y == nil || y.Probes == nil {
+		return TsuruYamlProbes{}
+	}
+	return y.Probes[process]
+}
+
+// TsuruYamlPostDeploy configures the observation window after a deploy. While
+// the window is open, the deploy is rolled back if more than MaxUnitFailures
+// units fail or if the error rate reported by the app routers, as a
+// percentage of the requests, goes above MaxErrorRate. A zero threshold is not
+// checked.
+type TsuruYamlPostDeploy struct {
+	WindowSeconds   int     `json:"window_seconds" yaml:"window_seconds" bson:"window_seconds,omitempty"`
+	MaxUnitFailures int     `json:"max_unit_failures" yaml:"max_unit_failures" bson:"max_unit_failures,omitempty"`
+	MaxErrorRate    float64 `json:"max_error_rate" yaml:"max_error_rate" bson:"max_error_rate,omitempty"`
 }
 
+// TsuruYamlHooks holds the hooks of the app. PreStop commands run inside each
+// unit before it's stopped, and units are given GracePeriodSeconds to shut
+// down before being killed.
 type TsuruYamlHooks struct {
-	Restart TsuruYamlRestartHooks `bson:",omitempty"`
-	Build   []string              `bson:",omitempty"`
+	Restart            TsuruYamlRestartHooks `bson:",omitempty"`
+	Build              []string              `bson:",omitempty"`
+	PreStop            []string              `json:"pre_stop" yaml:"pre_stop" bson:"pre_stop,omitempty"`
+	GracePeriodSeconds int                   `json:"grace_period_seconds" yaml:"grace_period_seconds" bson:"grace_period_seconds,omitempty"`
 }
 
 type TsuruYamlRestartHooks struct {
@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/globalsign/mgo/bson"
 	"gopkg.in/check.v1"
 )
 
@@ -194,3 +195,30 @@ func (ProvisionSuite) TestNodeToSpecExtraData(c *check.C) {
 		Pool:     "a",
 	})
 }
+
+func (ProvisionSuite) TestTsuruYamlDataToCustomData(c *check.C) {
+	yamlData := &TsuruYamlData{
+		Healthcheck: TsuruYamlHealthcheck{Path: "/status"},
+		Probes: map[string]TsuruYamlProbes{
+			"web": {Readiness: &TsuruYamlProbe{Path: "/ready", PeriodSeconds: 5}},
+		},
+		Ports: map[string][]TsuruYamlPort{
+			"web": {{Name: "http", Port: 8080, Protocol: "TCP"}},
+		},
+	}
+	customData, err := yamlData.ToCustomData()
+	c.Assert(err, check.IsNil)
+	c.Assert(customData, check.DeepEquals, map[string]interface{}{
+		"healthcheck": bson.M{"path": "/status", "method": "", "scheme": "", "status": 0},
+		"probes": bson.M{
+			"web": bson.M{"readiness": bson.M{"path": "/ready", "period_seconds": 5}},
+		},
+		"ports": bson.M{
+			"web": []interface{}{bson.M{"name": "http", "port": 8080, "protocol": "TCP"}},
+		},
+	})
+	var nilData *TsuruYamlData
+	customData, err = nilData.ToCustomData()
+	c.Assert(err, check.IsNil)
+	c.Assert(customData, check.IsNil)
+}
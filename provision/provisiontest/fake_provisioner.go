@@ -37,6 +37,9 @@ var (
 	_ provision.NodeProvisioner      = &FakeProvisioner{}
 	_ provision.UpdatableProvisioner = &FakeProvisioner{}
 	_ provision.Provisioner          = &FakeProvisioner{}
+	_ provision.CanaryDeployer       = &FakeProvisioner{}
+	_ provision.MetricsProvisioner   = &FakeProvisioner{}
+	_ provision.AutoScaleProvisioner = &AutoScaleFakeProvisioner{}
 	_ provision.App                  = &FakeApp{}
 	_ bind.App                       = &FakeApp{}
 )
@@ -65,6 +68,7 @@ type FakeApp struct {
 	Memory          int64
 	Swap            int64
 	CpuShare        int
+	ProcessPlans    map[string]appTypes.Plan
 	commMut         sync.Mutex
 	Deploys         uint
 	env             map[string]bind.EnvVar
@@ -122,6 +126,27 @@ func (a *FakeApp) GetCpuShare() int {
 	return a.CpuShare
 }
 
+func (a *FakeApp) GetProcessMemory(process string) int64 {
+	if plan, ok := a.ProcessPlans[process]; ok {
+		return plan.Memory
+	}
+	return a.Memory
+}
+
+func (a *FakeApp) GetProcessSwap(process string) int64 {
+	if plan, ok := a.ProcessPlans[process]; ok {
+		return plan.Swap
+	}
+	return a.Swap
+}
+
+func (a *FakeApp) GetProcessCpuShare(process string) int {
+	if plan, ok := a.ProcessPlans[process]; ok {
+		return plan.CpuShare
+	}
+	return a.CpuShare
+}
+
 func (a *FakeApp) GetTeamsName() []string {
 	return a.Teams
 }
@@ -936,6 +961,92 @@ func (p *FakeProvisioner) Rollback(app provision.App, img string, evt *event.Eve
 	return img, nil
 }
 
+func (p *FakeProvisioner) DeployCanary(app provision.App, img string, evt *event.Event) ([]provision.Unit, error) {
+	if err := p.getError("DeployCanary"); err != nil {
+		return nil, err
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	pApp, ok := p.apps[app.GetName()]
+	if !ok {
+		return nil, errNotProvisioned
+	}
+	n := len(pApp.units)
+	if n == 0 {
+		n = 1
+	}
+	units := make([]provision.Unit, n)
+	for i := range units {
+		val := atomic.AddInt32(&uniqueIpCounter, 1)
+		hostAddr := fmt.Sprintf("10.10.10.%d", val)
+		units[i] = provision.Unit{
+			ID:          fmt.Sprintf("%s-canary-%d", app.GetName(), i),
+			AppName:     app.GetName(),
+			Type:        app.GetPlatform(),
+			Status:      provision.StatusStarted,
+			IP:          hostAddr,
+			ProcessName: "web",
+			Address: &url.URL{
+				Scheme: "http",
+				Host:   fmt.Sprintf("%s:%d", hostAddr, val),
+			},
+		}
+	}
+	pApp.canaryImage = img
+	pApp.canaryUnits = units
+	evt.Write([]byte("Canary deploy called"))
+	p.apps[app.GetName()] = pApp
+	return units, nil
+}
+
+func (p *FakeProvisioner) CanaryUnits(app provision.App) ([]provision.Unit, error) {
+	if err := p.getError("CanaryUnits"); err != nil {
+		return nil, err
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	pApp, ok := p.apps[app.GetName()]
+	if !ok {
+		return nil, errNotProvisioned
+	}
+	return pApp.canaryUnits, nil
+}
+
+func (p *FakeProvisioner) RemoveCanary(app provision.App, evt *event.Event) error {
+	if err := p.getError("RemoveCanary"); err != nil {
+		return err
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	pApp, ok := p.apps[app.GetName()]
+	if !ok {
+		return errNotProvisioned
+	}
+	pApp.canaryImage = ""
+	pApp.canaryUnits = nil
+	p.apps[app.GetName()] = pApp
+	return nil
+}
+
+// CanaryImage returns the image running in the canary units of the given app.
+func (p *FakeProvisioner) CanaryImage(app provision.App) string {
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	return p.apps[app.GetName()].canaryImage
+}
+
+// SetCanaryUnitsStatus changes the status of all canary units of the given
+// app, it's useful to simulate a degraded canary.
+func (p *FakeProvisioner) SetCanaryUnitsStatus(app provision.App, status provision.Status) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	pApp := p.apps[app.GetName()]
+	for i := range pApp.canaryUnits {
+		pApp.canaryUnits[i].Status = status
+	}
+	p.apps[app.GetName()] = pApp
+}
+
 func (p *FakeProvisioner) Rebuild(app provision.App, evt *event.Event) (string, error) {
 	if err := p.getError("Rebuild"); err != nil {
 		return "", err
@@ -1384,6 +1495,75 @@ func stringInArray(value string, array []string) bool {
 	return false
 }
 
+// SetUnitsMetrics sets the metrics returned by UnitsMetrics for the units of
+// the given process.
+func (p *FakeProvisioner) SetUnitsMetrics(app provision.App, process string, metrics []provision.UnitMetrics) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	pApp, ok := p.apps[app.GetName()]
+	if !ok {
+		return errNotProvisioned
+	}
+	if pApp.metrics == nil {
+		pApp.metrics = make(map[string][]provision.UnitMetrics)
+	}
+	pApp.metrics[process] = metrics
+	p.apps[app.GetName()] = pApp
+	return nil
+}
+
+func (p *FakeProvisioner) UnitsMetrics(app provision.App, process string) ([]provision.UnitMetrics, error) {
+	if err := p.getError("UnitsMetrics"); err != nil {
+		return nil, err
+	}
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	pApp, ok := p.apps[app.GetName()]
+	if !ok {
+		return nil, errNotProvisioned
+	}
+	return pApp.metrics[process], nil
+}
+
+// AutoScaleFakeProvisioner is a fake provisioner able to scale units
+// natively, it records the autoscale specs set for each app process.
+type AutoScaleFakeProvisioner struct {
+	*FakeProvisioner
+	mut        sync.Mutex
+	autoScales map[string]provision.AutoScaleSpec
+}
+
+func (p *AutoScaleFakeProvisioner) SetAutoScale(app provision.App, spec provision.AutoScaleSpec) error {
+	if err := p.getError("SetAutoScale"); err != nil {
+		return err
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if p.autoScales == nil {
+		p.autoScales = make(map[string]provision.AutoScaleSpec)
+	}
+	p.autoScales[app.GetName()+"/"+spec.Process] = spec
+	return nil
+}
+
+func (p *AutoScaleFakeProvisioner) RemoveAutoScale(app provision.App, process string) error {
+	if err := p.getError("RemoveAutoScale"); err != nil {
+		return err
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	delete(p.autoScales, app.GetName()+"/"+process)
+	return nil
+}
+
+// AutoScale returns the autoscale spec set for the app process.
+func (p *AutoScaleFakeProvisioner) AutoScale(app provision.App, process string) (provision.AutoScaleSpec, bool) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	spec, ok := p.autoScales[app.GetName()+"/"+process]
+	return spec, ok
+}
+
 type PipelineFakeProvisioner struct {
 	*FakeProvisioner
 	executedPipeline bool
@@ -1439,4 +1619,7 @@ type provisionedApp struct {
 	unitLen     int
 	lastData    map[string]interface{}
 	image       string
+	canaryImage string
+	canaryUnits []provision.Unit
+	metrics     map[string][]provision.UnitMetrics
 }
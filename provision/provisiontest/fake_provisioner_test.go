@@ -21,6 +21,7 @@ import (
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/router/routertest"
+	appTypes "github.com/tsuru/tsuru/types/app"
 	"github.com/tsuru/tsuru/types/quota"
 	"gopkg.in/check.v1"
 )
@@ -66,6 +67,18 @@ func (s *S) TestFakeAppGetSwap(c *check.C) {
 	c.Assert(app.GetSwap(), check.Equals, int64(0))
 }
 
+func (s *S) TestFakeAppGetProcessMemory(c *check.C) {
+	app := NewFakeApp("sou", "otm", 0)
+	app.Memory = 100
+	app.CpuShare = 10
+	app.ProcessPlans = map[string]appTypes.Plan{"worker": {Memory: 200, Swap: 50, CpuShare: 20}}
+	c.Assert(app.GetProcessMemory("web"), check.Equals, int64(100))
+	c.Assert(app.GetProcessMemory("worker"), check.Equals, int64(200))
+	c.Assert(app.GetProcessSwap("worker"), check.Equals, int64(50))
+	c.Assert(app.GetProcessCpuShare("web"), check.Equals, 10)
+	c.Assert(app.GetProcessCpuShare("worker"), check.Equals, 20)
+}
+
 func (s *S) TestEnvs(c *check.C) {
 	app := FakeApp{name: "time"}
 	env := bind.EnvVar{
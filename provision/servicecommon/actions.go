@@ -80,6 +80,9 @@ func RunServicePipeline(manager ServiceManager, a provision.App, newImg string,
 		updateImageInDB,
 		removeOldServices,
 	)
+	if evt != nil {
+		pipeline.SetObserver(evt)
+	}
 	return pipeline.Execute(&pipelineArgs{
 		manager:          manager,
 		app:              a,
@@ -311,6 +311,14 @@ func serviceSpecForApp(opts tsuruServiceOpts) (*swarm.ServiceSpec, error) {
 		opts.replicas = 1
 		srvName = fmt.Sprintf("%sisolated-run", srvName)
 	}
+	var resources *swarm.ResourceRequirements
+	if !opts.isDeploy {
+		if memory := opts.app.GetProcessMemory(opts.process); memory != 0 {
+			resources = &swarm.ResourceRequirements{
+				Limits: &swarm.Resources{MemoryBytes: memory},
+			}
+		}
+	}
 	uReplicas := uint64(opts.replicas)
 	spec := swarm.ServiceSpec{
 		TaskTemplate: swarm.TaskSpec{
@@ -322,7 +330,8 @@ func serviceSpecForApp(opts tsuruServiceOpts) (*swarm.ServiceSpec, error) {
 				Healthcheck: healthConfig,
 				Mounts:      mounts,
 			},
-			Networks: networks,
+			Resources: resources,
+			Networks:  networks,
 			RestartPolicy: &swarm.RestartPolicy{
 				Condition: swarm.RestartPolicyConditionAny,
 			},
@@ -24,6 +24,7 @@ import (
 	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/provision/nodecontainer"
 	"github.com/tsuru/tsuru/provision/servicecommon"
+	appTypes "github.com/tsuru/tsuru/types/app"
 	"gopkg.in/check.v1"
 )
 
@@ -379,6 +380,31 @@ func (s *S) TestServiceSpecForApp(c *check.C) {
 	}
 }
 
+func (s *S) TestServiceSpecForAppWithProcessPlan(c *check.C) {
+	s.addCluster(c)
+	a := &app.App{Name: "myapp", TeamOwner: s.team.Name, Deploys: 1}
+	err := app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	a.ProcessPlans = map[string]appTypes.Plan{
+		"worker": {Name: "big", Memory: 536870912, CpuShare: 200},
+	}
+	err = image.SaveImageCustomData("myapp:v1", map[string]interface{}{
+		"processes": map[string]interface{}{
+			"web":    "python myapp.py",
+			"worker": "python worker.py",
+		},
+	})
+	c.Assert(err, check.IsNil)
+	spec, err := serviceSpecForApp(tsuruServiceOpts{app: a, image: "myapp:v1", process: "worker"})
+	c.Assert(err, check.IsNil)
+	c.Assert(spec.TaskTemplate.Resources, check.DeepEquals, &swarm.ResourceRequirements{
+		Limits: &swarm.Resources{MemoryBytes: 536870912},
+	})
+	spec, err = serviceSpecForApp(tsuruServiceOpts{app: a, image: "myapp:v1", process: "web"})
+	c.Assert(err, check.IsNil)
+	c.Assert(spec.TaskTemplate.Resources, check.IsNil)
+}
+
 func (s *S) TestServiceSpecForNodeContainer(c *check.C) {
 	c1 := nodecontainer.NodeContainerConfig{
 		Name: "swarmbs",
@@ -69,6 +69,30 @@ func RemoveImage(imageName string) error {
 	return nil
 }
 
+// ImageDigest returns the repository digest of an image stored in a remote
+// registry v2 server, in the repo@digest form.
+func ImageDigest(imageName string) (string, error) {
+	registry, image, tag := parseImage(imageName)
+	if registry == "" {
+		registry, _ = config.GetString("docker:registry")
+	}
+	if registry == "" {
+		return "", errors.Errorf("no registry set to get digest of image %q", imageName)
+	}
+	if image == "" {
+		return "", errors.Errorf("empty image after parsing %q", imageName)
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+	r := &dockerRegistry{server: registry}
+	digest, err := r.getDigest(image, tag)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get digest for image %s/%s:%s on registry", r.server, image, tag)
+	}
+	return fmt.Sprintf("%s/%s@%s", r.server, image, digest), nil
+}
+
 // RemoveAppImages removes all app images from a remote registry v2 server, returning an error
 // in case of failure.
 func RemoveAppImages(appName string) error {
@@ -81,6 +81,18 @@ func (s *S) TestRegistryRemoveImage(c *check.C) {
 	c.Assert(s.server.Repos[0].Tags, check.HasLen, 1)
 }
 
+func (s *S) TestRegistryImageDigest(c *check.C) {
+	s.server.AddRepo(registrytest.Repository{Name: "tsuru/app-teste", Tags: map[string]string{"v1": "abcdefg", "latest": "hijklmn"}})
+	digest, err := ImageDigest(s.server.Addr() + "/tsuru/app-teste:v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(digest, check.Equals, s.server.Addr()+"/tsuru/app-teste@abcdefg")
+	digest, err = ImageDigest("tsuru/app-teste")
+	c.Assert(err, check.IsNil)
+	c.Assert(digest, check.Equals, s.server.Addr()+"/tsuru/app-teste@hijklmn")
+	_, err = ImageDigest(s.server.Addr() + "/tsuru/app-teste:v2")
+	c.Assert(errors.Cause(err), check.Equals, ErrDigestNotFound)
+}
+
 func (s *S) TestRegistryRemoveImageWithAuth(c *check.C) {
 	config.Set("docker:registry-auth:username", "user")
 	defer config.Unset("docker:registry-auth:username")
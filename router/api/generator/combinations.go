@@ -21,6 +21,10 @@ var capMap = map[string][]string{
 	"healthcheck": {"router.CustomHealthcheckRouter", "apiRouterWithHealthcheckSupport"},
 	"info":        {"router.InfoRouter", "apiRouterWithInfo"},
 	"status":      {"router.StatusRouter", "apiRouterWithStatus"},
+	"weighted":    {"router.WeightedRouter", "apiRouterWithWeightSupport"},
+	"path":        {"router.PathRouter", "apiRouterWithPathSupport"},
+	"backends":    {"router.BackendLister", "apiRouterWithBackendList"},
+	"acme":        {"router.ACMEChallengeRouter", "apiRouterWithACMESupport"},
 }
 
 var fileTpl = `// AUTOMATICALLY GENERATED FILE - DO NOT EDIT!
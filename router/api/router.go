@@ -35,6 +35,10 @@ var (
 	_ router.CustomHealthcheckRouter = &apiRouterWithHealthcheckSupport{}
 	_ router.InfoRouter              = &apiRouterWithInfo{}
 	_ router.StatusRouter            = &apiRouterWithStatus{}
+	_ router.WeightedRouter          = &apiRouterWithWeightSupport{}
+	_ router.PathRouter              = &apiRouterWithPathSupport{}
+	_ router.BackendLister           = &apiRouterWithBackendList{}
+	_ router.ACMEChallengeRouter     = &apiRouterWithACMESupport{}
 )
 
 type apiRouter struct {
@@ -56,6 +60,14 @@ type apiRouterWithInfo struct{ *apiRouter }
 
 type apiRouterWithStatus struct{ *apiRouter }
 
+type apiRouterWithWeightSupport struct{ *apiRouter }
+
+type apiRouterWithPathSupport struct{ *apiRouter }
+
+type apiRouterWithBackendList struct{ *apiRouter }
+
+type apiRouterWithACMESupport struct{ *apiRouter }
+
 type routesReq struct {
 	Addresses []string `json:"addresses"`
 }
@@ -78,6 +90,24 @@ type backendResp struct {
 	Address string `json:"address"`
 }
 
+type weightData struct {
+	Target string `json:"target"`
+	Weight int    `json:"weight"`
+}
+
+type pathsResp struct {
+	Paths []router.PathRoute `json:"paths"`
+}
+
+type backendsResp struct {
+	Backends []string `json:"backends"`
+}
+
+type acmeChallengeReq struct {
+	Token   string `json:"token"`
+	KeyAuth string `json:"keyAuth"`
+}
+
 type statusResp struct {
 	Status router.BackendStatus `json:"status"`
 	Detail string               `json:"detail"`
@@ -91,8 +121,12 @@ var (
 	capHealthcheck = capability("healthcheck")
 	capInfo        = capability("info")
 	capStatus      = capability("status")
+	capWeighted    = capability("weighted")
+	capPath        = capability("path")
+	capBackends    = capability("backends")
+	capACME        = capability("acme")
 
-	allCaps = []capability{capCName, capTLS, capHealthcheck, capInfo, capStatus}
+	allCaps = []capability{capCName, capTLS, capHealthcheck, capInfo, capStatus, capWeighted, capPath, capBackends, capACME}
 )
 
 func init() {
@@ -488,6 +522,137 @@ func (r *apiRouterWithStatus) GetBackendStatus(name string) (router.BackendStatu
 	return status.Status, status.Detail, nil
 }
 
+func (r *apiRouterWithWeightSupport) SetWeight(name, target string, weight int) error {
+	if !router.ValidWeight(weight) {
+		return router.ErrInvalidWeight
+	}
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(weightData{Target: target, Weight: weight})
+	if err != nil {
+		return err
+	}
+	_, code, err := r.do(http.MethodPut, fmt.Sprintf("backend/%s/weight", backendName), bytes.NewReader(b))
+	if code == http.StatusNotFound {
+		return router.ErrBackendNotFound
+	}
+	return err
+}
+
+func (r *apiRouterWithWeightSupport) Weight(name string) (string, int, error) {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return "", 0, err
+	}
+	data, code, err := r.do(http.MethodGet, fmt.Sprintf("backend/%s/weight", backendName), nil)
+	if code == http.StatusNotFound {
+		return "", 0, router.ErrBackendNotFound
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	var weight weightData
+	err = json.Unmarshal(data, &weight)
+	if err != nil {
+		return "", 0, err
+	}
+	return weight.Target, weight.Weight, nil
+}
+
+func (r *apiRouterWithPathSupport) AddPathRoute(name, host, path string) error {
+	return r.doPathRoute(name, "path", router.PathRoute{Host: host, Path: path})
+}
+
+func (r *apiRouterWithPathSupport) RemovePathRoute(name, host, path string) error {
+	return r.doPathRoute(name, "path/remove", router.PathRoute{Host: host, Path: path})
+}
+
+func (r *apiRouterWithPathSupport) doPathRoute(name, suffix string, route router.PathRoute) error {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+	data, code, err := r.do(http.MethodPost, fmt.Sprintf("backend/%s/%s", backendName, suffix), bytes.NewReader(b))
+	switch code {
+	case http.StatusNotFound:
+		return router.ErrBackendNotFound
+	case http.StatusConflict:
+		return router.ErrPathRouteExists
+	case http.StatusBadRequest:
+		if strings.Contains(string(data), router.ErrPathRouteNotFound.Error()) {
+			return router.ErrPathRouteNotFound
+		}
+	}
+	return err
+}
+
+func (r *apiRouterWithPathSupport) PathRoutes(name string) ([]router.PathRoute, error) {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return nil, err
+	}
+	data, code, err := r.do(http.MethodGet, fmt.Sprintf("backend/%s/path", backendName), nil)
+	if code == http.StatusNotFound {
+		return nil, router.ErrBackendNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var resp pathsResp
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Paths, nil
+}
+
+func (r *apiRouterWithBackendList) Backends() ([]string, error) {
+	data, _, err := r.do(http.MethodGet, "backends", nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp backendsResp
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Backends, nil
+}
+
+func (r *apiRouterWithACMESupport) AddACMEChallenge(name, token, keyAuth string) error {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(acmeChallengeReq{Token: token, KeyAuth: keyAuth})
+	if err != nil {
+		return err
+	}
+	_, code, err := r.do(http.MethodPost, fmt.Sprintf("backend/%s/acme-challenge", backendName), bytes.NewReader(b))
+	if code == http.StatusNotFound {
+		return router.ErrBackendNotFound
+	}
+	return err
+}
+
+func (r *apiRouterWithACMESupport) RemoveACMEChallenge(name, token string) error {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	_, code, err := r.do(http.MethodDelete, fmt.Sprintf("backend/%s/acme-challenge/%s", backendName, token), nil)
+	if code == http.StatusNotFound {
+		return router.ErrBackendNotFound
+	}
+	return err
+}
+
 func addDefaultOpts(app router.App, opts map[string]string) map[string]interface{} {
 	mergedOpts := make(map[string]interface{})
 	for k, v := range opts {
@@ -349,14 +349,109 @@ func (s *S) TestHealcheckBackendNotFound(c *check.C) {
 	c.Assert(err, check.DeepEquals, router.ErrBackendNotFound)
 }
 
+func (s *S) TestSetWeight(c *check.C) {
+	s.apiRouter.backends["mybackend-canary"] = &backend{}
+	weightRouter := &apiRouterWithWeightSupport{s.testRouter}
+	err := weightRouter.SetWeight("mybackend", "mybackend-canary", 30)
+	c.Assert(err, check.IsNil)
+	c.Assert(s.apiRouter.backends["mybackend"].weight, check.DeepEquals, weightData{Target: "mybackend-canary", Weight: 30})
+	target, weight, err := weightRouter.Weight("mybackend")
+	c.Assert(err, check.IsNil)
+	c.Assert(target, check.Equals, "mybackend-canary")
+	c.Assert(weight, check.Equals, 30)
+}
+
+func (s *S) TestSetWeightInvalid(c *check.C) {
+	weightRouter := &apiRouterWithWeightSupport{s.testRouter}
+	err := weightRouter.SetWeight("mybackend", "mybackend-canary", 101)
+	c.Assert(err, check.Equals, router.ErrInvalidWeight)
+	err = weightRouter.SetWeight("mybackend", "mybackend-canary", -1)
+	c.Assert(err, check.Equals, router.ErrInvalidWeight)
+}
+
+func (s *S) TestSetWeightBackendNotFound(c *check.C) {
+	weightRouter := &apiRouterWithWeightSupport{s.testRouter}
+	err := weightRouter.SetWeight("mybackend", "invalid", 10)
+	c.Assert(err, check.Equals, router.ErrBackendNotFound)
+	_, _, err = weightRouter.Weight("invalid")
+	c.Assert(err, check.Equals, router.ErrBackendNotFound)
+}
+
+func (s *S) TestAddPathRoute(c *check.C) {
+	pathRouter := &apiRouterWithPathSupport{s.testRouter}
+	err := pathRouter.AddPathRoute("mybackend", "api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	c.Assert(s.apiRouter.paths, check.DeepEquals, map[router.PathRoute]string{
+		{Host: "api.example.com", Path: "/orders"}: "mybackend",
+	})
+	routes, err := pathRouter.PathRoutes("mybackend")
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.DeepEquals, []router.PathRoute{{Host: "api.example.com", Path: "/orders"}})
+}
+
+func (s *S) TestAddPathRouteExists(c *check.C) {
+	pathRouter := &apiRouterWithPathSupport{s.testRouter}
+	err := pathRouter.AddPathRoute("mybackend", "api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	err = pathRouter.AddPathRoute("mybackend", "api.example.com", "/orders")
+	c.Assert(err, check.Equals, router.ErrPathRouteExists)
+}
+
+func (s *S) TestAddPathRouteBackendNotFound(c *check.C) {
+	pathRouter := &apiRouterWithPathSupport{s.testRouter}
+	err := pathRouter.AddPathRoute("invalid", "api.example.com", "/orders")
+	c.Assert(err, check.Equals, router.ErrBackendNotFound)
+	_, err = pathRouter.PathRoutes("invalid")
+	c.Assert(err, check.Equals, router.ErrBackendNotFound)
+}
+
+func (s *S) TestRemovePathRoute(c *check.C) {
+	pathRouter := &apiRouterWithPathSupport{s.testRouter}
+	err := pathRouter.AddPathRoute("mybackend", "api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	err = pathRouter.RemovePathRoute("mybackend", "api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	c.Assert(s.apiRouter.paths, check.HasLen, 0)
+	err = pathRouter.RemovePathRoute("mybackend", "api.example.com", "/orders")
+	c.Assert(err, check.Equals, router.ErrPathRouteNotFound)
+}
+
+func (s *S) TestBackends(c *check.C) {
+	s.apiRouter.backends["otherbackend"] = &backend{}
+	backends, err := (&apiRouterWithBackendList{s.testRouter}).Backends()
+	c.Assert(err, check.IsNil)
+	c.Assert(backends, check.DeepEquals, []string{"mybackend", "otherbackend"})
+}
+
+func (s *S) TestACMEChallenges(c *check.C) {
+	acmeRouter := &apiRouterWithACMESupport{s.testRouter}
+	err := acmeRouter.AddACMEChallenge("mybackend", "token1", "token1.thumb")
+	c.Assert(err, check.IsNil)
+	c.Assert(s.apiRouter.backends["mybackend"].acmeChallenges, check.DeepEquals, map[string]string{"token1": "token1.thumb"})
+	err = acmeRouter.RemoveACMEChallenge("mybackend", "token1")
+	c.Assert(err, check.IsNil)
+	c.Assert(s.apiRouter.backends["mybackend"].acmeChallenges, check.HasLen, 0)
+}
+
+func (s *S) TestAddACMEChallengeBackendNotFound(c *check.C) {
+	s.testRouter.AddBackend(routertest.FakeApp{Name: "other"})
+	delete(s.apiRouter.backends, "other")
+	err := (&apiRouterWithACMESupport{s.testRouter}).AddACMEChallenge("other", "token1", "token1.thumb")
+	c.Assert(err, check.Equals, router.ErrBackendNotFound)
+}
+
 func (s *S) TestCreateRouterSupport(c *check.C) {
 	tt := []struct {
 		features    map[string]bool
 		expectCname bool
 		expectTLS   bool
 		expectHC    bool
+		expectW     bool
+		expectPath  bool
+		expectList  bool
+		expectACME  bool
 	}{
-		{nil, false, false, false},
+		{nil, false, false, false, false, false, false, false},
 		{features: map[string]bool{"cname": true}, expectCname: true},
 		{features: map[string]bool{"tls": true}, expectTLS: true},
 		{features: map[string]bool{"healthcheck": true}, expectHC: true},
@@ -364,6 +459,12 @@ func (s *S) TestCreateRouterSupport(c *check.C) {
 		{features: map[string]bool{"cname": true, "tls": true, "healthcheck": true}, expectCname: true, expectTLS: true, expectHC: true},
 		{features: map[string]bool{"cname": true, "healthcheck": true}, expectCname: true, expectHC: true},
 		{features: map[string]bool{"tls": true, "healthcheck": true}, expectTLS: true, expectHC: true},
+		{features: map[string]bool{"weighted": true}, expectW: true},
+		{features: map[string]bool{"cname": true, "weighted": true}, expectCname: true, expectW: true},
+		{features: map[string]bool{"path": true}, expectPath: true},
+		{features: map[string]bool{"cname": true, "path": true}, expectCname: true, expectPath: true},
+		{features: map[string]bool{"backends": true}, expectList: true},
+		{features: map[string]bool{"acme": true, "tls": true}, expectACME: true, expectTLS: true},
 	}
 	var i int
 	s.apiRouter.router.HandleFunc("/support/{name}", func(w http.ResponseWriter, r *http.Request) {
@@ -389,6 +490,14 @@ func (s *S) TestCreateRouterSupport(c *check.C) {
 		c.Assert(ok, check.Equals, tt[i].expectTLS, comment)
 		_, ok = r.(router.CustomHealthcheckRouter)
 		c.Assert(ok, check.Equals, tt[i].expectHC, comment)
+		_, ok = r.(router.WeightedRouter)
+		c.Assert(ok, check.Equals, tt[i].expectW, comment)
+		_, ok = r.(router.PathRouter)
+		c.Assert(ok, check.Equals, tt[i].expectPath, comment)
+		_, ok = r.(router.BackendLister)
+		c.Assert(ok, check.Equals, tt[i].expectList, comment)
+		_, ok = r.(router.ACMEChallengeRouter)
+		c.Assert(ok, check.Equals, tt[i].expectACME, comment)
 	}
 }
 
@@ -412,7 +521,7 @@ func (s *S) TestCreateCustomHeaders(c *check.C) {
 }
 
 func newFakeRouter(c *check.C) *fakeRouterAPI {
-	api := &fakeRouterAPI{}
+	api := &fakeRouterAPI{paths: make(map[router.PathRoute]string)}
 	r := mux.NewRouter()
 	r.HandleFunc("/backend/{name}", api.getBackend).Methods(http.MethodGet)
 	r.HandleFunc("/backend/{name}", api.addBackend).Methods(http.MethodPost)
@@ -430,6 +539,14 @@ func newFakeRouter(c *check.C) *fakeRouterAPI {
 	r.HandleFunc("/backend/{name}/certificate/{cname}", api.addCertificate).Methods(http.MethodPut)
 	r.HandleFunc("/backend/{name}/certificate/{cname}", api.removeCertificate).Methods(http.MethodDelete)
 	r.HandleFunc("/backend/{name}/status", api.getStatusBackend).Methods(http.MethodGet)
+	r.HandleFunc("/backend/{name}/weight", api.getWeight).Methods(http.MethodGet)
+	r.HandleFunc("/backend/{name}/weight", api.setWeight).Methods(http.MethodPut)
+	r.HandleFunc("/backend/{name}/path", api.getPaths).Methods(http.MethodGet)
+	r.HandleFunc("/backend/{name}/path", api.addPath).Methods(http.MethodPost)
+	r.HandleFunc("/backend/{name}/path/remove", api.removePath).Methods(http.MethodPost)
+	r.HandleFunc("/backend/{name}/acme-challenge", api.addACMEChallenge).Methods(http.MethodPost)
+	r.HandleFunc("/backend/{name}/acme-challenge/{token}", api.removeACMEChallenge).Methods(http.MethodDelete)
+	r.HandleFunc("/backends", api.getBackends).Methods(http.MethodGet)
 	r.HandleFunc("/info", api.getInfo).Methods(http.MethodGet)
 
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
@@ -442,19 +559,22 @@ func newFakeRouter(c *check.C) *fakeRouterAPI {
 }
 
 type backend struct {
-	addr        string
-	addresses   []string
-	cnames      []string
-	swapWith    string
-	cnameOnly   bool
-	healthcheck router.HealthcheckData
-	opts        map[string]interface{}
+	addr           string
+	addresses      []string
+	cnames         []string
+	swapWith       string
+	cnameOnly      bool
+	healthcheck    router.HealthcheckData
+	opts           map[string]interface{}
+	weight         weightData
+	acmeChallenges map[string]string
 }
 
 type fakeRouterAPI struct {
 	listener     net.Listener
 	backends     map[string]*backend
 	certificates map[string]certData
+	paths        map[router.PathRoute]string
 	endpoint     string
 	router       *mux.Router
 }
@@ -682,6 +802,39 @@ func (f *fakeRouterAPI) unsetCname(w http.ResponseWriter, r *http.Request) {
 	backend.cnames = newCnames
 }
 
+func (f *fakeRouterAPI) addACMEChallenge(w http.ResponseWriter, r *http.Request) {
+	backend, ok := f.backends[mux.Vars(r)["name"]]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var req acmeChallengeReq
+	json.NewDecoder(r.Body).Decode(&req)
+	if backend.acmeChallenges == nil {
+		backend.acmeChallenges = map[string]string{}
+	}
+	backend.acmeChallenges[req.Token] = req.KeyAuth
+}
+
+func (f *fakeRouterAPI) removeACMEChallenge(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	backend, ok := f.backends[vars["name"]]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	delete(backend.acmeChallenges, vars["token"])
+}
+
+func (f *fakeRouterAPI) getBackends(w http.ResponseWriter, r *http.Request) {
+	var resp backendsResp
+	for name := range f.backends {
+		resp.Backends = append(resp.Backends, name)
+	}
+	sort.Strings(resp.Backends)
+	json.NewEncoder(w).Encode(&resp)
+}
+
 func (f *fakeRouterAPI) getCnames(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
@@ -742,6 +895,84 @@ func (f *fakeRouterAPI) setHealthcheck(w http.ResponseWriter, r *http.Request) {
 	b.healthcheck = hc
 }
 
+func (f *fakeRouterAPI) getWeight(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	b, ok := f.backends[name]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(&b.weight)
+}
+
+func (f *fakeRouterAPI) setWeight(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	b, ok := f.backends[name]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var weight weightData
+	json.NewDecoder(r.Body).Decode(&weight)
+	if _, ok = f.backends[weight.Target]; !ok && weight.Weight > 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	b.weight = weight
+}
+
+func (f *fakeRouterAPI) getPaths(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if _, ok := f.backends[name]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	resp := pathsResp{}
+	for route, backendName := range f.paths {
+		if backendName == name {
+			resp.Paths = append(resp.Paths, route)
+		}
+	}
+	sort.Slice(resp.Paths, func(i, j int) bool {
+		return resp.Paths[i].Host+resp.Paths[i].Path < resp.Paths[j].Host+resp.Paths[j].Path
+	})
+	json.NewEncoder(w).Encode(&resp)
+}
+
+func (f *fakeRouterAPI) addPath(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if _, ok := f.backends[name]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var route router.PathRoute
+	json.NewDecoder(r.Body).Decode(&route)
+	if _, ok := f.paths[route]; ok {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(router.ErrPathRouteExists.Error()))
+		return
+	}
+	f.paths[route] = name
+}
+
+func (f *fakeRouterAPI) removePath(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if _, ok := f.backends[name]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var route router.PathRoute
+	json.NewDecoder(r.Body).Decode(&route)
+	if f.paths[route] != name {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(router.ErrPathRouteNotFound.Error()))
+		return
+	}
+	delete(f.paths, route)
+}
+
 func (f *fakeRouterAPI) stop() {
 	f.listener.Close()
 }
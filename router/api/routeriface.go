@@ -12,13 +12,17 @@ import (
 )
 
 func toSupportedInterface(base *apiRouter, supports map[capability]bool) router.Router {
+	apiRouterWithACMESupportInst := &apiRouterWithACMESupport{base}
+	apiRouterWithBackendListInst := &apiRouterWithBackendList{base}
 	apiRouterWithCnameSupportInst := &apiRouterWithCnameSupport{base}
 	apiRouterWithHealthcheckSupportInst := &apiRouterWithHealthcheckSupport{base}
 	apiRouterWithInfoInst := &apiRouterWithInfo{base}
+	apiRouterWithPathSupportInst := &apiRouterWithPathSupport{base}
 	apiRouterWithStatusInst := &apiRouterWithStatus{base}
 	apiRouterWithTLSSupportInst := &apiRouterWithTLSSupport{base}
+	apiRouterWithWeightSupportInst := &apiRouterWithWeightSupport{base}
 
-	if !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["status"] && !supports["tls"] {
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
@@ -27,443 +31,9211 @@ func toSupportedInterface(base *apiRouter, supports map[capability]bool) router.
 			base,
 		}
 	}
-	if supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["status"] && !supports["tls"] {
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.ACMEChallengeRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && !supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && !supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && !supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
 			router.CNameRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
-			apiRouterWithCnameSupportInst,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["status"] && !supports["tls"] {
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.BackendLister
 			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithBackendListInst,
 			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["status"] && !supports["tls"] {
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
-			router.CNameRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
 			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
-			apiRouterWithCnameSupportInst,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
 			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["status"] && !supports["tls"] {
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
 			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
 			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["status"] && !supports["tls"] {
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.ACMEChallengeRouter
 			router.CNameRouter
+			router.CustomHealthcheckRouter
 			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithACMESupportInst,
 			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
 			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["status"] && !supports["tls"] {
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
 			router.CustomHealthcheckRouter
 			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
 			apiRouterWithHealthcheckSupportInst,
 			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["status"] && !supports["tls"] {
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
 			router.CNameRouter
 			router.CustomHealthcheckRouter
 			router.InfoRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
 			apiRouterWithCnameSupportInst,
 			apiRouterWithHealthcheckSupportInst,
 			apiRouterWithInfoInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["status"] && !supports["tls"] {
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.PathRouter
 			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["status"] && !supports["tls"] {
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
-			router.CNameRouter
+			router.ACMEChallengeRouter
+			router.PathRouter
 			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
-			apiRouterWithCnameSupportInst,
+			apiRouterWithACMESupportInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["status"] && !supports["tls"] {
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
-			router.CustomHealthcheckRouter
+			router.BackendLister
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.PathRouter
 			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
-			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["status"] && !supports["tls"] {
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
 			router.CNameRouter
-			router.CustomHealthcheckRouter
+			router.PathRouter
 			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
 			apiRouterWithCnameSupportInst,
-			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["status"] && !supports["tls"] {
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
-			router.InfoRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.PathRouter
 			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
-			apiRouterWithInfoInst,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["status"] && !supports["tls"] {
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.BackendLister
 			router.CNameRouter
-			router.InfoRouter
+			router.PathRouter
 			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithBackendListInst,
 			apiRouterWithCnameSupportInst,
-			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CNameRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["status"] && !supports["tls"] {
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
 			router.CustomHealthcheckRouter
-			router.InfoRouter
+			router.PathRouter
 			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
 			apiRouterWithHealthcheckSupportInst,
-			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && supports["healthcheck"] && supports["info"] && supports["status"] && !supports["tls"] {
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
-			router.CNameRouter
+			router.ACMEChallengeRouter
 			router.CustomHealthcheckRouter
-			router.InfoRouter
+			router.PathRouter
 			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
-			apiRouterWithCnameSupportInst,
+			apiRouterWithACMESupportInst,
 			apiRouterWithHealthcheckSupportInst,
-			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["status"] && supports["tls"] {
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && !supports["healthcheck"] && !supports["info"] && !supports["status"] && supports["tls"] {
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.ACMEChallengeRouter
 			router.CNameRouter
+			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithACMESupportInst,
 			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["status"] && supports["tls"] {
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
 			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
 			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && supports["healthcheck"] && !supports["info"] && !supports["status"] && supports["tls"] {
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
 			router.CNameRouter
 			router.CustomHealthcheckRouter
+			router.PathRouter
+			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
 			apiRouterWithCnameSupportInst,
 			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["status"] && supports["tls"] {
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
 			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
 			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && !supports["healthcheck"] && supports["info"] && !supports["status"] && supports["tls"] {
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
-			router.CNameRouter
+			router.ACMEChallengeRouter
 			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
-			apiRouterWithCnameSupportInst,
+			apiRouterWithACMESupportInst,
 			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["status"] && supports["tls"] {
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
-			router.CustomHealthcheckRouter
+			router.BackendLister
 			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
-			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithBackendListInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
 			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && supports["healthcheck"] && supports["info"] && !supports["status"] && supports["tls"] {
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
 			router.CNameRouter
-			router.CustomHealthcheckRouter
 			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
 			apiRouterWithCnameSupportInst,
-			apiRouterWithHealthcheckSupportInst,
 			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && !supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["status"] && supports["tls"] {
+	if !supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
 			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && !supports["healthcheck"] && !supports["info"] && supports["status"] && supports["tls"] {
+	if supports["acme"] && supports["backends"] && supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
 			router.CNameRouter
+			router.InfoRouter
+			router.PathRouter
 			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
 			apiRouterWithCnameSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["status"] && supports["tls"] {
+	if !supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
 			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
 			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
 			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && supports["healthcheck"] && !supports["info"] && supports["status"] && supports["tls"] {
+	if supports["acme"] && !supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
-			router.CNameRouter
+			router.ACMEChallengeRouter
 			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
 			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
-			apiRouterWithCnameSupportInst,
+			apiRouterWithACMESupportInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if !supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
+			apiRouterWithStatusInst,
+			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
+		}
+	}
+	if supports["acme"] && supports["backends"] && !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
+		return &struct {
+			router.Router
+			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
+			router.CustomHealthcheckRouter
+			router.InfoRouter
+			router.PathRouter
+			router.StatusRouter
+			router.TLSRouter
+			router.WeightedRouter
+		}{
+			base,
+			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
 			apiRouterWithHealthcheckSupportInst,
+			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["status"] && supports["tls"] {
+	if !supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.CNameRouter
+			router.CustomHealthcheckRouter
 			router.InfoRouter
+			router.PathRouter
 			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
 			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && !supports["healthcheck"] && supports["info"] && supports["status"] && supports["tls"] {
+	if supports["acme"] && !supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.ACMEChallengeRouter
 			router.CNameRouter
+			router.CustomHealthcheckRouter
 			router.InfoRouter
+			router.PathRouter
 			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithACMESupportInst,
 			apiRouterWithCnameSupportInst,
+			apiRouterWithHealthcheckSupportInst,
 			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if !supports["cname"] && supports["healthcheck"] && supports["info"] && supports["status"] && supports["tls"] {
+	if !supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.BackendLister
+			router.CNameRouter
 			router.CustomHealthcheckRouter
 			router.InfoRouter
+			router.PathRouter
 			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithBackendListInst,
+			apiRouterWithCnameSupportInst,
 			apiRouterWithHealthcheckSupportInst,
 			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
-	if supports["cname"] && supports["healthcheck"] && supports["info"] && supports["status"] && supports["tls"] {
+	if supports["acme"] && supports["backends"] && supports["cname"] && supports["healthcheck"] && supports["info"] && supports["path"] && supports["status"] && supports["tls"] && supports["weighted"] {
 		return &struct {
 			router.Router
 			router.OptsRouter
+			router.ACMEChallengeRouter
+			router.BackendLister
 			router.CNameRouter
 			router.CustomHealthcheckRouter
 			router.InfoRouter
+			router.PathRouter
 			router.StatusRouter
 			router.TLSRouter
+			router.WeightedRouter
 		}{
 			base,
 			base,
+			apiRouterWithACMESupportInst,
+			apiRouterWithBackendListInst,
 			apiRouterWithCnameSupportInst,
 			apiRouterWithHealthcheckSupportInst,
 			apiRouterWithInfoInst,
+			apiRouterWithPathSupportInst,
 			apiRouterWithStatusInst,
 			apiRouterWithTLSSupportInst,
+			apiRouterWithWeightSupportInst,
 		}
 	}
 	return nil
@@ -0,0 +1,371 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configfile
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/exec"
+	"github.com/tsuru/tsuru/router"
+)
+
+var (
+	execut exec.Executor
+
+	renderMu    sync.Mutex
+	renderLocks = map[string]*sync.Mutex{}
+)
+
+func executor() exec.Executor {
+	if execut == nil {
+		execut = exec.OsExecutor{}
+	}
+	return execut
+}
+
+// runCommand runs cmd with the shell. The configuration file being checked
+// or loaded is exported to the command in the TSURU_CONFIG_FILE variable.
+func runCommand(cmd, configFile string) error {
+	var buf bytes.Buffer
+	err := executor().Execute(exec.ExecuteOptions{
+		Cmd:    "/bin/sh",
+		Args:   []string{"-c", cmd},
+		Envs:   append(os.Environ(), "TSURU_CONFIG_FILE="+configFile),
+		Stdout: &buf,
+		Stderr: &buf,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error running %q: %s", cmd, strings.TrimSpace(buf.String()))
+	}
+	return nil
+}
+
+func routerLock(name string) *sync.Mutex {
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	if renderLocks[name] == nil {
+		renderLocks[name] = &sync.Mutex{}
+	}
+	return renderLocks[name]
+}
+
+type templateData struct {
+	Domain          string
+	HTTPPort        int
+	HTTPSPort       int
+	CertificatesDir string
+	Backends        []templateBackend
+}
+
+type templateBackend struct {
+	Name           string
+	Hosts          []string
+	Routes         []string
+	Healthcheck    router.HealthcheckData
+	Certificates   []templateCertificate
+	ACMEChallenges []acmeChallengeData
+}
+
+type templateCertificate struct {
+	CName string
+	Path  string
+}
+
+// render writes the certificates and the configuration file of the router
+// and then runs the reload command. The configuration file is written to a
+// temporary file, validated by the check command and renamed, so the server
+// never reads a partial or invalid file.
+func (r *configFileRouter) render(op string) error {
+	lock := routerLock(r.routerName)
+	lock.Lock()
+	defer lock.Unlock()
+	err := r.renderAndReload()
+	if err != nil {
+		return &router.RouterError{Op: op, Err: err}
+	}
+	return nil
+}
+
+func (r *configFileRouter) renderAndReload() error {
+	configFile, _ := config.GetString(r.prefix + ":config-file")
+	certsDir, _ := config.GetString(r.prefix + ":certificates-dir")
+	if certsDir == "" {
+		certsDir = filepath.Join(filepath.Dir(configFile), "tsuru-certs")
+	}
+	httpPort, _ := config.GetInt(r.prefix + ":http-port")
+	if httpPort == 0 {
+		httpPort = 80
+	}
+	httpsPort, _ := config.GetInt(r.prefix + ":https-port")
+	if httpsPort == 0 {
+		httpsPort = 443
+	}
+	tpl, err := r.template()
+	if err != nil {
+		return err
+	}
+	backends, err := r.listBackends()
+	if err != nil {
+		return err
+	}
+	data := templateData{
+		Domain:          r.domain(),
+		HTTPPort:        httpPort,
+		HTTPSPort:       httpsPort,
+		CertificatesDir: certsDir,
+	}
+	written := map[string]struct{}{}
+	for _, b := range backends {
+		tb := templateBackend{
+			Name:           b.Name,
+			Hosts:          append([]string{b.Name + "." + data.Domain}, b.CNames...),
+			Routes:         b.Routes,
+			Healthcheck:    b.Healthcheck,
+			ACMEChallenges: b.ACMEChallenges,
+		}
+		for _, cert := range b.Certificates {
+			path, err := r.writeCertificate(certsDir, cert)
+			if err != nil {
+				return err
+			}
+			for _, f := range r.certificateFiles(path) {
+				written[f] = struct{}{}
+			}
+			tb.Certificates = append(tb.Certificates, templateCertificate{CName: cert.CName, Path: path})
+		}
+		data.Backends = append(data.Backends, tb)
+	}
+	var buf bytes.Buffer
+	err = tpl.Execute(&buf, data)
+	if err != nil {
+		return errors.Wrap(err, "unable to render config file")
+	}
+	tmpFile, err := writeTempFile(configFile, buf.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile)
+	checkCmd, _ := config.GetString(r.prefix + ":check-command")
+	if strings.TrimSpace(checkCmd) != "" {
+		err = runCommand(checkCmd, tmpFile)
+		if err != nil {
+			return errors.Wrap(err, "rendered config file is invalid, keeping the previous one")
+		}
+	}
+	err = os.Rename(tmpFile, configFile)
+	if err != nil {
+		return err
+	}
+	reloadCmd, _ := config.GetString(r.prefix + ":reload-command")
+	if strings.TrimSpace(reloadCmd) != "" {
+		err = runCommand(reloadCmd, configFile)
+		if err != nil {
+			return err
+		}
+	}
+	return removeStaleCertificates(certsDir, written)
+}
+
+func (r *configFileRouter) template() (*template.Template, error) {
+	tplFile, _ := config.GetString(r.prefix + ":template")
+	if tplFile != "" {
+		return template.New(filepath.Base(tplFile)).ParseFiles(tplFile)
+	}
+	if r.routerType == haproxyRouterType {
+		return haproxyTemplate, nil
+	}
+	return nginxTemplate, nil
+}
+
+// writeCertificate stores the certificate in the format expected by the
+// server: nginx reads the certificate and the key from separate files while
+// HAProxy expects both in a single pem file.
+func (r *configFileRouter) writeCertificate(dir string, cert certificateData) (string, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Join(dir, cert.CName)
+	if r.routerType == haproxyRouterType {
+		content := strings.TrimSpace(cert.Certificate) + "\n" + strings.TrimSpace(cert.Key) + "\n"
+		return base + ".pem", writeFileAtomic(base+".pem", []byte(content), 0600)
+	}
+	err = writeFileAtomic(base+".crt", []byte(cert.Certificate), 0644)
+	if err != nil {
+		return "", err
+	}
+	return base, writeFileAtomic(base+".key", []byte(cert.Key), 0600)
+}
+
+// certificateFiles returns the files written by writeCertificate for the
+// certificate stored in path.
+func (r *configFileRouter) certificateFiles(path string) []string {
+	if r.routerType == haproxyRouterType {
+		return []string{path}
+	}
+	return []string{path + ".crt", path + ".key"}
+}
+
+// removeStaleCertificates removes the certificate files in dir not written in
+// the last render, left behind by removed certificates and backends. It runs
+// after the reload, so the server never references a removed file.
+func removeStaleCertificates(dir string, written map[string]struct{}) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		switch filepath.Ext(f.Name()) {
+		case ".crt", ".key", ".pem":
+		default:
+			continue
+		}
+		path := filepath.Join(dir, f.Name())
+		if _, ok := written[path]; ok {
+			continue
+		}
+		err = os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	tmpFile, err := writeTempFile(path, content, perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile)
+	return os.Rename(tmpFile, path)
+}
+
+// writeTempFile writes content to a temporary file in the directory of path,
+// so it can be renamed to path atomically, and returns its name.
+func writeTempFile(path string, content []byte, perm os.FileMode) (string, error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(content)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), perm)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+var nginxTemplate = template.Must(template.New("nginx").Parse(`# generated by tsuru, do not edit
+{{- range $b := .Backends}}
+
+upstream {{$b.Name}} {
+{{- range $b.Routes}}
+    server {{.}};
+{{- else}}
+    server 127.0.0.1:65535 down;
+{{- end}}
+}
+
+server {
+    listen {{$.HTTPPort}};
+    server_name{{range $b.Hosts}} {{.}}{{end}};
+{{- range $b.ACMEChallenges}}
+
+    location = /.well-known/acme-challenge/{{.Token}} {
+        default_type text/plain;
+        return 200 "{{.KeyAuth}}";
+    }
+{{- end}}
+
+    location / {
+        proxy_set_header Host $host;
+        proxy_set_header X-Real-IP $remote_addr;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        proxy_pass http://{{$b.Name}};
+    }
+}
+{{- range $c := $b.Certificates}}
+
+server {
+    listen {{$.HTTPSPort}} ssl;
+    server_name {{$c.CName}};
+    ssl_certificate {{$c.Path}}.crt;
+    ssl_certificate_key {{$c.Path}}.key;
+
+    location / {
+        proxy_set_header Host $host;
+        proxy_set_header X-Real-IP $remote_addr;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        proxy_set_header X-Forwarded-Proto https;
+        proxy_pass http://{{$b.Name}};
+    }
+}
+{{- end}}
+{{- end}}
+`))
+
+var haproxyTemplate = template.Must(template.New("haproxy").Parse(`# generated by tsuru, do not edit
+{{- $hasCerts := false}}
+{{- range .Backends}}{{if .Certificates}}{{$hasCerts = true}}{{end}}{{end}}
+
+frontend tsuru
+    mode http
+    bind *:{{.HTTPPort}}
+{{- if $hasCerts}}
+    bind *:{{.HTTPSPort}} ssl crt {{.CertificatesDir}}
+{{- end}}
+    option forwardfor
+{{- range $b := .Backends}}
+{{- range $b.ACMEChallenges}}
+    http-request return status 200 content-type text/plain string "{{.KeyAuth}}" if { path /.well-known/acme-challenge/{{.Token}} }
+{{- end}}
+{{- end}}
+{{- range $b := .Backends}}
+{{- range $b.Hosts}}
+    use_backend {{$b.Name}} if { hdr(host) -i {{.}} }
+{{- end}}
+{{- end}}
+{{- range $b := .Backends}}
+
+backend {{$b.Name}}
+    mode http
+    balance roundrobin
+{{- if $b.Healthcheck.Path}}
+    option httpchk GET "{{$b.Healthcheck.Path}}"
+{{- if $b.Healthcheck.Body}}
+    http-check expect string "{{$b.Healthcheck.Body}}"
+{{- else if $b.Healthcheck.Status}}
+    http-check expect status {{$b.Healthcheck.Status}}
+{{- end}}
+{{- end}}
+{{- range $i, $route := $b.Routes}}
+    server {{$b.Name}}-{{$i}} {{$route}}{{if $b.Healthcheck.Path}} check{{end}}
+{{- end}}
+{{- end}}
+`))
@@ -0,0 +1,541 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package configfile provides a router implementation that renders the
+// configuration file of a plain nginx or HAProxy server. Backends, routes,
+// cnames and certificates are kept in the tsuru database, and the whole
+// configuration file is rendered again on each change, followed by the
+// configured reload command.
+//
+// In order to use this router, you need to define the "routers:<name>:type =
+// nginx" or "routers:<name>:type = haproxy" in your config.
+package configfile
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/db/storage"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/hc"
+	"github.com/tsuru/tsuru/router"
+)
+
+const (
+	nginxRouterType   = "nginx"
+	haproxyRouterType = "haproxy"
+)
+
+var (
+	_ router.CNameRouter             = &configFileRouter{}
+	_ router.TLSRouter               = &configFileRouter{}
+	_ router.CustomHealthcheckRouter = &configFileRouter{}
+	_ router.HealthChecker           = &configFileRouter{}
+	_ router.BackendLister           = &configFileRouter{}
+	_ router.ACMEChallengeRouter     = &configFileRouter{}
+)
+
+func init() {
+	router.Register(nginxRouterType, createNginxRouter)
+	router.Register(haproxyRouterType, createHAProxyRouter)
+	hc.AddChecker("Router nginx", router.BuildHealthCheck(nginxRouterType))
+	hc.AddChecker("Router haproxy", router.BuildHealthCheck(haproxyRouterType))
+}
+
+type configFileRouter struct {
+	routerName string
+	routerType string
+	prefix     string
+}
+
+type backendData struct {
+	ID             string `bson:"_id"`
+	Router         string
+	Name           string
+	Routes         []string
+	CNames         []string
+	Healthcheck    router.HealthcheckData
+	Certificates   []certificateData
+	ACMEChallenges []acmeChallengeData
+}
+
+type certificateData struct {
+	CName       string
+	Certificate string
+	Key         string
+}
+
+type acmeChallengeData struct {
+	Token   string
+	KeyAuth string
+}
+
+func createNginxRouter(routerName, configPrefix string) (router.Router, error) {
+	return newRouter(routerName, nginxRouterType, configPrefix)
+}
+
+func createHAProxyRouter(routerName, configPrefix string) (router.Router, error) {
+	return newRouter(routerName, haproxyRouterType, configPrefix)
+}
+
+func newRouter(routerName, routerType, configPrefix string) (router.Router, error) {
+	if _, err := config.GetString(configPrefix + ":domain"); err != nil {
+		return nil, err
+	}
+	if _, err := config.GetString(configPrefix + ":config-file"); err != nil {
+		return nil, err
+	}
+	return &configFileRouter{routerName: routerName, routerType: routerType, prefix: configPrefix}, nil
+}
+
+func (r *configFileRouter) GetName() string {
+	return r.routerName
+}
+
+func (r *configFileRouter) domain() string {
+	domain, _ := config.GetString(r.prefix + ":domain")
+	return domain
+}
+
+func (r *configFileRouter) backendID(name string) string {
+	return r.routerName + "/" + name
+}
+
+func (r *configFileRouter) collection() (*storage.Collection, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Collection("router_configfile_backends"), nil
+}
+
+// update applies change to the backend and renders the configuration file
+// again.
+func (r *configFileRouter) update(op, backendName string, change bson.M) error {
+	coll, err := r.collection()
+	if err != nil {
+		return &router.RouterError{Op: op, Err: err}
+	}
+	defer coll.Close()
+	err = coll.UpdateId(r.backendID(backendName), change)
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	if err != nil {
+		return &router.RouterError{Op: op, Err: err}
+	}
+	return r.render(op)
+}
+
+func (r *configFileRouter) getBackend(name string) (*backendData, error) {
+	coll, err := r.collection()
+	if err != nil {
+		return nil, err
+	}
+	defer coll.Close()
+	var data backendData
+	err = coll.FindId(r.backendID(name)).One(&data)
+	if err == mgo.ErrNotFound {
+		return nil, router.ErrBackendNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *configFileRouter) listBackends() ([]backendData, error) {
+	coll, err := r.collection()
+	if err != nil {
+		return nil, err
+	}
+	defer coll.Close()
+	var backends []backendData
+	err = coll.Find(bson.M{"router": r.routerName}).Sort("name").All(&backends)
+	return backends, err
+}
+
+func (r *configFileRouter) AddBackend(app router.App) (err error) {
+	name := app.GetName()
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	coll, err := r.collection()
+	if err != nil {
+		return &router.RouterError{Op: "add", Err: err}
+	}
+	defer coll.Close()
+	err = coll.Insert(backendData{
+		ID:     r.backendID(name),
+		Router: r.routerName,
+		Name:   name,
+		Routes: []string{},
+		CNames: []string{},
+	})
+	if mgo.IsDup(err) {
+		return router.ErrBackendExists
+	}
+	if err != nil {
+		return &router.RouterError{Op: "add", Err: err}
+	}
+	err = router.Store(name, name, r.routerType)
+	if err != nil {
+		return err
+	}
+	return r.render("add")
+}
+
+func (r *configFileRouter) RemoveBackend(name string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	if backendName != name {
+		return router.ErrBackendSwapped
+	}
+	coll, err := r.collection()
+	if err != nil {
+		return &router.RouterError{Op: "remove", Err: err}
+	}
+	defer coll.Close()
+	err = coll.RemoveId(r.backendID(backendName))
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	if err != nil {
+		return &router.RouterError{Op: "remove", Err: err}
+	}
+	return r.render("remove")
+}
+
+func (r *configFileRouter) AddRoutes(name string, addresses []*url.URL) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	routes := make([]string, len(addresses))
+	for i, addr := range addresses {
+		routes[i] = addr.Host
+	}
+	return r.update("add-routes", backendName, bson.M{
+		"$addToSet": bson.M{"routes": bson.M{"$each": routes}},
+	})
+}
+
+func (r *configFileRouter) RemoveRoutes(name string, addresses []*url.URL) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	routes := make([]string, len(addresses))
+	for i, addr := range addresses {
+		routes[i] = addr.Host
+	}
+	return r.update("remove-routes", backendName, bson.M{
+		"$pullAll": bson.M{"routes": routes},
+	})
+}
+
+func (r *configFileRouter) Routes(name string) (urls []*url.URL, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.getBackend(backendName)
+	if err != nil {
+		return nil, err
+	}
+	urls = make([]*url.URL, len(data.Routes))
+	for i, route := range data.Routes {
+		urls[i] = &url.URL{Scheme: router.HttpScheme, Host: route}
+	}
+	return urls, nil
+}
+
+func (r *configFileRouter) Addr(name string) (addr string, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return "", err
+	}
+	_, err = r.getBackend(backendName)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.%s", backendName, r.domain()), nil
+}
+
+func (r *configFileRouter) Swap(backend1, backend2 string, cnameOnly bool) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	return router.Swap(r, backend1, backend2, cnameOnly)
+}
+
+func (r *configFileRouter) SetCName(cname, name string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	if !router.ValidCName(cname, r.domain()) {
+		return router.ErrCNameNotAllowed
+	}
+	data, err := r.getBackend(backendName)
+	if err != nil {
+		return err
+	}
+	for _, c := range data.CNames {
+		if c == cname {
+			return router.ErrCNameExists
+		}
+	}
+	return r.update("set-cname", backendName, bson.M{
+		"$addToSet": bson.M{"cnames": cname},
+	})
+}
+
+func (r *configFileRouter) UnsetCName(cname, name string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	coll, err := r.collection()
+	if err != nil {
+		return &router.RouterError{Op: "unset-cname", Err: err}
+	}
+	defer coll.Close()
+	err = coll.Update(bson.M{"_id": r.backendID(backendName), "cnames": cname}, bson.M{
+		"$pull": bson.M{"cnames": cname},
+	})
+	if err == mgo.ErrNotFound {
+		return router.ErrCNameNotFound
+	}
+	if err != nil {
+		return &router.RouterError{Op: "unset-cname", Err: err}
+	}
+	return r.render("unset-cname")
+}
+
+func (r *configFileRouter) CNames(name string) (urls []*url.URL, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.getBackend(backendName)
+	if err != nil {
+		return nil, err
+	}
+	urls = make([]*url.URL, len(data.CNames))
+	for i, cname := range data.CNames {
+		urls[i] = &url.URL{Host: cname}
+	}
+	return urls, nil
+}
+
+func (r *configFileRouter) SetHealthcheck(name string, hcData router.HealthcheckData) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	err = validateHealthcheck(hcData)
+	if err != nil {
+		return err
+	}
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	return r.update("set-healthcheck", backendName, bson.M{
+		"$set": bson.M{"healthcheck": hcData},
+	})
+}
+
+// validateHealthcheck rejects healthcheck values that could break out of the
+// quoted strings in the rendered configuration file.
+func validateHealthcheck(hcData router.HealthcheckData) error {
+	for field, value := range map[string]string{"path": hcData.Path, "body": hcData.Body} {
+		invalid := strings.IndexFunc(value, func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.IsControl(r) || strings.ContainsRune(`"'\`, r)
+		})
+		if invalid != -1 {
+			return &tsuruErrors.ValidationError{
+				Message: fmt.Sprintf("invalid healthcheck %s %q: whitespace, control characters, quotes and backslashes are not allowed", field, value),
+			}
+		}
+	}
+	return nil
+}
+
+func (r *configFileRouter) AddCertificate(app router.App, cname, certificate, key string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(app.GetName())
+	if err != nil {
+		return err
+	}
+	coll, err := r.collection()
+	if err != nil {
+		return &router.RouterError{Op: "add-certificate", Err: err}
+	}
+	defer coll.Close()
+	err = coll.UpdateId(r.backendID(backendName), bson.M{
+		"$pull": bson.M{"certificates": bson.M{"cname": cname}},
+	})
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	if err != nil {
+		return &router.RouterError{Op: "add-certificate", Err: err}
+	}
+	return r.update("add-certificate", backendName, bson.M{
+		"$push": bson.M{"certificates": certificateData{CName: cname, Certificate: certificate, Key: key}},
+	})
+}
+
+func (r *configFileRouter) RemoveCertificate(app router.App, cname string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(app.GetName())
+	if err != nil {
+		return err
+	}
+	coll, err := r.collection()
+	if err != nil {
+		return &router.RouterError{Op: "remove-certificate", Err: err}
+	}
+	defer coll.Close()
+	err = coll.Update(bson.M{"_id": r.backendID(backendName), "certificates.cname": cname}, bson.M{
+		"$pull": bson.M{"certificates": bson.M{"cname": cname}},
+	})
+	if err == mgo.ErrNotFound {
+		return router.ErrCertificateNotFound
+	}
+	if err != nil {
+		return &router.RouterError{Op: "remove-certificate", Err: err}
+	}
+	return r.render("remove-certificate")
+}
+
+func (r *configFileRouter) GetCertificate(app router.App, cname string) (certificate string, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(app.GetName())
+	if err != nil {
+		return "", err
+	}
+	data, err := r.getBackend(backendName)
+	if err != nil {
+		return "", err
+	}
+	for _, cert := range data.Certificates {
+		if cert.CName == cname {
+			return cert.Certificate, nil
+		}
+	}
+	return "", router.ErrCertificateNotFound
+}
+
+func (r *configFileRouter) AddACMEChallenge(name, token, keyAuth string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	return r.update("add-acme-challenge", backendName, bson.M{
+		"$push": bson.M{"acmechallenges": acmeChallengeData{Token: token, KeyAuth: keyAuth}},
+	})
+}
+
+func (r *configFileRouter) RemoveACMEChallenge(name, token string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	return r.update("remove-acme-challenge", backendName, bson.M{
+		"$pull": bson.M{"acmechallenges": bson.M{"token": token}},
+	})
+}
+
+func (r *configFileRouter) Backends() (names []string, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backends, err := r.listBackends()
+	if err != nil {
+		return nil, err
+	}
+	names = make([]string, len(backends))
+	for i, b := range backends {
+		names[i] = b.Name
+	}
+	return names, nil
+}
+
+// HealthCheck runs the configured check command, like "nginx -t", ensuring
+// the server accepts the rendered configuration.
+func (r *configFileRouter) HealthCheck() (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	cmd, _ := config.GetString(r.prefix + ":check-command")
+	if strings.TrimSpace(cmd) == "" {
+		return nil
+	}
+	configFile, _ := config.GetString(r.prefix + ":config-file")
+	return runCommand(cmd, configFile)
+}
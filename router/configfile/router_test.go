@@ -0,0 +1,376 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configfile
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/db/dbtest"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/exec/exectest"
+	"github.com/tsuru/tsuru/router"
+	"github.com/tsuru/tsuru/router/routertest"
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type S struct {
+	conn     *db.Storage
+	dir      string
+	executor *exectest.FakeExecutor
+}
+
+var _ = check.Suite(&S{})
+
+func init() {
+	for _, routerType := range []string{nginxRouterType, haproxyRouterType} {
+		routerType := routerType
+		base := &S{}
+		suite := &routertest.RouterSuite{
+			SetUpSuiteFunc:   base.SetUpSuite,
+			TearDownTestFunc: base.TearDownTest,
+		}
+		suite.SetUpTestFunc = func(c *check.C) {
+			base.SetUpTest(c)
+			prefix := "routers:generic_" + routerType
+			config.Set(prefix+":domain", "configfile.router")
+			config.Set(prefix+":config-file", filepath.Join(base.dir, "tsuru.conf"))
+			config.Set(prefix+":reload-command", "reload")
+			r, err := newRouter("generic_"+routerType, routerType, prefix)
+			c.Assert(err, check.IsNil)
+			suite.Router = r
+		}
+		check.Suite(suite)
+	}
+}
+
+func (s *S) SetUpSuite(c *check.C) {
+	config.Set("log:disable-syslog", true)
+	config.Set("database:url", "127.0.0.1:27017?maxPoolSize=100")
+	config.Set("database:name", "router_configfile_tests")
+}
+
+func (s *S) SetUpTest(c *check.C) {
+	var err error
+	s.conn, err = db.Conn()
+	c.Assert(err, check.IsNil)
+	dbtest.ClearAllCollections(s.conn.Apps().Database)
+	s.dir, err = ioutil.TempDir("", "configfile")
+	c.Assert(err, check.IsNil)
+	s.executor = &exectest.FakeExecutor{}
+	execut = s.executor
+	config.Set("routers:nginx:domain", "nginx.router")
+	config.Set("routers:nginx:config-file", filepath.Join(s.dir, "nginx.conf"))
+	config.Set("routers:nginx:reload-command", "nginx -s reload")
+	config.Set("routers:haproxy:domain", "haproxy.router")
+	config.Set("routers:haproxy:config-file", filepath.Join(s.dir, "haproxy.cfg"))
+	config.Set("routers:haproxy:reload-command", "systemctl reload haproxy")
+}
+
+func (s *S) TearDownTest(c *check.C) {
+	execut = nil
+	os.RemoveAll(s.dir)
+	s.conn.Close()
+}
+
+func (s *S) TestCreateRouterRequiresConfigFile(c *check.C) {
+	config.Unset("routers:nginx:config-file")
+	_, err := createNginxRouter("nginx", "routers:nginx")
+	c.Assert(err, check.NotNil)
+}
+
+func (s *S) TestNginxRenderConfigAndReload(c *check.C) {
+	r, err := createNginxRouter("nginx", "routers:nginx")
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = r.AddRoutes("myapp", []*url.URL{{Scheme: "http", Host: "10.0.0.1:8080"}})
+	c.Assert(err, check.IsNil)
+	err = r.(router.CNameRouter).SetCName("myapp.example.com", "myapp")
+	c.Assert(err, check.IsNil)
+	err = r.(router.TLSRouter).AddCertificate(routertest.FakeApp{Name: "myapp"}, "myapp.example.com", "my-cert", "my-key")
+	c.Assert(err, check.IsNil)
+	content, err := ioutil.ReadFile(filepath.Join(s.dir, "nginx.conf"))
+	c.Assert(err, check.IsNil)
+	certPath := filepath.Join(s.dir, "tsuru-certs", "myapp.example.com")
+	c.Assert(string(content), check.Equals, `# generated by tsuru, do not edit
+
+upstream myapp {
+    server 10.0.0.1:8080;
+}
+
+server {
+    listen 80;
+    server_name myapp.nginx.router myapp.example.com;
+
+    location / {
+        proxy_set_header Host $host;
+        proxy_set_header X-Real-IP $remote_addr;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        proxy_pass http://myapp;
+    }
+}
+
+server {
+    listen 443 ssl;
+    server_name myapp.example.com;
+    ssl_certificate `+certPath+`.crt;
+    ssl_certificate_key `+certPath+`.key;
+
+    location / {
+        proxy_set_header Host $host;
+        proxy_set_header X-Real-IP $remote_addr;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        proxy_set_header X-Forwarded-Proto https;
+        proxy_pass http://myapp;
+    }
+}
+`)
+	key, err := ioutil.ReadFile(certPath + ".key")
+	c.Assert(err, check.IsNil)
+	c.Assert(string(key), check.Equals, "my-key")
+	cmds := s.executor.GetCommands("/bin/sh")
+	c.Assert(cmds, check.HasLen, 4)
+	c.Assert(cmds[3].GetArgs(), check.DeepEquals, []string{"-c", "nginx -s reload"})
+}
+
+func (s *S) TestHAProxyRenderConfig(c *check.C) {
+	r, err := createHAProxyRouter("haproxy", "routers:haproxy")
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = r.AddRoutes("myapp", []*url.URL{{Scheme: "http", Host: "10.0.0.1:8080"}, {Scheme: "http", Host: "10.0.0.2:8080"}})
+	c.Assert(err, check.IsNil)
+	err = r.(router.CustomHealthcheckRouter).SetHealthcheck("myapp", router.HealthcheckData{Path: "/healthcheck", Status: 200})
+	c.Assert(err, check.IsNil)
+	err = r.(router.TLSRouter).AddCertificate(routertest.FakeApp{Name: "myapp"}, "myapp.haproxy.router", "my-cert", "my-key")
+	c.Assert(err, check.IsNil)
+	content, err := ioutil.ReadFile(filepath.Join(s.dir, "haproxy.cfg"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(content), check.Equals, `# generated by tsuru, do not edit
+
+frontend tsuru
+    mode http
+    bind *:80
+    bind *:443 ssl crt `+filepath.Join(s.dir, "tsuru-certs")+`
+    option forwardfor
+    use_backend myapp if { hdr(host) -i myapp.haproxy.router }
+
+backend myapp
+    mode http
+    balance roundrobin
+    option httpchk GET "/healthcheck"
+    http-check expect status 200
+    server myapp-0 10.0.0.1:8080 check
+    server myapp-1 10.0.0.2:8080 check
+`)
+	pem, err := ioutil.ReadFile(filepath.Join(s.dir, "tsuru-certs", "myapp.haproxy.router.pem"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(pem), check.Equals, "my-cert\nmy-key\n")
+}
+
+func (s *S) TestNginxACMEChallenges(c *check.C) {
+	r, err := createNginxRouter("nginx", "routers:nginx")
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = r.(router.ACMEChallengeRouter).AddACMEChallenge("myapp", "token1", "token1.thumb")
+	c.Assert(err, check.IsNil)
+	content, err := ioutil.ReadFile(filepath.Join(s.dir, "nginx.conf"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(content), check.Equals, `# generated by tsuru, do not edit
+
+upstream myapp {
+    server 127.0.0.1:65535 down;
+}
+
+server {
+    listen 80;
+    server_name myapp.nginx.router;
+
+    location = /.well-known/acme-challenge/token1 {
+        default_type text/plain;
+        return 200 "token1.thumb";
+    }
+
+    location / {
+        proxy_set_header Host $host;
+        proxy_set_header X-Real-IP $remote_addr;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        proxy_pass http://myapp;
+    }
+}
+`)
+	err = r.(router.ACMEChallengeRouter).RemoveACMEChallenge("myapp", "token1")
+	c.Assert(err, check.IsNil)
+	content, err = ioutil.ReadFile(filepath.Join(s.dir, "nginx.conf"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(content), check.Not(check.Matches), "(?s).*acme-challenge.*")
+	err = r.(router.ACMEChallengeRouter).AddACMEChallenge("unknown", "token1", "token1.thumb")
+	c.Assert(err, check.Equals, router.ErrBackendNotFound)
+}
+
+func (s *S) TestHAProxyACMEChallenges(c *check.C) {
+	r, err := createHAProxyRouter("haproxy", "routers:haproxy")
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = r.(router.ACMEChallengeRouter).AddACMEChallenge("myapp", "token1", "token1.thumb")
+	c.Assert(err, check.IsNil)
+	content, err := ioutil.ReadFile(filepath.Join(s.dir, "haproxy.cfg"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(content), check.Equals, `# generated by tsuru, do not edit
+
+frontend tsuru
+    mode http
+    bind *:80
+    option forwardfor
+    http-request return status 200 content-type text/plain string "token1.thumb" if { path /.well-known/acme-challenge/token1 }
+    use_backend myapp if { hdr(host) -i myapp.haproxy.router }
+
+backend myapp
+    mode http
+    balance roundrobin
+`)
+}
+
+func (s *S) TestRemoveCertificate(c *check.C) {
+	r, err := createNginxRouter("nginx", "routers:nginx")
+	c.Assert(err, check.IsNil)
+	tlsRouter := r.(router.TLSRouter)
+	app := routertest.FakeApp{Name: "myapp"}
+	err = r.AddBackend(app)
+	c.Assert(err, check.IsNil)
+	err = tlsRouter.AddCertificate(app, "myapp.example.com", "cert1", "key1")
+	c.Assert(err, check.IsNil)
+	err = tlsRouter.AddCertificate(app, "myapp.example.com", "cert2", "key2")
+	c.Assert(err, check.IsNil)
+	cert, err := tlsRouter.GetCertificate(app, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	c.Assert(cert, check.Equals, "cert2")
+	certsDir := filepath.Join(s.dir, "tsuru-certs")
+	_, err = os.Stat(filepath.Join(certsDir, "myapp.example.com.crt"))
+	c.Assert(err, check.IsNil)
+	err = tlsRouter.RemoveCertificate(app, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	_, err = tlsRouter.GetCertificate(app, "myapp.example.com")
+	c.Assert(err, check.Equals, router.ErrCertificateNotFound)
+	_, err = os.Stat(filepath.Join(certsDir, "myapp.example.com.crt"))
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+	_, err = os.Stat(filepath.Join(certsDir, "myapp.example.com.key"))
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+	err = tlsRouter.RemoveCertificate(app, "myapp.example.com")
+	c.Assert(err, check.Equals, router.ErrCertificateNotFound)
+}
+
+func (s *S) TestRemoveBackendRemovesCertificates(c *check.C) {
+	r, err := createHAProxyRouter("haproxy", "routers:haproxy")
+	c.Assert(err, check.IsNil)
+	tlsRouter := r.(router.TLSRouter)
+	for _, name := range []string{"myapp", "otherapp"} {
+		app := routertest.FakeApp{Name: name}
+		err = r.AddBackend(app)
+		c.Assert(err, check.IsNil)
+		err = tlsRouter.AddCertificate(app, name+".example.com", "cert", "key")
+		c.Assert(err, check.IsNil)
+	}
+	certsDir := filepath.Join(s.dir, "tsuru-certs")
+	err = ioutil.WriteFile(filepath.Join(certsDir, "README"), []byte("not a certificate"), 0644)
+	c.Assert(err, check.IsNil)
+	err = r.RemoveBackend("myapp")
+	c.Assert(err, check.IsNil)
+	files, err := ioutil.ReadDir(certsDir)
+	c.Assert(err, check.IsNil)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	c.Assert(names, check.DeepEquals, []string{"README", "otherapp.example.com.pem"})
+}
+
+func (s *S) TestReloadCommandFailure(c *check.C) {
+	execut = &exectest.ErrorExecutor{}
+	r, err := createNginxRouter("nginx", "routers:nginx")
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.ErrorMatches, `(?s).*error running "nginx -s reload".*`)
+}
+
+func (s *S) TestCheckCommandFailureKeepsConfigFile(c *check.C) {
+	r, err := createNginxRouter("nginx", "routers:nginx")
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	previous, err := ioutil.ReadFile(filepath.Join(s.dir, "nginx.conf"))
+	c.Assert(err, check.IsNil)
+	config.Set("routers:nginx:check-command", "nginx -t -c $TSURU_CONFIG_FILE")
+	defer config.Unset("routers:nginx:check-command")
+	execut = &exectest.ErrorExecutor{}
+	err = r.AddBackend(routertest.FakeApp{Name: "otherapp"})
+	c.Assert(err, check.ErrorMatches, `(?s).*rendered config file is invalid.*`)
+	content, err := ioutil.ReadFile(filepath.Join(s.dir, "nginx.conf"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(content), check.Equals, string(previous))
+	files, err := ioutil.ReadDir(s.dir)
+	c.Assert(err, check.IsNil)
+	c.Assert(files, check.HasLen, 1)
+}
+
+func (s *S) TestCheckCommandRunsBeforeReload(c *check.C) {
+	config.Set("routers:nginx:check-command", "nginx -t -c $TSURU_CONFIG_FILE")
+	defer config.Unset("routers:nginx:check-command")
+	r, err := createNginxRouter("nginx", "routers:nginx")
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	cmds := s.executor.GetCommands("/bin/sh")
+	c.Assert(cmds, check.HasLen, 2)
+	c.Assert(cmds[0].GetArgs(), check.DeepEquals, []string{"-c", "nginx -t -c $TSURU_CONFIG_FILE"})
+	envs := cmds[0].GetEnvs()
+	c.Assert(envs[len(envs)-1], check.Matches, "TSURU_CONFIG_FILE="+regexp.QuoteMeta(filepath.Join(s.dir, ".nginx.conf"))+".+")
+	c.Assert(cmds[1].GetArgs(), check.DeepEquals, []string{"-c", "nginx -s reload"})
+}
+
+func (s *S) TestSetHealthcheckRejectsInvalidValues(c *check.C) {
+	r, err := createHAProxyRouter("haproxy", "routers:haproxy")
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	hcRouter := r.(router.CustomHealthcheckRouter)
+	for _, hc := range []router.HealthcheckData{
+		{Path: "/hc\n    server evil 10.0.0.9:80"},
+		{Path: "/hc check"},
+		{Path: "/hc\"quoted"},
+		{Path: "/hc", Body: "WORKING\nbackend evil"},
+		{Path: "/hc", Body: "it's"},
+	} {
+		err = hcRouter.SetHealthcheck("myapp", hc)
+		_, isValidation := err.(*tsuruErrors.ValidationError)
+		c.Assert(isValidation, check.Equals, true, check.Commentf("%#v", hc))
+	}
+	content, err := ioutil.ReadFile(filepath.Join(s.dir, "haproxy.cfg"))
+	c.Assert(err, check.IsNil)
+	c.Assert(string(content), check.Not(check.Matches), "(?s).*httpchk.*")
+}
+
+func (s *S) TestHealthCheck(c *check.C) {
+	config.Set("routers:nginx:check-command", "nginx -t")
+	defer config.Unset("routers:nginx:check-command")
+	r, err := createNginxRouter("nginx", "routers:nginx")
+	c.Assert(err, check.IsNil)
+	err = r.(router.HealthChecker).HealthCheck()
+	c.Assert(err, check.IsNil)
+	c.Assert(s.executor.ExecutedCmd("/bin/sh", []string{"-c", "nginx -t"}), check.Equals, true)
+}
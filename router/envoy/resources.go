@@ -0,0 +1,381 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envoy
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/duration"
+)
+
+// The types in this file mirror the subset of the Envoy v2 API messages
+// (envoy/api/v2/*.proto) used by the router. Only the fields set by tsuru
+// are declared, keeping the original field numbers, so the wire format is
+// the same as the one produced by the generated code.
+
+const (
+	listenerType = "type.googleapis.com/envoy.api.v2.Listener"
+	routeType    = "type.googleapis.com/envoy.api.v2.RouteConfiguration"
+	clusterType  = "type.googleapis.com/envoy.api.v2.Cluster"
+	endpointType = "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment"
+
+	httpConnectionManagerType = "type.googleapis.com/envoy.config.filter.network.http_connection_manager.v2.HttpConnectionManager"
+)
+
+type discoveryRequest struct {
+	VersionInfo   string    `protobuf:"bytes,1,opt,name=version_info"`
+	Node          *node     `protobuf:"bytes,2,opt,name=node"`
+	ResourceNames []string  `protobuf:"bytes,3,rep,name=resource_names"`
+	TypeUrl       string    `protobuf:"bytes,4,opt,name=type_url"`
+	ResponseNonce string    `protobuf:"bytes,5,opt,name=response_nonce"`
+	ErrorDetail   *rpcError `protobuf:"bytes,6,opt,name=error_detail"`
+}
+
+func (m *discoveryRequest) Reset()         { *m = discoveryRequest{} }
+func (m *discoveryRequest) String() string { return proto.CompactTextString(m) }
+func (*discoveryRequest) ProtoMessage()    {}
+
+type discoveryResponse struct {
+	VersionInfo string     `protobuf:"bytes,1,opt,name=version_info"`
+	Resources   []*any.Any `protobuf:"bytes,2,rep,name=resources"`
+	TypeUrl     string     `protobuf:"bytes,4,opt,name=type_url"`
+	Nonce       string     `protobuf:"bytes,5,opt,name=nonce"`
+}
+
+func (m *discoveryResponse) Reset()         { *m = discoveryResponse{} }
+func (m *discoveryResponse) String() string { return proto.CompactTextString(m) }
+func (*discoveryResponse) ProtoMessage()    {}
+
+type node struct {
+	Id      string `protobuf:"bytes,1,opt,name=id"`
+	Cluster string `protobuf:"bytes,2,opt,name=cluster"`
+}
+
+func (m *node) Reset()         { *m = node{} }
+func (m *node) String() string { return proto.CompactTextString(m) }
+func (*node) ProtoMessage()    {}
+
+// rpcError mirrors google.rpc.Status.
+type rpcError struct {
+	Code    int32  `protobuf:"varint,1,opt,name=code"`
+	Message string `protobuf:"bytes,2,opt,name=message"`
+}
+
+func (m *rpcError) Reset()         { *m = rpcError{} }
+func (m *rpcError) String() string { return proto.CompactTextString(m) }
+func (*rpcError) ProtoMessage()    {}
+
+// uint32Value mirrors google.protobuf.UInt32Value.
+type uint32Value struct {
+	Value uint32 `protobuf:"varint,1,opt,name=value"`
+}
+
+func (m *uint32Value) Reset()         { *m = uint32Value{} }
+func (m *uint32Value) String() string { return proto.CompactTextString(m) }
+func (*uint32Value) ProtoMessage()    {}
+
+type address struct {
+	SocketAddress *socketAddress `protobuf:"bytes,1,opt,name=socket_address"`
+}
+
+func (m *address) Reset()         { *m = address{} }
+func (m *address) String() string { return proto.CompactTextString(m) }
+func (*address) ProtoMessage()    {}
+
+type socketAddress struct {
+	Address   string `protobuf:"bytes,2,opt,name=address"`
+	PortValue uint32 `protobuf:"varint,3,opt,name=port_value"`
+}
+
+func (m *socketAddress) Reset()         { *m = socketAddress{} }
+func (m *socketAddress) String() string { return proto.CompactTextString(m) }
+func (*socketAddress) ProtoMessage()    {}
+
+type cluster struct {
+	Name             string             `protobuf:"bytes,1,opt,name=name"`
+	Type             int32              `protobuf:"varint,2,opt,name=type"`
+	EdsClusterConfig *edsClusterConfig  `protobuf:"bytes,3,opt,name=eds_cluster_config"`
+	ConnectTimeout   *duration.Duration `protobuf:"bytes,4,opt,name=connect_timeout"`
+	HealthChecks     []*healthCheck     `protobuf:"bytes,8,rep,name=health_checks"`
+}
+
+func (m *cluster) Reset()         { *m = cluster{} }
+func (m *cluster) String() string { return proto.CompactTextString(m) }
+func (*cluster) ProtoMessage()    {}
+
+// clusterTypeEDS is the EDS value of the Cluster.DiscoveryType enum.
+const clusterTypeEDS = 3
+
+type edsClusterConfig struct {
+	EdsConfig *configSource `protobuf:"bytes,1,opt,name=eds_config"`
+}
+
+func (m *edsClusterConfig) Reset()         { *m = edsClusterConfig{} }
+func (m *edsClusterConfig) String() string { return proto.CompactTextString(m) }
+func (*edsClusterConfig) ProtoMessage()    {}
+
+type configSource struct {
+	Ads *aggregatedConfigSource `protobuf:"bytes,3,opt,name=ads"`
+}
+
+func (m *configSource) Reset()         { *m = configSource{} }
+func (m *configSource) String() string { return proto.CompactTextString(m) }
+func (*configSource) ProtoMessage()    {}
+
+type aggregatedConfigSource struct{}
+
+func (m *aggregatedConfigSource) Reset()         { *m = aggregatedConfigSource{} }
+func (m *aggregatedConfigSource) String() string { return proto.CompactTextString(m) }
+func (*aggregatedConfigSource) ProtoMessage()    {}
+
+type healthCheck struct {
+	Timeout            *duration.Duration `protobuf:"bytes,1,opt,name=timeout"`
+	Interval           *duration.Duration `protobuf:"bytes,2,opt,name=interval"`
+	UnhealthyThreshold *uint32Value       `protobuf:"bytes,4,opt,name=unhealthy_threshold"`
+	HealthyThreshold   *uint32Value       `protobuf:"bytes,5,opt,name=healthy_threshold"`
+	HttpHealthCheck    *httpHealthCheck   `protobuf:"bytes,8,opt,name=http_health_check"`
+}
+
+func (m *healthCheck) Reset()         { *m = healthCheck{} }
+func (m *healthCheck) String() string { return proto.CompactTextString(m) }
+func (*healthCheck) ProtoMessage()    {}
+
+type httpHealthCheck struct {
+	Path             string        `protobuf:"bytes,2,opt,name=path"`
+	ExpectedStatuses []*int64Range `protobuf:"bytes,9,rep,name=expected_statuses"`
+}
+
+func (m *httpHealthCheck) Reset()         { *m = httpHealthCheck{} }
+func (m *httpHealthCheck) String() string { return proto.CompactTextString(m) }
+func (*httpHealthCheck) ProtoMessage()    {}
+
+type int64Range struct {
+	Start int64 `protobuf:"varint,1,opt,name=start"`
+	End   int64 `protobuf:"varint,2,opt,name=end"`
+}
+
+func (m *int64Range) Reset()         { *m = int64Range{} }
+func (m *int64Range) String() string { return proto.CompactTextString(m) }
+func (*int64Range) ProtoMessage()    {}
+
+type clusterLoadAssignment struct {
+	ClusterName string                 `protobuf:"bytes,1,opt,name=cluster_name"`
+	Endpoints   []*localityLbEndpoints `protobuf:"bytes,2,rep,name=endpoints"`
+}
+
+func (m *clusterLoadAssignment) Reset()         { *m = clusterLoadAssignment{} }
+func (m *clusterLoadAssignment) String() string { return proto.CompactTextString(m) }
+func (*clusterLoadAssignment) ProtoMessage()    {}
+
+type localityLbEndpoints struct {
+	LbEndpoints []*lbEndpoint `protobuf:"bytes,2,rep,name=lb_endpoints"`
+}
+
+func (m *localityLbEndpoints) Reset()         { *m = localityLbEndpoints{} }
+func (m *localityLbEndpoints) String() string { return proto.CompactTextString(m) }
+func (*localityLbEndpoints) ProtoMessage()    {}
+
+type lbEndpoint struct {
+	Endpoint *endpoint `protobuf:"bytes,1,opt,name=endpoint"`
+}
+
+func (m *lbEndpoint) Reset()         { *m = lbEndpoint{} }
+func (m *lbEndpoint) String() string { return proto.CompactTextString(m) }
+func (*lbEndpoint) ProtoMessage()    {}
+
+type endpoint struct {
+	Address *address `protobuf:"bytes,1,opt,name=address"`
+}
+
+func (m *endpoint) Reset()         { *m = endpoint{} }
+func (m *endpoint) String() string { return proto.CompactTextString(m) }
+func (*endpoint) ProtoMessage()    {}
+
+type listener struct {
+	Name            string            `protobuf:"bytes,1,opt,name=name"`
+	Address         *address          `protobuf:"bytes,2,opt,name=address"`
+	FilterChains    []*filterChain    `protobuf:"bytes,3,rep,name=filter_chains"`
+	ListenerFilters []*listenerFilter `protobuf:"bytes,9,rep,name=listener_filters"`
+}
+
+func (m *listener) Reset()         { *m = listener{} }
+func (m *listener) String() string { return proto.CompactTextString(m) }
+func (*listener) ProtoMessage()    {}
+
+type listenerFilter struct {
+	Name string `protobuf:"bytes,1,opt,name=name"`
+}
+
+func (m *listenerFilter) Reset()         { *m = listenerFilter{} }
+func (m *listenerFilter) String() string { return proto.CompactTextString(m) }
+func (*listenerFilter) ProtoMessage()    {}
+
+type filterChain struct {
+	FilterChainMatch *filterChainMatch     `protobuf:"bytes,1,opt,name=filter_chain_match"`
+	TlsContext       *downstreamTlsContext `protobuf:"bytes,2,opt,name=tls_context"`
+	Filters          []*filter             `protobuf:"bytes,3,rep,name=filters"`
+}
+
+func (m *filterChain) Reset()         { *m = filterChain{} }
+func (m *filterChain) String() string { return proto.CompactTextString(m) }
+func (*filterChain) ProtoMessage()    {}
+
+type filterChainMatch struct {
+	ServerNames []string `protobuf:"bytes,11,rep,name=server_names"`
+}
+
+func (m *filterChainMatch) Reset()         { *m = filterChainMatch{} }
+func (m *filterChainMatch) String() string { return proto.CompactTextString(m) }
+func (*filterChainMatch) ProtoMessage()    {}
+
+type filter struct {
+	Name        string   `protobuf:"bytes,1,opt,name=name"`
+	TypedConfig *any.Any `protobuf:"bytes,4,opt,name=typed_config"`
+}
+
+func (m *filter) Reset()         { *m = filter{} }
+func (m *filter) String() string { return proto.CompactTextString(m) }
+func (*filter) ProtoMessage()    {}
+
+type downstreamTlsContext struct {
+	CommonTlsContext *commonTlsContext `protobuf:"bytes,1,opt,name=common_tls_context"`
+}
+
+func (m *downstreamTlsContext) Reset()         { *m = downstreamTlsContext{} }
+func (m *downstreamTlsContext) String() string { return proto.CompactTextString(m) }
+func (*downstreamTlsContext) ProtoMessage()    {}
+
+type commonTlsContext struct {
+	TlsCertificates []*tlsCertificate `protobuf:"bytes,2,rep,name=tls_certificates"`
+}
+
+func (m *commonTlsContext) Reset()         { *m = commonTlsContext{} }
+func (m *commonTlsContext) String() string { return proto.CompactTextString(m) }
+func (*commonTlsContext) ProtoMessage()    {}
+
+type tlsCertificate struct {
+	CertificateChain *dataSource `protobuf:"bytes,1,opt,name=certificate_chain"`
+	PrivateKey       *dataSource `protobuf:"bytes,2,opt,name=private_key"`
+}
+
+func (m *tlsCertificate) Reset()         { *m = tlsCertificate{} }
+func (m *tlsCertificate) String() string { return proto.CompactTextString(m) }
+func (*tlsCertificate) ProtoMessage()    {}
+
+type dataSource struct {
+	InlineString string `protobuf:"bytes,3,opt,name=inline_string"`
+}
+
+func (m *dataSource) Reset()         { *m = dataSource{} }
+func (m *dataSource) String() string { return proto.CompactTextString(m) }
+func (*dataSource) ProtoMessage()    {}
+
+type httpConnectionManager struct {
+	StatPrefix  string        `protobuf:"bytes,2,opt,name=stat_prefix"`
+	Rds         *rds          `protobuf:"bytes,3,opt,name=rds"`
+	HttpFilters []*httpFilter `protobuf:"bytes,5,rep,name=http_filters"`
+}
+
+func (m *httpConnectionManager) Reset()         { *m = httpConnectionManager{} }
+func (m *httpConnectionManager) String() string { return proto.CompactTextString(m) }
+func (*httpConnectionManager) ProtoMessage()    {}
+
+type rds struct {
+	ConfigSource    *configSource `protobuf:"bytes,1,opt,name=config_source"`
+	RouteConfigName string        `protobuf:"bytes,2,opt,name=route_config_name"`
+}
+
+func (m *rds) Reset()         { *m = rds{} }
+func (m *rds) String() string { return proto.CompactTextString(m) }
+func (*rds) ProtoMessage()    {}
+
+type httpFilter struct {
+	Name string `protobuf:"bytes,1,opt,name=name"`
+}
+
+func (m *httpFilter) Reset()         { *m = httpFilter{} }
+func (m *httpFilter) String() string { return proto.CompactTextString(m) }
+func (*httpFilter) ProtoMessage()    {}
+
+type routeConfiguration struct {
+	Name         string         `protobuf:"bytes,1,opt,name=name"`
+	VirtualHosts []*virtualHost `protobuf:"bytes,2,rep,name=virtual_hosts"`
+}
+
+func (m *routeConfiguration) Reset()         { *m = routeConfiguration{} }
+func (m *routeConfiguration) String() string { return proto.CompactTextString(m) }
+func (*routeConfiguration) ProtoMessage()    {}
+
+type virtualHost struct {
+	Name    string   `protobuf:"bytes,1,opt,name=name"`
+	Domains []string `protobuf:"bytes,2,rep,name=domains"`
+	Routes  []*route `protobuf:"bytes,3,rep,name=routes"`
+}
+
+func (m *virtualHost) Reset()         { *m = virtualHost{} }
+func (m *virtualHost) String() string { return proto.CompactTextString(m) }
+func (*virtualHost) ProtoMessage()    {}
+
+type route struct {
+	Match          *routeMatch           `protobuf:"bytes,1,opt,name=match"`
+	Route          *routeAction          `protobuf:"bytes,2,opt,name=route"`
+	DirectResponse *directResponseAction `protobuf:"bytes,7,opt,name=direct_response"`
+}
+
+func (m *route) Reset()         { *m = route{} }
+func (m *route) String() string { return proto.CompactTextString(m) }
+func (*route) ProtoMessage()    {}
+
+type routeMatch struct {
+	Prefix string `protobuf:"bytes,1,opt,name=prefix"`
+	Path   string `protobuf:"bytes,2,opt,name=path"`
+}
+
+func (m *routeMatch) Reset()         { *m = routeMatch{} }
+func (m *routeMatch) String() string { return proto.CompactTextString(m) }
+func (*routeMatch) ProtoMessage()    {}
+
+type routeAction struct {
+	Cluster          string           `protobuf:"bytes,1,opt,name=cluster"`
+	WeightedClusters *weightedCluster `protobuf:"bytes,3,opt,name=weighted_clusters"`
+}
+
+func (m *routeAction) Reset()         { *m = routeAction{} }
+func (m *routeAction) String() string { return proto.CompactTextString(m) }
+func (*routeAction) ProtoMessage()    {}
+
+type directResponseAction struct {
+	Status uint32      `protobuf:"varint,1,opt,name=status"`
+	Body   *dataSource `protobuf:"bytes,2,opt,name=body"`
+}
+
+func (m *directResponseAction) Reset()         { *m = directResponseAction{} }
+func (m *directResponseAction) String() string { return proto.CompactTextString(m) }
+func (*directResponseAction) ProtoMessage()    {}
+
+type weightedCluster struct {
+	Clusters []*clusterWeight `protobuf:"bytes,1,rep,name=clusters"`
+}
+
+func (m *weightedCluster) Reset()         { *m = weightedCluster{} }
+func (m *weightedCluster) String() string { return proto.CompactTextString(m) }
+func (*weightedCluster) ProtoMessage()    {}
+
+type clusterWeight struct {
+	Name   string       `protobuf:"bytes,1,opt,name=name"`
+	Weight *uint32Value `protobuf:"bytes,2,opt,name=weight"`
+}
+
+func (m *clusterWeight) Reset()         { *m = clusterWeight{} }
+func (m *clusterWeight) String() string { return proto.CompactTextString(m) }
+func (*clusterWeight) ProtoMessage()    {}
+
+func marshalAny(typeURL string, msg proto.Message) (*any.Any, error) {
+	value, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &any.Any{TypeUrl: typeURL, Value: value}, nil
+}
@@ -0,0 +1,586 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package envoy provides a router implementation that turns tsuru into an
+// xDS management server for a fleet of Envoy proxies. Backends, routes,
+// cnames, weights, healthchecks and certificates are stored in the database
+// and kept in memory, and every change is pushed to the connected proxies
+// through the LDS, RDS, CDS and EDS gRPC APIs.
+//
+// In order to use this router, you need to define the "routers:<name>:type =
+// envoy" in your config.
+package envoy
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/hc"
+	"github.com/tsuru/tsuru/router"
+)
+
+const routerType = "envoy"
+
+var (
+	_ router.CNameRouter             = &envoyRouter{}
+	_ router.TLSRouter               = &envoyRouter{}
+	_ router.CustomHealthcheckRouter = &envoyRouter{}
+	_ router.WeightedRouter          = &envoyRouter{}
+	_ router.HealthChecker           = &envoyRouter{}
+	_ router.BackendLister           = &envoyRouter{}
+	_ router.ACMEChallengeRouter     = &envoyRouter{}
+)
+
+func init() {
+	router.Register(routerType, createRouter)
+	hc.AddChecker("Router envoy", router.BuildHealthCheck(routerType))
+}
+
+type envoyRouter struct {
+	routerName string
+	prefix     string
+	state      *routerState
+}
+
+func createRouter(routerName, configPrefix string) (router.Router, error) {
+	if _, err := config.GetString(configPrefix + ":domain"); err != nil {
+		return nil, err
+	}
+	state, err := getState(routerName)
+	if err != nil {
+		return nil, err
+	}
+	return &envoyRouter{routerName: routerName, prefix: configPrefix, state: state}, nil
+}
+
+func (r *envoyRouter) GetName() string {
+	return r.routerName
+}
+
+func (r *envoyRouter) domain() string {
+	domain, _ := config.GetString(r.prefix + ":domain")
+	return domain
+}
+
+// update applies change to the backend in the database and then refreshes
+// the in memory state, notifying the connected proxies.
+func (r *envoyRouter) update(op, backendName string, query, change bson.M) error {
+	coll, err := collection()
+	if err != nil {
+		return &router.RouterError{Op: op, Err: err}
+	}
+	defer coll.Close()
+	query["_id"] = backendID(r.routerName, backendName)
+	err = coll.Update(query, change)
+	if err == mgo.ErrNotFound {
+		return err
+	}
+	if err != nil {
+		return &router.RouterError{Op: op, Err: err}
+	}
+	return r.sync(op, backendName)
+}
+
+// sync refreshes the backend in the in memory state after a change and
+// increments the version of the router in the database, so the change is
+// picked up by the other tsuru API instances.
+func (r *envoyRouter) sync(op, backendName string) error {
+	err := r.state.incDBVersion()
+	if err == nil {
+		err = r.state.sync(backendName)
+	}
+	if err != nil {
+		return &router.RouterError{Op: op, Err: err}
+	}
+	return nil
+}
+
+func (r *envoyRouter) getBackend(name string) (backendData, error) {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return backendData{}, err
+	}
+	data, ok := r.state.get(backendName)
+	if !ok {
+		// the backend may have been added by another tsuru API instance
+		// since the last reload of the state.
+		if err = r.state.sync(backendName); err != nil {
+			return backendData{}, err
+		}
+		if data, ok = r.state.get(backendName); !ok {
+			return backendData{}, router.ErrBackendNotFound
+		}
+	}
+	return data, nil
+}
+
+func (r *envoyRouter) AddBackend(app router.App) (err error) {
+	name := app.GetName()
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	coll, err := collection()
+	if err != nil {
+		return &router.RouterError{Op: "add", Err: err}
+	}
+	defer coll.Close()
+	err = coll.Insert(backendData{
+		ID:     backendID(r.routerName, name),
+		Router: r.routerName,
+		Name:   name,
+		Routes: []string{},
+		CNames: []string{},
+	})
+	if mgo.IsDup(err) {
+		return router.ErrBackendExists
+	}
+	if err != nil {
+		return &router.RouterError{Op: "add", Err: err}
+	}
+	err = router.Store(name, name, routerType)
+	if err != nil {
+		return err
+	}
+	return r.sync("add", name)
+}
+
+func (r *envoyRouter) RemoveBackend(name string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	if backendName != name {
+		return router.ErrBackendSwapped
+	}
+	coll, err := collection()
+	if err != nil {
+		return &router.RouterError{Op: "remove", Err: err}
+	}
+	defer coll.Close()
+	err = coll.RemoveId(backendID(r.routerName, backendName))
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	if err != nil {
+		return &router.RouterError{Op: "remove", Err: err}
+	}
+	err = r.releaseCNames(bson.M{"router": r.routerName, "backend": backendName})
+	if err != nil {
+		return &router.RouterError{Op: "remove", Err: err}
+	}
+	return r.sync("remove", backendName)
+}
+
+func (r *envoyRouter) AddRoutes(name string, addresses []*url.URL) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(name)
+	if err != nil {
+		return err
+	}
+	routes := make([]string, len(addresses))
+	for i, addr := range addresses {
+		routes[i] = addr.Host
+	}
+	err = r.update("add-routes", data.Name, bson.M{}, bson.M{
+		"$addToSet": bson.M{"routes": bson.M{"$each": routes}},
+	})
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	return err
+}
+
+func (r *envoyRouter) RemoveRoutes(name string, addresses []*url.URL) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(name)
+	if err != nil {
+		return err
+	}
+	routes := make([]string, len(addresses))
+	for i, addr := range addresses {
+		routes[i] = addr.Host
+	}
+	err = r.update("remove-routes", data.Name, bson.M{}, bson.M{
+		"$pullAll": bson.M{"routes": routes},
+	})
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	return err
+}
+
+func (r *envoyRouter) Routes(name string) (urls []*url.URL, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(name)
+	if err != nil {
+		return nil, err
+	}
+	urls = make([]*url.URL, len(data.Routes))
+	for i, route := range data.Routes {
+		urls[i] = &url.URL{Scheme: router.HttpScheme, Host: route}
+	}
+	return urls, nil
+}
+
+func (r *envoyRouter) Addr(name string) (addr string, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(name)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.%s", data.Name, r.domain()), nil
+}
+
+func (r *envoyRouter) Swap(backend1, backend2 string, cnameOnly bool) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	return router.Swap(r, backend1, backend2, cnameOnly)
+}
+
+func (r *envoyRouter) SetCName(cname, name string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	if !router.ValidCName(cname, r.domain()) {
+		return router.ErrCNameNotAllowed
+	}
+	data, err := r.getBackend(name)
+	if err != nil {
+		return err
+	}
+	claimed, err := r.claimCName(cname, data.Name)
+	if err != nil {
+		return &router.RouterError{Op: "set-cname", Err: err}
+	}
+	if !claimed {
+		return router.ErrCNameExists
+	}
+	err = r.update("set-cname", data.Name, bson.M{}, bson.M{
+		"$addToSet": bson.M{"cnames": cname},
+	})
+	if err != nil {
+		r.releaseCNames(bson.M{"_id": backendID(r.routerName, cname), "backend": data.Name})
+	}
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	return err
+}
+
+// cnameClaimTimeout is the time after which a claim of a cname not set in its
+// backend is considered left behind by a failure and may be taken over.
+var cnameClaimTimeout = time.Minute
+
+// claimCName claims the cname for the backend in the database, returning
+// false if the cname is used by another backend.
+func (r *envoyRouter) claimCName(cname, backendName string) (bool, error) {
+	backends, err := collection()
+	if err != nil {
+		return false, err
+	}
+	defer backends.Close()
+	// backends may use cnames set before claims were stored.
+	n, err := backends.Find(bson.M{"router": r.routerName, "cnames": cname}).Count()
+	if err != nil || n > 0 {
+		return false, err
+	}
+	coll, err := cnamesCollection()
+	if err != nil {
+		return false, err
+	}
+	defer coll.Close()
+	id := backendID(r.routerName, cname)
+	now := time.Now().UTC()
+	err = coll.Insert(cnameData{ID: id, Router: r.routerName, CName: cname, Backend: backendName, Claimed: now})
+	if !mgo.IsDup(err) {
+		return err == nil, err
+	}
+	var claim cnameData
+	err = coll.FindId(id).One(&claim)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if claim.Claimed.After(now.Add(-cnameClaimTimeout)) {
+		return false, nil
+	}
+	n, err = backends.Find(bson.M{"_id": backendID(r.routerName, claim.Backend), "cnames": cname}).Count()
+	if err != nil || n > 0 {
+		return false, err
+	}
+	err = coll.Update(bson.M{"_id": id, "backend": claim.Backend, "claimed": claim.Claimed}, bson.M{
+		"$set": bson.M{"backend": backendName, "claimed": now},
+	})
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *envoyRouter) releaseCNames(query bson.M) error {
+	coll, err := cnamesCollection()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	_, err = coll.RemoveAll(query)
+	return err
+}
+
+func (r *envoyRouter) UnsetCName(cname, name string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(name)
+	if err != nil {
+		return err
+	}
+	err = r.update("unset-cname", data.Name, bson.M{"cnames": cname}, bson.M{
+		"$pull": bson.M{"cnames": cname},
+	})
+	if err == mgo.ErrNotFound {
+		return router.ErrCNameNotFound
+	}
+	if err != nil {
+		return err
+	}
+	err = r.releaseCNames(bson.M{"_id": backendID(r.routerName, cname), "backend": data.Name})
+	if err != nil {
+		return &router.RouterError{Op: "unset-cname", Err: err}
+	}
+	return nil
+}
+
+func (r *envoyRouter) CNames(name string) (urls []*url.URL, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(name)
+	if err != nil {
+		return nil, err
+	}
+	urls = make([]*url.URL, len(data.CNames))
+	for i, cname := range data.CNames {
+		urls[i] = &url.URL{Host: cname}
+	}
+	return urls, nil
+}
+
+func (r *envoyRouter) SetHealthcheck(name string, hcData router.HealthcheckData) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(name)
+	if err != nil {
+		return err
+	}
+	err = r.update("set-healthcheck", data.Name, bson.M{}, bson.M{
+		"$set": bson.M{"healthcheck": hcData},
+	})
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	return err
+}
+
+func (r *envoyRouter) SetWeight(name, target string, weight int) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	if !router.ValidWeight(weight) {
+		return router.ErrInvalidWeight
+	}
+	data, err := r.getBackend(name)
+	if err != nil {
+		return err
+	}
+	if weight == 0 {
+		target = ""
+	} else if _, err = r.getBackend(target); err != nil {
+		return err
+	}
+	err = r.update("set-weight", data.Name, bson.M{}, bson.M{
+		"$set": bson.M{"weighttarget": target, "weight": weight},
+	})
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	return err
+}
+
+func (r *envoyRouter) Weight(name string) (target string, weight int, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(name)
+	if err != nil {
+		return "", 0, err
+	}
+	return data.WeightTarget, data.Weight, nil
+}
+
+func (r *envoyRouter) AddCertificate(app router.App, cname, certificate, key string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	if certFile, _ := config.GetString(r.prefix + ":xds-tls-cert"); certFile == "" {
+		return &router.RouterError{Op: "add-certificate", Err: errors.New("certificates are only sent to the proxies by xDS servers using TLS, set xds-tls-cert and xds-tls-key")}
+	}
+	data, err := r.getBackend(app.GetName())
+	if err != nil {
+		return err
+	}
+	err = r.update("add-certificate", data.Name, bson.M{}, bson.M{
+		"$pull": bson.M{"certificates": bson.M{"cname": cname}},
+	})
+	if err == nil {
+		err = r.update("add-certificate", data.Name, bson.M{}, bson.M{
+			"$push": bson.M{"certificates": certificateData{CName: cname, Certificate: certificate, Key: key}},
+		})
+	}
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	return err
+}
+
+func (r *envoyRouter) AddACMEChallenge(name, token, keyAuth string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(name)
+	if err != nil {
+		return err
+	}
+	err = r.update("add-acme-challenge", data.Name, bson.M{}, bson.M{
+		"$push": bson.M{"acmechallenges": acmeChallengeData{Token: token, KeyAuth: keyAuth}},
+	})
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	return err
+}
+
+func (r *envoyRouter) RemoveACMEChallenge(name, token string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(name)
+	if err != nil {
+		return err
+	}
+	err = r.update("remove-acme-challenge", data.Name, bson.M{}, bson.M{
+		"$pull": bson.M{"acmechallenges": bson.M{"token": token}},
+	})
+	if err == mgo.ErrNotFound {
+		return router.ErrBackendNotFound
+	}
+	return err
+}
+
+func (r *envoyRouter) RemoveCertificate(app router.App, cname string) (err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(app.GetName())
+	if err != nil {
+		return err
+	}
+	err = r.update("remove-certificate", data.Name, bson.M{"certificates.cname": cname}, bson.M{
+		"$pull": bson.M{"certificates": bson.M{"cname": cname}},
+	})
+	if err == mgo.ErrNotFound {
+		return router.ErrCertificateNotFound
+	}
+	return err
+}
+
+func (r *envoyRouter) GetCertificate(app router.App, cname string) (certificate string, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	data, err := r.getBackend(app.GetName())
+	if err != nil {
+		return "", err
+	}
+	for _, cert := range data.Certificates {
+		if cert.CName == cname {
+			return cert.Certificate, nil
+		}
+	}
+	return "", router.ErrCertificateNotFound
+}
+
+// Backends reloads the state from the database before listing the
+// backends, as they may have been changed by other tsuru API instances.
+func (r *envoyRouter) Backends() (names []string, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	err = r.state.reload()
+	if err != nil {
+		return nil, err
+	}
+	r.state.mu.RLock()
+	defer r.state.mu.RUnlock()
+	names = make([]string, 0, len(r.state.backends))
+	for name := range r.state.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// HealthCheck reports whether the xDS server of the router is running.
+func (r *envoyRouter) HealthCheck() error {
+	addr, _ := config.GetString(r.prefix + ":xds-listen")
+	if addr == "" {
+		return nil
+	}
+	serversMu.Lock()
+	defer serversMu.Unlock()
+	if _, ok := servers[r.routerName]; !ok {
+		return errors.Errorf("xDS server of router %q is not running", r.routerName)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envoy
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/db/dbtest"
+	"github.com/tsuru/tsuru/router"
+	"github.com/tsuru/tsuru/router/routertest"
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	check.TestingT(t)
+}
+
+type S struct {
+	conn *db.Storage
+}
+
+var _ = check.Suite(&S{})
+
+func init() {
+	base := &S{}
+	suite := &routertest.RouterSuite{
+		SetUpSuiteFunc:   base.SetUpSuite,
+		TearDownTestFunc: base.TearDownTest,
+	}
+	suite.SetUpTestFunc = func(c *check.C) {
+		base.SetUpTest(c)
+		config.Set("routers:generic_envoy:domain", "envoy.router")
+		r, err := createRouter("generic_envoy", "routers:generic_envoy")
+		c.Assert(err, check.IsNil)
+		suite.Router = r
+	}
+	check.Suite(suite)
+}
+
+func (s *S) SetUpSuite(c *check.C) {
+	config.Set("log:disable-syslog", true)
+	config.Set("database:url", "127.0.0.1:27017?maxPoolSize=100")
+	config.Set("database:name", "router_envoy_tests")
+}
+
+func (s *S) SetUpTest(c *check.C) {
+	var err error
+	s.conn, err = db.Conn()
+	c.Assert(err, check.IsNil)
+	dbtest.ClearAllCollections(s.conn.Apps().Database)
+	statesMu.Lock()
+	states = map[string]*routerState{}
+	statesMu.Unlock()
+	config.Set("routers:envoy:domain", "envoy.router")
+}
+
+func (s *S) TearDownTest(c *check.C) {
+	s.conn.Close()
+}
+
+func (s *S) TestCreateRouterLoadsStateFromDatabase(c *check.C) {
+	r, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = r.AddRoutes("myapp", []*url.URL{{Scheme: "http", Host: "10.0.0.1:8080"}})
+	c.Assert(err, check.IsNil)
+	statesMu.Lock()
+	states = map[string]*routerState{}
+	statesMu.Unlock()
+	r, err = createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	data, ok := r.(*envoyRouter).state.get("myapp")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(data.Routes, check.DeepEquals, []string{"10.0.0.1:8080"})
+}
+
+func (s *S) TestRoutesAddedByOtherInstance(c *check.C) {
+	r1, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	statesMu.Lock()
+	states = map[string]*routerState{}
+	statesMu.Unlock()
+	r2, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	err = r2.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	addr, err := r1.Addr("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(addr, check.Equals, "myapp.envoy.router")
+}
+
+func (s *S) TestSetWeight(c *check.C) {
+	r, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	wRouter := r.(router.WeightedRouter)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp-canary"})
+	c.Assert(err, check.IsNil)
+	err = wRouter.SetWeight("myapp", "myapp-canary", 20)
+	c.Assert(err, check.IsNil)
+	target, weight, err := wRouter.Weight("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(target, check.Equals, "myapp-canary")
+	c.Assert(weight, check.Equals, 20)
+	err = wRouter.SetWeight("myapp", "myapp-canary", 0)
+	c.Assert(err, check.IsNil)
+	target, weight, err = wRouter.Weight("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(target, check.Equals, "")
+	c.Assert(weight, check.Equals, 0)
+	err = wRouter.SetWeight("myapp", "myapp-canary", 101)
+	c.Assert(err, check.Equals, router.ErrInvalidWeight)
+	err = wRouter.SetWeight("myapp", "unknown", 10)
+	c.Assert(err, check.Equals, router.ErrBackendNotFound)
+}
+
+func (s *S) TestAddCertificateRequiresTLS(c *check.C) {
+	r, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	app := routertest.FakeApp{Name: "myapp"}
+	err = r.AddBackend(app)
+	c.Assert(err, check.IsNil)
+	err = r.(router.TLSRouter).AddCertificate(app, "myapp.example.com", "cert", "key")
+	c.Assert(err, check.ErrorMatches, ".*only sent to the proxies by xDS servers using TLS.*")
+}
+
+func (s *S) TestCertificates(c *check.C) {
+	config.Set("routers:envoy:xds-tls-cert", "/etc/tsuru/xds.crt")
+	defer config.Unset("routers:envoy:xds-tls-cert")
+	r, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	tlsRouter := r.(router.TLSRouter)
+	app := routertest.FakeApp{Name: "myapp"}
+	err = r.AddBackend(app)
+	c.Assert(err, check.IsNil)
+	err = tlsRouter.AddCertificate(app, "myapp.example.com", "cert1", "key1")
+	c.Assert(err, check.IsNil)
+	err = tlsRouter.AddCertificate(app, "myapp.example.com", "cert2", "key2")
+	c.Assert(err, check.IsNil)
+	cert, err := tlsRouter.GetCertificate(app, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	c.Assert(cert, check.Equals, "cert2")
+	err = tlsRouter.RemoveCertificate(app, "myapp.example.com")
+	c.Assert(err, check.IsNil)
+	_, err = tlsRouter.GetCertificate(app, "myapp.example.com")
+	c.Assert(err, check.Equals, router.ErrCertificateNotFound)
+	err = tlsRouter.RemoveCertificate(app, "myapp.example.com")
+	c.Assert(err, check.Equals, router.ErrCertificateNotFound)
+}
+
+func (s *S) TestACMEChallenges(c *check.C) {
+	r, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	challengeRouter := r.(router.ACMEChallengeRouter)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = challengeRouter.AddACMEChallenge("myapp", "token1", "token1.thumb")
+	c.Assert(err, check.IsNil)
+	data, ok := r.(*envoyRouter).state.get("myapp")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(data.ACMEChallenges, check.DeepEquals, []acmeChallengeData{{Token: "token1", KeyAuth: "token1.thumb"}})
+	err = challengeRouter.RemoveACMEChallenge("myapp", "token1")
+	c.Assert(err, check.IsNil)
+	data, _ = r.(*envoyRouter).state.get("myapp")
+	c.Assert(data.ACMEChallenges, check.HasLen, 0)
+	err = challengeRouter.AddACMEChallenge("unknown", "token1", "token1.thumb")
+	c.Assert(err, check.Equals, router.ErrBackendNotFound)
+}
+
+func (s *S) TestSetCNameUsedByOtherBackend(c *check.C) {
+	r, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	cnameRouter := r.(router.CNameRouter)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "otherapp"})
+	c.Assert(err, check.IsNil)
+	err = cnameRouter.SetCName("myapp.example.com", "myapp")
+	c.Assert(err, check.IsNil)
+	err = cnameRouter.SetCName("myapp.example.com", "otherapp")
+	c.Assert(err, check.Equals, router.ErrCNameExists)
+}
+
+func (s *S) TestReloadIfChangedByOtherInstance(c *check.C) {
+	r1, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	statesMu.Lock()
+	states = map[string]*routerState{}
+	statesMu.Unlock()
+	r2, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	err = r2.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	state := r1.(*envoyRouter).state
+	_, ok := state.get("myapp")
+	c.Assert(ok, check.Equals, false)
+	err = state.reloadIfChanged()
+	c.Assert(err, check.IsNil)
+	_, ok = state.get("myapp")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(state.dbVersion, check.Equals, r2.(*envoyRouter).state.dbVersion)
+}
+
+func (s *S) TestSetCNameUsedByOtherBackendInOtherInstance(c *check.C) {
+	r1, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	statesMu.Lock()
+	states = map[string]*routerState{}
+	statesMu.Unlock()
+	r2, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	err = r1.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = r2.AddBackend(routertest.FakeApp{Name: "otherapp"})
+	c.Assert(err, check.IsNil)
+	err = r1.(router.CNameRouter).SetCName("myapp.example.com", "myapp")
+	c.Assert(err, check.IsNil)
+	err = r2.(router.CNameRouter).SetCName("myapp.example.com", "otherapp")
+	c.Assert(err, check.Equals, router.ErrCNameExists)
+	err = r1.(router.CNameRouter).UnsetCName("myapp.example.com", "myapp")
+	c.Assert(err, check.IsNil)
+	err = r2.(router.CNameRouter).SetCName("myapp.example.com", "otherapp")
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestSetCNameTakesOverStaleClaim(c *check.C) {
+	r, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	coll, err := cnamesCollection()
+	c.Assert(err, check.IsNil)
+	defer coll.Close()
+	err = coll.Insert(cnameData{
+		ID:      backendID("envoy", "myapp.example.com"),
+		Router:  "envoy",
+		CName:   "myapp.example.com",
+		Backend: "gone",
+		Claimed: time.Now().UTC().Add(-2 * cnameClaimTimeout),
+	})
+	c.Assert(err, check.IsNil)
+	err = r.(router.CNameRouter).SetCName("myapp.example.com", "myapp")
+	c.Assert(err, check.IsNil)
+	var claim cnameData
+	err = coll.FindId(backendID("envoy", "myapp.example.com")).One(&claim)
+	c.Assert(err, check.IsNil)
+	c.Assert(claim.Backend, check.Equals, "myapp")
+}
+
+func (s *S) TestRemoveBackendReleasesCNames(c *check.C) {
+	r, err := createRouter("envoy", "routers:envoy")
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "otherapp"})
+	c.Assert(err, check.IsNil)
+	err = r.(router.CNameRouter).SetCName("myapp.example.com", "myapp")
+	c.Assert(err, check.IsNil)
+	err = r.RemoveBackend("myapp")
+	c.Assert(err, check.IsNil)
+	err = r.(router.CNameRouter).SetCName("myapp.example.com", "otherapp")
+	c.Assert(err, check.IsNil)
+}
@@ -0,0 +1,351 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envoy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/router"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var (
+	serversMu sync.Mutex
+	servers   = map[string]*xdsServer{}
+)
+
+// xdsServer implements the state of the world variant of the Envoy xDS
+// protocol, both through the aggregated discovery service (ADS) and the
+// per resource type services.
+type xdsServer struct {
+	state    *routerState
+	prefix   string
+	grpc     *grpc.Server
+	listener net.Listener
+	quit     chan struct{}
+	// secure is set when the server uses TLS. Listeners are sent with the
+	// private keys of the certificates inline, so they are only served by
+	// secure servers.
+	secure bool
+}
+
+type discoveryHandler interface {
+	handleStream(stream grpc.ServerStream, typeURL string) error
+}
+
+func streamHandler(typeURL string) grpc.StreamHandler {
+	return func(srv interface{}, stream grpc.ServerStream) error {
+		return srv.(discoveryHandler).handleStream(stream, typeURL)
+	}
+}
+
+func serviceDesc(serviceName, streamName, typeURL string) *grpc.ServiceDesc {
+	return &grpc.ServiceDesc{
+		ServiceName: serviceName,
+		HandlerType: (*discoveryHandler)(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    streamName,
+			Handler:       streamHandler(typeURL),
+			ServerStreams: true,
+			ClientStreams: true,
+		}},
+	}
+}
+
+var serviceDescs = []*grpc.ServiceDesc{
+	serviceDesc("envoy.service.discovery.v2.AggregatedDiscoveryService", "StreamAggregatedResources", ""),
+	serviceDesc("envoy.api.v2.ListenerDiscoveryService", "StreamListeners", listenerType),
+	serviceDesc("envoy.api.v2.RouteDiscoveryService", "StreamRoutes", routeType),
+	serviceDesc("envoy.api.v2.ClusterDiscoveryService", "StreamClusters", clusterType),
+	serviceDesc("envoy.api.v2.EndpointDiscoveryService", "StreamEndpoints", endpointType),
+}
+
+// Initialize starts the xDS servers of the configured envoy routers.
+func Initialize() error {
+	routers, err := router.List()
+	if err != nil {
+		return err
+	}
+	for _, r := range routers {
+		if r.Type != routerType {
+			continue
+		}
+		_, prefix, err := router.Type(r.Name)
+		if err != nil {
+			return err
+		}
+		state, err := getState(r.Name)
+		if err != nil {
+			return err
+		}
+		err = startServer(r.Name, prefix, state)
+		if err != nil {
+			return errors.Wrapf(err, "unable to start xDS server of router %q", r.Name)
+		}
+	}
+	return nil
+}
+
+// startServer starts the xDS server of the router once, listening on the
+// address set in the xds-listen config.
+func startServer(routerName, prefix string, state *routerState) error {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+	if _, ok := servers[routerName]; ok {
+		return nil
+	}
+	addr, _ := config.GetString(prefix + ":xds-listen")
+	if addr == "" {
+		return nil
+	}
+	creds, err := serverCredentials(prefix)
+	if err != nil {
+		return err
+	}
+	if creds == nil {
+		log.Errorf("[router envoy %s] WARNING: xDS server listening without TLS, certificates won't be sent to the proxies", routerName)
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := newServer(prefix, state, creds)
+	srv.listener = l
+	servers[routerName] = srv
+	go srv.grpc.Serve(l)
+	go srv.syncLoop()
+	return nil
+}
+
+// serverCredentials returns the TLS credentials of the xDS server, set in
+// the xds-tls-cert and xds-tls-key configs. When xds-tls-client-ca is set,
+// the proxies must present a certificate signed by it. It returns nil when
+// TLS isn't configured.
+func serverCredentials(prefix string) (credentials.TransportCredentials, error) {
+	certFile, _ := config.GetString(prefix + ":xds-tls-cert")
+	keyFile, _ := config.GetString(prefix + ":xds-tls-key")
+	caFile, _ := config.GetString(prefix + ":xds-tls-client-ca")
+	if certFile == "" && keyFile == "" {
+		if caFile != "" {
+			return nil, errors.New("xds-tls-client-ca requires xds-tls-cert and xds-tls-key")
+		}
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("both xds-tls-cert and xds-tls-key must be set")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load xDS server certificate")
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caFile != "" {
+		caData, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read xDS client CA")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, errors.Errorf("no certificates found in %q", caFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// newServer creates the xDS server of the router. A nil creds creates an
+// insecure server, which never sends certificates to the proxies.
+func newServer(prefix string, state *routerState, creds credentials.TransportCredentials) *xdsServer {
+	var opts []grpc.ServerOption
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+	srv := &xdsServer{
+		state:  state,
+		prefix: prefix,
+		grpc:   grpc.NewServer(opts...),
+		quit:   make(chan struct{}),
+		secure: creds != nil,
+	}
+	for _, desc := range serviceDescs {
+		srv.grpc.RegisterService(desc, srv)
+	}
+	return srv
+}
+
+func (s *xdsServer) stop() {
+	close(s.quit)
+	s.grpc.Stop()
+}
+
+// syncLoop periodically checks the version of the router in the database,
+// reloading the state when it was changed by other tsuru API instances.
+func (s *xdsServer) syncLoop() {
+	interval, _ := config.GetInt(s.prefix + ":sync-interval")
+	if interval <= 0 {
+		interval = 1
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := s.state.reloadIfChanged()
+			if err != nil {
+				log.Errorf("[router envoy %s] unable to reload state: %s", s.state.name, err)
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *xdsServer) listenerConfig() listenerConfig {
+	domain, _ := config.GetString(s.prefix + ":domain")
+	httpPort, _ := config.GetInt(s.prefix + ":http-port")
+	if httpPort == 0 {
+		httpPort = 80
+	}
+	httpsPort, _ := config.GetInt(s.prefix + ":https-port")
+	if httpsPort == 0 {
+		httpsPort = 443
+	}
+	return listenerConfig{
+		domain:       domain,
+		httpPort:     uint32(httpPort),
+		httpsPort:    uint32(httpsPort),
+		certificates: s.secure,
+	}
+}
+
+type watchedType struct {
+	names   []string
+	nonce   string
+	version uint64
+	sent    bool
+}
+
+func (s *xdsServer) handleStream(stream grpc.ServerStream, defaultTypeURL string) error {
+	ctx := stream.Context()
+	requests := make(chan *discoveryRequest)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req := &discoveryRequest{}
+			if err := stream.RecvMsg(req); err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	changed := s.state.watch()
+	defer s.state.unwatch(changed)
+	watched := map[string]*watchedType{}
+	var nonce uint64
+	send := func(typeURL string, w *watchedType) error {
+		version, resources, err := s.state.resources(typeURL, w.names, s.listenerConfig())
+		if err != nil {
+			return err
+		}
+		nonce++
+		w.nonce = strconv.FormatUint(nonce, 10)
+		w.version = version
+		w.sent = true
+		return stream.SendMsg(&discoveryResponse{
+			VersionInfo: strconv.FormatUint(version, 10),
+			Resources:   resources,
+			TypeUrl:     typeURL,
+			Nonce:       w.nonce,
+		})
+	}
+	for {
+		select {
+		case err := <-recvErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return nil
+		case req := <-requests:
+			typeURL := req.TypeUrl
+			if typeURL == "" {
+				typeURL = defaultTypeURL
+			}
+			w := watched[typeURL]
+			if w == nil {
+				w = &watchedType{}
+				watched[typeURL] = w
+			}
+			if req.ResponseNonce != "" && req.ResponseNonce != w.nonce {
+				// stale request, answering a response we've already replaced
+				continue
+			}
+			if req.ErrorDetail != nil {
+				log.Errorf("[router envoy %s] node %q rejected %s version %s: %s", s.state.name,
+					nodeID(req), typeURL, req.VersionInfo, req.ErrorDetail.Message)
+			}
+			namesChanged := !equalNames(w.names, req.ResourceNames)
+			w.names = req.ResourceNames
+			s.state.mu.RLock()
+			version := s.state.version
+			s.state.mu.RUnlock()
+			if req.ResponseNonce != "" && !namesChanged && w.version == version {
+				continue
+			}
+			if err := send(typeURL, w); err != nil {
+				return err
+			}
+		case <-changed:
+			for typeURL, w := range watched {
+				if !w.sent {
+					continue
+				}
+				if err := send(typeURL, w); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func nodeID(req *discoveryRequest) string {
+	if req.Node == nil {
+		return ""
+	}
+	return req.Node.Id
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,349 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envoy
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/router"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"gopkg.in/check.v1"
+)
+
+type XDSSuite struct {
+	state   *routerState
+	server  *xdsServer
+	conn    *grpc.ClientConn
+	addr    string
+	certDir string
+}
+
+var _ = check.Suite(&XDSSuite{})
+
+// SetUpSuite generates a self signed certificate, used both by the xDS
+// server and as the client certificate of the proxies.
+func (s *XDSSuite) SetUpSuite(c *check.C) {
+	var err error
+	s.certDir, err = ioutil.TempDir("", "envoy-xds")
+	c.Assert(err, check.IsNil)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, check.IsNil)
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	c.Assert(err, check.IsNil)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	err = ioutil.WriteFile(filepath.Join(s.certDir, "cert.pem"), certPEM, 0600)
+	c.Assert(err, check.IsNil)
+	err = ioutil.WriteFile(filepath.Join(s.certDir, "key.pem"), keyPEM, 0600)
+	c.Assert(err, check.IsNil)
+}
+
+func (s *XDSSuite) TearDownSuite(c *check.C) {
+	os.RemoveAll(s.certDir)
+}
+
+func (s *XDSSuite) SetUpTest(c *check.C) {
+	config.Set("routers:xds:domain", "envoy.router")
+	config.Set("routers:xds:http-port", 8080)
+	config.Set("routers:xds:https-port", 8443)
+	config.Set("routers:xds:xds-tls-cert", filepath.Join(s.certDir, "cert.pem"))
+	config.Set("routers:xds:xds-tls-key", filepath.Join(s.certDir, "key.pem"))
+	config.Set("routers:xds:xds-tls-client-ca", filepath.Join(s.certDir, "cert.pem"))
+	s.state = &routerState{
+		name:     "xds",
+		backends: map[string]backendData{},
+		watchers: map[chan struct{}]struct{}{},
+	}
+	creds, err := serverCredentials("routers:xds")
+	c.Assert(err, check.IsNil)
+	s.server = newServer("routers:xds", s.state, creds)
+	s.addr = serve(c, s.server)
+	s.conn, err = grpc.Dial(s.addr, grpc.WithTransportCredentials(s.clientCredentials(c, true)))
+	c.Assert(err, check.IsNil)
+}
+
+func (s *XDSSuite) TearDownTest(c *check.C) {
+	s.conn.Close()
+	s.server.stop()
+	config.Unset("routers:xds")
+}
+
+func serve(c *check.C, srv *xdsServer) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, check.IsNil)
+	go srv.grpc.Serve(l)
+	return l.Addr().String()
+}
+
+func (s *XDSSuite) clientCredentials(c *check.C, withCert bool) credentials.TransportCredentials {
+	caData, err := ioutil.ReadFile(filepath.Join(s.certDir, "cert.pem"))
+	c.Assert(err, check.IsNil)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caData)
+	tlsConfig := &tls.Config{RootCAs: pool}
+	if withCert {
+		cert, err := tls.LoadX509KeyPair(filepath.Join(s.certDir, "cert.pem"), filepath.Join(s.certDir, "key.pem"))
+		c.Assert(err, check.IsNil)
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(tlsConfig)
+}
+
+func (s *XDSSuite) setBackend(data backendData) {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+	s.state.backends[data.Name] = data
+	s.state.changed()
+}
+
+func (s *XDSSuite) stream(c *check.C) grpc.ClientStream {
+	stream, err := grpc.NewClientStream(context.Background(), &serviceDescs[0].Streams[0], s.conn,
+		"/envoy.service.discovery.v2.AggregatedDiscoveryService/StreamAggregatedResources")
+	c.Assert(err, check.IsNil)
+	return stream
+}
+
+func recv(c *check.C, stream grpc.ClientStream) *discoveryResponse {
+	result := make(chan *discoveryResponse, 1)
+	go func() {
+		var resp discoveryResponse
+		if err := stream.RecvMsg(&resp); err == nil {
+			result <- &resp
+		}
+	}()
+	select {
+	case resp := <-result:
+		return resp
+	case <-time.After(5 * time.Second):
+		c.Fatal("timeout waiting for discovery response")
+	}
+	return nil
+}
+
+func unmarshalResources(c *check.C, resp *discoveryResponse, newMsg func() proto.Message) []proto.Message {
+	var result []proto.Message
+	for _, r := range resp.Resources {
+		c.Assert(r.TypeUrl, check.Equals, resp.TypeUrl)
+		msg := newMsg()
+		err := proto.Unmarshal(r.Value, msg)
+		c.Assert(err, check.IsNil)
+		result = append(result, msg)
+	}
+	return result
+}
+
+func (s *XDSSuite) TestStreamClustersAndEndpoints(c *check.C) {
+	s.setBackend(backendData{
+		Name:        "myapp",
+		Routes:      []string{"10.0.0.1:8080", "10.0.0.2:8080"},
+		Healthcheck: router.HealthcheckData{Path: "/healthcheck", Status: 200},
+	})
+	stream := s.stream(c)
+	err := stream.SendMsg(&discoveryRequest{TypeUrl: clusterType, Node: &node{Id: "envoy-1"}})
+	c.Assert(err, check.IsNil)
+	resp := recv(c, stream)
+	c.Assert(resp.TypeUrl, check.Equals, clusterType)
+	c.Assert(resp.VersionInfo, check.Equals, "1")
+	clusters := unmarshalResources(c, resp, func() proto.Message { return &cluster{} })
+	c.Assert(clusters, check.HasLen, 1)
+	cl := clusters[0].(*cluster)
+	c.Assert(cl.Name, check.Equals, "myapp")
+	c.Assert(cl.Type, check.Equals, int32(clusterTypeEDS))
+	c.Assert(cl.EdsClusterConfig.EdsConfig.Ads, check.NotNil)
+	c.Assert(cl.HealthChecks, check.HasLen, 1)
+	c.Assert(cl.HealthChecks[0].HttpHealthCheck.Path, check.Equals, "/healthcheck")
+	c.Assert(cl.HealthChecks[0].HttpHealthCheck.ExpectedStatuses, check.DeepEquals, []*int64Range{{Start: 200, End: 201}})
+	err = stream.SendMsg(&discoveryRequest{TypeUrl: endpointType, ResourceNames: []string{"myapp"}})
+	c.Assert(err, check.IsNil)
+	resp = recv(c, stream)
+	c.Assert(resp.TypeUrl, check.Equals, endpointType)
+	assignments := unmarshalResources(c, resp, func() proto.Message { return &clusterLoadAssignment{} })
+	c.Assert(assignments, check.HasLen, 1)
+	cla := assignments[0].(*clusterLoadAssignment)
+	c.Assert(cla.ClusterName, check.Equals, "myapp")
+	c.Assert(cla.Endpoints[0].LbEndpoints, check.HasLen, 2)
+	c.Assert(cla.Endpoints[0].LbEndpoints[1].Endpoint.Address.SocketAddress, check.DeepEquals, &socketAddress{Address: "10.0.0.2", PortValue: 8080})
+}
+
+func (s *XDSSuite) TestStreamPushesChanges(c *check.C) {
+	s.setBackend(backendData{Name: "myapp", Routes: []string{"10.0.0.1:8080"}})
+	stream := s.stream(c)
+	err := stream.SendMsg(&discoveryRequest{TypeUrl: endpointType})
+	c.Assert(err, check.IsNil)
+	resp := recv(c, stream)
+	c.Assert(resp.VersionInfo, check.Equals, "1")
+	err = stream.SendMsg(&discoveryRequest{TypeUrl: endpointType, VersionInfo: resp.VersionInfo, ResponseNonce: resp.Nonce})
+	c.Assert(err, check.IsNil)
+	s.setBackend(backendData{Name: "myapp", Routes: []string{"10.0.0.1:8080", "10.0.0.3:8080"}})
+	resp = recv(c, stream)
+	c.Assert(resp.VersionInfo, check.Equals, "2")
+	assignments := unmarshalResources(c, resp, func() proto.Message { return &clusterLoadAssignment{} })
+	c.Assert(assignments[0].(*clusterLoadAssignment).Endpoints[0].LbEndpoints, check.HasLen, 2)
+}
+
+func (s *XDSSuite) TestStreamRoutesWithWeightAndCNames(c *check.C) {
+	s.setBackend(backendData{Name: "myapp", CNames: []string{"myapp.example.com"}, WeightTarget: "myapp-canary", Weight: 10})
+	s.setBackend(backendData{Name: "myapp-canary"})
+	stream := s.stream(c)
+	err := stream.SendMsg(&discoveryRequest{TypeUrl: routeType, ResourceNames: []string{routeConfigName}})
+	c.Assert(err, check.IsNil)
+	resp := recv(c, stream)
+	configs := unmarshalResources(c, resp, func() proto.Message { return &routeConfiguration{} })
+	c.Assert(configs, check.HasLen, 1)
+	vhosts := configs[0].(*routeConfiguration).VirtualHosts
+	c.Assert(vhosts, check.HasLen, 2)
+	c.Assert(vhosts[0].Domains, check.DeepEquals, []string{"myapp.envoy.router", "myapp.example.com"})
+	c.Assert(vhosts[0].Routes[0].Route.WeightedClusters.Clusters, check.DeepEquals, []*clusterWeight{
+		{Name: "myapp", Weight: &uint32Value{Value: 90}},
+		{Name: "myapp-canary", Weight: &uint32Value{Value: 10}},
+	})
+	c.Assert(vhosts[1].Domains, check.DeepEquals, []string{"myapp-canary.envoy.router"})
+	c.Assert(vhosts[1].Routes[0].Route.Cluster, check.Equals, "myapp-canary")
+}
+
+func (s *XDSSuite) TestStreamRoutesWithACMEChallenges(c *check.C) {
+	s.setBackend(backendData{Name: "myapp", ACMEChallenges: []acmeChallengeData{{Token: "token1", KeyAuth: "token1.thumb"}}})
+	stream := s.stream(c)
+	err := stream.SendMsg(&discoveryRequest{TypeUrl: routeType, ResourceNames: []string{routeConfigName}})
+	c.Assert(err, check.IsNil)
+	resp := recv(c, stream)
+	configs := unmarshalResources(c, resp, func() proto.Message { return &routeConfiguration{} })
+	routes := configs[0].(*routeConfiguration).VirtualHosts[0].Routes
+	c.Assert(routes, check.HasLen, 2)
+	c.Assert(routes[0].Match.Path, check.Equals, "/.well-known/acme-challenge/token1")
+	c.Assert(routes[0].DirectResponse, check.DeepEquals, &directResponseAction{
+		Status: 200,
+		Body:   &dataSource{InlineString: "token1.thumb"},
+	})
+	c.Assert(routes[1].Match.Prefix, check.Equals, "/")
+	c.Assert(routes[1].Route.Cluster, check.Equals, "myapp")
+}
+
+func (s *XDSSuite) TestStreamListeners(c *check.C) {
+	s.setBackend(backendData{Name: "myapp", Certificates: []certificateData{
+		{CName: "myapp.example.com", Certificate: "my-cert", Key: "my-key"},
+	}})
+	stream, err := grpc.NewClientStream(context.Background(), &serviceDescs[1].Streams[0], s.conn,
+		"/envoy.api.v2.ListenerDiscoveryService/StreamListeners")
+	c.Assert(err, check.IsNil)
+	err = stream.SendMsg(&discoveryRequest{})
+	c.Assert(err, check.IsNil)
+	resp := recv(c, stream)
+	c.Assert(resp.TypeUrl, check.Equals, listenerType)
+	listeners := unmarshalResources(c, resp, func() proto.Message { return &listener{} })
+	c.Assert(listeners, check.HasLen, 2)
+	httpListener := listeners[0].(*listener)
+	c.Assert(httpListener.Name, check.Equals, httpListenerName)
+	c.Assert(httpListener.Address.SocketAddress.PortValue, check.Equals, uint32(8080))
+	var hcm httpConnectionManager
+	err = proto.Unmarshal(httpListener.FilterChains[0].Filters[0].TypedConfig.Value, &hcm)
+	c.Assert(err, check.IsNil)
+	c.Assert(hcm.Rds.RouteConfigName, check.Equals, routeConfigName)
+	httpsListener := listeners[1].(*listener)
+	c.Assert(httpsListener.Name, check.Equals, httpsListenerName)
+	c.Assert(httpsListener.Address.SocketAddress.PortValue, check.Equals, uint32(8443))
+	c.Assert(httpsListener.FilterChains, check.HasLen, 1)
+	chain := httpsListener.FilterChains[0]
+	c.Assert(chain.FilterChainMatch.ServerNames, check.DeepEquals, []string{"myapp.example.com"})
+	c.Assert(chain.TlsContext.CommonTlsContext.TlsCertificates[0].CertificateChain.InlineString, check.Equals, "my-cert")
+	c.Assert(chain.TlsContext.CommonTlsContext.TlsCertificates[0].PrivateKey.InlineString, check.Equals, "my-key")
+}
+
+func (s *XDSSuite) TestStreamListenersInsecureServer(c *check.C) {
+	s.setBackend(backendData{Name: "myapp", Certificates: []certificateData{
+		{CName: "myapp.example.com", Certificate: "my-cert", Key: "my-key"},
+	}})
+	srv := newServer("routers:xds", s.state, nil)
+	defer srv.stop()
+	conn, err := grpc.Dial(serve(c, srv), grpc.WithInsecure())
+	c.Assert(err, check.IsNil)
+	defer conn.Close()
+	stream, err := grpc.NewClientStream(context.Background(), &serviceDescs[1].Streams[0], conn,
+		"/envoy.api.v2.ListenerDiscoveryService/StreamListeners")
+	c.Assert(err, check.IsNil)
+	err = stream.SendMsg(&discoveryRequest{})
+	c.Assert(err, check.IsNil)
+	resp := recv(c, stream)
+	listeners := unmarshalResources(c, resp, func() proto.Message { return &listener{} })
+	c.Assert(listeners, check.HasLen, 1)
+	c.Assert(listeners[0].(*listener).Name, check.Equals, httpListenerName)
+}
+
+func (s *XDSSuite) TestStreamRequiresClientCertificate(c *check.C) {
+	s.setBackend(backendData{Name: "myapp"})
+	conn, err := grpc.Dial(s.addr, grpc.WithTransportCredentials(s.clientCredentials(c, false)))
+	c.Assert(err, check.IsNil)
+	defer conn.Close()
+	stream, err := grpc.NewClientStream(context.Background(), &serviceDescs[0].Streams[0], conn,
+		"/envoy.service.discovery.v2.AggregatedDiscoveryService/StreamAggregatedResources")
+	if err == nil {
+		err = stream.SendMsg(&discoveryRequest{TypeUrl: clusterType})
+		if err == nil {
+			err = stream.RecvMsg(&discoveryResponse{})
+		}
+	}
+	c.Assert(err, check.NotNil)
+}
+
+func (s *XDSSuite) TestServerCredentials(c *check.C) {
+	config.Unset("routers:xds:xds-tls-client-ca")
+	creds, err := serverCredentials("routers:xds")
+	c.Assert(err, check.IsNil)
+	c.Assert(creds, check.NotNil)
+	config.Unset("routers:xds:xds-tls-cert")
+	config.Unset("routers:xds:xds-tls-key")
+	creds, err = serverCredentials("routers:xds")
+	c.Assert(err, check.IsNil)
+	c.Assert(creds, check.IsNil)
+	config.Set("routers:xds:xds-tls-cert", filepath.Join(s.certDir, "cert.pem"))
+	_, err = serverCredentials("routers:xds")
+	c.Assert(err, check.ErrorMatches, "both xds-tls-cert and xds-tls-key must be set")
+	config.Unset("routers:xds:xds-tls-cert")
+	config.Set("routers:xds:xds-tls-client-ca", filepath.Join(s.certDir, "cert.pem"))
+	_, err = serverCredentials("routers:xds")
+	c.Assert(err, check.ErrorMatches, "xds-tls-client-ca requires xds-tls-cert and xds-tls-key")
+}
+
+func (s *XDSSuite) TestStreamIgnoresStaleNonce(c *check.C) {
+	s.setBackend(backendData{Name: "myapp"})
+	stream := s.stream(c)
+	err := stream.SendMsg(&discoveryRequest{TypeUrl: clusterType})
+	c.Assert(err, check.IsNil)
+	resp := recv(c, stream)
+	err = stream.SendMsg(&discoveryRequest{TypeUrl: clusterType, ResponseNonce: "stale"})
+	c.Assert(err, check.IsNil)
+	err = stream.SendMsg(&discoveryRequest{TypeUrl: clusterType, VersionInfo: resp.VersionInfo, ResponseNonce: resp.Nonce})
+	c.Assert(err, check.IsNil)
+	s.setBackend(backendData{Name: "otherapp"})
+	resp = recv(c, stream)
+	c.Assert(resp.Nonce, check.Equals, "2")
+	c.Assert(resp.Resources, check.HasLen, 2)
+}
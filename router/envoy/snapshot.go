@@ -0,0 +1,228 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envoy
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/duration"
+)
+
+const (
+	routeConfigName   = "tsuru"
+	httpListenerName  = "tsuru_http"
+	httpsListenerName = "tsuru_https"
+	defaultRoutePort  = 80
+
+	acmeChallengePath = "/.well-known/acme-challenge/"
+)
+
+type listenerConfig struct {
+	domain    string
+	httpPort  uint32
+	httpsPort uint32
+	// certificates is unset for insecure xDS servers, which must not send
+	// private keys to the proxies.
+	certificates bool
+}
+
+type namedResource struct {
+	name string
+	msg  proto.Message
+}
+
+// resources returns the current version of the state and the resources of
+// the given type, filtered by names when the list isn't empty.
+func (s *routerState) resources(typeURL string, names []string, conf listenerConfig) (uint64, []*any.Any, error) {
+	s.mu.RLock()
+	version := s.version
+	backends := make([]backendData, 0, len(s.backends))
+	for _, b := range s.backends {
+		backends = append(backends, b)
+	}
+	s.mu.RUnlock()
+	sort.Slice(backends, func(i, j int) bool {
+		return backends[i].Name < backends[j].Name
+	})
+	var list []namedResource
+	switch typeURL {
+	case clusterType:
+		list = clusters(backends)
+	case endpointType:
+		list = loadAssignments(backends)
+	case routeType:
+		list = routeConfigurations(backends, conf)
+	case listenerType:
+		list = listeners(backends, conf)
+	}
+	var filter map[string]bool
+	if len(names) > 0 {
+		filter = make(map[string]bool, len(names))
+		for _, n := range names {
+			filter[n] = true
+		}
+	}
+	result := []*any.Any{}
+	for _, r := range list {
+		if filter != nil && !filter[r.name] {
+			continue
+		}
+		value, err := marshalAny(typeURL, r.msg)
+		if err != nil {
+			return 0, nil, err
+		}
+		result = append(result, value)
+	}
+	return version, result, nil
+}
+
+func clusters(backends []backendData) []namedResource {
+	result := make([]namedResource, len(backends))
+	for i, b := range backends {
+		c := &cluster{
+			Name: b.Name,
+			Type: clusterTypeEDS,
+			EdsClusterConfig: &edsClusterConfig{
+				EdsConfig: &configSource{Ads: &aggregatedConfigSource{}},
+			},
+			ConnectTimeout: &duration.Duration{Seconds: 5},
+		}
+		if b.Healthcheck.Path != "" {
+			hc := &healthCheck{
+				Timeout:            &duration.Duration{Seconds: 5},
+				Interval:           &duration.Duration{Seconds: 10},
+				UnhealthyThreshold: &uint32Value{Value: 3},
+				HealthyThreshold:   &uint32Value{Value: 1},
+				HttpHealthCheck:    &httpHealthCheck{Path: b.Healthcheck.Path},
+			}
+			if b.Healthcheck.Status != 0 {
+				status := int64(b.Healthcheck.Status)
+				hc.HttpHealthCheck.ExpectedStatuses = []*int64Range{{Start: status, End: status + 1}}
+			}
+			c.HealthChecks = []*healthCheck{hc}
+		}
+		result[i] = namedResource{name: b.Name, msg: c}
+	}
+	return result
+}
+
+func loadAssignments(backends []backendData) []namedResource {
+	result := make([]namedResource, len(backends))
+	for i, b := range backends {
+		endpoints := &localityLbEndpoints{}
+		for _, r := range b.Routes {
+			host, port, err := net.SplitHostPort(r)
+			if err != nil {
+				host, port = r, strconv.Itoa(defaultRoutePort)
+			}
+			portValue, _ := strconv.ParseUint(port, 10, 32)
+			endpoints.LbEndpoints = append(endpoints.LbEndpoints, &lbEndpoint{
+				Endpoint: &endpoint{
+					Address: &address{SocketAddress: &socketAddress{Address: host, PortValue: uint32(portValue)}},
+				},
+			})
+		}
+		result[i] = namedResource{name: b.Name, msg: &clusterLoadAssignment{
+			ClusterName: b.Name,
+			Endpoints:   []*localityLbEndpoints{endpoints},
+		}}
+	}
+	return result
+}
+
+func routeConfigurations(backends []backendData, conf listenerConfig) []namedResource {
+	names := make(map[string]bool, len(backends))
+	for _, b := range backends {
+		names[b.Name] = true
+	}
+	routeConfig := &routeConfiguration{Name: routeConfigName}
+	for _, b := range backends {
+		action := &routeAction{Cluster: b.Name}
+		if b.Weight > 0 && names[b.WeightTarget] {
+			action = &routeAction{WeightedClusters: &weightedCluster{
+				Clusters: []*clusterWeight{
+					{Name: b.Name, Weight: &uint32Value{Value: uint32(100 - b.Weight)}},
+					{Name: b.WeightTarget, Weight: &uint32Value{Value: uint32(b.Weight)}},
+				},
+			}}
+		}
+		var routes []*route
+		for _, challenge := range b.ACMEChallenges {
+			routes = append(routes, &route{
+				Match: &routeMatch{Path: acmeChallengePath + challenge.Token},
+				DirectResponse: &directResponseAction{
+					Status: http.StatusOK,
+					Body:   &dataSource{InlineString: challenge.KeyAuth},
+				},
+			})
+		}
+		routeConfig.VirtualHosts = append(routeConfig.VirtualHosts, &virtualHost{
+			Name:    b.Name,
+			Domains: append([]string{b.Name + "." + conf.domain}, b.CNames...),
+			Routes:  append(routes, &route{Match: &routeMatch{Prefix: "/"}, Route: action}),
+		})
+	}
+	return []namedResource{{name: routeConfigName, msg: routeConfig}}
+}
+
+func listeners(backends []backendData, conf listenerConfig) []namedResource {
+	result := []namedResource{
+		{name: httpListenerName, msg: &listener{
+			Name:         httpListenerName,
+			Address:      listenAddress(conf.httpPort),
+			FilterChains: []*filterChain{{Filters: []*filter{connectionManager(httpListenerName)}}},
+		}},
+	}
+	if !conf.certificates {
+		return result
+	}
+	var chains []*filterChain
+	for _, b := range backends {
+		for _, cert := range b.Certificates {
+			chains = append(chains, &filterChain{
+				FilterChainMatch: &filterChainMatch{ServerNames: []string{cert.CName}},
+				TlsContext: &downstreamTlsContext{
+					CommonTlsContext: &commonTlsContext{
+						TlsCertificates: []*tlsCertificate{{
+							CertificateChain: &dataSource{InlineString: cert.Certificate},
+							PrivateKey:       &dataSource{InlineString: cert.Key},
+						}},
+					},
+				},
+				Filters: []*filter{connectionManager(httpsListenerName)},
+			})
+		}
+	}
+	if len(chains) > 0 {
+		result = append(result, namedResource{name: httpsListenerName, msg: &listener{
+			Name:            httpsListenerName,
+			Address:         listenAddress(conf.httpsPort),
+			FilterChains:    chains,
+			ListenerFilters: []*listenerFilter{{Name: "envoy.listener.tls_inspector"}},
+		}})
+	}
+	return result
+}
+
+func listenAddress(port uint32) *address {
+	return &address{SocketAddress: &socketAddress{Address: "0.0.0.0", PortValue: port}}
+}
+
+func connectionManager(statPrefix string) *filter {
+	config, _ := marshalAny(httpConnectionManagerType, &httpConnectionManager{
+		StatPrefix: statPrefix,
+		Rds: &rds{
+			ConfigSource:    &configSource{Ads: &aggregatedConfigSource{}},
+			RouteConfigName: routeConfigName,
+		},
+		HttpFilters: []*httpFilter{{Name: "envoy.router"}},
+	})
+	return &filter{Name: "envoy.http_connection_manager", TypedConfig: config}
+}
@@ -0,0 +1,262 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envoy
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/db/storage"
+	"github.com/tsuru/tsuru/router"
+)
+
+var (
+	statesMu sync.Mutex
+	states   = map[string]*routerState{}
+)
+
+type backendData struct {
+	ID             string `bson:"_id"`
+	Router         string
+	Name           string
+	Routes         []string
+	CNames         []string
+	Healthcheck    router.HealthcheckData
+	Certificates   []certificateData
+	WeightTarget   string
+	Weight         int
+	ACMEChallenges []acmeChallengeData
+}
+
+type acmeChallengeData struct {
+	Token   string
+	KeyAuth string
+}
+
+type certificateData struct {
+	CName       string
+	Certificate string
+	Key         string
+}
+
+// cnameData claims a cname for a backend. Claims are keyed by router and
+// cname, so the database rejects a cname used by two backends.
+type cnameData struct {
+	ID      string `bson:"_id"`
+	Router  string
+	CName   string
+	Backend string
+	Claimed time.Time
+}
+
+// routerState holds the backends of a router in memory, so xDS responses are
+// built without reaching the database. Every change increments the version
+// and wakes up the xDS streams watching the state. The dbVersion is the
+// version of the router in the database the state is up to date with, every
+// tsuru API instance increments it when changing the router.
+type routerState struct {
+	mu        sync.RWMutex
+	name      string
+	version   uint64
+	dbVersion int64
+	backends  map[string]backendData
+	watchers  map[chan struct{}]struct{}
+}
+
+func collection() (*storage.Collection, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Collection("router_envoy_backends"), nil
+}
+
+func cnamesCollection() (*storage.Collection, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Collection("router_envoy_cnames"), nil
+}
+
+func versionsCollection() (*storage.Collection, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Collection("router_envoy_versions"), nil
+}
+
+// getState returns the in memory state of the router, loading it from the
+// database on the first call.
+func getState(routerName string) (*routerState, error) {
+	statesMu.Lock()
+	defer statesMu.Unlock()
+	if s, ok := states[routerName]; ok {
+		return s, nil
+	}
+	s := &routerState{
+		name:     routerName,
+		watchers: map[chan struct{}]struct{}{},
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	states[routerName] = s
+	return s, nil
+}
+
+// reload replaces the state with the backends stored in the database. It's
+// used on startup and whenever the version of the router in the database
+// changes, picking up changes made by other tsuru API instances.
+func (s *routerState) reload() error {
+	dbVersion, err := s.readDBVersion()
+	if err != nil {
+		return err
+	}
+	coll, err := collection()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	var list []backendData
+	err = coll.Find(bson.M{"router": s.name}).All(&list)
+	if err != nil {
+		return err
+	}
+	backends := make(map[string]backendData, len(list))
+	for _, b := range list {
+		backends[b.Name] = b
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dbVersion = dbVersion
+	if s.backends != nil && reflect.DeepEqual(s.backends, backends) {
+		return nil
+	}
+	s.backends = backends
+	s.changed()
+	return nil
+}
+
+// reloadIfChanged reloads the state when the version of the router in the
+// database is not the one the state is up to date with.
+func (s *routerState) reloadIfChanged() error {
+	dbVersion, err := s.readDBVersion()
+	if err != nil {
+		return err
+	}
+	s.mu.RLock()
+	upToDate := dbVersion == s.dbVersion
+	s.mu.RUnlock()
+	if upToDate {
+		return nil
+	}
+	return s.reload()
+}
+
+func (s *routerState) readDBVersion() (int64, error) {
+	coll, err := versionsCollection()
+	if err != nil {
+		return 0, err
+	}
+	defer coll.Close()
+	var data struct{ Version int64 }
+	err = coll.FindId(s.name).One(&data)
+	if err != nil && err != mgo.ErrNotFound {
+		return 0, err
+	}
+	return data.Version, nil
+}
+
+// incDBVersion increments the version of the router in the database after a
+// change, so other tsuru API instances reload their state. The state stays
+// up to date with the new version only if no other instance changed the
+// router since the state was last loaded.
+func (s *routerState) incDBVersion() error {
+	coll, err := versionsCollection()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	var data struct{ Version int64 }
+	_, err = coll.FindId(s.name).Apply(mgo.Change{
+		Update:    bson.M{"$inc": bson.M{"version": 1}},
+		Upsert:    true,
+		ReturnNew: true,
+	}, &data)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if data.Version == s.dbVersion+1 {
+		s.dbVersion = data.Version
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// sync reads a single backend from the database into the state.
+func (s *routerState) sync(name string) error {
+	coll, err := collection()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	var data backendData
+	err = coll.FindId(backendID(s.name, name)).One(&data)
+	if err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == mgo.ErrNotFound {
+		delete(s.backends, name)
+	} else {
+		s.backends[name] = data
+	}
+	s.changed()
+	return nil
+}
+
+func (s *routerState) get(name string) (backendData, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.backends[name]
+	return data, ok
+}
+
+// changed must be called with the write lock held.
+func (s *routerState) changed() {
+	s.version++
+	for ch := range s.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *routerState) watch() chan struct{} {
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.watchers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *routerState) unwatch(ch chan struct{}) {
+	s.mu.Lock()
+	delete(s.watchers, ch)
+	s.mu.Unlock()
+}
+
+func backendID(routerName, name string) string {
+	return routerName + "/" + name
+}
@@ -514,6 +514,35 @@ func (c *GalebClient) FindTargetsByParent(poolName string) ([]Target, error) {
 	return rspObj.Embedded.Targets, nil
 }
 
+func (c *GalebClient) FindPoolsByNamePrefix(prefix string) ([]Pool, error) {
+	path := fmt.Sprintf("/pool/search/findByNameContaining?name=%s&size=999999", prefix)
+	rsp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	responseData, _ := ioutil.ReadAll(rsp.Body)
+	if rsp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("GET /pool/search/findByNameContaining?name={name}: wrong status code: %d. content: %s", rsp.StatusCode, string(responseData))
+	}
+	var rspObj struct {
+		Embedded struct {
+			Pools []Pool `json:"pool"`
+		} `json:"_embedded"`
+	}
+	err = json.Unmarshal(responseData, &rspObj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET /pool/search/findByNameContaining?name={name}: unable to parse: %s", string(responseData))
+	}
+	var pools []Pool
+	for _, pool := range rspObj.Embedded.Pools {
+		if strings.HasPrefix(pool.Name, prefix) {
+			pools = append(pools, pool)
+		}
+	}
+	return pools, nil
+}
+
 func (c *GalebClient) FindVirtualHostsByRule(ruleName string) ([]VirtualHost, error) {
 	ruleID, err := c.findItemByName("rule", ruleName)
 	if err != nil {
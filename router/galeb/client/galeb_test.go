@@ -749,6 +749,49 @@ func (s *S) TestFindTargetsByParent(c *check.C) {
 	c.Assert(s.handler.Header[0].Get("Content-Type"), check.Equals, "application/json")
 }
 
+func (s *S) TestFindPoolsByNamePrefix(c *check.C) {
+	s.handler.ConditionalContent["/api/pool/search/findByNameContaining?name=mypool-&size=999999"] = []string{
+		"200", `{
+		"_embedded": {
+			"pool": [
+				{
+					"name": "mypool-a",
+					"_links": {
+						"self": {
+							"href": "http://galeb.somewhere/api/pool/9"
+						}
+					}
+				},
+				{
+					"name": "other-mypool-b",
+					"_links": {
+						"self": {
+							"href": "http://galeb.somewhere/api/pool/10"
+						}
+					}
+				}
+			]
+		}
+	}`}
+	s.handler.RspCode = http.StatusOK
+	pools, err := s.client.FindPoolsByNamePrefix("mypool-")
+	c.Assert(err, check.IsNil)
+	c.Assert(pools, check.DeepEquals, []Pool{
+		{
+			commonPostResponse: commonPostResponse{
+				Name: "mypool-a",
+				Links: linkData{
+					Self: hrefData{Href: "http://galeb.somewhere/api/pool/9"},
+				},
+			},
+		},
+	})
+	c.Assert(s.handler.Method, check.DeepEquals, []string{"GET"})
+	c.Assert(s.handler.URL, check.DeepEquals, []string{
+		"/api/pool/search/findByNameContaining?name=mypool-&size=999999",
+	})
+}
+
 func (s *S) TestFindVirtualHostsByRule(c *check.C) {
 	s.handler.ConditionalContent["/api/rule/search/findByName?name=myrule"] = []string{
 		"200", fmt.Sprintf(`{
@@ -7,6 +7,8 @@ package galeb
 import (
 	"fmt"
 	"net/url"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,6 +23,8 @@ import (
 
 var _ router.AsyncRouter = &galebRouter{}
 
+var _ router.BackendLister = &galebRouter{}
+
 const routerType = "galeb"
 
 var clientCache struct {
@@ -463,6 +467,25 @@ func (r *galebRouter) RemoveBackend(name string) (err error) {
 	return r.client.RemoveBackendPool(r.poolName(backendName))
 }
 
+// Backends returns the names of the backends in the router, taken from the
+// names of the backend pools created by it.
+func (r *galebRouter) Backends() (names []string, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	prefix := r.poolName("")
+	pools, err := r.client.FindPoolsByNamePrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range pools {
+		names = append(names, strings.TrimPrefix(pool.Name, prefix))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 func (r *galebRouter) forceCleanupBackend(backendName string) error {
 	rule := r.ruleName(backendName)
 	multiErr := tsuruErrors.NewMultiError()
@@ -22,6 +22,7 @@ import (
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/db"
 	"github.com/tsuru/tsuru/db/dbtest"
+	"github.com/tsuru/tsuru/router"
 	galebClient "github.com/tsuru/tsuru/router/galeb/client"
 	"github.com/tsuru/tsuru/router/routertest"
 	"gopkg.in/check.v1"
@@ -73,6 +74,7 @@ func NewFakeGalebServer() (*fakeGalebServer, error) {
 	r.HandleFunc("/api/rule/{id}/parents", server.findVirtualhostByRule).Methods("GET")
 	r.HandleFunc("/api/rule/{id}/parents/{vhid}", server.destroyRuleVirtualhost).Methods("DELETE")
 	r.HandleFunc("/api/target/search/findByParentName", server.findTargetsByParent).Methods("GET")
+	r.HandleFunc("/api/pool/search/findByNameContaining", server.findPoolsByNameContaining).Methods("GET")
 	server.router = r
 	return server, nil
 }
@@ -161,6 +163,17 @@ func (s *fakeGalebServer) findTargetsByParent(w http.ResponseWriter, r *http.Req
 	json.NewEncoder(w).Encode(makeSearchRsp("target", ret...))
 }
 
+func (s *fakeGalebServer) findPoolsByNameContaining(w http.ResponseWriter, r *http.Request) {
+	wantedName := r.URL.Query().Get("name")
+	var ret []interface{}
+	for i, item := range s.pools {
+		if strings.Contains(item.(*galebClient.Pool).Name, wantedName) {
+			ret = append(ret, s.pools[i])
+		}
+	}
+	json.NewEncoder(w).Encode(makeSearchRsp("pool", ret...))
+}
+
 func (s *fakeGalebServer) destroyItem(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	item := mux.Vars(r)["item"]
@@ -471,6 +484,23 @@ func (s *S) TestAddBackendPartialFailure(c *check.C) {
 	c.Check(fakeServer.ruleVh, check.DeepEquals, map[string][]string{})
 }
 
+func (s *S) TestBackends(c *check.C) {
+	fakeServer, err := NewFakeGalebServer()
+	c.Assert(err, check.IsNil)
+	server := httptest.NewServer(fakeServer)
+	defer server.Close()
+	config.Set("routers:galeb:api-url", server.URL+"/api")
+	gRouter, err := createRouter("galeb", "routers:galeb")
+	c.Assert(err, check.IsNil)
+	err = gRouter.AddBackend(routertest.FakeApp{Name: "backend2"})
+	c.Assert(err, check.IsNil)
+	err = gRouter.AddBackend(routertest.FakeApp{Name: "backend1"})
+	c.Assert(err, check.IsNil)
+	backends, err := gRouter.(router.BackendLister).Backends()
+	c.Assert(err, check.IsNil)
+	c.Assert(backends, check.DeepEquals, []string{"backend1", "backend2"})
+}
+
 func (s *S) TestAddBackendPartialFailureExisting(c *check.C) {
 	fakeServer, err := NewFakeGalebServer()
 	c.Assert(err, check.IsNil)
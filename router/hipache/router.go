@@ -16,7 +16,9 @@ package hipache
 import (
 	"fmt"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -82,6 +84,8 @@ func (r *hipacheRouter) connect() (tsuruRedis.Client, error) {
 	return client, nil
 }
 
+var _ router.BackendLister = &hipacheRouter{}
+
 type hipacheRouter struct {
 	routerName string
 	prefix     string
@@ -173,6 +177,33 @@ func (r *hipacheRouter) RemoveBackend(name string) (err error) {
 	return nil
 }
 
+// Backends returns the names of the backends in the router. Frontends of
+// cnames are not listed, as cnames can't use the domain of the router.
+func (r *hipacheRouter) Backends() (names []string, err error) {
+	done := router.InstrumentRequest(r.routerName)
+	defer func() {
+		done(err)
+	}()
+	domain, err := config.GetString(r.prefix + ":domain")
+	if err != nil {
+		return nil, &router.RouterError{Op: "backends", Err: err}
+	}
+	conn, err := r.connect()
+	if err != nil {
+		return nil, &router.RouterError{Op: "backends", Err: err}
+	}
+	suffix := "." + domain
+	keys, err := conn.Keys("frontend:*" + suffix).Result()
+	if err != nil {
+		return nil, &router.RouterError{Op: "backends", Err: err}
+	}
+	for _, key := range keys {
+		names = append(names, strings.TrimSuffix(strings.TrimPrefix(key, "frontend:"), suffix))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 func (r *hipacheRouter) AddRoutes(name string, addresses []*url.URL) (err error) {
 	done := router.InstrumentRequest(r.routerName)
 	defer func() {
@@ -228,6 +228,19 @@ func (s *S) TestRemoveBackend(c *check.C) {
 	c.Assert(int64(0), check.Equals, healthchecks)
 }
 
+func (s *S) TestBackends(c *check.C) {
+	r := hipacheRouter{prefix: "hipache"}
+	err := r.AddBackend(routertest.FakeApp{Name: "tip"})
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(routertest.FakeApp{Name: "tap"})
+	c.Assert(err, check.IsNil)
+	err = r.SetCName("mycname.example.com", "tip")
+	c.Assert(err, check.IsNil)
+	backends, err := r.Backends()
+	c.Assert(err, check.IsNil)
+	c.Assert(backends, check.DeepEquals, []string{"tap", "tip"})
+}
+
 func (s *S) TestRemoveBackendAlsoRemovesRelatedCNameBackendAndControlRecord(c *check.C) {
 	router := hipacheRouter{prefix: "hipache"}
 	err := router.AddBackend(routertest.FakeApp{Name: "tip"})
@@ -0,0 +1,383 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rebuild
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/api/shutdown"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/router"
+)
+
+var (
+	reconcileRoutesDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tsuru_router_reconcile_routes_drift",
+		Help: "The number of routes out of sync found in the last reconciliation, by kind (missing or extra).",
+	}, []string{"router", "kind"})
+
+	reconcileDriftedApps = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tsuru_router_reconcile_drifted_apps",
+		Help: "The number of apps with routes out of sync found in the last reconciliation.",
+	}, []string{"router"})
+
+	reconcileOrphanedBackends = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tsuru_router_reconcile_orphaned_backends",
+		Help: "The number of backends without a matching app found in the last reconciliation.",
+	}, []string{"router"})
+
+	reconcileRepairs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tsuru_router_reconcile_repairs_total",
+		Help: "The total number of repairs made by the routes reconciler.",
+	}, []string{"router"})
+
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tsuru_router_reconcile_errors_total",
+		Help: "The total number of errors in the routes reconciler.",
+	}, []string{"router"})
+
+	reconcileDuration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tsuru_router_reconcile_last_duration",
+		Help: "The duration of the last routes reconciliation.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(reconcileRoutesDrift, reconcileDriftedApps, reconcileOrphanedBackends,
+		reconcileRepairs, reconcileErrors, reconcileDuration)
+}
+
+// ReconcileOptions controls how drift found by Reconcile is repaired.
+type ReconcileOptions struct {
+	// Dry only reports the drift, without repairing it.
+	Dry bool
+	// RemoveOrphans removes backends without a matching app from routers
+	// able to list their backends.
+	RemoveOrphans bool
+	// RateLimit is the maximum number of repairs per minute. Zero means
+	// unlimited.
+	RateLimit int
+}
+
+// ReconcileResult is the drift found in a router.
+type ReconcileResult struct {
+	DriftedApps      []string
+	MissingRoutes    int
+	ExtraRoutes      int
+	OrphanedBackends []string
+	Repaired         []string
+}
+
+// Reconcile walks every router and every app in them comparing the
+// addresses of the app units with the routes in the router, and rebuilding
+// the routes of apps out of sync. It also looks for backends without a
+// matching app in routers implementing router.BackendLister. The result is
+// keyed by router name and also exported as prometheus metrics.
+func Reconcile(ctx context.Context, apps []RebuildApp, opts ReconcileOptions) (map[string]*ReconcileResult, error) {
+	routers, err := router.List()
+	if err != nil {
+		return nil, err
+	}
+	appsByRouter := make(map[string][]RebuildApp)
+	for _, a := range apps {
+		for _, appRouter := range a.GetRouters() {
+			appsByRouter[appRouter.Name] = append(appsByRouter[appRouter.Name], a)
+		}
+	}
+	wait := rateLimiter(ctx, opts.RateLimit)
+	results := make(map[string]*ReconcileResult)
+	for _, planRouter := range routers {
+		if ctx.Err() != nil {
+			break
+		}
+		r, err := router.Get(planRouter.Name)
+		if err != nil {
+			log.Errorf("[routes-reconciler] unable to get router %q: %s", planRouter.Name, err)
+			reconcileErrors.WithLabelValues(planRouter.Name).Inc()
+			continue
+		}
+		result := reconcileRouter(ctx, r, appsByRouter[planRouter.Name], opts, wait)
+		results[planRouter.Name] = result
+		reconcileRoutesDrift.WithLabelValues(planRouter.Name, "missing").Set(float64(result.MissingRoutes))
+		reconcileRoutesDrift.WithLabelValues(planRouter.Name, "extra").Set(float64(result.ExtraRoutes))
+		reconcileDriftedApps.WithLabelValues(planRouter.Name).Set(float64(len(result.DriftedApps)))
+		reconcileOrphanedBackends.WithLabelValues(planRouter.Name).Set(float64(len(result.OrphanedBackends)))
+	}
+	return results, ctx.Err()
+}
+
+func reconcileRouter(ctx context.Context, r router.Router, apps []RebuildApp, opts ReconcileOptions, wait func() bool) *ReconcileResult {
+	routerName := r.GetName()
+	result := &ReconcileResult{}
+	known := make(map[string]bool)
+	for _, a := range apps {
+		known[a.GetName()] = true
+		// the canary backend of an app exists from the start of a canary
+		// deploy, before any traffic is sent to it, until its cleanup.
+		known[router.CanaryBackendName(a.GetName())] = true
+		if weightedRouter, ok := r.(router.WeightedRouter); ok {
+			if target, weight, err := weightedRouter.Weight(a.GetName()); err == nil && weight > 0 {
+				known[target] = true
+			}
+		}
+		missing, extra, backendMissing, err := routesDrift(r, a)
+		if err != nil {
+			log.Errorf("[routes-reconciler] unable to check routes for app %q in router %q: %s", a.GetName(), routerName, err)
+			reconcileErrors.WithLabelValues(routerName).Inc()
+			continue
+		}
+		if !backendMissing && missing+extra == 0 {
+			continue
+		}
+		result.DriftedApps = append(result.DriftedApps, a.GetName())
+		result.MissingRoutes += missing
+		result.ExtraRoutes += extra
+		log.Debugf("[routes-reconciler] app %q has %d missing and %d extra routes in router %q", a.GetName(), missing, extra, routerName)
+		if opts.Dry || !wait() {
+			continue
+		}
+		err = repairApp(a, routerName)
+		if err != nil {
+			log.Errorf("[routes-reconciler] unable to repair routes for app %q in router %q: %s", a.GetName(), routerName, err)
+			reconcileErrors.WithLabelValues(routerName).Inc()
+			continue
+		}
+		result.Repaired = append(result.Repaired, a.GetName())
+		reconcileRepairs.WithLabelValues(routerName).Inc()
+	}
+	lister, ok := r.(router.BackendLister)
+	if !ok || ctx.Err() != nil {
+		return result
+	}
+	backends, err := lister.Backends()
+	if err != nil {
+		log.Errorf("[routes-reconciler] unable to list backends in router %q: %s", routerName, err)
+		reconcileErrors.WithLabelValues(routerName).Inc()
+		return result
+	}
+	for _, backend := range backends {
+		if known[backend] {
+			continue
+		}
+		remove := !opts.Dry && opts.RemoveOrphans && wait()
+		// the app may have been created after the apps were listed, so it's
+		// looked up again right before its backend is removed.
+		exists, err := backendAppExists(backend)
+		if err != nil {
+			log.Errorf("[routes-reconciler] unable to check app for backend %q in router %q: %s", backend, routerName, err)
+			reconcileErrors.WithLabelValues(routerName).Inc()
+			continue
+		}
+		if exists {
+			continue
+		}
+		result.OrphanedBackends = append(result.OrphanedBackends, backend)
+		if !remove {
+			continue
+		}
+		err = r.RemoveBackend(backend)
+		if err != nil {
+			log.Errorf("[routes-reconciler] unable to remove orphaned backend %q from router %q: %s", backend, routerName, err)
+			reconcileErrors.WithLabelValues(routerName).Inc()
+			continue
+		}
+		result.Repaired = append(result.Repaired, backend)
+		reconcileRepairs.WithLabelValues(routerName).Inc()
+	}
+	sort.Strings(result.OrphanedBackends)
+	return result
+}
+
+func appExists(name string) (bool, error) {
+	if appFinder == nil {
+		return false, errors.New("app finder not registered")
+	}
+	a, err := appFinder(name)
+	if err != nil {
+		return false, err
+	}
+	return a != nil, nil
+}
+
+// backendAppExists returns whether the backend belongs to an app, either as
+// the backend of the app or as its canary backend.
+func backendAppExists(backend string) (bool, error) {
+	exists, err := appExists(backend)
+	if err != nil || exists {
+		return exists, err
+	}
+	appName := strings.TrimSuffix(backend, router.CanaryBackendName(""))
+	if appName == backend || appName == "" {
+		return false, nil
+	}
+	return appExists(appName)
+}
+
+func routesDrift(r router.Router, a RebuildApp) (missing, extra int, backendMissing bool, err error) {
+	addresses, err := a.RoutableAddresses()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	routes, err := r.Routes(a.GetName())
+	if err == router.ErrBackendNotFound {
+		return len(addresses), 0, true, nil
+	}
+	if err != nil {
+		return 0, 0, false, err
+	}
+	toAdd, toRemove := diffRoutes(routes, addresses)
+	return len(toAdd), len(toRemove), false, nil
+}
+
+func repairApp(a RebuildApp, routerName string) error {
+	locked, err := a.InternalLock("routes-reconciler")
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return errors.Errorf("app %q is locked", a.GetName())
+	}
+	defer a.Unlock()
+	for _, appRouter := range a.GetRouters() {
+		if appRouter.Name == routerName {
+			_, err = rebuildRoutesInRouter(a, false, appRouter, true)
+			return err
+		}
+	}
+	return nil
+}
+
+// rateLimiter returns a function blocking until the next repair is allowed.
+// It returns false if ctx is done while waiting.
+func rateLimiter(ctx context.Context, perMinute int) func() bool {
+	if perMinute <= 0 {
+		return func() bool {
+			return ctx.Err() == nil
+		}
+	}
+	interval := time.Minute / time.Duration(perMinute)
+	var last time.Time
+	return func() bool {
+		if !last.IsZero() {
+			select {
+			case <-time.After(time.Until(last.Add(interval))):
+			case <-ctx.Done():
+				return false
+			}
+		}
+		last = time.Now()
+		return ctx.Err() == nil
+	}
+}
+
+// InitializeReconciler starts the background routes reconciler, running
+// Reconcile on the apps returned by appLister every
+// routes-reconciler:interval.
+func InitializeReconciler(appLister func() ([]RebuildApp, error)) error {
+	disabled, _ := config.GetBool("routes-reconciler:disabled")
+	if disabled {
+		return nil
+	}
+	interval, _ := config.GetDuration("routes-reconciler:interval")
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	var opts ReconcileOptions
+	opts.Dry, _ = config.GetBool("routes-reconciler:dry")
+	opts.RemoveOrphans, _ = config.GetBool("routes-reconciler:remove-orphans")
+	opts.RateLimit, _ = config.GetInt("routes-reconciler:rate-limit")
+	if opts.RateLimit == 0 {
+		opts.RateLimit = 60
+	}
+	reconciler := &routesReconciler{
+		interval:  interval,
+		appLister: appLister,
+		opts:      opts,
+	}
+	err := reconciler.start()
+	if err != nil {
+		return err
+	}
+	shutdown.Register(reconciler)
+	return nil
+}
+
+type routesReconciler struct {
+	interval  time.Duration
+	appLister func() ([]RebuildApp, error)
+	opts      ReconcileOptions
+
+	started bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func (r *routesReconciler) start() error {
+	if r.started {
+		return errors.New("reconciler already started")
+	}
+	if r.appLister == nil {
+		return errors.New("must set app lister function")
+	}
+	var ctx context.Context
+	ctx, r.cancel = context.WithCancel(context.Background())
+	r.done = make(chan struct{})
+	r.started = true
+	log.Debugf("[routes-reconciler] starting. Running every %s.\n", r.interval)
+	go func() {
+		defer close(r.done)
+		for {
+			select {
+			case <-time.After(r.interval):
+				r.run(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *routesReconciler) run(ctx context.Context) {
+	start := time.Now()
+	defer func() {
+		reconcileDuration.Set(time.Since(start).Seconds())
+	}()
+	log.Debug("[routes-reconciler] starting run")
+	apps, err := r.appLister()
+	if err != nil {
+		log.Errorf("[routes-reconciler] error listing apps: %v. Aborting reconciliation.", err)
+		return
+	}
+	results, err := Reconcile(ctx, apps, r.opts)
+	if err != nil {
+		log.Errorf("[routes-reconciler] error reconciling routes: %v", err)
+		return
+	}
+	for routerName, result := range results {
+		log.Debugf("[routes-reconciler] router %q: %d drifted apps, %d orphaned backends, %d repairs",
+			routerName, len(result.DriftedApps), len(result.OrphanedBackends), len(result.Repaired))
+	}
+}
+
+// Shutdown stops the reconciler, interrupting the current run.
+func (r *routesReconciler) Shutdown(ctx context.Context) error {
+	if !r.started {
+		return nil
+	}
+	r.cancel()
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+	}
+	r.started = false
+	return ctx.Err()
+}
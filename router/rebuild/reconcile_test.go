@@ -0,0 +1,131 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rebuild_test
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	"github.com/tsuru/tsuru/router/rebuild"
+	"github.com/tsuru/tsuru/router/routertest"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) createDriftedApp(c *check.C) (*app.App, []*url.URL) {
+	a := &app.App{Name: "my-test-app", TeamOwner: s.team.Name}
+	err := app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	err = provisiontest.ProvisionerInstance.AddUnits(a, 3, "web", nil)
+	c.Assert(err, check.IsNil)
+	units, err := a.Units()
+	c.Assert(err, check.IsNil)
+	addrs := []*url.URL{units[0].Address, units[1].Address, units[2].Address}
+	routertest.FakeRouter.RemoveRoutes(a.Name, []*url.URL{units[2].Address})
+	routertest.FakeRouter.AddRoutes(a.Name, []*url.URL{{Scheme: "http", Host: "invalid:1234"}})
+	return a, addrs
+}
+
+func (s *S) TestReconcile(c *check.C) {
+	a, addrs := s.createDriftedApp(c)
+	results, err := rebuild.Reconcile(context.Background(), []rebuild.RebuildApp{a}, rebuild.ReconcileOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(results["fake"], check.DeepEquals, &rebuild.ReconcileResult{
+		DriftedApps:   []string{a.Name},
+		MissingRoutes: 1,
+		ExtraRoutes:   1,
+		Repaired:      []string{a.Name},
+	})
+	c.Assert(results["fake-hc"], check.DeepEquals, &rebuild.ReconcileResult{})
+	routes, err := routertest.FakeRouter.Routes(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.HasLen, 3)
+	for _, addr := range addrs {
+		c.Assert(routertest.FakeRouter.HasRoute(a.Name, addr.String()), check.Equals, true)
+	}
+}
+
+func (s *S) TestReconcileDry(c *check.C) {
+	a, addrs := s.createDriftedApp(c)
+	results, err := rebuild.Reconcile(context.Background(), []rebuild.RebuildApp{a}, rebuild.ReconcileOptions{Dry: true})
+	c.Assert(err, check.IsNil)
+	c.Assert(results["fake"], check.DeepEquals, &rebuild.ReconcileResult{
+		DriftedApps:   []string{a.Name},
+		MissingRoutes: 1,
+		ExtraRoutes:   1,
+	})
+	c.Assert(routertest.FakeRouter.HasRoute(a.Name, "invalid:1234"), check.Equals, true)
+	c.Assert(routertest.FakeRouter.HasRoute(a.Name, addrs[2].String()), check.Equals, false)
+}
+
+func (s *S) TestReconcileLockedApp(c *check.C) {
+	a, _ := s.createDriftedApp(c)
+	locked, err := app.AcquireApplicationLock(a.Name, "me", "deploy")
+	c.Assert(err, check.IsNil)
+	c.Assert(locked, check.Equals, true)
+	defer app.ReleaseApplicationLock(a.Name)
+	results, err := rebuild.Reconcile(context.Background(), []rebuild.RebuildApp{a}, rebuild.ReconcileOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(results["fake"].DriftedApps, check.DeepEquals, []string{a.Name})
+	c.Assert(results["fake"].Repaired, check.IsNil)
+	c.Assert(routertest.FakeRouter.HasRoute(a.Name, "invalid:1234"), check.Equals, true)
+}
+
+func (s *S) TestReconcileOrphanedBackends(c *check.C) {
+	a := &app.App{Name: "my-test-app", TeamOwner: s.team.Name}
+	err := app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	err = routertest.FakeRouter.AddBackend(routertest.FakeApp{Name: "orphan"})
+	c.Assert(err, check.IsNil)
+	results, err := rebuild.Reconcile(context.Background(), []rebuild.RebuildApp{a}, rebuild.ReconcileOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(results["fake"], check.DeepEquals, &rebuild.ReconcileResult{
+		OrphanedBackends: []string{"orphan"},
+	})
+	c.Assert(routertest.FakeRouter.HasBackend("orphan"), check.Equals, true)
+	results, err = rebuild.Reconcile(context.Background(), []rebuild.RebuildApp{a}, rebuild.ReconcileOptions{RemoveOrphans: true})
+	c.Assert(err, check.IsNil)
+	c.Assert(results["fake"], check.DeepEquals, &rebuild.ReconcileResult{
+		OrphanedBackends: []string{"orphan"},
+		Repaired:         []string{"orphan"},
+	})
+	c.Assert(routertest.FakeRouter.HasBackend("orphan"), check.Equals, false)
+	c.Assert(routertest.FakeRouter.HasBackend(a.Name), check.Equals, true)
+}
+
+func (s *S) TestReconcileOrphanedBackendsKeepsCanaryBackends(c *check.C) {
+	a := &app.App{Name: "my-test-app", TeamOwner: s.team.Name}
+	err := app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	other := &app.App{Name: "other-app", TeamOwner: s.team.Name}
+	err = app.CreateApp(other, s.user)
+	c.Assert(err, check.IsNil)
+	err = routertest.FakeRouter.AddBackend(routertest.FakeApp{Name: "my-test-app-canary"})
+	c.Assert(err, check.IsNil)
+	err = routertest.FakeRouter.AddBackend(routertest.FakeApp{Name: "other-app-canary"})
+	c.Assert(err, check.IsNil)
+	err = routertest.FakeRouter.AddBackend(routertest.FakeApp{Name: "gone-canary"})
+	c.Assert(err, check.IsNil)
+	results, err := rebuild.Reconcile(context.Background(), []rebuild.RebuildApp{a}, rebuild.ReconcileOptions{RemoveOrphans: true})
+	c.Assert(err, check.IsNil)
+	c.Assert(results["fake"], check.DeepEquals, &rebuild.ReconcileResult{
+		OrphanedBackends: []string{"gone-canary"},
+		Repaired:         []string{"gone-canary"},
+	})
+	c.Assert(routertest.FakeRouter.HasBackend("my-test-app-canary"), check.Equals, true)
+	c.Assert(routertest.FakeRouter.HasBackend("other-app-canary"), check.Equals, true)
+	c.Assert(routertest.FakeRouter.HasBackend("gone-canary"), check.Equals, false)
+}
+
+func (s *S) TestReconcileOrphanedBackendsKeepsAppsCreatedAfterListing(c *check.C) {
+	a := &app.App{Name: "my-test-app", TeamOwner: s.team.Name}
+	err := app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	results, err := rebuild.Reconcile(context.Background(), nil, rebuild.ReconcileOptions{RemoveOrphans: true})
+	c.Assert(err, check.IsNil)
+	c.Assert(results["fake"], check.DeepEquals, &rebuild.ReconcileResult{})
+	c.Assert(routertest.FakeRouter.HasBackend(a.Name), check.Equals, true)
+}
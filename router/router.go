@@ -33,6 +33,9 @@ var (
 	ErrCNameNotAllowed       = errors.New("CName as router subdomain not allowed")
 	ErrCertificateNotFound   = errors.New("Certificate not found")
 	ErrDefaultRouterNotFound = errors.New("No default router found")
+	ErrInvalidWeight         = errors.New("Weight must be between 0 and 100")
+	ErrPathRouteExists       = errors.New("Path route already exists")
+	ErrPathRouteNotFound     = errors.New("Path route not found")
 )
 
 type ErrRouterNotFound struct {
@@ -190,6 +193,62 @@ type StatusRouter interface {
 	GetBackendStatus(name string) (status BackendStatus, detail string, err error)
 }
 
+// StatsRouter is a router able to report the traffic received by a backend.
+// ErrorRate returns the percentage of the requests answered with a server
+// error.
+type StatsRouter interface {
+	RequestsPerSecond(name string) (float64, error)
+	ErrorRate(name string) (float64, error)
+}
+
+// WeightedRouter is a router able to split the traffic of a backend, sending
+// a percentage of the requests to a second backend. Setting the weight to 0
+// removes the split, routing all requests back to the original backend.
+type WeightedRouter interface {
+	SetWeight(name, target string, weight int) error
+	Weight(name string) (target string, weight int, err error)
+}
+
+// CanaryBackendName returns the name of the backend receiving the canary
+// traffic of an app during a canary deploy.
+func CanaryBackendName(appName string) string {
+	return appName + "-canary"
+}
+
+// BackendLister is a router able to list the names of all its backends,
+// allowing backends without a matching app to be found.
+type BackendLister interface {
+	Backends() ([]string, error)
+}
+
+// ACMEChallengeRouter is a router able to answer ACME http-01 challenges
+// itself, serving keyAuth at /.well-known/acme-challenge/<token> on every
+// address of the backend.
+type ACMEChallengeRouter interface {
+	AddACMEChallenge(name, token, keyAuth string) error
+	RemoveACMEChallenge(name, token string) error
+}
+
+// PathRoute is a path prefix of a hostname routed to a backend.
+type PathRoute struct {
+	Host string `json:"host"`
+	Path string `json:"path"`
+}
+
+// PathRouter is a router able to route requests for a path prefix of a
+// hostname to a backend, allowing multiple backends to share the hostname.
+// When more than one prefix matches a request, the longest one is used.
+type PathRouter interface {
+	AddPathRoute(name, host, path string) error
+	RemovePathRoute(name, host, path string) error
+	PathRoutes(name string) ([]PathRoute, error)
+}
+
+// ValidWeight returns true if weight is a valid percentage of traffic.
+func ValidWeight(weight int) bool {
+	return weight >= 0 && weight <= 100
+}
+
 type HealthcheckData struct {
 	Path   string
 	Status int
@@ -825,3 +825,28 @@ func (s *RouterSuite) TestCNamesAsync(c *check.C) {
 	err = s.Router.RemoveBackend(testBackend1)
 	c.Assert(err, check.IsNil)
 }
+
+func (s *RouterSuite) TestPathRoutes(c *check.C) {
+	pathRouter, ok := s.Router.(router.PathRouter)
+	if !ok {
+		c.Skip(fmt.Sprintf("%T does not implement PathRouter", s.Router))
+	}
+	err := s.Router.AddBackend(FakeApp{Name: testBackend1})
+	c.Assert(err, check.IsNil)
+	err = pathRouter.AddPathRoute(testBackend1, "api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	err = pathRouter.AddPathRoute(testBackend1, "api.example.com", "/orders")
+	c.Assert(err, check.Equals, router.ErrPathRouteExists)
+	routes, err := pathRouter.PathRoutes(testBackend1)
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.DeepEquals, []router.PathRoute{{Host: "api.example.com", Path: "/orders"}})
+	err = pathRouter.RemovePathRoute(testBackend1, "api.example.com", "/orders")
+	c.Assert(err, check.IsNil)
+	err = pathRouter.RemovePathRoute(testBackend1, "api.example.com", "/orders")
+	c.Assert(err, check.Equals, router.ErrPathRouteNotFound)
+	routes, err = pathRouter.PathRoutes(testBackend1)
+	c.Assert(err, check.IsNil)
+	c.Assert(routes, check.HasLen, 0)
+	err = s.Router.RemoveBackend(testBackend1)
+	c.Assert(err, check.IsNil)
+}
@@ -7,6 +7,7 @@ package routertest
 import (
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 
@@ -37,6 +38,23 @@ var TLSRouter = tlsRouter{
 	fakeRouter: newFakeRouter(),
 	Certs:      make(map[string]string),
 	Keys:       make(map[string]string),
+	Challenges: make(map[string]string),
+}
+
+var WeightedRouter = weightedRouter{
+	fakeRouter: newFakeRouter(),
+	Weights:    make(map[string]Weight),
+}
+
+var StatsRouter = statsRouter{
+	fakeRouter: newFakeRouter(),
+	Requests:   make(map[string]float64),
+	Errors:     make(map[string]float64),
+}
+
+var PathRouter = pathRouter{
+	fakeRouter: newFakeRouter(),
+	Paths:      make(map[router.PathRoute]string),
 }
 
 var ErrForcedFailure = errors.New("Forced failure")
@@ -48,6 +66,9 @@ func init() {
 	router.Register("fake-opts", createOptsRouter)
 	router.Register("fake-info", createInfoRouter)
 	router.Register("fake-status", createStatusRouter)
+	router.Register("fake-weighted", createWeightedRouter)
+	router.Register("fake-stats", createStatsRouter)
+	router.Register("fake-path", createPathRouter)
 }
 
 func createRouter(name, prefix string) (router.Router, error) {
@@ -74,6 +95,18 @@ func createStatusRouter(name, prefix string) (router.Router, error) {
 	return &StatusRouter, nil
 }
 
+func createWeightedRouter(name, prefix string) (router.Router, error) {
+	return &WeightedRouter, nil
+}
+
+func createStatsRouter(name, prefix string) (router.Router, error) {
+	return &StatsRouter, nil
+}
+
+func createPathRouter(name, prefix string) (router.Router, error) {
+	return &PathRouter, nil
+}
+
 func newFakeRouter() fakeRouter {
 	return fakeRouter{cnames: make(map[string]string), backends: make(map[string][]string), failuresByIp: make(map[string]bool), healthcheck: make(map[string]router.HealthcheckData), mutex: &sync.Mutex{}}
 }
@@ -87,7 +120,10 @@ type fakeRouter struct {
 	mutex        *sync.Mutex
 }
 
-var _ router.Router = &fakeRouter{}
+var (
+	_ router.Router        = &fakeRouter{}
+	_ router.BackendLister = &fakeRouter{}
+)
 
 func (r *fakeRouter) GetName() string {
 	return "fake"
@@ -347,6 +383,17 @@ func (r *fakeRouter) Addr(name string) (string, error) {
 	return "", router.ErrBackendNotFound
 }
 
+func (r *fakeRouter) Backends() ([]string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	names := make([]string, 0, len(r.backends))
+	for name := range r.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 func (r *fakeRouter) Reset() {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
@@ -423,11 +470,15 @@ func (r *fakeRouter) RemoveHealthcheck(name string) error {
 
 type tlsRouter struct {
 	fakeRouter
-	Certs map[string]string
-	Keys  map[string]string
+	Certs      map[string]string
+	Keys       map[string]string
+	Challenges map[string]string
 }
 
-var _ router.TLSRouter = &tlsRouter{}
+var (
+	_ router.TLSRouter           = &tlsRouter{}
+	_ router.ACMEChallengeRouter = &tlsRouter{}
+)
 
 func (r *tlsRouter) AddCertificate(app router.App, cname, certificate, key string) error {
 	r.Certs[cname] = certificate
@@ -449,6 +500,20 @@ func (r *tlsRouter) GetCertificate(app router.App, cname string) (string, error)
 	return data, nil
 }
 
+func (r *tlsRouter) AddACMEChallenge(name, token, keyAuth string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.Challenges[token] = keyAuth
+	return nil
+}
+
+func (r *tlsRouter) RemoveACMEChallenge(name, token string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.Challenges, token)
+	return nil
+}
+
 func (r *tlsRouter) Addr(name string) (string, error) {
 	addr, err := r.fakeRouter.Addr(name)
 	if err != nil {
@@ -507,3 +572,198 @@ func (r *statusRouter) Reset() {
 	r.Status = router.BackendStatusReady
 	r.StatusDetail = ""
 }
+
+type Weight struct {
+	Target string
+	Weight int
+}
+
+type weightedRouter struct {
+	fakeRouter
+	Weights map[string]Weight
+	// History records every weight set for a backend, in order.
+	History map[string][]int
+}
+
+var _ router.WeightedRouter = &weightedRouter{}
+
+func (r *weightedRouter) SetWeight(name, target string, weight int) error {
+	if !router.ValidWeight(weight) {
+		return router.ErrInvalidWeight
+	}
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	if !r.HasBackend(backendName) {
+		return router.ErrBackendNotFound
+	}
+	if weight > 0 && !r.HasBackend(target) {
+		return router.ErrBackendNotFound
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.failuresByIp[target] {
+		return ErrForcedFailure
+	}
+	if r.History == nil {
+		r.History = make(map[string][]int)
+	}
+	r.History[backendName] = append(r.History[backendName], weight)
+	if weight == 0 {
+		delete(r.Weights, backendName)
+		return nil
+	}
+	r.Weights[backendName] = Weight{Target: target, Weight: weight}
+	return nil
+}
+
+func (r *weightedRouter) Weight(name string) (string, int, error) {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return "", 0, err
+	}
+	if !r.HasBackend(backendName) {
+		return "", 0, router.ErrBackendNotFound
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	w := r.Weights[backendName]
+	return w.Target, w.Weight, nil
+}
+
+func (r *weightedRouter) Reset() {
+	r.fakeRouter.Reset()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.Weights = make(map[string]Weight)
+	r.History = nil
+}
+
+type statsRouter struct {
+	fakeRouter
+	Requests map[string]float64
+	Errors   map[string]float64
+}
+
+var _ router.StatsRouter = &statsRouter{}
+
+func (r *statsRouter) RequestsPerSecond(name string) (float64, error) {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return 0, err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.Requests[backendName], nil
+}
+
+func (r *statsRouter) ErrorRate(name string) (float64, error) {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return 0, err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.Errors[backendName], nil
+}
+
+func (r *statsRouter) Reset() {
+	r.fakeRouter.Reset()
+	r.Requests = make(map[string]float64)
+	r.Errors = make(map[string]float64)
+}
+
+type pathRouter struct {
+	fakeRouter
+	Paths map[router.PathRoute]string
+}
+
+var _ router.PathRouter = &pathRouter{}
+
+func (r *pathRouter) AddPathRoute(name, host, path string) error {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	if !r.HasBackend(backendName) {
+		return router.ErrBackendNotFound
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	route := router.PathRoute{Host: host, Path: path}
+	if _, ok := r.Paths[route]; ok {
+		return router.ErrPathRouteExists
+	}
+	r.Paths[route] = backendName
+	return nil
+}
+
+func (r *pathRouter) RemovePathRoute(name, host, path string) error {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	if !r.HasBackend(backendName) {
+		return router.ErrBackendNotFound
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	route := router.PathRoute{Host: host, Path: path}
+	if r.Paths[route] != backendName {
+		return router.ErrPathRouteNotFound
+	}
+	delete(r.Paths, route)
+	return nil
+}
+
+func (r *pathRouter) PathRoutes(name string) ([]router.PathRoute, error) {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return nil, err
+	}
+	if !r.HasBackend(backendName) {
+		return nil, router.ErrBackendNotFound
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var routes []router.PathRoute
+	for route, backend := range r.Paths {
+		if backend == backendName {
+			routes = append(routes, route)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		return routes[i].Path < routes[j].Path
+	})
+	return routes, nil
+}
+
+func (r *pathRouter) RemoveBackend(name string) error {
+	backendName, err := router.Retrieve(name)
+	if err != nil {
+		return err
+	}
+	err = r.fakeRouter.RemoveBackend(name)
+	if err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for route, backend := range r.Paths {
+		if backend == backendName {
+			delete(r.Paths, route)
+		}
+	}
+	return nil
+}
+
+func (r *pathRouter) Reset() {
+	r.fakeRouter.Reset()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.Paths = make(map[router.PathRoute]string)
+}
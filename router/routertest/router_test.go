@@ -311,3 +311,35 @@ func (s *S) TestUpdateBackendOpts(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(r.Opts["myapp"], check.DeepEquals, map[string]string{"opt1": "val1"})
 }
+
+func (s *S) TestSetWeight(c *check.C) {
+	r := &WeightedRouter
+	defer r.Reset()
+	err := r.AddBackend(FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = r.AddBackend(FakeApp{Name: "myapp-canary"})
+	c.Assert(err, check.IsNil)
+	err = r.SetWeight("myapp", "myapp-canary", 20)
+	c.Assert(err, check.IsNil)
+	target, weight, err := r.Weight("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(target, check.Equals, "myapp-canary")
+	c.Assert(weight, check.Equals, 20)
+	err = r.SetWeight("myapp", "myapp-canary", 0)
+	c.Assert(err, check.IsNil)
+	_, weight, err = r.Weight("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(weight, check.Equals, 0)
+	c.Assert(r.History["myapp"], check.DeepEquals, []int{20, 0})
+}
+
+func (s *S) TestSetWeightInvalid(c *check.C) {
+	r := &WeightedRouter
+	defer r.Reset()
+	err := r.AddBackend(FakeApp{Name: "myapp"})
+	c.Assert(err, check.IsNil)
+	err = r.SetWeight("myapp", "myapp-canary", 150)
+	c.Assert(err, check.Equals, router.ErrInvalidWeight)
+	err = r.SetWeight("myapp", "myapp-canary", 10)
+	c.Assert(err, check.Equals, router.ErrBackendNotFound)
+}